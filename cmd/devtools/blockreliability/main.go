@@ -99,7 +99,8 @@ func main() {
 	}, natsConn)
 	utxoQueue := utxoQueueManager.NewMessageQueue("dispatch")
 
-	emitter := events.NewEmitter(eventQueue, utxoQueue, services.Nats.SubjectPrefix)
+	emitter := events.NewEmitter(eventQueue, utxoQueue, services.Nats.SubjectPrefix,
+		events.WithEncodings(services.Nats.Encoding))
 	defer emitter.Close()
 
 	// Address bloom filter (optional)
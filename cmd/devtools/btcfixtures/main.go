@@ -0,0 +1,177 @@
+// Command btcfixtures records Bitcoin Core JSON-RPC responses into a fixture
+// directory served by bitcointest.Server.
+//
+// Only response results are written; node URLs and credentials never end up
+// in the fixtures.
+//
+//	go run ./cmd/devtools/btcfixtures -url http://localhost:8332 \
+//	    -auth-key Authorization -auth-value "Basic ..." \
+//	    -heights 0,840000 -out internal/indexer/testdata/bitcoin/mycase
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+func main() {
+	var (
+		url        string
+		authKey    string
+		authValue  string
+		heightsArg string
+		txidsArg   string
+		outDir     string
+		verbosity  int
+		withTxs    bool
+	)
+
+	flag.StringVar(&url, "url", "", "Bitcoin Core RPC URL (required)")
+	flag.StringVar(&authKey, "auth-key", "", "Header name for RPC auth (e.g. Authorization)")
+	flag.StringVar(&authValue, "auth-value", "", "Header value for RPC auth")
+	flag.StringVar(&heightsArg, "heights", "", "Comma-separated block heights to record")
+	flag.StringVar(&txidsArg, "txids", "", "Comma-separated txids to record with getrawtransaction")
+	flag.StringVar(&outDir, "out", "", "Fixture directory to write (required)")
+	flag.IntVar(&verbosity, "verbosity", 3, "getblock verbosity to record")
+	flag.BoolVar(&withTxs, "txs", false, "Also record getrawtransaction for every tx and prevout tx in the blocks")
+	flag.Parse()
+
+	logger.Init(&logger.Options{Level: slog.LevelInfo, TimeFormat: time.RFC3339})
+
+	if url == "" || outDir == "" {
+		fmt.Fprintln(os.Stderr, "missing required -url or -out flag")
+		os.Exit(1)
+	}
+
+	var auth *rpc.AuthConfig
+	if authKey != "" {
+		auth = &rpc.AuthConfig{Type: rpc.AuthTypeHeader, Key: authKey, Value: authValue}
+	}
+	r := &recorder{
+		client: rpc.NewBaseClient(url, rpc.NetworkBitcoin, rpc.ClientTypeRPC, auth, time.Minute, nil),
+		dir:    outDir,
+	}
+	ctx := context.Background()
+
+	if err := r.record(ctx, "getblockcount"); err != nil {
+		logger.Fatal("Record failed", "err", err)
+	}
+
+	var txids []string
+	for _, h := range splitList(heightsArg) {
+		height, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			logger.Fatal("Invalid height", "height", h, "err", err)
+		}
+		block, err := r.recordBlock(ctx, height, verbosity)
+		if err != nil {
+			logger.Fatal("Record block failed", "height", height, "err", err)
+		}
+		if withTxs {
+			txids = append(txids, blockTxids(block)...)
+		}
+	}
+	txids = append(txids, splitList(txidsArg)...)
+
+	seen := make(map[string]bool, len(txids))
+	for _, txid := range txids {
+		if seen[txid] {
+			continue
+		}
+		seen[txid] = true
+		if err := r.record(ctx, "getrawtransaction", txid, 2); err != nil {
+			logger.Fatal("Record tx failed", "txid", txid, "err", err)
+		}
+	}
+	logger.Info("Recorded fixtures", "dir", outDir, "txs", len(seen))
+}
+
+type recorder struct {
+	client *rpc.BaseClient
+	dir    string
+}
+
+// record calls method and writes its result, or its RPC error, as a fixture.
+func (r *recorder) record(ctx context.Context, method string, params ...any) error {
+	_, err := r.call(ctx, method, params...)
+	return err
+}
+
+func (r *recorder) call(ctx context.Context, method string, params ...any) (*rpc.RPCResponse, error) {
+	resp, err := r.client.CallRPC(ctx, method, params)
+	var rpcErr *rpc.RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		return nil, err
+	}
+	fixture := bitcointest.Fixture{Result: resp.Result, Error: resp.Error}
+	if err := bitcointest.WriteFixture(r.dir, method, params, fixture); err != nil {
+		return nil, err
+	}
+	logger.Info("Recorded", "method", method, "params", params)
+	return resp, nil
+}
+
+func (r *recorder) recordBlock(ctx context.Context, height uint64, verbosity int) (*bitcoin.Block, error) {
+	resp, err := r.call(ctx, "getblockhash", height)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	hash := strings.Trim(string(resp.Result), `"`)
+
+	resp, err = r.call(ctx, "getblock", hash, verbosity)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	// Verbosity 0/1 return a hex string or txid list; there is nothing to walk.
+	var block bitcoin.Block
+	if verbosity < 2 {
+		return &block, nil
+	}
+	if err := json.Unmarshal(resp.Result, &block); err != nil {
+		return nil, fmt.Errorf("decode block %s: %w", hash, err)
+	}
+	return &block, nil
+}
+
+func blockTxids(block *bitcoin.Block) []string {
+	var txids []string
+	for _, tx := range block.Tx {
+		txids = append(txids, tx.TxID)
+		for _, vin := range tx.Vin {
+			if vin.TxID != "" {
+				txids = append(txids, vin.TxID)
+			}
+		}
+	}
+	return txids
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
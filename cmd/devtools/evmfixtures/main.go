@@ -0,0 +1,126 @@
+// Command evmfixtures records EVM JSON-RPC responses into a fixture
+// directory under internal/rpc/evm/testdata, in the layout its tests read:
+// tx.json, receipt.json and trace.json for a transaction, block.json and
+// receipts.json for a block.
+//
+// Only response results are written; node URLs and credentials never end up
+// in the fixtures. Tracing needs a node serving debug_traceTransaction.
+//
+//	go run ./cmd/devtools/evmfixtures -url http://localhost:8545 \
+//	    -tx 0x... -out internal/rpc/evm/testdata/mycase
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+func main() {
+	var (
+		url       string
+		authKey   string
+		authValue string
+		txHash    string
+		blockArg  string
+		outDir    string
+		trace     bool
+	)
+
+	flag.StringVar(&url, "url", "", "EVM node RPC URL (required)")
+	flag.StringVar(&authKey, "auth-key", "", "Header name for RPC auth (e.g. Authorization)")
+	flag.StringVar(&authValue, "auth-value", "", "Header value for RPC auth")
+	flag.StringVar(&txHash, "tx", "", "Transaction hash to record with its receipt")
+	flag.StringVar(&blockArg, "block", "", "Block number to record with its receipts")
+	flag.StringVar(&outDir, "out", "", "Fixture directory to write (required)")
+	flag.BoolVar(&trace, "trace", true, "Also record the transaction's callTracer trace")
+	flag.Parse()
+
+	logger.Init(&logger.Options{Level: slog.LevelInfo, TimeFormat: time.RFC3339})
+
+	if url == "" || outDir == "" || (txHash == "") == (blockArg == "") {
+		fmt.Fprintln(os.Stderr, "want -url, -out and one of -tx or -block")
+		os.Exit(1)
+	}
+
+	var auth *rpc.AuthConfig
+	if authKey != "" {
+		auth = &rpc.AuthConfig{Type: rpc.AuthTypeHeader, Key: authKey, Value: authValue}
+	}
+	r := &recorder{
+		client: rpc.NewBaseClient(url, rpc.NetworkEVM, rpc.ClientTypeRPC, auth, time.Minute, nil),
+		dir:    outDir,
+	}
+	ctx := context.Background()
+
+	if txHash != "" {
+		if err := r.record(ctx, "tx.json", "eth_getTransactionByHash", txHash); err != nil {
+			logger.Fatal("Record tx failed", "tx", txHash, "err", err)
+		}
+		if err := r.record(ctx, "receipt.json", "eth_getTransactionReceipt", txHash); err != nil {
+			logger.Fatal("Record receipt failed", "tx", txHash, "err", err)
+		}
+		if trace {
+			tracer := map[string]string{"tracer": "callTracer"}
+			if err := r.record(ctx, "trace.json", "debug_traceTransaction", txHash, tracer); err != nil {
+				logger.Fatal("Record trace failed", "tx", txHash, "err", err)
+			}
+		}
+	} else {
+		number, err := strconv.ParseUint(blockArg, 10, 64)
+		if err != nil {
+			logger.Fatal("Invalid block number", "block", blockArg, "err", err)
+		}
+		hexNumber := "0x" + strconv.FormatUint(number, 16)
+		if err := r.record(ctx, "block.json", "eth_getBlockByNumber", hexNumber, true); err != nil {
+			logger.Fatal("Record block failed", "block", number, "err", err)
+		}
+		if err := r.record(ctx, "receipts.json", "eth_getBlockReceipts", hexNumber); err != nil {
+			logger.Fatal("Record receipts failed", "block", number, "err", err)
+		}
+	}
+	logger.Info("Recorded fixtures", "dir", outDir)
+}
+
+type recorder struct {
+	client *rpc.BaseClient
+	dir    string
+}
+
+// record calls method and writes its indented result to file. A null
+// result or an RPC error fails, as the tests need the data.
+func (r *recorder) record(ctx context.Context, file, method string, params ...any) error {
+	resp, err := r.client.CallRPC(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return fmt.Errorf("%s: no result", method)
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, resp.Result, "", "  "); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	out.WriteByte('\n')
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, file), out.Bytes(), 0o644); err != nil {
+		return err
+	}
+	logger.Info("Recorded", "method", method, "file", file)
+	return nil
+}
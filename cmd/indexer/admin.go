@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/watchaddress"
+	"github.com/fystack/multichain-indexer/internal/worker"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+)
+
+// Address discoveries each run a UTXO set scan on a node, allowed
+// discoverBurst at once and then one per discoverInterval.
+const (
+	discoverInterval = 10 * time.Second
+	discoverBurst    = 3
+)
+
+// startAdminServer serves the endpoints that change the indexer's state on
+// services.admin.port, each request authenticated by services.admin.token.
+// It returns nil, serving nothing, when no admin port is configured.
+func startAdminServer(
+	cfg config.AdminConfig,
+	manager *worker.Manager,
+	addressService *watchaddress.Service,
+) *http.Server {
+	if cfg.Port == 0 {
+		logger.Info("Admin server disabled, set services.admin.port to serve it")
+		return nil
+	}
+	mux := http.NewServeMux()
+
+	// POST /status/reorg-halt?chain=<name> resumes a chain halted by a reorg
+	// deeper than its rollback window, as the JSON body directs:
+	// {"depth": N} rolls back N blocks further once, {"resync_from": H}
+	// resumes indexing at height H.
+	mux.HandleFunc("POST /status/reorg-halt", func(w http.ResponseWriter, r *http.Request) {
+		chain := r.URL.Query().Get("chain")
+		var res worker.ReorgResolution
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			http.Error(w, fmt.Sprintf("invalid resolution: %v", err), http.StatusBadRequest)
+			return
+		}
+		switch err := manager.ResolveReorgHalt(chain, res); {
+		case errors.Is(err, worker.ErrChainNotRunning):
+			http.Error(w, fmt.Sprintf("chain %q not running", chain), http.StatusNotFound)
+		case errors.Is(err, worker.ErrChainNotHalted):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			logger.Warn("Reorg halt resolution accepted", "chain", chain, "depth", res.Depth, "resync_from", res.ResyncFrom)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+
+	// POST /addresses/discover?chain=<name> finds the unspent outputs of an
+	// address registered after it was used, with scantxoutset on a node with
+	// heavy_scans, and queues the blocks of an optional range for the
+	// chain's manual worker to recover its past transfers:
+	// {"address": "bc1...", "from_block": F, "to_block": T}. Addresses not
+	// registered on the chain are refused with 409.
+	discoverLimiter := ratelimiter.NewRateLimiter(discoverInterval, discoverBurst)
+	mux.HandleFunc("POST /addresses/discover", func(w http.ResponseWriter, r *http.Request) {
+		chain := r.URL.Query().Get("chain")
+		var req worker.AddressDiscovery
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Address == "" {
+			http.Error(w, "invalid request body: an address is required", http.StatusBadRequest)
+			return
+		}
+		if !discoverLimiter.TryAcquire() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(discoverInterval.Seconds())))
+			http.Error(w, "too many address discoveries, retry later", http.StatusTooManyRequests)
+			return
+		}
+		res, found, err := manager.DiscoverAddress(r.Context(), chain, req)
+		switch {
+		case !found:
+			http.Error(w, fmt.Sprintf("chain %q not running", chain), http.StatusNotFound)
+		case errors.Is(err, worker.ErrDiscoveryUnsupported), errors.Is(err, indexer.ErrNoScanNodes):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		case errors.Is(err, worker.ErrInvalidBackfill), errors.Is(err, indexer.ErrInvalidAddress):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, worker.ErrAddressNotWatched):
+			http.Error(w, err.Error()+", register it first", http.StatusConflict)
+		case err != nil:
+			// The error can name the node, and its URL its credentials.
+			logger.Error("Address discovery failed", "chain", chain, "address", req.Address, "error", err)
+			http.Error(w, "address discovery failed, see the indexer log", http.StatusBadGateway)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(res)
+		}
+	})
+
+	if addressService != nil {
+		mux.Handle("/addresses", addressService.Handler())
+		mux.Handle("/addresses/xpub", addressService.XpubHandler())
+		mux.Handle("/addresses/scripts", addressService.ScriptHandler())
+		mux.Handle("/addresses/redeem-scripts", addressService.RedeemScriptHandler())
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Handler: requireToken(cfg.Token, mux),
+	}
+	server.RegisterOnShutdown(discoverLimiter.Close)
+
+	go func() {
+		logger.Info("Admin server started", "port", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Admin server failed to start", "error", err)
+		}
+	}()
+
+	return server
+}
+
+// requireToken answers 401 to requests not carrying token as a bearer
+// token.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
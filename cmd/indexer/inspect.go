@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/worker"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+)
+
+// The commands below troubleshoot a chain's extraction and nodes without
+// running the indexer. They read the chain settings from the configuration
+// file and reach its nodes only: the database, Redis and NATS are never
+// connected. Results are printed to stdout as JSON, logs go to stderr.
+
+// InspectBlockCmd prints a block as the chain's indexer normalizes it. A
+// block given by hash may be one a reorg took off the main chain, on chains
+// whose indexer is an indexer.HashFetcher. A block given by time is the
+// last one at or before it, on chains whose indexer is an
+// indexer.BlockTimeFinder.
+type InspectBlockCmd struct {
+	ConfigPath string   `help:"Path to configuration file containing chain and worker settings." default:"configs/config.yaml" short:"c" name:"config"`
+	Chain      string   `help:"Chain to fetch the block from." required:"" short:"n" name:"chain"`
+	Height     uint64   `help:"Height of the block." required:"" xor:"block" name:"height"`
+	Hash       string   `help:"Hash of the block, which may be off the main chain." required:"" xor:"block" name:"hash"`
+	At         string   `help:"Time to inspect the last block at or before, as RFC 3339 or YYYY-MM-DD (UTC)." required:"" xor:"block" name:"at"`
+	Watch      []string `help:"Addresses to treat as monitored (comma-separated). Every address is by default." sep:"," name:"watch"`
+	Pretty     bool     `help:"Indent the JSON output." name:"pretty"`
+	Debug      bool     `help:"Enable debug-level logging." short:"d" name:"debug"`
+}
+
+func (c *InspectBlockCmd) Run() error {
+	initToolLogger(c.Debug)
+	chainCfg, err := loadChainConfig(c.ConfigPath, c.Chain)
+	if err != nil {
+		return err
+	}
+	idx := worker.BuildIndexer(c.Chain, chainCfg, inspectPubkeyStore(chainCfg, c.Watch))
+	if c.Hash != "" {
+		fetcher, ok := idx.(indexer.HashFetcher)
+		if !ok {
+			return fmt.Errorf("%s chains cannot fetch blocks by hash", chainCfg.Type)
+		}
+		block, err := fetcher.GetBlockByHash(context.Background(), c.Hash)
+		if err != nil {
+			return fmt.Errorf("get block %s: %w", c.Hash, err)
+		}
+		return printJSON(block, c.Pretty)
+	}
+	height := c.Height
+	if c.At != "" {
+		target, err := parseTime(c.At)
+		if err != nil {
+			return err
+		}
+		finder, ok := idx.(indexer.BlockTimeFinder)
+		if !ok {
+			return fmt.Errorf("%s chains cannot find blocks by time", chainCfg.Type)
+		}
+		if height, err = finder.FindBlockByTime(context.Background(), target); err != nil {
+			return fmt.Errorf("find block at %s: %w", c.At, err)
+		}
+	}
+	block, err := idx.GetBlock(context.Background(), height)
+	if err != nil {
+		return fmt.Errorf("get block %d: %w", height, err)
+	}
+	return printJSON(block, c.Pretty)
+}
+
+// DecodeTxCmd prints the transfers and fee extracted from one transaction.
+type DecodeTxCmd struct {
+	ConfigPath string `help:"Path to configuration file containing chain and worker settings." default:"configs/config.yaml" short:"c" name:"config"`
+	Chain      string `help:"Bitcoin chain to fetch the transaction from." required:"" short:"n" name:"chain"`
+	TxID       string `help:"ID of the transaction." required:"" name:"txid"`
+	Pretty     bool   `help:"Indent the JSON output." name:"pretty"`
+	Debug      bool   `help:"Enable debug-level logging." short:"d" name:"debug"`
+}
+
+func (c *DecodeTxCmd) Run() error {
+	initToolLogger(c.Debug)
+	chainCfg, err := loadChainConfig(c.ConfigPath, c.Chain)
+	if err != nil {
+		return err
+	}
+	if chainCfg.Type != enum.NetworkTypeBtc {
+		return fmt.Errorf("decode-tx supports Bitcoin chains only, %s is %s", c.Chain, chainCfg.Type)
+	}
+	idx := worker.BuildBitcoinIndexer(c.Chain, chainCfg)
+	decoded, err := idx.DecodeTransaction(context.Background(), c.TxID)
+	if err != nil {
+		return err
+	}
+	return printJSON(decoded, c.Pretty)
+}
+
+// ValidateAddressCmd prints whether an address is valid for a network type,
+// and its canonical form and type. It needs no configuration.
+type ValidateAddressCmd struct {
+	Network string `help:"Network type of the address." required:"" enum:"evm,tron,btc,sol,apt,sui,cosmos,ton" name:"network"`
+	Address string `help:"Address to validate." required:"" name:"address"`
+	HRP     string `help:"Segwit HRP to accept besides Bitcoin's, as a chain's bech32_hrp." name:"hrp"`
+	Pretty  bool   `help:"Indent the JSON output." name:"pretty"`
+}
+
+// addressValidation is the output of ValidateAddressCmd. Validated is false
+// for network types whose addresses are accepted unchecked.
+type addressValidation struct {
+	Network   enum.NetworkType `json:"network"`
+	Address   string           `json:"address"`
+	Valid     bool             `json:"valid"`
+	Validated bool             `json:"validated"`
+	Canonical string           `json:"canonical,omitempty"`
+	Display   string           `json:"display,omitempty"`
+	Hex       string           `json:"hex,omitempty"`
+	Type      string           `json:"type,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+func (c *ValidateAddressCmd) Run() error {
+	networkType := enum.NetworkType(c.Network)
+	result := addressValidation{
+		Network:   networkType,
+		Address:   c.Address,
+		Validated: addressutil.Validates(networkType),
+	}
+	var hrps []string
+	if c.HRP != "" {
+		hrps = []string{c.HRP}
+	}
+	v := addressutil.Validate(networkType, c.Address, hrps...)
+	result.Valid, result.Canonical, result.Type, result.Error = v.Valid, v.Normalized, v.Type, v.Error
+	result.Display, result.Hex = v.Display, v.Hex
+	if err := printJSON(result, c.Pretty); err != nil {
+		return err
+	}
+	if !result.Valid {
+		return errors.New("invalid address")
+	}
+	return nil
+}
+
+// NodeCheckCmd prints the diagnostics of every node configured for a chain,
+// as served by /status/diagnose while indexing.
+type NodeCheckCmd struct {
+	ConfigPath string `help:"Path to configuration file containing chain and worker settings." default:"configs/config.yaml" short:"c" name:"config"`
+	Chain      string `help:"Chain whose nodes to check." required:"" short:"n" name:"chain"`
+	Pretty     bool   `help:"Indent the JSON output." name:"pretty"`
+	Debug      bool   `help:"Enable debug-level logging." short:"d" name:"debug"`
+}
+
+func (c *NodeCheckCmd) Run() error {
+	initToolLogger(c.Debug)
+	chainCfg, err := loadChainConfig(c.ConfigPath, c.Chain)
+	if err != nil {
+		return err
+	}
+	idx := worker.BuildIndexer(c.Chain, chainCfg, inspectPubkeyStore(chainCfg, nil))
+	diagnoser, ok := idx.(indexer.NodeDiagnoser)
+	if !ok {
+		return fmt.Errorf("%w for %s chains", worker.ErrDiagnosticsUnsupported, chainCfg.Type)
+	}
+	return printJSON(struct {
+		Chain string         `json:"chain"`
+		Nodes map[string]any `json:"nodes"`
+	}{c.Chain, diagnoser.DiagnoseNodes(context.Background())}, c.Pretty)
+}
+
+// initToolLogger logs warnings and errors, or everything with debug, to
+// stderr so they stay out of the JSON output.
+func initToolLogger(debug bool) {
+	level := slog.LevelWarn
+	if debug {
+		level = slog.LevelDebug
+	}
+	logger.Init(&logger.Options{
+		Level:      level,
+		Writer:     os.Stderr,
+		TimeFormat: time.RFC3339,
+	})
+}
+
+func loadChainConfig(path, chain string) (config.ChainConfig, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.ChainConfig{}, fmt.Errorf("load config: %w", err)
+	}
+	return cfg.Chains.GetChain(chain)
+}
+
+// parseTime parses an RFC 3339 time or a UTC date.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC 3339 or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+func printJSON(v any, pretty bool) error {
+	enc := json.NewEncoder(os.Stdout)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// watchList is a pubkeystore.Store monitoring the addresses it holds, or
+// every address when it holds none.
+type watchList map[string]bool
+
+func (w watchList) Exist(addressType enum.NetworkType, publicKey string) bool {
+	return len(w) == 0 || w[addressutil.Canonical(addressType, publicKey)]
+}
+
+func (watchList) Save(enum.NetworkType, string) error { return nil }
+func (watchList) Close() error                        { return nil }
+
+// inspectPubkeyStore returns the store an inspected chain checks monitored
+// addresses against. Without a watch list Bitcoin chains get none, as
+// watching every address would flag every transaction a consolidation.
+func inspectPubkeyStore(chainCfg config.ChainConfig, watch []string) pubkeystore.Store {
+	if len(watch) == 0 && chainCfg.Type == enum.NetworkTypeBtc {
+		return nil
+	}
+	w := make(watchList, len(watch))
+	for _, addr := range watch {
+		w[addressutil.Canonical(chainCfg.Type, addr, chainCfg.SegwitHRPs()...)] = true
+	}
+	return w
+}
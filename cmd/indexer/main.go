@@ -8,23 +8,46 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"gorm.io/gorm"
 
+	"github.com/fystack/multichain-indexer/internal/alert"
+	"github.com/fystack/multichain-indexer/internal/snapshot"
+	"github.com/fystack/multichain-indexer/internal/supply"
+	"github.com/fystack/multichain-indexer/internal/watchaddress"
 	"github.com/fystack/multichain-indexer/internal/worker"
 	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/amount"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/events"
 	"github.com/fystack/multichain-indexer/pkg/infra"
 	"github.com/fystack/multichain-indexer/pkg/kvstore"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/fystack/multichain-indexer/pkg/sink"
+	"github.com/fystack/multichain-indexer/pkg/store/budgetstore"
+	"github.com/fystack/multichain-indexer/pkg/store/channelstore"
+	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
 )
 
 type CLI struct {
-	Index IndexCmd `cmd:"" help:"Start the multi-chain transaction indexer with configurable worker modes."`
+	Index           IndexCmd           `cmd:"" help:"Start the multi-chain transaction indexer with configurable worker modes."`
+	SupplyReconcile SupplyReconcileCmd `cmd:"" help:"Check the supply recorded for a Bitcoin chain against its nodes, optionally fixing it."`
+	InspectBlock    InspectBlockCmd    `cmd:"" help:"Print a block as the chain's indexer normalizes it."`
+	DecodeTx        DecodeTxCmd        `cmd:"" help:"Print the transfers and fee extracted from a Bitcoin transaction."`
+	ValidateAddress ValidateAddressCmd `cmd:"" help:"Print whether an address is valid for a network type, with its type."`
+	NodeCheck       NodeCheckCmd       `cmd:"" help:"Run the diagnostics against every node configured for a chain."`
+	SnapshotExport  SnapshotExportCmd  `cmd:"" help:"Write the indexer's state (checkpoints, block hashes, channel fundings, xpub cursors) to an archive."`
+	SnapshotImport  SnapshotImportCmd  `cmd:"" help:"Restore the indexer's state from a snapshot-export archive."`
 }
 
 type IndexCmd struct {
@@ -43,10 +66,13 @@ type IndexCmd struct {
 
 	// Block starting point
 	FromLatest bool `help:"Start indexing from the latest blockchain block instead of configured starting points. Useful for fresh deployments." name:"from-latest"`
+
+	// State migration
+	RestoreSnapshot string `help:"Restore the state of a snapshot-export archive before the workers start." name:"restore-snapshot" placeholder:"PATH"`
 }
 
 func (c *IndexCmd) Run() error {
-	runIndexer(c.Chains, c.ConfigPath, c.Debug, c.EnableManual, c.EnableCatchup, c.FromLatest)
+	runIndexer(c.Chains, c.ConfigPath, c.Debug, c.EnableManual, c.EnableCatchup, c.FromLatest, c.RestoreSnapshot)
 	return nil
 }
 
@@ -67,7 +93,7 @@ func main() {
 	ctx.FatalIfErrorf(err)
 }
 
-func runIndexer(chains []string, configPath string, debug, manual, catchup, fromLatest bool) {
+func runIndexer(chains []string, configPath string, debug, manual, catchup, fromLatest bool, snapshotPath string) {
 	ctx := context.Background()
 
 	level := slog.LevelInfo
@@ -87,8 +113,17 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 			"hint", "Check the config file syntax and structure")
 	}
 	logger.Info("Config loaded", "environment", cfg.Environment)
+	logger.Debug("Effective config", "config", cfg.Redacted())
 
 	services := cfg.Services
+	shutdownTracing, err := tracing.Init(ctx, services.Tracing, cfg.Version)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", "error", err)
+	}
+	if services.Tracing.Enabled {
+		logger.Info("Tracing enabled", "endpoint", services.Tracing.Endpoint)
+	}
+
 	// start redis
 	logger.Info(
 		"Connecting to redis",
@@ -110,6 +145,9 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	}
 	logger.Info("Redis connection established")
 
+	// Budgets must exist before the workers' rate limiter pools take them
+	registerBudgets(ctx, services.LimiterGroups, redisClient)
+
 	// start db (optional)
 	var db *gorm.DB
 	if services.Database != nil {
@@ -130,6 +168,24 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	}
 	defer kvstore.Close()
 
+	// Roll back an interrupted snapshot restore, and restore a snapshot,
+	// before anything reads the state they replace
+	if err := recoverInterruptedRestore(kvstore); err != nil {
+		logger.Fatal("Recover interrupted snapshot restore failed", "err", err)
+	}
+	if snapshotPath != "" {
+		stores := snapshot.Stores{KV: kvstore}
+		if store := channelstore.New(redisClient); store != nil {
+			stores.Fundings = store
+		}
+		report, err := restoreSnapshot(ctx, snapshotPath, cfg, nil, stores, false)
+		if err != nil {
+			logger.Fatal("Restore snapshot failed", "path", snapshotPath, "err", err)
+		}
+		logger.Info("Snapshot restored", "path", snapshotPath, "created_at", report.CreatedAt,
+			"chains", len(report.Chains), "xpubs", len(report.Xpubs), "keys", report.Keys)
+	}
+
 	logger.Info("Connecting to NATS", "url", services.Nats.URL)
 	natsConn, err := infra.GetNATSConnection(services.Nats, string(cfg.Environment))
 	if err != nil {
@@ -148,17 +204,42 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	}, natsConn)
 	utxoQueue := utxoQueueManager.NewMessageQueue("dispatch")
 
-	emitter := events.NewEmitter(eventQueue, utxoQueue, services.Nats.SubjectPrefix)
+	// Optional buffer between the workers and NATS, one log per stream
+	var queueBuffers map[string]*sink.BufferedQueue
+	if buf := services.Nats.Buffer; buf.Dir != "" {
+		queueBuffers = map[string]*sink.BufferedQueue{
+			"transfer": openQueueBuffer(eventQueue, buf, "transfer"),
+			"utxo":     openQueueBuffer(utxoQueue, buf, "utxo"),
+		}
+		eventQueue, utxoQueue = queueBuffers["transfer"], queueBuffers["utxo"]
+		logger.Info("NATS buffer enabled", "dir", buf.Dir)
+	}
+
+	// tx_types was validated by config.Load.
+	txTypes, err := constant.NewTxTypeRegistry(services.TxTypes)
+	if err != nil {
+		logger.Fatal("Invalid tx_types", "err", err)
+	}
+	emitter := events.NewEmitter(eventQueue, utxoQueue, services.Nats.SubjectPrefix,
+		events.WithEncodings(services.Nats.Encoding),
+		events.WithTxTypes(txTypes))
 	defer emitter.Close()
 
-	// start address bloom filter (Initialize is optional)
+	// start address bloom filter (Initialize is optional). Loading a large
+	// table takes a while; workers emit every transfer until it is ready.
 	var addressBF addressbloomfilter.WalletAddressBloomFilter
 	if services.Bloomfilter != nil && db != nil {
 		addressBF = addressbloomfilter.NewBloomFilter(*services.Bloomfilter, db, redisClient)
-		if err := addressBF.Initialize(ctx); err != nil {
-			logger.Fatal("Address bloom filter init failed", "err", err)
-		}
-		logger.Info("Address bloom filter initialized")
+		go func() {
+			started := time.Now()
+			if err := addressBF.Initialize(ctx); err != nil {
+				// Reported on /health; matching stays by the not-ready policy.
+				logger.Error("Address bloom filter init failed, it stays not ready", "err", err)
+				return
+			}
+			logger.Info("Address bloom filter initialized", "took", time.Since(started))
+		}()
+		logger.Info("Address bloom filter loading in the background")
 	} else if services.Bloomfilter != nil {
 		// Create bloom filter instance even without database, but skip initialization
 		addressBF = addressbloomfilter.NewBloomFilter(*services.Bloomfilter, db, redisClient)
@@ -194,11 +275,76 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	}
 
 	// Create manager with all workers using factory
+	// Transfer sink (requires the database)
+	var transferSink *sink.DBSink
+	if services.TransferSink.Enabled {
+		if db == nil {
+			logger.Fatal("transfer_sink requires the database to be configured")
+		}
+		transferSink = sink.NewDBSink(repository.NewTransferRepository(db, services.TransferSink.BatchSize), txTypes)
+		logger.Info("Transfer sink enabled", "batch_size", services.TransferSink.BatchSize)
+	}
+	// Optional buffer between the workers and the transfer sink
+	var bufferedSink *sink.BufferedSink
+	if buf := services.TransferSink.Buffer; transferSink != nil && buf.Dir != "" {
+		bufferedSink, err = sink.NewBufferedSink(transferSink, bufferedConfig(buf, buf.Dir))
+		if err != nil {
+			logger.Fatal("Open transfer sink buffer failed", "dir", buf.Dir, "err", err)
+		}
+		logger.Info("Transfer sink buffer enabled", "dir", buf.Dir)
+	}
+
 	managerCfg := worker.ManagerConfig{
 		Chains:        chains,
 		EnableCatchup: catchup,
 		EnableManual:  manual,
 		BloomSync:     bloomSyncCfg,
+		AmountFormat:  amount.Format(services.AmountFormat),
+	}
+	switch { // a nil *DBSink would make a non-nil Sink
+	case bufferedSink != nil:
+		managerCfg.Sink = bufferedSink
+	case transferSink != nil:
+		managerCfg.Sink = transferSink
+	}
+
+	// Watch-address import API (requires the database). Keys imported as
+	// xpubs derive more addresses as transfers use the earlier ones; scripts
+	// and redeem scripts reach the workers through managerCfg.Scripts, and
+	// addresses registered again are reinstated through managerCfg.Retired.
+	var addressService *watchaddress.Service
+	if db != nil {
+		addressService = watchaddress.NewService(repository.NewRepository[model.WalletAddress](db), addressBF)
+		addressService.SetChains(cfg.Chains)
+		managerCfg.Retired = addressbloomfilter.NewRetiredSet()
+		addressService.TrackRetired(managerCfg.Retired)
+		if err := addressService.TrackXpubs(ctx, kvstore, services.WatchAddresses.XpubLookahead); err != nil {
+			logger.Fatal("Load watched xpubs failed", "err", err)
+		}
+		managerCfg.Scripts = addressbloomfilter.NewScriptSet()
+		if err := addressService.WatchScripts(ctx, repository.NewRepository[model.WatchedScript](db), managerCfg.Scripts); err != nil {
+			logger.Fatal("Load watched scripts failed", "err", err)
+		}
+		if err := addressService.TrackRedeemScripts(ctx, repository.NewRepository[model.RedeemScript](db), managerCfg.Scripts); err != nil {
+			logger.Fatal("Load redeem scripts failed", "err", err)
+		}
+	}
+
+	// Supply API (requires the database), reading what the workers of
+	// chains with supply tracking record.
+	var supplyTrackers []*supply.Tracker
+	if db != nil {
+		supplyRepo := repository.NewSupplyRepository(db)
+		for _, name := range chains {
+			chainCfg := cfg.Chains[name]
+			if !chainCfg.Supply.Enabled {
+				continue
+			}
+			// An invalid config is fatal once the chain's workers are built.
+			if t, err := supply.ForChain(supplyRepo, nil, chainCfg); err == nil {
+				supplyTrackers = append(supplyTrackers, t)
+			}
+		}
 	}
 
 	manager := worker.CreateManagerWithWorkers(
@@ -207,17 +353,35 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 		kvstore,
 		db,
 		addressBF,
-		emitter,
+		watchaddress.ObservingEmitter(emitter, addressService),
 		redisClient,
 		managerCfg,
 	)
 
-	healthServer := startHealthServer(cfg.Services.Port, cfg)
+	healthServer := startHealthServer(cfg.Services.Port, cfg, manager, addressBF, txTypes, transferSink, bufferedSink, queueBuffers, supplyTrackers)
+	adminServer := startAdminServer(services.Admin, manager, addressService)
 
 	// Start all workers
 	logger.Info("Starting all workers")
 	manager.Start()
 
+	go watchReload(ctx, configPath, chains, fromLatest, manager, addressService, redisClient)
+
+	if alerting := services.Alerting; alerting.Enabled {
+		var notifiers []alert.Notifier
+		if alerting.SlackWebhookURL != "" {
+			notifiers = append(notifiers, alert.NewSlackNotifier(alerting.SlackWebhookURL))
+		}
+		if alerting.WebhookURL != "" {
+			notifiers = append(notifiers, alert.NewWebhookNotifier(alerting.WebhookURL))
+		}
+		alerter := alert.NewAlerter(alerting, notifiers...)
+		go alerter.Run(ctx, func() map[string]worker.ChainStatus {
+			return manager.ChainStatuses(services.Health.MaxLag)
+		})
+		logger.Info("Alerting enabled", "notifiers", len(notifiers))
+	}
+
 	logger.Info("🚀 Transaction indexer is running... Press Ctrl+C to stop")
 	waitForShutdown()
 
@@ -226,7 +390,28 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	// Stop workers first so health endpoint can report during drain
 	manager.Stop()
 
-	// Then shutdown health server
+	// Spill what the buffer still holds; the log is replayed on restart
+	if bufferedSink != nil {
+		if err := bufferedSink.Close(); err != nil {
+			logger.Error("Transfer sink buffer close failed", "error", err)
+		}
+	}
+
+	// Export the spans of the last blocks
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Error("Tracing shutdown failed", "error", err)
+	}
+	cancelTracing()
+
+	// Then shutdown the admin and health servers
+	if adminServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Admin server shutdown failed", "error", err)
+		}
+	}
 	if healthServer != nil {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -240,13 +425,126 @@ func runIndexer(chains []string, configPath string, debug, manual, catchup, from
 	logger.Info("Indexer stopped gracefully")
 }
 
+// registerBudgets registers the request budget of every limiter group,
+// counted in Redis, and resumes each from the count there.
+func registerBudgets(ctx context.Context, groups map[string]config.LimiterGroupConfig, redisClient infra.RedisClient) {
+	var store ratelimiter.BudgetStore
+	if s := budgetstore.New(redisClient); s != nil {
+		store = s
+	}
+	for name, group := range groups {
+		budget := ratelimiter.RegisterBudget(name, ratelimiter.BudgetLimits{
+			Daily:          group.DailyRequests,
+			Monthly:        group.MonthlyRequests,
+			SlowDownAt:     group.SlowDownAt,
+			StopOptionalAt: group.StopOptionalAt,
+			MaxDelay:       group.MaxDelay,
+		}, store)
+		flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		budget.Flush(flushCtx)
+		cancel()
+		stats := budget.Stats()
+		logger.Info("Request budget registered", "group", name,
+			"daily_used", stats.DailyUsed, "monthly_used", stats.MonthlyUsed)
+	}
+}
+
+// watchReload reloads the config on SIGHUP and applies per-chain enabled
+// flags, starting or stopping chains without restarting the process, the
+// asset filters and segwit HRPs of running chains, the HRPs addresses are
+// registered with and the limiter groups' budgets.
+func watchReload(
+	ctx context.Context,
+	configPath string,
+	chains []string,
+	fromLatest bool,
+	manager *worker.Manager,
+	addressService *watchaddress.Service,
+	redisClient infra.RedisClient,
+) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		logger.Info("Reloading configuration", "config_path", configPath)
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			logger.Error("Config reload failed, keeping current config", "error", err)
+			continue
+		}
+		if fromLatest {
+			cfg.Chains.OverrideFromLatest(chains)
+		}
+		// Chains started by the reload may name a new limiter group.
+		registerBudgets(ctx, cfg.Services.LimiterGroups, redisClient)
+		manager.ApplyChainConfigs(cfg.Chains)
+		if addressService != nil {
+			addressService.SetChains(cfg.Chains)
+		}
+	}
+}
+
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
+	Status    string                       `json:"status"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Version   string                       `json:"version"`
+	Chains    map[string]worker.ChainState `json:"chains,omitempty"`
+	Stats     map[string]map[string]any    `json:"stats,omitempty"`
+
+	// RateLimiters reports, per rate limiter pool and node, available
+	// tokens and recent token waits.
+	RateLimiters map[string]map[string]any `json:"rate_limiters,omitempty"`
+
+	// Budgets reports, per limiter group, the requests counted against its
+	// daily and monthly budgets and the optional ones held back or refused.
+	Budgets map[string]ratelimiter.BudgetStats `json:"budgets,omitempty"`
+
+	// Sink reports the transfer sink's writes and write latency, and its
+	// buffer's depth, spill segments and replay progress.
+	Sink map[string]any `json:"sink,omitempty"`
+
+	// NatsBuffers reports, per stream, the NATS buffer's depth, spill
+	// segments and replay progress.
+	NatsBuffers map[string]map[string]any `json:"nats_buffers,omitempty"`
+
+	// BloomFilter reports whether the address bloom filter is ready, its
+	// loading progress per network type, and how many addresses were
+	// treated as watched while it was not.
+	BloomFilter map[string]any `json:"bloom_filter,omitempty"`
+}
+
+// bufferedConfig returns the sink buffer settings of cfg, logging to dir.
+func bufferedConfig(cfg config.BufferConfig, dir string) sink.BufferedConfig {
+	return sink.BufferedConfig{
+		Dir:            dir,
+		QueueSize:      cfg.QueueSize,
+		HandoffTimeout: cfg.HandoffTimeout,
+		SegmentBytes:   cfg.SegmentBytes,
+		RetryInterval:  cfg.RetryInterval,
+	}
 }
 
-func startHealthServer(port int, cfg *config.Config) *http.Server {
+// openQueueBuffer wraps queue in a buffer logging to the stream's
+// subdirectory of cfg.Dir.
+func openQueueBuffer(queue infra.MessageQueue, cfg config.BufferConfig, stream string) *sink.BufferedQueue {
+	dir := filepath.Join(cfg.Dir, stream)
+	buffered, err := sink.NewBufferedQueue(queue, bufferedConfig(cfg, dir))
+	if err != nil {
+		logger.Fatal("Open NATS buffer failed", "dir", dir, "err", err)
+	}
+	return buffered
+}
+
+func startHealthServer(
+	port int,
+	cfg *config.Config,
+	manager *worker.Manager,
+	addressBF addressbloomfilter.WalletAddressBloomFilter,
+	txTypes *constant.TxTypeRegistry,
+	transferSink *sink.DBSink,
+	bufferedSink *sink.BufferedSink,
+	queueBuffers map[string]*sink.BufferedQueue,
+	supplyTrackers []*supply.Tracker,
+) *http.Server {
 	mux := http.NewServeMux()
 
 	version := cfg.Version
@@ -259,6 +557,26 @@ func startHealthServer(port int, cfg *config.Config) *http.Server {
 			Status:    "ok",
 			Timestamp: time.Now().UTC(),
 			Version:   version,
+			Chains:    manager.ChainStates(),
+			Stats:     manager.ChainStats(),
+
+			RateLimiters: ratelimiter.GetSharedPoolStats(),
+			Budgets:      ratelimiter.GetBudgetStats(),
+		}
+		if transferSink != nil {
+			response.Sink = transferSink.Stats()
+		}
+		if bufferedSink != nil {
+			response.Sink["buffer"] = bufferedSink.Stats()
+		}
+		if len(queueBuffers) > 0 {
+			response.NatsBuffers = make(map[string]map[string]any, len(queueBuffers))
+			for name, buffered := range queueBuffers {
+				response.NatsBuffers[name] = buffered.Stats()
+			}
+		}
+		if addressBF != nil {
+			response.BloomFilter = bloomFilterHealth(addressBF)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -266,6 +584,54 @@ func startHealthServer(port int, cfg *config.Config) *http.Server {
 		json.NewEncoder(w).Encode(response)
 	})
 
+	// /status details every chain; /healthz is the readiness probe and
+	// answers 503 while any running chain is not ready.
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, manager.ChainStatuses(cfg.Services.Health.MaxLag))
+	})
+	// /status/diagnose?chain=<name> queries each of the chain's nodes; runs
+	// against a node are rate limited by the client.
+	mux.HandleFunc("/status/diagnose", func(w http.ResponseWriter, r *http.Request) {
+		chain := r.URL.Query().Get("chain")
+		nodes, found, err := manager.DiagnoseChain(r.Context(), chain)
+		switch {
+		case !found:
+			http.Error(w, fmt.Sprintf("chain %q not running", chain), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Chain string         `json:"chain"`
+			Nodes map[string]any `json:"nodes"`
+		}{chain, nodes})
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		statuses := manager.ChainStatuses(cfg.Services.Health.MaxLag)
+		code := http.StatusOK
+		for _, status := range statuses {
+			if status.NotReady != "" {
+				code = http.StatusServiceUnavailable
+			}
+		}
+		writeStatus(w, code, statuses)
+	})
+
+	// /tx-types maps each transaction type the indexers emit to the name
+	// it is published under.
+	mux.HandleFunc("/tx-types", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(txTypes.Mapping())
+	})
+
+	// /v1/networks/{id}/supply[?height=N] serves a tracked network's
+	// supply at a height, by default the highest recorded.
+	if len(supplyTrackers) > 0 {
+		mux.Handle("/v1/networks/{id}/supply", supply.Handler(supplyTrackers...))
+	}
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: mux,
@@ -281,6 +647,37 @@ func startHealthServer(port int, cfg *config.Config) *http.Server {
 	return server
 }
 
+// bloomFilterHealth reports the bloom filter's readiness, why its loading
+// failed if it did and, per network type, its loading progress.
+func bloomFilterHealth(bf addressbloomfilter.WalletAddressBloomFilter) map[string]any {
+	types := make(map[string]any, len(enum.AllNetworkTypes))
+	for _, t := range enum.AllNetworkTypes {
+		stats := bf.Stats(t)
+		types[string(t)] = map[string]any{
+			"rows_loaded": stats["rowsLoaded"],
+			"rows_total":  stats["rowsTotal"],
+		}
+	}
+	health := map[string]any{
+		"ready":               bf.IsReady(),
+		"fail_open_decisions": pubkeystore.FailOpenDecisions(),
+		"types":               types,
+	}
+	if err := bf.LoadError(); err != nil {
+		health["load_error"] = err.Error()
+	}
+	return health
+}
+
+func writeStatus(w http.ResponseWriter, code int, chains map[string]worker.ChainStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Timestamp time.Time                     `json:"timestamp"`
+		Chains    map[string]worker.ChainStatus `json:"chains"`
+	}{time.Now().UTC(), chains})
+}
+
 func waitForShutdown() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
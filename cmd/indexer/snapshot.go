@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/snapshot"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/kvstore"
+	"github.com/fystack/multichain-indexer/pkg/store/channelstore"
+)
+
+// SnapshotExportCmd writes the indexer's state to a versioned archive, to
+// restore it in another deployment with snapshot-import or index
+// --restore-snapshot. The indexer should be stopped, for its checkpoints
+// not to move while they are read.
+type SnapshotExportCmd struct {
+	ConfigPath string   `help:"Path to configuration file containing chain and worker settings." default:"configs/config.yaml" short:"c" name:"config"`
+	Chains     []string `help:"Chains to export (comma-separated). All configured chains by default." sep:"," short:"n" name:"chains"`
+	Out        string   `help:"Path of the archive to write." required:"" short:"o" name:"out"`
+	Debug      bool     `help:"Enable debug-level logging." short:"d" name:"debug"`
+}
+
+func (c *SnapshotExportCmd) Run() error {
+	initSnapshotLogger(c.Debug)
+	cfg, err := config.Load(c.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	codes, err := chainCodes(cfg, c.Chains)
+	if err != nil {
+		return err
+	}
+	stores, closeStores, err := openSnapshotStores(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStores()
+
+	snap, err := snapshot.Export(context.Background(), stores, codes)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(c.Out)
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Write(f, snap); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logger.Info("Snapshot exported", "path", c.Out, "version", snap.Version,
+		"chains", len(snap.Chains), "xpubs", len(snap.Xpubs), "excluded", snapshot.Excluded)
+	return nil
+}
+
+// SnapshotImportCmd restores a snapshot-export archive, replacing the state
+// of the chains it holds. The indexer must be stopped. The report of what
+// was restored, or would be with --dry-run, is printed as JSON.
+type SnapshotImportCmd struct {
+	ConfigPath string   `help:"Path to configuration file containing chain and worker settings." default:"configs/config.yaml" short:"c" name:"config"`
+	Chains     []string `help:"Chains to restore (comma-separated). All chains of the archive by default." sep:"," short:"n" name:"chains"`
+	In         string   `help:"Path of the archive to restore." required:"" short:"i" name:"in"`
+	DryRun     bool     `help:"Report what would be restored without writing anything." name:"dry-run"`
+	Debug      bool     `help:"Enable debug-level logging." short:"d" name:"debug"`
+}
+
+func (c *SnapshotImportCmd) Run() error {
+	initSnapshotLogger(c.Debug)
+	cfg, err := config.Load(c.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	stores, closeStores, err := openSnapshotStores(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStores()
+
+	report, err := restoreSnapshot(context.Background(), c.In, cfg, c.Chains, stores, c.DryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(report, true)
+}
+
+// restoreSnapshot restores the named chains, all if none, of the archive
+// at path into stores.
+func restoreSnapshot(
+	ctx context.Context,
+	path string,
+	cfg *config.Config,
+	chains []string,
+	stores snapshot.Stores,
+	dryRun bool,
+) (*snapshot.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snap, err := snapshot.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	if snap, err = snap.Filter(chains); err != nil {
+		return nil, err
+	}
+	codes, err := chainCodes(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := recoverInterruptedRestore(stores.KV); err != nil {
+			return nil, err
+		}
+	}
+	report, err := snapshot.Restore(ctx, stores, snap, codes, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Snapshot state not restored, rebuilt once indexing resumes", "excluded", snapshot.Excluded)
+	return report, nil
+}
+
+// recoverInterruptedRestore rolls back a snapshot restore that did not
+// complete.
+func recoverInterruptedRestore(kv infra.KVStore) error {
+	found, err := snapshot.RecoverInterrupted(kv)
+	if found && err == nil {
+		logger.Warn("Rolled back an interrupted snapshot restore")
+	}
+	return err
+}
+
+// chainCodes maps the named chains, all configured if none, to their
+// internal codes, which their state is kept under.
+func chainCodes(cfg *config.Config, names []string) (map[string]string, error) {
+	if len(names) == 0 {
+		names = cfg.Chains.Names()
+	}
+	codes := make(map[string]string, len(names))
+	for _, name := range names {
+		chainCfg, err := cfg.Chains.GetChain(name)
+		if err != nil {
+			return nil, err
+		}
+		codes[name] = chainCfg.InternalCode
+	}
+	return codes, nil
+}
+
+// openSnapshotStores connects to the KV store and, when configured, to
+// Redis, which holds the channel fundings.
+func openSnapshotStores(cfg *config.Config) (snapshot.Stores, func(), error) {
+	services := cfg.Services
+	kv, err := kvstore.NewFromConfig(services.KVS)
+	if err != nil {
+		return snapshot.Stores{}, nil, fmt.Errorf("create kvstore: %w", err)
+	}
+	stores := snapshot.Stores{KV: kv}
+	closeStores := func() { kv.Close() }
+	if services.Redis.URL == "" {
+		return stores, closeStores, nil
+	}
+	redisClient, err := infra.NewRedisClient(
+		services.Redis.URL,
+		services.Redis.Password,
+		string(cfg.Environment),
+		services.Redis.MTLS,
+	)
+	if err != nil {
+		kv.Close()
+		return snapshot.Stores{}, nil, fmt.Errorf("create redis client: %w", err)
+	}
+	if store := channelstore.New(redisClient); store != nil {
+		stores.Fundings = store
+	}
+	return stores, func() {
+		redisClient.Close()
+		kv.Close()
+	}, nil
+}
+
+func initSnapshotLogger(debug bool) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	logger.Init(&logger.Options{
+		Level:      level,
+		Writer:     os.Stderr,
+		TimeFormat: time.RFC3339,
+	})
+}
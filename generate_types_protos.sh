@@ -0,0 +1,16 @@
+#!/bin/bash
+# Regenerates pkg/common/types/typespb from types.proto.
+
+set -e
+
+echo "Generating Go code from proto files..."
+
+PROTO_ROOT="./pkg/common/types/typespb"
+
+protoc \
+  -I"${PROTO_ROOT}" \
+  --go_out="${PROTO_ROOT}" \
+  --go_opt=paths=source_relative \
+  "${PROTO_ROOT}"/types.proto
+
+echo "✓ Code generation complete!"
@@ -0,0 +1,222 @@
+// Package alert notifies operators of chains needing attention: stalled,
+// lagging or failing indexing, exhausted failover pools, deep reorgs and the
+// halts they cause.
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/worker"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultCooldown = 15 * time.Minute
+)
+
+// Condition is a state of a chain that is alerted on.
+type Condition string
+
+const (
+	ConditionStalled             Condition = "stalled"
+	ConditionLag                 Condition = "lag"
+	ConditionConsecutiveFailures Condition = "consecutive_failures"
+	ConditionAllNodesBlacklisted Condition = "all_nodes_blacklisted"
+	ConditionDeepReorg           Condition = "deep_reorg"
+	ConditionReorgHalted         Condition = "reorg_halted"
+)
+
+// Alert is a notification that a chain's condition started or cleared.
+type Alert struct {
+	Chain     string    `json:"chain"`
+	Condition Condition `json:"condition"`
+	Resolved  bool      `json:"resolved"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+type key struct {
+	chain     string
+	condition Condition
+}
+
+type alertState struct {
+	firing   bool      // a firing alert was sent and not resolved yet
+	notified time.Time // when the last alert was sent
+}
+
+type heightSeen struct {
+	height uint64
+	since  time.Time
+}
+
+// Alerter checks chain statuses against the configured conditions. A
+// condition is notified when it starts and again when it clears; a
+// condition flapping faster than the cooldown is notified at most once per
+// cooldown.
+type Alerter struct {
+	cfg       config.AlertingConfig
+	notifiers []Notifier
+
+	state   map[key]*alertState
+	heights map[string]heightSeen
+	now     func() time.Time
+}
+
+// NewAlerter returns an Alerter sending to notifiers, applying the
+// interval and cooldown defaults to cfg.
+func NewAlerter(cfg config.AlertingConfig, notifiers ...Notifier) *Alerter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCooldown
+	}
+	return &Alerter{
+		cfg:       cfg,
+		notifiers: notifiers,
+		state:     make(map[key]*alertState),
+		heights:   make(map[string]heightSeen),
+		now:       time.Now,
+	}
+}
+
+// Run checks the statuses returned by source every interval until ctx is
+// done.
+func (a *Alerter) Run(ctx context.Context, source func() map[string]worker.ChainStatus) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Check(ctx, source())
+		}
+	}
+}
+
+// Check evaluates statuses, sending the alerts due. Conditions of chains
+// missing from statuses, or not running, are resolved. A notification that
+// fails is retried on the next check.
+func (a *Alerter) Check(ctx context.Context, statuses map[string]worker.ChainStatus) {
+	now := a.now()
+	active := make(map[key]string)
+	for chain, status := range statuses {
+		for cond, msg := range a.evaluate(chain, status, now) {
+			active[key{chain, cond}] = msg
+		}
+	}
+
+	keys := make([]key, 0, len(active)+len(a.state))
+	for k := range active {
+		keys = append(keys, k)
+	}
+	for k := range a.state {
+		if _, ok := active[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].chain != keys[j].chain {
+			return keys[i].chain < keys[j].chain
+		}
+		return keys[i].condition < keys[j].condition
+	})
+
+	for _, k := range keys {
+		st := a.state[k]
+		if st == nil {
+			st = &alertState{}
+			a.state[k] = st
+		}
+		msg, firing := active[k]
+		send := false
+		switch {
+		case firing && !st.firing:
+			send = st.notified.IsZero() || now.Sub(st.notified) >= a.cfg.Cooldown
+		case !firing && st.firing:
+			send, msg = true, "resolved"
+		case !firing && now.Sub(st.notified) >= a.cfg.Cooldown:
+			delete(a.state, k)
+		}
+		if !send {
+			continue
+		}
+
+		// Messages quote node errors, which may carry node URLs and their
+		// credentials, to Slack or a webhook.
+		alert := Alert{Chain: k.chain, Condition: k.condition, Resolved: !firing, Message: rpc.RedactURLs(msg), At: now}
+		if err := a.notify(ctx, alert); err != nil {
+			logger.Error("Alert notification failed", "chain", k.chain, "condition", k.condition, "error", err)
+			continue
+		}
+		st.firing = firing
+		st.notified = now
+	}
+}
+
+// evaluate returns the conditions chain is in, with a message for each.
+func (a *Alerter) evaluate(chain string, status worker.ChainStatus, now time.Time) map[Condition]string {
+	if status.State != worker.ChainStateRunning && status.State != worker.ChainStateHalted {
+		delete(a.heights, chain)
+		return nil
+	}
+	conds := make(map[Condition]string)
+
+	if a.cfg.DeepReorg && status.ReorgHalt != nil {
+		conds[ConditionReorgHalted] = fmt.Sprintf("indexing halted at block %d by a reorg deeper than the rollback window (%d), awaiting an operator",
+			status.ReorgHalt.Block, status.ReorgHalt.RollbackWindow)
+	}
+
+	if p := status.ProgressSnapshot; p != nil {
+		seen, ok := a.heights[chain]
+		if !ok || seen.height != p.IndexedHeight {
+			seen = heightSeen{height: p.IndexedHeight, since: now}
+			a.heights[chain] = seen
+		}
+		if a.cfg.StallAfter > 0 && now.Sub(seen.since) >= a.cfg.StallAfter {
+			conds[ConditionStalled] = fmt.Sprintf("no block indexed for %s, at height %d",
+				now.Sub(seen.since).Round(time.Second), p.IndexedHeight)
+		}
+		if a.cfg.MaxLag > 0 && p.Lag > a.cfg.MaxLag {
+			conds[ConditionLag] = fmt.Sprintf("lag %d exceeds %d", p.Lag, a.cfg.MaxLag)
+		}
+		if a.cfg.MaxConsecutiveFailures > 0 && p.ConsecutiveFailures >= a.cfg.MaxConsecutiveFailures {
+			conds[ConditionConsecutiveFailures] = fmt.Sprintf("%d consecutive failures, last: %s",
+				p.ConsecutiveFailures, p.LastError)
+		}
+		if a.cfg.DeepReorg && p.LastDeepReorgAt != nil && now.Sub(*p.LastDeepReorgAt) < a.cfg.Cooldown {
+			conds[ConditionDeepReorg] = fmt.Sprintf("reorg deeper than the rollback window (%d so far)", p.DeepReorgs)
+		}
+	}
+
+	if a.cfg.AllNodesBlacklisted && len(status.Nodes) > 0 &&
+		!slices.ContainsFunc(status.Nodes, func(n rpc.ProviderStatus) bool { return n.Available }) {
+		conds[ConditionAllNodesBlacklisted] = fmt.Sprintf("all %d nodes blacklisted", len(status.Nodes))
+	}
+	return conds
+}
+
+func (a *Alerter) notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, n := range a.notifiers {
+		if err := n.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
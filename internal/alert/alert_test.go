@@ -0,0 +1,176 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/worker"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	alerts []Alert
+	err    error
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, alert Alert) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func newTestAlerter(cfg config.AlertingConfig) (*Alerter, *recordingNotifier, *time.Time) {
+	n := &recordingNotifier{}
+	a := NewAlerter(cfg, n)
+	now := time.Unix(1_700_000_000, 0)
+	a.now = func() time.Time { return now }
+	return a, n, &now
+}
+
+func running(p worker.ProgressSnapshot, nodes ...rpc.ProviderStatus) worker.ChainStatus {
+	return worker.ChainStatus{State: worker.ChainStateRunning, ProgressSnapshot: &p, Nodes: nodes}
+}
+
+func TestAlerter_FiresAndResolves(t *testing.T) {
+	a, n, _ := newTestAlerter(config.AlertingConfig{MaxLag: 100, MaxConsecutiveFailures: 3})
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{
+		"btc": running(worker.ProgressSnapshot{Lag: 150, ConsecutiveFailures: 3, LastError: "timeout"}),
+		"eth": running(worker.ProgressSnapshot{Lag: 10}),
+	})
+	require.Len(t, n.alerts, 2)
+	assert.Equal(t, ConditionConsecutiveFailures, n.alerts[0].Condition)
+	assert.Equal(t, "3 consecutive failures, last: timeout", n.alerts[0].Message)
+	assert.Equal(t, ConditionLag, n.alerts[1].Condition)
+	assert.Equal(t, "btc", n.alerts[1].Chain)
+	assert.False(t, n.alerts[1].Resolved)
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{
+		"btc": running(worker.ProgressSnapshot{Lag: 200, ConsecutiveFailures: 4}),
+	})
+	assert.Len(t, n.alerts, 2, "a firing condition is not notified again")
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{
+		"btc": running(worker.ProgressSnapshot{Lag: 20, ConsecutiveFailures: 4}),
+	})
+	require.Len(t, n.alerts, 3)
+	assert.Equal(t, ConditionLag, n.alerts[2].Condition)
+	assert.True(t, n.alerts[2].Resolved)
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{"btc": {State: worker.ChainStateDisabled}})
+	require.Len(t, n.alerts, 4, "a disabled chain's conditions are resolved")
+	assert.Equal(t, ConditionConsecutiveFailures, n.alerts[3].Condition)
+	assert.True(t, n.alerts[3].Resolved)
+}
+
+func TestAlerter_RedactsNodeURLs(t *testing.T) {
+	a, n, _ := newTestAlerter(config.AlertingConfig{MaxConsecutiveFailures: 1})
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{
+		"eth": running(worker.ProgressSnapshot{ConsecutiveFailures: 1,
+			LastError: `Post "https://eth-mainnet.g.alchemy.com/v2/KEY": EOF`}),
+	})
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, `1 consecutive failures, last: Post "https://eth-mainnet.g.alchemy.com/***": EOF`, n.alerts[0].Message)
+}
+
+func TestAlerter_CooldownSuppressesFlapping(t *testing.T) {
+	a, n, now := newTestAlerter(config.AlertingConfig{AllNodesBlacklisted: true, Cooldown: 10 * time.Minute})
+	down := running(worker.ProgressSnapshot{}, rpc.ProviderStatus{Name: "n1"}, rpc.ProviderStatus{Name: "n2"})
+	up := running(worker.ProgressSnapshot{}, rpc.ProviderStatus{Name: "n1", Available: true})
+
+	for range 3 {
+		a.Check(context.Background(), map[string]worker.ChainStatus{"tron": down})
+		*now = now.Add(time.Minute)
+		a.Check(context.Background(), map[string]worker.ChainStatus{"tron": up})
+		*now = now.Add(time.Minute)
+	}
+	require.Len(t, n.alerts, 2, "fired and resolved once within the cooldown")
+	assert.Equal(t, "all 2 nodes blacklisted", n.alerts[0].Message)
+	assert.True(t, n.alerts[1].Resolved)
+
+	*now = now.Add(10 * time.Minute)
+	a.Check(context.Background(), map[string]worker.ChainStatus{"tron": down})
+	assert.Len(t, n.alerts, 3, "fires again once the cooldown passed")
+}
+
+func TestAlerter_Stalled(t *testing.T) {
+	a, n, now := newTestAlerter(config.AlertingConfig{StallAfter: 5 * time.Minute})
+	at := func(height uint64) map[string]worker.ChainStatus {
+		return map[string]worker.ChainStatus{"eth": running(worker.ProgressSnapshot{IndexedHeight: height})}
+	}
+
+	a.Check(context.Background(), at(100))
+	*now = now.Add(4 * time.Minute)
+	a.Check(context.Background(), at(100))
+	assert.Empty(t, n.alerts)
+
+	*now = now.Add(time.Minute)
+	a.Check(context.Background(), at(100))
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, ConditionStalled, n.alerts[0].Condition)
+	assert.Equal(t, "no block indexed for 5m0s, at height 100", n.alerts[0].Message)
+
+	a.Check(context.Background(), at(101))
+	require.Len(t, n.alerts, 2)
+	assert.True(t, n.alerts[1].Resolved)
+}
+
+func TestAlerter_DeepReorg(t *testing.T) {
+	a, n, now := newTestAlerter(config.AlertingConfig{DeepReorg: true, Cooldown: 10 * time.Minute})
+	reorgAt := *now
+	status := map[string]worker.ChainStatus{
+		"btc": running(worker.ProgressSnapshot{DeepReorgs: 1, LastDeepReorgAt: &reorgAt}),
+	}
+
+	a.Check(context.Background(), status)
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, ConditionDeepReorg, n.alerts[0].Condition)
+
+	*now = now.Add(10 * time.Minute)
+	a.Check(context.Background(), status)
+	require.Len(t, n.alerts, 2)
+	assert.True(t, n.alerts[1].Resolved)
+}
+
+func TestAlerter_ReorgHalted(t *testing.T) {
+	a, n, now := newTestAlerter(config.AlertingConfig{DeepReorg: true, Cooldown: 10 * time.Minute})
+	halted := running(worker.ProgressSnapshot{IndexedHeight: 105})
+	halted.State = worker.ChainStateHalted
+	halted.ReorgHalt = &blockstore.ReorgHalt{Block: 105, RollbackWindow: 3}
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{"btc": halted})
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, ConditionReorgHalted, n.alerts[0].Condition)
+	assert.Contains(t, n.alerts[0].Message, "halted at block 105")
+
+	*now = now.Add(time.Hour)
+	a.Check(context.Background(), map[string]worker.ChainStatus{"btc": halted})
+	require.Len(t, n.alerts, 1, "still halted")
+
+	a.Check(context.Background(), map[string]worker.ChainStatus{
+		"btc": running(worker.ProgressSnapshot{IndexedHeight: 106}),
+	})
+	require.Len(t, n.alerts, 2)
+	assert.True(t, n.alerts[1].Resolved)
+}
+
+func TestAlerter_RetriesFailedNotification(t *testing.T) {
+	a, n, _ := newTestAlerter(config.AlertingConfig{MaxLag: 1})
+	status := map[string]worker.ChainStatus{"eth": running(worker.ProgressSnapshot{Lag: 5})}
+
+	n.err = errors.New("webhook down")
+	a.Check(context.Background(), status)
+	n.err = nil
+	a.Check(context.Background(), status)
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, ConditionLag, n.alerts[0].Condition)
+}
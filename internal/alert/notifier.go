@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// WebhookNotifier posts each alert as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	return post(ctx, n.client, n.url, alert)
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook.
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{url: webhookURL, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	icon := ":rotating_light:"
+	if alert.Resolved {
+		icon = ":white_check_mark:"
+	}
+	text := fmt.Sprintf("%s *%s* %s: %s", icon, alert.Chain, alert.Condition, alert.Message)
+	return post(ctx, n.client, n.url, map[string]string{"text": text})
+}
+
+// post sends body as JSON to target, failing on any status but 2xx. The URL
+// is left out of errors since webhook URLs carry their credentials.
+func post(ctx context.Context, client *http.Client, target string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return errors.New("create alert request: invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("post alert: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post alert: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureServer(t *testing.T, status int) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestSlackNotifier(t *testing.T) {
+	srv, bodies := captureServer(t, http.StatusOK)
+	n := NewSlackNotifier(srv.URL)
+
+	require.NoError(t, n.Notify(context.Background(), Alert{Chain: "btc", Condition: ConditionLag, Message: "lag 150 exceeds 100"}))
+	require.NoError(t, n.Notify(context.Background(), Alert{Chain: "btc", Condition: ConditionLag, Resolved: true, Message: "resolved"}))
+
+	require.Len(t, *bodies, 2)
+	assert.Equal(t, ":rotating_light: *btc* lag: lag 150 exceeds 100", (*bodies)[0]["text"])
+	assert.Equal(t, ":white_check_mark: *btc* lag: resolved", (*bodies)[1]["text"])
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	srv, bodies := captureServer(t, http.StatusNoContent)
+	at := time.Unix(1_700_000_000, 0).UTC()
+
+	err := NewWebhookNotifier(srv.URL).Notify(context.Background(),
+		Alert{Chain: "eth", Condition: ConditionStalled, Message: "stalled", At: at})
+	require.NoError(t, err)
+
+	require.Len(t, *bodies, 1)
+	assert.Equal(t, map[string]any{
+		"chain": "eth", "condition": "stalled", "resolved": false, "message": "stalled", "at": "2023-11-14T22:13:20Z",
+	}, (*bodies)[0])
+}
+
+func TestWebhookNotifier_ErrorsHideURL(t *testing.T) {
+	srv, _ := captureServer(t, http.StatusInternalServerError)
+	err := NewWebhookNotifier(srv.URL+"/secret-token").Notify(context.Background(), Alert{})
+	require.Error(t, err)
+	assert.Equal(t, "post alert: status 500", err.Error())
+
+	srv.Close()
+	err = NewWebhookNotifier(srv.URL+"/secret-token").Notify(context.Background(), Alert{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
@@ -7,7 +7,6 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
@@ -37,6 +36,7 @@ type AptosIndexer struct {
 	config      config.ChainConfig
 	failover    *rpc.Failover[aptos.AptosAPI]
 	pubkeyStore PubkeyStore
+	fetcher     blockFetcher
 }
 
 func NewAptosIndexer(
@@ -79,7 +79,14 @@ func (a *AptosIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error)
 	return latest, err
 }
 
+// GetBlock fetches the block at number through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (a *AptosIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	_, block, err := a.fetchScheduler().fetchOne(ctx, number)
+	return block, err
+}
+
+func (a *AptosIndexer) fetchBlock(ctx context.Context, number uint64) (*types.Block, error) {
 	var blockData *aptos.BlockResponse
 	err := a.failover.ExecuteWithRetry(ctx, func(client aptos.AptosAPI) error {
 		b, err := client.GetBlockByHeight(ctx, number, true)
@@ -109,88 +116,45 @@ func (a *AptosIndexer) GetBlocks(
 		nums = append(nums, n)
 	}
 
-	workers := 1
-	if isParallel {
-		workers = a.config.Throttle.Concurrency
-	}
-	return a.getBlocks(ctx, nums, workers)
+	return a.getBlocks(ctx, nums)
 }
 
 func (a *AptosIndexer) GetBlocksByNumbers(
 	ctx context.Context,
 	blockNumbers []uint64,
 ) ([]BlockResult, error) {
-	return a.getBlocks(ctx, blockNumbers, a.config.Throttle.Concurrency)
+	return a.getBlocks(ctx, blockNumbers)
 }
 
+// getBlocks fetches blockNumbers through the indexer's fetch scheduler, by
+// the priority ctx carries.
 func (a *AptosIndexer) getBlocks(
 	ctx context.Context,
 	blockNumbers []uint64,
-	workers int,
 ) ([]BlockResult, error) {
 	if len(blockNumbers) == 0 {
 		return nil, nil
 	}
-	if workers <= 0 {
-		workers = 1
-	}
-	workers = min(workers, len(blockNumbers))
-
-	results := make([]BlockResult, len(blockNumbers))
-
-	type job struct {
-		index int
-		num   uint64
-	}
-
-	jobs := make(chan job, workers*2)
-	var wg sync.WaitGroup
-
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := range jobs {
-				block, err := a.GetBlock(ctx, j.num)
-				results[j.index] = BlockResult{
-					Number: j.num,
-					Block:  block,
-				}
-				if err != nil {
-					results[j.index].Error = &Error{
-						ErrorType: classifyAptosError(err),
-						Message:   err.Error(),
-					}
-				}
-			}
-		}()
-	}
-
-	go func() {
-		defer close(jobs)
-		for i, num := range blockNumbers {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- job{index: i, num: num}:
-			}
-		}
-	}()
-
-	wg.Wait()
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+	results, err := fetchAll(ctx, a.fetchScheduler(), blockNumbers, func(err error) *Error {
+		e := NewError(err)
+		e.ErrorType = classifyAptosError(err)
+		return e
+	})
+	if err != nil {
+		return nil, err
 	}
+	return results, firstBlockError(results)
+}
 
-	var firstErr error
-	for _, res := range results {
-		if res.Error != nil {
-			firstErr = fmt.Errorf("block %d: %s", res.Number, res.Error.Message)
-			break
-		}
-	}
+// fetchScheduler returns the pool every block fetch of the indexer runs on,
+// Throttle.Concurrency at a time.
+func (a *AptosIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
+	return a.fetcher.scheduler(a.config.Throttle.Concurrency, a.fetchBlock)
+}
 
-	return results, firstErr
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (a *AptosIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return a.fetchScheduler().stats()
 }
 
 func (a *AptosIndexer) IsHealthy() bool {
@@ -200,6 +164,21 @@ func (a *AptosIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (a *AptosIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return a.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (a *AptosIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	a.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (a *AptosIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return a.failover.ExpectNetwork(ctx, want)
+}
+
 func (a *AptosIndexer) convertBlock(
 	blockData *aptos.BlockResponse,
 	fallbackHeight uint64,
@@ -233,6 +212,7 @@ func (a *AptosIndexer) convertBlock(
 		ParentHash:   "",
 		Timestamp:    blockTs,
 		Transactions: txs,
+		InternalCode: a.config.InternalCode,
 	}, nil
 }
 
@@ -272,6 +252,7 @@ func (a *AptosIndexer) extractTransfer(
 	return types.Transaction{
 		TxHash:       tx.Hash,
 		NetworkId:    a.config.NetworkId,
+		InternalCode: a.config.InternalCode,
 		BlockNumber:  blockHeight,
 		FromAddress:  fromAddress,
 		ToAddress:    toAddress,
@@ -309,6 +290,9 @@ func classifyAptosError(err error) ErrorType {
 	if err == nil {
 		return ErrorTypeUnknown
 	}
+	if errType := ErrorTypeOf(err); errType != ErrorTypeUnknown {
+		return errType
+	}
 	if aptos.IsNotFoundError(err) {
 		return ErrorTypeBlockNotFound
 	}
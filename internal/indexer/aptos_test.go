@@ -25,7 +25,8 @@ func TestConvertAptosBlock_ParsesNativeTransferAndConvertsFeeToNative(t *testing
 	idx := &AptosIndexer{
 		chainName: "aptos_mainnet",
 		config: config.ChainConfig{
-			NetworkId: "aptos_mainnet",
+			NetworkId:    "aptos_mainnet",
+			InternalCode: "APTOS_MAINNET",
 		},
 	}
 
@@ -61,6 +62,8 @@ func TestConvertAptosBlock_ParsesNativeTransferAndConvertsFeeToNative(t *testing
 	tx := block.Transactions[0]
 	assert.Equal(t, "0xtxhash", tx.TxHash)
 	assert.Equal(t, "aptos_mainnet", tx.NetworkId)
+	assert.Equal(t, "APTOS_MAINNET", tx.InternalCode)
+	assert.Equal(t, "APTOS_MAINNET", block.InternalCode)
 	assert.Equal(t, uint64(10), tx.BlockNumber)
 	assert.Equal(t, "0xa11ce", tx.FromAddress)
 	assert.Equal(t, "0xb0b", tx.ToAddress)
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// AssetFilterUpdater is implemented by indexers applying
+// ChainConfig.Assets, so a reloaded config reaches a running chain.
+type AssetFilterUpdater interface {
+	UpdateAssetFilter(cfg config.AssetFilterConfig)
+}
+
+// assetFilter decides which assets' transfers a chain extracts, see
+// config.AssetFilterConfig. Native transfers, with no asset address, always
+// pass. It is safe for concurrent use and replaced in place by update. A
+// nil *assetFilter passes everything.
+type assetFilter struct {
+	networkType enum.NetworkType
+	rules       atomic.Pointer[assetRules]
+}
+
+type assetRules struct {
+	allow map[string]struct{} // nil allows every asset
+	deny  map[string]struct{}
+}
+
+func newAssetFilter(networkType enum.NetworkType, cfg config.AssetFilterConfig) *assetFilter {
+	f := &assetFilter{networkType: networkType}
+	f.update(cfg)
+	return f
+}
+
+// update replaces the filter's rules with cfg's. Entries that do not
+// normalize, which config validation rejects, are ignored.
+func (f *assetFilter) update(cfg config.AssetFilterConfig) {
+	if cfg.IsZero() {
+		f.rules.Store(nil)
+		return
+	}
+	rules := &assetRules{deny: f.assetSet(cfg.Deny)}
+	if len(cfg.Allow) > 0 {
+		rules.allow = f.assetSet(cfg.Allow)
+	}
+	f.rules.Store(rules)
+}
+
+func (f *assetFilter) assetSet(entries []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		if asset, err := config.NormalizeAsset(f.networkType, entry); err == nil {
+			set[asset] = struct{}{}
+		}
+	}
+	return set
+}
+
+// allowList returns the assets an allow list limits transfers to, nil when
+// there is none.
+func (f *assetFilter) allowList() []string {
+	if f == nil {
+		return nil
+	}
+	rules := f.rules.Load()
+	if rules == nil || rules.allow == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(rules.allow))
+}
+
+// allows reports whether transfers of asset are extracted.
+func (f *assetFilter) allows(asset string) bool {
+	if f == nil || asset == "" {
+		return true
+	}
+	rules := f.rules.Load()
+	if rules == nil {
+		return true
+	}
+	asset = addressutil.Canonical(f.networkType, asset)
+	if _, denied := rules.deny[asset]; denied {
+		return false
+	}
+	if rules.allow == nil {
+		return true
+	}
+	_, allowed := rules.allow[asset]
+	return allowed
+}
+
+// filter returns the transfers of txs whose asset is allowed, reusing its
+// backing array.
+func (f *assetFilter) filter(txs []types.Transaction) []types.Transaction {
+	if f == nil || f.rules.Load() == nil {
+		return txs
+	}
+	kept := txs[:0]
+	for _, tx := range txs {
+		if f.allows(tx.AssetAddress) {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetFilter(t *testing.T) {
+	const (
+		usdt = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+		usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		dai  = "0x6b175474e89094c44da98b954eedeac495271d0f"
+	)
+	txs := []types.Transaction{
+		{TransferIndex: "native"},
+		{TransferIndex: "usdt", AssetAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		{TransferIndex: "usdc", AssetAddress: usdc},
+		{TransferIndex: "dai", AssetAddress: dai},
+	}
+	kept := func(f *assetFilter) []string {
+		var out []string
+		for _, tx := range f.filter(append([]types.Transaction(nil), txs...)) {
+			out = append(out, tx.TransferIndex)
+		}
+		return out
+	}
+
+	var none *assetFilter
+	assert.Equal(t, []string{"native", "usdt", "usdc", "dai"}, kept(none))
+
+	f := newAssetFilter(enum.NetworkTypeEVM, config.AssetFilterConfig{})
+	assert.Equal(t, []string{"native", "usdt", "usdc", "dai"}, kept(f))
+
+	f.update(config.AssetFilterConfig{Allow: []string{usdt, usdc}})
+	assert.Equal(t, []string{"native", "usdt", "usdc"}, kept(f), "entries match whatever their case")
+
+	f.update(config.AssetFilterConfig{Allow: []string{usdt, usdc}, Deny: []string{usdc}})
+	assert.Equal(t, []string{"native", "usdt"}, kept(f))
+
+	f.update(config.AssetFilterConfig{Deny: []string{dai}})
+	assert.Equal(t, []string{"native", "usdt", "usdc"}, kept(f))
+}
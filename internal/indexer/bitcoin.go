@@ -2,40 +2,147 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
 	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// bitcoinTipRaceWindow is how close to the last observed tip a not-found
+// block is treated as not yet propagated rather than as a node failure.
+const bitcoinTipRaceWindow = 2
+
 type BitcoinIndexer struct {
+	chainLog
 	chainName   string
 	config      config.ChainConfig
 	failover    *rpc.Failover[bitcoin.BitcoinAPI]
 	pubkeyStore PubkeyStore
+
+	// latestHeight is the last tip height returned by GetLatestBlockNumber.
+	latestHeight atomic.Uint64
+
+	txIndexWarning sync.Once
+
+	// recentTxs remembers which recent block each txid is in, for
+	// blockhash-hinted prevout lookups on nodes without txindex.
+	recentTxs *recentTxBlocks
+	// watchedOuts remembers the outputs paying watched addresses, so
+	// inputs spending them are looked up first, see prevoutLookups.
+	watchedOuts    *watchedOutputs
+	lookupCounters prevoutLookupCounters
+
+	extractor TransferExtractor
+	// decorated is set when decorators wrap the extractor.
+	decorated bool
+
+	outputStats outputStatsTotals
+
+	// hrps overrides the segwit HRPs of config once a reloaded config set
+	// them, see UpdateSegwitHRPs.
+	hrps atomic.Pointer[[]string]
+
+	// channelStore remembers channel funding outputs, see tagChannels.
+	channelStore ChannelStore
+
+	// responseCache is shared by the clients of the indexer's nodes; nil
+	// when disabled.
+	responseCache *bitcoin.ImmutableCache
+
+	// scanFailover holds the nodes allowed heavy scans; nil if none is.
+	scanFailover *rpc.Failover[bitcoin.BitcoinAPI]
+
+	fetcherOnce sync.Once
+	fetcher     *btcFetchScheduler
 }
 
+// NewBitcoinIndexer creates a Bitcoin indexer. decorators are stacked on
+// DefaultTransferExtractor in the given order, see TransferDecorator.
 func NewBitcoinIndexer(
 	chainName string,
 	cfg config.ChainConfig,
 	failover *rpc.Failover[bitcoin.BitcoinAPI],
 	pubkeyStore PubkeyStore,
+	decorators ...TransferDecorator,
 ) *BitcoinIndexer {
-	return &BitcoinIndexer{
+	b := &BitcoinIndexer{
+		chainLog:    chainLog{ChainLogger(chainName, cfg)},
 		chainName:   chainName,
 		config:      cfg,
 		failover:    failover,
 		pubkeyStore: pubkeyStore,
+		recentTxs:   newRecentTxBlocks(recentTxBlocksCapacity),
+		watchedOuts: newWatchedOutputs(watchedOutputsCapacity),
 	}
+	b.extractor = buildTransferExtractor(b.DefaultTransferExtractor(), decorators)
+	b.decorated = len(decorators) > 0
+	return b
+}
+
+// SegwitHRPUpdater is implemented by indexers validating addresses with
+// the chain's ChainConfig.Bech32HRP, so a reloaded config reaches a running
+// chain.
+type SegwitHRPUpdater interface {
+	UpdateSegwitHRPs(cfg config.ChainConfig)
+}
+
+// UpdateSegwitHRPs applies a reloaded ChainConfig.Bech32HRP.
+func (b *BitcoinIndexer) UpdateSegwitHRPs(cfg config.ChainConfig) {
+	hrps := cfg.SegwitHRPs()
+	b.hrps.Store(&hrps)
+}
+
+// segwitHRPs returns the HRPs the chain's segwit addresses use besides
+// Bitcoin's, see config.ChainConfig.SegwitHRPs.
+func (b *BitcoinIndexer) segwitHRPs() []string {
+	if hrps := b.hrps.Load(); hrps != nil {
+		return *hrps
+	}
+	return b.config.SegwitHRPs()
+}
+
+// transferExtractor returns the configured extractor chain, or the default
+// one for indexers not built by NewBitcoinIndexer.
+func (b *BitcoinIndexer) transferExtractor() TransferExtractor {
+	if b.extractor == nil {
+		return b.DefaultTransferExtractor()
+	}
+	return b.extractor
+}
+
+// btcFetchScheduler fetches Bitcoin blocks by height.
+type btcFetchScheduler = fetchScheduler[uint64, *bitcoin.Block]
+
+// fetchScheduler returns the pool every block fetch of the indexer runs on,
+// built on first use so indexers not built by NewBitcoinIndexer have one.
+func (b *BitcoinIndexer) fetchScheduler() *btcFetchScheduler {
+	b.fetcherOnce.Do(func() {
+		b.fetcher = newFetchScheduler(b.config.Throttle.Concurrency, b.fetchBlock)
+	})
+	return b.fetcher
+}
+
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (b *BitcoinIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return b.fetchScheduler().stats()
 }
 
 // satoshisFromFloat converts a BTC float64 value to satoshis using string-based decimal
@@ -69,205 +176,679 @@ func (b *BitcoinIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, erro
 		latest = n
 		return err
 	})
+	if err == nil {
+		b.latestHeight.Store(latest)
+	}
 	return latest, err
 }
 
+// isNearTip reports whether number is within bitcoinTipRaceWindow of the
+// last observed tip, i.e. a not-found for it is likely a propagation race
+// between nodes rather than a broken node.
+func (b *BitcoinIndexer) isNearTip(number uint64) bool {
+	latest := b.latestHeight.Load()
+	return latest > 0 && number+bitcoinTipRaceWindow > latest
+}
+
+// GetBlock fetches and converts a block. The hash lookup, block fetch and
+// prevout enrichment share one failover session so they hit the same node.
+// The fetch waits its turn on the indexer's fetch pool like any other.
 func (b *BitcoinIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	ctx, btcBlock, err := b.fetchScheduler().fetchOne(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return b.convertBlockWithPrevoutResolution(ctx, btcBlock)
+}
+
+// fetchBlock fetches the raw block at number in a new failover session and
+// returns the session's context for converting it, see GetBlock.
+func (b *BitcoinIndexer) fetchBlock(ctx context.Context, number uint64) (context.Context, *bitcoin.Block, error) {
+	sessionCtx := rpc.WithSession(ctx)
+	ctx, span := tracing.Start(sessionCtx, "bitcoin.fetch_block", attribute.Int64("block.number", int64(number)))
 	var btcBlock *bitcoin.Block
 
 	err := b.failover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
 		// Verbosity 3 = full transaction details with prevout data included
 		block, err := c.GetBlockByHeight(ctx, number, 3)
+		if err != nil && ctx.Err() == nil && isBlockTooLargeError(err) {
+			b.logger().Warn("Full block fetch failed, falling back to per-transaction fetch",
+				"block", number, "error", err)
+			span.AddEvent("fallback to per-transaction fetch")
+			block, err = b.getBlockByTxids(ctx, c, number)
+		}
 		if err != nil {
 			return err
 		}
+		if err := b.verifyBlock(number, block); err != nil {
+			return err
+		}
 		btcBlock = block
 		return nil
 	})
+	tracing.End(span, err)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get block %d: %w", number, err)
+		if errors.Is(err, rpc.ErrNotFound) && b.isNearTip(number) {
+			return nil, nil, fmt.Errorf("block %d: %w: %w", number, ErrBlockNotReady, err)
+		}
+		return nil, nil, fmt.Errorf("failed to get block %d: %w", number, err)
 	}
+	return sessionCtx, btcBlock, nil
+}
 
-	return b.convertBlockWithPrevoutResolution(ctx, btcBlock)
+// btcBlockMetaCanonical is the block metadata key set by GetBlockByHash to
+// whether the block is the main chain's block at its height.
+const btcBlockMetaCanonical = "canonical"
+
+// FindBlockByTime returns the highest block whose median time past is at
+// most target, by binary search over the main chain. Block times may go
+// backwards, median times never do; as median time lags block time by
+// about an hour, the block returned may be followed by a few whose own time
+// is still before target.
+func (b *BitcoinIndexer) FindBlockByTime(ctx context.Context, target time.Time) (uint64, error) {
+	latest, err := b.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	return searchBlockByTime(ctx, latest, target.Unix(), func(ctx context.Context, number uint64) (uint64, error) {
+		var medianTime uint64
+		err := b.failover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
+			hash, err := c.GetBlockHash(ctx, number)
+			if err != nil {
+				return err
+			}
+			header, err := c.GetBlockHeader(ctx, hash)
+			if err != nil {
+				return err
+			}
+			medianTime = header.MedianTime
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get header of block %d: %w", number, err)
+		}
+		return medianTime, nil
+	})
+}
+
+// GetBlockByHash fetches and converts the block with hash, which need not
+// be on the main chain: reorg investigations look at the blocks a reorg
+// replaced, which getblockhash no longer returns. The block's canonical
+// metadata is false when the main chain holds another block at its height.
+// The fetch, canonical check and prevout enrichment share one failover
+// session, like GetBlock's.
+func (b *BitcoinIndexer) GetBlockByHash(ctx context.Context, hash string) (*types.Block, error) {
+	sessionCtx := rpc.WithSession(ctx)
+	ctx, span := tracing.Start(sessionCtx, "bitcoin.fetch_block", attribute.String("block.hash", hash))
+	var btcBlock *bitcoin.Block
+	var canonical bool
+
+	err := b.failover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
+		block, err := c.GetBlock(ctx, hash, 3)
+		if err != nil {
+			return err
+		}
+		if err := b.verifyBlock(block.Height, block); err != nil {
+			return err
+		}
+		mainHash, err := c.GetBlockHash(ctx, block.Height)
+		switch {
+		case errors.Is(err, rpc.ErrNotFound):
+			// A stale block above the main chain's tip.
+			canonical = false
+		case err != nil:
+			return err
+		default:
+			canonical = block.Confirmations >= 0 && mainHash == block.Hash
+		}
+		btcBlock = block
+		return nil
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %s: %w", hash, err)
+	}
+
+	block, err := b.convertBlockWithPrevoutResolution(sessionCtx, btcBlock)
+	if err != nil {
+		return nil, err
+	}
+	if !canonical {
+		b.logger().Info("Fetched block is not on the main chain", "block", btcBlock.Height, "hash", hash)
+	}
+	block.SetMetadata(btcBlockMetaCanonical, canonical)
+	return block, nil
+}
+
+// getBlockByTxids is the degraded block fetch for nodes that cannot serve a
+// large block in one verbose response: it lists the txids with getblock
+// verbosity=1 and fetches each transaction separately, bounded by
+// Throttle.Concurrency, keeping Core's transaction order.
+func (b *BitcoinIndexer) getBlockByTxids(ctx context.Context, c bitcoin.BitcoinAPI, number uint64) (_ *bitcoin.Block, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.getblock_by_txids", attribute.Int64("block.number", int64(number)))
+	defer func() { tracing.End(span, err) }()
+
+	hash, err := c.GetBlockHash(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block hash for height %d: %w", number, err)
+	}
+	block, txids, err := c.GetBlockTxids(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get txids for block %s: %w", hash, err)
+	}
+	txs, err := c.GetBlockTransactions(ctx, hash, txids, max(b.config.Throttle.Concurrency, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions for block %s: %w", hash, err)
+	}
+	block.Tx = txs
+	block.Height = number
+	return block, nil
+}
+
+// isBlockTooLargeError reports whether a verbose getblock failure looks like
+// the node, or a proxy in front of it, giving up on the response size:
+// timeouts, payload-too-large and gateway errors, or a truncated body; or
+// the response exceeding client.max_response_bytes.
+func isBlockTooLargeError(err error) bool {
+	if errors.Is(err, rpc.ErrTimeout) || errors.Is(err, rpc.ErrResponseTooLarge) {
+		return true
+	}
+	var httpErr *rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusRequestEntityTooLarge, http.StatusBadGateway, http.StatusServiceUnavailable:
+			return true
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"too large", "exceeds", "failed to read response body", "unexpected eof", "unexpected end of json"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropRepeatedTxs removes the transactions of btcBlock whose txid already
+// appeared earlier in it, and returns the txids it removed. A valid block
+// never repeats a txid, but a misbehaving node or proxy can return one that
+// does, which would emit its transfers twice. Txids repeated across blocks,
+// like the BIP30 duplicate coinbases of blocks 91842 and 91880, are not
+// affected.
+func dropRepeatedTxs(btcBlock *bitcoin.Block) (dropped []string) {
+	seen := make(map[string]bool, len(btcBlock.Tx))
+	kept := btcBlock.Tx[:0]
+	for _, tx := range btcBlock.Tx {
+		if tx.TxID != "" && seen[tx.TxID] {
+			dropped = append(dropped, tx.TxID)
+			continue
+		}
+		seen[tx.TxID] = true
+		kept = append(kept, tx)
+	}
+	clear(btcBlock.Tx[len(kept):])
+	btcBlock.Tx = kept
+	return dropped
 }
 
 // convertBlockWithPrevoutResolution converts a block and resolves prevout data
-// for transactions that lack it. Prevout resolution runs in parallel using a
-// pool sized to config.Throttle.Concurrency.
-func (b *BitcoinIndexer) convertBlockWithPrevoutResolution(ctx context.Context, btcBlock *bitcoin.Block) (*types.Block, error) {
+// for transactions that lack it, see enrichPrevouts. Prevout resolution runs
+// in parallel using a pool sized to config.Throttle.Concurrency.
+func (b *BitcoinIndexer) convertBlockWithPrevoutResolution(ctx context.Context, btcBlock *bitcoin.Block) (_ *types.Block, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.process_block",
+		attribute.Int64("block.number", int64(btcBlock.Height)), attribute.Int("txs", len(btcBlock.Tx)))
+	defer func() { tracing.End(span, err) }()
+
 	latestBlock := btcBlock.Height
 	if btcBlock.Confirmations > 0 {
-		latestBlock = btcBlock.Height + btcBlock.Confirmations - 1
+		latestBlock = btcBlock.Height + uint64(btcBlock.Confirmations) - 1
+	}
+
+	repeated := dropRepeatedTxs(btcBlock)
+	if len(repeated) > 0 {
+		b.logger().Warn("Block repeats transactions, keeping the first of each",
+			"block", btcBlock.Height, "hash", btcBlock.Hash, "dropped", len(repeated))
+	}
+
+	// Stage 1: Remember the block's txids and collect indices of
+	// transactions missing prevout data.
+	txids := make([]string, len(btcBlock.Tx))
+	for i := range btcBlock.Tx {
+		txids[i] = btcBlock.Tx[i].TxID
 	}
+	b.recentTxs.add(btcBlock.Hash, txids)
 
-	// Stage 1: Collect indices of transactions missing prevout data.
 	var needsResolution []int
 	for i := range btcBlock.Tx {
 		tx := &btcBlock.Tx[i]
 		if tx.IsCoinbase() {
 			continue
 		}
-		needsAny := false
-		for _, vin := range tx.Vin {
-			if vin.TxID != "" && vin.PrevOut == nil {
-				needsAny = true
-				break
-			}
-		}
-		if needsAny {
+		if missingPrevouts(tx) > 0 {
 			needsResolution = append(needsResolution, i)
 		}
 	}
 
-	// Stage 2: Resolve prevout data in parallel.
+	// Stage 2: Match the addresses known before enrichment, so transactions
+	// touching watched ones are looked up first, and within budget.
+	b.recordWatchedOutputs(btcBlock)
+	needsResolution, skipped := b.prevoutLookups(btcBlock, needsResolution)
+	b.lookupCounters.lookedUp.Add(uint64(len(needsResolution)))
+	b.lookupCounters.skipped.Add(uint64(skipped))
+	if skipped > 0 {
+		b.logger().Warn("Prevout lookup budget spent, unwatched transactions keep unknown fees",
+			"block", btcBlock.Height, "budget", b.config.PrevoutLookups, "skipped", skipped)
+	}
+
+	// Stage 3: Resolve prevout data in parallel.
 	if len(needsResolution) > 0 {
-		concurrency := b.config.Throttle.Concurrency
-		if concurrency < 1 {
-			concurrency = 1
-		}
-
-		type job struct{ txIdx int }
-		jobs := make(chan job, len(needsResolution))
-		for _, idx := range needsResolution {
-			jobs <- job{txIdx: idx}
-		}
-		close(jobs)
-
-		var wg sync.WaitGroup
-		for i := 0; i < concurrency; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				provider, err := b.failover.GetBestProvider()
-				if err != nil || provider == nil {
-					for range jobs {
-					}
-					return
-				}
-				client, ok := provider.Client.(bitcoin.BitcoinAPI)
-				if !ok {
-					for range jobs {
-					}
-					return
-				}
-				for j := range jobs {
-					tx := &btcBlock.Tx[j.txIdx]
-					resolved, err := client.GetTransactionWithPrevouts(ctx, tx.TxID)
-					if err != nil {
-						continue // silently skip — same behaviour as the previous sequential code
-					}
-					for k := range tx.Vin {
-						if k < len(resolved.Vin) && resolved.Vin[k].PrevOut != nil {
-							tx.Vin[k].PrevOut = resolved.Vin[k].PrevOut
-						}
-					}
-				}
-			}()
+		if err := b.enrichPrevouts(ctx, btcBlock, needsResolution); err != nil {
+			return nil, fmt.Errorf("block %d: %w", btcBlock.Height, err)
 		}
-		wg.Wait()
 	}
 
-	// Stage 3: Extract transfers and UTXO events.
-	var allTransfers []types.Transaction
-	var allUTXOEvents []types.UTXOEvent
+	if err := checkNegativeValues(btcBlock); err != nil {
+		return nil, err
+	}
 
+	// Stage 4: Extract transfers and UTXO events.
+	_, extractSpan := tracing.Start(ctx, "bitcoin.extract")
+	allTransfers := b.transferExtractor().Extract(btcBlock, b.chainContext(latestBlock))
+	b.tagChannels(ctx, btcBlock, allTransfers)
+
+	var allUTXOEvents []types.UTXOEvent
 	for i := range btcBlock.Tx {
 		tx := &btcBlock.Tx[i]
-		if tx.IsCoinbase() {
+		if tx.IsCoinbase() || !b.config.IndexUTXO {
 			continue
 		}
-
-		transfers := b.extractTransfersFromTx(tx, btcBlock.Hash, btcBlock.Height, btcBlock.Time, latestBlock)
-		allTransfers = append(allTransfers, transfers...)
-
-		if b.config.IndexUTXO {
-			utxoEvent := b.extractUTXOEvent(tx, btcBlock.Height, btcBlock.Hash, btcBlock.Time, latestBlock)
-			if utxoEvent != nil {
-				allUTXOEvents = append(allUTXOEvents, *utxoEvent)
-			}
+		utxoEvent := b.extractUTXOEvent(tx, btcBlock.Height, btcBlock.Hash, btcBlock.Time, latestBlock)
+		if utxoEvent != nil {
+			allUTXOEvents = append(allUTXOEvents, *utxoEvent)
 		}
 	}
+	extractSpan.SetAttributes(attribute.Int("transfers", len(allTransfers)), attribute.Int("utxo_events", len(allUTXOEvents)))
+	extractSpan.End()
 
 	block := &types.Block{
 		Number:       btcBlock.Height,
 		Hash:         btcBlock.Hash,
-		ParentHash:   btcBlock.PreviousBlockHash,
+		ParentHash:   btcParentHash(btcBlock),
 		Timestamp:    btcBlock.Time,
 		Transactions: allTransfers,
+		Size:         uint64(btcBlock.Size),
+		Weight:       uint64(btcBlock.Weight),
+		Difficulty:   btcBlock.Difficulty,
+		TxCount:      btcBlock.NTx,
+		InternalCode: b.config.InternalCode,
+	}
+	if block.TxCount == 0 {
+		block.TxCount = len(btcBlock.Tx)
 	}
 	block.SetMetadata("utxo_events", allUTXOEvents)
 
+	stats := b.blockOutputStats(btcBlock)
+	discrepancies, valueErr := b.verifyValueConservation(btcBlock, allTransfers, &stats)
+	b.outputStats.add(stats)
+	b.logger().Debug("Block output stats",
+		"block", btcBlock.Height,
+		"outputs", stats.Outputs,
+		"script_types", stats.ScriptTypes,
+		"address_types", stats.AddressTypes,
+		"op_return", stats.OpReturn,
+		"nonstandard", stats.Nonstandard,
+		"no_address", stats.NoAddress,
+		"no_address_sats", stats.NoAddressSats,
+		"value_checked", stats.ValueChecked,
+		"value_discrepancies", stats.ValueDiscrepancies)
+	if b.config.StrictMode {
+		if issues := b.strictIssues(btcBlock, repeated, discrepancies); len(issues) > 0 {
+			return nil, &UnparseableTxError{Block: btcBlock.Height, Issues: issues}
+		}
+	}
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	if complete, total := feeCompleteness(btcBlock); complete < total {
+		b.logger().Warn("Block has transactions with unknown fees",
+			"block", btcBlock.Height,
+			"fee_complete", complete, "txs", total,
+			"ratio", float64(complete)/float64(total))
+	} else {
+		b.logger().Debug("Block fee completeness",
+			"block", btcBlock.Height, "txs", total, "ratio", 1.0)
+		block.SetMetadata(btcBlockMetaFees, blockFees(btcBlock))
+	}
+
 	return block, nil
 }
 
-func (b *BitcoinIndexer) GetBlocks(
-	ctx context.Context,
-	from, to uint64,
-	isParallel bool,
-) ([]BlockResult, error) {
-	blockNums := make([]uint64, 0, to-from+1)
-	for n := from; n <= to; n++ {
-		blockNums = append(blockNums, n)
+// enrichPrevouts resolves missing prevouts for btcBlock.Tx[txIdxs], starting
+// with the session's provider. While more than MaxMissingPrevouts of the
+// inputs stay unresolved it retries the remaining ones on each other
+// available provider in turn; if none gets below the threshold the
+// *bitcoin.PartialEnrichmentError is returned. Below it, the block proceeds
+// with partial data. Outside strict mode lookups are optional work, which a
+// request budget running low holds back and then refuses: the block then
+// proceeds with the prevouts resolved so far.
+func (b *BitcoinIndexer) enrichPrevouts(ctx context.Context, btcBlock *bitcoin.Block, txIdxs []int) (err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.enrich_prevouts", attribute.Int("txs", len(txIdxs)))
+	defer func() { tracing.End(span, err) }()
+	if !b.config.StrictMode {
+		ctx = ratelimiter.WithOptional(ctx)
 	}
 
-	return b.GetBlocksByNumbers(ctx, blockNums)
+	maxMissing := b.config.MaxMissingPrevoutRatio()
+
+	providers, err := b.prevoutProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	var partial *bitcoin.PartialEnrichmentError
+	for _, provider := range providers {
+		client, ok := provider.Client.(bitcoin.BitcoinAPI)
+		if !ok {
+			return fmt.Errorf("provider %s: client %T is not a Bitcoin client", provider.Name, provider.Client)
+		}
+		passCtx, passSpan := tracing.Start(ctx, "bitcoin.resolve_prevouts", attribute.String("provider", provider.Name))
+		partial = b.resolveBlockPrevouts(passCtx, client, btcBlock, txIdxs)
+		if partial != nil {
+			passSpan.SetAttributes(attribute.Int("missing", partial.Missing), attribute.Int("inputs", partial.Total))
+		}
+		passSpan.End()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if partial == nil || partial.Ratio() <= maxMissing {
+			return nil
+		}
+		// The budget is the limiter group's, shared by every provider.
+		if errors.Is(partial.Err, rpc.ErrOverBudget) {
+			b.logger().Warn("Request budget spent, prevouts left unresolved",
+				"block", btcBlock.Height, "missing", partial.Missing, "inputs", partial.Total)
+			return nil
+		}
+		b.logger().Warn("Prevout enrichment incomplete",
+			"block", btcBlock.Height, "provider", provider.Name,
+			"missing", partial.Missing, "inputs", partial.Total, "error", partial.Err)
+	}
+
+	// Retrying cannot help when no node is able to look prevouts up.
+	if !slices.ContainsFunc(providers, canLookUpPrevouts) {
+		b.warnNoTxIndex()
+		return nil
+	}
+	return partial
 }
 
-func (b *BitcoinIndexer) GetBlocksByNumbers(
-	ctx context.Context,
-	blockNumbers []uint64,
-) ([]BlockResult, error) {
-	if len(blockNumbers) == 0 {
-		return nil, nil
+// prevoutProviders returns the providers to resolve prevouts on, the
+// session's first, with nodes known to run without txindex moved last:
+// those can only resolve prevouts from blocks in recentTxs. When no node
+// has txindex it warns once.
+func (b *BitcoinIndexer) prevoutProviders(ctx context.Context) ([]*rpc.Provider, error) {
+	first, err := b.failover.GetSessionProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no available provider: %w", err)
 	}
+	var capable, limited []*rpc.Provider
+	for _, p := range append([]*rpc.Provider{first}, b.failover.GetAvailableProviders()...) {
+		if slices.Contains(capable, p) || slices.Contains(limited, p) {
+			continue
+		}
+		if canLookUpPrevouts(p) {
+			capable = append(capable, p)
+		} else {
+			limited = append(limited, p)
+		}
+	}
+	if len(capable) == 0 {
+		b.warnNoTxIndex()
+	}
+	return append(capable, limited...), nil
+}
+
+// canLookUpPrevouts reports whether p is a Bitcoin node not known to lack
+// txindex.
+func canLookUpPrevouts(p *rpc.Provider) bool {
+	client, ok := p.Client.(bitcoin.BitcoinAPI)
+	return ok && client.TxIndex() != bitcoin.TxIndexDisabled
+}
 
-	// Bitcoin RPC doesn't support batching like Ethereum
-	// Process blocks sequentially with concurrency control
-	results := make([]BlockResult, len(blockNumbers))
+// warnNoTxIndex tells the operator, once, that fees cannot be computed.
+func (b *BitcoinIndexer) warnNoTxIndex() {
+	b.txIndexWarning.Do(func() {
+		b.logger().Warn("No Bitcoin node can look up arbitrary prevouts: only those created in the " +
+			"last blocks this indexer processed are resolved, other fees will be zero. Run a node " +
+			"with -txindex=1, or one serving getblock verbosity=3 with prevout data.")
+	})
+}
 
-	workers := len(blockNumbers)
-	workers = min(workers, b.config.Throttle.Concurrency)
+// ProbeTxIndex checks every provider's txindex with getindexinfo so prevout
+// lookups avoid nodes without it from the start, and warns when none has it.
+// Nodes that cannot be probed stay eligible; their getrawtransaction errors
+// mark them later if they lack txindex.
+func (b *BitcoinIndexer) ProbeTxIndex(ctx context.Context) {
+	providers := b.failover.GetAvailableProviders()
+	disabled := 0
+	for _, p := range providers {
+		client, ok := p.Client.(bitcoin.BitcoinAPI)
+		if !ok {
+			continue
+		}
+		status, err := client.ProbeTxIndex(ctx)
+		if err != nil {
+			b.logger().Debug("txindex probe failed", "provider", p.Name, "error", err)
+			continue
+		}
+		b.logger().Info("Bitcoin node txindex", "provider", p.Name, "txindex", status)
+		if status == bitcoin.TxIndexDisabled {
+			disabled++
+		}
+	}
+	if len(providers) > 0 && disabled == len(providers) {
+		b.warnNoTxIndex()
+	}
+}
 
-	type job struct {
-		num   uint64
-		index int
+// resolveBlockPrevouts fetches prevouts for the inputs of btcBlock.Tx[txIdxs]
+// still lacking one, in that order, using a pool sized to
+// config.Throttle.Concurrency. On a node without txindex only prevouts from
+// recentTxs are looked up, see resolveHintedPrevouts. A transaction some of
+// whose prevouts are already set, e.g. by a pass on another node, only has
+// the others looked up. No new fetch starts once ctx is done. It returns
+// nil when every input of those transactions has its prevout.
+func (b *BitcoinIndexer) resolveBlockPrevouts(
+	ctx context.Context,
+	client bitcoin.BitcoinAPI,
+	btcBlock *bitcoin.Block,
+	txIdxs []int,
+) *bitcoin.PartialEnrichmentError {
+	jobs := make(chan int, len(txIdxs))
+	for _, idx := range txIdxs {
+		if missingPrevouts(&btcBlock.Tx[idx]) > 0 {
+			jobs <- idx
+		}
 	}
+	close(jobs)
 
-	jobs := make(chan job, workers*2)
+	var mu sync.Mutex
+	var firstErr error
 	var wg sync.WaitGroup
-
-	for i := 0; i < workers; i++ {
+	for i := 0; i < max(b.config.Throttle.Concurrency, 1); i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for j := range jobs {
-				block, err := b.GetBlock(ctx, j.num)
-				results[j.index] = BlockResult{Number: j.num, Block: block}
+			for idx := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				tx := &btcBlock.Tx[idx]
+				var err error
+				if client.TxIndex() != bitcoin.TxIndexDisabled && missingPrevouts(tx) < spentInputs(tx) {
+					// An earlier pass, possibly on another node, resolved
+					// some inputs: only look the others up.
+					err = client.ResolvePrevouts(ctx, []*bitcoin.Transaction{tx}, 0)
+				} else if client.TxIndex() != bitcoin.TxIndexDisabled {
+					var resolved *bitcoin.Transaction
+					resolved, err = client.GetTransactionWithPrevouts(ctx, tx.TxID)
+					if resolved != nil {
+						for k := range tx.Vin {
+							if k < len(resolved.Vin) && resolved.Vin[k].PrevOut != nil {
+								tx.Vin[k].PrevOut = resolved.Vin[k].PrevOut
+							}
+						}
+					}
+				}
+				// The lookup may just have revealed the node lacks txindex.
+				if client.TxIndex() == bitcoin.TxIndexDisabled && missingPrevouts(tx) > 0 {
+					if hintErr := b.resolveHintedPrevouts(ctx, client, tx); hintErr != nil {
+						err = hintErr
+					}
+				}
 				if err != nil {
-					results[j.index].Error = &Error{
-						ErrorType: ErrorTypeUnknown,
-						Message:   err.Error(),
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
 					}
+					mu.Unlock()
 				}
 			}
 		}()
 	}
+	wg.Wait()
+
+	partial := &bitcoin.PartialEnrichmentError{Err: firstErr}
+	for _, idx := range txIdxs {
+		tx := &btcBlock.Tx[idx]
+		partial.Total += spentInputs(tx)
+		partial.Missing += missingPrevouts(tx)
+	}
+	if partial.Missing == 0 {
+		return nil
+	}
+	return partial
+}
 
-	go func() {
-		defer close(jobs)
-		for i, num := range blockNumbers {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- job{num: num, index: i}:
+// resolveHintedPrevouts fills tx's missing prevouts that were created in a
+// block recentTxs remembers, passing that block's hash to getrawtransaction
+// so a node without txindex can serve them. Other inputs are left alone. It
+// returns the first lookup error.
+func (b *BitcoinIndexer) resolveHintedPrevouts(ctx context.Context, client bitcoin.BitcoinAPI, tx *bitcoin.Transaction) error {
+	var firstErr error
+	for k := range tx.Vin {
+		vin := &tx.Vin[k]
+		if vin.TxID == "" || vin.PrevOut != nil {
+			continue
+		}
+		blockHash, ok := b.recentTxs.blockOf(vin.TxID)
+		if !ok {
+			continue
+		}
+		prevTx, err := client.GetRawTransactionInBlock(ctx, vin.TxID, blockHash)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
 			}
+			continue
+		}
+		if int(vin.Vout) < len(prevTx.Vout) {
+			vin.PrevOut = &prevTx.Vout[vin.Vout]
+		}
+	}
+	return firstErr
+}
+
+// spentInputs counts tx's inputs that spend an outpoint, i.e. all but a
+// coinbase input.
+func spentInputs(tx *bitcoin.Transaction) int {
+	n := 0
+	for _, vin := range tx.Vin {
+		if vin.TxID != "" {
+			n++
 		}
-	}()
+	}
+	return n
+}
 
-	wg.Wait()
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+// missingPrevouts counts tx's inputs that spend an outpoint but carry no
+// prevout data.
+func missingPrevouts(tx *bitcoin.Transaction) int {
+	n := 0
+	for _, vin := range tx.Vin {
+		if vin.TxID != "" && vin.PrevOut == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// feeCompleteness returns how many of the block's non-coinbase transactions
+// have every prevout, i.e. a computable fee, and how many there are.
+func feeCompleteness(btcBlock *bitcoin.Block) (complete, total int) {
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		if tx.IsCoinbase() {
+			continue
+		}
+		total++
+		if missingPrevouts(tx) == 0 {
+			complete++
+		}
+	}
+	return complete, total
+}
+
+func (b *BitcoinIndexer) GetBlocks(
+	ctx context.Context,
+	from, to uint64,
+	isParallel bool,
+) ([]BlockResult, error) {
+	blockNums := make([]uint64, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		blockNums = append(blockNums, n)
+	}
+
+	return b.GetBlocksByNumbers(ctx, blockNums)
+}
+
+// GetBlocksStream fetches from..to in windows bounded by
+// Throttle.MaxBlocksInMemory and Throttle.MaxMemoryBytes, handing each
+// result to fn in height order, so a large backfill never holds the whole
+// range in memory.
+func (b *BitcoinIndexer) GetBlocksStream(
+	ctx context.Context,
+	from, to uint64,
+	fn func(BlockResult) error,
+) error {
+	return streamWindows(ctx, from, to, b.config.Throttle, func(ctx context.Context, from, to uint64) ([]BlockResult, error) {
+		return b.GetBlocks(ctx, from, to, true)
+	}, fn)
+}
+
+// GetBlocksByNumbers fetches blocks on the indexer's pool of
+// Throttle.Concurrency fetchers, with the priority ctx carries (see
+// WithFetchPriority), and converts them with Throttle.ProcessConcurrency
+// workers, see blockPipeline. Results are in the order of blockNumbers.
+func (b *BitcoinIndexer) GetBlocksByNumbers(
+	ctx context.Context,
+	blockNumbers []uint64,
+) ([]BlockResult, error) {
+	if len(blockNumbers) == 0 {
+		return nil, nil
+	}
+
+	results, err := blockPipeline{
+		fetcher:        b.fetchScheduler(),
+		processWorkers: processConcurrency(b.config.Throttle.ProcessConcurrency, b.config.Throttle.Concurrency),
+		process:        b.convertBlockWithPrevoutResolution,
+		budget:         b.config.Throttle.BatchBudget,
+		log:            b.logger(),
+	}.run(ctx, blockNumbers)
+	if err != nil {
+		return nil, err
 	}
 
 	var firstErr error
@@ -280,20 +861,114 @@ func (b *BitcoinIndexer) GetBlocksByNumbers(
 	return results, firstErr
 }
 
+// Metadata keys carried by Bitcoin transfers so consumers can build spends
+// of the paying output without re-fetching the transaction.
+const (
+	btcMetaVout         = "vout"          // uint32 output index
+	btcMetaScriptPubKey = "script_pubkey" // hex-encoded scriptPubKey
+	btcMetaScriptType   = "script_type"   // Core's scriptPubKey type, set on nonstandard transfers
+	btcMetaScriptLabel  = "script_label"  // label of the watched script a transfer pays, if any
+	btcMetaTimelocks    = "timelocks"     // []bitcoin.Timelock of the transaction and output, if any
+)
+
+// outputAddresses returns the addresses an output pays. With
+// IndexNonstandard, a value-carrying output Core reports no address for
+// (bare multisig, P2PK, non-standard scripts) yields its synthetic
+// bitcoin.ScriptID instead and nonstandard is true, so value never leaves
+// the ledger unaccounted. Such an output whose script is watched does so
+// regardless.
+func (b *BitcoinIndexer) outputAddresses(out *bitcoin.Output) (addrs []string, nonstandard bool) {
+	if addrs := bitcoin.GetOutputAddresses(out); len(addrs) > 0 {
+		return addrs, false
+	}
+	if out == nil || out.Value <= 0 {
+		return nil, false
+	}
+	if _, _, watched := b.watchedScript(out); watched {
+		return []string{bitcoin.ScriptID(out.ScriptPubKey.Hex)}, true
+	}
+	if !b.config.IndexNonstandard {
+		return nil, false
+	}
+	if id := bitcoin.ScriptID(out.ScriptPubKey.Hex); id != "" {
+		return []string{id}, true
+	}
+	return nil, false
+}
+
+// keepZeroValueOutput reports whether a zero-value output paying addrs
+// yields transfers, per ChainConfig.ZeroValueOutputs. Protocols tag
+// transactions with such outputs (dust-free markers, anchors), so they can
+// matter to the owner of the address even though they move no value.
+func (b *BitcoinIndexer) keepZeroValueOutput(addrs []string, scriptWatched bool) bool {
+	switch b.config.ZeroValueOutputs {
+	case config.ZeroValueSkip:
+		return false
+	case config.ZeroValueWatched:
+		if scriptWatched {
+			return true
+		}
+		if b.pubkeyStore == nil {
+			return false
+		}
+		for _, addr := range addrs {
+			if b.pubkeyStore.Exist(enum.NetworkTypeBtc, addr) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// watchedScript returns the script ID of out and the label it is watched
+// with, when the pubkey store watches scripts and this one.
+func (b *BitcoinIndexer) watchedScript(out *bitcoin.Output) (id, label string, ok bool) {
+	watcher, isWatcher := b.pubkeyStore.(ScriptWatcher)
+	if !isWatcher || out == nil {
+		return "", "", false
+	}
+	id = bitcoin.ScriptID(out.ScriptPubKey.Hex)
+	if id == "" {
+		return "", "", false
+	}
+	label, ok = watcher.WatchedScript(enum.NetworkTypeBtc, id)
+	return id, label, ok
+}
+
+// inputAddress returns the address an input spends from, falling back to the
+// prevout's synthetic script ID like outputAddresses.
+func (b *BitcoinIndexer) inputAddress(vin *bitcoin.Input) string {
+	if addr := bitcoin.GetInputAddress(vin); addr != "" {
+		return addr
+	}
+	if vin == nil {
+		return ""
+	}
+	if addrs, _ := b.outputAddresses(vin.PrevOut); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return ""
+}
+
 // extractTransfersFromTx extracts all transfers from a transaction.
+// Each output address yields one transfer; outputs are never merged, so
+// every transfer maps to exactly one outpoint (TxHash, vout). Transfers are
+// emitted by ascending vout, then address, and the fee is attributed per
+// ChainConfig.FeeAttribution. A coinbase yields transfers only per
+// ChainConfig.CoinbaseTransfers, see extractCoinbaseTransfers.
 func (b *BitcoinIndexer) extractTransfersFromTx(
 	tx *bitcoin.Transaction,
 	blockHash string,
 	blockNumber, ts, latestBlock uint64,
 ) []types.Transaction {
-	var transfers []types.Transaction
-
-	// Skip coinbase transactions
 	if tx.IsCoinbase() {
-		return transfers
+		return b.extractCoinbaseTransfers(tx, blockHash, blockNumber, ts, latestBlock)
 	}
+	var transfers []types.Transaction
 
 	fee := tx.CalculateFee()
+	feeRate := txFeeRate(tx)
 
 	confirmations := b.calculateConfirmations(blockNumber, latestBlock)
 	status := types.StatusPending
@@ -307,50 +982,241 @@ func (b *BitcoinIndexer) extractTransfersFromTx(
 		fromAddr = allInputAddrs[0]
 	}
 
-	feeAssigned := false
-	for voutIdx, vout := range tx.Vout {
-		toAddrs := bitcoin.GetOutputAddresses(&vout)
+	var outs []btcTransferOutput
+	for _, vout := range sortedOutputs(tx.Vout) {
+		toAddrs, nonstandard := b.sortedOutputAddresses(vout)
+		// A watched script is matched besides the output's addresses.
+		scriptID, label, watched := b.watchedScript(vout)
+		if watched && !slices.Contains(toAddrs, scriptID) {
+			toAddrs = append(toAddrs, scriptID)
+		}
 		if len(toAddrs) == 0 {
 			continue // Skip unspendable outputs (OP_RETURN, etc.)
 		}
-
-		amountSat := satoshisFromFloat(vout.Value)
-
-		txFee := decimal.Zero
-		if !feeAssigned {
-			txFee = fee
-			feeAssigned = true
+		if vout.Value == 0 && !b.keepZeroValueOutput(toAddrs, watched) {
+			continue
+		}
+		o := btcTransferOutput{
+			out:         vout,
+			addrs:       toAddrs,
+			nonstandard: nonstandard,
+			sats:        satoshisFromFloat(vout.Value),
+		}
+		if watched {
+			o.scriptID, o.scriptLabel = scriptID, label
 		}
+		outs = append(outs, o)
+	}
+	fees := b.feeShares(fee, outs, allInputAddrs)
+	txLocks := bitcoin.TransactionTimelocks(tx)
+	consolidation := b.isConsolidation(tx, allInputAddrs, outs)
+	fromAddrs := allInputAddrs
+	if consolidation {
+		fromAddrs = nil
+	}
+	cosigners := b.multisigSpends(tx)
+
+	for i, o := range outs {
+		txType := constant.TxTypeNativeTransfer
+		if o.nonstandard {
+			txType = constant.TxTypeNonstandard
+		} else if consolidation {
+			txType = constant.TxTypeConsolidation
+		}
+		locks := append(slices.Clip(txLocks), bitcoin.OutputTimelocks(o.out)...)
 
-		for addrIdx, toAddr := range toAddrs {
-			if normalized, err := bitcoin.NormalizeBTCAddress(toAddr); err == nil {
-				toAddr = normalized
+		for addrIdx, toAddr := range o.addrs {
+			txFee := decimal.Zero
+			if addrIdx == 0 {
+				txFee = fees[i]
 			}
 
 			transfer := types.Transaction{
 				TxHash:        tx.TxID,
 				NetworkId:     b.config.NetworkId,
+				InternalCode:  b.config.InternalCode,
 				BlockHash:     blockHash,
 				BlockNumber:   blockNumber,
-				TransferIndex: fmt.Sprintf("%d:%d", voutIdx, addrIdx),
+				TransferIndex: fmt.Sprintf("%d:%d", o.out.N, addrIdx),
 				FromAddress:   fromAddr,
-				FromAddresses: allInputAddrs,
+				FromAddresses: fromAddrs,
 				ToAddress:     toAddr,
 				AssetAddress:  "",
-				Amount:        strconv.FormatInt(amountSat, 10),
-				Type:          constant.TxTypeNativeTransfer,
+				Amount:        strconv.FormatInt(o.sats, 10),
+				Type:          txType,
 				TxFee:         txFee,
 				Timestamp:     ts,
 				Confirmations: confirmations,
 				Status:        status,
 			}
+			transfer.SetMetadata(btcMetaVout, o.out.N)
+			transfer.SetMetadataString(btcMetaScriptPubKey, o.out.ScriptPubKey.Hex)
+			if o.nonstandard {
+				transfer.SetMetadataString(btcMetaScriptType, o.out.ScriptPubKey.Type)
+			}
+			if toAddr == o.scriptID {
+				transfer.SetMetadataString(btcMetaScriptLabel, o.scriptLabel)
+			}
+			if script, ok := b.redeemScript(toAddr); ok {
+				match := bitcoin.PaysToScript(o.out.ScriptPubKey.Hex, script)
+				if !match {
+					b.logger().Warn("Output does not pay the redeem script registered for its address",
+						"tx", tx.TxID, "vout", o.out.N, "address", toAddr)
+				}
+				transfer.SetMetadata(btcMetaRedeemScriptMatch, match)
+			}
+			if len(cosigners) > 0 {
+				transfer.SetMetadata(btcMetaCosigners, cosigners)
+			}
+			if len(locks) > 0 {
+				transfer.SetMetadata(btcMetaTimelocks, locks)
+			}
+			if consolidation {
+				transfer.SetMetadata(btcMetaConsolidationInputs, len(tx.Vin))
+			}
+			if feeRate != nil {
+				transfer.SetMetadata(btcMetaFeeRate, feeRate)
+			}
+			transfer.EnsureTransferID()
 			transfers = append(transfers, transfer)
 		}
 	}
 
+	if b.config.Ordinals.Enabled {
+		transfers = b.applyInscriptions(tx, transfers)
+	}
+
+	if b.config.FeeAttribution == config.FeeAttributionTransaction && fee.IsPositive() {
+		feeRecord := types.Transaction{
+			TxHash:        tx.TxID,
+			NetworkId:     b.config.NetworkId,
+			InternalCode:  b.config.InternalCode,
+			BlockHash:     blockHash,
+			BlockNumber:   blockNumber,
+			TransferIndex: btcFeeTransferIndex,
+			FromAddress:   fromAddr,
+			FromAddresses: allInputAddrs,
+			Amount:        fee.Shift(8).StringFixed(0),
+			Type:          constant.TxTypeFee,
+			TxFee:         fee,
+			Timestamp:     ts,
+			Confirmations: confirmations,
+			Status:        status,
+		}
+		if feeRate != nil {
+			feeRecord.SetMetadata(btcMetaFeeRate, feeRate)
+		}
+		feeRecord.EnsureTransferID()
+		transfers = append(transfers, feeRecord)
+	}
+
 	return transfers
 }
 
+// btcFeeTransferIndex is the TransferIndex of the fee record emitted under
+// config.FeeAttributionTransaction.
+const btcFeeTransferIndex = "fee"
+
+// sortedOutputAddresses is outputAddresses with each address normalized and
+// the result sorted, so multi-address outputs yield the same order whatever
+// order the node listed them in.
+func (b *BitcoinIndexer) sortedOutputAddresses(out *bitcoin.Output) ([]string, bool) {
+	addrs, nonstandard := b.outputAddresses(out)
+	for i, addr := range addrs {
+		if normalized, err := btcaddr.NormalizeBTCAddress(addr, b.segwitHRPs()...); err == nil {
+			addrs[i] = normalized
+		}
+	}
+	sort.Strings(addrs)
+	return addrs, nonstandard
+}
+
+// btcTransferOutput is an output that yields transfers.
+type btcTransferOutput struct {
+	out         *bitcoin.Output
+	addrs       []string
+	nonstandard bool
+	sats        int64
+	// scriptID is set when the output's script is watched, with the label
+	// it was registered with.
+	scriptID    string
+	scriptLabel string
+}
+
+// sortedOutputs returns pointers to vouts ordered by output index, without
+// reordering the transaction itself.
+func sortedOutputs(vouts []bitcoin.Output) []*bitcoin.Output {
+	sorted := make([]*bitcoin.Output, len(vouts))
+	for i := range vouts {
+		sorted[i] = &vouts[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].N < sorted[j].N })
+	return sorted
+}
+
+// feeShares returns the fee carried by the first transfer of each of outs:
+//
+//   - first_output (default): the whole fee on the lowest vout.
+//   - proportional: split by amount across outputs not paying back to an
+//     input address (all outputs if every one does), floored to whole
+//     satoshis with the remainder on the lowest of them, so shares sum to fee.
+//   - transaction: none; the fee goes on a separate fee record.
+func (b *BitcoinIndexer) feeShares(fee decimal.Decimal, outs []btcTransferOutput, inputAddrs []string) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(outs))
+	for i := range shares {
+		shares[i] = decimal.Zero
+	}
+	if len(outs) == 0 || !fee.IsPositive() {
+		return shares
+	}
+
+	switch b.config.FeeAttribution {
+	case config.FeeAttributionTransaction:
+		return shares
+	case config.FeeAttributionProportional:
+		return proportionalFeeShares(shares, fee, outs, inputAddrs)
+	}
+	shares[0] = fee
+	return shares
+}
+
+// proportionalFeeShares fills shares for config.FeeAttributionProportional.
+func proportionalFeeShares(shares []decimal.Decimal, fee decimal.Decimal, outs []btcTransferOutput, inputAddrs []string) []decimal.Decimal {
+	inputs := make(map[string]struct{}, len(inputAddrs))
+	for _, addr := range inputAddrs {
+		inputs[addr] = struct{}{}
+	}
+	var recipients []int
+	var total int64
+	for i, o := range outs {
+		if _, change := inputs[o.addrs[0]]; !change {
+			recipients = append(recipients, i)
+			total += o.sats
+		}
+	}
+	if len(recipients) == 0 {
+		for i, o := range outs {
+			recipients = append(recipients, i)
+			total += o.sats
+		}
+	}
+	if total <= 0 {
+		shares[recipients[0]] = fee
+		return shares
+	}
+
+	feeSats := fee.Shift(8).IntPart()
+	assigned := int64(0)
+	for _, i := range recipients {
+		sats := decimal.NewFromInt(feeSats).Mul(decimal.NewFromInt(outs[i].sats)).
+			Div(decimal.NewFromInt(total)).IntPart()
+		shares[i] = decimal.New(sats, -8)
+		assigned += sats
+	}
+	shares[recipients[0]] = shares[recipients[0]].Add(decimal.New(feeSats-assigned, -8))
+	return shares
+}
+
 func (b *BitcoinIndexer) extractUTXOEvent(
 	tx *bitcoin.Transaction,
 	blockNumber uint64,
@@ -366,8 +1232,8 @@ func (b *BitcoinIndexer) extractUTXOEvent(
 
 	// Extract ALL created UTXOs (vouts) without filtering
 	// Filtering happens at emission level based on monitored addresses
-	for i, vout := range tx.Vout {
-		addrs := bitcoin.GetOutputAddresses(&vout)
+	for _, vout := range sortedOutputs(tx.Vout) {
+		addrs, _ := b.sortedOutputAddresses(vout)
 		if len(addrs) == 0 {
 			continue
 		}
@@ -375,13 +1241,9 @@ func (b *BitcoinIndexer) extractUTXOEvent(
 		amountSat := satoshisFromFloat(vout.Value)
 
 		for _, addr := range addrs {
-			if normalized, err := bitcoin.NormalizeBTCAddress(addr); err == nil {
-				addr = normalized
-			}
-
 			created = append(created, types.UTXO{
 				TxHash:       tx.TxID,
-				Vout:         uint32(i),
+				Vout:         vout.N,
 				Address:      addr,
 				Amount:       strconv.FormatInt(amountSat, 10),
 				ScriptPubKey: vout.ScriptPubKey.Hex,
@@ -396,23 +1258,24 @@ func (b *BitcoinIndexer) extractUTXOEvent(
 			continue
 		}
 
-		addr := bitcoin.GetInputAddress(&vin)
+		addr := b.inputAddress(&vin)
 		if addr == "" {
 			continue
 		}
 
-		if normalized, err := bitcoin.NormalizeBTCAddress(addr); err == nil {
+		if normalized, err := btcaddr.NormalizeBTCAddress(addr, b.segwitHRPs()...); err == nil {
 			addr = normalized
 		}
 
 		amountSat := satoshisFromFloat(vin.PrevOut.Value)
 
 		spent = append(spent, types.SpentUTXO{
-			TxHash:  vin.TxID,
-			Vout:    vin.Vout,
-			Vin:     uint32(i),
-			Address: addr,
-			Amount:  strconv.FormatInt(amountSat, 10),
+			TxHash:    vin.TxID,
+			Vout:      vin.Vout,
+			Vin:       uint32(i),
+			Address:   addr,
+			Amount:    strconv.FormatInt(amountSat, 10),
+			SpendType: string(bitcoin.ClassifySpend(&vin)),
 		})
 	}
 
@@ -449,11 +1312,11 @@ func (b *BitcoinIndexer) getAllInputAddresses(tx *bitcoin.Transaction) []string
 	seen := make(map[string]bool)
 	var addrs []string
 	for _, vin := range tx.Vin {
-		addr := bitcoin.GetInputAddress(&vin)
+		addr := b.inputAddress(&vin)
 		if addr == "" {
 			continue
 		}
-		if normalized, err := bitcoin.NormalizeBTCAddress(addr); err == nil {
+		if normalized, err := btcaddr.NormalizeBTCAddress(addr, b.segwitHRPs()...); err == nil {
 			addr = normalized
 		}
 		if !seen[addr] {
@@ -482,6 +1345,38 @@ func (b *BitcoinIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (b *BitcoinIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return b.failover.ProviderStatuses()
+}
+
+// DiagnoseNodes reports the diagnostics of every RPC node, blacklisted ones
+// included; see bitcoin.BitcoinAPI.Diagnose.
+func (b *BitcoinIndexer) DiagnoseNodes(ctx context.Context) map[string]any {
+	reports := make(map[string]any)
+	for _, p := range b.failover.Providers() {
+		if client, ok := p.Client.(bitcoin.BitcoinAPI); ok {
+			reports[p.Name] = client.Diagnose(ctx)
+		}
+	}
+	return reports
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (b *BitcoinIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	b.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (b *BitcoinIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return b.failover.ExpectNetwork(ctx, want)
+}
+
+// WarmUp probes the chain's RPC nodes before first use.
+func (b *BitcoinIndexer) WarmUp(ctx context.Context) {
+	b.failover.WarmUp(ctx)
+}
+
 // GetMempoolTransactions fetches and processes transactions from the mempool
 // Returns transactions and UTXO events involving monitored addresses with 0 confirmations
 func (b *BitcoinIndexer) GetMempoolTransactions(ctx context.Context) ([]types.Transaction, []types.UTXOEvent, error) {
@@ -489,6 +1384,10 @@ func (b *BitcoinIndexer) GetMempoolTransactions(ctx context.Context) ([]types.Tr
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get bitcoin provider: %w", err)
 	}
+	// Mempool prevouts are mostly confirmed outputs, so prefer a txindex node.
+	if providers, err := b.prevoutProviders(ctx); err == nil && len(providers) > 0 {
+		provider = providers[0]
+	}
 
 	btcClient, ok := provider.Client.(*bitcoin.BitcoinClient)
 	if !ok {
@@ -513,15 +1412,21 @@ func (b *BitcoinIndexer) GetMempoolTransactions(ctx context.Context) ([]types.Tr
 	var allTransfers []types.Transaction
 	var allUTXOEvents []types.UTXOEvent
 	currentTime := uint64(time.Now().Unix())
+	extractor := b.transferExtractor()
+	chainCtx := b.chainContext(latestBlock)
 
 	for _, txid := range txids {
 		tx, err := btcClient.GetTransactionWithPrevouts(ctx, txid)
-		if err != nil {
+		var partial *bitcoin.PartialEnrichmentError
+		if err != nil && !errors.As(err, &partial) {
 			continue
 		}
+		if partial != nil && bitcoin.IsTxIndexError(partial.Err) {
+			_ = b.resolveHintedPrevouts(ctx, btcClient, tx)
+		}
 
-		transfers := b.extractTransfersFromTx(tx, "", 0, currentTime, latestBlock)
-		allTransfers = append(allTransfers, transfers...)
+		pending := &bitcoin.Block{Time: currentTime, Tx: []bitcoin.Transaction{*tx}}
+		allTransfers = append(allTransfers, extractor.Extract(pending, chainCtx)...)
 
 		if b.config.IndexUTXO {
 			utxoEvent := b.extractUTXOEvent(tx, 0, "", currentTime, latestBlock)
@@ -0,0 +1,125 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Blocks 91842 and 91880 repeat the coinbase txids of blocks 91812 and
+// 91722, overwriting their unspent outputs before BIP30 forbade it; Core
+// exempts exactly these two blocks. The coinbases below keep the real txids
+// and the 50 BTC subsidy; the rest of each block is made up.
+func TestBitcoinConvertBlock_BIP30DuplicateCoinbase(t *testing.T) {
+	cases := []struct {
+		coinbase           string
+		firstHeight        uint64
+		firstHash          string
+		duplicateHeight    uint64
+		duplicateBlockHash string
+	}{
+		{
+			coinbase:           "e3bf3d07d4b0375638d5f1db5255fe07ba2c4cb067cd81b84ee974b6585fb468",
+			firstHeight:        91722,
+			firstHash:          "block-91722",
+			duplicateHeight:    91880,
+			duplicateBlockHash: "00000000000743f190a18c5577a3c2d2a1f610ae9601ac046a38084ccb7cd721",
+		},
+		{
+			coinbase:           "d5d27987d2a3dfc724e359870c6644b40e497bdc0589a033220fe15429d88599",
+			firstHeight:        91812,
+			firstHash:          "block-91812",
+			duplicateHeight:    91842,
+			duplicateBlockHash: "00000000000a4d0a398161ffc163c503763b1f4360639393e0e4c8e300e0caec",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.coinbase[:8], func(t *testing.T) {
+			idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+			idx.recentTxs = newRecentTxBlocks(recentTxBlocksCapacity)
+
+			block := func(height uint64, hash, payment string) *bitcoin.Block {
+				return &bitcoin.Block{
+					Height:        height,
+					Hash:          hash,
+					Confirmations: 1,
+					Tx: []bitcoin.Transaction{
+						{
+							TxID: tc.coinbase,
+							Vin:  []bitcoin.Input{{Vout: 0xffffffff}},
+							Vout: []bitcoin.Output{btcOutput("miner", 50, 0)},
+						},
+						{
+							TxID: payment,
+							Vin:  []bitcoin.Input{btcInput("prev-"+payment, 0, "sender", 1.0)},
+							Vout: []bitcoin.Output{btcOutput("recipient", 0.999, 0)},
+						},
+					},
+				}
+			}
+
+			first, err := idx.convertBlockWithPrevoutResolution(context.Background(),
+				block(tc.firstHeight, tc.firstHash, "payment-a"))
+			require.NoError(t, err)
+			dup, err := idx.convertBlockWithPrevoutResolution(context.Background(),
+				block(tc.duplicateHeight, tc.duplicateBlockHash, "payment-b"))
+			require.NoError(t, err)
+
+			// Each block yields its own payment only: coinbases carry no
+			// transfers, so the repeated txid emits nothing twice.
+			require.Len(t, first.Transactions, 1)
+			require.Len(t, dup.Transactions, 1)
+			assert.Equal(t, "payment-a", first.Transactions[0].TxHash)
+			assert.Equal(t, tc.firstHeight, first.Transactions[0].BlockNumber)
+			assert.Equal(t, "payment-b", dup.Transactions[0].TxHash)
+			assert.Equal(t, tc.duplicateHeight, dup.Transactions[0].BlockNumber)
+			assert.Equal(t, tc.duplicateBlockHash, dup.Transactions[0].BlockHash)
+
+			// Fees count the payment of each block alone.
+			for _, blk := range []*types.Block{first, dup} {
+				fees, ok := blk.GetMetadata(btcBlockMetaFees)
+				require.True(t, ok)
+				assert.Equal(t, int64(100_000), fees)
+				assert.Equal(t, 2, blk.TxCount)
+			}
+
+			// Prevout lookups of the repeated txid go to the later block,
+			// which holds the outputs that remain spendable.
+			hash, ok := idx.recentTxs.blockOf(tc.coinbase)
+			require.True(t, ok)
+			assert.Equal(t, tc.duplicateBlockHash, hash)
+		})
+	}
+}
+
+func TestBitcoinConvertBlock_RepeatedTxidKeptOnce(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+	payment := bitcoin.Transaction{
+		TxID: "payment",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{btcOutput("recipient", 0.999, 0)},
+	}
+	block := &bitcoin.Block{
+		Height: 100,
+		Hash:   "h",
+		Tx: []bitcoin.Transaction{
+			{TxID: "coinbase", Vin: []bitcoin.Input{{Vout: 0xffffffff}}, Vout: []bitcoin.Output{btcOutput("miner", 3.125, 0)}},
+			payment,
+			payment,
+		},
+	}
+
+	result, err := idx.convertBlockWithPrevoutResolution(context.Background(), block)
+
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 1)
+	assert.Equal(t, "payment", result.Transactions[0].TxHash)
+	assert.Equal(t, 2, result.TxCount)
+	fees, _ := result.GetMetadata(btcBlockMetaFees)
+	assert.Equal(t, int64(100_000), fees, "the repeated transaction's fee is counted once")
+}
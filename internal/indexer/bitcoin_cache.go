@@ -0,0 +1,38 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+)
+
+// UseResponseCache records the cache the indexer's clients share, see
+// config.RPCCacheConfig, to report it and drop rolled-back heights from it.
+func (b *BitcoinIndexer) UseResponseCache(cache *bitcoin.ImmutableCache) {
+	b.responseCache = cache
+}
+
+// UseResponseStore shares the indexer's response cache through store.
+func (b *BitcoinIndexer) UseResponseStore(store rpc.ResponseStore) {
+	if b.responseCache != nil {
+		b.responseCache.UseStore(store)
+	}
+}
+
+// ResponseCacheStats reports the response cache's lookups, nil without one.
+func (b *BitcoinIndexer) ResponseCacheStats() *rpc.ResponseCacheStats {
+	if b.responseCache == nil {
+		return nil
+	}
+	stats := b.responseCache.Stats()
+	return &stats
+}
+
+// RolledBack drops the cached responses of heights from from on.
+func (b *BitcoinIndexer) RolledBack(ctx context.Context, from uint64) error {
+	if b.responseCache == nil {
+		return nil
+	}
+	return b.responseCache.InvalidateFrom(ctx, from)
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/shopspring/decimal"
+)
+
+// btcCoinbaseMaturity is how many blocks a coinbase output waits before it
+// can be spent (COINBASE_MATURITY).
+const btcCoinbaseMaturity = 100
+
+// btcMetaMaturityHeight carries the first block a coinbase transfer's
+// output can be spent in.
+const btcMetaMaturityHeight = "maturity_height"
+
+// btcParentHash returns the hash of block's parent, empty for the genesis
+// block, which has none, also from providers reporting it as all zeros.
+func btcParentHash(block *bitcoin.Block) string {
+	if block.Height == 0 {
+		return ""
+	}
+	return block.PreviousBlockHash
+}
+
+// extractCoinbaseTransfers returns the block reward outputs of coinbase tx
+// as coinbase transfers, with no sender and no fee, when
+// ChainConfig.CoinbaseTransfers is set. The genesis block's reward is never
+// emitted: it is not in the UTXO set and can never be spent.
+func (b *BitcoinIndexer) extractCoinbaseTransfers(
+	tx *bitcoin.Transaction,
+	blockHash string,
+	blockNumber, ts, latestBlock uint64,
+) []types.Transaction {
+	if !b.config.CoinbaseTransfers || blockNumber == 0 {
+		return nil
+	}
+
+	confirmations := b.calculateConfirmations(blockNumber, latestBlock)
+	status := types.StatusPending
+	if confirmations > 0 {
+		status = types.StatusConfirmed
+	}
+
+	var transfers []types.Transaction
+	for _, vout := range sortedOutputs(tx.Vout) {
+		// The witness commitment and other OP_RETURN outputs carry no
+		// address and no value.
+		toAddrs, nonstandard := b.sortedOutputAddresses(vout)
+		if len(toAddrs) == 0 || vout.Value == 0 {
+			continue
+		}
+		for addrIdx, toAddr := range toAddrs {
+			transfer := types.Transaction{
+				TxHash:        tx.TxID,
+				NetworkId:     b.config.NetworkId,
+				InternalCode:  b.config.InternalCode,
+				BlockHash:     blockHash,
+				BlockNumber:   blockNumber,
+				TransferIndex: fmt.Sprintf("%d:%d", vout.N, addrIdx),
+				ToAddress:     toAddr,
+				Amount:        strconv.FormatInt(satoshisFromFloat(vout.Value), 10),
+				Type:          constant.TxTypeCoinbase,
+				TxFee:         decimal.Zero,
+				Timestamp:     ts,
+				Confirmations: confirmations,
+				Status:        status,
+			}
+			transfer.SetMetadata(btcMetaVout, vout.N)
+			transfer.SetMetadataString(btcMetaScriptPubKey, vout.ScriptPubKey.Hex)
+			if nonstandard {
+				transfer.SetMetadataString(btcMetaScriptType, vout.ScriptPubKey.Type)
+			}
+			transfer.SetMetadata(btcMetaMaturityHeight, blockNumber+btcCoinbaseMaturity)
+			transfer.EnsureTransferID()
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// btcMetaConsolidationInputs carries the input count of a consolidation
+// transfer, whose FromAddresses are left out.
+const btcMetaConsolidationInputs = "consolidation_inputs"
+
+// isConsolidation reports whether tx consolidates watched funds: it spends
+// at least two inputs, every input address known is watched, and outs,
+// the outputs yielding transfers, are standard with all their addresses
+// watched. Inputs whose prevout could not be resolved are not counted
+// against it, but at least one must be known.
+func (b *BitcoinIndexer) isConsolidation(tx *bitcoin.Transaction, inputAddrs []string, outs []btcTransferOutput) bool {
+	if !b.config.Consolidation.Enabled || b.pubkeyStore == nil {
+		return false
+	}
+	if len(tx.Vin) < 2 || len(inputAddrs) == 0 || len(outs) == 0 {
+		return false
+	}
+	for _, addr := range inputAddrs {
+		if !b.pubkeyStore.Exist(enum.NetworkTypeBtc, addr) {
+			return false
+		}
+	}
+	for _, o := range outs {
+		if o.nonstandard {
+			return false
+		}
+		for _, addr := range o.addrs {
+			if !b.pubkeyStore.Exist(enum.NetworkTypeBtc, addr) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type btcWatchedSet map[string]bool
+
+func (s btcWatchedSet) Exist(_ enum.NetworkType, address string) bool { return s[address] }
+
+func consolidationIndexer(enabled bool) *BitcoinIndexer {
+	idx := newBTCTestIndexer(config.ChainConfig{
+		NetworkId:     "mainnet",
+		Consolidation: config.ConsolidationConfig{Enabled: enabled},
+	})
+	idx.pubkeyStore = btcWatchedSet{"hot1": true, "hot2": true, "hot3": true, "cold": true}
+	return idx
+}
+
+func consolidationTx(inputAddrs ...string) *bitcoin.Transaction {
+	tx := &bitcoin.Transaction{TxID: "sweep", Vout: []bitcoin.Output{btcOutput("cold", 0.0299, 0)}}
+	for i, addr := range inputAddrs {
+		tx.Vin = append(tx.Vin, btcInput("p", uint32(i), addr, 0.01))
+	}
+	return tx
+}
+
+func TestBitcoinExtractTransfers_Consolidation(t *testing.T) {
+	transfers := consolidationIndexer(true).extractTransfersFromTx(consolidationTx("hot1", "hot2", "hot3"), "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 1)
+	tr := transfers[0]
+	assert.Equal(t, constant.TxTypeConsolidation, tr.Type)
+	assert.Equal(t, "cold", tr.ToAddress)
+	assert.Equal(t, "hot1", tr.FromAddress)
+	assert.Nil(t, tr.FromAddresses)
+	inputs, ok := tr.GetMetadata(btcMetaConsolidationInputs)
+	require.True(t, ok)
+	assert.Equal(t, 3, inputs)
+}
+
+func TestBitcoinExtractTransfers_ConsolidationUnresolvedInput(t *testing.T) {
+	tx := consolidationTx("hot1", "hot2")
+	tx.Vin = append(tx.Vin, bitcoin.Input{TxID: "unknown", Vout: 0})
+
+	transfers := consolidationIndexer(true).extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 1)
+	assert.Equal(t, constant.TxTypeConsolidation, transfers[0].Type)
+	inputs, _ := transfers[0].GetMetadata(btcMetaConsolidationInputs)
+	assert.Equal(t, 3, inputs)
+}
+
+func TestBitcoinExtractTransfers_NotConsolidation(t *testing.T) {
+	externalOutput := consolidationTx("hot1", "hot2")
+	externalOutput.Vout = append(externalOutput.Vout, btcOutput("ext", 0.0001, 1))
+
+	for name, tc := range map[string]struct {
+		tx      *bitcoin.Transaction
+		enabled bool
+	}{
+		"external input":  {consolidationTx("hot1", "ext", "hot2"), true},
+		"external output": {externalOutput, true},
+		"single input":    {consolidationTx("hot1"), true},
+		"disabled":        {consolidationTx("hot1", "hot2"), false},
+	} {
+		transfers := consolidationIndexer(tc.enabled).extractTransfersFromTx(tc.tx, "bh", 100, 1_000_000, 100)
+
+		require.NotEmpty(t, transfers, name)
+		for _, tr := range transfers {
+			assert.Equal(t, constant.TxTypeNativeTransfer, tr.Type, name)
+			assert.NotEmpty(t, tr.FromAddresses, name)
+			_, ok := tr.GetMetadata(btcMetaConsolidationInputs)
+			assert.False(t, ok, name)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// DecodedTransaction is a single transaction as the indexer extracts it,
+// for troubleshooting extraction without indexing its block.
+type DecodedTransaction struct {
+	TxID string `json:"txid"`
+	// BlockHash and BlockNumber are empty for a mempool transaction.
+	BlockHash   string `json:"block_hash,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	FeeSats     int64  `json:"fee_sats"`
+	// UnresolvedPrevouts counts the inputs whose prevout could not be
+	// fetched; while positive, the fee and input addresses are incomplete.
+	UnresolvedPrevouts int                 `json:"unresolved_prevouts"`
+	Transfers          []types.Transaction `json:"transfers"`
+}
+
+// DecodeTransaction fetches txid with its prevouts and runs the configured
+// extraction on it, in its block when it is confirmed. Prevouts are resolved
+// as for mempool transactions, preferring nodes with txindex.
+func (b *BitcoinIndexer) DecodeTransaction(ctx context.Context, txid string) (*DecodedTransaction, error) {
+	latestBlock, err := b.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	var tx *bitcoin.Transaction
+	var client bitcoin.BitcoinAPI
+	err = b.failover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
+		t, err := c.GetTransactionWithPrevouts(ctx, txid)
+		var partial *bitcoin.PartialEnrichmentError
+		if err != nil && !errors.As(err, &partial) {
+			return err
+		}
+		if partial != nil && bitcoin.IsTxIndexError(partial.Err) {
+			_ = b.resolveHintedPrevouts(ctx, c, t)
+		}
+		tx, client = t, c
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", txid, err)
+	}
+
+	blk := &bitcoin.Block{Hash: tx.BlockHash, Tx: []bitcoin.Transaction{*tx}}
+	if tx.BlockHash != "" {
+		header, _, err := client.GetBlockTxids(ctx, tx.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %s: %w", tx.BlockHash, err)
+		}
+		blk.Height, blk.Time = header.Height, header.Time
+	}
+
+	decoded := &DecodedTransaction{
+		TxID:        tx.TxID,
+		BlockHash:   tx.BlockHash,
+		BlockNumber: blk.Height,
+		FeeSats:     tx.CalculateFee().Shift(8).IntPart(),
+		Transfers:   b.transferExtractor().Extract(blk, b.chainContext(latestBlock)),
+	}
+	if !tx.IsCoinbase() {
+		for _, vin := range tx.Vin {
+			if vin.PrevOut == nil {
+				decoded.UnresolvedPrevouts++
+			}
+		}
+	}
+	if decoded.UnresolvedPrevouts > 0 {
+		decoded.FeeSats = 0
+	}
+	return decoded, nil
+}
@@ -0,0 +1,39 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinDecodeTransaction(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 3, Pay: []string{"bc1qa", "bc1qb"}})
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, bitcointest.NewSimFailover(t, sim), nil)
+	ctx := context.Background()
+
+	payment := sim.Payments(101)[1]
+	decoded, err := idx.DecodeTransaction(ctx, payment.TxID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TxID, decoded.TxID)
+	assert.Equal(t, sim.BlockHash(101), decoded.BlockHash)
+	assert.Equal(t, uint64(101), decoded.BlockNumber)
+	assert.Equal(t, int64(1_000), decoded.FeeSats)
+	assert.Zero(t, decoded.UnresolvedPrevouts)
+
+	var paid bool
+	for _, tr := range decoded.Transfers {
+		assert.Equal(t, uint64(101), tr.BlockNumber)
+		if tr.ToAddress == payment.To {
+			paid = true
+			assert.Equal(t, payment.From, tr.FromAddress)
+		}
+	}
+	assert.True(t, paid, "transfer to %s extracted", payment.To)
+
+	_, err = idx.DecodeTransaction(ctx, "00")
+	assert.ErrorContains(t, err, "failed to get transaction 00")
+}
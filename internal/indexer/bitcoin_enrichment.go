@@ -0,0 +1,201 @@
+package indexer
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// watchedOutputsCapacity is how many outpoints watchedOutputs remembers.
+const watchedOutputsCapacity = 1 << 20
+
+// PrevoutLookupReporter is implemented by indexers budgeting prevout
+// lookups, see ChainConfig.PrevoutLookups, to report what the budget left
+// out.
+type PrevoutLookupReporter interface {
+	PrevoutLookupStats() PrevoutLookupStats
+}
+
+// PrevoutLookupStats counts the transactions missing prevouts that were
+// looked up, and those skipped past ChainConfig.PrevoutLookups, whose fees
+// stay unknown.
+type PrevoutLookupStats struct {
+	LookedUp uint64 `json:"looked_up"`
+	Skipped  uint64 `json:"skipped"`
+}
+
+type prevoutLookupCounters struct {
+	lookedUp, skipped atomic.Uint64
+}
+
+// PrevoutLookupStats reports the prevout lookups the budget left out.
+func (b *BitcoinIndexer) PrevoutLookupStats() PrevoutLookupStats {
+	return PrevoutLookupStats{
+		LookedUp: b.lookupCounters.lookedUp.Load(),
+		Skipped:  b.lookupCounters.skipped.Load(),
+	}
+}
+
+// watchedOutputs remembers the outpoints of outputs paying a watched address
+// or script in the blocks indexed, so an input spending one is recognised
+// before its prevout is looked up. The oldest is dropped once capacity are
+// held; outputs created before the indexer started are not known, and
+// their spends are only recognised through a prevout looked up.
+type watchedOutputs struct {
+	mu       sync.Mutex
+	capacity int
+	order    []string // outpoints, oldest first
+	set      map[string]bool
+}
+
+func newWatchedOutputs(capacity int) *watchedOutputs {
+	return &watchedOutputs{capacity: capacity, set: make(map[string]bool)}
+}
+
+func outpointKey(txid string, vout uint32) string {
+	return txid + ":" + strconv.FormatUint(uint64(vout), 10)
+}
+
+// add records an outpoint; adding it again is a no-op. A nil w remembers
+// nothing.
+func (w *watchedOutputs) add(txid string, vout uint32) {
+	if w == nil {
+		return
+	}
+	key := outpointKey(txid, vout)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.set[key] {
+		return
+	}
+	for len(w.order) >= w.capacity && len(w.order) > 0 {
+		delete(w.set, w.order[0])
+		w.order = w.order[1:]
+	}
+	w.order = append(w.order, key)
+	w.set[key] = true
+}
+
+// has reports whether the outpoint is remembered.
+func (w *watchedOutputs) has(txid string, vout uint32) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.set[outpointKey(txid, vout)]
+}
+
+// recordWatchedOutputs remembers the outpoints of btcBlock's outputs paying
+// a watched address or script, before prevouts are looked up so that later
+// transactions of the block spending them are recognised too.
+func (b *BitcoinIndexer) recordWatchedOutputs(btcBlock *bitcoin.Block) {
+	if b.watchedOuts == nil || b.pubkeyStore == nil {
+		return
+	}
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		for j := range tx.Vout {
+			if b.paysWatched(&tx.Vout[j]) {
+				b.watchedOuts.add(tx.TxID, tx.Vout[j].N)
+			}
+		}
+	}
+}
+
+// prevoutLookups orders btcBlock.Tx[txIdxs], the transactions missing
+// prevouts, for enrichment: those touching a watched address first, see
+// touchesWatched, then the others, each in block order. With
+// ChainConfig.PrevoutLookups set, unwatched transactions whose node calls,
+// see prevoutCalls, would take the block past that many are left out and
+// counted as skipped: their fees stay unknown, and a send from a watched
+// address through an output it received before the indexer started is not
+// recognised. Watched transactions are always looked up, and nothing is
+// left out in strict mode, which would fail the block instead.
+func (b *BitcoinIndexer) prevoutLookups(btcBlock *bitcoin.Block, txIdxs []int) (lookups []int, skipped int) {
+	watched := make([]int, 0, len(txIdxs))
+	var others []int
+	calls := 0
+	for _, idx := range txIdxs {
+		if b.touchesWatched(&btcBlock.Tx[idx]) {
+			watched = append(watched, idx)
+			calls += prevoutCalls(&btcBlock.Tx[idx])
+		} else {
+			others = append(others, idx)
+		}
+	}
+	budget := b.config.PrevoutLookups
+	if budget > 0 && !b.config.StrictMode {
+		kept := others[:0]
+		for _, idx := range others {
+			if n := prevoutCalls(&btcBlock.Tx[idx]); calls+n <= budget {
+				calls += n
+				kept = append(kept, idx)
+			} else {
+				skipped++
+			}
+		}
+		others = kept
+	}
+	return append(watched, others...), skipped
+}
+
+// prevoutCalls estimates the node calls looking tx's prevouts up takes:
+// one per transaction its missing prevouts were created in, plus one for tx
+// itself unless some of its prevouts are already known.
+func prevoutCalls(tx *bitcoin.Transaction) int {
+	parents := make(map[string]bool)
+	for _, vin := range tx.Vin {
+		if vin.TxID != "" && vin.PrevOut == nil {
+			parents[vin.TxID] = true
+		}
+	}
+	calls := len(parents)
+	if missingPrevouts(tx) == spentInputs(tx) {
+		calls++
+	}
+	return calls
+}
+
+// touchesWatched reports whether tx pays a watched address or script, or
+// spends from a watched address through an input whose prevout is known or
+// whose outpoint watchedOutputs remembers: what its transfers can be
+// matched by before enrichment.
+func (b *BitcoinIndexer) touchesWatched(tx *bitcoin.Transaction) bool {
+	if b.pubkeyStore == nil {
+		return false
+	}
+	for i := range tx.Vout {
+		if b.paysWatched(&tx.Vout[i]) {
+			return true
+		}
+	}
+	for _, vin := range tx.Vin {
+		if vin.TxID != "" && vin.PrevOut == nil && b.watchedOuts.has(vin.TxID, vin.Vout) {
+			return true
+		}
+	}
+	for _, addr := range b.getAllInputAddresses(tx) {
+		if b.pubkeyStore.Exist(enum.NetworkTypeBtc, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// paysWatched reports whether out pays a watched address or script.
+func (b *BitcoinIndexer) paysWatched(out *bitcoin.Output) bool {
+	if _, _, ok := b.watchedScript(out); ok {
+		return true
+	}
+	addrs, _ := b.sortedOutputAddresses(out)
+	for _, addr := range addrs {
+		if b.pubkeyStore.Exist(enum.NetworkTypeBtc, addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// enrichmentBlock holds four transactions missing a prevout: two paying
+// unwatched addresses, one paying a watched address and one spending from
+// a watched address through its known input. Each but the latter takes two
+// calls to look up, the transaction and its input's.
+func enrichmentBlock() *bitcoin.Block {
+	unresolved := bitcoin.Input{TxID: "prev", Vout: 0}
+	return &bitcoin.Block{Tx: []bitcoin.Transaction{
+		{TxID: "other1", Vin: []bitcoin.Input{unresolved}, Vout: []bitcoin.Output{btcOutput("stranger1", 1, 0)}},
+		{TxID: "deposit", Vin: []bitcoin.Input{unresolved}, Vout: []bitcoin.Output{btcOutput("hot", 1, 0)}},
+		{TxID: "other2", Vin: []bitcoin.Input{unresolved}, Vout: []bitcoin.Output{btcOutput("stranger2", 1, 0)}},
+		{TxID: "send", Vin: []bitcoin.Input{btcInput("p", 0, "hot", 2), unresolved}, Vout: []bitcoin.Output{btcOutput("stranger3", 1, 0)}},
+	}}
+}
+
+func TestBitcoinPrevoutLookups(t *testing.T) {
+	txIdxs := []int{0, 1, 2, 3}
+	tests := []struct {
+		name        string
+		cfg         config.ChainConfig
+		watched     btcWatchedSet
+		wantLookups []int
+		wantSkipped int
+	}{
+		{"watched first", config.ChainConfig{}, btcWatchedSet{"hot": true}, []int{1, 3, 0, 2}, 0},
+		{"budget left for one other", config.ChainConfig{PrevoutLookups: 5}, btcWatchedSet{"hot": true}, []int{1, 3, 0}, 1},
+		{"watched past the budget", config.ChainConfig{PrevoutLookups: 1}, btcWatchedSet{"hot": true}, []int{1, 3}, 2},
+		{"strict ignores the budget", config.ChainConfig{PrevoutLookups: 1, StrictMode: true}, btcWatchedSet{"hot": true}, []int{1, 3, 0, 2}, 0},
+		{"budget counts calls", config.ChainConfig{PrevoutLookups: 3}, nil, []int{0, 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newBTCTestIndexer(tt.cfg)
+			if tt.watched != nil {
+				idx.pubkeyStore = tt.watched
+			}
+			lookups, skipped := idx.prevoutLookups(enrichmentBlock(), txIdxs)
+			assert.Equal(t, tt.wantLookups, lookups)
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
+
+func TestBitcoinPrevoutLookups_SpendOfWatchedOutput(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{PrevoutLookups: 2})
+	idx.pubkeyStore = btcWatchedSet{"hot": true}
+	idx.watchedOuts = newWatchedOutputs(watchedOutputsCapacity)
+	idx.recordWatchedOutputs(&bitcoin.Block{Tx: []bitcoin.Transaction{
+		{TxID: "funding", Vout: []bitcoin.Output{btcOutput("stranger", 1, 0), btcOutput("hot", 1, 1)}},
+	}})
+
+	// Neither pays a watched address, nor has a known prevout; the second
+	// spends the watched output.
+	block := &bitcoin.Block{Tx: []bitcoin.Transaction{
+		{TxID: "other", Vin: []bitcoin.Input{{TxID: "funding", Vout: 0}}, Vout: []bitcoin.Output{btcOutput("a", 1, 0)}},
+		{TxID: "send", Vin: []bitcoin.Input{{TxID: "funding", Vout: 1}}, Vout: []bitcoin.Output{btcOutput("b", 1, 0)}},
+	}}
+	lookups, skipped := idx.prevoutLookups(block, []int{0, 1})
+	assert.Equal(t, []int{1}, lookups)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestWatchedOutputs_DropsOldest(t *testing.T) {
+	w := newWatchedOutputs(2)
+	w.add("a", 0)
+	w.add("b", 0)
+	w.add("a", 0)
+	w.add("c", 1)
+	assert.False(t, w.has("a", 0))
+	assert.True(t, w.has("b", 0))
+	assert.True(t, w.has("c", 1))
+	assert.False(t, w.has("c", 0))
+}
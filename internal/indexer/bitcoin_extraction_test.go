@@ -20,8 +20,10 @@ import (
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -571,6 +573,94 @@ func TestBitcoinExtractTransfers_BlockHashAndTransferIndex(t *testing.T) {
 	}
 }
 
+func TestBitcoinExtractTransfers_OutpointMetadata(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
+	out0 := btcOutput("recip_a", 0.3, 0)
+	out0.ScriptPubKey.Hex = "0014aaaa"
+	out2 := btcOutput("recip_a", 0.39, 2)
+	out2.ScriptPubKey.Hex = "0014bbbb"
+	tx := &bitcoin.Transaction{
+		TxID: "outpoint_test",
+		Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{out0, btcOpReturnOutput(1), out2},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "blockhash", 100, 1_000_000, 100)
+
+	// Same address twice: one transfer per output, each with its own outpoint.
+	require.Len(t, transfers, 2)
+	for i, want := range []struct {
+		vout   uint32
+		script string
+	}{{0, "0014aaaa"}, {2, "0014bbbb"}} {
+		vout, ok := transfers[i].GetMetadata(btcMetaVout)
+		require.True(t, ok)
+		assert.Equal(t, want.vout, vout)
+		assert.Equal(t, want.script, transfers[i].GetMetadataString(btcMetaScriptPubKey))
+	}
+}
+
+// TestBitcoinExtractTransfers_TimelockMetadata checks transfers carry the
+// transaction's locks, plus their own output's script locks.
+func TestBitcoinExtractTransfers_TimelockMetadata(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
+	// <700000> OP_CLTV OP_DROP <key> OP_CHECKSIG, paid to a bare script.
+	locked := btcOutput("locked", 0.5, 0)
+	locked.ScriptPubKey.Hex = "0360ae0ab17521" + "02" + strings.Repeat("11", 32) + "ac"
+	tx := &bitcoin.Transaction{
+		TxID:     "timelock_test",
+		Version:  2,
+		LockTime: 800_000,
+		Vin:      []bitcoin.Input{btcInput("p1", 0, "sender", 1.0)},
+		Vout:     []bitcoin.Output{locked, btcOutput("plain", 0.49, 1)},
+	}
+	tx.Vin[0].Sequence = 0xfffffffd
+
+	transfers := idx.extractTransfersFromTx(tx, "blockhash", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 2)
+	txLock := bitcoin.Timelock{Type: bitcoin.TimelockLocktime, Unit: bitcoin.TimelockHeight, Value: 800_000}
+	locks, ok := transfers[0].GetMetadata(btcMetaTimelocks)
+	require.True(t, ok)
+	assert.Equal(t, []bitcoin.Timelock{txLock, {
+		Type:     bitcoin.TimelockCLTV,
+		Unit:     bitcoin.TimelockHeight,
+		Value:    700_000,
+		Template: bitcoin.TemplateTimelock,
+	}}, locks)
+	locks, ok = transfers[1].GetMetadata(btcMetaTimelocks)
+	require.True(t, ok)
+	assert.Equal(t, []bitcoin.Timelock{txLock}, locks)
+
+	tx.LockTime = 0
+	transfers = idx.extractTransfersFromTx(tx, "blockhash", 100, 1_000_000, 100)
+	_, ok = transfers[1].GetMetadata(btcMetaTimelocks)
+	assert.False(t, ok, "no timelocks, no metadata")
+}
+
+func TestBitcoinExtractTransfers_DeterministicTransferID(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
+	tx := &bitcoin.Transaction{
+		TxID: "id_test",
+		Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{
+			btcOutput("recip_a", 0.3, 0),
+			btcOutput("recip_a", 0.3, 1),
+		},
+	}
+
+	first := idx.extractTransfersFromTx(tx, "blockhash", 100, 1_000_000, 100)
+	second := idx.extractTransfersFromTx(tx, "blockhash", 100, 1_000_000, 101)
+
+	require.Len(t, first, 2)
+	require.Len(t, second, 2)
+	for i := range first {
+		assert.NotEmpty(t, first[i].TransferID)
+		assert.Equal(t, first[i].TransferID, second[i].TransferID)
+	}
+	assert.NotEqual(t, first[0].TransferID, first[1].TransferID)
+}
+
 func TestBitcoinExtractTransfers_MultisigTransferIndex(t *testing.T) {
 	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
 	tx := &bitcoin.Transaction{
@@ -798,6 +888,30 @@ func TestBitcoinExtractUTXO_KeyFormat(t *testing.T) {
 	assert.Equal(t, "prevtx:2", event.Spent[0].Key())
 }
 
+// TestBitcoinExtractUTXO_WrappedSegwitSpendType checks the spend type of a
+// P2SH-P2WPKH input (the BIP-143 example, signature elided) is recorded
+// while a plain input's is left empty.
+func TestBitcoinExtractUTXO_WrappedSegwitSpendType(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet", IndexUTXO: true})
+	wrapped := btcInput("prev_wrapped", 1, "38BW8nqpHSWpkf5sXrQd2xYwvnPJwP59ic", 10)
+	wrapped.ScriptSig.Hex = "16001479091972186c449eb1ded22b78e40d009bdf0089"
+	wrapped.Witness = []string{"", "03ad1d8e89212f0b92c74d23bb710c00662ad1470198ac48c43f7d6f93a2a26873"}
+	wrapped.PrevOut.ScriptPubKey.Type = "scripthash"
+	wrapped.PrevOut.ScriptPubKey.Hex = "a9144733f37cf4db86fbc2efed2500b4f4e49f31202387"
+	tx := &bitcoin.Transaction{
+		TxID: "wrapped_spend",
+		Vin:  []bitcoin.Input{wrapped, btcInput("prev_plain", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{btcOutput("recipient", 10.9, 0)},
+	}
+
+	event := idx.extractUTXOEvent(tx, 100, "bh", 1_000_000, 100)
+
+	require.NotNil(t, event)
+	require.Len(t, event.Spent, 2)
+	assert.Equal(t, string(bitcoin.SpendP2SHP2WPKH), event.Spent[0].SpendType)
+	assert.Empty(t, event.Spent[1].SpendType)
+}
+
 // TestBitcoinExtractUTXO_RealConsolidation runs the UTXO extractor against the
 // real consolidation fixture and verifies created/spent counts and amounts.
 func TestBitcoinExtractUTXO_RealConsolidation(t *testing.T) {
@@ -827,7 +941,7 @@ func TestBitcoinExtractUTXO_RealConsolidation(t *testing.T) {
 
 func TestBitcoinNormalize_P2WPKH_Lowercase(t *testing.T) {
 	addr := "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
-	got, err := bitcoin.NormalizeBTCAddress(addr)
+	got, err := btcaddr.NormalizeBTCAddress(addr)
 	require.NoError(t, err)
 	assert.Equal(t, strings.ToLower(addr), got)
 }
@@ -836,25 +950,40 @@ func TestBitcoinNormalize_P2WPKH_Lowercase(t *testing.T) {
 // addresses are now accepted by NormalizeBTCAddress without error.
 func TestBitcoinNormalize_P2TR_Mainnet(t *testing.T) {
 	addr := "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
-	got, err := bitcoin.NormalizeBTCAddress(addr)
+	got, err := btcaddr.NormalizeBTCAddress(addr)
 	require.NoError(t, err, "P2TR mainnet address must be accepted (Bug #4 fix)")
 	assert.Equal(t, addr, got)
 }
 
 func TestBitcoinNormalize_P2TR_Testnet(t *testing.T) {
-	addr := "tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"
-	got, err := bitcoin.NormalizeBTCAddress(addr)
+	addr := "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c"
+	got, err := btcaddr.NormalizeBTCAddress(addr)
 	require.NoError(t, err, "P2TR testnet address must be accepted (Bug #4 fix)")
 	assert.Equal(t, addr, got)
 }
 
 func TestBitcoinNormalize_P2TR_NormalizesUppercase(t *testing.T) {
 	upper := "BC1P5CYXNUXMEUWUVKWFEM96LQZSZD02N6XDCJRS20CAC6YQJJWUDPXQKEDRCR"
-	got, err := bitcoin.NormalizeBTCAddress(upper)
+	got, err := btcaddr.NormalizeBTCAddress(upper)
 	require.NoError(t, err)
 	assert.Equal(t, strings.ToLower(upper), got)
 }
 
+func TestBitcoinNormalize_ChainHRP(t *testing.T) {
+	addr, err := btcaddr.EncodeSegwitAddress("tbs", 1, make([]byte, 32))
+	require.NoError(t, err)
+	out := btcOutput(strings.ToUpper(addr), 1, 0)
+
+	idx := newBTCTestIndexer(config.ChainConfig{Type: enum.NetworkTypeBtc})
+	got, _ := idx.sortedOutputAddresses(&out)
+	assert.NotEqual(t, []string{addr}, got, "not the chain's HRP")
+
+	// A reloaded config sets it on the running chain.
+	idx.UpdateSegwitHRPs(config.ChainConfig{Type: enum.NetworkTypeBtc, Bech32HRP: "tbs"})
+	got, _ = idx.sortedOutputAddresses(&out)
+	assert.Equal(t, []string{addr}, got)
+}
+
 func TestBitcoinNormalize_TaprootFallback_TransferNotMissed(t *testing.T) {
 	// Even when using a P2TR recipient, the transfer is produced correctly.
 	taprootRecipient := "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"
@@ -1099,3 +1228,301 @@ func TestBitcoinExtract_Integration_UTXOEventStructure(t *testing.T) {
 		assert.Equal(t, fmt.Sprintf("%s:%d", spent.TxHash, spent.Vout), spent.Key())
 	}
 }
+
+func TestBitcoinConvertBlock_CarriesBlockStats(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
+	block, err := idx.convertBlockWithPrevoutResolution(context.Background(), &bitcoin.Block{
+		Hash:       "h",
+		Height:     100,
+		Size:       1234,
+		Weight:     4000,
+		Difficulty: 1.5,
+		NTx:        7,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234), block.Size)
+	assert.Equal(t, uint64(4000), block.Weight)
+	assert.Equal(t, 1.5, block.Difficulty)
+	assert.Equal(t, 7, block.TxCount)
+}
+
+func TestBitcoinExtractTransfers_NonstandardOutputs(t *testing.T) {
+	p2pk := bitcoin.Output{
+		Value: 0.5,
+		N:     0,
+		ScriptPubKey: bitcoin.ScriptPubKey{
+			Type: "pubkey",
+			Hex:  "2102" + strings.Repeat("ab", 32) + "ac",
+		},
+	}
+	tx := &bitcoin.Transaction{
+		TxID: "nonstandard",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "", 0.6)},
+		Vout: []bitcoin.Output{p2pk, btcOpReturnOutput(1), btcOutput("bc1qdest", 0.09, 2)},
+	}
+	// The spent output had no address either.
+	tx.Vin[0].PrevOut.ScriptPubKey = bitcoin.ScriptPubKey{Type: "nonstandard", Hex: "51"}
+
+	off := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+	transfers := off.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 1, "address-less outputs are skipped by default")
+	assert.Empty(t, transfers[0].FromAddress)
+
+	on := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet", IndexNonstandard: true})
+	transfers = on.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 2, "zero-value OP_RETURN stays skipped")
+
+	assert.Equal(t, bitcoin.ScriptID(p2pk.ScriptPubKey.Hex), transfers[0].ToAddress)
+	assert.Equal(t, constant.TxTypeNonstandard, transfers[0].Type)
+	assert.Equal(t, "50000000", transfers[0].Amount)
+	assert.Equal(t, constant.TxTypeNativeTransfer, transfers[1].Type)
+	for _, tr := range transfers {
+		assert.Equal(t, bitcoin.ScriptID("51"), tr.FromAddress, "spent script gets the same kind of ID")
+	}
+	assert.NotEqual(t, transfers[0].TransferID, transfers[1].TransferID)
+}
+
+// btcScriptWatcher watches scripts by ID, with their label.
+type btcScriptWatcher map[string]string
+
+func (w btcScriptWatcher) Exist(_ enum.NetworkType, address string) bool {
+	_, ok := w[address]
+	return ok
+}
+
+func (w btcScriptWatcher) WatchedScript(_ enum.NetworkType, scriptID string) (string, bool) {
+	label, ok := w[scriptID]
+	return label, ok
+}
+
+func TestBitcoinExtractTransfers_WatchedScript(t *testing.T) {
+	bare := bitcoin.Output{
+		Value: 0.5,
+		N:     0,
+		ScriptPubKey: bitcoin.ScriptPubKey{
+			Type: "multisig",
+			Hex:  "5121" + strings.Repeat("02", 33) + "21" + strings.Repeat("03", 33) + "52ae",
+		},
+	}
+	standard := btcOutput("bc1qdest", 0.09, 1)
+	standard.ScriptPubKey.Hex = "0014" + strings.Repeat("11", 20)
+	tx := &bitcoin.Transaction{
+		TxID: "escrow",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qsender", 0.6)},
+		Vout: []bitcoin.Output{bare, standard, btcOutput("bc1qother", 0.01, 2)},
+	}
+	bareID, standardID := bitcoin.ScriptID(bare.ScriptPubKey.Hex), bitcoin.ScriptID(standard.ScriptPubKey.Hex)
+
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+	idx.pubkeyStore = btcScriptWatcher{bareID: "escrow", standardID: "custody"}
+	transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 4, "watched scripts are matched without index_nonstandard_outputs")
+
+	assert.Equal(t, bareID, transfers[0].ToAddress)
+	assert.Equal(t, constant.TxTypeNonstandard, transfers[0].Type)
+	assert.Equal(t, "50000000", transfers[0].Amount)
+	assert.Equal(t, "escrow", transfers[0].GetMetadataString(btcMetaScriptLabel))
+
+	assert.Equal(t, "bc1qdest", transfers[1].ToAddress, "address matching is kept")
+	assert.Empty(t, transfers[1].GetMetadataString(btcMetaScriptLabel))
+	assert.Equal(t, standardID, transfers[2].ToAddress)
+	assert.Equal(t, "1:1", transfers[2].TransferIndex)
+	assert.Equal(t, constant.TxTypeNativeTransfer, transfers[2].Type)
+	assert.Equal(t, "custody", transfers[2].GetMetadataString(btcMetaScriptLabel))
+	assert.Equal(t, "bc1qother", transfers[3].ToAddress)
+}
+
+func TestBitcoinExtractTransfers_ZeroValueOutputs(t *testing.T) {
+	// A protocol transaction tagged by an OP_RETURN, a zero-value marker to
+	// the watched user and a pay-to-anchor output for fee bumping.
+	anchor := btcOutput("bc1pfeessrawgf", 0, 2)
+	anchor.ScriptPubKey.Type = "anchor"
+	tx := &bitcoin.Transaction{
+		TxID: "tagged",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qprotocol", 0.0011)},
+		Vout: []bitcoin.Output{
+			btcOpReturnOutput(0),
+			btcOutput("bc1quser", 0, 1),
+			anchor,
+			btcOutput("bc1qprotocol", 0.001, 3),
+		},
+	}
+
+	tests := []struct {
+		mode string
+		to   []string
+	}{
+		{"", []string{"bc1quser", "bc1pfeessrawgf", "bc1qprotocol"}},
+		{config.ZeroValueEmit, []string{"bc1quser", "bc1pfeessrawgf", "bc1qprotocol"}},
+		{config.ZeroValueSkip, []string{"bc1qprotocol"}},
+		{config.ZeroValueWatched, []string{"bc1quser", "bc1qprotocol"}},
+	}
+	for _, tc := range tests {
+		idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet", ZeroValueOutputs: tc.mode})
+		idx.pubkeyStore = btcWatchedSet{"bc1quser": true}
+		transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+
+		var to []string
+		for _, tr := range transfers {
+			to = append(to, tr.ToAddress)
+			if tr.ToAddress != "bc1qprotocol" {
+				assert.Equal(t, "0", tr.Amount, tc.mode)
+			}
+		}
+		assert.Equal(t, tc.to, to, tc.mode)
+		assert.Equal(t, "0.0001", transfers[0].TxFee.String(), "%s: fee stays on the first transfer", tc.mode)
+	}
+
+	// A zero-value output to a watched script counts as watched.
+	bare := bitcoin.Output{N: 0, ScriptPubKey: bitcoin.ScriptPubKey{Type: "nonstandard", Hex: "0151"}}
+	scriptTx := &bitcoin.Transaction{
+		TxID: "script_tag",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qprotocol", 0.001)},
+		Vout: []bitcoin.Output{bare, btcOutput("bc1qprotocol", 0.0009, 1)},
+	}
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet", ZeroValueOutputs: config.ZeroValueWatched})
+	idx.pubkeyStore = btcScriptWatcher{bitcoin.ScriptID("0151"): "tag"}
+	transfers := idx.extractTransfersFromTx(scriptTx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, bitcoin.ScriptID("0151"), transfers[0].ToAddress)
+	assert.Equal(t, "0", transfers[0].Amount)
+	assert.Equal(t, "tag", transfers[0].GetMetadataString(btcMetaScriptLabel))
+}
+
+func TestBitcoinExtractTransfers_FeeAttribution(t *testing.T) {
+	// Fee 0.00001001 BTC = 1001 sats; 30000:10000 between the two recipients,
+	// vout 2 is change back to the sender.
+	tx := &bitcoin.Transaction{
+		TxID: "fee_modes",
+		Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 0.00101001)},
+		Vout: []bitcoin.Output{
+			btcOutput("recip_a", 0.0003, 0),
+			btcOutput("recip_b", 0.0001, 1),
+			btcOutput("sender", 0.0006, 2),
+		},
+	}
+
+	tests := []struct {
+		mode  string
+		fees  []string // per transfer, in order
+		types []constant.TxType
+	}{
+		{"", []string{"0.00001001", "0", "0"}, nil},
+		{config.FeeAttributionFirstOutput, []string{"0.00001001", "0", "0"}, nil},
+		{config.FeeAttributionProportional, []string{"0.00000751", "0.0000025", "0"}, nil},
+		{
+			config.FeeAttributionTransaction,
+			[]string{"0", "0", "0", "0.00001001"},
+			[]constant.TxType{
+				constant.TxTypeNativeTransfer, constant.TxTypeNativeTransfer,
+				constant.TxTypeNativeTransfer, constant.TxTypeFee,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3", FeeAttribution: tt.mode})
+			transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+			require.Len(t, transfers, len(tt.fees))
+			for i, want := range tt.fees {
+				assert.Equal(t, want, transfers[i].TxFee.String(), "transfer %d", i)
+			}
+			for i, want := range tt.types {
+				assert.Equal(t, want, transfers[i].Type, "transfer %d", i)
+			}
+		})
+	}
+
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3", FeeAttribution: config.FeeAttributionTransaction})
+	fee := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)[3]
+	assert.Equal(t, "1001", fee.Amount)
+	assert.Empty(t, fee.ToAddress)
+	assert.Equal(t, "sender", fee.FromAddress)
+	assert.Equal(t, btcFeeTransferIndex, fee.TransferIndex)
+}
+
+func TestBitcoinExtractTransfers_ProportionalFeeAllChange(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3", FeeAttribution: config.FeeAttributionProportional})
+	tx := &bitcoin.Transaction{
+		TxID: "self_send",
+		Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 0.001)},
+		Vout: []bitcoin.Output{btcOutput("sender", 0.0006, 0), btcOutput("sender", 0.0003, 1)},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, "0.0001", transfers[0].TxFee.Add(transfers[1].TxFee).String(),
+		"a pure self-send splits the fee across all outputs")
+	assert.Equal(t, "0.00006667", transfers[0].TxFee.String())
+}
+
+func TestBitcoinExtractTransfers_SortedByVout(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3"})
+	tx := &bitcoin.Transaction{
+		TxID: "unordered",
+		Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{btcOutput("recip_b", 0.5, 1), btcOutput("recip_a", 0.4, 0)},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, "recip_a", transfers[0].ToAddress)
+	assert.Equal(t, "0:0", transfers[0].TransferIndex)
+	assert.True(t, transfers[0].TxFee.IsPositive(), "fee goes to the lowest vout")
+	assert.Equal(t, "1:0", transfers[1].TransferIndex)
+	assert.Equal(t, uint32(1), tx.Vout[0].N, "the transaction itself is not reordered")
+}
+
+func TestBitcoinExtract_OrderIndependentOfNodeListing(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "testnet3", IndexUTXO: true})
+	listed := func(vout []bitcoin.Output) *bitcoin.Transaction {
+		return &bitcoin.Transaction{
+			TxID: "listing",
+			Vin:  []bitcoin.Input{btcInput("p1", 0, "sender", 1.0)},
+			Vout: vout,
+		}
+	}
+	a := listed([]bitcoin.Output{
+		btcOutput("recip", 0.2, 0),
+		btcMultisigOutput([]string{"ms_a", "ms_b", "ms_c"}, 0.3, 1),
+	})
+	b := listed([]bitcoin.Output{
+		btcMultisigOutput([]string{"ms_c", "ms_a", "ms_b"}, 0.3, 1),
+		btcOutput("recip", 0.2, 0),
+	})
+
+	ta := idx.extractTransfersFromTx(a, "h", 100, 1_000_000, 100)
+	tb := idx.extractTransfersFromTx(b, "h", 100, 1_000_000, 100)
+	assert.Equal(t, ta, tb)
+	require.Len(t, ta, 4)
+	var order []string
+	for _, tr := range ta {
+		order = append(order, tr.TransferIndex+"="+tr.ToAddress)
+	}
+	assert.Equal(t, []string{"0:0=recip", "1:0=ms_a", "1:1=ms_b", "1:2=ms_c"}, order)
+
+	ua := idx.extractUTXOEvent(a, 100, "h", 1_000_000, 100)
+	ub := idx.extractUTXOEvent(b, 100, "h", 1_000_000, 100)
+	assert.Equal(t, ua.Created, ub.Created)
+	assert.Equal(t, uint32(1), ub.Created[1].Vout, "UTXO vout is the output's index, not its list position")
+}
+
+// TestBitcoinExtractTransfers_OverlappingAddressFields checks an output
+// whose address is listed in both Address and the legacy Addresses yields
+// one transfer, not one per field.
+func TestBitcoinExtractTransfers_OverlappingAddressFields(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet"})
+	out := btcOutput("BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", 0.001, 0)
+	out.ScriptPubKey.Addresses = []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}
+	tx := &bitcoin.Transaction{
+		TxID: "overlap",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "sender", 0.002)},
+		Vout: []bitcoin.Output{out},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 1)
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", transfers[0].ToAddress)
+	assert.Equal(t, "100000", transfers[0].Amount)
+}
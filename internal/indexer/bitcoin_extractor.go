@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// BitcoinChainContext is what a TransferExtractor knows about the chain
+// besides the block it is given.
+type BitcoinChainContext struct {
+	ChainName   string
+	Config      config.ChainConfig
+	LatestBlock uint64 // tip height, for confirmations
+}
+
+// TransferExtractor turns a Bitcoin block whose prevouts have been resolved
+// into transfers. Mempool transactions are passed as a block of one with no
+// hash and height 0.
+type TransferExtractor interface {
+	Extract(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction
+}
+
+// TransferExtractorFunc adapts a function to TransferExtractor.
+type TransferExtractorFunc func(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction
+
+func (f TransferExtractorFunc) Extract(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction {
+	return f(blk, chainCtx)
+}
+
+// TransferDecorator wraps the extractor chain built so far and may add,
+// modify or drop the transfers it returns, e.g. to filter dust or decode a
+// protocol riding on OP_RETURN outputs.
+type TransferDecorator func(next TransferExtractor) TransferExtractor
+
+// buildTransferExtractor stacks decorators on base in order: decorators[0]
+// sees base's transfers first and the last decorator's output is final.
+func buildTransferExtractor(base TransferExtractor, decorators []TransferDecorator) TransferExtractor {
+	extractor := base
+	for _, decorate := range decorators {
+		extractor = decorate(extractor)
+	}
+	return extractor
+}
+
+// DefaultTransferExtractor returns the built-in extraction: one transfer per
+// output address of every transaction, coinbases only when
+// coinbase_transfers is set, see extractTransfersFromTx.
+func (b *BitcoinIndexer) DefaultTransferExtractor() TransferExtractor {
+	return TransferExtractorFunc(func(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction {
+		var transfers []types.Transaction
+		for i := range blk.Tx {
+			transfers = append(transfers,
+				b.extractTransfersFromTx(&blk.Tx[i], blk.Hash, blk.Height, blk.Time, chainCtx.LatestBlock)...)
+		}
+		return transfers
+	})
+}
+
+// chainContext returns the BitcoinChainContext for a tip at latestBlock.
+func (b *BitcoinIndexer) chainContext(latestBlock uint64) BitcoinChainContext {
+	return BitcoinChainContext{ChainName: b.chainName, Config: b.config, LatestBlock: latestBlock}
+}
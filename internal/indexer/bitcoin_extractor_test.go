@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadFixtureBlock reads the verbosity-3 block a fixture serves at height.
+func loadFixtureBlock(t *testing.T, fixture string, height uint64) *bitcoin.Block {
+	t.Helper()
+	dir := filepath.Join("testdata", "bitcoin", fixture)
+	f, err := bitcointest.ReadFixture(dir, "getblockhash", []any{height})
+	require.NoError(t, err)
+	var hash string
+	require.NoError(t, json.Unmarshal(f.Result, &hash))
+
+	f, err = bitcointest.ReadFixture(dir, "getblock", []any{hash, 3})
+	require.NoError(t, err)
+	var blk bitcoin.Block
+	require.NoError(t, json.Unmarshal(f.Result, &blk))
+	return &blk
+}
+
+// requireDefaultChainOutput checks that idx's extractor chain yields exactly
+// what per-transaction extraction did before extractors were pluggable.
+func requireDefaultChainOutput(t *testing.T, idx *BitcoinIndexer, blk *bitcoin.Block, latest uint64) {
+	t.Helper()
+	var want []types.Transaction
+	for i := range blk.Tx {
+		tx := &blk.Tx[i]
+		want = append(want, idx.extractTransfersFromTx(tx, blk.Hash, blk.Height, blk.Time, latest)...)
+	}
+	got := idx.transferExtractor().Extract(blk, idx.chainContext(latest))
+
+	wantJSON, err := json.Marshal(want)
+	require.NoError(t, err)
+	gotJSON, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.JSONEq(t, string(wantJSON), string(gotJSON))
+}
+
+var bitcoinExtractorFixtures = []struct {
+	fixture string
+	height  uint64
+}{
+	{"coinbase_only", 0},
+	{"segwit_heavy", btcIntegrationBlock},
+	{"multisig", btcIntegrationBlock + 1},
+	{"op_return", btcIntegrationBlock + 2},
+	{"coinbase_mined", btcIntegrationBlock + 3},
+	{"op_return_only", btcIntegrationBlock + 4},
+}
+
+func TestDefaultTransferExtractor_ReproducesPerTxExtraction(t *testing.T) {
+	cfg := config.ChainConfig{NetworkId: "btc_fixture", IndexNonstandard: true}
+	passThrough := func(next TransferExtractor) TransferExtractor { return next }
+	for _, tc := range bitcoinExtractorFixtures {
+		t.Run(tc.fixture, func(t *testing.T) {
+			blk := loadFixtureBlock(t, tc.fixture, tc.height)
+			requireDefaultChainOutput(t, NewBitcoinIndexer("btc_fixture", cfg, nil, nil), blk, tc.height+5)
+			requireDefaultChainOutput(t, NewBitcoinIndexer("btc_fixture", cfg, nil, nil, passThrough, passThrough),
+				blk, tc.height+5)
+			requireDefaultChainOutput(t, &BitcoinIndexer{chainName: "btc_fixture", config: cfg}, blk, tc.height+5)
+		})
+	}
+}
+
+// dropBelow drops transfers of fewer than minSats satoshis.
+func dropBelow(minSats int64) TransferDecorator {
+	return func(next TransferExtractor) TransferExtractor {
+		return TransferExtractorFunc(func(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction {
+			var kept []types.Transaction
+			for _, tr := range next.Extract(blk, chainCtx) {
+				if sats, err := strconv.ParseInt(tr.Amount, 10, 64); err == nil && sats >= minSats {
+					kept = append(kept, tr)
+				}
+			}
+			return kept
+		})
+	}
+}
+
+// tagType overwrites every transfer's Type, recording the decorator order.
+func tagType(tag string) TransferDecorator {
+	return func(next TransferExtractor) TransferExtractor {
+		return TransferExtractorFunc(func(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction {
+			transfers := next.Extract(blk, chainCtx)
+			for i := range transfers {
+				transfers[i].Type += constant.TxType("+" + tag)
+			}
+			return transfers
+		})
+	}
+}
+
+func TestBitcoinGetBlock_TransferDecorators(t *testing.T) {
+	srv := bitcointest.NewServer(t, filepath.Join("testdata", "bitcoin", "segwit_heavy"))
+	cfg := config.ChainConfig{NetworkId: "btc_fixture"}
+	cfg.Throttle.Concurrency = 2
+
+	plain, err := NewBitcoinIndexer("btc_fixture", cfg, bitcointest.NewFailover(t, srv), nil).
+		GetBlock(context.Background(), btcIntegrationBlock)
+	require.NoError(t, err)
+
+	idx := NewBitcoinIndexer("btc_fixture", cfg, bitcointest.NewFailover(t, srv), nil,
+		dropBelow(20850), tagType("a"), tagType("b"))
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, block.Transactions)
+	assert.Less(t, len(block.Transactions), len(plain.Transactions), "dust below 20850 sats is dropped")
+	for _, tr := range block.Transactions {
+		assert.Equal(t, "native_transfer+a+b", string(tr.Type), "decorators apply in registration order")
+		sats, err := strconv.ParseInt(tr.Amount, 10, 64)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, sats, int64(20850))
+	}
+}
@@ -0,0 +1,44 @@
+package indexer
+
+import (
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/shopspring/decimal"
+)
+
+// btcMetaFeeRate carries the FeeRate of the transaction on each of its
+// transfers, when its fee is known.
+const btcMetaFeeRate = "fee_rate"
+
+// FeeRate is a transaction's fee relative to its size, with the sizes the
+// node reported. Basis tells what the vsize SatPerVByte divides by was
+// taken from, see bitcoin.Transaction.VirtualSize; SatPerWU divides by the
+// weight when one was reported, else by four times that vsize.
+type FeeRate struct {
+	SatPerVByte decimal.Decimal   `json:"sat_per_vbyte"`
+	SatPerWU    decimal.Decimal   `json:"sat_per_wu"`
+	Basis       bitcoin.SizeBasis `json:"basis"`
+	Size        int               `json:"size,omitempty"`
+	VSize       int               `json:"vsize,omitempty"`
+	Weight      int               `json:"weight,omitempty"`
+}
+
+// txFeeRate returns tx's FeeRate, or nil when its fee is unknown, some
+// prevouts being unresolved, or the node reported no size for it.
+func txFeeRate(tx *bitcoin.Transaction) *FeeRate {
+	if missingPrevouts(tx) > 0 {
+		return nil
+	}
+	perVByte, basis := tx.CalculateFeeRate()
+	if basis == "" {
+		return nil
+	}
+	perWU, _ := tx.FeePerWeightUnit()
+	return &FeeRate{
+		SatPerVByte: perVByte,
+		SatPerWU:    perWU,
+		Basis:       basis,
+		Size:        tx.Size,
+		VSize:       tx.VSize,
+		Weight:      tx.Weight,
+	}
+}
@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinTransfersCarryFeeRate(t *testing.T) {
+	idx := &BitcoinIndexer{config: config.ChainConfig{FeeAttribution: config.FeeAttributionTransaction}}
+	tx := &bitcoin.Transaction{
+		TxID:   "aa11",
+		Size:   222,
+		Weight: 561, // a fork leaving vsize out
+		Vin:    []bitcoin.Input{btcInput("prev", 0, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0.001)},
+		Vout:   []bitcoin.Output{btcOutput("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 0.00099, 0)},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+	require.Len(t, transfers, 2, "payment and fee record")
+	for _, tr := range transfers {
+		v, ok := tr.GetMetadata(btcMetaFeeRate)
+		require.True(t, ok, tr.TransferIndex)
+		rate := v.(*FeeRate)
+		assert.Equal(t, bitcoin.SizeBasisWeight, rate.Basis)
+		assert.Equal(t, "7.092", rate.SatPerVByte.String(), "1000 sat over (561+3)/4 vbytes")
+		assert.Equal(t, "1.783", rate.SatPerWU.String())
+		assert.Equal(t, FeeRate{Basis: rate.Basis, SatPerVByte: rate.SatPerVByte, SatPerWU: rate.SatPerWU, Size: 222, Weight: 561}, *rate)
+	}
+
+	// An unresolved prevout leaves the fee, and so the rate, unknown.
+	tx.Vin[0].PrevOut = nil
+	for _, tr := range idx.extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100) {
+		_, ok := tr.GetMetadata(btcMetaFeeRate)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,668 @@
+package indexer
+
+// bitcoin_fixture_test.go
+//
+// Table-driven extraction tests against Bitcoin Core responses in
+// testdata/bitcoin, served by bitcointest.Server. GetBlock runs end to end:
+// failover -> BitcoinClient -> JSON-RPC -> convertBlockWithPrevoutResolution.
+//
+// Mainnet blocks:
+//
+//   coinbase_only  the genesis block (height 0), recorded verbatim
+//   mainnet_277647 block 277647, P2PKH spends with their prevouts, trimmed
+//                  to the coinbase and four transactions
+//   mainnet_540107 block 540107 as a pruned node without undo data serves
+//                  it, no prevouts or fees, trimmed to the coinbase and
+//                  five transactions: an OP_RETURN output, P2SH-P2WPKH,
+//                  P2SH-P2WSH multisig, P2SH multisig and P2WPKH spends
+//
+// The two trimmed blocks are decoded from the raw blocks and spent outputs
+// btcd ships as test data (blockchain/testdata/277647.dat and its
+// utxostore, wire/testdata/block-00000000000000000021868c...). They carry
+// the fields Core derives from those bytes, leaving out asm, desc and hex,
+// which the indexer does not read, and merkleroot and bits, so the trimmed
+// transaction list is not checked against the header.
+//
+// Hand-built blocks, for shapes the mainnet blocks lack or which need
+// prevouts the pruned block does not have; their hashes are placeholders:
+//
+//   segwit_heavy   coinbase plus the three transactions used in
+//                  bitcoin_extraction_test.go, with prevouts
+//   multisig       P2SH spend paying bare multisig, P2WSH and P2SH outputs
+//   op_return      payment + OP_RETURN data carrier + change
+//   coinbase_mined a block holding only its coinbase: a taproot reward
+//                  plus the witness commitment
+//   op_return_only coinbase + a transaction whose sole output is OP_RETURN,
+//                  so its whole input is fee
+//   address_scan   scantxoutset of a P2WPKH script finding two outputs
+//
+// Record more blocks from a node with cmd/devtools/btcfixtures.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wantBTCTransfer struct {
+	txHash string
+	vout   uint32
+	to     string
+	amount string
+	fee    string // BTC; "0" for every transfer but the tx's first
+}
+
+func newFixtureBTCIndexer(t *testing.T, fixture string, cfg config.ChainConfig) (*BitcoinIndexer, *bitcointest.Server) {
+	t.Helper()
+	srv := bitcointest.NewServer(t, filepath.Join("testdata", "bitcoin", fixture))
+	if cfg.Throttle.Concurrency == 0 {
+		cfg.Throttle.Concurrency = 2
+	}
+	return NewBitcoinIndexer("btc_fixture", cfg, bitcointest.NewFailover(t, srv), nil), srv
+}
+
+func TestBitcoinGetBlock_Fixtures(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		height    uint64
+		hash      string
+		txCount   int
+		pruned    bool // the node has no txindex to look prevouts up with
+		transfers []wantBTCTransfer
+	}{
+		{
+			name:    "coinbase only",
+			fixture: "coinbase_only",
+			height:  0,
+			hash:    "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+			txCount: 1,
+		},
+		{
+			name:    "mainnet legacy",
+			fixture: "mainnet_277647",
+			height:  277647,
+			hash:    "0000000000000000054a714e580b16c583701712ab91060e92dbde6eb1e052a8",
+			txCount: 213,
+			transfers: []wantBTCTransfer{
+				{"d1e594eabe8c582dc01a8768cb01679aea6956165806f69f40e22e5e352b3bd1", 0, "1586yAuW4UH9y6YbTx9p6U8xBPSX1fBmSi", "3799950000", "0.0005"},
+				{"d1e594eabe8c582dc01a8768cb01679aea6956165806f69f40e22e5e352b3bd1", 1, "11zro2wX8v6mkd99VuQShMrisZNLxYFNm", "100000000", "0"},
+				{"5b633c585506eca654972b58d89c749f748a679d13c265d70821789d4fa93af8", 0, "1M1wf5jKHwVWuxfUv5QB2jK9E3vBpdDHGr", "48968000", "0.000188"},
+				{"5b633c585506eca654972b58d89c749f748a679d13c265d70821789d4fa93af8", 1, "17xr4gmhoRn2E52NwFqkfbNwdzBLEWJXdS", "1414608500", "0"},
+				// Inputs and outputs add up to the same value: no fee.
+				{"20b15adf16076448ee3a6f818ee5fde37268a4dde394c2cfc0eaef1f432026c0", 0, "1GFDhbp9GA69LprYZCmw8rBYxaQdTyV5BE", "9509228", "0"},
+				{"20b15adf16076448ee3a6f818ee5fde37268a4dde394c2cfc0eaef1f432026c0", 1, "1PFUeY727eXj8kzSSP3YSGULHE7qVfCav7", "9502120620", "0"},
+				// Eight inputs from one address.
+				{"54d3c39b4726ea0eb8e8ccbd9323d329319126b29adab03e475805e069d96d98", 0, "1AvYeQK2djApm9j2cxQgpipAHWxXbJYW8z", "50000000", "0.0002"},
+				{"54d3c39b4726ea0eb8e8ccbd9323d329319126b29adab03e475805e069d96d98", 1, "1P33wMqVqwFVX84H8E2yGGFzxLniFS1z8F", "4621816", "0"},
+			},
+		},
+		{
+			// Without prevouts, inputs name no sender and fees stay zero.
+			// OP_RETURN and the coinbase's outputs are not transfers.
+			name:    "mainnet segwit pruned",
+			fixture: "mainnet_540107",
+			height:  540107,
+			hash:    "00000000000000000021868c2cefc52a480d173c849412fe81c4e5ab806f94ab",
+			txCount: 230,
+			pruned:  true,
+			transfers: []wantBTCTransfer{
+				{"e730c358ea348a6d109bf8035ebe5aedc79af0ba9476c1ac809057101a8f2412", 0, "1LussKtjeY6EWUAky7oneCEZZxtU2pX9x2", "546", "0"},
+				{"e730c358ea348a6d109bf8035ebe5aedc79af0ba9476c1ac809057101a8f2412", 1, "1x6YnuBVeeE65dQRZztRWgUPwyBjHCA5g", "146632160", "0"},
+				{"0c2f93f3cf3882564c92e1388adbb84e165772d1ce06c3161d39f8e813060ece", 0, "3CSvVWYTURL35y2akT5w89WuXtNsKcJ9m4", "4502094", "0"},
+				{"0c2f93f3cf3882564c92e1388adbb84e165772d1ce06c3161d39f8e813060ece", 1, "3LbnRt77JjFqZhMoAh5aBUjg1xu2qDrPb2", "29628260", "0"},
+				{"77ee6357a2454203b41e545af57dea589e9d843c8db4ac9578d81b3bc95a92fd", 0, "187NmKZj3fH1CUkGHBtmgqcREHYpnpHpPq", "495000", "0"},
+				{"77ee6357a2454203b41e545af57dea589e9d843c8db4ac9578d81b3bc95a92fd", 1, "3422VtS7UtCvXYxoXMVp6eZupR252z85oC", "649585098", "0"},
+				{"59b2968a02f52529a34417bc0663c0d6ad6935c442327e4d9e719b4b80a4c9d8", 0, "3QdnvcnZAwKwy7bpUHrwaCd99E1P6YjnhP", "714771", "0"},
+				{"59b2968a02f52529a34417bc0663c0d6ad6935c442327e4d9e719b4b80a4c9d8", 1, "1oQjJxMGjV1FjvmefVAtbN5AVEFhSWruu", "74115", "0"},
+				{"562b52e9ebaee7d3002d03343be16028ff39183b64d23147ded658cb04949114", 0, "3H9yoSFeMm79V6XHXpWDRAP2QR6GKR1tyj", "335948", "0"},
+				{"562b52e9ebaee7d3002d03343be16028ff39183b64d23147ded658cb04949114", 1, "bc1qv0zlls5lmfqe85tvvfvxsdupex6acexpyc666r", "348649057", "0"},
+			},
+		},
+		{
+			name:    "segwit heavy",
+			fixture: "segwit_heavy",
+			height:  btcIntegrationBlock,
+			txCount: 4,
+			transfers: []wantBTCTransfer{
+				{txidMultiInputConsolidation, 0, addrConsolidationRecipient, "21164", "0.00019671"},
+				{txidBatchPaymentWithChange, 0, addrBatchRecipient1, "20860", "0.0001118"},
+				{txidBatchPaymentWithChange, 1, addrBatchRecipient2, "20831", "0"},
+				{txidBatchPaymentWithChange, 2, addrBatchSender, "5321583", "0"},
+				{txidStressMultiSender, 0, addrStressExternal, "9900000", "0.00002274"},
+				{txidStressMultiSender, 1, addrStressSenderB, "7318111", "0"},
+				{txidStressMultiSender, 2, addrStressSenderA, "99995016", "0"},
+			},
+		},
+		{
+			// Core reports no address for bare multisig, so vout 0 is skipped
+			// and the fee lands on the first emitted output.
+			name:    "multisig",
+			fixture: "multisig",
+			height:  btcIntegrationBlock + 1,
+			txCount: 2,
+			transfers: []wantBTCTransfer{
+				{"", 1, "tb1qvt27df300rqd60tna86saspkqpk3r8f04xkqy833x3gtavswh28sy2cps0", "70000", "0.0002"},
+				{"", 2, "2Mwb7jxuLeEJzWeXBX935yEmB24WDmM6K37", "60000", "0"},
+			},
+		},
+		{
+			name:    "op_return",
+			fixture: "op_return",
+			height:  btcIntegrationBlock + 2,
+			txCount: 2,
+			transfers: []wantBTCTransfer{
+				{"", 0, addrBatchRecipient1, "30000", "0.00001"},
+				{"", 2, addrBatchSender, "69000", "0"},
+			},
+		},
+		{
+			name:    "coinbase mined",
+			fixture: "coinbase_mined",
+			height:  btcIntegrationBlock + 3,
+			txCount: 1,
+		},
+		{
+			name:    "op_return only",
+			fixture: "op_return_only",
+			height:  btcIntegrationBlock + 4,
+			txCount: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, srv := newFixtureBTCIndexer(t, tt.fixture, config.ChainConfig{NetworkId: "btc_fixture"})
+			if tt.pruned {
+				idx.ProbeTxIndex(context.Background())
+			}
+
+			block, err := idx.GetBlock(context.Background(), tt.height)
+			require.NoError(t, err)
+			assert.Empty(t, srv.Misses(), "every call must be served from fixtures")
+
+			assert.Equal(t, tt.height, block.Number)
+			if tt.hash != "" {
+				assert.Equal(t, tt.hash, block.Hash)
+			}
+			assert.Equal(t, tt.txCount, block.TxCount)
+			require.Len(t, block.Transactions, len(tt.transfers))
+
+			for i, want := range tt.transfers {
+				got := block.Transactions[i]
+				if want.txHash != "" {
+					assert.Equal(t, want.txHash, got.TxHash, "transfer %d", i)
+				}
+				assert.Equal(t, want.to, got.ToAddress, "transfer %d", i)
+				assert.Equal(t, want.amount, got.Amount, "transfer %d", i)
+				assert.Equal(t, want.fee, got.TxFee.String(), "transfer %d", i)
+				vout, ok := got.Metadata[btcMetaVout].(uint32)
+				require.True(t, ok, "transfer %d vout metadata", i)
+				assert.Equal(t, want.vout, vout, "transfer %d", i)
+				assert.NotEmpty(t, got.GetMetadataString(btcMetaScriptPubKey), "transfer %d", i)
+				assert.Equal(t, block.Hash, got.BlockHash)
+				assert.Equal(t, types.StatusConfirmed, got.Status)
+			}
+		})
+	}
+}
+
+func TestBitcoinGetBlock_FixtureGenesis(t *testing.T) {
+	idx, _ := newFixtureBTCIndexer(t, "coinbase_only", config.ChainConfig{NetworkId: "btc_fixture", CoinbaseTransfers: true})
+
+	block, err := idx.GetBlock(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, block.ParentHash)
+	assert.Empty(t, block.Transactions, "the genesis reward is unspendable")
+
+	assert.Empty(t, btcParentHash(&bitcoin.Block{PreviousBlockHash: strings.Repeat("0", 64)}))
+}
+
+func TestBitcoinGetBlock_FixtureCoinbaseTransfers(t *testing.T) {
+	const height = btcIntegrationBlock + 3
+	idx, _ := newFixtureBTCIndexer(t, "coinbase_mined", config.ChainConfig{NetworkId: "btc_fixture", CoinbaseTransfers: true})
+
+	block, err := idx.GetBlock(context.Background(), height)
+	require.NoError(t, err)
+	assert.Equal(t, "0b6f674ab1ebc8e1a3e77d69bb49962240568c4d51e775e5cbb1b264d70ffdb2", block.ParentHash)
+	require.Len(t, block.Transactions, 1, "the witness commitment yields no transfer")
+
+	got := block.Transactions[0]
+	assert.Equal(t, constant.TxTypeCoinbase, got.Type)
+	assert.Empty(t, got.FromAddress)
+	assert.Empty(t, got.FromAddresses)
+	assert.Equal(t, "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", got.ToAddress)
+	assert.Equal(t, "2500000", got.Amount)
+	assert.True(t, got.TxFee.IsZero())
+	assert.Equal(t, "0:0", got.TransferIndex)
+	maturity, _ := got.GetMetadata(btcMetaMaturityHeight)
+	assert.Equal(t, height+btcCoinbaseMaturity, maturity)
+	assert.NotEmpty(t, got.TransferID)
+}
+
+func TestBitcoinScanAddress_Fixture(t *testing.T) {
+	idx, _ := newFixtureBTCIndexer(t, "coinbase_only", config.ChainConfig{})
+	_, err := idx.ScanAddress(context.Background(), "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	require.ErrorIs(t, err, ErrNoScanNodes)
+
+	srv := bitcointest.NewServer(t, filepath.Join("testdata", "bitcoin", "address_scan"))
+	idx.UseScanFailover(bitcointest.NewFailover(t, srv))
+
+	scan, err := idx.ScanAddress(context.Background(), "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4")
+	require.NoError(t, err)
+	assert.Empty(t, srv.Misses(), "the address's script is scanned")
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", scan.Address)
+	assert.Equal(t, uint64(4842320), scan.Height)
+	require.Len(t, scan.UTXOs, 2)
+	assert.Equal(t, ScannedUTXO{
+		UTXO: types.UTXO{
+			TxHash:       "3f4c1a2b9d8e7f60112233445566778899aabbccddeeff00112233445566778a",
+			Vout:         0,
+			Address:      "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			Amount:       "1500000",
+			ScriptPubKey: "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		Height: 4842001,
+	}, scan.UTXOs[0])
+	assert.Equal(t, "21000", scan.UTXOs[1].Amount)
+	assert.True(t, scan.UTXOs[1].Coinbase)
+
+	_, err = idx.ScanAddress(context.Background(), "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+func TestBitcoinGetBlock_FixtureOpReturnOnly(t *testing.T) {
+	const height = btcIntegrationBlock + 4
+	idx, _ := newFixtureBTCIndexer(t, "op_return_only", config.ChainConfig{
+		NetworkId:         "btc_fixture",
+		FeeAttribution:    config.FeeAttributionTransaction,
+		CoinbaseTransfers: true,
+	})
+
+	block, err := idx.GetBlock(context.Background(), height)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 2)
+
+	reward, fee := block.Transactions[0], block.Transactions[1]
+	assert.Equal(t, constant.TxTypeCoinbase, reward.Type)
+	assert.Equal(t, "2510000", reward.Amount)
+	assert.Equal(t, constant.TxTypeFee, fee.Type, "an OP_RETURN-only spend is all fee")
+	assert.Equal(t, "10000", fee.Amount)
+	assert.Equal(t, addrBatchSender, fee.FromAddress)
+}
+
+func TestBitcoinGetBlock_FixtureMultiSenderFromAddresses(t *testing.T) {
+	idx, _ := newFixtureBTCIndexer(t, "segwit_heavy", config.ChainConfig{NetworkId: "btc_fixture"})
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock)
+	require.NoError(t, err)
+
+	for _, tr := range block.Transactions {
+		switch tr.TxHash {
+		case txidMultiInputConsolidation:
+			assert.Equal(t, []string{addrConsolidationSender}, tr.FromAddresses)
+		case txidStressMultiSender:
+			assert.Equal(t, addrStressSenderA, tr.FromAddress)
+			assert.Equal(t, []string{addrStressSenderA, addrStressSenderB}, tr.FromAddresses)
+		}
+	}
+}
+
+func TestBitcoinGetBlock_FixtureUTXOEvents(t *testing.T) {
+	idx, _ := newFixtureBTCIndexer(t, "op_return", config.ChainConfig{NetworkId: "btc_fixture", IndexUTXO: true})
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err)
+
+	events, ok := block.Metadata["utxo_events"].([]types.UTXOEvent)
+	require.True(t, ok)
+	require.Len(t, events, 1, "coinbase yields no UTXO event")
+	assert.Len(t, events[0].Created, 2, "OP_RETURN output is not spendable")
+	require.Len(t, events[0].Spent, 1)
+	assert.Equal(t, addrBatchSender, events[0].Spent[0].Address)
+}
+
+func TestBitcoinGetBlock_FixtureMissingBlock(t *testing.T) {
+	idx, srv := newFixtureBTCIndexer(t, "coinbase_only", config.ChainConfig{NetworkId: "btc_fixture"})
+
+	_, err := idx.GetBlock(context.Background(), 1)
+	require.Error(t, err)
+	assert.Contains(t, srv.Misses(), "getblockhash-1.json")
+}
+
+func TestBitcoinGetBlock_FallsBackToPerTxFetch(t *testing.T) {
+	const hash = "048209a71d60e56e375773e2bd6dcaf16a5533478afd6373cdcfa536d58c99ab"
+	cfg := config.ChainConfig{NetworkId: "btc_fixture"}
+
+	full, _ := newFixtureBTCIndexer(t, "segwit_heavy", cfg)
+	want, err := full.GetBlock(context.Background(), btcIntegrationBlock)
+	require.NoError(t, err)
+
+	idx, srv := newFixtureBTCIndexer(t, "segwit_heavy", cfg)
+	srv.FailWith(bitcointest.FixtureName("getblock", []any{hash, 3.0}), http.StatusRequestEntityTooLarge)
+
+	got, err := idx.GetBlock(context.Background(), btcIntegrationBlock)
+	require.NoError(t, err)
+	assert.Empty(t, srv.Misses())
+	assert.Equal(t, 2, srv.Calls("getblock"), "failed verbosity=3 call plus one verbosity=1 call")
+	assert.Equal(t, 4, srv.Calls("getrawtransaction"), "one fetch per transaction")
+
+	assert.Equal(t, want.Hash, got.Hash)
+	assert.Equal(t, want.TxCount, got.TxCount)
+	require.Len(t, got.Transactions, len(want.Transactions))
+	for i := range want.Transactions {
+		assert.Equal(t, want.Transactions[i].TransferID, got.Transactions[i].TransferID,
+			"transfer %d must keep Core's transaction order", i)
+		assert.Equal(t, want.Transactions[i].FromAddresses, got.Transactions[i].FromAddresses)
+	}
+}
+
+func TestIsBlockTooLargeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&rpc.HTTPError{StatusCode: http.StatusRequestEntityTooLarge}, true},
+		{&rpc.HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{rpc.WithClass(rpc.ErrTimeout, errors.New("HTTP request failed: context deadline exceeded")), true},
+		{errors.New("getblock decode error: unexpected end of JSON input"), true},
+		{errors.New("HTTP 200: failed to read response body: unexpected EOF"), true},
+		{&rpc.RPCError{Code: -5, Message: "Block not found"}, false},
+		{&rpc.HTTPError{StatusCode: http.StatusUnauthorized}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isBlockTooLargeError(tt.err), "%v", tt.err)
+	}
+}
+
+func TestBitcoinGetBlock_FixtureNonstandardOutputs(t *testing.T) {
+	idx, _ := newFixtureBTCIndexer(t, "multisig", config.ChainConfig{
+		NetworkId:        "btc_fixture",
+		IndexUTXO:        true,
+		IndexNonstandard: true,
+	})
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+1)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 3, "bare multisig output is now emitted")
+
+	bare := block.Transactions[0]
+	assert.Equal(t, constant.TxTypeNonstandard, bare.Type)
+	assert.Equal(t, bitcoin.ScriptID(bare.GetMetadataString(btcMetaScriptPubKey)), bare.ToAddress)
+	assert.True(t, strings.HasPrefix(bare.ToAddress, bitcoin.ScriptIDPrefix))
+	assert.Equal(t, "50000", bare.Amount)
+	assert.Equal(t, "multisig", bare.GetMetadataString(btcMetaScriptType))
+	assert.Equal(t, "0.0002", bare.TxFee.String(), "fee goes back to vout 0")
+	for _, tr := range block.Transactions[1:] {
+		assert.Equal(t, constant.TxTypeNativeTransfer, tr.Type)
+	}
+
+	events := block.Metadata["utxo_events"].([]types.UTXOEvent)
+	require.Len(t, events, 1)
+	assert.Len(t, events[0].Created, 3)
+	assert.Equal(t, bare.ToAddress, events[0].Created[0].Address)
+}
+
+// stripPrevoutsFixture copies the op_return fixture into a temp dir with the
+// block's prevout data removed, as a node serving verbosity 3 without undo
+// data would. With serveTx the spending transaction is also served through
+// getrawtransaction, prevouts included, so enrichment can succeed.
+func stripPrevoutsFixture(t *testing.T, serveTx bool) string {
+	t.Helper()
+	const (
+		height = btcIntegrationBlock + 2
+		hash   = "0b6f674ab1ebc8e1a3e77d69bb49962240568c4d51e775e5cbb1b264d70ffdb2"
+	)
+	src := filepath.Join("testdata", "bitcoin", "op_return")
+	dir := t.TempDir()
+	for _, call := range []struct {
+		method string
+		params []any
+	}{{"getblockcount", nil}, {"getblockhash", []any{height}}} {
+		f, err := bitcointest.ReadFixture(src, call.method, call.params)
+		require.NoError(t, err)
+		require.NoError(t, bitcointest.WriteFixture(dir, call.method, call.params, *f))
+	}
+
+	f, err := bitcointest.ReadFixture(src, "getblock", []any{hash, 3})
+	require.NoError(t, err)
+	var block map[string]any
+	require.NoError(t, json.Unmarshal(f.Result, &block))
+	for _, raw := range block["tx"].([]any) {
+		tx := raw.(map[string]any)
+		if serveTx {
+			full, err := json.Marshal(tx)
+			require.NoError(t, err)
+			require.NoError(t, bitcointest.WriteFixture(dir, "getrawtransaction",
+				[]any{tx["txid"], 2}, bitcointest.Fixture{Result: full}))
+		}
+		for _, vin := range tx["vin"].([]any) {
+			delete(vin.(map[string]any), "prevout")
+		}
+	}
+	stripped, err := json.Marshal(block)
+	require.NoError(t, err)
+	require.NoError(t, bitcointest.WriteFixture(dir, "getblock", []any{hash, 3}, bitcointest.Fixture{Result: stripped}))
+	return dir
+}
+
+func newStrippedBTCIndexer(t *testing.T, cfg config.ChainConfig, dirs ...string) (*BitcoinIndexer, []*bitcointest.Server) {
+	t.Helper()
+	servers := make([]*bitcointest.Server, len(dirs))
+	for i, dir := range dirs {
+		servers[i] = bitcointest.NewServer(t, dir)
+	}
+	cfg.NetworkId = "btc_fixture"
+	cfg.Throttle.Concurrency = 2
+	return NewBitcoinIndexer("btc_fixture", cfg, bitcointest.NewFailover(t, servers...), nil), servers
+}
+
+func TestBitcoinGetBlock_PartialEnrichmentFailsBlock(t *testing.T) {
+	idx, _ := newStrippedBTCIndexer(t, config.ChainConfig{}, stripPrevoutsFixture(t, false))
+
+	_, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	var partial *bitcoin.PartialEnrichmentError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, 1, partial.Missing)
+	assert.Equal(t, 1, partial.Total)
+	assert.InDelta(t, 1.0, partial.Ratio(), 1e-9)
+	assert.NotErrorIs(t, err, rpc.ErrNotFound, "missing prevouts must not read as a missing block")
+}
+
+func TestBitcoinGetBlock_PartialEnrichmentRetriesOtherProvider(t *testing.T) {
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{},
+		stripPrevoutsFixture(t, false), stripPrevoutsFixture(t, true))
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, srvs[0].Calls("getrawtransaction"))
+	assert.Equal(t, 1, srvs[1].Calls("getrawtransaction"))
+	require.NotEmpty(t, block.Transactions)
+	assert.Equal(t, "0.00001", block.Transactions[0].TxFee.String())
+	assert.Equal(t, []string{"tb1qvnsryrfswcgy8sseu54uv29ph9xyl6gzln9yev"}, block.Transactions[0].FromAddresses)
+}
+
+func TestBitcoinEnrichPrevouts_ResumesOnOtherProvider(t *testing.T) {
+	prevs := []string{"prev0", "prev1", "prev2", "prev3"}
+	spend := bitcoin.Transaction{TxID: "spend"}
+	for _, prev := range prevs {
+		spend.Vin = append(spend.Vin, bitcoin.Input{TxID: prev})
+	}
+	writeTx := func(dir string, tx bitcoin.Transaction) {
+		raw, err := json.Marshal(tx)
+		require.NoError(t, err)
+		require.NoError(t, bitcointest.WriteFixture(dir, "getrawtransaction", []any{tx.TxID, 2},
+			bitcointest.Fixture{Result: raw}))
+	}
+	prevTx := func(txid string) bitcoin.Transaction {
+		return bitcoin.Transaction{TxID: txid, Vout: []bitcoin.Output{{Value: 0.001, ScriptPubKey: bitcoin.ScriptPubKey{
+			Hex: "0014aa", Type: "witness_v0_keyhash",
+		}}}}
+	}
+	// The first node only knows half of the spent transactions.
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		writeTx(dir, spend)
+	}
+	for i, prev := range prevs {
+		if i < 2 {
+			writeTx(first, prevTx(prev))
+		}
+		writeTx(second, prevTx(prev))
+	}
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{}, first, second)
+
+	btcBlock := &bitcoin.Block{Height: 1, Tx: []bitcoin.Transaction{spend}}
+	btcBlock.Tx[0].Vin = slices.Clone(spend.Vin)
+	require.NoError(t, idx.enrichPrevouts(context.Background(), btcBlock, []int{0}))
+
+	assert.ElementsMatch(t, []string{
+		"getrawtransaction-spend-2.json",
+		"getrawtransaction-prev0-2.json", "getrawtransaction-prev1-2.json",
+		"getrawtransaction-prev2-2.json", "getrawtransaction-prev3-2.json",
+	}, srvs[0].Requests())
+	assert.ElementsMatch(t, []string{
+		"getrawtransaction-prev2-2.json", "getrawtransaction-prev3-2.json",
+	}, srvs[1].Requests(), "the second node only looks up what the first left")
+	for k, vin := range btcBlock.Tx[0].Vin {
+		assert.NotNil(t, vin.PrevOut, "input %d", k)
+	}
+}
+
+func TestBitcoinGetBlock_NonBitcoinProviderFailsEnrichment(t *testing.T) {
+	idx, _ := newStrippedBTCIndexer(t, config.ChainConfig{},
+		stripPrevoutsFixture(t, false), stripPrevoutsFixture(t, false))
+	idx.failover.Providers()[1].Client = struct{ rpc.NetworkClient }{}
+
+	_, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a Bitcoin client")
+}
+
+func TestBitcoinGetBlock_PartialEnrichmentWithinThreshold(t *testing.T) {
+	all := 1.0
+	idx, _ := newStrippedBTCIndexer(t, config.ChainConfig{MaxMissingPrevouts: &all}, stripPrevoutsFixture(t, false))
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err, "tolerated missing prevouts proceed with partial data")
+	require.NotEmpty(t, block.Transactions)
+	assert.True(t, block.Transactions[0].TxFee.IsZero())
+}
+
+func TestBitcoinGetBlock_EnrichmentStopsOnCancel(t *testing.T) {
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{}, stripPrevoutsFixture(t, true))
+	btcBlock, err := bitcointest.NewClient(srvs[0]).GetBlockByHeight(context.Background(), btcIntegrationBlock+2, 3)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = idx.convertBlockWithPrevoutResolution(ctx, btcBlock)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, srvs[0].Calls("getrawtransaction"), "no fetch starts after the deadline")
+}
+
+func TestBitcoinGetBlock_FixtureOutputIsStable(t *testing.T) {
+	cfg := config.ChainConfig{NetworkId: "btc_fixture", IndexUTXO: true, IndexNonstandard: true}
+	for _, tc := range []struct {
+		fixture string
+		height  uint64
+	}{
+		{"segwit_heavy", btcIntegrationBlock},
+		{"multisig", btcIntegrationBlock + 1},
+		{"op_return", btcIntegrationBlock + 2},
+	} {
+		t.Run(tc.fixture, func(t *testing.T) {
+			var first []byte
+			for run := 0; run < 10; run++ {
+				idx, _ := newFixtureBTCIndexer(t, tc.fixture, cfg)
+				block, err := idx.GetBlock(context.Background(), tc.height)
+				require.NoError(t, err)
+				got, err := json.Marshal(struct {
+					Transfers []types.Transaction
+					UTXO      any
+				}{block.Transactions, block.Metadata["utxo_events"]})
+				require.NoError(t, err)
+				if run == 0 {
+					first = got
+					continue
+				}
+				require.Equal(t, string(first), string(got), "run %d differs", run)
+			}
+		})
+	}
+}
+
+// withIndexInfo adds a getindexinfo fixture reporting txindex on or off.
+func withIndexInfo(t *testing.T, dir string, txindex bool) string {
+	t.Helper()
+	result := `{}`
+	if txindex {
+		result = `{"txindex":{"synced":true,"best_block_height":4842316}}`
+	}
+	require.NoError(t, bitcointest.WriteFixture(dir, "getindexinfo", []any{"txindex"},
+		bitcointest.Fixture{Result: json.RawMessage(result)}))
+	return dir
+}
+
+func TestBitcoinGetBlock_PrevoutsRoutedToTxIndexNodes(t *testing.T) {
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{},
+		withIndexInfo(t, stripPrevoutsFixture(t, false), false),
+		withIndexInfo(t, stripPrevoutsFixture(t, true), true))
+	idx.ProbeTxIndex(context.Background())
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, srvs[0].Calls("getblock"), "block fetch still uses the first node")
+	assert.Zero(t, srvs[0].Calls("getrawtransaction"), "no prevout lookups on a node without txindex")
+	assert.Equal(t, 1, srvs[1].Calls("getrawtransaction"))
+	assert.Equal(t, "0.00001", block.Transactions[0].TxFee.String())
+}
+
+func TestBitcoinGetBlock_NoTxIndexAnywhereProceeds(t *testing.T) {
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{},
+		withIndexInfo(t, stripPrevoutsFixture(t, false), false))
+	idx.ProbeTxIndex(context.Background())
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err, "failing the block cannot help when no node can look prevouts up")
+	assert.Zero(t, srvs[0].Calls("getrawtransaction"))
+	assert.True(t, block.Transactions[0].TxFee.IsZero())
+}
+
+func TestBitcoinGetBlock_NoTxIndexUsesRecentBlockHint(t *testing.T) {
+	const (
+		prevTxid  = "832874e8295fa82fee0cc9ee0a14fba0d7d9d0469db788591656cef845a4850f"
+		prevBlock = "00000000000000160b4a4e2ab1ccf0b49f1a8d2e9e1d6e6b7f4a1c2b3d4e5f60"
+	)
+	dir := withIndexInfo(t, stripPrevoutsFixture(t, false), false)
+	prevTx := `{"txid":"` + prevTxid + `","vin":[],"vout":[
+		{"value":0.5,"n":0,"scriptPubKey":{"hex":"0014aa","type":"witness_v0_keyhash"}},
+		{"value":0.001,"n":1,"scriptPubKey":{"hex":"001464e0320d30761043c219e52bc628a1b94c4fe902",
+			"address":"tb1qvnsryrfswcgy8sseu54uv29ph9xyl6gzln9yev","type":"witness_v0_keyhash"}}]}`
+	require.NoError(t, bitcointest.WriteFixture(dir, "getrawtransaction", []any{prevTxid, 2, prevBlock},
+		bitcointest.Fixture{Result: json.RawMessage(prevTx)}))
+
+	idx, srvs := newStrippedBTCIndexer(t, config.ChainConfig{}, dir)
+	idx.ProbeTxIndex(context.Background())
+	idx.recentTxs.add(prevBlock, []string{prevTxid})
+
+	block, err := idx.GetBlock(context.Background(), btcIntegrationBlock+2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, srvs[0].Calls("getrawtransaction"), "only the hinted lookup")
+	assert.Empty(t, srvs[0].Misses())
+	assert.Equal(t, "0.00001", block.Transactions[0].TxFee.String())
+}
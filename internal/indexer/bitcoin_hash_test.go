@@ -0,0 +1,88 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forkBTCNode is a BitcoinAPI stub knowing a main chain up to tip and the
+// stale blocks a reorg replaced.
+type forkBTCNode struct {
+	bitcoin.BitcoinAPI
+	tip    uint64
+	blocks map[string]*bitcoin.Block
+	main   map[uint64]string
+}
+
+func newForkBTCNode(tip uint64) *forkBTCNode {
+	n := &forkBTCNode{tip: tip, blocks: make(map[string]*bitcoin.Block), main: make(map[uint64]string)}
+	for h := uint64(1); h <= tip; h++ {
+		n.add(fmt.Sprintf("main-%d", h), h, true)
+	}
+	return n
+}
+
+func (n *forkBTCNode) add(hash string, height uint64, main bool) {
+	block := &bitcoin.Block{Hash: hash, Height: height, Confirmations: -1}
+	if main {
+		block.Confirmations = int64(n.tip - height + 1)
+		n.main[height] = hash
+	}
+	n.blocks[hash] = block
+}
+
+func (n *forkBTCNode) GetBlock(_ context.Context, hash string, _ int) (*bitcoin.Block, error) {
+	block, ok := n.blocks[hash]
+	if !ok {
+		return nil, &rpc.RPCError{Code: -5, Message: "Block not found"}
+	}
+	copied := *block
+	return &copied, nil
+}
+
+func (n *forkBTCNode) GetBlockHash(_ context.Context, height uint64) (string, error) {
+	hash, ok := n.main[height]
+	if !ok {
+		return "", &rpc.RPCError{Code: -8, Message: "Block height out of range"}
+	}
+	return hash, nil
+}
+
+func TestBitcoinGetBlockByHash_Canonical(t *testing.T) {
+	node := newForkBTCNode(10)
+	node.add("stale-9", 9, false)
+	node.add("stale-11", 11, false)
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	require.NoError(t, f.AddProvider(&rpc.Provider{Name: "node", URL: "http://node", Client: node, State: rpc.StateHealthy}))
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+
+	for _, tc := range []struct {
+		hash      string
+		height    uint64
+		canonical bool
+	}{
+		{"main-9", 9, true},
+		{"stale-9", 9, false},
+		{"stale-11", 11, false},
+	} {
+		block, err := idx.GetBlockByHash(context.Background(), tc.hash)
+		require.NoError(t, err, tc.hash)
+		assert.Equal(t, tc.hash, block.Hash)
+		assert.Equal(t, tc.height, block.Number, tc.hash)
+		canonical, ok := block.GetMetadata(btcBlockMetaCanonical)
+		require.True(t, ok, tc.hash)
+		assert.Equal(t, tc.canonical, canonical, tc.hash)
+	}
+
+	_, err := idx.GetBlockByHash(context.Background(), "unknown")
+	require.ErrorIs(t, err, rpc.ErrNotFound)
+}
@@ -0,0 +1,120 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// btcMetaLightning carries a ChannelTag on transfers of probable Lightning
+// channel opens and closes.
+const btcMetaLightning = "lightning"
+
+// Channel roles of a tagged transfer.
+const (
+	ChannelRoleFunding = "funding" // the transfer's output may fund a channel
+	ChannelRoleClose   = "close"   // the transfer's transaction may close one
+)
+
+// ChannelTag describes a transfer's part in a probable Lightning channel.
+type ChannelTag struct {
+	Role       string                    `json:"role"`
+	Confidence bitcoin.ChannelConfidence `json:"confidence"`
+	CloseType  bitcoin.ChannelCloseType  `json:"close_type,omitempty"`
+	// FundingOutpoint is the outpoint ("txid:vout") a close spends.
+	FundingOutpoint string `json:"funding_outpoint,omitempty"`
+}
+
+// ChannelStore remembers probable channel funding outpoints ("txid:vout")
+// for a while, so the transaction spending one can be tagged as a close
+// with more confidence.
+type ChannelStore interface {
+	SaveFundings(ctx context.Context, chain string, outpoints []string, ttl time.Duration) error
+	// TakeFundings returns which of outpoints were remembered, forgetting
+	// them.
+	TakeFundings(ctx context.Context, chain string, outpoints []string) ([]string, error)
+}
+
+// UseChannelStore remembers the funding outputs found by Lightning channel
+// detection in store, see config.LightningConfig.
+func (b *BitcoinIndexer) UseChannelStore(store ChannelStore) {
+	b.channelStore = store
+}
+
+// tagChannels tags the transfers of btcBlock's probable channel fundings
+// and closes, see bitcoin.ChannelFundingOutputs and
+// bitcoin.DetectChannelClose. A close spending a remembered funding output
+// is certain enough to be tagged with high confidence. Store errors only
+// lose that boost, so they are logged rather than failing the block.
+func (b *BitcoinIndexer) tagChannels(ctx context.Context, btcBlock *bitcoin.Block, transfers []types.Transaction) {
+	if !b.config.Lightning.Enabled {
+		return
+	}
+
+	fundings := make(map[string]bitcoin.ChannelConfidence)
+	closes := make(map[string]ChannelTag) // by txid
+	var fundingOutpoints, spentOutpoints []string
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		for vout, confidence := range bitcoin.ChannelFundingOutputs(tx) {
+			outpoint := fmt.Sprintf("%s:%d", tx.TxID, vout)
+			fundings[outpoint] = confidence
+			fundingOutpoints = append(fundingOutpoints, outpoint)
+		}
+		if c, ok := bitcoin.DetectChannelClose(tx); ok {
+			vin := tx.Vin[c.Vin]
+			outpoint := fmt.Sprintf("%s:%d", vin.TxID, vin.Vout)
+			closes[tx.TxID] = ChannelTag{
+				Role:            ChannelRoleClose,
+				Confidence:      c.Confidence,
+				CloseType:       c.Type,
+				FundingOutpoint: outpoint,
+			}
+			spentOutpoints = append(spentOutpoints, outpoint)
+		}
+	}
+	if len(fundings) == 0 && len(closes) == 0 {
+		return
+	}
+
+	// Save first, so a channel opened and closed within the block is found.
+	remembered := make(map[string]bool)
+	if b.channelStore != nil {
+		retention := b.config.Lightning.Retention
+		if retention <= 0 {
+			retention = config.DefaultLightningRetention
+		}
+		if err := b.channelStore.SaveFundings(ctx, b.chainName, fundingOutpoints, retention); err != nil {
+			b.logger().Warn("Failed to remember channel fundings", "block", btcBlock.Height, "error", err)
+		}
+		found, err := b.channelStore.TakeFundings(ctx, b.chainName, spentOutpoints)
+		if err != nil {
+			b.logger().Warn("Failed to look up channel fundings", "block", btcBlock.Height, "error", err)
+		}
+		for _, outpoint := range found {
+			remembered[outpoint] = true
+		}
+	}
+
+	for i := range transfers {
+		t := &transfers[i]
+		if tag, ok := closes[t.TxHash]; ok {
+			if remembered[tag.FundingOutpoint] {
+				tag.Confidence = bitcoin.ConfidenceHigh
+			}
+			t.SetMetadata(btcMetaLightning, tag)
+			continue
+		}
+		vout, ok := t.GetMetadata(btcMetaVout)
+		if !ok {
+			continue
+		}
+		if confidence, ok := fundings[fmt.Sprintf("%s:%d", t.TxHash, vout)]; ok {
+			t.SetMetadata(btcMetaLightning, ChannelTag{Role: ChannelRoleFunding, Confidence: confidence})
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memChannelStore is an in-memory ChannelStore.
+type memChannelStore struct {
+	outpoints map[string]time.Duration
+}
+
+func (s *memChannelStore) SaveFundings(_ context.Context, chain string, outpoints []string, ttl time.Duration) error {
+	for _, o := range outpoints {
+		s.outpoints[chain+"/"+o] = ttl
+	}
+	return nil
+}
+
+func (s *memChannelStore) TakeFundings(_ context.Context, chain string, outpoints []string) ([]string, error) {
+	var found []string
+	for _, o := range outpoints {
+		if _, ok := s.outpoints[chain+"/"+o]; ok {
+			delete(s.outpoints, chain+"/"+o)
+			found = append(found, o)
+		}
+	}
+	return found, nil
+}
+
+// Funding script of the BOLT-3 test vectors; signatures are elided.
+const lnFundingScript = "5221023da092f6980e58d2c037173180e9a465476026ee50f96695963e8efe436f54eb" +
+	"21030e9f7b623d2ccc7c9bd44d66d5ce21ce504c0acf6385a132cec6d3c39fa711c152ae"
+
+func lnOpenTx() bitcoin.Transaction {
+	in := btcInput("prev", 0, "bc1qsender", 0.2)
+	in.Witness = []string{"", "02aa"}
+	funding := btcOutput("bc1qfunding", 0.1, 0)
+	funding.ScriptPubKey.Type = "witness_v0_scripthash"
+	return bitcoin.Transaction{
+		TxID: "open",
+		Vin:  []bitcoin.Input{in},
+		Vout: []bitcoin.Output{funding, btcOutput("bc1qchange", 0.0999, 1)},
+	}
+}
+
+func lnCloseTx() bitcoin.Transaction {
+	in := btcInput("open", 0, "bc1qfunding", 0.1)
+	in.PrevOut.ScriptPubKey.Type = "witness_v0_scripthash"
+	in.Sequence = 0xffffffff
+	in.Witness = []string{"", "", "", lnFundingScript}
+	return bitcoin.Transaction{
+		TxID: "coop_close",
+		Vin:  []bitcoin.Input{in},
+		Vout: []bitcoin.Output{btcOutput("bc1qalice", 0.06, 0), btcOutput("bc1qbob", 0.0399, 1)},
+	}
+}
+
+func tagBlock(t *testing.T, idx *BitcoinIndexer, height uint64, txs ...bitcoin.Transaction) []types.Transaction {
+	t.Helper()
+	blk := &bitcoin.Block{Hash: "bh", Height: height, Tx: txs}
+	transfers := idx.DefaultTransferExtractor().Extract(blk, idx.chainContext(height))
+	idx.tagChannels(context.Background(), blk, transfers)
+	return transfers
+}
+
+func lnTag(t *testing.T, tr types.Transaction) (ChannelTag, bool) {
+	t.Helper()
+	v, ok := tr.GetMetadata(btcMetaLightning)
+	if !ok {
+		return ChannelTag{}, false
+	}
+	tag, ok := v.(ChannelTag)
+	require.True(t, ok)
+	return tag, true
+}
+
+func TestBitcoinTagChannels_OpenThenClose(t *testing.T) {
+	store := &memChannelStore{outpoints: map[string]time.Duration{}}
+	idx := newBTCTestIndexer(config.ChainConfig{
+		NetworkId: "mainnet",
+		Lightning: config.LightningConfig{Enabled: true, Retention: time.Hour},
+	})
+	idx.UseChannelStore(store)
+
+	opened := tagBlock(t, idx, 100, lnOpenTx())
+	require.Len(t, opened, 2)
+	tag, ok := lnTag(t, opened[0])
+	require.True(t, ok)
+	assert.Equal(t, ChannelTag{Role: ChannelRoleFunding, Confidence: bitcoin.ConfidenceMedium}, tag)
+	_, ok = lnTag(t, opened[1])
+	assert.False(t, ok, "change output is not tagged")
+	assert.Equal(t, map[string]time.Duration{"bitcoin_test/open:0": time.Hour}, store.outpoints)
+
+	closed := tagBlock(t, idx, 200, lnCloseTx())
+	require.Len(t, closed, 2)
+	for _, tr := range closed {
+		tag, ok := lnTag(t, tr)
+		require.True(t, ok)
+		assert.Equal(t, ChannelTag{
+			Role:            ChannelRoleClose,
+			Confidence:      bitcoin.ConfidenceHigh,
+			CloseType:       bitcoin.CloseCooperative,
+			FundingOutpoint: "open:0",
+		}, tag)
+	}
+	assert.Empty(t, store.outpoints, "a spent funding is forgotten")
+}
+
+func TestBitcoinTagChannels_CloseOfUnknownFunding(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet", Lightning: config.LightningConfig{Enabled: true}})
+
+	closed := tagBlock(t, idx, 200, lnCloseTx())
+
+	tag, ok := lnTag(t, closed[0])
+	require.True(t, ok)
+	assert.Equal(t, bitcoin.ConfidenceMedium, tag.Confidence, "no store, no boost")
+}
+
+func TestBitcoinTagChannels_Disabled(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet"})
+
+	for _, tr := range tagBlock(t, idx, 100, lnOpenTx(), lnCloseTx()) {
+		_, ok := lnTag(t, tr)
+		assert.False(t, ok)
+	}
+}
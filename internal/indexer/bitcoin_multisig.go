@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"encoding/hex"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// Metadata keys of transfers involving multisig addresses with a
+// registered redeem script.
+const (
+	// btcMetaRedeemScriptMatch is set on a transfer paying such an address:
+	// false when the output does not pay the registered script, a
+	// registration error or an address collision to investigate.
+	btcMetaRedeemScriptMatch = "redeem_script_match"
+	// btcMetaCosigners carries the []btcMultisigSpend of a transaction
+	// spending from such addresses.
+	btcMetaCosigners = "cosigners"
+)
+
+// btcMultisigSpend tells which cosigners signed an input spending from a
+// multisig address, by their keys in the registered script's order.
+type btcMultisigSpend struct {
+	Input    int      `json:"input"`
+	Address  string   `json:"address"`
+	Required int      `json:"required"`
+	Signers  []string `json:"signers"`
+}
+
+// redeemScript returns the redeem script registered for addr, if the
+// pubkey store knows redeem scripts.
+func (b *BitcoinIndexer) redeemScript(addr string) ([]byte, bool) {
+	resolver, ok := b.pubkeyStore.(RedeemScriptResolver)
+	if !ok || addr == "" {
+		return nil, false
+	}
+	script, ok := resolver.RedeemScript(enum.NetworkTypeBtc, addr)
+	if !ok {
+		return nil, false
+	}
+	raw, err := hex.DecodeString(script)
+	return raw, err == nil
+}
+
+// multisigSpends decodes the signers of the inputs of tx spending from
+// addresses with a registered redeem script. Inputs whose signatures
+// cannot be checked, e.g. for want of their prevout value, are left out.
+func (b *BitcoinIndexer) multisigSpends(tx *bitcoin.Transaction) []btcMultisigSpend {
+	if _, ok := b.pubkeyStore.(RedeemScriptResolver); !ok {
+		return nil
+	}
+	var spends []btcMultisigSpend
+	for i := range tx.Vin {
+		addr := bitcoin.GetInputAddress(&tx.Vin[i])
+		script, ok := b.redeemScript(addr)
+		if !ok {
+			continue
+		}
+		signers, ok := bitcoin.MultisigSigners(tx, i, script)
+		if !ok {
+			b.logger().Debug("Cannot tell the cosigners of a multisig spend",
+				"tx", tx.TxID, "input", i, "address", addr)
+			continue
+		}
+		ms, _ := bitcoin.ParseMultisig(script)
+		spends = append(spends, btcMultisigSpend{Input: i, Address: addr, Required: ms.Required, Signers: signers})
+	}
+	return spends
+}
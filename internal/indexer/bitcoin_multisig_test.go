@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// btc2of3 is a 2-of-3 multisig script over the keys G, 2G and 3G.
+const btc2of3 = "52" +
+	"210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+	"2102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5" +
+	"2102f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9" +
+	"53ae"
+
+// btcRedeemScripts knows redeem scripts by address.
+type btcRedeemScripts map[string]string
+
+func (s btcRedeemScripts) Exist(_ enum.NetworkType, address string) bool {
+	_, ok := s[address]
+	return ok
+}
+
+func (s btcRedeemScripts) RedeemScript(_ enum.NetworkType, address string) (string, bool) {
+	script, ok := s[address]
+	return script, ok
+}
+
+func TestBitcoinExtractTransfers_RedeemScripts(t *testing.T) {
+	redeem, err := hex.DecodeString(btc2of3)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(redeem)
+	p2wsh := "0020" + hex.EncodeToString(scriptHash[:])
+
+	deposit := btcOutput("bc1qvault", 0.5, 0)
+	deposit.ScriptPubKey.Hex = p2wsh
+	collision := btcOutput("bc1qmisregistered", 0.2, 1)
+	collision.ScriptPubKey.Hex = "0020" + hex.EncodeToString(make([]byte, 32))
+	tx := &bitcoin.Transaction{
+		TxID: "deposit",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qcustomer", 0.8)},
+		Vout: []bitcoin.Output{deposit, collision, btcOutput("bc1qcustomer", 0.09, 2)},
+	}
+
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+	idx.pubkeyStore = btcRedeemScripts{"bc1qvault": btc2of3, "bc1qmisregistered": btc2of3}
+	transfers := idx.extractTransfersFromTx(tx, "h", 100, 1_000_000, 100)
+	require.Len(t, transfers, 3)
+
+	match, ok := transfers[0].GetMetadata(btcMetaRedeemScriptMatch)
+	require.True(t, ok)
+	assert.Equal(t, true, match)
+	match, ok = transfers[1].GetMetadata(btcMetaRedeemScriptMatch)
+	require.True(t, ok)
+	assert.Equal(t, false, match, "output pays another script than registered")
+	_, ok = transfers[2].GetMetadata(btcMetaRedeemScriptMatch)
+	assert.False(t, ok, "no script registered")
+	_, ok = transfers[0].GetMetadata(btcMetaCosigners)
+	assert.False(t, ok, "nothing spent from a multisig address")
+
+	// Spending the deposit: the witness carries no valid signature, so no
+	// cosigner is reported, but the spend is decoded.
+	txid := strings.Repeat("ab", 32)
+	spendIn := btcInput(txid, 0, "bc1qvault", 0.5)
+	spendIn.PrevOut.ScriptPubKey.Hex = p2wsh
+	spendIn.Witness = []string{"", "300602010102010101", btc2of3}
+	spend := &bitcoin.Transaction{
+		TxID: "spend",
+		Vin:  []bitcoin.Input{spendIn, btcInput(txid, 1, "bc1qcustomer", 0.1)},
+		Vout: []bitcoin.Output{btcOutput("bc1qpayee", 0.59, 0)},
+	}
+	transfers = idx.extractTransfersFromTx(spend, "h", 101, 1_000_000, 101)
+	require.Len(t, transfers, 1)
+	cosigners, ok := transfers[0].GetMetadata(btcMetaCosigners)
+	require.True(t, ok)
+	assert.Equal(t, []btcMultisigSpend{{Input: 0, Address: "bc1qvault", Required: 2}}, cosigners)
+}
@@ -0,0 +1,98 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// networkNode serves getblockchaininfo and the genesis hash of a node on
+// network n.
+func networkNode(t *testing.T, n bitcoin.Network) *bitcointest.Server {
+	t.Helper()
+	params, err := bitcoin.ParamsFor(n)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, bitcointest.WriteFixture(dir, "getblockchaininfo", nil, bitcointest.Fixture{
+		Result: json.RawMessage(`{"chain":` + strconv.Quote(params.CoreChain) + `,"blocks":1}`),
+	}))
+	require.NoError(t, bitcointest.WriteFixture(dir, "getblockhash", []any{0}, bitcointest.Fixture{
+		Result: json.RawMessage(strconv.Quote(params.GenesisHash)),
+	}))
+	return bitcointest.NewServer(t, dir)
+}
+
+// identity is what nodes on network n report.
+func identity(t *testing.T, n bitcoin.Network) rpc.NetworkIdentity {
+	t.Helper()
+	params, err := bitcoin.ParamsFor(n)
+	require.NoError(t, err)
+	return rpc.NetworkIdentity{ChainID: params.CoreChain, GenesisHash: params.GenesisHash}
+}
+
+// providerNames lists the providers left in f's pool.
+func providerNames(f *rpc.Failover[bitcoin.BitcoinAPI]) []string {
+	var names []string
+	for _, p := range f.Providers() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestBitcoinExpectNetwork(t *testing.T) {
+	check := func(network bitcoin.Network, servers ...*bitcointest.Server) (*rpc.Failover[bitcoin.BitcoinAPI], error) {
+		f := bitcointest.NewFailover(t, servers...)
+		idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+		return f, idx.ExpectNetwork(context.Background(), identity(t, network))
+	}
+
+	f, err := check(bitcoin.NetworkTestnet4, networkNode(t, bitcoin.NetworkTestnet4), networkNode(t, bitcoin.NetworkTestnet4))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fixture-1", "fixture-2"}, providerNames(f))
+
+	f, err = check(bitcoin.NetworkTestnet3, networkNode(t, bitcoin.NetworkTestnet3), networkNode(t, bitcoin.NetworkSignet))
+	require.NoError(t, err, "one node still serves the network")
+	assert.Equal(t, []string{"fixture-1"}, providerNames(f), "the signet node is excluded")
+	assert.Equal(t, []string{"fixture-2"}, f.GetMetrics()["rejected_providers"])
+
+	_, err = check(bitcoin.NetworkMainnet, networkNode(t, bitcoin.NetworkSignet))
+	require.ErrorIs(t, err, rpc.ErrWrongNetwork)
+	assert.ErrorContains(t, err, "provider fixture-1: node serves another network: chain ID is signet, expected main")
+
+	unreachable := bitcointest.NewServer(t, t.TempDir())
+	f, err = check(bitcoin.NetworkMainnet, unreachable, networkNode(t, bitcoin.NetworkMainnet))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fixture-2"}, providerNames(f), "unchecked nodes are held out")
+	assert.Equal(t, []string{"fixture-1"}, f.GetMetrics()["unverified_providers"])
+}
+
+func TestBitcoinExpectNetwork_GenesisMismatch(t *testing.T) {
+	// A node reporting the configured chain name with another genesis,
+	// e.g. a testnet3 node relabelled by a proxy.
+	dir := t.TempDir()
+	require.NoError(t, bitcointest.WriteFixture(dir, "getblockchaininfo", nil, bitcointest.Fixture{
+		Result: json.RawMessage(`{"chain":"testnet4"}`),
+	}))
+	testnet3, err := bitcoin.ParamsFor(bitcoin.NetworkTestnet3)
+	require.NoError(t, err)
+	require.NoError(t, bitcointest.WriteFixture(dir, "getblockhash", []any{0}, bitcointest.Fixture{
+		Result: json.RawMessage(strconv.Quote(testnet3.GenesisHash)),
+	}))
+
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{},
+		bitcointest.NewFailover(t, bitcointest.NewServer(t, dir)), nil)
+	err = idx.ExpectNetwork(context.Background(), identity(t, bitcoin.NetworkTestnet4))
+	require.ErrorIs(t, err, rpc.ErrWrongNetwork)
+	assert.ErrorContains(t, err, "genesis hash is "+testnet3.GenesisHash)
+
+	_, err = bitcoin.ParamsFor("testnet5")
+	assert.EqualError(t, err, `unknown bitcoin network "testnet5"`)
+}
@@ -0,0 +1,125 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// btcMetaInscriptions carries the []InscriptionTag of the inscriptions
+	// landing on a transfer's output.
+	btcMetaInscriptions = "inscriptions"
+	// btcMetaBRC20 carries the bitcoin.BRC20Op of a BRC-20 token transfer.
+	btcMetaBRC20 = "brc20"
+)
+
+// BRC20AssetPrefix prefixes the tick in the AssetAddress of BRC-20 token
+// transfers.
+const BRC20AssetPrefix = "brc20:"
+
+// brc20Decimals is the precision of BRC-20 amounts, which the protocol
+// caps at 18 decimals.
+const brc20Decimals = 18
+
+// InscriptionTag describes an inscription revealed by input Vin. BRC20 is
+// set when its body is a BRC-20 operation.
+type InscriptionTag struct {
+	Vin         uint32           `json:"vin"`
+	ContentType string           `json:"content_type,omitempty"`
+	Size        int              `json:"size"`
+	BRC20       *bitcoin.BRC20Op `json:"brc20,omitempty"`
+}
+
+// applyInscriptions tags the transfers of the outputs tx's inscriptions
+// land on and returns them with a token transfer appended for each BRC-20
+// mint or transfer, paid to the output's address. An inscription lands on
+// the output holding the first sat of its input; one whose output cannot
+// be told (unknown prevouts) or has no transfer (spent as fee) is dropped,
+// as is a body that is not a valid BRC-20 operation, short of its tag.
+func (b *BitcoinIndexer) applyInscriptions(tx *bitcoin.Transaction, transfers []types.Transaction) []types.Transaction {
+	maxBody := b.config.Ordinals.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = config.DefaultInscriptionMaxBodyBytes
+	}
+	for i := range tx.Vin {
+		ins, ok := bitcoin.ParseInscription(&tx.Vin[i], maxBody)
+		if !ok {
+			continue
+		}
+		vout, ok := inscriptionOutput(tx, i)
+		if !ok {
+			continue
+		}
+		tag := InscriptionTag{Vin: uint32(i), ContentType: ins.ContentType, Size: ins.Size}
+		if ins.Body != nil {
+			tag.BRC20, _ = bitcoin.ParseBRC20(ins.Body)
+		}
+
+		var paid *types.Transaction
+		for j := range transfers {
+			t := &transfers[j]
+			if n, ok := t.GetMetadata(btcMetaVout); !ok || n != vout {
+				continue
+			}
+			tags, _ := t.GetMetadata(btcMetaInscriptions)
+			existing, _ := tags.([]InscriptionTag)
+			t.SetMetadata(btcMetaInscriptions, append(existing, tag))
+			if paid == nil {
+				paid = t
+			}
+		}
+		if paid == nil || tag.BRC20 == nil || tag.BRC20.Op == bitcoin.BRC20Deploy {
+			continue
+		}
+
+		record := types.Transaction{
+			TxHash:        paid.TxHash,
+			NetworkId:     paid.NetworkId,
+			InternalCode:  paid.InternalCode,
+			BlockHash:     paid.BlockHash,
+			BlockNumber:   paid.BlockNumber,
+			TransferIndex: fmt.Sprintf("%d:brc20:%d", vout, i),
+			FromAddress:   paid.FromAddress,
+			FromAddresses: paid.FromAddresses,
+			ToAddress:     paid.ToAddress,
+			AssetAddress:  BRC20AssetPrefix + tag.BRC20.Tick,
+			Type:          constant.TxTypeTokenTransfer,
+			TxFee:         decimal.Zero,
+			Timestamp:     paid.Timestamp,
+			Confirmations: paid.Confirmations,
+			Status:        paid.Status,
+		}
+		// BRC-20 amounts are stated in whole tokens.
+		record.SetDisplayAmount(tag.BRC20.Amt, types.AmountUnit{Name: tag.BRC20.Tick, Decimals: brc20Decimals})
+		record.SetMetadata(btcMetaVout, vout)
+		record.SetMetadata(btcMetaBRC20, *tag.BRC20)
+		record.EnsureTransferID()
+		transfers = append(transfers, record)
+	}
+	return transfers
+}
+
+// inscriptionOutput returns the output holding the first sat of input vin,
+// per ordinal theory's first-in-first-out sat order.
+func inscriptionOutput(tx *bitcoin.Transaction, vin int) (uint32, bool) {
+	var offset int64
+	for _, in := range tx.Vin[:vin] {
+		if in.PrevOut == nil {
+			return 0, false
+		}
+		offset += satoshisFromFloat(in.PrevOut.Value)
+	}
+	var end int64
+	for _, out := range sortedOutputs(tx.Vout) {
+		end += satoshisFromFloat(out.Value)
+		if offset < end {
+			return out.N, true
+		}
+	}
+	return 0, false
+}
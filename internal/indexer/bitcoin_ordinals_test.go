@@ -0,0 +1,121 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// btcRevealInput builds a taproot script-path input revealing a text/plain
+// inscription of body, of at most 75 bytes; the signature is elided.
+func btcRevealInput(prevTxID string, valueBTC float64, body string) bitcoin.Input {
+	push := func(data string) string {
+		return hex.EncodeToString([]byte{byte(len(data))}) + hex.EncodeToString([]byte(data))
+	}
+	script := "20" + strings.Repeat("00", 32) + "ac" + "0063" + push("ord") + "0101" + push("text/plain") + "00" +
+		push(body) + "68"
+	in := btcInput(prevTxID, 0, "bc1pcommit", valueBTC)
+	in.PrevOut.ScriptPubKey.Type = "witness_v1_taproot"
+	in.Witness = []string{"", script, "c0" + strings.Repeat("00", 32)}
+	return in
+}
+
+func ordinalsIndexer() *BitcoinIndexer {
+	return newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet", Ordinals: config.OrdinalsConfig{Enabled: true}})
+}
+
+func TestBitcoinExtractTransfers_BRC20Mint(t *testing.T) {
+	tx := &bitcoin.Transaction{
+		TxID: "reveal",
+		Vin:  []bitcoin.Input{btcRevealInput("commit", 0.0001, `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}`)},
+		Vout: []bitcoin.Output{btcOutput("bc1pminter", 0.00000546, 0)},
+	}
+
+	transfers := ordinalsIndexer().extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 2)
+	tags, ok := transfers[0].GetMetadata(btcMetaInscriptions)
+	require.True(t, ok)
+	brc20 := &bitcoin.BRC20Op{Op: bitcoin.BRC20Mint, Tick: "ordi", Amt: "1000"}
+	assert.Equal(t, []InscriptionTag{{Vin: 0, ContentType: "text/plain", Size: 53, BRC20: brc20}}, tags)
+
+	token := transfers[1]
+	assert.Equal(t, constant.TxTypeTokenTransfer, token.Type)
+	assert.Equal(t, "brc20:ordi", token.AssetAddress)
+	assert.Equal(t, "1000", token.Amount)
+	assert.True(t, token.DisplayAmount(), "BRC-20 amounts are in whole tokens")
+	assert.Equal(t, &types.AmountUnit{Name: "ordi", Decimals: 18}, token.Unit)
+	assert.Equal(t, "bc1pminter", token.ToAddress)
+	assert.Equal(t, "0:brc20:0", token.TransferIndex)
+	assert.True(t, token.TxFee.IsZero())
+	assert.NotEqual(t, transfers[0].TransferID, token.TransferID)
+}
+
+func TestBitcoinExtractTransfers_InscriptionDegradesToTag(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid json": `{"p":"brc-20","op":"mint",`,
+		"deploy":       `{"p":"brc-20","op":"deploy","tick":"ordi","max":"21000000"}`,
+		"plain text":   `hello`,
+	} {
+		tx := &bitcoin.Transaction{
+			TxID: "reveal",
+			Vin:  []bitcoin.Input{btcRevealInput("commit", 0.0001, body)},
+			Vout: []bitcoin.Output{btcOutput("bc1pholder", 0.00000546, 0)},
+		}
+
+		transfers := ordinalsIndexer().extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+		require.Len(t, transfers, 1, name)
+		_, ok := transfers[0].GetMetadata(btcMetaInscriptions)
+		assert.True(t, ok, name)
+	}
+}
+
+// TestBitcoinExtractTransfers_InscriptionFollowsFirstSat checks an
+// inscription on a later input lands on the output holding that input's
+// first sat.
+func TestBitcoinExtractTransfers_InscriptionFollowsFirstSat(t *testing.T) {
+	tx := &bitcoin.Transaction{
+		TxID: "reveal",
+		Vin: []bitcoin.Input{
+			btcInput("funding", 0, "bc1qpayer", 0.001),
+			btcRevealInput("commit", 0.0001, `{"p":"brc-20","op":"transfer","tick":"ordi","amt":"5"}`),
+		},
+		Vout: []bitcoin.Output{
+			btcOutput("bc1qchange", 0.001, 0),
+			btcOutput("bc1precipient", 0.00000546, 1),
+		},
+	}
+
+	transfers := ordinalsIndexer().extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 3)
+	_, ok := transfers[0].GetMetadata(btcMetaInscriptions)
+	assert.False(t, ok)
+	_, ok = transfers[1].GetMetadata(btcMetaInscriptions)
+	assert.True(t, ok)
+	assert.Equal(t, "bc1precipient", transfers[2].ToAddress)
+	assert.Equal(t, "brc20:ordi", transfers[2].AssetAddress)
+}
+
+func TestBitcoinExtractTransfers_OrdinalsDisabled(t *testing.T) {
+	idx := newBTCTestIndexer(config.ChainConfig{NetworkId: "mainnet"})
+	tx := &bitcoin.Transaction{
+		TxID: "reveal",
+		Vin:  []bitcoin.Input{btcRevealInput("commit", 0.0001, `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1"}`)},
+		Vout: []bitcoin.Output{btcOutput("bc1pminter", 0.00000546, 0)},
+	}
+
+	transfers := idx.extractTransfersFromTx(tx, "bh", 100, 1_000_000, 100)
+
+	require.Len(t, transfers, 1)
+	_, ok := transfers[0].GetMetadata(btcMetaInscriptions)
+	assert.False(t, ok)
+}
@@ -0,0 +1,180 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fetchedBlock is a raw block handed from the fetch pool to the process
+// pool. ctx carries the fetch's failover session, so prevout enrichment
+// hits the node that served the block.
+type fetchedBlock struct {
+	ctx   context.Context
+	index int
+	block *bitcoin.Block
+	span  trace.Span
+}
+
+// blockPipeline fetches blocks through a fetchScheduler, shared with the
+// indexer's other callers, and converts them with a pool of its own, so
+// decoding and extracting a large block never holds up the next fetch. A
+// run keeps at most fetcher.size + 2*processWorkers blocks outstanding,
+// fetched or being fetched but not yet converted: as many as the fetchers,
+// the process pool and a queue as deep as it hold. Fetching pauses while
+// processing falls behind instead of piling raw blocks up in memory.
+//
+// A panic fetching or processing a block fails that block with a
+// *PanicError; the rest of the run goes on. A run taking longer than budget,
+// if set, logs the heights still outstanding every budget until it ends.
+type blockPipeline struct {
+	fetcher        *btcFetchScheduler
+	processWorkers int
+	process        func(ctx context.Context, block *bitcoin.Block) (*types.Block, error)
+	budget         time.Duration
+	log            *slog.Logger
+}
+
+// processConcurrency returns the process pool size for a
+// Throttle.ProcessConcurrency of n. Processing resolves prevouts over RPC,
+// so when unset it is sized like the fetch pool, from Throttle.Concurrency,
+// rather than from the CPU count.
+func processConcurrency(n, concurrency int) int {
+	if n <= 0 {
+		return max(concurrency, 1)
+	}
+	return n
+}
+
+// run fetches and processes blockNumbers, with the fetch priority of ctx.
+// Results come back in the order of blockNumbers whatever order the pools
+// finish them in: each is written to its slot of the result slice, which
+// serves as the reorder buffer. Each result carries the block's trace span,
+// started as it is submitted for fetching, for the caller to end.
+func (p blockPipeline) run(ctx context.Context, blockNumbers []uint64) ([]BlockResult, error) {
+	results := make([]BlockResult, len(blockNumbers))
+	processWorkers := min(max(p.processWorkers, 1), len(blockNumbers))
+	window := min(p.fetcher.size+2*processWorkers, len(blockNumbers))
+
+	// slots bounds the outstanding blocks; raw is as deep, so handing a
+	// fetched block over never blocks a scheduler worker.
+	slots := make(chan struct{}, window)
+	raw := make(chan fetchedBlock, window)
+	var pending sync.WaitGroup
+	pending.Add(len(blockNumbers))
+	completed := make([]atomic.Bool, len(blockNumbers))
+	finish := func(i int) {
+		completed[i].Store(true)
+		<-slots
+		pending.Done()
+	}
+
+	go func() {
+		for i, num := range blockNumbers {
+			select {
+			case <-ctx.Done():
+				pending.Add(i - len(blockNumbers)) // never submitted
+				return
+			case slots <- struct{}{}:
+			}
+			blockCtx, span := tracing.Start(ctx, "block", attribute.Int64("block.number", int64(num)))
+			p.fetcher.submit(blockCtx, num, func(sessionCtx context.Context, block *bitcoin.Block, err error) {
+				if err != nil {
+					results[i] = BlockResult{Number: num, Error: NewError(err), Span: span}
+					finish(i)
+					return
+				}
+				raw <- fetchedBlock{ctx: sessionCtx, index: i, block: block, span: span}
+			})
+		}
+	}()
+	go func() {
+		pending.Wait()
+		close(raw)
+	}()
+
+	var processWG sync.WaitGroup
+	for range processWorkers {
+		processWG.Add(1)
+		go func() {
+			defer processWG.Done()
+			for f := range raw {
+				num := blockNumbers[f.index]
+				block, err := p.processRecovering(f.ctx, f.block)
+				results[f.index] = BlockResult{Number: num, Block: block, Span: f.span}
+				if err != nil {
+					results[f.index].Error = NewError(err)
+				}
+				finish(f.index)
+			}
+		}()
+	}
+
+	// A canceled run returns at once: its queued fetches may wait behind
+	// higher priorities before the scheduler drops them.
+	processed := make(chan struct{})
+	go func() {
+		processWG.Wait()
+		close(processed)
+	}()
+	if p.budget > 0 {
+		go p.watch(ctx, blockNumbers, completed, processed)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-processed:
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return results, nil
+}
+
+// processRecovering processes block, failing it with a *PanicError should
+// processing panic.
+func (p blockPipeline) processRecovering(ctx context.Context, block *bitcoin.Block) (_ *types.Block, err error) {
+	defer recoverPanic(&err)
+	return p.process(ctx, block)
+}
+
+// watch logs the heights of blockNumbers not yet completed each time the
+// run exceeds another budget, until processed is closed or ctx done.
+func (p blockPipeline) watch(ctx context.Context, blockNumbers []uint64, completed []atomic.Bool, processed <-chan struct{}) {
+	log := p.log
+	if log == nil {
+		log = logger.With()
+	}
+	start := time.Now()
+	ticker := time.NewTicker(p.budget)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-processed:
+			return
+		case <-ticker.C:
+		}
+		var outstanding []uint64
+		for i := range completed {
+			if !completed[i].Load() {
+				outstanding = append(outstanding, blockNumbers[i])
+			}
+		}
+		log.Warn("Block batch exceeds its time budget",
+			"budget", p.budget,
+			"elapsed", time.Since(start).Round(time.Millisecond),
+			"blocks", len(blockNumbers),
+			"outstanding", outstanding)
+	}
+}
@@ -0,0 +1,266 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockPipeline_KeepsOrder(t *testing.T) {
+	nums := []uint64{5, 3, 9, 1, 7, 2, 8}
+	p := blockPipeline{
+		fetcher: newFetchScheduler(3, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+			time.Sleep(time.Duration(n) * time.Millisecond) // finish out of order
+			if n == 9 {
+				return nil, nil, errors.New("fetch failed")
+			}
+			return ctx, &bitcoin.Block{Height: n}, nil
+		}),
+		processWorkers: 2,
+		process: func(_ context.Context, b *bitcoin.Block) (*types.Block, error) {
+			if b.Height == 2 {
+				return nil, errors.New("process failed")
+			}
+			return &types.Block{Number: b.Height}, nil
+		},
+	}
+
+	results, err := p.run(context.Background(), nums)
+	require.NoError(t, err)
+	require.Len(t, results, len(nums))
+	for i, res := range results {
+		assert.Equal(t, nums[i], res.Number)
+		switch nums[i] {
+		case 9:
+			assert.Equal(t, "fetch failed", res.Error.Message)
+		case 2:
+			assert.Equal(t, "process failed", res.Error.Message)
+		default:
+			require.Nil(t, res.Error)
+			assert.Equal(t, nums[i], res.Block.Number)
+		}
+	}
+}
+
+func TestBlockPipeline_Backpressure(t *testing.T) {
+	const fetchWorkers, processWorkers = 2, 1
+	var fetched atomic.Int32
+	release := make(chan struct{})
+	p := blockPipeline{
+		fetcher: newFetchScheduler(fetchWorkers, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+			fetched.Add(1)
+			return ctx, &bitcoin.Block{Height: n}, nil
+		}),
+		processWorkers: processWorkers,
+		process: func(_ context.Context, b *bitcoin.Block) (*types.Block, error) {
+			<-release
+			return &types.Block{Number: b.Height}, nil
+		},
+	}
+
+	nums := make([]uint64, 20)
+	for i := range nums {
+		nums[i] = uint64(i)
+	}
+	done := make(chan []BlockResult)
+	go func() {
+		results, _ := p.run(context.Background(), nums)
+		done <- results
+	}()
+
+	// A block in each process worker, as many queued for them, and one in
+	// each fetcher: the run's window.
+	const inFlight = 2*processWorkers + fetchWorkers
+	require.Eventually(t, func() bool { return fetched.Load() == inFlight }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(inFlight), fetched.Load(), "fetching pauses while processing is stuck")
+
+	close(release)
+	results := <-done
+	assert.Len(t, results, 20)
+	assert.Equal(t, int32(20), fetched.Load())
+}
+
+func TestBlockPipeline_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := blockPipeline{
+		fetcher: newFetchScheduler(2, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+			cancel()
+			return ctx, &bitcoin.Block{Height: n}, nil
+		}),
+		processWorkers: 2,
+		process: func(_ context.Context, b *bitcoin.Block) (*types.Block, error) {
+			return &types.Block{Number: b.Height}, nil
+		},
+	}
+	_, err := p.run(ctx, []uint64{1, 2, 3, 4, 5, 6})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// largeBitcoinBlock builds a block of n two-output transactions with
+// resolved prevouts.
+func largeBitcoinBlock(height uint64, n int) *bitcoin.Block {
+	block := &bitcoin.Block{Height: height, Hash: fmt.Sprintf("%064x", height), Confirmations: 1}
+	for i := range n {
+		block.Tx = append(block.Tx, bitcoin.Transaction{
+			TxID: fmt.Sprintf("%016x%048x", height, i),
+			Vin:  []bitcoin.Input{btcInput(fmt.Sprintf("%064x", i), 0, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0.01)},
+			Vout: []bitcoin.Output{
+				btcOutput("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 0.006, 0),
+				btcOutput("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0.0039, 1),
+			},
+		})
+	}
+	return block
+}
+
+// BenchmarkBitcoinBlockPipeline converts 4000-tx blocks behind a fetch
+// costing 5ms, as an RPC round trip would. With one process worker,
+// conversion bounds throughput; on a multi-core machine it scales with
+// process workers up to the CPU count, until the fetchers bound it.
+func BenchmarkBitcoinBlockPipeline(b *testing.B) {
+	const blocks = 16
+	fixtures := make([]*bitcoin.Block, blocks)
+	nums := make([]uint64, blocks)
+	for i := range fixtures {
+		fixtures[i] = largeBitcoinBlock(uint64(i), 4000)
+		nums[i] = uint64(i)
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("process=%d", workers), func(b *testing.B) {
+			idx := NewBitcoinIndexer("btc_bench", config.ChainConfig{}, nil, nil)
+			p := blockPipeline{
+				fetcher: newFetchScheduler(3, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+					time.Sleep(5 * time.Millisecond)
+					return ctx, fixtures[n], nil
+				}),
+				processWorkers: workers,
+				process:        idx.convertBlockWithPrevoutResolution,
+			}
+			b.ResetTimer()
+			for range b.N {
+				if _, err := p.run(context.Background(), nums); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(blocks*b.N)/b.Elapsed().Seconds(), "blocks/s")
+		})
+	}
+}
+
+func TestBlockPipeline_RecoversPanics(t *testing.T) {
+	panicking := func(next TransferExtractor) TransferExtractor {
+		return TransferExtractorFunc(func(blk *bitcoin.Block, chainCtx BitcoinChainContext) []types.Transaction {
+			if blk.Height == 3 {
+				var tx *bitcoin.Transaction
+				_ = tx.TxID // nil dereference
+			}
+			return next.Extract(blk, chainCtx)
+		})
+	}
+	idx := NewBitcoinIndexer("btc_panic", config.ChainConfig{}, nil, nil, panicking)
+	p := blockPipeline{
+		fetcher: newFetchScheduler(2, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+			if n == 5 {
+				panic("fetch blew up")
+			}
+			return ctx, &bitcoin.Block{Height: n, Hash: fmt.Sprintf("h%d", n)}, nil
+		}),
+		processWorkers: 2,
+		process:        idx.convertBlockWithPrevoutResolution,
+	}
+
+	done := make(chan []BlockResult)
+	go func() {
+		results, err := p.run(context.Background(), []uint64{1, 2, 3, 4, 5, 6})
+		assert.NoError(t, err)
+		done <- results
+	}()
+	var results []BlockResult
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch hangs after a panic")
+	}
+
+	require.Len(t, results, 6)
+	for _, res := range results {
+		switch res.Number {
+		case 3, 5:
+			require.NotNil(t, res.Error, res.Number)
+			assert.Equal(t, ErrorTypePanic, res.Error.ErrorType)
+			assert.Contains(t, res.Error.Stack, "runtime/debug.Stack", "stack trace attached")
+		default:
+			require.Nil(t, res.Error, res.Number)
+			assert.Equal(t, res.Number, res.Block.Number)
+		}
+	}
+	assert.Contains(t, results[2].Error.Message, "nil pointer dereference")
+	assert.Contains(t, results[4].Error.Message, "fetch blew up")
+
+	// The fetch workers survived: the scheduler still serves.
+	_, block, err := p.fetcher.fetchOne(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), block.Height)
+}
+
+func TestBlockPipeline_Watchdog(t *testing.T) {
+	logs := &syncBuffer{}
+	release := make(chan struct{})
+	p := blockPipeline{
+		fetcher: newFetchScheduler(2, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+			if n == 2 {
+				<-release
+			}
+			return ctx, &bitcoin.Block{Height: n}, nil
+		}),
+		processWorkers: 1,
+		process: func(_ context.Context, b *bitcoin.Block) (*types.Block, error) {
+			return &types.Block{Number: b.Height}, nil
+		},
+		budget: 20 * time.Millisecond,
+		log:    slog.New(slog.NewTextHandler(logs, nil)),
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+	}()
+	results, err := p.run(context.Background(), []uint64{1, 2, 3})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	out := logs.String()
+	assert.Contains(t, out, "Block batch exceeds its time budget")
+	assert.Contains(t, out, "outstanding=[2]", "only the stuck height is reported")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writers and readers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
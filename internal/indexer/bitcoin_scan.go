@@ -0,0 +1,113 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// ErrNoScanNodes is returned by ScanAddress when none of the chain's nodes
+// has heavy_scans set.
+var ErrNoScanNodes = errors.New("no node allows heavy scans")
+
+// ErrInvalidAddress is returned by ScanAddress for an address it cannot
+// derive a script from.
+var ErrInvalidAddress = errors.New("invalid address")
+
+// AddressScanner is implemented by indexers that can find the unspent
+// outputs of an address without indexing its history, e.g. when an address
+// that was used before is registered.
+type AddressScanner interface {
+	ScanAddress(ctx context.Context, address string) (*AddressScan, error)
+}
+
+// AddressScan is the unspent outputs of Address as of the block at Height.
+type AddressScan struct {
+	Address   string        `json:"address"`
+	Height    uint64        `json:"height"`
+	BestBlock string        `json:"best_block"`
+	UTXOs     []ScannedUTXO `json:"utxos"`
+}
+
+// ScannedUTXO is an unspent output found by an address scan, with the
+// height of the block that created it.
+type ScannedUTXO struct {
+	types.UTXO
+	Height   uint64 `json:"height"`
+	Coinbase bool   `json:"coinbase,omitempty"`
+}
+
+// UseScanFailover routes ScanAddress to the providers of f: the chain's
+// nodes with heavy_scans, whose clients allow a scan its whole timeout.
+func (b *BitcoinIndexer) UseScanFailover(f *rpc.Failover[bitcoin.BitcoinAPI]) {
+	b.scanFailover = f
+}
+
+// ScanAddress finds the unspent outputs paying address with scantxoutset,
+// on a heavy_scans node only and within config.AddressScanConfig.Timeout.
+// The address's script is scanned rather than the address itself, so
+// nodes match it whatever network or HRP they know it by.
+func (b *BitcoinIndexer) ScanAddress(ctx context.Context, address string) (*AddressScan, error) {
+	if b.scanFailover == nil {
+		return nil, ErrNoScanNodes
+	}
+	hrps := b.segwitHRPs()
+	normalized, err := btcaddr.NormalizeBTCAddress(address, hrps...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+	script, err := bitcoin.AddressScript(normalized, hrps...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	timeout := b.config.AddressScan.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultAddressScanTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result *bitcoin.ScanTxOutSetResult
+	err = b.scanFailover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
+		var err error
+		result, err = c.ScanTxOutSet(ctx, []string{"raw(" + script + ")"})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &AddressScan{
+		Address:   normalized,
+		Height:    result.Height,
+		BestBlock: result.BestBlock,
+		UTXOs:     make([]ScannedUTXO, 0, len(result.Unspents)),
+	}
+	for _, u := range result.Unspents {
+		scan.UTXOs = append(scan.UTXOs, ScannedUTXO{
+			UTXO: types.UTXO{
+				TxHash:       u.TxID,
+				Vout:         u.Vout,
+				Address:      normalized,
+				Amount:       strconv.FormatInt(satoshisFromFloat(u.Amount), 10),
+				ScriptPubKey: u.ScriptPubKey,
+			},
+			Height:   u.Height,
+			Coinbase: u.Coinbase,
+		})
+	}
+	b.logger().Info("Address scanned",
+		"address", normalized,
+		"height", scan.Height,
+		"utxos", len(scan.UTXOs),
+	)
+	return scan, nil
+}
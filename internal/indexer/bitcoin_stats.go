@@ -0,0 +1,119 @@
+package indexer
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+)
+
+// OutputStats tallies the outputs of indexed blocks by script and address
+// type, so a parsing regression shows up as a shift in the mix, e.g. a
+// spike of "unknown" address types or of outputs without an address.
+type OutputStats struct {
+	Blocks  uint64 `json:"blocks"`
+	Outputs uint64 `json:"outputs"`
+	// ScriptTypes counts outputs by Core's scriptPubKey type, e.g.
+	// "witness_v1_taproot" or "pubkeyhash".
+	ScriptTypes map[string]uint64 `json:"script_types"`
+	// AddressTypes counts output addresses by btcaddr.GetAddressType.
+	AddressTypes map[string]uint64 `json:"address_types"`
+	OpReturn     uint64            `json:"op_return"`
+	Nonstandard  uint64            `json:"nonstandard"`
+	// NoAddress counts outputs no transfer was extracted for, and
+	// NoAddressSats the value they carried.
+	NoAddress     uint64 `json:"no_address"`
+	NoAddressSats int64  `json:"no_address_sats"`
+	// ValueChecked counts the transactions sampled for the value
+	// conservation check, and ValueDiscrepancies the outputs and fee
+	// totals it found off, see config.ValueCheckConfig.
+	ValueChecked       uint64 `json:"value_checked"`
+	ValueDiscrepancies uint64 `json:"value_discrepancies"`
+}
+
+// blockOutputStats tallies btcBlock's outputs, coinbase included.
+func (b *BitcoinIndexer) blockOutputStats(btcBlock *bitcoin.Block) OutputStats {
+	stats := OutputStats{
+		Blocks:       1,
+		ScriptTypes:  make(map[string]uint64),
+		AddressTypes: make(map[string]uint64),
+	}
+	for i := range btcBlock.Tx {
+		for j := range btcBlock.Tx[i].Vout {
+			out := &btcBlock.Tx[i].Vout[j]
+			stats.Outputs++
+
+			scriptType := out.ScriptPubKey.Type
+			if scriptType == "" {
+				scriptType = "unknown"
+			}
+			stats.ScriptTypes[scriptType]++
+			switch scriptType {
+			case "nulldata":
+				stats.OpReturn++
+			case "nonstandard":
+				stats.Nonstandard++
+			}
+
+			for _, addr := range bitcoin.GetOutputAddresses(out) {
+				stats.AddressTypes[btcaddr.GetAddressType(addr)]++
+			}
+			if addrs, _ := b.outputAddresses(out); len(addrs) == 0 {
+				stats.NoAddress++
+				stats.NoAddressSats += satoshisFromFloat(out.Value)
+			}
+		}
+	}
+	return stats
+}
+
+func (s *OutputStats) add(o OutputStats) {
+	s.Blocks += o.Blocks
+	s.Outputs += o.Outputs
+	s.OpReturn += o.OpReturn
+	s.Nonstandard += o.Nonstandard
+	s.NoAddress += o.NoAddress
+	s.NoAddressSats += o.NoAddressSats
+	s.ValueChecked += o.ValueChecked
+	s.ValueDiscrepancies += o.ValueDiscrepancies
+	if s.ScriptTypes == nil {
+		s.ScriptTypes = make(map[string]uint64)
+	}
+	if s.AddressTypes == nil {
+		s.AddressTypes = make(map[string]uint64)
+	}
+	for k, v := range o.ScriptTypes {
+		s.ScriptTypes[k] += v
+	}
+	for k, v := range o.AddressTypes {
+		s.AddressTypes[k] += v
+	}
+}
+
+// outputStatsTotals accumulates OutputStats across blocks.
+type outputStatsTotals struct {
+	mu    sync.Mutex
+	stats OutputStats
+}
+
+func (t *outputStatsTotals) add(o OutputStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.add(o)
+}
+
+func (t *outputStatsTotals) snapshot() OutputStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.stats
+	s.ScriptTypes = maps.Clone(s.ScriptTypes)
+	s.AddressTypes = maps.Clone(s.AddressTypes)
+	return s
+}
+
+// OutputStats returns the output statistics of every block indexed since
+// startup. Blocks indexed twice, e.g. after a reorg, count twice.
+func (b *BitcoinIndexer) OutputStats() OutputStats {
+	return b.outputStats.snapshot()
+}
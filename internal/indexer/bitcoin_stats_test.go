@@ -0,0 +1,56 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitcoinBlockOutputStats(t *testing.T) {
+	out := func(scriptType, hex, addr string, value float64) bitcoin.Output {
+		return bitcoin.Output{Value: value, ScriptPubKey: bitcoin.ScriptPubKey{Type: scriptType, Hex: hex, Address: addr}}
+	}
+	block := &bitcoin.Block{Tx: []bitcoin.Transaction{
+		{Vout: []bitcoin.Output{
+			out("witness_v0_keyhash", "0014751e76e8199196d454941c45d1b3a323f1433bd6", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 3.125),
+			out("nulldata", "6a24aa21a9ed", "", 0),
+		}},
+		{Vout: []bitcoin.Output{
+			out("witness_v1_taproot", "5120", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", 0.5),
+			out("pubkeyhash", "76a914", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", 0.1),
+			out("nonstandard", "51", "", 0.0002),
+			out("", "", "xyz", 0.0001),
+		}},
+	}}
+
+	b := &BitcoinIndexer{}
+	stats := b.blockOutputStats(block)
+	assert.Equal(t, uint64(1), stats.Blocks)
+	assert.Equal(t, uint64(6), stats.Outputs)
+	assert.Equal(t, map[string]uint64{
+		"witness_v0_keyhash": 1, "witness_v1_taproot": 1, "pubkeyhash": 1,
+		"nulldata": 1, "nonstandard": 1, "unknown": 1,
+	}, stats.ScriptTypes)
+	assert.Equal(t, map[string]uint64{
+		"p2wpkh_mainnet": 1, "p2tr_mainnet": 1, "p2pkh_mainnet": 1, "unknown": 1,
+	}, stats.AddressTypes)
+	assert.Equal(t, uint64(1), stats.OpReturn)
+	assert.Equal(t, uint64(1), stats.Nonstandard)
+	assert.Equal(t, uint64(2), stats.NoAddress, "OP_RETURN and the nonstandard script")
+	assert.Equal(t, int64(20_000), stats.NoAddressSats)
+
+	b = &BitcoinIndexer{config: config.ChainConfig{IndexNonstandard: true}}
+	stats = b.blockOutputStats(block)
+	assert.Equal(t, uint64(1), stats.NoAddress, "nonstandard value is indexed under its script ID")
+	assert.Zero(t, stats.NoAddressSats)
+
+	b.outputStats.add(stats)
+	b.outputStats.add(stats)
+	total := b.OutputStats()
+	assert.Equal(t, uint64(2), total.Blocks)
+	assert.Equal(t, uint64(2), total.ScriptTypes["nulldata"])
+	total.ScriptTypes["nulldata"] = 100
+	assert.Equal(t, uint64(2), b.OutputStats().ScriptTypes["nulldata"], "snapshots are copies")
+}
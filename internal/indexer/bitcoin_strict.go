@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+)
+
+// ErrUnparseableTx fails a block with transactions that could not be fully
+// parsed while ChainConfig.StrictMode is set, see UnparseableTxError.
+var ErrUnparseableTx = errors.New("transactions could not be fully parsed")
+
+// TxIssue is why a transaction could not be fully parsed.
+type TxIssue struct {
+	TxID   string `json:"txid"`
+	Reason string `json:"reason"`
+}
+
+// UnparseableTxError lists the transactions of a block that strict mode
+// refuses to skip: outputs whose script yields no address, prevouts left
+// unresolved, transactions repeated in the block and value discrepancies.
+type UnparseableTxError struct {
+	Block  uint64
+	Issues []TxIssue
+}
+
+func (e *UnparseableTxError) Error() string {
+	first := e.Issues[0]
+	return fmt.Sprintf("block %d: %v: %d issues, first in tx %s: %s",
+		e.Block, ErrUnparseableTx, len(e.Issues), first.TxID, first.Reason)
+}
+
+func (e *UnparseableTxError) Unwrap() error { return ErrUnparseableTx }
+
+// strictIssues returns what lenient processing of btcBlock skipped or
+// logged only: the txids dropped as repeated, the value discrepancies
+// found, and the outputs and prevouts left out of its transfers. Outputs
+// with no value or an OP_RETURN script are left out by design.
+func (b *BitcoinIndexer) strictIssues(btcBlock *bitcoin.Block, repeated []string, discrepancies []valueDiscrepancy) []TxIssue {
+	var issues []TxIssue
+	for _, txID := range repeated {
+		issues = append(issues, TxIssue{TxID: txID, Reason: "repeated in the block"})
+	}
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		if tx.IsCoinbase() {
+			continue
+		}
+		for j := range tx.Vout {
+			out := &tx.Vout[j]
+			if out.Value <= 0 || out.ScriptPubKey.Type == "nulldata" {
+				continue
+			}
+			if addrs, _ := b.outputAddresses(out); len(addrs) == 0 {
+				issues = append(issues, TxIssue{TxID: tx.TxID,
+					Reason: fmt.Sprintf("output %d: %q script has no address", out.N, out.ScriptPubKey.Type)})
+			}
+		}
+		if missing := missingPrevouts(tx); missing > 0 {
+			issues = append(issues, TxIssue{TxID: tx.TxID,
+				Reason: fmt.Sprintf("%d of %d prevouts unresolved", missing, len(tx.Vin))})
+		}
+	}
+	for _, d := range discrepancies {
+		issues = append(issues, TxIssue{TxID: d.TxID,
+			Reason: fmt.Sprintf("%s: expected %d sats, transfers carry %d", d.Check, d.Expected, d.Actual)})
+	}
+	return issues
+}
@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strictTestBlock() *bitcoin.Block {
+	payment := bitcoin.Transaction{
+		TxID: "payment",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{btcOutput("recipient", 0.999, 0)},
+	}
+	bare := bitcoin.Transaction{
+		TxID: "bare",
+		Vin:  []bitcoin.Input{btcInput("prev2", 0, "sender", 1.0)},
+		Vout: []bitcoin.Output{
+			btcOutput("recipient", 0.5, 0),
+			{Value: 0.4, N: 1, ScriptPubKey: bitcoin.ScriptPubKey{Hex: "5121" + "02" + strings.Repeat("ab", 32) + "51ae", Type: "multisig"}},
+			{Value: 0, N: 2, ScriptPubKey: bitcoin.ScriptPubKey{Hex: "6a0568656c6c6f", Type: "nulldata"}},
+		},
+	}
+	return &bitcoin.Block{
+		Height: 100,
+		Hash:   "h",
+		Tx: []bitcoin.Transaction{
+			{TxID: "coinbase", Vin: []bitcoin.Input{{Vout: 0xffffffff}}, Vout: []bitcoin.Output{btcOutput("miner", 3.125, 0)}},
+			payment,
+			bare,
+			payment,
+		},
+	}
+}
+
+func TestBitcoinConvertBlock_StrictMode(t *testing.T) {
+	lenient := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet"})
+	result, err := lenient.convertBlockWithPrevoutResolution(context.Background(), strictTestBlock())
+	require.NoError(t, err)
+	assert.Len(t, result.Transactions, 2, "the bare multisig output is skipped")
+
+	strict := newBTCTestIndexer(config.ChainConfig{NetworkId: "bitcoin_mainnet", StrictMode: true})
+	_, err = strict.convertBlockWithPrevoutResolution(context.Background(), strictTestBlock())
+	require.ErrorIs(t, err, ErrUnparseableTx)
+	assert.EqualError(t, err, "block 100: transactions could not be fully parsed: 2 issues, first in tx payment: repeated in the block")
+
+	blockErr := NewError(err)
+	assert.Equal(t, ErrorTypeUnparseableTx, blockErr.ErrorType)
+	assert.Equal(t, []TxIssue{
+		{TxID: "payment", Reason: "repeated in the block"},
+		{TxID: "bare", Reason: `output 1: "multisig" script has no address`},
+	}, blockErr.Issues)
+
+	// Indexed as nonstandard, the output is no longer skipped.
+	strict.config.IndexNonstandard = true
+	block := strictTestBlock()
+	block.Tx = block.Tx[:3]
+	_, err = strict.convertBlockWithPrevoutResolution(context.Background(), block)
+	assert.NoError(t, err)
+}
+
+func TestBitcoinStrictIssues_PrevoutsAndValue(t *testing.T) {
+	b := newBTCTestIndexer(config.ChainConfig{StrictMode: true})
+	block := &bitcoin.Block{Tx: []bitcoin.Transaction{{
+		TxID: "partial",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "sender", 1.0), {TxID: "unresolved", Vout: 3}},
+		Vout: []bitcoin.Output{btcOutput("recipient", 0.5, 0)},
+	}}}
+	issues := b.strictIssues(block, nil, []valueDiscrepancy{{TxID: "other", Check: "fee", Expected: 10, Actual: 0}})
+	assert.Equal(t, []TxIssue{
+		{TxID: "partial", Reason: "1 of 2 prevouts unresolved"},
+		{TxID: "other", Reason: "fee: expected 10 sats, transfers carry 0"},
+	}, issues)
+}
@@ -0,0 +1,60 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// btcBlockMetaFees is the block metadata key holding the block's total fee
+// in satoshis, set when every transaction's fee could be computed.
+const btcBlockMetaFees = "total_fee_sats"
+
+// blockFees sums the fees of btcBlock's transactions in satoshis. It is only
+// the block's total fee when feeCompleteness reports every transaction
+// complete.
+func blockFees(btcBlock *bitcoin.Block) int64 {
+	var sats int64
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		if tx.IsCoinbase() {
+			continue
+		}
+		sats += tx.CalculateFee().Shift(8).Round(0).IntPart()
+	}
+	return sats
+}
+
+// BlockFees returns the total fee of block in satoshis: the one computed
+// while converting it when every prevout was resolved, otherwise the node's
+// getblockstats for its height. A node answering for another block at that
+// height, e.g. mid-reorg, is an error.
+func (b *BitcoinIndexer) BlockFees(ctx context.Context, block *types.Block) (int64, error) {
+	if v, ok := block.GetMetadata(btcBlockMetaFees); ok {
+		if sats, ok := v.(int64); ok {
+			return sats, nil
+		}
+	}
+	stats, err := b.BlockStats(ctx, block.Number)
+	if err != nil {
+		return 0, err
+	}
+	if stats.BlockHash != block.Hash {
+		return 0, fmt.Errorf("block stats at height %d are for block %s, expected %s",
+			block.Number, stats.BlockHash, block.Hash)
+	}
+	return stats.TotalFee, nil
+}
+
+// BlockStats returns getblockstats for the block at height.
+func (b *BitcoinIndexer) BlockStats(ctx context.Context, height uint64) (*bitcoin.BlockStats, error) {
+	var stats *bitcoin.BlockStats
+	err := b.failover.ExecuteWithRetry(ctx, func(c bitcoin.BitcoinAPI) error {
+		s, err := c.GetBlockStats(ctx, height)
+		stats = s
+		return err
+	})
+	return stats, err
+}
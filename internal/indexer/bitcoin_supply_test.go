@@ -0,0 +1,35 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinBlockFees(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 3, Pay: []string{"bc1qa", "bc1qb", "bc1qc"}})
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, bitcointest.NewSimFailover(t, sim), nil)
+	ctx := context.Background()
+
+	// Every prevout came with the block: fees are summed while converting.
+	block, err := idx.GetBlock(ctx, 101)
+	require.NoError(t, err)
+	fees, err := idx.BlockFees(ctx, block)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3_000), fees)
+	assert.Zero(t, sim.Calls("getblockstats"))
+
+	// Without them, the node's stats are used for the same block only.
+	fees, err = idx.BlockFees(ctx, &types.Block{Number: 101, Hash: sim.BlockHash(101)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3_000), fees)
+	assert.Equal(t, 1, sim.Calls("getblockstats"))
+
+	_, err = idx.BlockFees(ctx, &types.Block{Number: 101, Hash: "reorged"})
+	assert.ErrorContains(t, err, "block stats at height 101 are for block")
+}
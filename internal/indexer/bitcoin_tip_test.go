@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heightBTCNode is a BitcoinAPI stub that serves blocks up to height.
+type heightBTCNode struct {
+	bitcoin.BitcoinAPI
+	height uint64
+	calls  int
+}
+
+func (n *heightBTCNode) GetBlockCount(context.Context) (uint64, error) {
+	return n.height, nil
+}
+
+func (n *heightBTCNode) GetBlockByHeight(_ context.Context, height uint64, _ int) (*bitcoin.Block, error) {
+	n.calls++
+	if height > n.height {
+		return nil, fmt.Errorf("getblockhash RPC error: %w",
+			&rpc.RPCError{Code: -8, Message: "Block height out of range"})
+	}
+	return &bitcoin.Block{Height: height, Hash: fmt.Sprintf("hash-%d", height)}, nil
+}
+
+func newHeightFailover(t *testing.T, nodes ...*heightBTCNode) (*rpc.Failover[bitcoin.BitcoinAPI], []*rpc.Provider) {
+	t.Helper()
+	// The stub only serves blocks; keep background height probes out of it.
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	providers := make([]*rpc.Provider, len(nodes))
+	for i, n := range nodes {
+		providers[i] = &rpc.Provider{
+			Name:   fmt.Sprintf("node-%d", i),
+			URL:    fmt.Sprintf("http://node-%d", i),
+			Client: n,
+			State:  rpc.StateHealthy,
+		}
+		require.NoError(t, f.AddProvider(providers[i]))
+	}
+	return f, providers
+}
+
+func TestBitcoinGetBlock_TipRaceReturnsNotReady(t *testing.T) {
+	ahead := &heightBTCNode{height: 101}
+	behind := &heightBTCNode{height: 100}
+	f, providers := newHeightFailover(t, ahead, behind)
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+
+	latest, err := idx.GetLatestBlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), latest)
+
+	// Route the block fetch to the lagging node.
+	providers[0].Blacklist(time.Hour)
+
+	_, err = idx.GetBlock(context.Background(), 101)
+	require.ErrorIs(t, err, ErrBlockNotReady)
+	assert.Equal(t, 1, behind.calls, "not-ready must not be retried")
+	assert.Equal(t, ErrorTypeBlockNotReady, NewError(err).ErrorType)
+	assert.Equal(t, rpc.StateHealthy, providers[1].State, "lagging node must not be penalized")
+}
+
+func TestBitcoinGetBlock_NotFoundFarBelowTipIsNotTipRace(t *testing.T) {
+	node := &heightBTCNode{height: 50}
+	f, _ := newHeightFailover(t, node)
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+	idx.latestHeight.Store(200)
+
+	_, err := idx.GetBlock(context.Background(), 100)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBlockNotReady))
+	assert.Equal(t, ErrorTypeBlockNotFound, NewError(err).ErrorType)
+}
@@ -0,0 +1,59 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/tracing/tracingtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// A block's span holds its fetch, RPC calls included, and its processing,
+// with a child per provider prevouts were resolved on.
+func TestBitcoinGetBlocks_TracesBlock(t *testing.T) {
+	rec := tracingtest.Record(t)
+	idx, _ := newStrippedBTCIndexer(t, config.ChainConfig{},
+		stripPrevoutsFixture(t, false), stripPrevoutsFixture(t, true))
+
+	results, err := idx.GetBlocksByNumbers(context.Background(), []uint64{btcIntegrationBlock + 2})
+	require.NoError(t, err)
+	require.NotNil(t, results[0].Span, "the caller ends the block's span")
+	results[0].Span.End()
+
+	block := tracingtest.Find(rec, "block")
+	require.NotNil(t, block)
+	assert.False(t, block.Parent().IsValid(), "a block starts a trace")
+	assert.Equal(t, []string{"bitcoin.fetch_block", "bitcoin.process_block"}, tracingtest.Children(rec, block))
+
+	fetch := tracingtest.Find(rec, "bitcoin.fetch_block")
+	assert.Equal(t, []string{"bitcoin.getblockhash", "bitcoin.getblock"}, tracingtest.Children(rec, fetch))
+
+	process := tracingtest.Find(rec, "bitcoin.process_block")
+	assert.Equal(t, []string{"bitcoin.enrich_prevouts", "bitcoin.extract"}, tracingtest.Children(rec, process))
+
+	enrich := tracingtest.Find(rec, "bitcoin.enrich_prevouts")
+	passes := tracingtest.Children(rec, enrich)
+	assert.Equal(t, []string{"bitcoin.resolve_prevouts", "bitcoin.resolve_prevouts"}, passes,
+		"the first provider leaves the prevout missing, the second resolves it")
+
+	for _, s := range rec.Ended() {
+		assert.Equal(t, block.SpanContext().TraceID(), s.SpanContext().TraceID(), s.Name())
+	}
+}
+
+func TestBitcoinGetBlocks_TracesFailedFetch(t *testing.T) {
+	rec := tracingtest.Record(t)
+	idx, _ := newFixtureBTCIndexer(t, "coinbase_only", config.ChainConfig{NetworkId: "btc_fixture"})
+
+	results, _ := idx.GetBlocksByNumbers(context.Background(), []uint64{999_999})
+	require.NotNil(t, results[0].Error)
+	require.NotNil(t, results[0].Span)
+	results[0].Span.End()
+
+	fetch := tracingtest.Find(rec, "bitcoin.fetch_block")
+	require.NotNil(t, fetch)
+	assert.Equal(t, codes.Error, fetch.Status().Code)
+}
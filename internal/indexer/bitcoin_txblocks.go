@@ -0,0 +1,72 @@
+package indexer
+
+import "sync"
+
+// recentTxBlocksCapacity is how many blocks recentTxBlocks remembers, about
+// a day of Bitcoin blocks and within what a pruned node keeps by default.
+const recentTxBlocksCapacity = 144
+
+// recentTxBlocks maps the txids of recently converted blocks to their block
+// hash, so prevouts they created can be fetched with getrawtransaction's
+// blockhash hint from nodes without txindex. The oldest block is dropped
+// once capacity blocks are held.
+type recentTxBlocks struct {
+	mu       sync.Mutex
+	capacity int
+	order    []string            // block hashes, oldest first
+	blocks   map[string][]string // block hash -> txids
+	txs      map[string]string   // txid -> block hash
+}
+
+func newRecentTxBlocks(capacity int) *recentTxBlocks {
+	return &recentTxBlocks{
+		capacity: capacity,
+		blocks:   make(map[string][]string),
+		txs:      make(map[string]string),
+	}
+}
+
+// add records the txids of block hash. Re-adding a block is a no-op; a txid
+// seen again in another block (after a reorg) points at the newer one. A nil
+// r remembers nothing.
+func (r *recentTxBlocks) add(hash string, txids []string) {
+	if r == nil || hash == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.blocks[hash]; ok {
+		return
+	}
+	for len(r.order) >= r.capacity && len(r.order) > 0 {
+		r.evictOldest()
+	}
+	r.order = append(r.order, hash)
+	r.blocks[hash] = txids
+	for _, txid := range txids {
+		r.txs[txid] = hash
+	}
+}
+
+func (r *recentTxBlocks) evictOldest() {
+	oldest := r.order[0]
+	r.order = r.order[1:]
+	for _, txid := range r.blocks[oldest] {
+		if r.txs[txid] == oldest {
+			delete(r.txs, txid)
+		}
+	}
+	delete(r.blocks, oldest)
+}
+
+// blockOf returns the hash of the remembered block containing txid.
+func (r *recentTxBlocks) blockOf(txid string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hash, ok := r.txs[txid]
+	return hash, ok
+}
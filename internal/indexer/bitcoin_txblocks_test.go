@@ -0,0 +1,32 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentTxBlocks_EvictsOldest(t *testing.T) {
+	r := newRecentTxBlocks(2)
+	r.add("b1", []string{"t1", "t2"})
+	r.add("b2", []string{"t3"})
+	r.add("b3", []string{"t4"})
+
+	_, ok := r.blockOf("t1")
+	assert.False(t, ok)
+	hash, ok := r.blockOf("t4")
+	assert.True(t, ok)
+	assert.Equal(t, "b3", hash)
+}
+
+func TestRecentTxBlocks_ReorgedTxidSurvivesEviction(t *testing.T) {
+	r := newRecentTxBlocks(2)
+	r.add("b1", []string{"t1"})
+	r.add("b1'", []string{"t1"})
+	r.add("b1", nil) // already known, no-op
+	r.add("b2", []string{"t2"})
+
+	hash, ok := r.blockOf("t1")
+	assert.True(t, ok, "evicting b1 must not drop t1's newer mapping")
+	assert.Equal(t, "b1'", hash)
+}
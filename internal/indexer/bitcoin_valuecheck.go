@@ -0,0 +1,174 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// ErrValueDiscrepancy fails a block whose transfers do not conserve its
+// transactions' value while ValueCheckConfig.Strict is set.
+var ErrValueDiscrepancy = errors.New("transfers do not conserve transaction value")
+
+// ErrNegativeValue fails a block with an output or prevout of negative
+// value, which no valid transaction has: the node's response was decoded
+// wrongly, so none of the block's amounts can be trusted.
+var ErrNegativeValue = errors.New("negative output value")
+
+// checkNegativeValues returns ErrNegativeValue, naming the first output or
+// prevout of btcBlock with a negative value, if any.
+func checkNegativeValues(btcBlock *bitcoin.Block) error {
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		for _, out := range tx.Vout {
+			if out.Value < 0 {
+				return fmt.Errorf("block %d: tx %s output %d: %w: %v", btcBlock.Height, tx.TxID, out.N, ErrNegativeValue, out.Value)
+			}
+		}
+		for j, vin := range tx.Vin {
+			if vin.PrevOut != nil && vin.PrevOut.Value < 0 {
+				return fmt.Errorf("block %d: tx %s input %d prevout: %w: %v", btcBlock.Height, tx.TxID, j, ErrNegativeValue, vin.PrevOut.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// valueDiscrepancy is a transaction whose transfers carry another amount
+// than its outputs or fee, as recomputed from the raw transaction.
+type valueDiscrepancy struct {
+	TxID     string
+	Check    string // "outputs" or "fee"
+	Expected int64
+	Actual   int64
+}
+
+// checkValueConservation compares the transfers extracted from btcBlock with
+// its sampled transactions, see config.ValueCheckConfig, and returns how
+// many transactions were checked and the discrepancies found. Strict mode
+// checks every transaction. Indexers with decorators are not checked, as
+// decorators may drop or rewrite transfers.
+func (b *BitcoinIndexer) checkValueConservation(btcBlock *bitcoin.Block, transfers []types.Transaction) (checked int, found []valueDiscrepancy) {
+	rate := b.config.ValueCheck.SampleRate
+	if b.config.StrictMode {
+		rate = 1
+	}
+	if b.decorated || rate <= 0 {
+		return 0, nil
+	}
+
+	byTx := make(map[string][]types.Transaction)
+	for _, t := range transfers {
+		byTx[t.TxHash] = append(byTx[t.TxHash], t)
+	}
+
+	tolerance := int64(b.config.ValueCheck.ToleranceSats)
+	report := func(txID, check string, expected, actual int64) {
+		diff := expected - actual
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			found = append(found, valueDiscrepancy{TxID: txID, Check: check, Expected: expected, Actual: actual})
+		}
+	}
+
+	for i := range btcBlock.Tx {
+		tx := &btcBlock.Tx[i]
+		if tx.IsCoinbase() || !sampleTx(tx.TxID, rate) {
+			continue
+		}
+		checked++
+
+		var outSats, addressedSats int64
+		addressed := 0
+		for j := range tx.Vout {
+			sats := satoshisFromFloat(tx.Vout[j].Value)
+			outSats += sats
+			if addrs, _ := b.outputAddresses(&tx.Vout[j]); len(addrs) > 0 {
+				addressedSats += sats
+				addressed++
+			}
+		}
+
+		var actualOut, actualFee int64
+		seen := make(map[uint32]bool)
+		for _, t := range byTx[tx.TxID] {
+			actualFee += t.TxFee.Shift(8).IntPart()
+			if t.Type == constant.TxTypeFee || t.Type == constant.TxTypeTokenTransfer {
+				continue
+			}
+			// Every address of a multi-address output carries its full
+			// value, so count each output once.
+			if vout, ok := t.GetMetadata(btcMetaVout); ok {
+				n, _ := vout.(uint32)
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+			}
+			amount, _ := strconv.ParseInt(t.Amount, 10, 64)
+			actualOut += amount
+		}
+		report(tx.TxID, "outputs", addressedSats, actualOut)
+
+		// The fee is only known with every prevout, and only carried by a
+		// transfer when there is one or a fee record.
+		if missingPrevouts(tx) > 0 {
+			continue
+		}
+		var expectedFee int64
+		if addressed > 0 || b.config.FeeAttribution == config.FeeAttributionTransaction {
+			var inSats int64
+			for _, vin := range tx.Vin {
+				if vin.PrevOut != nil {
+					inSats += satoshisFromFloat(vin.PrevOut.Value)
+				}
+			}
+			expectedFee = max(inSats-outSats, 0)
+		}
+		report(tx.TxID, "fee", expectedFee, actualFee)
+	}
+	return checked, found
+}
+
+// verifyValueConservation runs checkValueConservation on a block, logs each
+// discrepancy and adds the counts to the output stats, returning the
+// discrepancies. With ValueCheckConfig.Strict a discrepancy fails the
+// block with ErrValueDiscrepancy.
+func (b *BitcoinIndexer) verifyValueConservation(btcBlock *bitcoin.Block, transfers []types.Transaction, stats *OutputStats) ([]valueDiscrepancy, error) {
+	checked, found := b.checkValueConservation(btcBlock, transfers)
+	stats.ValueChecked += uint64(checked)
+	stats.ValueDiscrepancies += uint64(len(found))
+
+	for _, d := range found {
+		b.logger().Warn("Value conservation discrepancy",
+			"block", btcBlock.Height,
+			"txid", d.TxID, "check", d.Check,
+			"expected_sats", d.Expected, "actual_sats", d.Actual)
+	}
+	if len(found) > 0 && b.config.ValueCheck.Strict {
+		return found, fmt.Errorf("block %d: %w: %d discrepancies, first in tx %s",
+			btcBlock.Height, ErrValueDiscrepancy, len(found), found[0].TxID)
+	}
+	return found, nil
+}
+
+// sampleTx reports whether txID falls in the sampled fraction rate. The
+// choice is a hash of the txid, so a transaction is sampled or not on every
+// run and every indexer instance alike.
+func sampleTx(txID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(txID))
+	return float64(h.Sum32()) < rate*math.MaxUint32
+}
@@ -0,0 +1,116 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinValueConservation(t *testing.T) {
+	tx := bitcoin.Transaction{
+		TxID: "aa11",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qsender", 1.0)},
+		Vout: []bitcoin.Output{
+			btcOutput("bc1qpayee", 0.6, 0),
+			btcOpReturnOutput(1),
+			btcMultisigOutput([]string{"1KeyA", "1KeyB"}, 0.2, 2),
+			btcOutput("bc1qsender", 0.1999, 3),
+		},
+	}
+	block := &bitcoin.Block{Height: 7, Tx: []bitcoin.Transaction{tx}}
+
+	for _, attribution := range []string{config.FeeAttributionFirstOutput, config.FeeAttributionProportional, config.FeeAttributionTransaction} {
+		b := newBTCTestIndexer(config.ChainConfig{
+			FeeAttribution: attribution,
+			ValueCheck:     config.ValueCheckConfig{SampleRate: 1, Strict: true},
+		})
+		transfers := b.extractTransfersFromTx(&block.Tx[0], "", 7, 0, 7)
+		checked, found := b.checkValueConservation(block, transfers)
+		assert.Equal(t, 1, checked, attribution)
+		assert.Empty(t, found, attribution)
+	}
+
+	b := newBTCTestIndexer(config.ChainConfig{ValueCheck: config.ValueCheckConfig{SampleRate: 1, ToleranceSats: 5}})
+	transfers := b.extractTransfersFromTx(&block.Tx[0], "", 7, 0, 7)
+	transfers[0].Amount = "60000003"
+	_, found := b.checkValueConservation(block, transfers)
+	assert.Empty(t, found, "within tolerance")
+
+	transfers[0].TxFee = decimal.Zero
+	transfers = transfers[:len(transfers)-1]
+	_, found = b.checkValueConservation(block, transfers)
+	assert.Equal(t, []valueDiscrepancy{
+		{TxID: "aa11", Check: "outputs", Expected: 99_990_000, Actual: 80_000_003},
+		{TxID: "aa11", Check: "fee", Expected: 10_000, Actual: 0},
+	}, found)
+
+	var stats OutputStats
+	found, err := b.verifyValueConservation(block, transfers, &stats)
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, uint64(1), stats.ValueChecked)
+	assert.Equal(t, uint64(2), stats.ValueDiscrepancies)
+
+	b.config.ValueCheck.Strict = true
+	_, err = b.verifyValueConservation(block, transfers, &stats)
+	require.ErrorIs(t, err, ErrValueDiscrepancy)
+	assert.EqualError(t, err, "block 7: transfers do not conserve transaction value: 2 discrepancies, first in tx aa11")
+}
+
+func TestBitcoinValueConservation_SkipsUnknownFees(t *testing.T) {
+	tx := bitcoin.Transaction{
+		TxID: "bb22",
+		Vin:  []bitcoin.Input{{TxID: "prev", Vout: 0}},
+		Vout: []bitcoin.Output{btcOutput("bc1qpayee", 0.5, 0)},
+	}
+	block := &bitcoin.Block{Tx: []bitcoin.Transaction{tx}}
+	b := newBTCTestIndexer(config.ChainConfig{ValueCheck: config.ValueCheckConfig{SampleRate: 1}})
+	transfers := b.extractTransfersFromTx(&block.Tx[0], "", 1, 0, 1)
+	transfers[0].TxFee = decimal.NewFromFloat(0.001)
+
+	_, found := b.checkValueConservation(block, transfers)
+	assert.Empty(t, found, "without the prevout there is no fee to check against")
+
+	b.decorated = true
+	transfers[0].Amount = "1"
+	checked, _ := b.checkValueConservation(block, transfers)
+	assert.Zero(t, checked, "decorated extractors are not checked")
+}
+
+func TestSampleTx(t *testing.T) {
+	sampled := 0
+	for i := range 10_000 {
+		txID := decimal.NewFromInt(int64(i)).String()
+		if sampleTx(txID, 0.1) {
+			sampled++
+		}
+		assert.Equal(t, sampleTx(txID, 0.1), sampleTx(txID, 0.1))
+	}
+	assert.InDelta(t, 1_000, sampled, 150)
+	assert.True(t, sampleTx("any", 1))
+	assert.False(t, sampleTx("any", 0))
+}
+
+func TestBitcoinCheckNegativeValues(t *testing.T) {
+	tx := bitcoin.Transaction{
+		TxID: "cc33",
+		Vin:  []bitcoin.Input{btcInput("prev", 0, "bc1qsender", 0.5)},
+		Vout: []bitcoin.Output{btcOutput("bc1qpayee", 0.4, 0), btcOpReturnOutput(1)},
+	}
+	block := &bitcoin.Block{Height: 9, Tx: []bitcoin.Transaction{tx}}
+	require.NoError(t, checkNegativeValues(block), "zero is a valid value")
+
+	block.Tx[0].Vout[0].Value = -0.4
+	err := checkNegativeValues(block)
+	require.ErrorIs(t, err, ErrNegativeValue)
+	assert.EqualError(t, err, "block 9: tx cc33 output 0: negative output value: -0.4")
+	assert.Equal(t, ErrorTypeBlockUnmarshal, ErrorTypeOf(err))
+
+	block.Tx[0].Vout[0].Value = 0.4
+	block.Tx[0].Vin[0].PrevOut.Value = -0.5
+	require.ErrorIs(t, checkNegativeValues(block), ErrNegativeValue)
+}
@@ -0,0 +1,41 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+)
+
+// verifiesBlock reports whether the block at number is checked against its
+// header, see config.BlockVerifyConfig: always within the reorg rollback
+// window of the last observed tip, where a node's bad block would be
+// indexed before anyone notices, otherwise at the backfill sample rate.
+func (b *BitcoinIndexer) verifiesBlock(number uint64, hash string) bool {
+	if b.config.BlockVerify.Disabled {
+		return false
+	}
+	window := uint64(max(b.config.ReorgRollbackWindow, bitcoinTipRaceWindow))
+	if latest := b.latestHeight.Load(); latest == 0 || number+window > latest {
+		return true
+	}
+	return sampleTx(hash, b.config.BlockVerify.SampleRate())
+}
+
+// verifyBlock checks block against its header when verifiesBlock says so.
+// A block failing the check is classed rpc.ErrBadData, so failover marks
+// the node serving it unhealthy and fetches the block from another. Blocks
+// lacking the fields to check, as some providers serve, pass.
+func (b *BitcoinIndexer) verifyBlock(number uint64, block *bitcoin.Block) error {
+	if !b.verifiesBlock(number, block.Hash) {
+		return nil
+	}
+	err := bitcoin.VerifyBlock(block)
+	switch {
+	case err == nil, errors.Is(err, bitcoin.ErrBlockUnverifiable):
+		return nil
+	}
+	b.logger().Warn("Block fails verification against its header", "block", number, "hash", block.Hash, "error", err)
+	return rpc.WithClass(rpc.ErrBadData, fmt.Errorf("block %d: %w", number, err))
+}
@@ -0,0 +1,112 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisBTCNode is a BitcoinAPI stub serving the genesis block, with its
+// nonce changed when corrupt.
+type genesisBTCNode struct {
+	bitcoin.BitcoinAPI
+	corrupt bool
+	calls   int
+}
+
+func (n *genesisBTCNode) GetBlockByHeight(context.Context, uint64, int) (*bitcoin.Block, error) {
+	n.calls++
+	block := &bitcoin.Block{
+		Hash:       "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+		Version:    1,
+		MerkleRoot: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		Time:       1231006505,
+		Bits:       "1d00ffff",
+		Nonce:      2083236893,
+		NTx:        1,
+		Tx: []bitcoin.Transaction{{
+			TxID: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			Hash: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			Vin:  []bitcoin.Input{{Sequence: 0xffffffff}},
+		}},
+	}
+	if n.corrupt {
+		block.Nonce++
+	}
+	return block, nil
+}
+
+func newGenesisFailover(t *testing.T, nodes ...*genesisBTCNode) (*rpc.Failover[bitcoin.BitcoinAPI], []*rpc.Provider) {
+	t.Helper()
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	providers := make([]*rpc.Provider, len(nodes))
+	for i, n := range nodes {
+		providers[i] = &rpc.Provider{
+			Name:   fmt.Sprintf("node-%d", i),
+			URL:    fmt.Sprintf("http://node-%d", i),
+			Client: n,
+			State:  rpc.StateHealthy,
+		}
+		require.NoError(t, f.AddProvider(providers[i]))
+	}
+	return f, providers
+}
+
+func TestBitcoinGetBlock_CorruptBlockRetriedOnAnotherNode(t *testing.T) {
+	corrupt := &genesisBTCNode{corrupt: true}
+	honest := &genesisBTCNode{}
+	f, providers := newGenesisFailover(t, corrupt, honest)
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+
+	block, err := idx.GetBlock(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", block.Hash)
+	assert.Equal(t, 1, corrupt.calls)
+	assert.Equal(t, 1, honest.calls)
+	assert.NotEqual(t, rpc.StateHealthy, providers[0].State, "node serving a corrupt block must be penalized")
+	assert.Equal(t, rpc.StateHealthy, providers[1].State)
+}
+
+func TestBitcoinGetBlock_CorruptBlockEverywhereIsBadData(t *testing.T) {
+	f, _ := newGenesisFailover(t, &genesisBTCNode{corrupt: true})
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+
+	_, err := idx.GetBlock(context.Background(), 0)
+	require.ErrorIs(t, err, rpc.ErrBadData)
+	require.ErrorIs(t, err, bitcoin.ErrBlockIntegrity)
+	assert.Equal(t, ErrorTypeBadData, NewError(err).ErrorType)
+}
+
+func TestBitcoinVerifiesBlock(t *testing.T) {
+	none := 0.0
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{
+		ReorgRollbackWindow: 6,
+		BlockVerify:         config.BlockVerifyConfig{BackfillSampleRate: &none},
+	}, nil, nil)
+
+	assert.True(t, idx.verifiesBlock(10, "hash"), "tip unknown")
+	idx.latestHeight.Store(1000)
+	assert.True(t, idx.verifiesBlock(1000, "hash"))
+	assert.True(t, idx.verifiesBlock(995, "hash"))
+	assert.False(t, idx.verifiesBlock(994, "hash"), "backfill sampled at rate 0")
+
+	idx.config.BlockVerify.BackfillSampleRate = nil
+	sampled := 0
+	for i := range 1000 {
+		if idx.verifiesBlock(uint64(i), fmt.Sprintf("hash-%d", i)) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, config.DefaultBlockVerifySampleRate*1000, sampled, 40)
+
+	idx.config.BlockVerify.Disabled = true
+	assert.False(t, idx.verifiesBlock(1000, "hash"))
+}
@@ -0,0 +1,51 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoBlockBefore is returned by FindBlockByTime when even the first
+// block is later than the target.
+var ErrNoBlockBefore = errors.New("no block at or before the given time")
+
+// searchBlockByTime returns the highest block in [0, latest] whose time, as
+// returned by timeOf, is at most target (Unix seconds). timeOf must never
+// decrease with height. It makes at most 1+ceil(log2(latest+1)) timeOf
+// calls.
+func searchBlockByTime(
+	ctx context.Context,
+	latest uint64,
+	target int64,
+	timeOf func(ctx context.Context, number uint64) (uint64, error),
+) (uint64, error) {
+	if target < 0 {
+		return 0, ErrNoBlockBefore
+	}
+	t, err := timeOf(ctx, latest)
+	if err != nil {
+		return 0, err
+	}
+	if t <= uint64(target) {
+		return latest, nil
+	}
+
+	// lo is at or before target, or -1 while none is known; hi is after it.
+	lo, hi := int64(-1), int64(latest)
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		t, err := timeOf(ctx, uint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if t <= uint64(target) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if lo < 0 {
+		return 0, ErrNoBlockBefore
+	}
+	return uint64(lo), nil
+}
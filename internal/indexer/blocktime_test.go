@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBlockByTime(t *testing.T) {
+	// Blocks 0..100 every 10s from 1000, with 50 and 51 sharing a time.
+	timeOf := func(n uint64) uint64 {
+		if n == 51 {
+			n = 50
+		}
+		return 1000 + 10*n
+	}
+	const latest = 100
+	maxCalls := 1 + int(math.Ceil(math.Log2(latest+1)))
+
+	for _, tc := range []struct {
+		target int64
+		want   uint64
+		err    error
+	}{
+		{target: 1000, want: 0},
+		{target: 1005, want: 0},
+		{target: 1500, want: 51},
+		{target: 1509, want: 51},
+		{target: 1520, want: 52},
+		{target: 1999, want: 99},
+		{target: 2000, want: 100},
+		{target: 9999, want: 100},
+		{target: 999, err: ErrNoBlockBefore},
+	} {
+		calls := 0
+		got, err := searchBlockByTime(context.Background(), latest, tc.target, func(_ context.Context, n uint64) (uint64, error) {
+			calls++
+			require.LessOrEqual(t, n, uint64(latest))
+			return timeOf(n), nil
+		})
+		if tc.err != nil {
+			require.ErrorIs(t, err, tc.err, "target %d", tc.target)
+		} else {
+			require.NoError(t, err, "target %d", tc.target)
+			assert.Equal(t, tc.want, got, "target %d", tc.target)
+		}
+		assert.LessOrEqual(t, calls, maxCalls, "target %d", tc.target)
+	}
+}
+
+// headerBTCNode is a BitcoinAPI stub serving block headers whose times go
+// backwards now and then while their median times do not.
+type headerBTCNode struct {
+	bitcoin.BitcoinAPI
+	tip     uint64
+	headers int
+}
+
+func (n *headerBTCNode) GetBlockCount(context.Context) (uint64, error) { return n.tip, nil }
+
+func (n *headerBTCNode) GetBlockHash(_ context.Context, height uint64) (string, error) {
+	return fmt.Sprintf("%d", height), nil
+}
+
+func (n *headerBTCNode) GetBlockHeader(_ context.Context, hash string) (*bitcoin.BlockHeader, error) {
+	n.headers++
+	var height uint64
+	_, _ = fmt.Sscan(hash, &height)
+	header := &bitcoin.BlockHeader{Hash: hash, Height: height, Time: 10000 + 600*height, MedianTime: 7000 + 600*height}
+	if height%2 == 1 {
+		header.Time -= 1200 // before its parent
+	}
+	return header, nil
+}
+
+func TestBitcoinFindBlockByTime(t *testing.T) {
+	node := &headerBTCNode{tip: 1000}
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	require.NoError(t, f.AddProvider(&rpc.Provider{Name: "node", URL: "http://node", Client: node, State: rpc.StateHealthy}))
+	idx := NewBitcoinIndexer("btc", config.ChainConfig{}, f, nil)
+
+	// Block 500's median time, 307000, is before its own time.
+	got, err := idx.FindBlockByTime(context.Background(), time.Unix(307599, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), got)
+	assert.LessOrEqual(t, node.headers, 1+int(math.Ceil(math.Log2(1001))))
+
+	_, err = idx.FindBlockByTime(context.Background(), time.Unix(0, 0))
+	require.ErrorIs(t, err, ErrNoBlockBefore)
+}
@@ -38,6 +38,7 @@ type CosmosIndexer struct {
 	config      config.ChainConfig
 	failover    *rpc.Failover[cosmos.CosmosAPI]
 	pubkeyStore PubkeyStore
+	fetcher     blockFetcher
 }
 
 func NewCosmosIndexer(
@@ -82,7 +83,14 @@ func (c *CosmosIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error
 	return latest, err
 }
 
+// GetBlock fetches the block at number through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (c *CosmosIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	_, block, err := c.fetchScheduler().fetchOne(ctx, number)
+	return block, err
+}
+
+func (c *CosmosIndexer) fetchBlock(ctx context.Context, number uint64) (*types.Block, error) {
 	var (
 		blockData   *cosmos.BlockResponse
 		blockResult *cosmos.BlockResultsResponse
@@ -138,87 +146,41 @@ func (c *CosmosIndexer) GetBlocks(
 		nums = append(nums, n)
 	}
 
-	workers := 1
-	if isParallel {
-		workers = c.config.Throttle.Concurrency
-	}
-	return c.getBlocks(ctx, nums, workers)
+	return c.getBlocks(ctx, nums)
 }
 
 func (c *CosmosIndexer) GetBlocksByNumbers(
 	ctx context.Context,
 	blockNumbers []uint64,
 ) ([]BlockResult, error) {
-	return c.getBlocks(ctx, blockNumbers, c.config.Throttle.Concurrency)
+	return c.getBlocks(ctx, blockNumbers)
 }
 
+// getBlocks fetches blockNumbers through the indexer's fetch scheduler, by
+// the priority ctx carries.
 func (c *CosmosIndexer) getBlocks(
 	ctx context.Context,
 	blockNumbers []uint64,
-	workers int,
 ) ([]BlockResult, error) {
 	if len(blockNumbers) == 0 {
 		return nil, nil
 	}
-	if workers <= 0 {
-		workers = 1
-	}
-	workers = min(workers, len(blockNumbers))
-
-	results := make([]BlockResult, len(blockNumbers))
-
-	type job struct {
-		index int
-		num   uint64
-	}
-
-	jobs := make(chan job, workers*2)
-	var wg sync.WaitGroup
-
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := range jobs {
-				block, err := c.GetBlock(ctx, j.num)
-				results[j.index] = BlockResult{
-					Number: j.num,
-					Block:  block,
-				}
-				if err != nil {
-					results[j.index].Error = &Error{
-						ErrorType: ErrorTypeUnknown,
-						Message:   err.Error(),
-					}
-				}
-			}
-		}()
+	results, err := fetchAll(ctx, c.fetchScheduler(), blockNumbers, NewError)
+	if err != nil {
+		return nil, err
 	}
+	return results, firstBlockError(results)
+}
 
-	go func() {
-		defer close(jobs)
-		for i, num := range blockNumbers {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- job{index: i, num: num}:
-			}
-		}
-	}()
-
-	wg.Wait()
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
-	}
+// fetchScheduler returns the pool every block fetch of the indexer runs on,
+// Throttle.Concurrency at a time.
+func (c *CosmosIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
+	return c.fetcher.scheduler(c.config.Throttle.Concurrency, c.fetchBlock)
+}
 
-	var firstErr error
-	for _, res := range results {
-		if res.Error != nil {
-			firstErr = fmt.Errorf("block %d: %s", res.Number, res.Error.Message)
-			break
-		}
-	}
-	return results, firstErr
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (c *CosmosIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return c.fetchScheduler().stats()
 }
 
 func (c *CosmosIndexer) IsHealthy() bool {
@@ -228,6 +190,21 @@ func (c *CosmosIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (c *CosmosIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return c.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (c *CosmosIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	c.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (c *CosmosIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return c.failover.ExpectNetwork(ctx, want)
+}
+
 func (c *CosmosIndexer) convertBlock(
 	blockData *cosmos.BlockResponse,
 	blockResults *cosmos.BlockResultsResponse,
@@ -260,6 +237,7 @@ func (c *CosmosIndexer) convertBlock(
 		ParentHash:   blockData.Block.Header.LastBlockID.Hash,
 		Timestamp:    ts,
 		Transactions: txs,
+		InternalCode: c.config.InternalCode,
 	}, nil
 }
 
@@ -304,6 +282,7 @@ func (c *CosmosIndexer) extractTransferTransactions(
 			tx := types.Transaction{
 				TxHash:        txHashes[i],
 				NetworkId:     c.config.NetworkId,
+				InternalCode:  c.config.InternalCode,
 				BlockNumber:   blockNumber,
 				BlockHash:     blockHash,
 				TransferIndex: fmt.Sprintf("%d:%d", i, transferIndex),
@@ -13,13 +13,14 @@ import (
 	"github.com/fystack/multichain-indexer/internal/rpc/evm"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
-	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"golang.org/x/sync/errgroup"
 )
 
 type EVMIndexer struct {
+	chainLog
 	chainName           string
 	config              config.ChainConfig
 	failover            *rpc.Failover[evm.EthereumAPI]
@@ -27,6 +28,19 @@ type EVMIndexer struct {
 	maxBatchSize        int                             // Maximum batch size to prevent RPC timeouts
 	maxReceiptBatchSize int                             // Specific limit for receipt batches (usually smaller)
 	pubkeyStore         PubkeyStore                     // For selective receipt fetching
+	assets              *assetFilter                    // ChainConfig.Assets
+	bloomCounters       receiptBloomCounters
+	logWindow           logWindow // ChainConfig.LogScan
+
+	fetchOnce  sync.Once
+	fetchSched *fetchScheduler[evmFetch, []BlockResult]
+}
+
+// evmFetch is a fetchBlocks call, run by the indexer's fetch scheduler as
+// one fetch since EVM batches the blocks of a call into few requests.
+type evmFetch struct {
+	blockNums  []uint64
+	isParallel bool
 }
 
 // traceModeActive returns true when tracing can actually run right now.
@@ -63,6 +77,7 @@ func NewEVMIndexer(chainName string, config config.ChainConfig, failover *rpc.Fa
 	}
 
 	return &EVMIndexer{
+		chainLog:            chainLog{ChainLogger(chainName, config)},
 		chainName:           chainName,
 		config:              config,
 		failover:            failover,
@@ -70,9 +85,16 @@ func NewEVMIndexer(chainName string, config config.ChainConfig, failover *rpc.Fa
 		maxBatchSize:        maxBatchSize,
 		maxReceiptBatchSize: maxReceiptBatchSize,
 		pubkeyStore:         pubkeyStore,
+		assets:              newAssetFilter(enum.NetworkTypeEVM, config.Assets),
+		logWindow:           logWindow{max: config.LogScan.MaxRange},
 	}
 }
 
+// UpdateAssetFilter applies a reloaded ChainConfig.Assets.
+func (e *EVMIndexer) UpdateAssetFilter(cfg config.AssetFilterConfig) {
+	e.assets.update(cfg)
+}
+
 func (e *EVMIndexer) GetName() string                  { return strings.ToUpper(e.chainName) }
 func (e *EVMIndexer) GetNetworkType() enum.NetworkType { return enum.NetworkTypeEVM }
 func (e *EVMIndexer) GetNetworkInternalCode() string {
@@ -92,6 +114,30 @@ func (e *EVMIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
 	return latest, err
 }
 
+// FindBlockByTime returns the highest block whose timestamp is at most
+// target, by binary search over block headers.
+func (e *EVMIndexer) FindBlockByTime(ctx context.Context, target time.Time) (uint64, error) {
+	latest, err := e.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	return searchBlockByTime(ctx, latest, target.Unix(), func(ctx context.Context, number uint64) (uint64, error) {
+		var block *evm.Block
+		err := e.failover.ExecuteWithRetry(ctx, func(c evm.EthereumAPI) error {
+			var err error
+			block, err = c.GetBlockByNumber(ctx, fmt.Sprintf("0x%x", number), false)
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block %d: %w", number, err)
+		}
+		if block == nil {
+			return 0, fmt.Errorf("block %d not found", number)
+		}
+		return utils.ParseHexUint64(block.Timestamp)
+	})
+}
+
 func (e *EVMIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
 	results, err := e.fetchBlocks(ctx, []uint64{number}, false)
 	if err != nil {
@@ -132,7 +178,8 @@ func (e *EVMIndexer) GetBlocksByNumbers(
 	return e.fetchBlocks(ctx, blockNumbers, false)
 }
 
-// Unified block fetching logic
+// fetchBlocks runs the fetch of blockNums through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (e *EVMIndexer) fetchBlocks(
 	ctx context.Context,
 	blockNums []uint64,
@@ -141,6 +188,34 @@ func (e *EVMIndexer) fetchBlocks(
 	if len(blockNums) == 0 {
 		return nil, nil
 	}
+	_, results, err := e.fetchScheduler().fetchOne(ctx, evmFetch{blockNums: blockNums, isParallel: isParallel})
+	return results, err
+}
+
+// fetchScheduler returns the pool every fetchBlocks call of the indexer
+// runs on, Throttle.Concurrency at a time.
+func (e *EVMIndexer) fetchScheduler() *fetchScheduler[evmFetch, []BlockResult] {
+	e.fetchOnce.Do(func() {
+		e.fetchSched = newFetchScheduler(e.config.Throttle.Concurrency,
+			func(ctx context.Context, f evmFetch) (context.Context, []BlockResult, error) {
+				results, err := e.fetchBlocksNow(ctx, f.blockNums, f.isParallel)
+				return ctx, results, err
+			})
+	})
+	return e.fetchSched
+}
+
+// FetchQueueStats reports the indexer's fetchBlocks calls by priority.
+func (e *EVMIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return e.fetchScheduler().stats()
+}
+
+// Unified block fetching logic
+func (e *EVMIndexer) fetchBlocksNow(
+	ctx context.Context,
+	blockNums []uint64,
+	isParallel bool,
+) ([]BlockResult, error) {
 
 	// Fetch raw blocks
 	blocks, err := e.getRawBlocks(ctx, blockNums, isParallel)
@@ -211,7 +286,7 @@ func (e *EVMIndexer) getRawBlocksParallel(
 
 				if err != nil {
 					// Try with all providers as fallback
-					logger.Warn("provider-specific batch failed, trying with failover",
+					e.logger().Warn("provider-specific batch failed, trying with failover",
 						"provider", provider, "error", err, "batch_size", len(batch))
 
 					err = e.failover.ExecuteWithRetry(ctx, func(c evm.EthereumAPI) error {
@@ -222,7 +297,7 @@ func (e *EVMIndexer) getRawBlocksParallel(
 
 					if err != nil {
 						// Final fallback to individual blocks
-						logger.Warn("all providers failed for batch, falling back to individual",
+						e.logger().Warn("all providers failed for batch, falling back to individual",
 							"error", err, "batch_size", len(batch))
 
 						individualBlocks, fallbackErr := e.fallbackBatchToIndividual(ctx, batch)
@@ -265,7 +340,7 @@ func (e *EVMIndexer) getRawBlocksSequential(
 
 		if err != nil {
 			// Fallback to individual block fetching for this batch
-			logger.Warn("batch failed, falling back to individual blocks",
+			e.logger().Warn("batch failed, falling back to individual blocks",
 				"error", err, "batch_size", len(batch))
 
 			individualBlocks, fallbackErr := e.fallbackBatchToIndividual(ctx, batch)
@@ -294,29 +369,48 @@ func (e *EVMIndexer) processBlocksAndReceipts(
 	startTime := time.Now()
 
 	traceActive := e.traceModeActive() // single snapshot per batch
+	logScan := e.logScanActive(traceActive)
 
 	var (
 		erc20TxHashes map[string]bool
+		transferLogs  map[uint64][]evm.Log
+		logScanErr    error
 		missingBlocks map[uint64]*evm.Block
 	)
 
 	g, gctx := errgroup.WithContext(ctx)
 
 	missingNums := e.findMissingBlocks(blockNums, blocks)
-	if len(missingNums) > 0 {
+	fetchMissing := func(ctx context.Context) {
+		if len(missingNums) == 0 {
+			return
+		}
+		var err error
+		missingBlocks, err = e.fetchMissingBlocksRaw(ctx, missingNums)
+		if err != nil {
+			e.logger().Warn("failed to fetch missing blocks", "error", err, "count", len(missingNums))
+			// Don't fail the entire operation, just log
+		}
+	}
+
+	if logScan {
+		// Token transfers come from the scanned logs, in place of the
+		// receipts of token calls and the ERC20 query below. Missing
+		// blocks are fetched first: reconcileLogHashes only catches a
+		// reorg when the scan is newer than the blocks, as a block fetched
+		// after it could be a version whose transfers it never saw.
 		g.Go(func() error {
-			var err error
-			missingBlocks, err = e.fetchMissingBlocksRaw(gctx, missingNums)
-			if err != nil {
-				logger.Warn("failed to fetch missing blocks", "error", err, "count", len(missingNums))
-				// Don't fail the entire operation, just log
-				return nil
-			}
+			fetchMissing(gctx)
+			transferLogs, logScanErr = e.scanTransferLogs(gctx, blockNums)
+			return nil
+		})
+	} else if len(missingNums) > 0 {
+		g.Go(func() error {
+			fetchMissing(gctx)
 			return nil
 		})
 	}
-
-	if len(blockNums) > 0 && e.pubkeyStore != nil && !traceActive {
+	if !logScan && len(blockNums) > 0 && e.pubkeyStore != nil && !traceActive {
 		g.Go(func() error {
 			fromBlock := blockNums[0]
 			toBlock := blockNums[len(blockNums)-1]
@@ -324,12 +418,12 @@ func (e *EVMIndexer) processBlocksAndReceipts(
 			erc20Start := time.Now()
 			matchedTxHashes, err := e.queryERC20TransfersToMonitoredAddresses(gctx, fromBlock, toBlock)
 			if err != nil {
-				logger.Warn("failed to query ERC20 transfers", "error", err)
+				e.logger().Warn("failed to query ERC20 transfers", "error", err)
 				// Don't fail the entire operation, just log
 				return nil
 			}
 			erc20TxHashes = matchedTxHashes
-			logger.Info("[ERC20 QUERY COMPLETE]",
+			e.logger().Info("[ERC20 QUERY COMPLETE]",
 				"elapsed_ms", time.Since(erc20Start).Milliseconds(),
 				"matched_txs", len(erc20TxHashes),
 			)
@@ -338,16 +432,29 @@ func (e *EVMIndexer) processBlocksAndReceipts(
 	}
 
 	if err := g.Wait(); err != nil {
-		logger.Warn("parallel operations failed", "error", err)
+		e.logger().Warn("parallel operations failed", "error", err)
+	}
+
+	if logScanErr != nil {
+		e.logger().Warn("failed to scan transfer logs", "error", logScanErr)
+		return failedBlockResults(blockNums, logScanErr), nil
 	}
 
 	if missingBlocks != nil && len(missingBlocks) > 0 {
 		maps.Copy(blocks, missingBlocks)
-		logger.Info("[MISSING BLOCKS FETCHED]", "count", len(missingBlocks))
+		e.logger().Info("[MISSING BLOCKS FETCHED]", "count", len(missingBlocks))
 	}
 
 	// Extract transaction hashes for native transfers to monitored addresses
 	txHashMap := e.extractReceiptTxHashes(blocks, traceActive)
+	var logErrs map[uint64]error
+	if logScan {
+		for num, hashes := range txHashMap {
+			txHashMap[num] = withoutLogsOnly(blocks[num], hashes)
+		}
+		logErrs = e.reconcileLogHashes(ctx, blocks, transferLogs)
+		e.addBlobTxsWithLogs(blocks, txHashMap, transferLogs)
+	}
 	// Add ERC20 transfer tx hashes to the map
 	if len(erc20TxHashes) > 0 {
 		// Use a set to track already added hashes for efficient deduplication
@@ -371,35 +478,56 @@ func (e *EVMIndexer) processBlocksAndReceipts(
 		}
 	}
 
+	e.pruneReceiptsByBloom(blocks, txHashMap, traceActive)
+
 	// Fetch all receipts at once
 	receiptsStart := time.Now()
 	allReceipts, err := e.fetchAllReceipts(ctx, txHashMap, isParallel)
 	if err != nil {
-		logger.Warn("failed to fetch receipts", "error", err)
+		e.logger().Warn("failed to fetch receipts", "error", err)
 	}
-	logger.Debug("[RECEIPTS FETCHED]",
+	e.logger().Debug("[RECEIPTS FETCHED]",
 		"elapsed_ms", time.Since(receiptsStart).Milliseconds(),
 		"count", len(allReceipts),
 	)
 
+	if logScan {
+		allReceipts = e.mergeTransferLogs(blocks, txHashMap, allReceipts, transferLogs)
+	}
+
 	// Fetch traces for successful contract calls
 	var traces map[string]*evm.CallTrace
 	if traceActive {
 		tracesStart := time.Now()
 		traces = e.fetchTraces(ctx, blocks, allReceipts)
-		logger.Debug("[TRACES FETCHED]",
+		e.logger().Debug("[TRACES FETCHED]",
 			"elapsed_ms", time.Since(tracesStart).Milliseconds(),
 			"count", len(traces))
 	}
 
 	totalElapsed := time.Since(startTime)
-	logger.Debug("[PROCESS BLOCKS COMPLETE]",
+	e.logger().Debug("[PROCESS BLOCKS COMPLETE]",
 		"total_elapsed_ms", totalElapsed.Milliseconds(),
 		"blocks", len(blockNums),
 	)
 
 	// Build final results
-	return e.buildBlockResults(blockNums, blocks, txHashMap, allReceipts, traces), nil
+	results := e.buildBlockResults(blockNums, blocks, txHashMap, allReceipts, traces)
+	for i := range results {
+		if err := logErrs[results[i].Number]; err != nil {
+			results[i] = BlockResult{Number: results[i].Number, Error: NewError(err)}
+		}
+	}
+	return results, nil
+}
+
+// failedBlockResults fails each of blockNums with err.
+func failedBlockResults(blockNums []uint64, err error) []BlockResult {
+	results := make([]BlockResult, 0, len(blockNums))
+	for _, num := range blockNums {
+		results = append(results, BlockResult{Number: num, Error: NewError(err)})
+	}
+	return results
 }
 
 // fetchMissingBlocksRaw fetches missing blocks as raw evm.Block objects
@@ -420,7 +548,7 @@ func (e *EVMIndexer) fetchMissingBlocksRaw(
 		})
 
 		if err != nil {
-			logger.Warn("failed to fetch individual block", "block_num", num, "error", err)
+			e.logger().Warn("failed to fetch individual block", "block_num", num, "error", err)
 			continue
 		}
 
@@ -463,7 +591,7 @@ func (e *EVMIndexer) queryERC20TransfersToMonitoredAddresses(ctx context.Context
 
 	for _, log := range logs {
 		if len(log.Topics) < 3 {
-			logger.Warn("Transfer log has less than 3 topics",
+			e.logger().Warn("Transfer log has less than 3 topics",
 				"topics", log.Topics,
 				"tx_hash", log.TransactionHash,
 			)
@@ -474,24 +602,13 @@ func (e *EVMIndexer) queryERC20TransfersToMonitoredAddresses(ctx context.Context
 		// Topics[1] = from address (indexed)
 		// Topics[2] = to address (indexed)
 		// Data = amount (not indexed)
-
-		// Extract 'from' (topics[1]) and 'to' (topics[2]) addresses.
-		// Topics are 32 bytes, address is last 20 bytes.
-		fromAddressTopic := log.Topics[1]
-		toAddressTopic := log.Topics[2]
-		if len(fromAddressTopic) < 64 || len(toAddressTopic) < 64 { // hex string without 0x prefix should be 64 chars
+		fromAddress, toAddress, ok := transferLogParties(log)
+		if !ok {
 			continue
 		}
-
-		// Get last 40 hex chars (20 bytes) and add 0x prefix.
-		fromAddress := "0x" + fromAddressTopic[len(fromAddressTopic)-40:]
-		toAddress := "0x" + toAddressTopic[len(toAddressTopic)-40:]
-
-		fromMonitored := e.config.TwoWayIndexing && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(fromAddress))
-		toMonitored := e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(toAddress))
-		if fromMonitored || toMonitored {
+		if e.monitoredTransfer(fromAddress, toAddress) {
 			matchedTxHashes[log.TransactionHash] = true
-			logger.Info("MATCHED ERC20 TRANSFER",
+			e.logger().Info("MATCHED ERC20 TRANSFER",
 				"tx_hash", log.TransactionHash,
 				"from_address", fromAddress,
 				"to_address", toAddress,
@@ -499,7 +616,7 @@ func (e *EVMIndexer) queryERC20TransfersToMonitoredAddresses(ctx context.Context
 		}
 	}
 
-	logger.Debug("[ERC20 TRANSFERS]",
+	e.logger().Debug("[ERC20 TRANSFERS]",
 		"from_block", fromBlock,
 		"to_block", toBlock,
 		"total_transfer_events", len(logs),
@@ -556,10 +673,15 @@ func (e *EVMIndexer) extractReceiptTxHashes(blocks map[uint64]*evm.Block, traceA
 			if !isSafeExecution {
 				// OPTIMIZATION: Only fetch receipts for native transfers involving monitored addresses.
 				isNativeTransfer := tx.To != "" && (tx.Input == "" || tx.Input == "0x")
+				to := tx.To
+				if tx.IsContractCreation() {
+					// Value sent with a creation is credited to the new contract.
+					isNativeTransfer, to = tx.NeedReceipt(), tx.CreatedAddress(nil)
+				}
 
 				if isNativeTransfer {
-					toMonitored := e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(tx.To))
-					fromMonitored := e.config.TwoWayIndexing && tx.From != "" && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(tx.From))
+					toMonitored := to != "" && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(to))
+					fromMonitored := e.config.TwoWayIndexing && tx.From != "" && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(tx.From))
 					if toMonitored || fromMonitored {
 						nativeTransfers++
 						appendHash(blockNum, tx.Hash, seen)
@@ -569,15 +691,15 @@ func (e *EVMIndexer) extractReceiptTxHashes(blocks map[uint64]*evm.Block, traceA
 				// Filter must match ExtractSafeTransfers acceptance criteria.
 				params, err := evm.DecodeGnosisSafeExecTransaction(tx.Input)
 				if err != nil {
-					logger.Debug("[DECODE GNOSIS SAFE EXEC TRANSACTION ERROR]", "error", err)
+					e.logger().Debug("[DECODE GNOSIS SAFE EXEC TRANSACTION ERROR]", "error", err)
 					continue
 				}
 				if params.Operation != 0 || params.Value.Sign() <= 0 || len(params.Data) != 0 {
 					continue
 				}
 
-				toMonitored := e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(params.To))
-				fromMonitored := e.config.TwoWayIndexing && tx.To != "" && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evm.ToChecksumAddress(tx.To))
+				toMonitored := e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(params.To))
+				fromMonitored := e.config.TwoWayIndexing && tx.To != "" && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(tx.To))
 				if toMonitored || fromMonitored {
 					safeTransfers++
 					appendHash(blockNum, tx.Hash, seen)
@@ -598,7 +720,7 @@ func (e *EVMIndexer) extractReceiptTxHashes(blocks map[uint64]*evm.Block, traceA
 	}
 
 	if e.pubkeyStore != nil {
-		logger.Debug("[SELECTIVE RECEIPTS - NATIVE]",
+		e.logger().Debug("[SELECTIVE RECEIPTS - NATIVE]",
 			"total_txs", totalTxs,
 			"native_transfers_matched", nativeTransfers,
 			"safe_transfers_matched", safeTransfers,
@@ -658,7 +780,7 @@ func (e *EVMIndexer) fetchTraces(
 		g.Go(func() error {
 			trace, err := e.traceWithProviderAwareness(gctx, tx.Hash)
 			if err != nil {
-				logger.Warn("debug_traceTransaction failed", "tx", tx.Hash, "error", err)
+				e.logger().Warn("debug_traceTransaction failed", "tx", tx.Hash, "error", err)
 				return nil // don't fail the whole batch
 			}
 			mu.Lock()
@@ -721,14 +843,14 @@ func (e *EVMIndexer) traceWithProviderAwareness(ctx context.Context, txHash stri
 			strings.Contains(errMsg, "unsupported method") ||
 			strings.Contains(errMsg, "unknown method") ||
 			strings.Contains(errMsg, "does not exist") {
-			logger.Error("trace provider does not support debug_traceTransaction, blacklisting",
+			e.logger().Error("trace provider does not support debug_traceTransaction, blacklisting",
 				"provider", provider.Name, "tx", txHash, "error", err)
 			e.traceFailover.HandleCapabilityError(provider, elapsed, 24*time.Hour)
 			continue
 		}
 
 		// All other errors: use the same analyzeError → blacklist/fail path as executeCore.
-		logger.Warn("trace provider returned error, trying next",
+		e.logger().Warn("trace provider returned error, trying next",
 			"provider", provider.Name, "tx", txHash, "error", err)
 		e.traceFailover.AnalyzeAndHandleError(provider, err, elapsed)
 		continue
@@ -768,7 +890,7 @@ func (e *EVMIndexer) fetchReceiptsSequential(
 
 	for batchIdx, batch := range batches {
 		var receipts map[string]*evm.TxnReceipt
-		logger.Debug(
+		e.logger().Debug(
 			"fetching receipt batch",
 			"batch",
 			batchIdx+1,
@@ -786,7 +908,7 @@ func (e *EVMIndexer) fetchReceiptsSequential(
 		})
 
 		if err != nil {
-			logger.Warn(
+			e.logger().Warn(
 				"receipt batch failed",
 				"error",
 				err,
@@ -849,8 +971,7 @@ func (e *EVMIndexer) fetchReceiptsParallel(
 				)
 
 				if err != nil {
-					logger.Warn("receipt batch failed",
-						"chain", e.GetName(),
+					e.logger().Warn("receipt batch failed",
 						"provider_idx", providerIdx,
 						"batch_idx", batchIdx+1,
 						"batch_size", len(batch),
@@ -890,7 +1011,7 @@ func (e *EVMIndexer) buildBlockResults(
 		if block == nil {
 			results = append(results, BlockResult{
 				Number: num,
-				Error:  &Error{ErrorType: ErrorTypeUnknown, Message: "block not found"},
+				Error:  &Error{ErrorType: ErrorTypeBlockNotFound, Message: "block not found"},
 			})
 			continue
 		}
@@ -907,7 +1028,7 @@ func (e *EVMIndexer) buildBlockResults(
 		if err != nil {
 			results = append(results, BlockResult{
 				Number: num,
-				Error:  &Error{ErrorType: ErrorTypeUnknown, Message: err.Error()},
+				Error:  NewError(err),
 			})
 		} else {
 			results = append(results, BlockResult{Number: num, Block: typesBlock})
@@ -935,7 +1056,7 @@ func (e *EVMIndexer) fallbackIndividual(
 		})
 
 		if err != nil {
-			logger.Warn("fallback individual block fetch failed", "block", num, "error", err)
+			e.logger().Warn("fallback individual block fetch failed", "block", num, "error", err)
 			continue
 		}
 
@@ -965,8 +1086,7 @@ func (e *EVMIndexer) fallbackBatchToIndividual(
 		})
 
 		if err != nil {
-			logger.Warn("individual block fetch failed",
-				"chain", e.GetName(),
+			e.logger().Warn("individual block fetch failed",
 				"block", num,
 				"error", err,
 			)
@@ -990,6 +1110,40 @@ func (e *EVMIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (e *EVMIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return e.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (e *EVMIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	e.failover.UseHealthStore(ctx, store)
+	if e.traceFailover != nil {
+		e.traceFailover.UseHealthStore(ctx, store)
+	}
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use,
+// in the trace pool too.
+func (e *EVMIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	if err := e.failover.ExpectNetwork(ctx, want); err != nil {
+		return err
+	}
+	if e.traceFailover != nil {
+		return e.traceFailover.ExpectNetwork(ctx, want)
+	}
+	return nil
+}
+
+// WarmUp probes the chain's RPC nodes before first use, in the trace pool
+// too.
+func (e *EVMIndexer) WarmUp(ctx context.Context) {
+	e.failover.WarmUp(ctx)
+	if e.traceFailover != nil {
+		e.traceFailover.WarmUp(ctx)
+	}
+}
+
 func (e *EVMIndexer) convertBlock(
 	eb *evm.Block,
 	receipts map[string]*evm.TxnReceipt,
@@ -1004,10 +1158,10 @@ func (e *EVMIndexer) convertBlock(
 			continue
 		}
 		if !receipt.IsSuccessful() {
-			logger.Debug("[RECEIPTS] skipping failed tx", "tx", tx.Hash, "status", receipt.Status, "block", num)
+			e.logger().Debug("[RECEIPTS] skipping failed tx", "tx", tx.Hash, "status", receipt.Status, "block", num)
 			continue
 		}
-		logger.Info("[RECEIPTS]", "tx", tx.Hash, "receipt", receipt)
+		e.logger().Info("[RECEIPTS]", "tx", tx.Hash, "receipt", receipt)
 		var transfers []types.Transaction
 		fee := tx.CalcFee(receipt)
 		traced := false
@@ -1032,6 +1186,12 @@ func (e *EVMIndexer) convertBlock(
 
 		// Cross-source dedup: trace + Safe + ExtractTransfers may overlap
 		transfers = utils.DedupTransfers(transfers)
+		transfers = e.assets.filter(transfers)
+		if receipt.FromLogs {
+			for i := range transfers {
+				transfers[i].SetMetadata("fee_unknown", true)
+			}
+		}
 		allTransfers = append(allTransfers, transfers...)
 	}
 
@@ -1039,14 +1199,25 @@ func (e *EVMIndexer) convertBlock(
 	// This enables reorg-aware idempotency in Transaction.Hash().
 	for i := range allTransfers {
 		allTransfers[i].BlockHash = eb.Hash
+		allTransfers[i].InternalCode = e.config.InternalCode
+		allTransfers[i].EnsureTransferID()
 	}
 
+	size, _ := utils.ParseHexUint64(eb.Size)
+	gasUsed, _ := utils.ParseHexUint64(eb.GasUsed)
+	gasLimit, _ := utils.ParseHexUint64(eb.GasLimit)
+
 	return &types.Block{
 		Number:       num,
 		Hash:         eb.Hash,
 		ParentHash:   eb.ParentHash,
 		Timestamp:    ts,
 		Transactions: allTransfers,
+		Size:         size,
+		TxCount:      len(eb.Transactions),
+		GasUsed:      gasUsed,
+		GasLimit:     gasLimit,
+		InternalCode: e.config.InternalCode,
 	}, nil
 }
 
@@ -0,0 +1,147 @@
+package indexer
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/evm"
+)
+
+// ReceiptBloomReporter is implemented by indexers skipping receipts by
+// block logsBloom, to report how many blocks it spared.
+type ReceiptBloomReporter interface {
+	ReceiptBloomStats() ReceiptBloomStats
+}
+
+// ReceiptBloomStats counts the blocks with token-call receipts to fetch
+// whose logsBloom was checked, and those whose bloom ruled out every
+// wanted Transfer log, so none of those receipts were fetched.
+type ReceiptBloomStats struct {
+	Checked        uint64  `json:"checked"`
+	Skipped        uint64  `json:"skipped"`
+	SkippedPercent float64 `json:"skipped_percent"`
+}
+
+type receiptBloomCounters struct {
+	checked, skipped atomic.Uint64
+}
+
+func (c *receiptBloomCounters) stats() ReceiptBloomStats {
+	s := ReceiptBloomStats{Checked: c.checked.Load(), Skipped: c.skipped.Load()}
+	if s.Checked > 0 {
+		s.SkippedPercent = 100 * float64(s.Skipped) / float64(s.Checked)
+	}
+	return s
+}
+
+// ReceiptBloomStats reports the blocks whose token-call receipts the
+// logsBloom check spared, see ChainConfig.UsesLogsBloom.
+func (e *EVMIndexer) ReceiptBloomStats() ReceiptBloomStats {
+	return e.bloomCounters.stats()
+}
+
+// transferBloom tells from a block's logsBloom whether it may hold a
+// Transfer log the chain extracts: one of an allowed contract, or of any
+// when there is no allow list.
+type transferBloom struct {
+	topic       evm.BloomBits
+	anyContract bool
+	contracts   []evm.BloomBits
+}
+
+// transferBloom returns the current transferBloom, nil when the chain does
+// not use logsBloom.
+func (e *EVMIndexer) transferBloom() *transferBloom {
+	if !e.config.UsesLogsBloom() {
+		return nil
+	}
+	topic, _ := evm.HexBloomBits(evm.ERC20_TRANSFER_TOPIC)
+	f := &transferBloom{topic: topic}
+	allow := e.assets.allowList()
+	if allow == nil {
+		f.anyContract = true
+		return f
+	}
+	for _, contract := range allow {
+		if bits, ok := evm.HexBloomBits(contract); ok {
+			f.contracts = append(f.contracts, bits)
+		}
+	}
+	return f
+}
+
+func (f *transferBloom) mayMatch(bloom *evm.Bloom) bool {
+	if !bloom.Has(f.topic) {
+		return false
+	}
+	if f.anyContract {
+		return true
+	}
+	for _, bits := range f.contracts {
+		if bloom.Has(bits) {
+			return true
+		}
+	}
+	return false
+}
+
+// logsOnlyReceipt reports whether tx's receipt can only yield transfers by
+// its Transfer logs: a call carrying input, other than a contract creation
+// or Safe execution, while no traces are taken.
+func logsOnlyReceipt(tx *evm.Txn) bool {
+	input := strings.TrimSpace(tx.Input)
+	return input != "" && input != "0x" && !tx.IsContractCreation() && !evm.IsSafeExecTransaction(tx.Input)
+}
+
+// withoutLogsOnly returns hashes less those of block's logs-only receipts.
+func withoutLogsOnly(block *evm.Block, hashes []string) []string {
+	logsOnly := make(map[string]bool)
+	for i := range block.Transactions {
+		if tx := &block.Transactions[i]; logsOnlyReceipt(tx) {
+			logsOnly[tx.Hash] = true
+		}
+	}
+	var kept []string
+	for _, hash := range hashes {
+		if !logsOnly[hash] {
+			kept = append(kept, hash)
+		}
+	}
+	return kept
+}
+
+// pruneReceiptsByBloom drops from txHashMap the logs-only receipts of
+// blocks whose logsBloom rules out every Transfer log the chain extracts:
+// those receipts could yield no transfer. A block without a valid bloom
+// keeps its receipts. Nothing is pruned while traces are taken, as
+// internal transfers leave no log.
+func (e *EVMIndexer) pruneReceiptsByBloom(blocks map[uint64]*evm.Block, txHashMap map[uint64][]string, traceActive bool) {
+	filter := e.transferBloom()
+	if filter == nil || traceActive {
+		return
+	}
+	for num, hashes := range txHashMap {
+		block := blocks[num]
+		if block == nil || len(hashes) == 0 {
+			continue
+		}
+		bloom, ok := evm.ParseBloom(block.LogsBloom)
+		if !ok {
+			continue
+		}
+		kept := withoutLogsOnly(block, hashes)
+		if len(kept) == len(hashes) {
+			continue
+		}
+		e.bloomCounters.checked.Add(1)
+		if filter.mayMatch(&bloom) {
+			continue
+		}
+		if len(kept) == 0 {
+			e.bloomCounters.skipped.Add(1)
+			delete(txHashMap, num)
+		} else {
+			txHashMap[num] = kept
+		}
+	}
+}
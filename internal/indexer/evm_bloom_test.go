@@ -0,0 +1,94 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/evm"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bloomUSDT = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	bloomUSDC = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+)
+
+func hexBloom(values ...string) string {
+	var bloom evm.Bloom
+	for _, v := range values {
+		bits, _ := evm.HexBloomBits(v)
+		bloom.Add(bits)
+	}
+	return "0x" + hex.EncodeToString(bloom[:])
+}
+
+func newBloomTestIndexer(cfg config.ChainConfig) *EVMIndexer {
+	return &EVMIndexer{config: cfg, assets: newAssetFilter(enum.NetworkTypeEVM, cfg.Assets)}
+}
+
+func bloomTestBlocks(bloom string) (map[uint64]*evm.Block, map[uint64][]string) {
+	block := &evm.Block{
+		LogsBloom: bloom,
+		Transactions: []evm.Txn{
+			{Hash: "0xnative", To: "0xrecipient", Input: "0x"},
+			{Hash: "0xtoken", To: bloomUSDT, Input: "0xa9059cbb"},
+		},
+	}
+	tokenOnly := &evm.Block{
+		LogsBloom:    bloom,
+		Transactions: []evm.Txn{{Hash: "0xtoken2", To: bloomUSDT, Input: "0xa9059cbb"}},
+	}
+	return map[uint64]*evm.Block{1: block, 2: tokenOnly},
+		map[uint64][]string{1: {"0xnative", "0xtoken"}, 2: {"0xtoken2"}}
+}
+
+func TestPruneReceiptsByBloom(t *testing.T) {
+	t.Run("no transfer topic", func(t *testing.T) {
+		e := newBloomTestIndexer(config.ChainConfig{})
+		blocks, hashes := bloomTestBlocks(hexBloom(bloomUSDT))
+		e.pruneReceiptsByBloom(blocks, hashes, false)
+		assert.Equal(t, map[uint64][]string{1: {"0xnative"}}, hashes, "native transfers keep their receipt")
+		assert.Equal(t, ReceiptBloomStats{Checked: 2, Skipped: 1, SkippedPercent: 50}, e.ReceiptBloomStats())
+	})
+
+	t.Run("transfer topic", func(t *testing.T) {
+		e := newBloomTestIndexer(config.ChainConfig{})
+		blocks, hashes := bloomTestBlocks(hexBloom(evm.ERC20_TRANSFER_TOPIC))
+		e.pruneReceiptsByBloom(blocks, hashes, false)
+		assert.Len(t, hashes, 2)
+		assert.Equal(t, ReceiptBloomStats{Checked: 2}, e.ReceiptBloomStats())
+	})
+
+	t.Run("allowed contract", func(t *testing.T) {
+		e := newBloomTestIndexer(config.ChainConfig{Assets: config.AssetFilterConfig{Allow: []string{bloomUSDC}}})
+		blocks, hashes := bloomTestBlocks(hexBloom(evm.ERC20_TRANSFER_TOPIC, bloomUSDT))
+		e.pruneReceiptsByBloom(blocks, hashes, false)
+		assert.Equal(t, map[uint64][]string{1: {"0xnative"}}, hashes, "only USDC transfers are extracted")
+
+		blocks, hashes = bloomTestBlocks(hexBloom(evm.ERC20_TRANSFER_TOPIC, bloomUSDC))
+		e.pruneReceiptsByBloom(blocks, hashes, false)
+		assert.Len(t, hashes, 2)
+	})
+
+	t.Run("kept", func(t *testing.T) {
+		disabled := false
+		for name, tc := range map[string]struct {
+			cfg         config.ChainConfig
+			bloom       string
+			traceActive bool
+		}{
+			"missing bloom": {bloom: ""},
+			"invalid bloom": {bloom: "0x1234"},
+			"traces":        {bloom: hexBloom(), traceActive: true},
+			"disabled":      {cfg: config.ChainConfig{LogsBloomFilter: &disabled}, bloom: hexBloom()},
+		} {
+			e := newBloomTestIndexer(tc.cfg)
+			blocks, hashes := bloomTestBlocks(tc.bloom)
+			e.pruneReceiptsByBloom(blocks, hashes, tc.traceActive)
+			assert.Len(t, hashes, 2, name)
+			assert.Zero(t, e.ReceiptBloomStats().Checked, name)
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/evm"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
+	"github.com/fystack/multichain-indexer/pkg/common/utils"
+)
+
+// logWindow sizes the eth_getLogs queries of the log scan, see
+// config.LogScanConfig. It is shared by the chain's concurrent fetches.
+type logWindow struct {
+	max  uint64 // 0 is config.DefaultLogScanMaxRange
+	size atomic.Uint64
+}
+
+func (w *logWindow) limit() uint64 {
+	if w.max == 0 {
+		return config.DefaultLogScanMaxRange
+	}
+	return w.max
+}
+
+// current returns the blocks the next query may span.
+func (w *logWindow) current() uint64 {
+	if size := w.size.Load(); size > 0 {
+		return size
+	}
+	return w.limit()
+}
+
+// shrink halves the window after a query over tried blocks was refused.
+func (w *logWindow) shrink(tried uint64) uint64 {
+	size := max(tried/2, 1)
+	w.size.Store(size)
+	return size
+}
+
+// grow widens the window by a quarter after a successful query, up to the
+// limit.
+func (w *logWindow) grow() {
+	size := w.current()
+	w.size.Store(min(size+max(size/4, 1), w.limit()))
+}
+
+// logScanActive reports whether the batch finds token transfers by the log
+// scan rather than by receipts.
+func (e *EVMIndexer) logScanActive(traceActive bool) bool {
+	return e.config.LogScan.Enabled && !traceActive
+}
+
+// scanTransferLogs returns the Transfer logs, of the allowed contracts if
+// any, of the blocks in blockNums by block number. Each contiguous run of
+// blocks is scanned in windows, see logWindow.
+func (e *EVMIndexer) scanTransferLogs(ctx context.Context, blockNums []uint64) (map[uint64][]evm.Log, error) {
+	contracts := e.assets.allowList()
+	logs := make(map[uint64][]evm.Log)
+	for _, run := range contiguousRuns(blockNums) {
+		for start, end := run[0], run[1]; start <= end; {
+			last := min(start+e.logWindow.current()-1, end)
+			found, err := e.filterLogs(ctx, evm.FilterQuery{
+				FromBlock: fmt.Sprintf("0x%x", start),
+				ToBlock:   fmt.Sprintf("0x%x", last),
+				Addresses: contracts,
+				Topics:    [][]string{{evm.ERC20_TRANSFER_TOPIC}},
+			})
+			if errors.Is(err, evm.ErrLogRangeTooLarge) && last > start {
+				size := e.logWindow.shrink(last - start + 1)
+				e.logger().Info("[LOG SCAN] window shrunk",
+					"from_block", start, "to_block", last, "window", size, "error", err)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("scan transfer logs of blocks %d-%d: %w", start, last, err)
+			}
+			e.logWindow.grow()
+			addLogs(logs, found)
+			start = last + 1
+		}
+	}
+	return logs, nil
+}
+
+// filterLogs runs query with failover. A refused range is the node's
+// limit, not a failure: it is returned without retrying nor counting
+// against the node.
+func (e *EVMIndexer) filterLogs(ctx context.Context, query evm.FilterQuery) ([]evm.Log, error) {
+	var (
+		logs     []evm.Log
+		rangeErr error
+	)
+	err := e.failover.ExecuteWithRetry(ctx, func(c evm.EthereumAPI) error {
+		var err error
+		logs, err = c.FilterLogs(ctx, query)
+		if errors.Is(err, evm.ErrLogRangeTooLarge) {
+			rangeErr, err = err, nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, rangeErr
+}
+
+// addLogs files logs by block number, leaving out removed ones.
+func addLogs(byBlock map[uint64][]evm.Log, logs []evm.Log) {
+	for _, log := range logs {
+		if log.Removed {
+			continue
+		}
+		num, err := utils.ParseHexUint64(log.BlockNumber)
+		if err != nil {
+			continue
+		}
+		byBlock[num] = append(byBlock[num], log)
+	}
+}
+
+// reconcileLogHashes checks the scanned logs against the fetched blocks. A
+// block whose logs carry another hash was reorged between the two fetches:
+// its logs are scanned again by the fetched block's hash, and it fails if
+// that does not succeed.
+func (e *EVMIndexer) reconcileLogHashes(
+	ctx context.Context,
+	blocks map[uint64]*evm.Block,
+	logs map[uint64][]evm.Log,
+) map[uint64]error {
+	var reorged []uint64
+	for num, blockLogs := range logs {
+		if block := blocks[num]; block != nil && slices.ContainsFunc(blockLogs, func(log evm.Log) bool {
+			return log.BlockHash != "" && !strings.EqualFold(log.BlockHash, block.Hash)
+		}) {
+			reorged = append(reorged, num)
+		}
+	}
+	var errs map[uint64]error
+	for _, num := range reorged {
+		block := blocks[num]
+		e.logger().Warn("[LOG SCAN] block hash mismatch, rescanning block",
+			"block", num, "hash", block.Hash, "log_hash", logs[num][0].BlockHash)
+		found, err := e.filterLogs(ctx, evm.FilterQuery{
+			BlockHash: block.Hash,
+			Addresses: e.assets.allowList(),
+			Topics:    [][]string{{evm.ERC20_TRANSFER_TOPIC}},
+		})
+		delete(logs, num)
+		if err != nil {
+			if errs == nil {
+				errs = make(map[uint64]error)
+			}
+			errs[num] = fmt.Errorf("rescan transfer logs of block %d %s after a reorg: %w", num, block.Hash, err)
+			continue
+		}
+		addLogs(logs, found)
+	}
+	return errs
+}
+
+// mergeTransferLogs adds the scanned logs to receipts as receipts holding
+// only them, for convertBlock to extract their transfers, and lists their
+// transactions in txHashMap. Such a receipt is marked FromLogs: without
+// the gas used, the fee of their transfers is left unknown, zero with
+// "fee_unknown" metadata. Transactions with a fetched receipt keep it.
+// With a pubkey store, only transactions with a transfer involving a
+// monitored address are kept, as by queryERC20TransfersToMonitoredAddresses.
+func (e *EVMIndexer) mergeTransferLogs(
+	blocks map[uint64]*evm.Block,
+	txHashMap map[uint64][]string,
+	receipts map[string]*evm.TxnReceipt,
+	logs map[uint64][]evm.Log,
+) map[string]*evm.TxnReceipt {
+	if receipts == nil {
+		receipts = make(map[string]*evm.TxnReceipt)
+	}
+	for num, blockLogs := range logs {
+		block := blocks[num]
+		if block == nil {
+			continue
+		}
+		txs := make(map[string]*evm.Txn, len(block.Transactions))
+		for i := range block.Transactions {
+			txs[block.Transactions[i].Hash] = &block.Transactions[i]
+		}
+		byTx := make(map[string]*evm.TxnReceipt)
+		var order []string
+		for _, log := range blockLogs {
+			tx := txs[log.TransactionHash]
+			if tx == nil || receipts[log.TransactionHash] != nil {
+				continue
+			}
+			receipt := byTx[log.TransactionHash]
+			if receipt == nil {
+				receipt = &evm.TxnReceipt{TransactionHash: log.TransactionHash, FromLogs: true}
+				byTx[log.TransactionHash] = receipt
+				order = append(order, log.TransactionHash)
+			}
+			receipt.Logs = append(receipt.Logs, log)
+		}
+		for _, hash := range order {
+			receipt := byTx[hash]
+			if e.pubkeyStore != nil && !slices.ContainsFunc(receipt.Logs, e.monitoredTransferLog) {
+				continue
+			}
+			receipts[hash] = receipt
+			txHashMap[num] = append(txHashMap[num], hash)
+		}
+	}
+	return receipts
+}
+
+// addBlobTxsWithLogs lists in txHashMap the blob transactions holding
+// scanned logs, so that their receipts are fetched rather than made up by
+// mergeTransferLogs: only a receipt reports the blob gas paid. With a
+// pubkey store, only those with a transfer involving a monitored address
+// are listed.
+func (e *EVMIndexer) addBlobTxsWithLogs(
+	blocks map[uint64]*evm.Block,
+	txHashMap map[uint64][]string,
+	logs map[uint64][]evm.Log,
+) {
+	for num, blockLogs := range logs {
+		block := blocks[num]
+		if block == nil {
+			continue
+		}
+		blobTxs := make(map[string]bool)
+		for i := range block.Transactions {
+			if tx := &block.Transactions[i]; tx.IsBlobTx() {
+				blobTxs[tx.Hash] = true
+			}
+		}
+		for _, log := range blockLogs {
+			hash := log.TransactionHash
+			if !blobTxs[hash] || slices.Contains(txHashMap[num], hash) ||
+				(e.pubkeyStore != nil && !e.monitoredTransferLog(log)) {
+				continue
+			}
+			txHashMap[num] = append(txHashMap[num], hash)
+		}
+	}
+}
+
+// monitoredTransferLog reports whether a Transfer log credits a monitored
+// address, or debits one with two_way_indexing.
+func (e *EVMIndexer) monitoredTransferLog(log evm.Log) bool {
+	from, to, ok := transferLogParties(log)
+	return ok && e.monitoredTransfer(from, to)
+}
+
+func (e *EVMIndexer) monitoredTransfer(from, to string) bool {
+	fromMonitored := e.config.TwoWayIndexing && e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(from))
+	toMonitored := e.pubkeyStore.Exist(enum.NetworkTypeEVM, evmaddr.LowerAddress(to))
+	return fromMonitored || toMonitored
+}
+
+// transferLogParties returns the from and to addresses indexed by a
+// Transfer log, false if it lacks them.
+func transferLogParties(log evm.Log) (from, to string, ok bool) {
+	if len(log.Topics) < 3 {
+		return "", "", false
+	}
+	// Topics are 32 bytes, 64 hex chars without 0x; the address is the
+	// last 20 bytes.
+	fromTopic, toTopic := log.Topics[1], log.Topics[2]
+	if len(fromTopic) < 64 || len(toTopic) < 64 {
+		return "", "", false
+	}
+	return "0x" + fromTopic[len(fromTopic)-40:], "0x" + toTopic[len(toTopic)-40:], true
+}
+
+// contiguousRuns returns the first and last of each run of consecutive
+// numbers in nums, in ascending order.
+func contiguousRuns(nums []uint64) [][2]uint64 {
+	sorted := slices.Clone(nums)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	var runs [][2]uint64
+	for i, n := range sorted {
+		if i > 0 && n == runs[len(runs)-1][1]+1 {
+			runs[len(runs)-1][1] = n
+			continue
+		}
+		runs = append(runs, [2]uint64{n, n})
+	}
+	return runs
+}
@@ -0,0 +1,287 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/evm"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	logScanSender  = "0x1111111111111111111111111111111111111111"
+	logScanWatched = "0x2222222222222222222222222222222222222222"
+	logScanOther   = "0x3333333333333333333333333333333333333333"
+)
+
+// logScanNode serves eth_getLogs over at most maxRange blocks, and the
+// blocks and receipts in blocks and receipts.
+type logScanNode struct {
+	maxRange uint64
+	logs     []evm.Log
+	byHash   map[string][]evm.Log
+	blocks   map[uint64]*evm.Block
+	receipts map[string]*evm.TxnReceipt
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (n *logScanNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var reqs []struct {
+		ID     int64             `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	single := len(body) > 0 && body[0] == '{'
+	if single {
+		body = append(append(json.RawMessage("["), body...), ']')
+	}
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resps []map[string]any
+	for _, req := range reqs {
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_getLogs":
+			var filter struct {
+				FromBlock, ToBlock, BlockHash string
+			}
+			_ = json.Unmarshal(req.Params[0], &filter)
+			result, rpcErr := n.getLogs(filter.FromBlock, filter.ToBlock, filter.BlockHash)
+			if rpcErr != "" {
+				resp["error"] = map[string]any{"code": -32005, "message": rpcErr}
+			} else {
+				resp["result"] = result
+			}
+		case "eth_getBlockByNumber":
+			var hex string
+			_ = json.Unmarshal(req.Params[0], &hex)
+			num, _ := parseHexTestUint(hex)
+			n.mu.Lock()
+			n.queries = append(n.queries, fmt.Sprintf("block %d", num))
+			n.mu.Unlock()
+			resp["result"] = n.blocks[num]
+		case "eth_getTransactionReceipt":
+			var hash string
+			_ = json.Unmarshal(req.Params[0], &hash)
+			resp["result"] = n.receipts[hash]
+		}
+		resps = append(resps, resp)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if single {
+		_ = json.NewEncoder(w).Encode(resps[0])
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resps)
+}
+
+func (n *logScanNode) getLogs(from, to, hash string) ([]evm.Log, string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if hash != "" {
+		n.queries = append(n.queries, hash)
+		logs, ok := n.byHash[hash]
+		if !ok {
+			return nil, "unknown block"
+		}
+		return logs, ""
+	}
+	start, _ := parseHexTestUint(from)
+	end, _ := parseHexTestUint(to)
+	n.queries = append(n.queries, fmt.Sprintf("%d-%d", start, end))
+	if end-start+1 > n.maxRange {
+		return nil, "query returned more than 10000 results"
+	}
+	var logs []evm.Log
+	for _, log := range n.logs {
+		if num, _ := parseHexTestUint(log.BlockNumber); num >= start && num <= end {
+			logs = append(logs, log)
+		}
+	}
+	return logs, ""
+}
+
+func parseHexTestUint(s string) (uint64, error) {
+	var n uint64
+	_, err := fmt.Sscanf(strings.TrimPrefix(s, "0x"), "%x", &n)
+	return n, err
+}
+
+func transferLog(block uint64, blockHash, txHash, from, to string) evm.Log {
+	topic := func(addr string) string { return "0x000000000000000000000000" + strings.TrimPrefix(addr, "0x") }
+	return evm.Log{
+		Address:         bloomUSDT,
+		Topics:          []string{evm.ERC20_TRANSFER_TOPIC, topic(from), topic(to)},
+		Data:            "0x05",
+		BlockNumber:     fmt.Sprintf("0x%x", block),
+		BlockHash:       blockHash,
+		TransactionHash: txHash,
+		LogIndex:        "0x0",
+	}
+}
+
+func logScanBlock(num uint64, txs ...evm.Txn) *evm.Block {
+	return &evm.Block{
+		Number:       fmt.Sprintf("0x%x", num),
+		Hash:         fmt.Sprintf("0xb%d", num),
+		Timestamp:    "0x1",
+		Transactions: txs,
+	}
+}
+
+func tokenCall(hash string) evm.Txn {
+	return evm.Txn{Hash: hash, From: logScanSender, To: bloomUSDT, Input: "0xa9059cbb", Gas: "0x5208", GasPrice: "0x3b9aca00", TransactionIndex: "0x1"}
+}
+
+func newLogScanIndexer(t *testing.T, node *logScanNode) *EVMIndexer {
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	failover := rpc.NewFailover[evm.EthereumAPI](&rpc.FailoverConfig{MaxAttempts: 1})
+	require.NoError(t, failover.AddProvider(&rpc.Provider{
+		Name: "node", URL: server.URL, Network: "test", ClientType: "rpc", State: rpc.StateHealthy,
+		Client: evm.NewEthereumClient(server.URL, nil, 5*time.Second, nil),
+	}))
+	cfg := config.ChainConfig{NetworkId: "ethereum-mainnet", LogScan: config.LogScanConfig{Enabled: true, MaxRange: 4}}
+	idx := NewEVMIndexer("ethereum", cfg, failover, nil, evmPubkeyStoreStub{
+		addresses: map[string]bool{evmaddr.LowerAddress(logScanWatched): true},
+	})
+	return idx
+}
+
+func TestEVMLogScan(t *testing.T) {
+	native := evm.Txn{Hash: "0xnative", From: logScanSender, To: logScanWatched, Input: "0x", Value: "0x64", TransactionIndex: "0x0"}
+	node := &logScanNode{
+		maxRange: 2,
+		logs: []evm.Log{
+			transferLog(10, "0xb10", "0xtoken", logScanSender, logScanWatched),
+			transferLog(11, "0xb11", "0xunwatched", logScanSender, logScanOther),
+			transferLog(12, "0xstale", "0xreorged", logScanSender, logScanWatched),
+			transferLog(13, "0xstale", "0xlost", logScanSender, logScanWatched),
+		},
+		byHash: map[string][]evm.Log{
+			"0xb12": {transferLog(12, "0xb12", "0xreorged2", logScanSender, logScanWatched)},
+		},
+		receipts: map[string]*evm.TxnReceipt{
+			"0xnative": {TransactionHash: "0xnative", Status: "0x1", GasUsed: "0x1", EffectiveGasPrice: "0x1"},
+		},
+	}
+	idx := newLogScanIndexer(t, node)
+	blocks := map[uint64]*evm.Block{
+		10: logScanBlock(10, native, tokenCall("0xtoken")),
+		11: logScanBlock(11, tokenCall("0xunwatched")),
+		12: logScanBlock(12, tokenCall("0xreorged2")),
+		13: logScanBlock(13, tokenCall("0xlost")),
+	}
+
+	results, err := idx.processBlocksAndReceipts(context.Background(), []uint64{10, 11, 12, 13}, blocks, false)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, []string{"10-13", "10-11", "12-13"}, node.queries[:3], "the window halves when refused")
+	assert.ElementsMatch(t, []string{"0xb12", "0xb13"}, node.queries[3:], "reorged blocks are rescanned by hash")
+	assert.Equal(t, uint64(4), idx.logWindow.current(), "and grows back to max_range on success")
+
+	require.Nil(t, results[0].Error)
+	transfers := results[0].Block.Transactions
+	require.Len(t, transfers, 2)
+	assert.Equal(t, constant.TxTypeNativeTransfer, transfers[0].Type)
+	assert.Equal(t, "0xtoken", transfers[1].TxHash)
+	assert.Equal(t, "5", transfers[1].Amount)
+	assert.True(t, transfers[1].TxFee.IsZero(), "no receipt, no gas used: the fee is unknown")
+	assert.Equal(t, map[string]any{"fee_unknown": true}, transfers[1].Metadata)
+	assert.Nil(t, transfers[0].Metadata, "the native transfer's receipt was fetched")
+
+	require.Nil(t, results[1].Error)
+	assert.Empty(t, results[1].Block.Transactions, "no monitored address")
+
+	require.Nil(t, results[2].Error)
+	require.Len(t, results[2].Block.Transactions, 1)
+	assert.Equal(t, "0xreorged2", results[2].Block.Transactions[0].TxHash)
+
+	require.NotNil(t, results[3].Error)
+	assert.Contains(t, results[3].Error.Message, "after a reorg")
+}
+
+func TestEVMLogScan_FetchesMissingBlocksFirst(t *testing.T) {
+	node := &logScanNode{
+		maxRange: 4,
+		logs:     []evm.Log{transferLog(7, "0xb7", "0xtoken", logScanSender, logScanWatched)},
+		blocks:   map[uint64]*evm.Block{7: logScanBlock(7, tokenCall("0xtoken"))},
+	}
+	idx := newLogScanIndexer(t, node)
+	blocks := map[uint64]*evm.Block{6: logScanBlock(6)}
+
+	results, err := idx.processBlocksAndReceipts(context.Background(), []uint64{6, 7}, blocks, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"block 7", "6-7"}, node.queries, "a block fetched after the scan could be one it never saw")
+	require.Nil(t, results[1].Error)
+	require.Len(t, results[1].Block.Transactions, 1)
+	assert.Equal(t, "0xtoken", results[1].Block.Transactions[0].TxHash)
+}
+
+func TestEVMLogScan_FailsBatch(t *testing.T) {
+	node := &logScanNode{maxRange: 0}
+	idx := newLogScanIndexer(t, node)
+	blocks := map[uint64]*evm.Block{5: logScanBlock(5, tokenCall("0xtoken"))}
+
+	results, err := idx.processBlocksAndReceipts(context.Background(), []uint64{5}, blocks, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].Error, "a single block over the node's limit cannot be scanned")
+	assert.Contains(t, results[0].Error.Message, "log query range too large")
+}
+
+func TestEVMLogScan_BlobTxReceiptFetched(t *testing.T) {
+	log := transferLog(20, "0xb20", "0xblob", logScanSender, logScanWatched)
+	blobTx := tokenCall("0xblob")
+	blobTx.Type = "0x3"
+	blobTx.BlobVersionedHashes = []string{"0x01"}
+	node := &logScanNode{
+		maxRange: 4,
+		logs:     []evm.Log{log},
+		receipts: map[string]*evm.TxnReceipt{
+			"0xblob": {
+				TransactionHash: "0xblob", Status: "0x1", Logs: []evm.Log{log},
+				GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00",
+				BlobGasUsed: "0x20000", BlobGasPrice: "0x3b9aca00",
+			},
+		},
+	}
+	idx := newLogScanIndexer(t, node)
+	blocks := map[uint64]*evm.Block{20: logScanBlock(20, blobTx)}
+
+	results, err := idx.processBlocksAndReceipts(context.Background(), []uint64{20}, blocks, false)
+	require.NoError(t, err)
+	require.Nil(t, results[0].Error)
+	transfers := results[0].Block.Transactions
+	require.Len(t, transfers, 1)
+	// 21000 gas plus 131072 blob gas, both at 1 gwei.
+	assert.Equal(t, "0.000152072", transfers[0].TxFee.String(), "the fetched receipt reports the blob gas paid")
+}
+
+func TestContiguousRuns(t *testing.T) {
+	assert.Equal(t, [][2]uint64{{3, 5}, {9, 9}, {11, 12}}, contiguousRuns([]uint64{12, 3, 4, 5, 9, 11, 4}))
+	assert.Empty(t, contiguousRuns(nil))
+}
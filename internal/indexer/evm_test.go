@@ -10,6 +10,7 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -61,7 +62,7 @@ func TestExtractReceiptTxHashes_SelectsSafeOutgoingWhenTwoWayEnabled(t *testing.
 		},
 		pubkeyStore: evmPubkeyStoreStub{
 			addresses: map[string]bool{
-				evm.ToChecksumAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"): true,
+				evmaddr.LowerAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"): true,
 			},
 		},
 	}
@@ -84,6 +85,34 @@ func TestExtractReceiptTxHashes_SelectsSafeOutgoingWhenTwoWayEnabled(t *testing.
 	require.Equal(t, []string{"0xabc123"}, got[blockNum])
 }
 
+func TestExtractReceiptTxHashes_SelectsCreationFundingMonitoredContract(t *testing.T) {
+	sender := "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"
+	idx := &EVMIndexer{
+		chainName: "ethereum",
+		config:    config.ChainConfig{NetworkId: "ethereum-mainnet"},
+		pubkeyStore: evmPubkeyStoreStub{
+			addresses: map[string]bool{
+				// Deployed by sender at nonce 1.
+				evmaddr.LowerAddress("0x343c43a37d37dff08ae8c4a11544c718abb4fcf8"): true,
+			},
+		},
+	}
+
+	blockNum := uint64(123)
+	blocks := map[uint64]*evm.Block{
+		blockNum: {
+			Transactions: []evm.Txn{
+				{Hash: "0xmonitored", From: sender, Nonce: "0x1", Value: "0x1", Input: "0x6080"},
+				{Hash: "0xother", From: sender, Nonce: "0x2", Value: "0x1", Input: "0x6080"},
+				{Hash: "0xnovalue", From: sender, Nonce: "0x1", Value: "0x0", Input: "0x6080"},
+			},
+		},
+	}
+
+	got := idx.extractReceiptTxHashes(blocks, false)
+	require.Equal(t, []string{"0xmonitored"}, got[blockNum])
+}
+
 // TestParseGnosisSafeETHTransfer tests indexing a Gnosis Safe execTransaction that transfers 0.1 ETH internally.
 // Transaction: 0x7c98ff7c910b025736b11d2f70db001d5c2ec25df6de9fb65193963f6059b1f9
 // Block: 22869070
@@ -162,8 +191,8 @@ func TestParseGnosisSafeETHTransfer(t *testing.T) {
 	}
 
 	require.NotNil(t, nativeTransfer, "Gnosis Safe internal ETH transfer SHOULD be detected as native_transfer")
-	assert.Equal(t, evm.ToChecksumAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"), nativeTransfer.FromAddress, "from should be the Safe contract")
-	assert.Equal(t, evm.ToChecksumAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d"), nativeTransfer.ToAddress, "to should be the decoded recipient")
+	assert.Equal(t, evmaddr.LowerAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"), nativeTransfer.FromAddress, "from should be the Safe contract")
+	assert.Equal(t, evmaddr.LowerAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d"), nativeTransfer.ToAddress, "to should be the decoded recipient")
 	assert.Equal(t, "100000000000000000", nativeTransfer.Amount, "amount should be 0.1 ETH in wei")
 
 	t.Log("RESULT: Gnosis Safe execTransaction with internal ETH transfer IS indexed via input decoding.")
@@ -256,8 +285,8 @@ func TestParseGnosisSafeETHTransfer_Sepolia(t *testing.T) {
 	}
 
 	require.NotNil(t, nativeTransfer, "Gnosis Safe internal ETH transfer SHOULD be detected")
-	assert.Equal(t, evm.ToChecksumAddress(safeAddr), nativeTransfer.FromAddress, "from should be the Safe contract")
-	assert.Equal(t, evm.ToChecksumAddress(recipientAddr), nativeTransfer.ToAddress, "to should be the decoded recipient")
+	assert.Equal(t, evmaddr.LowerAddress(safeAddr), nativeTransfer.FromAddress, "from should be the Safe contract")
+	assert.Equal(t, evmaddr.LowerAddress(recipientAddr), nativeTransfer.ToAddress, "to should be the decoded recipient")
 	assert.Equal(t, "100000000000000000", nativeTransfer.Amount, "amount should be 0.1 ETH in wei")
 
 	t.Log("RESULT: Sepolia Gnosis Safe execTransaction with internal ETH transfer IS indexed correctly.")
@@ -439,8 +468,8 @@ func TestExtractReceiptTxHashes_TraceActive_NoDuplicateHashes(t *testing.T) {
 		},
 		pubkeyStore: evmPubkeyStoreStub{
 			addresses: map[string]bool{
-				evm.ToChecksumAddress("0xaaaa"): true,
-				evm.ToChecksumAddress("0xbbbb"): true,
+				evmaddr.LowerAddress("0xaaaa"): true,
+				evmaddr.LowerAddress("0xbbbb"): true,
 			},
 		},
 	}
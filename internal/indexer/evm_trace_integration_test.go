@@ -15,6 +15,7 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -106,8 +107,8 @@ func TestEndToEnd_TraceInConvertBlock_Integration(t *testing.T) {
 	for _, tr := range result.Transactions {
 		if tr.Type == constant.TxTypeNativeTransfer && tr.Amount == "100000000000000000" {
 			found = true
-			assert.Equal(t, evm.ToChecksumAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"), tr.FromAddress)
-			assert.Equal(t, evm.ToChecksumAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d"), tr.ToAddress)
+			assert.Equal(t, evmaddr.LowerAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c"), tr.FromAddress)
+			assert.Equal(t, evmaddr.LowerAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d"), tr.ToAddress)
 		}
 	}
 	assert.True(t, found, "should find 0.1 ETH internal transfer via trace")
@@ -0,0 +1,269 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// FetchPriority orders the block fetches of workers sharing an indexer's
+// fetch pool: queued fetches of a higher priority start first.
+type FetchPriority int
+
+const (
+	PriorityBackfill FetchPriority = iota // historical ranges, e.g. catchup
+	PriorityGap                           // failed or missing blocks
+	PriorityReorg                         // blocks rolled back by a reorg
+	PriorityTip                           // the chain tip, followed by the regular worker
+
+	numFetchPriorities = int(PriorityTip) + 1
+)
+
+func (p FetchPriority) String() string {
+	switch p {
+	case PriorityBackfill:
+		return "backfill"
+	case PriorityGap:
+		return "gap"
+	case PriorityReorg:
+		return "reorg"
+	case PriorityTip:
+		return "tip"
+	}
+	return "unknown"
+}
+
+type fetchPriorityKey struct{}
+
+// WithFetchPriority returns a copy of ctx under which blocks are fetched
+// with priority p.
+func WithFetchPriority(ctx context.Context, p FetchPriority) context.Context {
+	return context.WithValue(ctx, fetchPriorityKey{}, p)
+}
+
+// fetchPriorityOf returns the priority ctx carries, PriorityTip if none so
+// that callers unaware of priorities are never starved.
+func fetchPriorityOf(ctx context.Context) FetchPriority {
+	if p, ok := ctx.Value(fetchPriorityKey{}).(FetchPriority); ok && p >= 0 && int(p) < numFetchPriorities {
+		return p
+	}
+	return PriorityTip
+}
+
+// FetchQueueStats describes the fetches of one priority.
+type FetchQueueStats struct {
+	Queued    int    `json:"queued"`
+	InFlight  int    `json:"in_flight"`
+	Completed uint64 `json:"completed"`
+	// OldestQueuedSeconds is how long the oldest queued fetch has waited;
+	// it keeps growing while higher priorities starve this one.
+	OldestQueuedSeconds float64 `json:"oldest_queued_seconds"`
+}
+
+type fetchJob[K, V any] struct {
+	ctx      context.Context
+	key      K
+	priority FetchPriority
+	queuedAt time.Time
+	// done receives the result; it must not block.
+	done func(sessionCtx context.Context, v V, err error)
+}
+
+// fetchScheduler runs every fetch of an indexer, whichever worker asked
+// for it, with at most size at a time. A fetch is of the block at a height
+// K on chains fetching block by block, or of a whole request on chains
+// batching theirs, such as EVM. Queued fetches start by priority, then in
+// the order they were queued; fetches already running are never
+// interrupted. Workers are started as fetches are queued and exit once the
+// queues are empty.
+type fetchScheduler[K, V any] struct {
+	size  int
+	fetch func(ctx context.Context, key K) (context.Context, V, error)
+
+	mu        sync.Mutex
+	queues    [numFetchPriorities][]*fetchJob[K, V]
+	workers   int
+	inFlight  [numFetchPriorities]int
+	completed [numFetchPriorities]uint64
+}
+
+func newFetchScheduler[K, V any](
+	size int,
+	fetch func(ctx context.Context, key K) (context.Context, V, error),
+) *fetchScheduler[K, V] {
+	return &fetchScheduler[K, V]{size: max(size, 1), fetch: fetch}
+}
+
+// submit queues a fetch of key with the priority of ctx; done is called
+// with its result from a scheduler worker.
+func (s *fetchScheduler[K, V]) submit(
+	ctx context.Context,
+	key K,
+	done func(sessionCtx context.Context, v V, err error),
+) {
+	job := &fetchJob[K, V]{ctx: ctx, key: key, priority: fetchPriorityOf(ctx), queuedAt: time.Now(), done: done}
+
+	s.mu.Lock()
+	s.queues[job.priority] = append(s.queues[job.priority], job)
+	start := s.workers < s.size
+	if start {
+		s.workers++
+	}
+	s.mu.Unlock()
+
+	if start {
+		go s.work()
+	}
+}
+
+// fetchOne fetches key through the scheduler and waits for it.
+func (s *fetchScheduler[K, V]) fetchOne(ctx context.Context, key K) (context.Context, V, error) {
+	type result struct {
+		ctx context.Context
+		v   V
+		err error
+	}
+	ch := make(chan result, 1)
+	s.submit(ctx, key, func(sessionCtx context.Context, v V, err error) {
+		ch <- result{sessionCtx, v, err}
+	})
+	select {
+	case <-ctx.Done():
+		var zero V
+		return nil, zero, ctx.Err()
+	case r := <-ch:
+		return r.ctx, r.v, r.err
+	}
+}
+
+func (s *fetchScheduler[K, V]) work() {
+	var job *fetchJob[K, V]
+	for {
+		if job = s.next(job); job == nil {
+			return
+		}
+		// Drop fetches whose caller has gone while they were queued.
+		if err := job.ctx.Err(); err != nil {
+			var zero V
+			job.done(nil, zero, err)
+			continue
+		}
+		sessionCtx, v, err := s.runFetch(job)
+		job.done(sessionCtx, v, err)
+	}
+}
+
+// runFetch runs job's fetch, failing it with a *PanicError should the
+// fetch panic.
+func (s *fetchScheduler[K, V]) runFetch(job *fetchJob[K, V]) (_ context.Context, _ V, err error) {
+	defer recoverPanic(&err)
+	return s.fetch(job.ctx, job.key)
+}
+
+// next accounts for the finished job, if any, and dequeues the next one by
+// priority. It returns nil, retiring the worker, once the queues are empty.
+func (s *fetchScheduler[K, V]) next(finished *fetchJob[K, V]) *fetchJob[K, V] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if finished != nil {
+		s.inFlight[finished.priority]--
+		s.completed[finished.priority]++
+	}
+	for p := numFetchPriorities - 1; p >= 0; p-- {
+		if q := s.queues[p]; len(q) > 0 {
+			job := q[0]
+			q[0] = nil
+			s.queues[p] = q[1:]
+			s.inFlight[p]++
+			return job
+		}
+	}
+	s.workers--
+	return nil
+}
+
+// stats returns the FetchQueueStats of every priority, by name.
+func (s *fetchScheduler[K, V]) stats() map[string]FetchQueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stats := make(map[string]FetchQueueStats, numFetchPriorities)
+	for p := range numFetchPriorities {
+		st := FetchQueueStats{
+			Queued:    len(s.queues[p]),
+			InFlight:  s.inFlight[p],
+			Completed: s.completed[p],
+		}
+		if st.Queued > 0 {
+			st.OldestQueuedSeconds = now.Sub(s.queues[p][0].queuedAt).Seconds()
+		}
+		stats[FetchPriority(p).String()] = st
+	}
+	return stats
+}
+
+// blockFetcher is the fetchScheduler of an indexer fetching block by
+// block, built on first use so indexers built as literals have one.
+type blockFetcher struct {
+	once  sync.Once
+	sched *fetchScheduler[uint64, *types.Block]
+}
+
+// scheduler returns the scheduler, building it with size fetchers calling
+// fetchBlock the first time.
+func (f *blockFetcher) scheduler(
+	size int,
+	fetchBlock func(ctx context.Context, number uint64) (*types.Block, error),
+) *fetchScheduler[uint64, *types.Block] {
+	f.once.Do(func() {
+		f.sched = newFetchScheduler(size, func(ctx context.Context, number uint64) (context.Context, *types.Block, error) {
+			block, err := fetchBlock(ctx, number)
+			return ctx, block, err
+		})
+	})
+	return f.sched
+}
+
+// fetchAll fetches numbers through s, with the priority ctx carries, and
+// returns their results in the order of numbers, each failure converted
+// by toError. It returns ctx's error if ctx ends first.
+func fetchAll(
+	ctx context.Context,
+	s *fetchScheduler[uint64, *types.Block],
+	numbers []uint64,
+	toError func(error) *Error,
+) ([]BlockResult, error) {
+	results := make([]BlockResult, len(numbers))
+	var wg sync.WaitGroup
+	wg.Add(len(numbers))
+	for i, n := range numbers {
+		s.submit(ctx, n, func(_ context.Context, block *types.Block, err error) {
+			defer wg.Done()
+			results[i] = BlockResult{Number: n, Block: block}
+			if err != nil {
+				results[i].Error = toError(err)
+			}
+		})
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// firstBlockError returns an error naming the first failed block of
+// results, nil if none failed.
+func firstBlockError(results []BlockResult) error {
+	for _, r := range results {
+		if r.Error != nil {
+			return fmt.Errorf("block %d: %s", r.Number, r.Error.Message)
+		}
+	}
+	return nil
+}
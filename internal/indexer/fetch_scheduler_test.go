@@ -0,0 +1,136 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchSchedulerPriority(t *testing.T) {
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	var order []uint64
+	s := newFetchScheduler(1, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+		if n == 0 {
+			<-gate // hold the only fetcher while the queues fill
+		}
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+		return ctx, &bitcoin.Block{Height: n}, nil
+	})
+
+	var wg sync.WaitGroup
+	submit := func(p FetchPriority, n uint64) {
+		wg.Add(1)
+		s.submit(WithFetchPriority(context.Background(), p), n, func(context.Context, *bitcoin.Block, error) { wg.Done() })
+	}
+	submit(PriorityBackfill, 0)
+	require.Eventually(t, func() bool { return s.stats()["backfill"].InFlight == 1 }, time.Second, time.Millisecond)
+
+	submit(PriorityBackfill, 1)
+	submit(PriorityBackfill, 2)
+	submit(PriorityGap, 10)
+	submit(PriorityTip, 30)
+	submit(PriorityReorg, 20)
+	wg.Add(1)
+	s.submit(context.Background(), 31, func(context.Context, *bitcoin.Block, error) { wg.Done() }) // untagged: tip
+
+	stats := s.stats()
+	assert.Equal(t, FetchQueueStats{Queued: 2, InFlight: 1}, withoutAge(stats["backfill"]))
+	assert.Equal(t, 2, stats["tip"].Queued)
+	assert.Positive(t, stats["backfill"].OldestQueuedSeconds)
+
+	close(gate)
+	wg.Wait()
+	assert.Equal(t, []uint64{0, 30, 31, 20, 10, 1, 2}, order, "higher priorities overtake queued backfill")
+
+	stats = s.stats()
+	assert.Equal(t, FetchQueueStats{Completed: 3}, stats["backfill"])
+	assert.Equal(t, FetchQueueStats{Completed: 2}, stats["tip"])
+}
+
+func TestFetchSchedulerDropsCanceled(t *testing.T) {
+	gate := make(chan struct{})
+	fetched := make(chan uint64, 2)
+	s := newFetchScheduler(1, func(ctx context.Context, n uint64) (context.Context, *bitcoin.Block, error) {
+		<-gate
+		fetched <- n
+		return ctx, &bitcoin.Block{Height: n}, nil
+	})
+	s.submit(context.Background(), 1, func(context.Context, *bitcoin.Block, error) {})
+
+	ctx, cancel := context.WithCancel(WithFetchPriority(context.Background(), PriorityBackfill))
+	done := make(chan error, 1)
+	go func() {
+		_, err := blockPipeline{fetcher: s, processWorkers: 1}.run(ctx, []uint64{2, 3})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return s.stats()["backfill"].Queued == 2 }, time.Second, time.Millisecond)
+
+	// The run returns although its fetches are still queued.
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	close(gate)
+	assert.Equal(t, uint64(1), <-fetched)
+	require.Eventually(t, func() bool { return s.stats()["backfill"].Completed == 2 }, time.Second, time.Millisecond)
+	assert.Empty(t, fetched, "canceled fetches are dropped")
+}
+
+func TestFetchAllOvertakenByTip(t *testing.T) {
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	var order []uint64
+	var f blockFetcher
+	s := f.scheduler(1, func(ctx context.Context, n uint64) (*types.Block, error) {
+		if n == 1 {
+			<-gate
+		}
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+		if n == 3 {
+			return nil, fmt.Errorf("block 3: %w", rpc.ErrNotFound)
+		}
+		return &types.Block{Number: n}, nil
+	})
+
+	done := make(chan []BlockResult, 1)
+	go func() {
+		results, err := fetchAll(WithFetchPriority(context.Background(), PriorityBackfill), s, []uint64{1, 2, 3}, NewError)
+		assert.NoError(t, err)
+		done <- results
+	}()
+	require.Eventually(t, func() bool { return s.stats()["backfill"].Queued == 2 }, time.Second, time.Millisecond)
+
+	tip := make(chan *types.Block, 1)
+	go func() {
+		_, block, err := s.fetchOne(context.Background(), 10)
+		assert.NoError(t, err)
+		tip <- block
+	}()
+	require.Eventually(t, func() bool { return s.stats()["tip"].Queued == 1 }, time.Second, time.Millisecond)
+
+	close(gate)
+	assert.Equal(t, uint64(10), (<-tip).Number)
+	results := <-done
+	assert.Equal(t, []uint64{1, 10, 2, 3}, order, "the tip overtakes queued backfill")
+	require.Len(t, results, 3)
+	assert.Equal(t, uint64(2), results[1].Block.Number)
+	assert.Equal(t, ErrorTypeBlockNotFound, results[2].Error.ErrorType)
+	assert.EqualError(t, firstBlockError(results), "block 3: block 3: not found")
+}
+
+func withoutAge(st FetchQueueStats) FetchQueueStats {
+	st.OldestQueuedSeconds = 0
+	return st
+}
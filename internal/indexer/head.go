@@ -0,0 +1,274 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/evm"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/utils"
+)
+
+// headPushRetry is how long a chain polls before trying a failed push
+// source again.
+const headPushRetry = 30 * time.Second
+
+// defaultHeadPollInterval is the poll interval of a PollHeadSource without
+// one.
+const defaultHeadPollInterval = 5 * time.Second
+
+// HeadEvent announces a new chain head.
+type HeadEvent struct {
+	Height uint64 // 0 when the source only knows the hash, as ZMQ
+	Hash   string // empty when the source only knows the height, as polling
+	Source string // config.HeadSourcePoll, HeadSourceZMQ or HeadSourceWebsocket
+}
+
+// HeadSource delivers the chain's new heads. The channel is closed when ctx
+// ends or the source fails; heads may be coalesced while the receiver is
+// busy.
+type HeadSource interface {
+	Subscribe(ctx context.Context) (<-chan HeadEvent, error)
+}
+
+// HeadSourceReporter is implemented by head sources switching between
+// sources, to report the one in use.
+type HeadSourceReporter interface {
+	ActiveHeadSource() string
+}
+
+// NewHeadSource returns the chain's configured push source, falling back to
+// polling chain while it is down, see config.HeadConfig, or for polling
+// chains a PollHeadSource.
+func NewHeadSource(chainName string, cfg config.ChainConfig, chain Indexer) HeadSource {
+	poll := &PollHeadSource{Latest: chain.GetLatestBlockNumber, Interval: cfg.PollInterval}
+	var push HeadSource
+	switch cfg.Head.Source {
+	case config.HeadSourceZMQ:
+		push = zmqHeadSource{endpoint: cfg.Head.URL}
+	case config.HeadSourceWebsocket:
+		push = wsHeadSource{url: cfg.Head.URL}
+	default:
+		return poll
+	}
+	return &FallbackHeadSource{
+		Push:       push,
+		PushName:   cfg.Head.Source,
+		Poll:       poll,
+		StaleAfter: cfg.Head.StaleAfter,
+		Log:        ChainLogger(chainName, cfg),
+	}
+}
+
+// PollHeadSource polls Latest every Interval, or Next() apart when set,
+// and announces the height when it grows.
+type PollHeadSource struct {
+	Latest   func(ctx context.Context) (uint64, error)
+	Interval time.Duration // 0 is 5s
+	// Next, if set, returns the wait before the next poll, e.g. a
+	// worker's adaptive poll schedule; see SchedulePolls.
+	Next func() time.Duration
+}
+
+func (p *PollHeadSource) Subscribe(ctx context.Context) (<-chan HeadEvent, error) {
+	events := make(chan HeadEvent, 1)
+	go func() {
+		defer close(events)
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		var last uint64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if height, err := p.Latest(ctx); err == nil && height > last {
+				last = height
+				sendHead(events, HeadEvent{Height: height, Source: config.HeadSourcePoll})
+			}
+			timer.Reset(p.wait())
+		}
+	}()
+	return events, nil
+}
+
+func (p *PollHeadSource) wait() time.Duration {
+	if p.Next != nil {
+		return p.Next()
+	}
+	if p.Interval <= 0 {
+		return defaultHeadPollInterval
+	}
+	return p.Interval
+}
+
+// SchedulePolls has the polls of src, a PollHeadSource or the one a
+// FallbackHeadSource falls back to, wait next() apart. It must be called
+// before src is subscribed to.
+func SchedulePolls(src HeadSource, next func() time.Duration) {
+	switch s := src.(type) {
+	case *PollHeadSource:
+		s.Next = next
+	case *FallbackHeadSource:
+		SchedulePolls(s.Poll, next)
+	}
+}
+
+// FallbackHeadSource relays a push source, and Poll while Push cannot be
+// subscribed to, has closed, or stayed silent for StaleAfter. Push is
+// tried again every 30s while polling.
+type FallbackHeadSource struct {
+	Push       HeadSource
+	PushName   string
+	Poll       HeadSource
+	StaleAfter time.Duration // 0 never deems a subscribed push source stale
+	Log        *slog.Logger
+
+	retry  time.Duration // 0 is headPushRetry
+	active atomic.Value  // string
+}
+
+func (f *FallbackHeadSource) Subscribe(ctx context.Context) (<-chan HeadEvent, error) {
+	events := make(chan HeadEvent, 1)
+	go func() {
+		defer close(events)
+		for ctx.Err() == nil {
+			f.relayPush(ctx, events)
+			f.relayPoll(ctx, events)
+		}
+	}()
+	return events, nil
+}
+
+// PushActive reports whether the push source is the one in use, see
+// PushSource.
+func (f *FallbackHeadSource) PushActive() bool {
+	return f.ActiveHeadSource() == f.PushName
+}
+
+// ActiveHeadSource returns the name of the source in use.
+func (f *FallbackHeadSource) ActiveHeadSource() string {
+	if active, ok := f.active.Load().(string); ok {
+		return active
+	}
+	return config.HeadSourcePoll
+}
+
+// relayPush relays the push source until it fails, closes or goes stale.
+func (f *FallbackHeadSource) relayPush(ctx context.Context, out chan<- HeadEvent) {
+	pushCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in, err := f.Push.Subscribe(pushCtx)
+	if err != nil {
+		f.logger().Warn("Head source unavailable, polling", "source", f.PushName, "error", err)
+		return
+	}
+	f.active.Store(f.PushName)
+	defer f.active.Store(config.HeadSourcePoll)
+	f.logger().Info("Head source subscribed", "source", f.PushName)
+
+	var stale <-chan time.Time
+	var timer *time.Timer
+	if f.StaleAfter > 0 {
+		timer = time.NewTimer(f.StaleAfter)
+		defer timer.Stop()
+		stale = timer.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-in:
+			if !ok {
+				f.logger().Warn("Head source closed, polling", "source", f.PushName)
+				return
+			}
+			sendHead(out, ev)
+			if timer != nil {
+				timer.Reset(f.StaleAfter)
+			}
+		case <-stale:
+			f.logger().Warn("Head source stale, polling", "source", f.PushName, "stale_after", f.StaleAfter)
+			return
+		}
+	}
+}
+
+// relayPoll relays the poll source until the push source is due a retry.
+func (f *FallbackHeadSource) relayPoll(ctx context.Context, out chan<- HeadEvent) {
+	retry := f.retry
+	if retry <= 0 {
+		retry = headPushRetry
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, retry)
+	defer cancel()
+	in, err := f.Poll.Subscribe(pollCtx)
+	if err != nil {
+		<-pollCtx.Done()
+		return
+	}
+	for ev := range in {
+		sendHead(out, ev)
+	}
+}
+
+func (f *FallbackHeadSource) logger() *slog.Logger {
+	return chainLog{log: f.Log}.logger()
+}
+
+// sendHead delivers ev unless a head is already pending: the receiver only
+// needs to know the chain moved.
+func sendHead(events chan<- HeadEvent, ev HeadEvent) {
+	select {
+	case events <- ev:
+	default:
+	}
+}
+
+// zmqHeadSource subscribes to bitcoind's hashblock ZMQ topic.
+type zmqHeadSource struct {
+	endpoint string
+}
+
+func (z zmqHeadSource) Subscribe(ctx context.Context) (<-chan HeadEvent, error) {
+	hashes, err := bitcoin.SubscribeHashBlock(ctx, z.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return relayHeads(hashes, func(hash string) HeadEvent {
+		return HeadEvent{Hash: hash, Source: config.HeadSourceZMQ}
+	}), nil
+}
+
+// wsHeadSource subscribes to an EVM node's newHeads over websocket.
+type wsHeadSource struct {
+	url string
+}
+
+func (w wsHeadSource) Subscribe(ctx context.Context) (<-chan HeadEvent, error) {
+	heads, err := evm.SubscribeNewHeads(ctx, w.url)
+	if err != nil {
+		return nil, err
+	}
+	return relayHeads(heads, func(head evm.Head) HeadEvent {
+		height, _ := utils.ParseHexUint64(head.Number)
+		return HeadEvent{Height: height, Hash: head.Hash, Source: config.HeadSourceWebsocket}
+	}), nil
+}
+
+// relayHeads converts a subscription's items into HeadEvents, closing the
+// returned channel with in.
+func relayHeads[T any](in <-chan T, convert func(T) HeadEvent) <-chan HeadEvent {
+	events := make(chan HeadEvent, 1)
+	go func() {
+		defer close(events)
+		for item := range in {
+			sendHead(events, convert(item))
+		}
+	}()
+	return events
+}
@@ -0,0 +1,145 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePushSource fails its first failures subscriptions, then hands out
+// feed.
+type fakePushSource struct {
+	failures atomic.Int32
+	feed     chan HeadEvent
+}
+
+func (f *fakePushSource) Subscribe(ctx context.Context) (<-chan HeadEvent, error) {
+	if f.failures.Add(-1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+	return f.feed, nil
+}
+
+func countingPoll() (*PollHeadSource, *atomic.Uint64) {
+	var height atomic.Uint64
+	return &PollHeadSource{
+		Latest:   func(context.Context) (uint64, error) { return height.Add(1), nil },
+		Interval: time.Millisecond,
+	}, &height
+}
+
+func nextHead(t *testing.T, events <-chan HeadEvent) HeadEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		require.True(t, ok, "head source closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no head event")
+		return HeadEvent{}
+	}
+}
+
+func TestPollHeadSource(t *testing.T) {
+	heights := []uint64{5, 5, 6}
+	var calls atomic.Int32
+	poll := &PollHeadSource{
+		Latest: func(context.Context) (uint64, error) {
+			return heights[min(int(calls.Add(1))-1, len(heights)-1)], nil
+		},
+		Interval: time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := poll.Subscribe(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, HeadEvent{Height: 5, Source: config.HeadSourcePoll}, nextHead(t, events))
+	assert.Equal(t, HeadEvent{Height: 6, Source: config.HeadSourcePoll}, nextHead(t, events), "unchanged heights are not announced")
+	cancel()
+	for range events {
+	}
+}
+
+// growingChain is an Indexer whose head grows by one on each call.
+type growingChain struct {
+	Indexer
+	height atomic.Uint64
+}
+
+func (c *growingChain) GetLatestBlockNumber(context.Context) (uint64, error) {
+	return c.height.Add(1), nil
+}
+
+func TestNewHeadSource_PollsWithoutPush(t *testing.T) {
+	chain := &growingChain{}
+	source := NewHeadSource("test", config.ChainConfig{PollInterval: time.Hour}, chain)
+	require.IsType(t, &PollHeadSource{}, source, "polling chains get a PollHeadSource")
+
+	SchedulePolls(source, func() time.Duration { return time.Millisecond })
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := source.Subscribe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nextHead(t, events).Height)
+	assert.Equal(t, uint64(2), nextHead(t, events).Height, "polls follow the schedule, not Interval")
+	cancel()
+	for range events {
+	}
+
+	fallback := NewHeadSource("test", config.ChainConfig{Head: config.HeadConfig{Source: config.HeadSourceZMQ}}, chain)
+	SchedulePolls(fallback, func() time.Duration { return time.Second })
+	assert.NotNil(t, fallback.(*FallbackHeadSource).Poll.(*PollHeadSource).Next)
+}
+
+func TestFallbackHeadSource_RecoversPush(t *testing.T) {
+	push := &fakePushSource{feed: make(chan HeadEvent, 1)}
+	push.failures.Store(1)
+	poll, _ := countingPoll()
+	source := &FallbackHeadSource{Push: push, PushName: config.HeadSourceZMQ, Poll: poll, retry: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := source.Subscribe(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.HeadSourcePoll, nextHead(t, events).Source, "polls while the push source is down")
+	assert.False(t, source.PushActive())
+
+	require.Eventually(t, source.PushActive, 5*time.Second, time.Millisecond, "retries the push source")
+	assert.Equal(t, config.HeadSourceZMQ, source.ActiveHeadSource())
+	for len(events) > 0 {
+		<-events
+	}
+	push.feed <- HeadEvent{Hash: "h1", Source: config.HeadSourceZMQ}
+	assert.Equal(t, HeadEvent{Hash: "h1", Source: config.HeadSourceZMQ}, nextHead(t, events))
+
+	close(push.feed)
+	require.Eventually(t, func() bool { return !source.PushActive() }, 5*time.Second, time.Millisecond,
+		"falls back when the push source closes")
+	assert.Equal(t, config.HeadSourcePoll, nextHead(t, events).Source)
+}
+
+func TestFallbackHeadSource_Stale(t *testing.T) {
+	push := &fakePushSource{feed: make(chan HeadEvent)}
+	poll, _ := countingPoll()
+	source := &FallbackHeadSource{
+		Push: push, PushName: config.HeadSourceWebsocket, Poll: poll,
+		StaleAfter: 20 * time.Millisecond, retry: time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := source.Subscribe(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.HeadSourcePoll, nextHead(t, events).Source, "a silent push source is deemed stale")
+	assert.Equal(t, config.HeadSourcePoll, source.ActiveHeadSource())
+
+	cancel()
+	for range events {
+	}
+}
@@ -2,7 +2,9 @@ package indexer
 
 import (
 	"context"
+	"time"
 
+	"github.com/fystack/multichain-indexer/internal/rpc"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 )
@@ -14,8 +16,101 @@ type Indexer interface {
 	GetLatestBlockNumber(ctx context.Context) (uint64, error)
 	GetBlock(ctx context.Context, number uint64) (*types.Block, error)
 
-	// batch version: each block can have its own error
+	// batch version: each block can have its own error. Fetches run on the
+	// indexer's fetch pool by the FetchPriority of ctx (WithFetchPriority);
+	// isParallel only matters to chains batching a call's blocks, e.g. EVM.
 	GetBlocks(ctx context.Context, from, to uint64, isParallel bool) ([]BlockResult, error)
 	GetBlocksByNumbers(ctx context.Context, blockNumbers []uint64) ([]BlockResult, error)
 	IsHealthy() bool
 }
+
+// PushSource is implemented by indexers that can learn of new blocks from a
+// push feed (e.g. ZMQ or a websocket subscription). While PushActive is true
+// workers poll at the plain PollInterval as a fallback only.
+type PushSource interface {
+	PushActive() bool
+}
+
+// HashFetcher is implemented by indexers that can fetch a block by hash,
+// including one a reorg took off the main chain. Such a block carries
+// "canonical" metadata set to false.
+type HashFetcher interface {
+	GetBlockByHash(ctx context.Context, hash string) (*types.Block, error)
+}
+
+// BlockTimeFinder is implemented by indexers that can find the last block
+// mined at or before a point in time, to turn dates into heights, e.g.
+// "index from 2024-01-01". See ErrNoBlockBefore.
+type BlockTimeFinder interface {
+	FindBlockByTime(ctx context.Context, target time.Time) (uint64, error)
+}
+
+// ScriptWatcher is implemented by pubkey stores that also watch
+// scriptPubKeys, by their synthetic script ID (see bitcoin.ScriptID), for
+// outputs with no address form such as bare multisig.
+type ScriptWatcher interface {
+	WatchedScript(networkType enum.NetworkType, scriptID string) (label string, ok bool)
+}
+
+// RedeemScriptResolver is implemented by pubkey stores that know the
+// redeem scripts registered for script-hash addresses, hex-encoded, to
+// verify deposits to them and tell which cosigners spent from them.
+type RedeemScriptResolver interface {
+	RedeemScript(networkType enum.NetworkType, address string) (script string, ok bool)
+}
+
+// ProviderReporter is implemented by indexers backed by an RPC failover pool,
+// to describe its nodes in status reports.
+type ProviderReporter interface {
+	ProviderStatuses() []rpc.ProviderStatus
+}
+
+// OutputStatsReporter is implemented by UTXO indexers that tally the
+// script types of the outputs they index.
+type OutputStatsReporter interface {
+	OutputStats() OutputStats
+}
+
+// FetchQueueReporter is implemented by indexers scheduling their block
+// fetches by FetchPriority, to report them by priority name.
+type FetchQueueReporter interface {
+	FetchQueueStats() map[string]FetchQueueStats
+}
+
+// NodeDiagnoser is implemented by indexers that can query their nodes for
+// troubleshooting details, reported by node name. It is called on demand,
+// so implementations must limit how often they query each node.
+type NodeDiagnoser interface {
+	DiagnoseNodes(ctx context.Context) map[string]any
+}
+
+// HealthPersister is implemented by indexers backed by an RPC failover pool,
+// to keep node health across restarts.
+type HealthPersister interface {
+	UseHealthStore(ctx context.Context, store rpc.HealthStore)
+}
+
+// ResponseCacheReporter is implemented by indexers caching immutable node
+// responses, to report the cache's hit rate. ResponseCacheStats returns nil
+// while no cache is in use.
+type ResponseCacheReporter interface {
+	ResponseCacheStats() *rpc.ResponseCacheStats
+}
+
+// RollbackObserver is implemented by indexers keeping state by block
+// height, to drop that of heights a reorg rolled back, from from on.
+type RollbackObserver interface {
+	RolledBack(ctx context.Context, from uint64) error
+}
+
+// NetworkChecker is implemented by indexers backed by an RPC failover pool,
+// to keep nodes serving another network than configured out of it.
+type NetworkChecker interface {
+	ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error
+}
+
+// NodeWarmer is implemented by indexers backed by an RPC failover pool, to
+// probe their nodes before first use, see rpc.Failover.WarmUp.
+type NodeWarmer interface {
+	WarmUp(ctx context.Context)
+}
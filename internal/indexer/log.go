@@ -0,0 +1,30 @@
+package indexer
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+// ChainLogger returns the logger for everything serving a chain: its
+// indexer, workers and failover pools log with the chain's name, as
+// returned by GetName, and network ID, from the chain's level.
+func ChainLogger(chainName string, cfg config.ChainConfig) *slog.Logger {
+	return logger.ForChain(strings.ToUpper(chainName), cfg.NetworkId, cfg.Logging.Leveler())
+}
+
+// chainLog is embedded in indexers to log with their chain's context.
+type chainLog struct {
+	log *slog.Logger
+}
+
+// logger returns the chain's logger, or the global one for indexers built
+// without it.
+func (c chainLog) logger() *slog.Logger {
+	if c.log == nil {
+		return logger.With()
+	}
+	return c.log
+}
@@ -0,0 +1,48 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// ErrBlockPanic marks a block whose fetching or processing panicked. The
+// panic is recovered so the rest of its batch completes, and the block is
+// failed like any other, see PanicError.
+var ErrBlockPanic = errors.New("panic while fetching or processing block")
+
+// PanicError is a recovered panic with the stack it was raised on.
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrBlockPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error { return ErrBlockPanic }
+
+// recoverPanic, deferred, turns a panic of the calling function into a
+// *PanicError stored in *err. Worker loops call each block's work through a
+// function deferring it, so a panic fails that block instead of killing the
+// worker and leaving its batch waiting for a result that never comes.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: string(debug.Stack())}
+	}
+}
+
+// getBlockRecovering calls getBlock for number, failing the block with a
+// *PanicError should it panic.
+func getBlockRecovering(
+	ctx context.Context,
+	getBlock func(ctx context.Context, number uint64) (*types.Block, error),
+	number uint64,
+) (_ *types.Block, err error) {
+	defer recoverPanic(&err)
+	return getBlock(ctx, number)
+}
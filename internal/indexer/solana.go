@@ -13,18 +13,18 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
-	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/mr-tron/base58"
 	"github.com/shopspring/decimal"
-	"golang.org/x/sync/errgroup"
 )
 
 type SolanaIndexer struct {
+	chainLog
 	chainName   string
 	config      config.ChainConfig
 	failover    *rpc.Failover[solana.SolanaAPI]
 	pubkeyStore PubkeyStore
+	fetcher     blockFetcher
 }
 
 func NewSolanaIndexer(
@@ -33,7 +33,13 @@ func NewSolanaIndexer(
 	failover *rpc.Failover[solana.SolanaAPI],
 	pubkeyStore PubkeyStore,
 ) *SolanaIndexer {
-	return &SolanaIndexer{chainName: chainName, config: cfg, failover: failover, pubkeyStore: pubkeyStore}
+	return &SolanaIndexer{
+		chainLog:    chainLog{ChainLogger(chainName, cfg)},
+		chainName:   chainName,
+		config:      cfg,
+		failover:    failover,
+		pubkeyStore: pubkeyStore,
+	}
 }
 
 func (s *SolanaIndexer) GetName() string                  { return strings.ToUpper(s.chainName) }
@@ -64,24 +70,11 @@ func (s *SolanaIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error
 	return slot, err
 }
 
+// GetBlock fetches the block at slot number through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (s *SolanaIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
-	results, err := s.GetBlocksByNumbers(ctx, []uint64{number})
-	if err != nil {
-		return nil, err
-	}
-
-	if len(results) == 0 || results[0].Error != nil {
-		if len(results) > 0 && results[0].Error != nil {
-			return nil, fmt.Errorf("block error: %s", results[0].Error.Message)
-		}
-		return nil, fmt.Errorf("block not found")
-	}
-
-	if results[0].Block == nil {
-		return nil, fmt.Errorf("block not found")
-	}
-
-	return results[0].Block, nil
+	_, block, err := s.fetchScheduler().fetchOne(ctx, number)
+	return block, err
 }
 
 func (s *SolanaIndexer) GetBlocks(ctx context.Context, from, to uint64, isParallel bool) ([]BlockResult, error) {
@@ -92,139 +85,68 @@ func (s *SolanaIndexer) GetBlocks(ctx context.Context, from, to uint64, isParall
 	for n := from; n <= to; n++ {
 		nums = append(nums, n)
 	}
-
-	if !isParallel {
-		return s.getBlocksByNumbersSequential(ctx, nums)
-	}
 	return s.GetBlocksByNumbers(ctx, nums)
 }
 
-func (s *SolanaIndexer) getBlocksByNumbersSequential(ctx context.Context, blockNumbers []uint64) ([]BlockResult, error) {
-	results := make([]BlockResult, 0, len(blockNumbers))
-	for _, slot := range blockNumbers {
-		slot := slot
-		var b *solana.GetBlockResult
-		err := s.failover.ExecuteWithRetry(ctx, func(c solana.SolanaAPI) error {
-			blk, err := c.GetBlock(ctx, slot)
-			b = blk
-			return err
-		})
-		if err != nil {
-			results = append(results, BlockResult{Number: slot, Error: &Error{ErrorType: ErrorTypeUnknown, Message: err.Error()}})
-			continue
-		}
-		if b == nil {
-			results = append(results, BlockResult{Number: slot, Error: &Error{ErrorType: ErrorTypeBlockNotFound, Message: "block not found (skipped slot?)"}})
-			continue
-		}
-
-		timestamp := uint64(0)
-		if b.BlockTime != nil {
-			timestamp = uint64(*b.BlockTime)
-		} else {
-			timestamp = uint64(time.Now().UTC().Unix())
-		}
-		logger.Debug("[SOLANA] fetched block",
-			"chain", s.chainName,
-			"slot", slot,
-			"txs", len(b.Transactions),
-			"blockhash", b.Blockhash,
-			"parent", b.PreviousBlockhash,
-		)
-
-		txs := s.extractSolanaTransfers(s.config.NetworkId, slot, timestamp, b)
-		block := &types.Block{
-			Number:       slot,
-			Hash:         b.Blockhash,
-			ParentHash:   b.PreviousBlockhash,
-			Timestamp:    timestamp,
-			Transactions: txs,
-		}
-		results = append(results, BlockResult{Number: slot, Block: block})
-	}
-	return results, nil
-}
-
+// GetBlocksByNumbers fetches the blocks at slots blockNumbers through the
+// indexer's fetch scheduler, by the priority ctx carries. Failed and
+// skipped slots are reported in their BlockResult only.
 func (s *SolanaIndexer) GetBlocksByNumbers(ctx context.Context, blockNumbers []uint64) ([]BlockResult, error) {
 	if len(blockNumbers) == 0 {
 		return []BlockResult{}, nil
 	}
+	return fetchAll(ctx, s.fetchScheduler(), blockNumbers, NewError)
+}
 
-	maxConc := s.config.Throttle.Concurrency
-	if maxConc <= 0 {
-		maxConc = 1
-	}
-
-	results := make([]BlockResult, len(blockNumbers))
-
-	eg, egCtx := errgroup.WithContext(ctx)
-	sem := make(chan struct{}, maxConc)
-
-	for i, slot := range blockNumbers {
-		i := i
-		slot := slot
-		eg.Go(func() error {
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-egCtx.Done():
-				return egCtx.Err()
-			}
-
-			var (
-				b    *solana.GetBlockResult
-				berr error
-			)
-			berr = s.failover.ExecuteWithRetry(egCtx, func(c solana.SolanaAPI) error {
-				blk, err := c.GetBlock(egCtx, slot)
-				b = blk
-				return err
-			})
-
-			if berr != nil {
-				results[i] = BlockResult{Number: slot, Error: &Error{ErrorType: ErrorTypeUnknown, Message: berr.Error()}}
-				return nil
-			}
-			if b == nil {
-				results[i] = BlockResult{Number: slot, Error: &Error{ErrorType: ErrorTypeBlockNotFound, Message: "block not found (skipped slot?)"}}
-				return nil
-			}
+// fetchScheduler returns the pool every block fetch of the indexer runs on,
+// Throttle.Concurrency at a time.
+func (s *SolanaIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
+	return s.fetcher.scheduler(s.config.Throttle.Concurrency, s.fetchSlot)
+}
 
-			timestamp := uint64(0)
-			if b.BlockTime != nil {
-				timestamp = uint64(*b.BlockTime)
-			} else {
-				timestamp = uint64(time.Now().UTC().Unix())
-			}
-			logger.Debug("[SOLANA] fetched block",
-				"chain", s.chainName,
-				"slot", slot,
-				"txs", len(b.Transactions),
-				"blockhash", b.Blockhash,
-				"parent", b.PreviousBlockhash,
-			)
-
-			txs := s.extractSolanaTransfers(s.config.NetworkId, slot, timestamp, b)
-			block := &types.Block{
-				Number:       slot,
-				Hash:         b.Blockhash,
-				ParentHash:   b.PreviousBlockhash,
-				Timestamp:    timestamp,
-				Transactions: txs,
-			}
-			results[i] = BlockResult{Number: slot, Block: block}
-			return nil
-		})
-	}
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (s *SolanaIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return s.fetchScheduler().stats()
+}
 
-	if err := eg.Wait(); err != nil {
-		if err == context.Canceled || err == context.DeadlineExceeded {
-			return nil, err
-		}
+// fetchSlot fetches the block at slot, failing with rpc.ErrNotFound when
+// the slot was skipped.
+func (s *SolanaIndexer) fetchSlot(ctx context.Context, slot uint64) (*types.Block, error) {
+	var b *solana.GetBlockResult
+	err := s.failover.ExecuteWithRetry(ctx, func(c solana.SolanaAPI) error {
+		blk, err := c.GetBlock(ctx, slot)
+		b = blk
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
+	if b == nil {
+		return nil, fmt.Errorf("block not found (skipped slot?): %w", rpc.ErrNotFound)
+	}
 
-	return results, nil
+	timestamp := uint64(0)
+	if b.BlockTime != nil {
+		timestamp = uint64(*b.BlockTime)
+	} else {
+		timestamp = uint64(time.Now().UTC().Unix())
+	}
+	s.logger().Debug("[SOLANA] fetched block",
+		"slot", slot,
+		"txs", len(b.Transactions),
+		"blockhash", b.Blockhash,
+		"parent", b.PreviousBlockhash,
+	)
+
+	txs := s.extractSolanaTransfers(s.config.NetworkId, slot, timestamp, b)
+	return &types.Block{
+		Number:       slot,
+		Hash:         b.Blockhash,
+		ParentHash:   b.PreviousBlockhash,
+		Timestamp:    timestamp,
+		Transactions: txs,
+		InternalCode: s.config.InternalCode,
+	}, nil
 }
 
 func (s *SolanaIndexer) IsHealthy() bool {
@@ -234,6 +156,21 @@ func (s *SolanaIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (s *SolanaIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return s.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (s *SolanaIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	s.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (s *SolanaIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return s.failover.ExpectNetwork(ctx, want)
+}
+
 const solanaSystemProgramID = "11111111111111111111111111111111"
 const solanaTokenProgramID = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
 const solanaToken2022ProgramID = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb"
@@ -354,57 +291,77 @@ func solanaParseSystemTransfer(ix solana.Instruction, accountKeys []solana.Accou
 	return accountKeys[srcIdx].Pubkey, accountKeys[dstIdx].Pubkey, amt, true
 }
 
-func solanaParseTokenTransfer(ix solana.Instruction, accountKeys []solana.AccountKey) (srcTokenAcc string, dstTokenAcc string, mint string, amount uint64, ok bool) {
+// solanaTokenTransfer is a transfer instruction of either token program.
+type solanaTokenTransfer struct {
+	program     string // solanaTokenProgramID or solanaToken2022ProgramID, if known
+	srcTokenAcc string
+	dstTokenAcc string
+	mint        string // empty for plain transfer, which names no mint
+	authority   string // owner, delegate or multisig signing for the source
+	amount      uint64 // debited from the source
+	// fee is the Token-2022 transfer fee withheld from amount at the
+	// destination, when the instruction states it (transferCheckedWithFee).
+	fee      uint64
+	feeKnown bool
+}
+
+func solanaParseAmount(v any) uint64 {
+	switch v := v.(type) {
+	case string:
+		if vv, err := decimal.NewFromString(v); err == nil {
+			return vv.BigInt().Uint64()
+		}
+	case float64:
+		return uint64(v)
+	case int:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+	return 0
+}
+
+func solanaParseTokenTransfer(ix solana.Instruction, accountKeys []solana.AccountKey) (solanaTokenTransfer, bool) {
 	// Prefer jsonParsed format
 	// Typical:
 	// { program:"spl-token", programId:"Tokenkeg...", parsed:{ type:"transfer", info:{ source:"..", destination:"..", amount:"123", authority:".." } } }
 	// TransferChecked:
 	// { type:"transferChecked", info:{ source:"..", destination:"..", mint:"..", tokenAmount:{ amount:"..", decimals:n } } }
-	if ix.Program == "spl-token" || ix.ProgramId == solanaTokenProgramID || ix.ProgramId == solanaToken2022ProgramID {
+	// TransferCheckedWithFee (Token-2022) adds feeAmount:{ amount:"..", decimals:n }.
+	// Multisig-owned sources name multisigAuthority and signers instead of authority.
+	if ix.Program == "spl-token" || ix.Program == "spl-token-2022" ||
+		ix.ProgramId == solanaTokenProgramID || ix.ProgramId == solanaToken2022ProgramID {
 		if p, _ := ix.Parsed.(map[string]any); p != nil {
 			t, _ := p["type"].(string)
 			if info, _ := p["info"].(map[string]any); info != nil {
-				var src, dst, mintOut string
-				src, _ = info["source"].(string)
-				dst, _ = info["destination"].(string)
-				mintOut, _ = info["mint"].(string)
+				tr := solanaTokenTransfer{program: ix.ProgramId}
+				if tr.program == "" && ix.Program == "spl-token-2022" {
+					tr.program = solanaToken2022ProgramID
+				}
+				tr.srcTokenAcc, _ = info["source"].(string)
+				tr.dstTokenAcc, _ = info["destination"].(string)
+				tr.mint, _ = info["mint"].(string)
+				tr.authority, _ = info["authority"].(string)
+				if tr.authority == "" {
+					tr.authority, _ = info["multisigAuthority"].(string)
+				}
 
-				var amt uint64
 				switch t {
 				case "transfer":
 					// amount can be string or number
-					switch v := info["amount"].(type) {
-					case string:
-						if vv, err := decimal.NewFromString(v); err == nil {
-							amt = vv.BigInt().Uint64()
-						}
-					case float64:
-						amt = uint64(v)
-					case int:
-						amt = uint64(v)
-					case uint64:
-						amt = v
-					}
+					tr.amount = solanaParseAmount(info["amount"])
 				case "transferChecked", "transferCheckedWithFee":
 					// tokenAmount: { amount:"..", decimals:n, uiAmountString:".." }
 					if ta, _ := info["tokenAmount"].(map[string]any); ta != nil {
-						switch v := ta["amount"].(type) {
-						case string:
-							if vv, err := decimal.NewFromString(v); err == nil {
-								amt = vv.BigInt().Uint64()
-							}
-						case float64:
-							amt = uint64(v)
-						case int:
-							amt = uint64(v)
-						case uint64:
-							amt = v
-						}
+						tr.amount = solanaParseAmount(ta["amount"])
+					}
+					if fa, _ := info["feeAmount"].(map[string]any); fa != nil {
+						tr.fee, tr.feeKnown = solanaParseAmount(fa["amount"]), true
 					}
 				}
 
-				if src != "" && dst != "" && amt > 0 {
-					return src, dst, mintOut, amt, true
+				if tr.srcTokenAcc != "" && tr.dstTokenAcc != "" && tr.amount > 0 {
+					return tr, true
 				}
 			}
 		}
@@ -413,55 +370,136 @@ func solanaParseTokenTransfer(ix solana.Instruction, accountKeys []solana.Accoun
 	// Fallback for encoding=json where ix.Data is base58
 	progIdx := int(ix.ProgramIdIndex)
 	if progIdx < 0 || progIdx >= len(accountKeys) {
-		return "", "", "", 0, false
+		return solanaTokenTransfer{}, false
 	}
 	prog := accountKeys[progIdx].Pubkey
 	if prog != solanaTokenProgramID && prog != solanaToken2022ProgramID {
-		return "", "", "", 0, false
+		return solanaTokenTransfer{}, false
 	}
 
 	accIdx := solanaInstructionAccountsToIndices(ix.Accounts, accountKeys)
+	for _, i := range accIdx {
+		if i < 0 || i >= len(accountKeys) {
+			return solanaTokenTransfer{}, false
+		}
+	}
 	data, err := solanaDecodeIxDataBase58(ix.Data)
 	if err != nil {
-		return "", "", "", 0, false
+		return solanaTokenTransfer{}, false
 	}
 	if len(data) < 1 {
-		return "", "", "", 0, false
+		return solanaTokenTransfer{}, false
 	}
 
-	switch data[0] {
-	case 3:
-		if len(accIdx) < 2 || len(data) < 9 {
-			return "", "", "", 0, false
-		}
-		srcIdx := accIdx[0]
-		dstIdx := accIdx[1]
-		if srcIdx < 0 || srcIdx >= len(accountKeys) || dstIdx < 0 || dstIdx >= len(accountKeys) {
-			return "", "", "", 0, false
-		}
-		amt := binary.LittleEndian.Uint64(data[1:9])
-		if amt == 0 {
-			return "", "", "", 0, false
-		}
-		return accountKeys[srcIdx].Pubkey, accountKeys[dstIdx].Pubkey, "", amt, true
-	case 12, 26: // 12 = transferChecked, 26 = transferCheckedWithFee (same account layout)
+	tr := solanaTokenTransfer{program: prog}
+	switch {
+	case data[0] == 3:
+		// transfer: source, destination, authority[, signers]
 		if len(accIdx) < 3 || len(data) < 9 {
-			return "", "", "", 0, false
+			return solanaTokenTransfer{}, false
 		}
-		srcIdx := accIdx[0]
-		mintIdx := accIdx[1]
-		dstIdx := accIdx[2]
-		if srcIdx < 0 || srcIdx >= len(accountKeys) || dstIdx < 0 || dstIdx >= len(accountKeys) || mintIdx < 0 || mintIdx >= len(accountKeys) {
-			return "", "", "", 0, false
+		tr.srcTokenAcc = accountKeys[accIdx[0]].Pubkey
+		tr.dstTokenAcc = accountKeys[accIdx[1]].Pubkey
+		tr.authority = accountKeys[accIdx[2]].Pubkey
+		tr.amount = binary.LittleEndian.Uint64(data[1:9])
+	case data[0] == 12:
+		// transferChecked: source, mint, destination, authority[, signers]
+		if len(accIdx) < 4 || len(data) < 9 {
+			return solanaTokenTransfer{}, false
 		}
-		amt := binary.LittleEndian.Uint64(data[1:9])
-		if amt == 0 {
-			return "", "", "", 0, false
+		tr.srcTokenAcc = accountKeys[accIdx[0]].Pubkey
+		tr.mint = accountKeys[accIdx[1]].Pubkey
+		tr.dstTokenAcc = accountKeys[accIdx[2]].Pubkey
+		tr.authority = accountKeys[accIdx[3]].Pubkey
+		tr.amount = binary.LittleEndian.Uint64(data[1:9])
+	case data[0] == 26 && prog == solanaToken2022ProgramID:
+		// TransferFeeExtension; only its transferCheckedWithFee (1) moves
+		// tokens: amount u64, decimals u8, fee u64, with transferChecked's accounts.
+		if len(data) < 19 || data[1] != 1 || len(accIdx) < 4 {
+			return solanaTokenTransfer{}, false
 		}
-		return accountKeys[srcIdx].Pubkey, accountKeys[dstIdx].Pubkey, accountKeys[mintIdx].Pubkey, amt, true
+		tr.srcTokenAcc = accountKeys[accIdx[0]].Pubkey
+		tr.mint = accountKeys[accIdx[1]].Pubkey
+		tr.dstTokenAcc = accountKeys[accIdx[2]].Pubkey
+		tr.authority = accountKeys[accIdx[3]].Pubkey
+		tr.amount = binary.LittleEndian.Uint64(data[2:10])
+		tr.fee, tr.feeKnown = binary.LittleEndian.Uint64(data[11:19]), true
 	default:
-		return "", "", "", 0, false
+		return solanaTokenTransfer{}, false
 	}
+	if tr.amount == 0 {
+		return solanaTokenTransfer{}, false
+	}
+	return tr, true
+}
+
+// solanaTokenBalances maps token accounts to their owner, mint and raw
+// balance before and after a transaction, and mints to their decimals, from
+// its token balance metadata.
+type solanaTokenBalances struct {
+	owner    map[string]string
+	mint     map[string]string
+	pre      map[string]uint64
+	post     map[string]uint64
+	decimals map[string]uint8
+}
+
+func newSolanaTokenBalances(meta *solana.TxnMeta, accountKeys []solana.AccountKey) solanaTokenBalances {
+	tb := solanaTokenBalances{
+		owner:    map[string]string{},
+		mint:     map[string]string{},
+		pre:      map[string]uint64{},
+		post:     map[string]uint64{},
+		decimals: map[string]uint8{},
+	}
+	add := func(balances []solana.TokenBalance, amounts map[string]uint64) {
+		for _, b := range balances {
+			idx := int(b.AccountIndex)
+			if idx < 0 || idx >= len(accountKeys) {
+				continue
+			}
+			acc := accountKeys[idx].Pubkey
+			if b.Owner != "" {
+				tb.owner[acc] = b.Owner
+			}
+			if b.Mint != "" {
+				tb.mint[acc] = b.Mint
+				tb.decimals[b.Mint] = b.UiTokenAmount.Decimals
+			}
+			amounts[acc] = solanaParseAmount(b.UiTokenAmount.Amount)
+		}
+	}
+	add(meta.PreTokenBalances, tb.pre)
+	add(meta.PostTokenBalances, tb.post)
+	return tb
+}
+
+// unit returns the unit of mint's amounts, nil when the transaction's
+// balances do not state it.
+func (tb solanaTokenBalances) unit(mint string) *types.AmountUnit {
+	decimals, ok := tb.decimals[mint]
+	if !ok {
+		return nil
+	}
+	return &types.AmountUnit{Decimals: decimals}
+}
+
+// withheldFee returns the Token-2022 transfer fee withheld from tr. Unless
+// the instruction states it, it is the part of the amount the destination
+// didn't receive, which is only attributable to tr when tr is the one
+// transfer touching the destination; touched counts transfers per account.
+func (tb solanaTokenBalances) withheldFee(tr solanaTokenTransfer, touched map[string]int) uint64 {
+	if tr.feeKnown {
+		return tr.fee
+	}
+	if tr.program != solanaToken2022ProgramID || touched[tr.dstTokenAcc] != 1 {
+		return 0
+	}
+	pre, post := tb.pre[tr.dstTokenAcc], tb.post[tr.dstTokenAcc]
+	if post <= pre || post-pre >= tr.amount {
+		return 0
+	}
+	return tr.amount - (post - pre)
 }
 
 func (s *SolanaIndexer) extractSolanaTransfers(networkID string, slot uint64, ts uint64, b *solana.GetBlockResult) []types.Transaction {
@@ -480,36 +518,17 @@ func (s *SolanaIndexer) extractSolanaTransfers(networkID string, slot uint64, ts
 		fee := decimal.NewFromInt(int64(tx.Meta.Fee))
 		accountKeys := tx.Transaction.Message.AccountKeys
 
-		// Build token-account -> (owner, mint) lookup from token balance metadata.
-		// This isn't used to infer transfers; only to map SPL token accounts to owners/mints.
-		tokenOwnerByAcc := map[string]string{}
-		tokenMintByAcc := map[string]string{}
-		for _, tb := range tx.Meta.PreTokenBalances {
-			idx := int(tb.AccountIndex)
-			if idx >= 0 && idx < len(accountKeys) {
-				acc := accountKeys[idx].Pubkey
-				if tb.Owner != "" {
-					tokenOwnerByAcc[acc] = tb.Owner
-				}
-				if tb.Mint != "" {
-					tokenMintByAcc[acc] = tb.Mint
-				}
-			}
-		}
-		for _, tb := range tx.Meta.PostTokenBalances {
-			idx := int(tb.AccountIndex)
-			if idx >= 0 && idx < len(accountKeys) {
-				acc := accountKeys[idx].Pubkey
-				if tb.Owner != "" {
-					tokenOwnerByAcc[acc] = tb.Owner
-				}
-				if tb.Mint != "" {
-					tokenMintByAcc[acc] = tb.Mint
-				}
-			}
-		}
+		// Token-account -> (owner, mint, balances) lookup from token balance metadata.
+		// This isn't used to infer transfers; only to map SPL token accounts to
+		// owners/mints and to measure Token-2022 transfer fees.
+		balances := newSolanaTokenBalances(tx.Meta, accountKeys)
 
 		transferIdx := 0
+		nextIndex := func() string {
+			idx := fmt.Sprintf("%d:%d", txIdx, transferIdx)
+			transferIdx++
+			return idx
+		}
 
 		appendNative := func(from, to string, lamports uint64) {
 			if !s.isMonitoredTransfer(from, to) {
@@ -518,9 +537,10 @@ func (s *SolanaIndexer) extractSolanaTransfers(networkID string, slot uint64, ts
 			out = append(out, types.Transaction{
 				TxHash:        txHash,
 				NetworkId:     networkID,
+				InternalCode:  s.config.InternalCode,
 				BlockNumber:   slot,
 				BlockHash:     b.Blockhash,
-				TransferIndex: fmt.Sprintf("%d:%d", txIdx, transferIdx),
+				TransferIndex: nextIndex(),
 				FromAddress:   from,
 				ToAddress:     to,
 				AssetAddress:  "",
@@ -529,51 +549,91 @@ func (s *SolanaIndexer) extractSolanaTransfers(networkID string, slot uint64, ts
 				TxFee:         fee,
 				Timestamp:     ts,
 			})
-			transferIdx++
 		}
 
-		appendSPL := func(srcTokenAcc, dstTokenAcc, mint string, amount uint64) {
-			fromOwner := tokenOwnerByAcc[srcTokenAcc]
-			toOwner := tokenOwnerByAcc[dstTokenAcc]
+		// appendSPL emits the amount the destination received and, for a
+		// Token-2022 transfer fee, a fee record of the amount withheld.
+		// Token accounts are attributed to the wallets owning them, falling
+		// back to the signing authority for a source missing from the
+		// balance metadata; a multisig owner is the wallet.
+		appendSPL := func(tr solanaTokenTransfer, withheld uint64) {
+			fromOwner := balances.owner[tr.srcTokenAcc]
+			if fromOwner == "" {
+				fromOwner = tr.authority
+			}
+			toOwner := balances.owner[tr.dstTokenAcc]
 
 			if !s.isMonitoredTransfer(fromOwner, toOwner) {
 				return
 			}
 
+			mint := tr.mint
 			if mint == "" {
-				mint = tokenMintByAcc[srcTokenAcc]
+				mint = balances.mint[tr.srcTokenAcc]
 				if mint == "" {
-					mint = tokenMintByAcc[dstTokenAcc]
+					mint = balances.mint[tr.dstTokenAcc]
 				}
 			}
 			if mint == "" {
 				return
 			}
 
+			withheld = min(withheld, tr.amount)
+			unit := balances.unit(mint)
 			out = append(out, types.Transaction{
 				TxHash:        txHash,
 				NetworkId:     networkID,
+				InternalCode:  s.config.InternalCode,
 				BlockNumber:   slot,
 				BlockHash:     b.Blockhash,
-				TransferIndex: fmt.Sprintf("%d:%d", txIdx, transferIdx),
+				TransferIndex: nextIndex(),
 				FromAddress:   fromOwner,
 				ToAddress:     toOwner,
 				AssetAddress:  mint,
-				Amount:        strconv.FormatUint(amount, 10),
+				Amount:        strconv.FormatUint(tr.amount-withheld, 10),
 				Type:          constant.TxTypeTokenTransfer,
 				TxFee:         fee,
 				Timestamp:     ts,
+				Unit:          unit,
 			})
-			transferIdx++
+			if withheld > 0 {
+				out = append(out, types.Transaction{
+					TxHash:        txHash,
+					NetworkId:     networkID,
+					InternalCode:  s.config.InternalCode,
+					BlockNumber:   slot,
+					BlockHash:     b.Blockhash,
+					TransferIndex: nextIndex(),
+					FromAddress:   fromOwner,
+					AssetAddress:  mint,
+					Amount:        strconv.FormatUint(withheld, 10),
+					Type:          constant.TxTypeFee,
+					TxFee:         fee,
+					Timestamp:     ts,
+					Unit:          unit,
+				})
+			}
 		}
 
+		// Parse every instruction first: inferring a withheld fee needs to
+		// know how many transfers touch each token account.
+		type parsedIx struct {
+			native        bool
+			from, to      string
+			lamports      uint64
+			tokenTransfer solanaTokenTransfer
+		}
+		var parsed []parsedIx
+		touched := map[string]int{}
 		processIx := func(ix solana.Instruction) {
 			if from, to, lamports, ok := solanaParseSystemTransfer(ix, accountKeys); ok {
-				appendNative(from, to, lamports)
+				parsed = append(parsed, parsedIx{native: true, from: from, to: to, lamports: lamports})
 				return
 			}
-			if srcTok, dstTok, mint, amt, ok := solanaParseTokenTransfer(ix, accountKeys); ok {
-				appendSPL(srcTok, dstTok, mint, amt)
+			if tr, ok := solanaParseTokenTransfer(ix, accountKeys); ok {
+				parsed = append(parsed, parsedIx{tokenTransfer: tr})
+				touched[tr.srcTokenAcc]++
+				touched[tr.dstTokenAcc]++
 				return
 			}
 		}
@@ -586,6 +646,14 @@ func (s *SolanaIndexer) extractSolanaTransfers(networkID string, slot uint64, ts
 				processIx(ix)
 			}
 		}
+
+		for _, p := range parsed {
+			if p.native {
+				appendNative(p.from, p.to, p.lamports)
+				continue
+			}
+			appendSPL(p.tokenTransfer, balances.withheldFee(p.tokenTransfer, touched))
+		}
 	}
 
 	return out
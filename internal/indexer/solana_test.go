@@ -2,6 +2,11 @@ package indexer
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,6 +14,7 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -370,3 +376,123 @@ func TestParseSquadsMultisigTransfer(t *testing.T) {
 		tokenTransfer.FromAddress, tokenTransfer.ToAddress,
 		tokenTransfer.Amount, tokenTransfer.AssetAddress)
 }
+
+// loadSolanaFixture reads a jsonParsed getTransaction result from
+// testdata/solana.
+func loadSolanaFixture(t *testing.T, name string) *solana.GetTransactionResult {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "solana", name+".json"))
+	require.NoError(t, err)
+	var tx solana.GetTransactionResult
+	require.NoError(t, json.Unmarshal(raw, &tx))
+	return &tx
+}
+
+type solanaTransferWant struct {
+	typ              constant.TxType
+	from, to, amount string
+	asset            string
+}
+
+func solanaTransfersOf(transfers []types.Transaction) []solanaTransferWant {
+	var got []solanaTransferWant
+	for _, tr := range transfers {
+		got = append(got, solanaTransferWant{tr.Type, tr.FromAddress, tr.ToAddress, tr.Amount, tr.AssetAddress})
+	}
+	return got
+}
+
+func TestSolanaTokenTransferFixtures(t *testing.T) {
+	const (
+		usdc    = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+		feeMint = "FeeMint2022xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx1"
+		walletC = "WalletC1111111111111111111111111111111111111"
+		walletD = "WalletD1111111111111111111111111111111111111"
+	)
+	mintDecimals := map[string]uint8{usdc: 6, feeMint: 9}
+	tests := []struct {
+		fixture string
+		want    []solanaTransferWant
+	}{
+		{
+			// SPL Token transferChecked, attributed to the owning wallets.
+			fixture: "spl_transfer_checked",
+			want: []solanaTransferWant{
+				{constant.TxTypeTokenTransfer, "WalletA1111111111111111111111111111111111111",
+					"WalletB1111111111111111111111111111111111111", "2500000", usdc},
+			},
+		},
+		{
+			// Token-2022 transferCheckedWithFee: net amount plus the stated fee.
+			fixture: "token2022_transfer_checked_with_fee",
+			want: []solanaTransferWant{
+				{constant.TxTypeTokenTransfer, walletC, walletD, "990000000", feeMint},
+				{constant.TxTypeFee, walletC, "", "10000000", feeMint},
+			},
+		},
+		{
+			// Token-2022 transferChecked on a fee mint: fee read from balances.
+			fixture: "token2022_transfer_checked_withheld",
+			want: []solanaTransferWant{
+				{constant.TxTypeTokenTransfer, walletC, walletD, "495000000", feeMint},
+				{constant.TxTypeFee, walletC, "", "5000000", feeMint},
+			},
+		},
+		{
+			// Source token account owned by an SPL multisig.
+			fixture: "multisig_owner_transfer",
+			want: []solanaTransferWant{
+				{constant.TxTypeTokenTransfer, "Multisig11111111111111111111111111111111111",
+					"WalletE1111111111111111111111111111111111111", "42000000", usdc},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			tx := loadSolanaFixture(t, tt.fixture)
+			transfers := newTestSolanaIndexer().extractSolanaTransfers("solana-mainnet", tx.Slot, 0, txToBlockResult(tx))
+			assert.Equal(t, tt.want, solanaTransfersOf(transfers))
+			for i, tr := range transfers {
+				assert.Equal(t, fmt.Sprintf("0:%d", i), tr.TransferIndex)
+				assert.Equal(t, &types.AmountUnit{Decimals: mintDecimals[tr.AssetAddress]}, tr.Unit,
+					"unit from the token balances")
+			}
+		})
+	}
+}
+
+func TestSolanaTokenTransfer_RawEncoding(t *testing.T) {
+	const (
+		src, dst, mint, authority = "srcAta", "dstAta", "mint2022", "multisig"
+		signer                    = "signer"
+	)
+	keys := []solana.AccountKey{
+		{Pubkey: signer}, {Pubkey: src}, {Pubkey: mint}, {Pubkey: dst}, {Pubkey: authority},
+		{Pubkey: solanaToken2022ProgramID},
+	}
+
+	// TransferFeeExtension / transferCheckedWithFee: amount, decimals, fee.
+	data := []byte{26, 1}
+	data = binary.LittleEndian.AppendUint64(data, 1000)
+	data = append(data, 6)
+	data = binary.LittleEndian.AppendUint64(data, 25)
+
+	env := solana.TxnEnvelope{Signatures: []string{"rawSig"}}
+	env.Message.AccountKeys = keys
+	env.Message.Instructions = []solana.Instruction{{
+		ProgramIdIndex: 5,
+		// source, mint, destination, multisig authority, signer
+		Accounts: []any{float64(1), float64(2), float64(3), float64(4), float64(0)},
+		Data:     base58.Encode(data),
+	}}
+	// Only the destination is in the balance metadata: the source is
+	// attributed to its signing authority.
+	meta := &solana.TxnMeta{PostTokenBalances: []solana.TokenBalance{{AccountIndex: 3, Mint: mint, Owner: "walletDst"}}}
+
+	block := &solana.GetBlockResult{Transactions: []solana.BlockTxn{{Meta: meta, Transaction: env}}}
+	transfers := newTestSolanaIndexer().extractSolanaTransfers("solana-mainnet", 1, 0, block)
+	assert.Equal(t, []solanaTransferWant{
+		{constant.TxTypeTokenTransfer, authority, "walletDst", "975", mint},
+		{constant.TxTypeFee, authority, "", "25", mint},
+	}, solanaTransfersOf(transfers))
+}
@@ -0,0 +1,99 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+)
+
+// DefaultMaxBlocksInMemory bounds a streamed fetch window when
+// Throttle.MaxBlocksInMemory is unset.
+const DefaultMaxBlocksInMemory = 100
+
+// BlockStreamer is implemented by indexers that can fetch a height range in
+// bounded windows, handing each result to fn in height order instead of
+// returning the whole range at once.
+type BlockStreamer interface {
+	GetBlocksStream(ctx context.Context, from, to uint64, fn func(BlockResult) error) error
+}
+
+// StreamBlocks feeds fn the results for from..to in height order, holding at
+// most one window of blocks in memory. It uses idx's GetBlocksStream when
+// available and otherwise calls GetBlocks window by window. An error from fn
+// stops the stream and is returned.
+func StreamBlocks(
+	ctx context.Context,
+	idx Indexer,
+	from, to uint64,
+	throttle config.Throttle,
+	fn func(BlockResult) error,
+) error {
+	if s, ok := idx.(BlockStreamer); ok {
+		return s.GetBlocksStream(ctx, from, to, fn)
+	}
+	return streamWindows(ctx, from, to, throttle, func(ctx context.Context, from, to uint64) ([]BlockResult, error) {
+		return idx.GetBlocks(ctx, from, to, false)
+	}, fn)
+}
+
+// streamWindows walks from..to in windows of at most
+// throttle.MaxBlocksInMemory heights, fetching each with fetch and passing
+// its results to fn before fetching the next. A fetch error with no results
+// ends the stream; per-block errors travel in the results.
+func streamWindows(
+	ctx context.Context,
+	from, to uint64,
+	throttle config.Throttle,
+	fetch func(ctx context.Context, from, to uint64) ([]BlockResult, error),
+	fn func(BlockResult) error,
+) error {
+	maxBlocks := throttle.MaxBlocksInMemory
+	if maxBlocks <= 0 {
+		maxBlocks = DefaultMaxBlocksInMemory
+	}
+
+	window := maxBlocks
+	for start := from; start <= to; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := to
+		if to-start >= uint64(window) {
+			end = start + uint64(window) - 1
+		}
+
+		results, err := fetch(ctx, start, end)
+		if err != nil && len(results) == 0 {
+			return err
+		}
+		var size uint64
+		var sized int
+		for _, res := range results {
+			if res.Block != nil && res.Block.Size > 0 {
+				size += res.Block.Size
+				sized++
+			}
+			if err := fn(res); err != nil {
+				return err
+			}
+		}
+
+		if end == to {
+			break
+		}
+		start = end + 1
+		window = nextWindow(maxBlocks, throttle.MaxMemoryBytes, size, sized)
+	}
+	return nil
+}
+
+// nextWindow sizes the next window to fit budget bytes at the average block
+// size seen in the last one, within [1, maxBlocks]. Without a budget or any
+// sized blocks it is maxBlocks.
+func nextWindow(maxBlocks, budget int, size uint64, sized int) int {
+	if budget <= 0 || sized == 0 || size == 0 {
+		return maxBlocks
+	}
+	avg := size / uint64(sized)
+	return int(max(1, min(uint64(budget)/max(avg, 1), uint64(maxBlocks))))
+}
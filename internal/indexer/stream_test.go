@@ -0,0 +1,113 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// windowRecorder fakes a range fetch, recording the requested windows.
+type windowRecorder struct {
+	windows   [][2]uint64
+	blockSize uint64
+}
+
+func (w *windowRecorder) fetch(_ context.Context, from, to uint64) ([]BlockResult, error) {
+	w.windows = append(w.windows, [2]uint64{from, to})
+	var results []BlockResult
+	for n := from; n <= to; n++ {
+		results = append(results, BlockResult{Number: n, Block: &types.Block{Number: n, Size: w.blockSize}})
+	}
+	return results, nil
+}
+
+func TestStreamWindows_BoundedInOrder(t *testing.T) {
+	rec := &windowRecorder{}
+	var got []uint64
+	err := streamWindows(context.Background(), 10, 34, config.Throttle{MaxBlocksInMemory: 10}, rec.fetch,
+		func(res BlockResult) error {
+			got = append(got, res.Number)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]uint64{{10, 19}, {20, 29}, {30, 34}}, rec.windows)
+	require.Len(t, got, 25)
+	for i, n := range got {
+		assert.Equal(t, uint64(10+i), n)
+	}
+}
+
+func TestStreamWindows_DefaultWindow(t *testing.T) {
+	rec := &windowRecorder{}
+	require.NoError(t, streamWindows(context.Background(), 1, 250, config.Throttle{}, rec.fetch,
+		func(BlockResult) error { return nil }))
+	assert.Equal(t, [][2]uint64{{1, 100}, {101, 200}, {201, 250}}, rec.windows)
+}
+
+func TestStreamWindows_ByteBudgetShrinksWindow(t *testing.T) {
+	rec := &windowRecorder{blockSize: 1000}
+	throttle := config.Throttle{MaxBlocksInMemory: 10, MaxMemoryBytes: 3500}
+	require.NoError(t, streamWindows(context.Background(), 0, 19, throttle, rec.fetch,
+		func(BlockResult) error { return nil }))
+	assert.Equal(t, [][2]uint64{{0, 9}, {10, 12}, {13, 15}, {16, 18}, {19, 19}}, rec.windows,
+		"after the first window, 3500 bytes fit 3 blocks of 1000")
+}
+
+func TestStreamWindows_CallbackErrorStops(t *testing.T) {
+	rec := &windowRecorder{}
+	stop := errors.New("stop")
+	err := streamWindows(context.Background(), 0, 99, config.Throttle{MaxBlocksInMemory: 5}, rec.fetch,
+		func(res BlockResult) error {
+			if res.Number == 7 {
+				return stop
+			}
+			return nil
+		})
+	assert.ErrorIs(t, err, stop)
+	assert.Len(t, rec.windows, 2, "no window is fetched after the callback fails")
+}
+
+func TestStreamWindows_FetchError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	err := streamWindows(context.Background(), 0, 9, config.Throttle{MaxBlocksInMemory: 5},
+		func(context.Context, uint64, uint64) ([]BlockResult, error) {
+			calls++
+			return nil, boom
+		},
+		func(BlockResult) error { return nil })
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamWindows_PartialResultsPassThrough(t *testing.T) {
+	var got []BlockResult
+	err := streamWindows(context.Background(), 0, 1, config.Throttle{},
+		func(context.Context, uint64, uint64) ([]BlockResult, error) {
+			return []BlockResult{
+				{Number: 0, Block: &types.Block{}},
+				{Number: 1, Error: &Error{ErrorType: ErrorTypeTimeout, Message: "timeout"}},
+			}, errors.New("block 1: timeout")
+		},
+		func(res BlockResult) error {
+			got = append(got, res)
+			return nil
+		})
+	require.NoError(t, err, "per-block errors are the callback's to handle")
+	require.Len(t, got, 2)
+	assert.NotNil(t, got[1].Error)
+}
+
+func TestStreamWindows_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := &windowRecorder{}
+	err := streamWindows(ctx, 0, 9, config.Throttle{}, rec.fetch, func(BlockResult) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, rec.windows)
+}
@@ -7,7 +7,6 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
@@ -18,7 +17,6 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/shopspring/decimal"
-	"golang.org/x/sync/semaphore"
 )
 
 // SuiIndexer implements the generic Indexer interface for the Sui blockchain,
@@ -28,6 +26,7 @@ type SuiIndexer struct {
 	cfg         config.ChainConfig
 	failover    *rpc.Failover[sui.SuiAPI]
 	pubkeyStore PubkeyStore
+	fetcher     blockFetcher
 }
 
 const suiMistPerSUI = 1_000_000_000
@@ -93,12 +92,13 @@ func isSuiSystemSender(addr string) bool {
 	return sameSuiAddress(addr, suiZeroAddress)
 }
 
-func makeSuiBaseTransaction(execTx *v2.ExecutedTransaction, networkID string, blockNumber, blockTs uint64) types.Transaction {
+func makeSuiBaseTransaction(execTx *v2.ExecutedTransaction, networkID, internalCode string, blockNumber, blockTs uint64) types.Transaction {
 	t := types.Transaction{
-		TxHash:      execTx.GetDigest(),
-		NetworkId:   networkID,
-		BlockNumber: blockNumber,
-		Timestamp:   blockTs,
+		TxHash:       execTx.GetDigest(),
+		NetworkId:    networkID,
+		InternalCode: internalCode,
+		BlockNumber:  blockNumber,
+		Timestamp:    blockTs,
 	}
 
 	if execTx.Transaction != nil {
@@ -561,8 +561,15 @@ func (s *SuiIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
 	return latest, err
 }
 
-// GetBlock fetches a single checkpoint and converts it into the generic Block type.
+// GetBlock fetches a single checkpoint through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (s *SuiIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	_, block, err := s.fetchScheduler().fetchOne(ctx, number)
+	return block, err
+}
+
+// fetchBlock fetches a single checkpoint and converts it into the generic Block type.
+func (s *SuiIndexer) fetchBlock(ctx context.Context, number uint64) (*types.Block, error) {
 	var cp *sui.Checkpoint
 	err := s.failover.ExecuteWithRetry(ctx, func(c sui.SuiAPI) error {
 		var err error
@@ -614,9 +621,8 @@ func (s *SuiIndexer) GetBlocks(
 	return s.GetBlocksByNumbers(ctx, nums)
 }
 
-// GetBlocksByNumbers fetches checkpoints by sequence numbers.
-//
-// Optimized with concurrent workers using a semaphore.
+// GetBlocksByNumbers fetches checkpoints by sequence numbers through the
+// indexer's fetch scheduler, by the priority ctx carries.
 func (s *SuiIndexer) GetBlocksByNumbers(
 	ctx context.Context,
 	blockNumbers []uint64,
@@ -624,7 +630,16 @@ func (s *SuiIndexer) GetBlocksByNumbers(
 	if len(blockNumbers) == 0 {
 		return nil, nil
 	}
+	results, err := fetchAll(ctx, s.fetchScheduler(), blockNumbers, NewError)
+	if err != nil {
+		return nil, err
+	}
+	return results, firstBlockError(results)
+}
 
+// fetchScheduler returns the pool every checkpoint fetch of the indexer
+// runs on, Throttle.Concurrency at a time.
+func (s *SuiIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
 	// Use configured concurrency when available, fallback to a safe default.
 	workers := s.cfg.Throttle.Concurrency
 	if workers <= 0 {
@@ -633,61 +648,12 @@ func (s *SuiIndexer) GetBlocksByNumbers(
 	if workers > 50 {
 		workers = 50
 	}
-	sem := semaphore.NewWeighted(int64(workers))
-
-	// Results map protected by mutex
-	resultsMap := make(map[uint64]BlockResult)
-	var mu sync.Mutex
-
-	var wg sync.WaitGroup
-
-	for _, num := range blockNumbers {
-		if err := sem.Acquire(ctx, 1); err != nil {
-			return nil, err
-		}
-		wg.Add(1)
-
-		go func(blockNum uint64) {
-			defer sem.Release(1)
-			defer wg.Done()
-
-			blk, err := s.GetBlock(ctx, blockNum)
-			res := BlockResult{Number: blockNum}
-
-			if err != nil {
-				res.Error = &Error{
-					ErrorType: ErrorTypeUnknown,
-					Message:   err.Error(),
-				}
-			} else {
-				res.Block = blk
-			}
-
-			mu.Lock()
-			resultsMap[blockNum] = res
-			mu.Unlock()
-		}(num)
-	}
-
-	wg.Wait()
-
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
-	}
-
-	// Convert map to slice in order of requested blockNumbers and surface first error.
-	results := make([]BlockResult, 0, len(blockNumbers))
-	var firstErr error
-	for _, num := range blockNumbers {
-		if res, ok := resultsMap[num]; ok {
-			results = append(results, res)
-			if firstErr == nil && res.Error != nil {
-				firstErr = fmt.Errorf("block %d: %s", res.Number, res.Error.Message)
-			}
-		}
-	}
+	return s.fetcher.scheduler(workers, s.fetchBlock)
+}
 
-	return results, firstErr
+// FetchQueueStats reports the indexer's checkpoint fetches by priority.
+func (s *SuiIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return s.fetchScheduler().stats()
 }
 
 // IsHealthy does a quick gRPC health check by asking for the latest checkpoint.
@@ -698,6 +664,21 @@ func (s *SuiIndexer) IsHealthy() bool {
 	return err == nil
 }
 
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (s *SuiIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return s.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (s *SuiIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	s.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (s *SuiIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return s.failover.ExpectNetwork(ctx, want)
+}
+
 // convertCheckpoint maps a Sui checkpoint into the generic Block representation.
 func (s *SuiIndexer) convertCheckpoint(cp *sui.Checkpoint) *types.Block {
 	// Sui timestamps are typically in milliseconds.
@@ -723,6 +704,7 @@ func (s *SuiIndexer) convertCheckpoint(cp *sui.Checkpoint) *types.Block {
 		ParentHash:   cp.PreviousDigest(),
 		Timestamp:    ts,
 		Transactions: txs,
+		InternalCode: s.cfg.InternalCode,
 	}
 }
 
@@ -735,7 +717,7 @@ func (s *SuiIndexer) convertTransaction(execTx *v2.ExecutedTransaction, blockNum
 }
 
 func (s *SuiIndexer) convertTransactions(execTx *v2.ExecutedTransaction, blockNumber, blockTs uint64) []types.Transaction {
-	base := makeSuiBaseTransaction(execTx, s.cfg.InternalCode, blockNumber, blockTs)
+	base := makeSuiBaseTransaction(execTx, s.cfg.NetworkId, s.cfg.InternalCode, blockNumber, blockTs)
 	if base.FromAddress == "" || isSuiSystemSender(base.FromAddress) {
 		return nil
 	}
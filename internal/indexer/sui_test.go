@@ -72,7 +72,7 @@ func TestConvertTransactionClassifiesNativeSuiTransfer(t *testing.T) {
 	t.Parallel()
 
 	s := &SuiIndexer{
-		cfg: config.ChainConfig{InternalCode: "sui_testnet"},
+		cfg: config.ChainConfig{NetworkId: "sui", InternalCode: "sui_testnet"},
 	}
 
 	from := "0xbd97a67763c8101771308f5a91311c2d826189cc471332d8a6cc5001c00946ee"
@@ -102,6 +102,8 @@ func TestConvertTransactionClassifiesNativeSuiTransfer(t *testing.T) {
 	require.Equal(t, "125000000", tx.Amount)
 	require.Equal(t, "0x2::sui::SUI", tx.AssetAddress)
 	require.Equal(t, to, tx.ToAddress)
+	require.Equal(t, "sui", tx.NetworkId)
+	require.Equal(t, "sui_testnet", tx.InternalCode)
 }
 
 func TestConvertTransactionClassifiesTokenTransfer(t *testing.T) {
@@ -13,7 +13,6 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
-	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"github.com/xssnick/tonutils-go/address"
@@ -35,6 +34,7 @@ const (
 )
 
 type TonIndexer struct {
+	chainLog
 	chainName        string
 	cfg              config.ChainConfig
 	client           tonrpc.TonAPI
@@ -53,6 +53,8 @@ type TonIndexer struct {
 	jettonOwners   map[string]string // wallet -> owner
 	// preloaded raw TON jetton-wallet addresses derived from (owner wallets x jetton masters)
 	trackedJettonWallets map[string]struct{}
+
+	fetcher blockFetcher
 }
 
 type tonShardScanRange struct {
@@ -100,6 +102,7 @@ func NewTonIndexer(
 	}
 
 	return &TonIndexer{
+		chainLog:             chainLog{ChainLogger(chainName, cfg)},
 		chainName:            chainName,
 		cfg:                  cfg,
 		client:               client,
@@ -149,7 +152,14 @@ func (t *TonIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
 	return uint64(master.SeqNo), nil
 }
 
+// GetBlock fetches the masterchain block at number through the indexer's
+// fetch scheduler, by the priority ctx carries.
 func (t *TonIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	_, block, err := t.fetchScheduler().fetchOne(ctx, number)
+	return block, err
+}
+
+func (t *TonIndexer) fetchBlock(ctx context.Context, number uint64) (*types.Block, error) {
 	if t.client == nil {
 		return nil, fmt.Errorf("ton client is not configured")
 	}
@@ -239,6 +249,7 @@ func (t *TonIndexer) GetBlock(ctx context.Context, number uint64) (*types.Block,
 		ParentHash:   masterParentHash(master),
 		Timestamp:    blockTS,
 		Transactions: allTxs,
+		InternalCode: t.cfg.InternalCode,
 	}, nil
 }
 
@@ -251,26 +262,15 @@ func (t *TonIndexer) GetBlocks(
 		return nil, fmt.Errorf("invalid range")
 	}
 
-	results := make([]BlockResult, 0, to-from+1)
-	var firstErr error
+	nums := make([]uint64, 0, to-from+1)
 	for n := from; n <= to; n++ {
-		block, err := t.GetBlock(ctx, n)
-		res := BlockResult{
-			Number: n,
-			Block:  block,
-		}
-		if err != nil {
-			res.Error = toBlockError(err)
-			if firstErr == nil {
-				firstErr = err
-			}
-		}
-		results = append(results, res)
+		nums = append(nums, n)
 	}
-
-	return results, firstErr
+	return t.GetBlocksByNumbers(ctx, nums)
 }
 
+// GetBlocksByNumbers fetches blockNumbers through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (t *TonIndexer) GetBlocksByNumbers(
 	ctx context.Context,
 	blockNumbers []uint64,
@@ -278,25 +278,23 @@ func (t *TonIndexer) GetBlocksByNumbers(
 	if len(blockNumbers) == 0 {
 		return nil, nil
 	}
-
-	results := make([]BlockResult, 0, len(blockNumbers))
-	var firstErr error
-	for _, n := range blockNumbers {
-		block, err := t.GetBlock(ctx, n)
-		res := BlockResult{
-			Number: n,
-			Block:  block,
-		}
-		if err != nil {
-			res.Error = toBlockError(err)
-			if firstErr == nil {
-				firstErr = err
-			}
-		}
-		results = append(results, res)
+	results, err := fetchAll(ctx, t.fetchScheduler(), blockNumbers, toBlockError)
+	if err != nil {
+		return nil, err
 	}
+	return results, firstBlockError(results)
+}
+
+// fetchScheduler returns the pool every block fetch of the indexer runs on.
+// Fetching a block advances the shard heads, so blocks are fetched one at
+// a time; shards are scanned in parallel within each.
+func (t *TonIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
+	return t.fetcher.scheduler(1, t.fetchBlock)
+}
 
-	return results, firstErr
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (t *TonIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return t.fetchScheduler().stats()
 }
 
 func (t *TonIndexer) IsHealthy() bool {
@@ -687,7 +685,7 @@ func (t *TonIndexer) fetchTransactions(
 				}
 
 				if err != nil && isSkippableTONGetTxError(err) {
-					logger.Warn(
+					t.logger().Warn(
 						"skip TON tx fetch due to liteserver resolve error",
 						"master_seqno", masterSeqno,
 						"shard_seqno", shard.SeqNo,
@@ -782,6 +780,7 @@ func (t *TonIndexer) parseMatchedTransactions(
 	base := types.Transaction{
 		TxHash:       encodeTONTxHash(tx.Hash),
 		NetworkId:    t.networkID(),
+		InternalCode: t.cfg.InternalCode,
 		FromAddress:  fromAddress,
 		ToAddress:    toAddress,
 		AssetAddress: "",
@@ -1067,6 +1066,9 @@ func toBlockError(err error) *Error {
 	}
 
 	msg := err.Error()
+	if errType := ErrorTypeOf(err); errType != ErrorTypeUnknown {
+		return &Error{ErrorType: errType, Message: msg}
+	}
 	if strings.Contains(strings.ToLower(msg), "not found") {
 		return &Error{
 			ErrorType: ErrorTypeBlockNotFound,
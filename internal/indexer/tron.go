@@ -2,7 +2,6 @@ package indexer
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -12,29 +11,40 @@ import (
 	"github.com/fystack/multichain-indexer/internal/rpc"
 	"github.com/fystack/multichain-indexer/internal/rpc/tron"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
-	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
-	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
-	"github.com/shopspring/decimal"
 )
 
+// tronMetaFeeBreakdown is the metadata key of a transfer's
+// tron.FeeBreakdown, set on the transfer carrying its transaction's fee.
+const tronMetaFeeBreakdown = "fee_breakdown"
+
 type TronIndexer struct {
+	chainLog
 	chainName   string
 	config      config.ChainConfig
 	failover    *rpc.Failover[tron.TronAPI]
 	pubkeyStore PubkeyStore
+	assets      *assetFilter // ChainConfig.Assets
+	fetcher     blockFetcher
 }
 
 func NewTronIndexer(chainName string, cfg config.ChainConfig, f *rpc.Failover[tron.TronAPI], pubkeyStore PubkeyStore) *TronIndexer {
 	return &TronIndexer{
+		chainLog:    chainLog{ChainLogger(chainName, cfg)},
 		chainName:   chainName,
 		config:      cfg,
 		failover:    f,
 		pubkeyStore: pubkeyStore,
+		assets:      newAssetFilter(enum.NetworkTypeTron, cfg.Assets),
 	}
 }
 
+// UpdateAssetFilter applies a reloaded ChainConfig.Assets.
+func (t *TronIndexer) UpdateAssetFilter(cfg config.AssetFilterConfig) {
+	t.assets.update(cfg)
+}
+
 func (t *TronIndexer) GetName() string                  { return strings.ToUpper(t.chainName) }
 func (t *TronIndexer) GetNetworkType() enum.NetworkType { return enum.NetworkTypeTron }
 func (t *TronIndexer) GetNetworkInternalCode() string {
@@ -68,7 +78,14 @@ func (t *TronIndexer) GetLatestBlockNumber(ctx context.Context) (uint64, error)
 	return latest, err
 }
 
+// GetBlock fetches the block at blockNumber through the indexer's fetch
+// scheduler, by the priority ctx carries.
 func (t *TronIndexer) GetBlock(ctx context.Context, blockNumber uint64) (*types.Block, error) {
+	_, block, err := t.fetchScheduler().fetchOne(ctx, blockNumber)
+	return block, err
+}
+
+func (t *TronIndexer) fetchBlock(ctx context.Context, blockNumber uint64) (*types.Block, error) {
 	var (
 		wg        sync.WaitGroup
 		tronBlock *tron.Block
@@ -119,6 +136,8 @@ func (t *TronIndexer) processBlock(
 		ParentHash:   tronBlock.BlockHeader.RawData.ParentHash,
 		Timestamp:    tron.ConvertTronTimestamp(tronBlock.BlockHeader.RawData.Timestamp),
 		Transactions: make([]types.Transaction, 0, len(tronBlock.Transactions)),
+		TxCount:      len(tronBlock.Transactions),
+		InternalCode: t.config.InternalCode,
 	}
 
 	// Index TxnInfo by ID
@@ -138,7 +157,7 @@ func (t *TronIndexer) processBlock(
 			continue
 		}
 		transfers := make([]types.Transaction, 0, len(ti.Log))
-		for _, log := range ti.Log {
+		for logIdx, log := range ti.Log {
 			parsed, err := log.ParseTRC20Transfers(
 				ti.ID,
 				networkId,
@@ -147,93 +166,81 @@ func (t *TronIndexer) processBlock(
 			)
 			if err == nil && len(parsed) > 0 {
 				for _, p := range parsed {
-					if !t.isMonitoredTransfer(p.FromAddress, p.ToAddress) {
+					if !t.assets.allows(p.AssetAddress) || !t.isMonitoredTransfer(p.FromAddress, p.ToAddress) {
 						continue
 					}
+					p.InternalCode = t.config.InternalCode
+					p.TransferIndex = fmt.Sprintf("log:%d", logIdx)
+					p.EnsureTransferID()
 					transfers = append(transfers, p)
 				}
 			}
 		}
 		if len(transfers) > 0 {
 			// Assign fee to first transfer
-			transfers[0].TxFee = ti.TotalFeeTRX()
+			assignTronFee(&transfers[0], ti)
 			feeAssigned[ti.ID] = true
 			block.Transactions = append(block.Transactions, transfers...)
 		}
 	}
 
-	// Parse top-level contracts (TRX transfer, TRC10)
+	// Parse top-level contracts (TRX transfer, TRC10, call values)
 	for _, rawTx := range tronBlock.Transactions {
 		if !rawTx.IsSuccessful() {
-			logger.Debug("Skipping failed transaction", "txid", rawTx.TxID)
+			t.logger().Debug("Skipping failed transaction", "txid", rawTx.TxID)
 			continue
 		}
 
 		ts := tron.ConvertTronTimestamp(tronBlock.BlockHeader.RawData.Timestamp)
 		blkNum := uint64(tronBlock.BlockHeader.RawData.Number)
-		fee := decimal.Zero
-
-		if ti := infoByID[rawTx.TxID]; ti != nil {
+		ti := infoByID[rawTx.TxID]
+		if ti != nil {
 			ts = tron.ConvertTronTimestamp(ti.BlockTimestamp)
 			blkNum = uint64(ti.BlockNumber)
-			fee = ti.TotalFeeTRX()
 		}
 
-		for _, contract := range rawTx.RawData.Contract {
-			var tr *types.Transaction
-			var err error
-
-			switch contract.Type {
-			case tron.ContractTypeTransfer:
-				var transfer tron.TransferContract
-				if err = json.Unmarshal(contract.Parameter.Value, &transfer); err == nil {
-					tr = &types.Transaction{
-						TxHash:       rawTx.TxID,
-						NetworkId:    networkId,
-						BlockNumber:  blkNum,
-						FromAddress:  tron.HexToTronAddress(transfer.OwnerAddress),
-						ToAddress:    tron.HexToTronAddress(transfer.ToAddress),
-						AssetAddress: "",
-						Amount:       decimal.NewFromInt(transfer.Amount).String(),
-						Type:         constant.TxTypeNativeTransfer,
-						Timestamp:    ts,
-					}
-				}
-			case tron.ContractTypeTransferAsset:
-				var asset tron.TransferAssetContract
-				if err = json.Unmarshal(contract.Parameter.Value, &asset); err == nil {
-					tr = &types.Transaction{
-						TxHash:       rawTx.TxID,
-						NetworkId:    networkId,
-						BlockNumber:  blkNum,
-						FromAddress:  tron.HexToTronAddress(asset.OwnerAddress),
-						ToAddress:    tron.HexToTronAddress(asset.ToAddress),
-						AssetAddress: asset.AssetName,
-						Amount:       decimal.NewFromInt(asset.Amount).String(),
-						Type:         constant.TxTypeTokenTransfer,
-						Timestamp:    ts,
-					}
-				}
-			}
-			if tr == nil {
-				continue
-			}
-			if !t.isMonitoredTransfer(tr.FromAddress, tr.ToAddress) {
+		for contractIdx, contract := range rawTx.RawData.Contract {
+			parsed, err := contract.ParseTransfers(rawTx.TxID, networkId, blkNum, ts)
+			if err != nil {
+				t.logger().Debug("Skipping undecodable contract",
+					"txid", rawTx.TxID, "contract", contractIdx, "type", contract.Type, "error", err)
 				continue
 			}
+			for partIdx, tr := range parsed {
+				if !t.assets.allows(tr.AssetAddress) || !t.isMonitoredTransfer(tr.FromAddress, tr.ToAddress) {
+					continue
+				}
 
-			// Assign fee only if not already assigned
-			if !feeAssigned[rawTx.TxID] {
-				tr.TxFee = fee
-				feeAssigned[rawTx.TxID] = true
+				tr.InternalCode = t.config.InternalCode
+				tr.TransferIndex = fmt.Sprintf("contract:%d", contractIdx)
+				if partIdx > 0 {
+					// A smart contract call sending both TRX and a TRC-10 asset.
+					tr.TransferIndex += fmt.Sprintf(":%d", partIdx)
+				}
+				tr.EnsureTransferID()
+
+				// Assign fee only if not already assigned
+				if !feeAssigned[rawTx.TxID] {
+					assignTronFee(&tr, ti)
+					feeAssigned[rawTx.TxID] = true
+				}
+				block.Transactions = append(block.Transactions, tr)
 			}
-			block.Transactions = append(block.Transactions, *tr)
 		}
 	}
 
 	return block, nil
 }
 
+// assignTronFee puts the whole fee of a transaction, and its breakdown by
+// resource, on tr; a transaction's other transfers carry no fee.
+func assignTronFee(tr *types.Transaction, ti *tron.TxnInfo) {
+	tr.TxFee = ti.TotalFeeTRX()
+	if ti != nil {
+		tr.SetMetadata(tronMetaFeeBreakdown, ti.FeeBreakdown())
+	}
+}
+
 func (t *TronIndexer) GetBlocks(
 	ctx context.Context,
 	start, end uint64,
@@ -253,64 +260,28 @@ func (t *TronIndexer) GetBlocksByNumbers(
 	return t.getBlocks(ctx, nums)
 }
 
+// getBlocks fetches nums through the indexer's fetch scheduler, by the
+// priority ctx carries.
 func (t *TronIndexer) getBlocks(ctx context.Context, nums []uint64) ([]BlockResult, error) {
 	if len(nums) == 0 {
 		return nil, nil
 	}
-	blocks := make([]BlockResult, len(nums))
-
-	workers := len(nums)
-	workers = min(workers, t.config.Throttle.Concurrency)
-
-	type job struct {
-		num   uint64
-		index int
-	}
-
-	jobs := make(chan job, workers*2)
-	var wg sync.WaitGroup
-
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := range jobs {
-				blk, err := t.GetBlock(ctx, j.num)
-				blocks[j.index] = BlockResult{Number: j.num, Block: blk}
-				if err != nil {
-					blocks[j.index].Error = &Error{
-						ErrorType: ErrorTypeUnknown,
-						Message:   err.Error(),
-					}
-				}
-			}
-		}()
+	blocks, err := fetchAll(ctx, t.fetchScheduler(), nums, NewError)
+	if err != nil {
+		return nil, err
 	}
+	return blocks, firstBlockError(blocks)
+}
 
-	go func() {
-		defer close(jobs)
-		for i, num := range nums {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- job{num: num, index: i}:
-			}
-		}
-	}()
-
-	wg.Wait()
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
-	}
+// fetchScheduler returns the pool every block fetch of the indexer runs on,
+// Throttle.Concurrency at a time.
+func (t *TronIndexer) fetchScheduler() *fetchScheduler[uint64, *types.Block] {
+	return t.fetcher.scheduler(t.config.Throttle.Concurrency, t.fetchBlock)
+}
 
-	var firstErr error
-	for _, b := range blocks {
-		if b.Error != nil {
-			firstErr = fmt.Errorf("block %d: %s", b.Number, b.Error.Message)
-			break
-		}
-	}
-	return blocks, firstErr
+// FetchQueueStats reports the indexer's block fetches by priority.
+func (t *TronIndexer) FetchQueueStats() map[string]FetchQueueStats {
+	return t.fetchScheduler().stats()
 }
 
 func (t *TronIndexer) IsHealthy() bool {
@@ -319,3 +290,18 @@ func (t *TronIndexer) IsHealthy() bool {
 	_, err := t.GetLatestBlockNumber(ctx)
 	return err == nil
 }
+
+// ProviderStatuses reports the state of the chain's RPC nodes.
+func (t *TronIndexer) ProviderStatuses() []rpc.ProviderStatus {
+	return t.failover.ProviderStatuses()
+}
+
+// UseHealthStore persists the chain's RPC node health in store.
+func (t *TronIndexer) UseHealthStore(ctx context.Context, store rpc.HealthStore) {
+	t.failover.UseHealthStore(ctx, store)
+}
+
+// ExpectNetwork keeps the chain's RPC nodes not serving want out of use.
+func (t *TronIndexer) ExpectNetwork(ctx context.Context, want rpc.NetworkIdentity) error {
+	return t.failover.ExpectNetwork(ctx, want)
+}
@@ -0,0 +1,160 @@
+package indexer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/tron"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tronContract(t *testing.T, typ tron.ContractType, value any) tron.Contract {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	require.NoError(t, err)
+	return tron.Contract{Type: typ, Parameter: tron.ContractParam{Value: raw}}
+}
+
+func TestTronProcessBlock_MultiContractTransaction(t *testing.T) {
+	const (
+		owner    = "414c1029697ee358715d3a14a2add817c4b0165144"
+		to       = "41663ea1bfffe5038f3f0cf667f14c4257eff52d77"
+		contract = "41cc8321d6375c494d043fdd0260f21bc0ec51dacc"
+	)
+	block := &tron.Block{
+		BlockID: "blk",
+		BlockHeader: tron.BlockHeader{RawData: tron.BlockRawData{
+			Number: 61234567, Timestamp: 1714000000000,
+		}},
+		Transactions: []tron.Txn{{
+			TxID: "multi",
+			Ret:  []tron.TxnRet{{ContractRet: "SUCCESS"}},
+			RawData: tron.TxnRawData{Contract: []tron.Contract{
+				tronContract(t, tron.ContractTypeTransfer, tron.TransferContract{
+					OwnerAddress: owner, ToAddress: to, Amount: 2500000,
+				}),
+				tronContract(t, tron.ContractTypeTransferAsset, tron.TransferAssetContract{
+					OwnerAddress: owner, ToAddress: to, AssetName: "31303032303030", Amount: 700,
+				}),
+				tronContract(t, tron.ContractTypeTriggerSmartContract, tron.TriggerSmartContract{
+					OwnerAddress: owner, ContractAddress: contract,
+					CallValue: 1000000, CallTokenValue: 50, TokenID: 1002000,
+				}),
+			}},
+		}},
+	}
+	info := &tron.TxnInfo{
+		ID: "multi", Fee: 1100000, BlockNumber: 61234567, BlockTimestamp: 1714000000000,
+		Receipt: tron.Receipt{NetFee: 100000},
+	}
+
+	idx := &TronIndexer{chainName: "tron", config: config.ChainConfig{NetworkId: "tron_mainnet"}}
+	got, err := idx.processBlock(block, []*tron.TxnInfo{info})
+	require.NoError(t, err)
+	require.Len(t, got.Transactions, 4)
+
+	var indexes []string
+	for _, tr := range got.Transactions {
+		indexes = append(indexes, tr.TransferIndex)
+	}
+	assert.Equal(t, []string{"contract:0", "contract:1", "contract:2", "contract:2:1"}, indexes)
+	assert.Equal(t, "1002000", got.Transactions[1].AssetAddress)
+
+	// The whole fee, with its breakdown, is on the first transfer only.
+	assert.Equal(t, "1.1", got.Transactions[0].TxFee.String())
+	breakdown, ok := got.Transactions[0].GetMetadata(tronMetaFeeBreakdown)
+	require.True(t, ok)
+	assert.Equal(t, "1", breakdown.(tron.FeeBreakdown).AccountCreation.String())
+	assert.Equal(t, "0.1", breakdown.(tron.FeeBreakdown).Bandwidth.String())
+	for _, tr := range got.Transactions[1:] {
+		assert.True(t, tr.TxFee.IsZero())
+		_, ok := tr.GetMetadata(tronMetaFeeBreakdown)
+		assert.False(t, ok)
+	}
+}
+
+func TestTronProcessBlock_FeeFollowsFirstMonitoredContract(t *testing.T) {
+	const (
+		owner   = "414c1029697ee358715d3a14a2add817c4b0165144"
+		other   = "41663ea1bfffe5038f3f0cf667f14c4257eff52d77"
+		watched = "41cc8321d6375c494d043fdd0260f21bc0ec51dacc"
+	)
+	block := &tron.Block{
+		BlockHeader: tron.BlockHeader{RawData: tron.BlockRawData{Number: 1}},
+		Transactions: []tron.Txn{{
+			TxID: "tx",
+			RawData: tron.TxnRawData{Contract: []tron.Contract{
+				tronContract(t, tron.ContractTypeTransfer, tron.TransferContract{
+					OwnerAddress: owner, ToAddress: other, Amount: 1,
+				}),
+				tronContract(t, tron.ContractTypeTransfer, tron.TransferContract{
+					OwnerAddress: owner, ToAddress: watched, Amount: 2,
+				}),
+			}},
+		}},
+	}
+	info := &tron.TxnInfo{ID: "tx", Fee: 345000, BlockNumber: 1, Receipt: tron.Receipt{NetFee: 345000}}
+
+	idx := &TronIndexer{
+		chainName: "tron",
+		config:    config.ChainConfig{NetworkId: "tron_mainnet"},
+		pubkeyStore: tronPubkeyStoreStub{
+			tron.HexToTronAddress(watched): true,
+		},
+	}
+	got, err := idx.processBlock(block, []*tron.TxnInfo{info})
+	require.NoError(t, err)
+	require.Len(t, got.Transactions, 1)
+	assert.Equal(t, "contract:1", got.Transactions[0].TransferIndex)
+	assert.Equal(t, "0.345", got.Transactions[0].TxFee.String())
+}
+
+func TestTronProcessBlock_AssetFilter(t *testing.T) {
+	const (
+		owner = "414c1029697ee358715d3a14a2add817c4b0165144"
+		to    = "41663ea1bfffe5038f3f0cf667f14c4257eff52d77"
+	)
+	block := &tron.Block{
+		BlockHeader: tron.BlockHeader{RawData: tron.BlockRawData{Number: 1}},
+		Transactions: []tron.Txn{{
+			TxID: "tx",
+			RawData: tron.TxnRawData{Contract: []tron.Contract{
+				tronContract(t, tron.ContractTypeTransfer, tron.TransferContract{
+					OwnerAddress: owner, ToAddress: to, Amount: 1,
+				}),
+				tronContract(t, tron.ContractTypeTransferAsset, tron.TransferAssetContract{
+					OwnerAddress: owner, ToAddress: to, AssetName: "31303032303030", Amount: 2,
+				}),
+			}},
+		}},
+	}
+	indexes := func(idx *TronIndexer) []string {
+		got, err := idx.processBlock(block, nil)
+		require.NoError(t, err)
+		var out []string
+		for _, tr := range got.Transactions {
+			out = append(out, tr.TransferIndex)
+		}
+		return out
+	}
+
+	idx := NewTronIndexer("tron", config.ChainConfig{
+		Assets: config.AssetFilterConfig{Allow: []string{"1002001"}},
+	}, nil, nil)
+	assert.Equal(t, []string{"contract:0"}, indexes(idx), "native transfers are always extracted")
+
+	idx.UpdateAssetFilter(config.AssetFilterConfig{Allow: []string{"1002000"}})
+	assert.Equal(t, []string{"contract:0", "contract:1"}, indexes(idx))
+
+	idx.UpdateAssetFilter(config.AssetFilterConfig{Deny: []string{"1002000"}})
+	assert.Equal(t, []string{"contract:0"}, indexes(idx))
+}
+
+type tronPubkeyStoreStub map[string]bool
+
+func (s tronPubkeyStoreStub) Exist(_ enum.NetworkType, address string) bool {
+	return s[address]
+}
@@ -1,24 +1,95 @@
 package indexer
 
-import "github.com/fystack/multichain-indexer/pkg/common/types"
+import (
+	"errors"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrBlockNotReady is returned when a block just past the observed tip is
+// not yet served by the node that handled the request. Callers should wait
+// for the next poll rather than retrying or recording a failure.
+var ErrBlockNotReady = errors.New("block not ready")
 
 type ErrorType string
 
 const (
 	ErrorTypeBlockUnmarshal ErrorType = "block_unmarshal"
 	ErrorTypeBlockNotFound  ErrorType = "block_not_found"
+	ErrorTypeBlockNotReady  ErrorType = "block_not_ready"
 	ErrorTypeBlockNil       ErrorType = "block_nil"
 	ErrorTypeTimeout        ErrorType = "timeout"
+	ErrorTypeRateLimited    ErrorType = "rate_limited"
+	ErrorTypeAuth           ErrorType = "auth"
+	ErrorTypeNodeBehind     ErrorType = "node_behind"
+	ErrorTypeBadData        ErrorType = "bad_data"
+	ErrorTypeOverBudget     ErrorType = "over_budget"
+	ErrorTypePanic          ErrorType = "panic"
+	ErrorTypeUnparseableTx  ErrorType = "unparseable_tx"
 	ErrorTypeUnknown        ErrorType = "unknown"
 )
 
 type Error struct {
 	ErrorType ErrorType
 	Message   string
+	Stack     string    // where a recovered panic was raised, see PanicError
+	Issues    []TxIssue // the transactions failing a block in strict mode
+}
+
+// NewError builds a block error whose type is derived from err's rpc class,
+// with the stack of a recovered panic or the issues of an
+// UnparseableTxError.
+func NewError(err error) *Error {
+	e := &Error{ErrorType: ErrorTypeOf(err), Message: err.Error()}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		e.Stack = panicErr.Stack
+	}
+	var unparseable *UnparseableTxError
+	if errors.As(err, &unparseable) {
+		e.Issues = unparseable.Issues
+	}
+	return e
+}
+
+// ErrorTypeOf maps ErrBlockNotReady, ErrNegativeValue, ErrBlockPanic,
+// ErrUnparseableTx and the rpc error classes (rpc.ErrNotFound, ...) to an
+// ErrorType.
+func ErrorTypeOf(err error) ErrorType {
+	switch {
+	case errors.Is(err, ErrBlockPanic):
+		return ErrorTypePanic
+	case errors.Is(err, ErrUnparseableTx):
+		return ErrorTypeUnparseableTx
+	case errors.Is(err, ErrNegativeValue):
+		return ErrorTypeBlockUnmarshal
+	case errors.Is(err, ErrBlockNotReady):
+		return ErrorTypeBlockNotReady
+	case errors.Is(err, rpc.ErrNotFound):
+		return ErrorTypeBlockNotFound
+	case errors.Is(err, rpc.ErrTimeout):
+		return ErrorTypeTimeout
+	case errors.Is(err, rpc.ErrRateLimited):
+		return ErrorTypeRateLimited
+	case errors.Is(err, rpc.ErrAuth):
+		return ErrorTypeAuth
+	case errors.Is(err, rpc.ErrNodeBehind):
+		return ErrorTypeNodeBehind
+	case errors.Is(err, rpc.ErrBadData):
+		return ErrorTypeBadData
+	case errors.Is(err, rpc.ErrOverBudget):
+		return ErrorTypeOverBudget
+	}
+	return ErrorTypeUnknown
 }
 
 type BlockResult struct {
 	Number uint64 // Block number for debug
 	Block  *types.Block
 	Error  *Error // Nil if OK
+	// Span traces the block from its fetch, when the indexer started one;
+	// whoever handles the result ends it.
+	Span trace.Span
 }
@@ -73,6 +73,15 @@ func (c *Client) GetLedgerInfo(ctx context.Context) (*LedgerInfo, error) {
 	return &info, nil
 }
 
+// ChainID returns the ledger's chain_id.
+func (c *Client) ChainID(ctx context.Context) (string, error) {
+	info, err := c.GetLedgerInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(info.ChainID, 10), nil
+}
+
 func (c *Client) GetLatestBlockHeight(ctx context.Context) (uint64, error) {
 	info, err := c.GetLedgerInfo(ctx)
 	if err != nil {
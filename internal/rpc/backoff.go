@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Retry defaults used when FailoverConfig leaves the fields unset.
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultInitialBackoff   = 500 * time.Millisecond
+	DefaultMaxBackoff       = 5 * time.Second
+)
+
+// clock abstracts time for retry backoff so tests can run without sleeping.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// retryPolicy is FailoverConfig's retry settings with defaults applied.
+type retryPolicy struct {
+	attempts int
+	initial  time.Duration
+	max      time.Duration
+}
+
+func (c FailoverConfig) retryPolicy() retryPolicy {
+	p := retryPolicy{attempts: c.MaxAttempts, initial: c.InitialBackoff, max: c.MaxBackoff}
+	if p.attempts <= 0 {
+		p.attempts = DefaultRetryMaxAttempts
+	}
+	if p.initial <= 0 {
+		p.initial = DefaultInitialBackoff
+	}
+	if p.max <= 0 {
+		p.max = DefaultMaxBackoff
+	}
+	p.max = max(p.max, p.initial)
+	return p
+}
+
+// backoff returns the wait before retry n (1-based) with full jitter: a
+// uniform draw from [0, min(max, initial*2^(n-1))], so concurrent callers
+// failing together do not retry together.
+func (p retryPolicy) backoff(n int, randN func(int64) int64) time.Duration {
+	ceiling := p.initial
+	for i := 1; i < n && ceiling < p.max; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, p.max)
+	return time.Duration(randN(int64(ceiling) + 1))
+}
+
+// sleep waits d on f's clock. It returns false without waiting when d
+// would end past ctx's deadline, and false as soon as ctx is done.
+func (f *Failover[T]) sleep(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && !f.clock.Now().Add(d).Before(deadline) {
+		return false
+	}
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-f.clock.After(d):
+		return true
+	}
+}
+
+var defaultRandN = rand.Int64N
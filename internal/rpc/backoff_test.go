@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock records requested waits and fires them immediately, advancing
+// Now by the waited duration.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// withFakeClock makes f's backoff deterministic: every draw is the ceiling.
+func withFakeClock(f *Failover[NetworkClient]) *fakeClock {
+	c := &fakeClock{now: time.Now()}
+	f.clock = c
+	f.randN = func(n int64) int64 { return n - 1 }
+	return c
+}
+
+// namedClient tells providers apart inside fn.
+type namedClient struct {
+	mockNetworkClient
+	name string
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := FailoverConfig{MaxAttempts: 6, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}.retryPolicy()
+	ceiling := func(n int64) int64 { return n - 1 }
+
+	var got []time.Duration
+	for n := 1; n <= 5; n++ {
+		got = append(got, p.backoff(n, ceiling))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, got)
+	assert.Zero(t, p.backoff(3, func(int64) int64 { return 0 }), "full jitter can draw zero")
+
+	def := FailoverConfig{}.retryPolicy()
+	assert.Equal(t, retryPolicy{attempts: DefaultRetryMaxAttempts, initial: DefaultInitialBackoff, max: DefaultMaxBackoff}, def)
+}
+
+func TestExecuteWithRetry_BacksOffOnSameProvider(t *testing.T) {
+	f, p := newTestFailover()
+	f.config.InitialBackoff = 100 * time.Millisecond
+	clk := withFakeClock(f)
+
+	calls := 0
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		calls++
+		if calls < 3 {
+			return errors.New("internal error")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, clk.waits)
+	assert.True(t, p.IsAvailable())
+}
+
+func TestExecuteWithRetry_RotatesWithoutWaiting(t *testing.T) {
+	f, a, b := newTwoProviderFailover(t)
+	a.Client = &namedClient{name: "a"}
+	b.Client = &namedClient{name: "b"}
+	clk := withFakeClock(f)
+
+	var used []string
+	err := f.ExecuteWithRetry(context.Background(), func(c NetworkClient) error {
+		name := c.(*namedClient).name
+		used = append(used, name)
+		if name == "a" {
+			return WithClass(ErrRateLimited, errors.New("429 too many requests"))
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, used)
+	assert.Empty(t, clk.waits, "a blacklisted node is replaced immediately")
+}
+
+func TestExecuteWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	f, _ := newTestFailover()
+	f.config.MaxAttempts = 4
+	clk := withFakeClock(f)
+
+	calls := 0
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		calls++
+		return errors.New("internal error")
+	})
+
+	require.ErrorContains(t, err, "failed after 4 attempts: ")
+	assert.Equal(t, 4, calls)
+	assert.Len(t, clk.waits, 3)
+}
+
+func TestExecuteWithRetry_StopsAtDeadline(t *testing.T) {
+	f, _ := newTestFailover()
+	f.config.InitialBackoff = time.Minute
+	f.config.MaxBackoff = time.Hour
+	clk := withFakeClock(f)
+
+	// The first backoff (1m) fits the budget, the second (2m) would not.
+	ctx, cancel := context.WithDeadline(context.Background(), clk.now.Add(150*time.Second))
+	defer cancel()
+
+	calls := 0
+	err := f.ExecuteWithRetry(ctx, func(NetworkClient) error {
+		calls++
+		return errors.New("internal error")
+	})
+
+	require.ErrorContains(t, err, "retry budget exhausted after 2 attempts")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []time.Duration{time.Minute}, clk.waits)
+}
+
+func TestExecuteWithRetry_CanceledContext(t *testing.T) {
+	f, _ := newTestFailover()
+	withFakeClock(f)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := f.ExecuteWithRetry(ctx, func(NetworkClient) error {
+		calls++
+		cancel()
+		return errors.New("internal error")
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
@@ -2,6 +2,7 @@ package bitcoin
 
 import (
 	"context"
+	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
 )
@@ -14,17 +15,35 @@ type BitcoinAPI interface {
 	GetBlockCount(ctx context.Context) (uint64, error)
 	GetBlockHash(ctx context.Context, height uint64) (string, error)
 	GetBlock(ctx context.Context, hash string, verbosity int) (*Block, error)
+	GetBlockHeader(ctx context.Context, hash string) (*BlockHeader, error)
 	GetBlockByHeight(ctx context.Context, height uint64, verbosity int) (*Block, error)
+	GetBlockTxids(ctx context.Context, hash string) (*Block, []string, error)
+	GetBlockTransactions(ctx context.Context, blockHash string, txids []string, concurrency int) ([]Transaction, error)
 
 	// Network info
 	GetBlockchainInfo(ctx context.Context) (*BlockchainInfo, error)
+	GetBlockStats(ctx context.Context, height uint64) (*BlockStats, error)
 
 	// Mempool operations
 	GetRawMempool(ctx context.Context, verbose bool) (interface{}, error)
 	GetRawTransaction(ctx context.Context, txid string, verbose bool) (*Transaction, error)
+	GetRawTransactionInBlock(ctx context.Context, txid, blockHash string) (*Transaction, error)
 	GetTransactionWithPrevouts(ctx context.Context, txid string) (*Transaction, error)
 	GetMempoolEntry(ctx context.Context, txid string) (*MempoolEntry, error)
 
 	// Batch operations
 	ResolvePrevouts(ctx context.Context, txs []*Transaction, concurrency int) error
+
+	// Heavy scans
+	ScanTxOutSet(ctx context.Context, descriptors []string) (*ScanTxOutSetResult, error)
+
+	// Capabilities
+	TxIndex() TxIndexStatus
+	ProbeTxIndex(ctx context.Context) (TxIndexStatus, error)
+
+	// Diagnostics
+	GetIndexInfo(ctx context.Context) (map[string]IndexInfo, error)
+	Uptime(ctx context.Context) (time.Duration, error)
+	GetNetTotals(ctx context.Context) (*NetTotals, error)
+	Diagnose(ctx context.Context) Diagnostics
 }
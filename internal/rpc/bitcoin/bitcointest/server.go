@@ -0,0 +1,186 @@
+package bitcointest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+)
+
+// Bitcoin Core's code for unknown blocks and transactions; served for calls
+// without a fixture so callers see the same error class as a real node.
+const rpcInvalidAddressOrKey = -5
+
+// Server is a fake Bitcoin Core JSON-RPC endpoint backed by a fixture
+// directory. It accepts single and batch requests.
+type Server struct {
+	*httptest.Server
+	dir string
+
+	mu       sync.Mutex
+	calls    map[string]int
+	requests []string
+	misses   []string
+	failures map[string]int
+}
+
+// NewServer starts a Server serving fixtures from dir. It is closed when the
+// test ends.
+func NewServer(t testing.TB, dir string) *Server {
+	t.Helper()
+	s := &Server{dir: dir, calls: make(map[string]int), failures: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Calls returns how many times method was requested.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Requests returns the fixture names of the requests served, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Misses returns the fixture names requested but not found in the directory.
+func (s *Server) Misses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.misses...)
+}
+
+// FailWith makes single (non-batch) requests for the fixture name answer with
+// an empty HTTP status response instead, e.g. to simulate a proxy rejecting
+// an oversized getblock.
+func (s *Server) FailWith(name string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[name] = status
+}
+
+type request struct {
+	ID     any    `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type response struct {
+	ID      any             `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpc.RPCError   `json:"error"`
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out any
+	var batch []request
+	if err := json.Unmarshal(body, &batch); err == nil {
+		resps := make([]response, len(batch))
+		for i, req := range batch {
+			resps[i] = s.serve(req)
+		}
+		out = resps
+	} else {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		status, fail := s.failures[FixtureName(req.Method, req.Params)]
+		if fail {
+			s.calls[req.Method]++
+		}
+		s.mu.Unlock()
+		if fail {
+			w.WriteHeader(status)
+			return
+		}
+		out = s.serve(req)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (s *Server) serve(req request) response {
+	resp := response{ID: req.ID, JSONRPC: "2.0", Result: json.RawMessage("null")}
+
+	s.mu.Lock()
+	s.calls[req.Method]++
+	s.requests = append(s.requests, FixtureName(req.Method, req.Params))
+	s.mu.Unlock()
+
+	fixture, err := ReadFixture(s.dir, req.Method, req.Params)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s.mu.Lock()
+		s.misses = append(s.misses, FixtureName(req.Method, req.Params))
+		s.mu.Unlock()
+		resp.Error = &rpc.RPCError{
+			Code:    rpcInvalidAddressOrKey,
+			Message: fmt.Sprintf("no fixture for %s", FixtureName(req.Method, req.Params)),
+		}
+	case err != nil:
+		resp.Error = &rpc.RPCError{Code: -32603, Message: err.Error()}
+	case fixture.Error != nil:
+		resp.Error = fixture.Error
+	default:
+		resp.Result = fixture.Result
+	}
+	return resp
+}
+
+// NewClient returns a BitcoinClient pointed at s.
+func NewClient(s *Server) *bitcoin.BitcoinClient {
+	return bitcoin.NewBitcoinClient(s.URL, nil, 10*time.Second, nil)
+}
+
+// NewFailover returns a failover with one provider per server, in order.
+// Background height probes are disabled so they don't consume fixtures.
+func NewFailover(t testing.TB, servers ...*Server) *rpc.Failover[bitcoin.BitcoinAPI] {
+	t.Helper()
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	for i, s := range servers {
+		addProvider(t, f, fmt.Sprintf("fixture-%d", i+1), s.URL, NewClient(s))
+	}
+	return f
+}
+
+func addProvider(t testing.TB, f *rpc.Failover[bitcoin.BitcoinAPI], name, url string, client *bitcoin.BitcoinClient) {
+	t.Helper()
+	err := f.AddProvider(&rpc.Provider{
+		Name:       name,
+		URL:        url,
+		Network:    rpc.NetworkBitcoin,
+		ClientType: rpc.ClientTypeRPC,
+		Client:     client,
+		State:      rpc.StateHealthy,
+	})
+	if err != nil {
+		t.Fatalf("add provider %s: %v", name, err)
+	}
+}
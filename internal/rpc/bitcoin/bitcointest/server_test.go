@@ -0,0 +1,85 @@
+package bitcointest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ServesFixtures(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, WriteFixture(dir, "getblockhash", []any{uint64(7)}, Fixture{Result: json.RawMessage(`"hash7"`)}))
+	require.NoError(t, WriteFixture(dir, "getblockcount", nil, Fixture{Result: json.RawMessage(`10`)}))
+
+	srv := NewServer(t, dir)
+	client := NewClient(srv)
+
+	hash, err := client.GetBlockHash(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "hash7", hash)
+
+	count, err := client.GetBlockCount(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), count)
+	assert.Equal(t, 1, srv.Calls("getblockhash"))
+	assert.Empty(t, srv.Misses())
+}
+
+func TestServer_Batch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, WriteFixture(dir, "getblockhash", []any{1}, Fixture{Result: json.RawMessage(`"h1"`)}))
+
+	srv := NewServer(t, dir)
+	resps, err := NewClient(srv).DoBatch(context.Background(), []*rpc.RPCRequest{
+		{ID: 1, JSONRPC: "2.0", Method: "getblockhash", Params: []any{1}},
+		{ID: 2, JSONRPC: "2.0", Method: "getblockhash", Params: []any{2}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resps, 2)
+	assert.JSONEq(t, `"h1"`, string(resps[0].Result))
+	require.NotNil(t, resps[1].Error)
+	assert.Equal(t, []string{"getblockhash-2.json"}, srv.Misses())
+}
+
+func TestServer_MissingFixtureIsNotFound(t *testing.T) {
+	srv := NewServer(t, t.TempDir())
+	_, err := NewClient(srv).GetRawTransaction(context.Background(), "deadbeef", true)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, rpc.ErrNotFound)
+}
+
+func TestServer_RecordedError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, WriteFixture(dir, "getblockhash", []any{999}, Fixture{
+		Error: &rpc.RPCError{Code: -8, Message: "Block height out of range"},
+	}))
+
+	_, err := NewClient(NewServer(t, dir)).GetBlockHash(context.Background(), 999)
+	var rpcErr *rpc.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, -8, rpcErr.Code)
+}
+
+func TestGetTransactionWithPrevouts_Partial(t *testing.T) {
+	dir := t.TempDir()
+	tx := `{"txid":"aa","vin":[{"txid":"p1","vout":0},{"txid":"p2","vout":1}],"vout":[{"value":0.5,"n":0}]}`
+	require.NoError(t, WriteFixture(dir, "getrawtransaction", []any{"aa", 2}, Fixture{Result: json.RawMessage(tx)}))
+	require.NoError(t, WriteFixture(dir, "getrawtransaction", []any{"p1", 2}, Fixture{
+		Result: json.RawMessage(`{"txid":"p1","vout":[{"value":0.7,"n":0}]}`),
+	}))
+
+	got, err := NewClient(NewServer(t, dir)).GetTransactionWithPrevouts(context.Background(), "aa")
+	var partial *bitcoin.PartialEnrichmentError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, 1, partial.Missing)
+	assert.Equal(t, 2, partial.Total)
+	require.NotNil(t, got, "resolved prevouts are kept")
+	require.NotNil(t, got.Vin[0].PrevOut)
+	assert.Equal(t, 0.7, got.Vin[0].PrevOut.Value)
+	assert.Nil(t, got.Vin[1].PrevOut)
+}
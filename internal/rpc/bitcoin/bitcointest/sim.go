@@ -0,0 +1,536 @@
+package bitcointest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+)
+
+// Core's error codes served by Sim.
+const (
+	rpcMethodNotFound   = -32601
+	rpcInvalidParameter = -8
+)
+
+// Sim amounts, in satoshis.
+const (
+	simSubsidy = 312_500_000
+	simFee     = 1_000
+	simChange  = 50_000
+)
+
+// SimConfig describes the synthetic chain a Sim serves.
+type SimConfig struct {
+	// Start is the height of the first block served; lower heights are out
+	// of range.
+	Start uint64
+	// Blocks is the initial chain length, at least 1.
+	Blocks int
+	// BlockTime is the spacing of block timestamps, 10 minutes if unset.
+	BlockTime time.Duration
+	// GenesisTime is the timestamp of block Start; a fixed date if unset.
+	GenesisTime time.Time
+	// Pay lists the addresses the generated transactions pay, in rotation.
+	Pay []string
+	// PaymentsPerBlock is the number of payment transactions per block
+	// besides the coinbase, len(Pay) if unset.
+	PaymentsPerBlock int
+	// Seed varies hashes and amounts between chains. Sims with the same
+	// config serve the same chain, like several nodes of one network.
+	Seed string
+}
+
+// Fault is a failure Sim can answer a request with.
+type Fault int
+
+const (
+	// FaultTimeout holds the request open until the client gives up.
+	FaultTimeout Fault = iota + 1
+	// FaultRateLimit answers HTTP 429.
+	FaultRateLimit
+	// FaultMalformed answers HTTP 200 with a truncated JSON body.
+	FaultMalformed
+)
+
+// Payment is a transfer to one of SimConfig.Pay, as the indexer should
+// emit it.
+type Payment struct {
+	TxID string
+	Vout uint32
+	From string
+	To   string
+	Sats int64
+}
+
+// Sim is a Bitcoin Core JSON-RPC endpoint serving a deterministic synthetic
+// chain with txindex enabled. Every block holds a coinbase and
+// PaymentsPerBlock payments whose inputs carry prevouts at getblock
+// verbosity 3, so no enrichment calls are needed unless a test forces the
+// per-transaction fallback. Tests grow the chain with Mine, replace part of
+// it with Reorg and inject failures per method with Fail.
+type Sim struct {
+	*httptest.Server
+	cfg  SimConfig
+	done chan struct{}
+
+	mu     sync.Mutex
+	tip    uint64
+	reorgs []uint64
+	faults map[string][]Fault
+	calls  map[string]int
+}
+
+// NewSim starts a Sim for cfg. It is closed when the test ends.
+func NewSim(t testing.TB, cfg SimConfig) *Sim {
+	t.Helper()
+	if cfg.Blocks <= 0 {
+		cfg.Blocks = 1
+	}
+	if cfg.BlockTime <= 0 {
+		cfg.BlockTime = 10 * time.Minute
+	}
+	if cfg.GenesisTime.IsZero() {
+		cfg.GenesisTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if cfg.PaymentsPerBlock <= 0 {
+		cfg.PaymentsPerBlock = len(cfg.Pay)
+	}
+	if len(cfg.Pay) == 0 {
+		cfg.PaymentsPerBlock = 0
+	}
+	if cfg.Seed == "" {
+		cfg.Seed = "sim"
+	}
+
+	s := &Sim{
+		cfg:    cfg,
+		done:   make(chan struct{}),
+		tip:    cfg.Start + uint64(cfg.Blocks) - 1,
+		faults: make(map[string][]Fault),
+		calls:  make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(func() {
+		close(s.done)
+		s.Close()
+	})
+	return s
+}
+
+// Tip returns the height of the best block.
+func (s *Sim) Tip() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tip
+}
+
+// Mine appends n blocks.
+func (s *Sim) Mine(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tip += uint64(n)
+}
+
+// Reorg replaces the blocks from height up with a competing branch of the
+// same length: their hashes, txids and amounts change, and the block below
+// height stays the parent.
+func (s *Sim) Reorg(height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reorgs = append(s.reorgs, height)
+}
+
+// Fail makes the next times requests calling method answer with fault. A
+// batch request fails as a whole when any of its calls does.
+func (s *Sim) Fail(method string, fault Fault, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range times {
+		s.faults[method] = append(s.faults[method], fault)
+	}
+}
+
+// Calls returns how many times method was requested, failed calls
+// included.
+func (s *Sim) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// BlockHash returns the hash of the block at height on the current branch.
+func (s *Sim) BlockHash(height uint64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blockHash(height)
+}
+
+// Payments returns the payments in the block at height on the current
+// branch, in block order.
+func (s *Sim) Payments(height uint64) []Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.inChain(height) {
+		return nil
+	}
+	var out []Payment
+	for _, tx := range s.block(height).Tx[1:] {
+		out = append(out, Payment{
+			TxID: tx.TxID,
+			Vout: 0,
+			From: tx.Vin[0].PrevOut.ScriptPubKey.Address,
+			To:   tx.Vout[0].ScriptPubKey.Address,
+			Sats: satoshis(tx.Vout[0].Value),
+		})
+	}
+	return out
+}
+
+// The chain is a pure function of the config, the reorg list and the
+// height; callers hold s.mu.
+
+func (s *Sim) inChain(height uint64) bool {
+	return height >= s.cfg.Start && height <= s.tip
+}
+
+// branch counts the reorgs at or below height, so a reorg changes every
+// block from its height up, including blocks mined after it.
+func (s *Sim) branch(height uint64) int {
+	n := 0
+	for _, h := range s.reorgs {
+		if h <= height {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Sim) digest(kind string, height uint64, idx int) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s/%s/%d/%d/%d", s.cfg.Seed, kind, s.branch(height), height, idx))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Sim) blockHash(height uint64) string {
+	return s.digest("block", height, 0)
+}
+
+func (s *Sim) heightOf(hash string) (uint64, bool) {
+	for h := s.cfg.Start; h <= s.tip; h++ {
+		if s.blockHash(h) == hash {
+			return h, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Sim) simAddress(kind string, height uint64, idx int) string {
+	return "sim1" + s.digest(kind, height, idx)[:38]
+}
+
+func (s *Sim) output(n uint32, sats int64, addr string) bitcoin.Output {
+	return bitcoin.Output{
+		Value: float64(sats) / 1e8,
+		N:     n,
+		ScriptPubKey: bitcoin.ScriptPubKey{
+			Hex:     "0014" + s.digest("script/"+addr, 0, 0)[:40],
+			Type:    "witness_v0_keyhash",
+			Address: addr,
+		},
+	}
+}
+
+// block builds the block at height with prevouts on every input.
+func (s *Sim) block(height uint64) *bitcoin.Block {
+	coinbaseAddr := s.simAddress("miner", height, 0)
+	txs := []bitcoin.Transaction{{
+		TxID:    s.digest("coinbase", height, 0),
+		Version: 2,
+		Vin:     []bitcoin.Input{{Sequence: 0xffffffff}},
+		Vout:    []bitcoin.Output{s.output(0, simSubsidy, coinbaseAddr)},
+	}}
+
+	for i := range s.cfg.PaymentsPerBlock {
+		// Amounts differ per height, index and branch, so a reorged
+		// payment is told apart by its amount as well as its txid.
+		sats := int64(10_000 * (1 + (int(height)*7+i*13+s.branch(height)*101)%1000))
+		from := s.simAddress("sender", height, i)
+		to := s.cfg.Pay[(int(height)+i)%len(s.cfg.Pay)]
+		prevout := s.output(0, sats+simChange+simFee, from)
+		txs = append(txs, bitcoin.Transaction{
+			TxID:    s.digest("tx", height, i),
+			Version: 2,
+			Vin: []bitcoin.Input{{
+				TxID:     s.digest("funding", height, i),
+				Sequence: 0xfffffffd,
+				PrevOut:  &prevout,
+			}},
+			Vout: []bitcoin.Output{
+				s.output(0, sats, to),
+				s.output(1, simChange, from),
+			},
+		})
+	}
+	for i := range txs {
+		txs[i].Hash = txs[i].TxID
+		txs[i].Size = 250
+		txs[i].VSize = 141
+	}
+
+	parentSum := sha256.Sum256([]byte(s.cfg.Seed + "/parent"))
+	parent := hex.EncodeToString(parentSum[:])
+	if height > s.cfg.Start {
+		parent = s.blockHash(height - 1)
+	}
+	return &bitcoin.Block{
+		Hash:              s.blockHash(height),
+		Height:            height,
+		PreviousBlockHash: parent,
+		Time:              uint64(s.cfg.GenesisTime.Add(time.Duration(height-s.cfg.Start) * s.cfg.BlockTime).Unix()),
+		Tx:                txs,
+		Confirmations:     int64(s.tip - height + 1),
+		Size:              80 + 250*len(txs),
+		Weight:            4 * (80 + 250*len(txs)),
+		Difficulty:        1,
+		NTx:               len(txs),
+	}
+}
+
+func (s *Sim) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reqs []request
+	batch := json.Unmarshal(body, &reqs) == nil
+	if !batch {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reqs = []request{req}
+	}
+
+	switch s.takeFault(reqs) {
+	case FaultTimeout:
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		}
+		return
+	case FaultRateLimit:
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	case FaultMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"result":{"hash":"`)
+		return
+	}
+
+	resps := make([]response, len(reqs))
+	for i, req := range reqs {
+		resps[i] = s.serve(req)
+	}
+	var out any = resps[0]
+	if batch {
+		out = resps
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// takeFault counts the calls of reqs and pops the first fault pending for
+// any of them.
+func (s *Sim) takeFault(reqs []request) Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fault Fault
+	for _, req := range reqs {
+		s.calls[req.Method]++
+		if pending := s.faults[req.Method]; fault == 0 && len(pending) > 0 {
+			fault = pending[0]
+			s.faults[req.Method] = pending[1:]
+		}
+	}
+	return fault
+}
+
+func (s *Sim) serve(req request) response {
+	resp := response{ID: req.ID, JSONRPC: "2.0", Result: json.RawMessage("null")}
+	result, rpcErr := s.call(req.Method, req.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &rpc.RPCError{Code: -32603, Message: err.Error()}
+		return resp
+	}
+	resp.Result = raw
+	return resp
+}
+
+func (s *Sim) call(method string, params []any) (any, *rpc.RPCError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch method {
+	case "getblockcount":
+		return s.tip, nil
+
+	case "getblockhash":
+		height, ok := paramUint(params, 0)
+		if !ok || !s.inChain(height) {
+			return nil, &rpc.RPCError{Code: rpcInvalidParameter, Message: "Block height out of range"}
+		}
+		return s.blockHash(height), nil
+
+	case "getblock":
+		hash, _ := paramString(params, 0)
+		height, ok := s.heightOf(hash)
+		if !ok {
+			return nil, &rpc.RPCError{Code: rpcInvalidAddressOrKey, Message: "Block not found"}
+		}
+		verbosity, ok := paramUint(params, 1)
+		if !ok {
+			verbosity = 1
+		}
+		block := s.block(height)
+		switch verbosity {
+		case 1:
+			txids := make([]string, len(block.Tx))
+			for i := range block.Tx {
+				txids[i] = block.Tx[i].TxID
+			}
+			return struct {
+				bitcoin.Block
+				Tx []string `json:"tx"`
+			}{*block, txids}, nil
+		case 2:
+			stripPrevouts(block.Tx)
+			return block, nil
+		case 3:
+			return block, nil
+		}
+		return nil, &rpc.RPCError{Code: rpcInvalidParameter, Message: fmt.Sprintf("verbosity %d not simulated", verbosity)}
+
+	case "getrawtransaction":
+		txid, _ := paramString(params, 0)
+		blockHash, inBlock := paramString(params, 2)
+		for h := s.cfg.Start; h <= s.tip; h++ {
+			if inBlock && s.blockHash(h) != blockHash {
+				continue
+			}
+			for _, tx := range s.block(h).Tx {
+				if tx.TxID == txid {
+					tx.BlockHash = s.blockHash(h)
+					return tx, nil
+				}
+			}
+		}
+		return nil, &rpc.RPCError{
+			Code:    rpcInvalidAddressOrKey,
+			Message: "No such mempool or blockchain transaction. Use gettransaction for wallet transactions.",
+		}
+
+	case "getblockstats":
+		height, ok := paramUint(params, 0)
+		if !ok || !s.inChain(height) {
+			return nil, &rpc.RPCError{Code: rpcInvalidParameter, Message: "Target block height after current tip"}
+		}
+		return bitcoin.BlockStats{
+			BlockHash: s.blockHash(height),
+			Height:    height,
+			Subsidy:   simSubsidy,
+			TotalFee:  int64(simFee * s.cfg.PaymentsPerBlock),
+		}, nil
+
+	case "getindexinfo":
+		return map[string]any{"txindex": map[string]any{"synced": true, "best_block_height": s.tip}}, nil
+
+	case "getblockchaininfo":
+		return bitcoin.BlockchainInfo{Chain: "regtest", Blocks: s.tip, Headers: s.tip, BestBlockHash: s.blockHash(s.tip)}, nil
+
+	case "getrawmempool":
+		if verbose, _ := paramBool(params, 0); verbose {
+			return map[string]bitcoin.MempoolEntry{}, nil
+		}
+		return []string{}, nil
+
+	case "getmempoolentry":
+		return nil, &rpc.RPCError{Code: rpcInvalidAddressOrKey, Message: "Transaction not in mempool"}
+	}
+	return nil, &rpc.RPCError{Code: rpcMethodNotFound, Message: "Method not found"}
+}
+
+func stripPrevouts(txs []bitcoin.Transaction) {
+	for i := range txs {
+		for j := range txs[i].Vin {
+			txs[i].Vin[j].PrevOut = nil
+		}
+	}
+}
+
+func satoshis(btc float64) int64 {
+	return int64(btc*1e8 + 0.5)
+}
+
+func paramUint(params []any, i int) (uint64, bool) {
+	if i >= len(params) {
+		return 0, false
+	}
+	v, ok := params[i].(float64)
+	return uint64(v), ok && v >= 0
+}
+
+func paramString(params []any, i int) (string, bool) {
+	if i >= len(params) {
+		return "", false
+	}
+	v, ok := params[i].(string)
+	return v, ok
+}
+
+func paramBool(params []any, i int) (bool, bool) {
+	if i >= len(params) {
+		return false, false
+	}
+	v, ok := params[i].(bool)
+	return v, ok
+}
+
+// NewSimClient returns a BitcoinClient pointed at s. Its timeout is short
+// so FaultTimeout costs little test time.
+func NewSimClient(s *Sim) *bitcoin.BitcoinClient {
+	return bitcoin.NewBitcoinClient(s.URL, nil, simClientTimeout, nil)
+}
+
+const simClientTimeout = 500 * time.Millisecond
+
+// NewSimFailover returns a failover with one provider per sim, in order,
+// with backoff shortened so retries don't slow tests down.
+func NewSimFailover(t testing.TB, sims ...*Sim) *rpc.Failover[bitcoin.BitcoinAPI] {
+	t.Helper()
+	cfg := rpc.DefaultFailoverConfig()
+	cfg.MaxBlockLag = 0
+	cfg.InitialBackoff = 10 * time.Millisecond
+	cfg.MaxBackoff = 50 * time.Millisecond
+	f := rpc.NewFailover[bitcoin.BitcoinAPI](&cfg)
+	for i, s := range sims {
+		addProvider(t, f, fmt.Sprintf("sim-%d", i+1), s.URL, NewSimClient(s))
+	}
+	return f
+}
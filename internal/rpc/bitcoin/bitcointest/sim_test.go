@@ -0,0 +1,93 @@
+package bitcointest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSim_ServesDeterministicChain(t *testing.T) {
+	genesis := time.Unix(1_700_000_000, 0)
+	cfg := SimConfig{Start: 100, Blocks: 3, BlockTime: time.Minute, GenesisTime: genesis, Pay: []string{"addr1", "addr2"}}
+	a, b := NewSim(t, cfg), NewSim(t, cfg)
+	ctx := context.Background()
+
+	block, err := NewSimClient(a).GetBlockByHeight(ctx, 101, 3)
+	require.NoError(t, err)
+	assert.Equal(t, a.BlockHash(101), block.Hash)
+	assert.Equal(t, a.BlockHash(100), block.PreviousBlockHash)
+	assert.Equal(t, uint64(genesis.Add(time.Minute).Unix()), block.Time)
+	assert.Equal(t, int64(2), block.Confirmations)
+	require.Len(t, block.Tx, 3)
+	assert.True(t, block.Tx[0].IsCoinbase())
+	require.NotNil(t, block.Tx[1].Vin[0].PrevOut)
+	assert.Equal(t, "1000", block.Tx[1].CalculateFee().Shift(8).String())
+
+	payments := a.Payments(101)
+	require.Len(t, payments, 2)
+	assert.Equal(t, []string{"addr2", "addr1"}, []string{payments[0].To, payments[1].To})
+	assert.Equal(t, payments, b.Payments(101), "same config, same chain")
+
+	other := NewSim(t, SimConfig{Start: 100, Blocks: 3, Seed: "other"})
+	assert.NotEqual(t, a.BlockHash(101), other.BlockHash(101))
+
+	_, err = NewSimClient(a).GetBlockHash(ctx, 103)
+	require.Error(t, err)
+	a.Mine(1)
+	hash, err := NewSimClient(a).GetBlockHash(ctx, 103)
+	require.NoError(t, err)
+	assert.Equal(t, a.BlockHash(103), hash)
+}
+
+func TestSim_Reorg(t *testing.T) {
+	sim := NewSim(t, SimConfig{Start: 10, Blocks: 5, Pay: []string{"addr1"}})
+	before := []string{sim.BlockHash(11), sim.BlockHash(12), sim.BlockHash(14)}
+	paid := sim.Payments(12)
+
+	sim.Reorg(12)
+
+	assert.Equal(t, before[0], sim.BlockHash(11))
+	assert.NotEqual(t, before[1], sim.BlockHash(12))
+	assert.NotEqual(t, before[2], sim.BlockHash(14))
+	assert.NotEqual(t, paid[0].TxID, sim.Payments(12)[0].TxID)
+	assert.NotEqual(t, paid[0].Sats, sim.Payments(12)[0].Sats)
+
+	block, err := NewSimClient(sim).GetBlockByHeight(context.Background(), 12, 2)
+	require.NoError(t, err)
+	assert.Equal(t, before[0], block.PreviousBlockHash)
+	assert.Nil(t, block.Tx[1].Vin[0].PrevOut, "verbosity 2 has no prevouts")
+
+	_, err = NewSimClient(sim).GetBlock(context.Background(), before[1], 1)
+	assert.ErrorIs(t, err, rpc.ErrNotFound, "the stale block is gone")
+}
+
+func TestSim_Faults(t *testing.T) {
+	sim := NewSim(t, SimConfig{Blocks: 2})
+	client := NewSimClient(sim)
+	ctx := context.Background()
+
+	sim.Fail("getblockcount", FaultRateLimit, 1)
+	sim.Fail("getblockcount", FaultMalformed, 1)
+	sim.Fail("getblockcount", FaultTimeout, 1)
+
+	_, err := client.GetBlockCount(ctx)
+	var httpErr *rpc.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, 429, httpErr.StatusCode)
+
+	_, err = client.GetBlockCount(ctx)
+	require.ErrorContains(t, err, "decode error")
+
+	_, err = client.GetBlockCount(ctx)
+	assert.True(t, errors.Is(err, rpc.ErrTimeout), "got %v", err)
+
+	count, err := client.GetBlockCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 4, sim.Calls("getblockcount"))
+}
@@ -0,0 +1,84 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// decodeBlock reads a getblock result from dec into b one transaction at a
+// time, so a verbose block is never held as raw JSON: at verbosity 2 and 3
+// the tx array is most of a response that can reach tens of megabytes. A
+// null result leaves b untouched.
+func decodeBlock(dec *json.Decoder, b *Block) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("block: expected object, got %v", tok)
+	}
+
+	// The header fields are few and small: collect them and decode them
+	// into b once the object ends.
+	header := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if key != "tx" {
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return fmt.Errorf("block %s: %w", key, err)
+			}
+			header[key] = v
+			continue
+		}
+		// Core writes nTx before tx, which sizes the slice up front.
+		var nTx int
+		if raw, ok := header["nTx"]; ok {
+			_ = json.Unmarshal(raw, &nTx)
+		}
+		if err := decodeBlockTxs(dec, b, nTx); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, b)
+}
+
+// maxBlockTxs bounds how many transactions a block can hold: the maximum
+// block weight over the weight of the smallest transaction, 60 bytes
+// without witness. It caps the capacity reserved from the nTx a node
+// reports, so a bad node cannot have a huge slice allocated up front.
+const maxBlockTxs = 4_000_000 / (60 * 4)
+
+func decodeBlockTxs(dec *json.Decoder, b *Block, nTx int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("block tx: expected array, got %v", tok)
+	}
+	b.Tx = make([]Transaction, 0, min(max(nTx, 0), maxBlockTxs))
+	for dec.More() {
+		b.Tx = append(b.Tx, Transaction{})
+		if err := dec.Decode(&b.Tx[len(b.Tx)-1]); err != nil {
+			return fmt.Errorf("block tx %d: %w", len(b.Tx)-1, err)
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
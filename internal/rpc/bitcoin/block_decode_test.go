@@ -0,0 +1,239 @@
+package bitcoin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/metrics"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeBlock returns a verbosity 3 block of n two-in, two-out SegWit
+// transactions; 10000 of them make a response of about 16MB.
+func largeBlock(n int) *bitcoin.Block {
+	block := &bitcoin.Block{
+		Hash:              fmt.Sprintf("%064x", n),
+		Height:            840000,
+		PreviousBlockHash: fmt.Sprintf("%064x", n-1),
+		Time:              1713571767,
+		Confirmations:     3,
+		Size:              n * 400,
+		Weight:            n * 1000,
+		NTx:               n,
+		Tx:                make([]bitcoin.Transaction, n),
+	}
+	for i := range block.Tx {
+		tx := &block.Tx[i]
+		tx.TxID = fmt.Sprintf("%064x", i)
+		tx.Hash = tx.TxID
+		tx.Size, tx.VSize, tx.Weight = 370, 208, 832
+		for j := range 2 {
+			out := bitcoin.Output{
+				Value: 0.0125,
+				N:     uint32(j),
+				ScriptPubKey: bitcoin.ScriptPubKey{
+					Hex:     "0014" + fmt.Sprintf("%040x", i*2+j),
+					Type:    "witness_v0_keyhash",
+					Address: fmt.Sprintf("bc1q%038x", i*2+j),
+				},
+			}
+			prevout := out
+			tx.Vin = append(tx.Vin, bitcoin.Input{
+				TxID:     fmt.Sprintf("%064x", i+n),
+				Vout:     uint32(j),
+				Sequence: 0xfffffffd,
+				Witness:  []string{fmt.Sprintf("%0144x", i), fmt.Sprintf("%066x", i)},
+				PrevOut:  &prevout,
+			})
+			tx.Vout = append(tx.Vout, out)
+		}
+	}
+	return block
+}
+
+// blockServer serves body as the response to any call, chunked without a
+// Content-Length when chunked is set.
+func blockServer(t testing.TB, body []byte, chunked bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if chunked {
+			// Writing in pieces with flushes keeps net/http from setting a
+			// Content-Length.
+			for rest := body; len(rest) > 0; {
+				n := min(len(rest), 1<<16)
+				_, _ = w.Write(rest[:n])
+				w.(http.Flusher).Flush()
+				rest = rest[n:]
+			}
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func rpcBody(t testing.TB, result any) []byte {
+	raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result, "error": nil})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestGetBlockStreamsLikeUnmarshal(t *testing.T) {
+	want := largeBlock(50)
+	body := rpcBody(t, want)
+	client := bitcoin.NewBitcoinClient(blockServer(t, body, true).URL, nil, 10*time.Second, nil)
+
+	got, err := client.GetBlock(context.Background(), want.Hash, 3)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// nTx only presizes the slice; the decode must not depend on it.
+	var noCount map[string]any
+	require.NoError(t, json.Unmarshal(rpcBody(t, want), &noCount))
+	delete(noCount["result"].(map[string]any), "nTx")
+	raw, err := json.Marshal(noCount)
+	require.NoError(t, err)
+	client = bitcoin.NewBitcoinClient(blockServer(t, raw, false).URL, nil, 10*time.Second, nil)
+	got, err = client.GetBlock(context.Background(), want.Hash, 3)
+	require.NoError(t, err)
+	assert.Len(t, got.Tx, 50)
+	assert.Equal(t, want.Tx[49], got.Tx[49])
+}
+
+func TestGetBlockRPCError(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"result":null,"error":{"code":-5,"message":"Block not found"}}`)
+	client := bitcoin.NewBitcoinClient(blockServer(t, body, false).URL, nil, 10*time.Second, nil)
+
+	_, err := client.GetBlock(context.Background(), "00", 3)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, rpc.ErrNotFound)
+}
+
+func TestGetBlockTruncated(t *testing.T) {
+	body := rpcBody(t, largeBlock(5))
+	client := bitcoin.NewBitcoinClient(blockServer(t, body[:len(body)/2], true).URL, nil, 10*time.Second, nil)
+
+	_, err := client.GetBlock(context.Background(), "00", 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected EOF")
+}
+
+func TestGetBlockMaxResponseSize(t *testing.T) {
+	body := rpcBody(t, largeBlock(20))
+	for _, chunked := range []bool{false, true} {
+		t.Run(fmt.Sprintf("chunked=%v", chunked), func(t *testing.T) {
+			client := bitcoin.NewBitcoinClient(blockServer(t, body, chunked).URL, nil, 10*time.Second, nil)
+
+			client.SetMaxResponseSize(int64(len(body)) - 1)
+			_, err := client.GetBlock(context.Background(), "00", 3)
+			require.ErrorIs(t, err, rpc.ErrResponseTooLarge)
+			assert.Contains(t, err.Error(), fmt.Sprintf("over the %d byte limit", len(body)-1))
+
+			client.SetMaxResponseSize(int64(len(body)))
+			got, err := client.GetBlock(context.Background(), "00", 3)
+			require.NoError(t, err)
+			assert.Len(t, got.Tx, 20)
+		})
+	}
+}
+
+// peakHeap runs fn and returns the highest live heap seen while it ran,
+// sampled every 100µs, over the heap before it started. It stands in for
+// the peak RSS a container's memory limit applies to.
+func peakHeap(fn func()) uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	read := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+
+	runtime.GC()
+	base := read()
+	var peak atomic.Uint64
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(100 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			if v := read(); v > peak.Load() {
+				peak.Store(v)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	fn()
+	close(done)
+	<-stopped
+	return max(peak.Load(), base) - base
+}
+
+// BenchmarkGetBlock compares reading a large verbose block whole, as every
+// other call is, with the streaming decode GetBlock uses. Besides time and
+// allocations it reports the peak heap above the baseline of one fetch.
+func BenchmarkGetBlock(b *testing.B) {
+	block := largeBlock(10000)
+	body := rpcBody(b, block)
+	srv := blockServer(b, body, true)
+	b.Logf("response: %.1f MB, %d transactions", float64(len(body))/(1<<20), len(block.Tx))
+
+	fetches := map[string]func(*bitcoin.BitcoinClient) (*bitcoin.Block, error){
+		"buffered": func(c *bitcoin.BitcoinClient) (*bitcoin.Block, error) {
+			resp, err := c.BaseClient.CallRPC(context.Background(), "getblock", []any{block.Hash, 3})
+			if err != nil {
+				return nil, err
+			}
+			var got bitcoin.Block
+			return &got, json.Unmarshal(resp.Result, &got)
+		},
+		"streaming": func(c *bitcoin.BitcoinClient) (*bitcoin.Block, error) {
+			return c.GetBlock(context.Background(), block.Hash, 3)
+		},
+	}
+	for _, name := range []string{"buffered", "streaming"} {
+		fetch := fetches[name]
+		b.Run(name, func(b *testing.B) {
+			client := bitcoin.NewBitcoinClient(srv.URL, nil, time.Minute, nil)
+			b.ReportAllocs()
+			var peak uint64
+			for range b.N {
+				var got *bitcoin.Block
+				p := peakHeap(func() {
+					var err error
+					if got, err = fetch(client); err != nil {
+						b.Fatal(err)
+					}
+				})
+				peak = max(peak, p)
+				runtime.KeepAlive(got)
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+func TestGetBlockHugeNTx(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"result":{"hash":"00ab","height":1,"nTx":1000000000000,"tx":[{"txid":"aa"}]}}`)
+	client := bitcoin.NewBitcoinClient(blockServer(t, body, false).URL, nil, 10*time.Second, nil)
+
+	block, err := client.GetBlock(context.Background(), "00ab", 3)
+	require.NoError(t, err, "a bogus nTx must not size the allocation")
+	require.Len(t, block.Tx, 1)
+	assert.Equal(t, "aa", block.Tx[0].TxID)
+}
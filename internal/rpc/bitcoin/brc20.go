@@ -0,0 +1,75 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// BRC-20 operations.
+const (
+	BRC20Deploy   = "deploy"
+	BRC20Mint     = "mint"
+	BRC20Transfer = "transfer"
+)
+
+// BRC20Op is a BRC-20 operation inscribed as JSON, e.g.
+// {"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}.
+type BRC20Op struct {
+	Op   string `json:"op"`
+	Tick string `json:"tick"` // lowercased, as ticks are case-insensitive
+	Amt  string `json:"amt,omitempty"`
+	Max  string `json:"max,omitempty"`
+	Lim  string `json:"lim,omitempty"`
+}
+
+// brc20Amount is a BRC-20 amount: a decimal string of up to 18 decimals,
+// with no sign or exponent.
+var brc20Amount = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,18})?$`)
+
+// ParseBRC20 decodes a BRC-20 operation from an inscription body. Every
+// field must be a string, the tick 4 or 5 bytes, and the amounts of a mint
+// or transfer (amt) and of a deploy (max, and lim if set) positive.
+// Whether an operation is valid against the token's state, e.g. a mint
+// within the deployed supply, is left to BRC-20 indexers.
+func ParseBRC20(body []byte) (*BRC20Op, bool) {
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	str := func(key string) (string, bool) {
+		v, ok := fields[key].(string)
+		return v, ok
+	}
+	if p, _ := str("p"); p != "brc-20" {
+		return nil, false
+	}
+	op, _ := str("op")
+	tick, _ := str("tick")
+	if n := len(tick); n < 4 || n > 5 {
+		return nil, false
+	}
+	parsed := &BRC20Op{Op: op, Tick: strings.ToLower(tick)}
+
+	amount := func(key string, required bool) (string, bool) {
+		v, present := fields[key]
+		if !present {
+			return "", !required
+		}
+		s, ok := v.(string)
+		return s, ok && brc20Amount.MatchString(s) && strings.Trim(s, "0.") != ""
+	}
+	var ok bool
+	switch op {
+	case BRC20Mint, BRC20Transfer:
+		parsed.Amt, ok = amount("amt", true)
+	case BRC20Deploy:
+		if parsed.Max, ok = amount("max", true); ok {
+			parsed.Lim, ok = amount("lim", false)
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,115 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+)
+
+// immutableMethods are the RPCs whose results ImmutableCache may keep:
+// once their block is buried under the rollback window they can no longer
+// change. getblock is left out, as whole blocks would crowd the cache.
+var immutableMethods = map[string]bool{
+	"getblockhash":      true,
+	"getblockheader":    true,
+	"getrawtransaction": true,
+}
+
+// ImmutableCache caches the results of immutableMethods once their block
+// has depth confirmations, judged by the confirmations a result carries or,
+// for getblockhash, by the height's distance from the tip. It is shared by
+// the clients of a chain's nodes, whose getblockcount and
+// getblockchaininfo results keep its tip.
+type ImmutableCache struct {
+	*rpc.ResponseCache
+	depth uint64
+	tip   atomic.Uint64
+}
+
+// NewImmutableCache returns a cache of up to size results, keeping those
+// with at least depth confirmations.
+func NewImmutableCache(size int, depth uint64) *ImmutableCache {
+	return &ImmutableCache{ResponseCache: rpc.NewResponseCache(size), depth: max(depth, 1)}
+}
+
+func (c *ImmutableCache) get(ctx context.Context, method string, params any) (*rpc.RPCResponse, bool) {
+	if !immutableMethods[method] {
+		return nil, false
+	}
+	return c.Get(ctx, method, params)
+}
+
+// observe tracks the tip from resp, and caches it if it is immutable.
+func (c *ImmutableCache) observe(ctx context.Context, method string, params any, resp *rpc.RPCResponse) {
+	if resp == nil || len(resp.Result) == 0 {
+		return
+	}
+	switch method {
+	case "getblockcount":
+		var height uint64
+		if json.Unmarshal(resp.Result, &height) == nil {
+			c.observeTip(height)
+		}
+		return
+	case "getblockchaininfo":
+		var info struct {
+			Blocks uint64 `json:"blocks"`
+		}
+		if json.Unmarshal(resp.Result, &info) == nil {
+			c.observeTip(info.Blocks)
+		}
+		return
+	}
+	if !immutableMethods[method] {
+		return
+	}
+	if height, ok := c.buriedHeight(method, params, resp.Result); ok {
+		c.Put(ctx, method, params, height, resp.Result)
+	}
+}
+
+func (c *ImmutableCache) observeTip(height uint64) {
+	for {
+		tip := c.tip.Load()
+		if height <= tip || c.tip.CompareAndSwap(tip, height) {
+			return
+		}
+	}
+}
+
+// buriedHeight returns the height of the block a result belongs to, and
+// whether that block has depth confirmations.
+func (c *ImmutableCache) buriedHeight(method string, params any, result json.RawMessage) (uint64, bool) {
+	tip := c.tip.Load()
+	if method == "getblockhash" {
+		args, _ := params.([]any)
+		if len(args) != 1 {
+			return 0, false
+		}
+		height, ok := args[0].(uint64)
+		return height, ok && tip >= height && tip-height+1 >= c.depth
+	}
+	var confirmed struct {
+		Confirmations int64   `json:"confirmations"` // -1 for a block off the main chain
+		Height        *uint64 `json:"height"`
+	}
+	if json.Unmarshal(result, &confirmed) != nil || confirmed.Confirmations < int64(c.depth) {
+		return 0, false
+	}
+	if confirmed.Height != nil {
+		return *confirmed.Height, true
+	}
+	// Transactions carry no height: derive it from the tip.
+	if tip == 0 || uint64(confirmed.Confirmations) > tip+1 {
+		return 0, false
+	}
+	return tip - uint64(confirmed.Confirmations) + 1, true
+}
+
+// UseCache makes the client serve immutable results from cache, see
+// ImmutableCache.
+func (c *BitcoinClient) UseCache(cache *ImmutableCache) {
+	c.cache = cache
+}
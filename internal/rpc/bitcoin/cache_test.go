@@ -0,0 +1,75 @@
+package bitcoin_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableCache(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := map[string]struct {
+		params []any
+		result string
+	}{
+		"getblockcount":            {nil, `200`},
+		"getblockhash":             {[]any{100}, `"deephash"`},
+		"getblockhash:tip":         {[]any{195}, `"tiphash"`},
+		"getrawtransaction":        {[]any{"deep", 2}, `{"txid":"deep","confirmations":50}`},
+		"getrawtransaction:recent": {[]any{"recent", 2}, `{"txid":"recent","confirmations":3}`},
+	}
+	for name, f := range fixtures {
+		method, _, _ := strings.Cut(name, ":")
+		require.NoError(t, bitcointest.WriteFixture(dir, method, f.params, bitcointest.Fixture{Result: json.RawMessage(f.result)}))
+	}
+	server := bitcointest.NewServer(t, dir)
+	client := bitcointest.NewClient(server)
+	cache := bitcoin.NewImmutableCache(100, 10)
+	client.UseCache(cache)
+	ctx := context.Background()
+
+	// Nothing is immutable before the tip is known.
+	_, err := client.GetBlockHash(ctx, 100)
+	require.NoError(t, err)
+	_, err = client.GetBlockCount(ctx)
+	require.NoError(t, err)
+
+	for range 2 {
+		hash, err := client.GetBlockHash(ctx, 100)
+		require.NoError(t, err)
+		assert.Equal(t, "deephash", hash)
+		_, err = client.GetBlockHash(ctx, 195)
+		require.NoError(t, err)
+		tx, err := client.GetRawTransaction(ctx, "deep", true)
+		require.NoError(t, err)
+		assert.Equal(t, "deep", tx.TxID)
+		_, err = client.GetRawTransaction(ctx, "recent", true)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, server.Calls("getblockhash")-2, "only the height within the rollback window is fetched again")
+	assert.Equal(t, 3, server.Calls("getrawtransaction"), "the transaction with 3 confirmations is fetched again")
+
+	_, err = client.GetBlockHash(rpc.WithFreshRead(ctx), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 5, server.Calls("getblockhash"), "fresh reads bypass the cache")
+
+	// The deep transaction is at height 151.
+	require.NoError(t, cache.InvalidateFrom(ctx, 151))
+	_, err = client.GetRawTransaction(ctx, "deep", true)
+	require.NoError(t, err)
+	assert.Equal(t, 4, server.Calls("getrawtransaction"), "rolled-back heights are fetched again")
+	_, err = client.GetBlockHash(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 5, server.Calls("getblockhash"))
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, 2, stats.Entries)
+}
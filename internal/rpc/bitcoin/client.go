@@ -3,21 +3,55 @@ package bitcoin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
 	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 )
 
 // DefaultPrevoutConcurrency is the default number of parallel prevout fetches
 // when no concurrency value is provided.
 const DefaultPrevoutConcurrency = 8
 
+// readOnlyMethods are the RPCs whose concurrent identical calls share one
+// request, e.g. several inputs of a block spending outputs of the same
+// transaction. Anything that changes node state must stay out of this list.
+var readOnlyMethods = []string{
+	"getblock",
+	"getblockchaininfo",
+	"getblockcount",
+	"getblockhash",
+	"getblockheader",
+	"getindexinfo",
+	"getmempoolentry",
+	"getnettotals",
+	"getrawmempool",
+	"getrawtransaction",
+	"uptime",
+}
+
 // BitcoinClient implements the BitcoinAPI interface
 type BitcoinClient struct {
 	*rpc.BaseClient
+
+	calls   *rpc.CallGroup
+	txIndex atomic.Int32 // TxIndexStatus
+
+	diagMu   sync.Mutex
+	lastDiag *Diagnostics
+
+	// cache, if set, serves results that can no longer change.
+	cache *ImmutableCache
+
+	// scanSlot holds the client's one running scantxoutset.
+	scanSlot chan struct{}
 }
 
 // NewBitcoinClient creates a new Bitcoin RPC client
@@ -36,9 +70,29 @@ func NewBitcoinClient(
 			timeout,
 			rateLimiter,
 		),
+		calls:    rpc.NewCallGroup(readOnlyMethods...),
+		scanSlot: make(chan struct{}, 1),
 	}
 }
 
+// CallRPC sends a JSON-RPC request, sharing it with identical concurrent
+// read-only calls; see rpc.CallGroup and rpc.WithFreshRead. With a cache,
+// immutable results are served from it; see ImmutableCache.
+func (c *BitcoinClient) CallRPC(ctx context.Context, method string, params any) (*rpc.RPCResponse, error) {
+	if c.cache != nil {
+		if resp, ok := c.cache.get(ctx, method, params); ok {
+			return resp, nil
+		}
+	}
+	resp, err := c.calls.Do(ctx, method, params, func(ctx context.Context) (*rpc.RPCResponse, error) {
+		return c.BaseClient.CallRPC(ctx, method, params)
+	})
+	if err == nil && c.cache != nil {
+		c.cache.observe(ctx, method, params, resp)
+	}
+	return resp, err
+}
+
 // GetBlockCount returns the current block count
 func (c *BitcoinClient) GetBlockCount(ctx context.Context) (uint64, error) {
 	resp, err := c.CallRPC(ctx, "getblockcount", nil)
@@ -54,7 +108,10 @@ func (c *BitcoinClient) GetBlockCount(ctx context.Context) (uint64, error) {
 }
 
 // GetBlockHash returns the block hash for a given height
-func (c *BitcoinClient) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+func (c *BitcoinClient) GetBlockHash(ctx context.Context, height uint64) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.getblockhash", attribute.Int64("block.number", int64(height)))
+	defer func() { tracing.End(span, err) }()
+
 	resp, err := c.CallRPC(ctx, "getblockhash", []any{height})
 	if err != nil {
 		return "", fmt.Errorf("getblockhash failed: %w", err)
@@ -67,22 +124,100 @@ func (c *BitcoinClient) GetBlockHash(ctx context.Context, height uint64) (string
 	return result, nil
 }
 
+// GetBlockHeader returns the header of the block with the given hash.
+func (c *BitcoinClient) GetBlockHeader(ctx context.Context, hash string) (_ *BlockHeader, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.getblockheader", attribute.String("block.hash", hash))
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.CallRPC(ctx, "getblockheader", []any{hash, true})
+	if err != nil {
+		return nil, fmt.Errorf("getblockheader failed for %s: %w", hash, err)
+	}
+
+	var header BlockHeader
+	if err := json.Unmarshal(resp.Result, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block header %s: %w", hash, err)
+	}
+	return &header, nil
+}
+
 // GetBlock returns a block by hash with specified verbosity
 // Verbosity levels:
-// 0: Returns hex-encoded block data
-// 1: Returns block with transaction IDs
-// 2: Returns block with full transaction details (recommended for indexing)
-func (c *BitcoinClient) GetBlock(ctx context.Context, hash string, verbosity int) (*Block, error) {
-	resp, err := c.CallRPC(ctx, "getblock", []any{hash, verbosity})
+// 2: Returns block with full transaction details
+// 3: Same as 2, with prevout data on every input (recommended for indexing)
+// Use GetBlockTxids for verbosity 1. The block is decoded as the response
+// streams in, so unlike other calls it is never shared between concurrent
+// callers, see rpc.CallGroup: sharing needs the whole response in memory.
+func (c *BitcoinClient) GetBlock(ctx context.Context, hash string, verbosity int) (_ *Block, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.getblock",
+		attribute.String("block.hash", hash), attribute.Int("verbosity", verbosity))
+	defer func() { tracing.End(span, err) }()
+
+	var result Block
+	err = c.CallRPCStream(ctx, "getblock", []any{hash, verbosity}, func(dec *json.Decoder) error {
+		return decodeBlock(dec, &result)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("getblock failed: %w", err)
 	}
+	return &result, nil
+}
 
-	var result Block
+// GetBlockTxids returns a block at verbosity 1: header fields plus the txids
+// in block order. The returned Block's Tx is empty.
+func (c *BitcoinClient) GetBlockTxids(ctx context.Context, hash string) (_ *Block, _ []string, err error) {
+	ctx, span := tracing.Start(ctx, "bitcoin.getblock", attribute.String("block.hash", hash), attribute.Int("verbosity", 1))
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := c.CallRPC(ctx, "getblock", []any{hash, 1})
+	if err != nil {
+		return nil, nil, fmt.Errorf("getblock failed: %w", err)
+	}
+
+	var result struct {
+		Block
+		Tx []string `json:"tx"`
+	}
 	if err := json.Unmarshal(resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal block: %w", err)
 	}
-	return &result, nil
+	return &result.Block, result.Tx, nil
+}
+
+// GetBlockTransactions fetches the given transactions of block blockHash with
+// getrawtransaction verbosity 2, at most concurrency at a time, and returns
+// them in txids order. Passing the block hash lets nodes without txindex
+// serve confirmed transactions. Any failed fetch fails the whole call.
+func (c *BitcoinClient) GetBlockTransactions(
+	ctx context.Context,
+	blockHash string,
+	txids []string,
+	concurrency int,
+) (_ []Transaction, err error) {
+	if concurrency <= 0 {
+		concurrency = DefaultPrevoutConcurrency
+	}
+	ctx, span := tracing.Start(ctx, "bitcoin.getblock_transactions",
+		attribute.String("block.hash", blockHash), attribute.Int("txs", len(txids)))
+	defer func() { tracing.End(span, err) }()
+
+	txs := make([]Transaction, len(txids))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(concurrency)
+	for i, txid := range txids {
+		eg.Go(func() error {
+			tx, err := c.GetRawTransactionInBlock(egCtx, txid, blockHash)
+			if err != nil {
+				return err
+			}
+			txs[i] = *tx
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return txs, nil
 }
 
 // GetBlockByHeight returns a block by height
@@ -120,6 +255,24 @@ func (c *BitcoinClient) GetBlockchainInfo(ctx context.Context) (*BlockchainInfo,
 	return &result, nil
 }
 
+// blockStatsFields are the stats GetBlockStats requests; naming them spares
+// the node computing the rest, e.g. feerate percentiles.
+var blockStatsFields = []string{"blockhash", "height", "subsidy", "totalfee"}
+
+// GetBlockStats returns the subsidy and total fee of the block at height.
+func (c *BitcoinClient) GetBlockStats(ctx context.Context, height uint64) (*BlockStats, error) {
+	resp, err := c.CallRPC(ctx, "getblockstats", []any{height, blockStatsFields})
+	if err != nil {
+		return nil, fmt.Errorf("getblockstats failed: %w", err)
+	}
+
+	var result BlockStats
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block stats: %w", err)
+	}
+	return &result, nil
+}
+
 // GetRawMempool returns all transaction IDs in the mempool
 // If verbose is false, returns []string of txids
 // If verbose is true, returns map[string]MempoolEntry with details
@@ -157,6 +310,7 @@ func (c *BitcoinClient) GetRawTransaction(ctx context.Context, txid string, verb
 
 	resp, err := c.CallRPC(ctx, "getrawtransaction", []interface{}{txid, verbosity})
 	if err != nil {
+		c.noteTxIndexError(err)
 		return nil, fmt.Errorf("getrawtransaction failed for %s: %w", txid, err)
 	}
 
@@ -173,8 +327,27 @@ func (c *BitcoinClient) GetRawTransaction(ctx context.Context, txid string, verb
 	return &tx, nil
 }
 
+// GetRawTransactionInBlock returns transaction txid of block blockHash with
+// getrawtransaction verbosity 2. Naming the block lets nodes without
+// txindex, including pruned ones still holding the block, serve it; prevouts
+// are included when the node has the block's undo data.
+func (c *BitcoinClient) GetRawTransactionInBlock(ctx context.Context, txid, blockHash string) (*Transaction, error) {
+	resp, err := c.CallRPC(ctx, "getrawtransaction", []any{txid, 2, blockHash})
+	if err != nil {
+		return nil, fmt.Errorf("getrawtransaction failed for %s in block %s: %w", txid, blockHash, err)
+	}
+
+	var tx Transaction
+	if err := json.Unmarshal(resp.Result, &tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction %s: %w", txid, err)
+	}
+	return &tx, nil
+}
+
 // GetTransactionWithPrevouts fetches a transaction and resolves prevout data for all inputs.
 // This is necessary because getblock verbosity=2 doesn't include prevout data.
+// On a *PartialEnrichmentError the transaction is still returned, carrying
+// the prevouts that did resolve.
 func (c *BitcoinClient) GetTransactionWithPrevouts(ctx context.Context, txid string) (*Transaction, error) {
 	tx, err := c.GetRawTransaction(ctx, txid, true)
 	if err != nil {
@@ -182,6 +355,10 @@ func (c *BitcoinClient) GetTransactionWithPrevouts(ctx context.Context, txid str
 	}
 
 	if err := c.ResolvePrevouts(ctx, []*Transaction{tx}, 4); err != nil {
+		var partial *PartialEnrichmentError
+		if errors.As(err, &partial) {
+			return tx, err
+		}
 		return nil, err
 	}
 	return tx, nil
@@ -190,6 +367,12 @@ func (c *BitcoinClient) GetTransactionWithPrevouts(ctx context.Context, txid str
 // ResolvePrevouts resolves prevout data for all inputs across multiple transactions
 // using parallel fetching with deduplication. This eliminates the N+1 problem where
 // each input would otherwise require a separate RPC call.
+//
+// Only inputs still lacking a prevout are looked up, so a call retried on
+// another node after a partial failure fetches just the remainder. Inputs
+// whose prevout could not be fetched are counted and reported as a
+// *PartialEnrichmentError after the resolved ones are assigned. Once ctx is
+// done no further fetches are started and ctx.Err() is returned.
 func (c *BitcoinClient) ResolvePrevouts(ctx context.Context, txs []*Transaction, concurrency int) error {
 	if concurrency <= 0 {
 		concurrency = DefaultPrevoutConcurrency
@@ -205,12 +388,8 @@ func (c *BitcoinClient) ResolvePrevouts(ctx context.Context, txs []*Transaction,
 		if tx.IsCoinbase() {
 			continue
 		}
-		// Skip if prevouts are already resolved
-		if len(tx.Vin) > 0 && tx.Vin[0].PrevOut != nil {
-			continue
-		}
 		for _, vin := range tx.Vin {
-			if vin.TxID != "" {
+			if vin.TxID != "" && vin.PrevOut == nil {
 				needed[vin.TxID] = struct{}{}
 			}
 		}
@@ -231,15 +410,24 @@ func (c *BitcoinClient) ResolvePrevouts(ctx context.Context, txs []*Transaction,
 
 	jobs := make(chan string, concurrency*2)
 	var wg sync.WaitGroup
+	var firstErr error
 
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for txid := range jobs {
+				if ctx.Err() != nil {
+					continue // drain without fetching
+				}
 				prevTx, err := c.GetRawTransaction(ctx, txid, true)
 				if err != nil {
-					continue // Skip unavailable prevouts
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
 				}
 				mu.Lock()
 				prevoutCache[txid] = prevTx
@@ -265,28 +453,32 @@ func (c *BitcoinClient) ResolvePrevouts(ctx context.Context, txs []*Transaction,
 	}
 
 	// Assign resolved prevouts back to inputs
+	partial := &PartialEnrichmentError{Err: firstErr}
 	for _, tx := range txs {
 		if tx.IsCoinbase() {
 			continue
 		}
-		if len(tx.Vin) > 0 && tx.Vin[0].PrevOut != nil {
-			continue
-		}
 		for i := range tx.Vin {
 			if tx.Vin[i].TxID == "" {
 				continue
 			}
-			prevTx, ok := prevoutCache[tx.Vin[i].TxID]
-			if !ok {
+			partial.Total++
+			if tx.Vin[i].PrevOut != nil {
 				continue
 			}
+			prevTx, ok := prevoutCache[tx.Vin[i].TxID]
 			voutIdx := tx.Vin[i].Vout
-			if int(voutIdx) < len(prevTx.Vout) {
-				tx.Vin[i].PrevOut = &prevTx.Vout[voutIdx]
+			if !ok || int(voutIdx) >= len(prevTx.Vout) {
+				partial.Missing++
+				continue
 			}
+			tx.Vin[i].PrevOut = &prevTx.Vout[voutIdx]
 		}
 	}
 
+	if partial.Missing > 0 {
+		return partial
+	}
 	return nil
 }
 
@@ -0,0 +1,209 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+)
+
+// DiagnoseInterval is the least time between two diagnostic runs against a
+// node; Diagnose answers calls in between with the last report.
+const DiagnoseInterval = 30 * time.Second
+
+// IndexInfo is the state of one of the node's optional indexes, as reported
+// by getindexinfo.
+type IndexInfo struct {
+	Synced          bool   `json:"synced"`
+	BestBlockHeight uint64 `json:"best_block_height"`
+}
+
+// NetTotals are the node's network traffic counters since it started.
+type NetTotals struct {
+	TotalBytesRecv uint64 `json:"totalbytesrecv"`
+	TotalBytesSent uint64 `json:"totalbytessent"`
+	TimeMillis     int64  `json:"timemillis"`
+}
+
+// Diagnostics is a report on a node's health, for troubleshooting it
+// without access to the host. A field is left empty when the call behind
+// it failed; Errors holds the failures by RPC method.
+type Diagnostics struct {
+	CheckedAt     time.Time             `json:"checked_at"`
+	TxIndex       string                `json:"txindex"`
+	Indexes       map[string]IndexInfo  `json:"indexes,omitempty"`
+	UptimeSeconds int64                 `json:"uptime_seconds,omitempty"`
+	NetTotals     *NetTotals            `json:"net_totals,omitempty"`
+	Warnings      Warnings              `json:"warnings,omitempty"`
+	Deployments   map[string]Deployment `json:"deployments,omitempty"`
+	Errors        map[string]string     `json:"errors,omitempty"`
+}
+
+// Warnings are the warnings a node reports, e.g. "Unknown new rules
+// activated (versionbit 28)". Core reports them as one string before 28.0
+// and as a list since; either decodes, an empty string to none.
+type Warnings []string
+
+func (w *Warnings) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*w = slices.DeleteFunc(list, func(s string) bool { return strings.TrimSpace(s) == "" })
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("warnings: %w", err)
+	}
+	*w = nil
+	if s = strings.TrimSpace(s); s != "" {
+		*w = Warnings{s}
+	}
+	return nil
+}
+
+// Deployment is the state of a softfork deployment, as reported by
+// getdeploymentinfo.
+type Deployment struct {
+	Type   string `json:"type"` // "buried" or "bip9"
+	Active bool   `json:"active"`
+	// Height is the activation height, set once known.
+	Height uint64      `json:"height,omitempty"`
+	BIP9   *BIP9Status `json:"bip9,omitempty"`
+}
+
+// BIP9Status is the signalling state of a version bits deployment.
+type BIP9Status struct {
+	Status string `json:"status"` // defined, started, locked_in, active or failed
+	Bit    *int   `json:"bit,omitempty"`
+	Since  uint64 `json:"since"`
+}
+
+// GetDeploymentInfo returns the state of the node's softfork deployments at
+// its tip, keyed by name, e.g. "taproot".
+func (c *BitcoinClient) GetDeploymentInfo(ctx context.Context) (map[string]Deployment, error) {
+	resp, err := c.CallRPC(ctx, "getdeploymentinfo", nil)
+	if err != nil {
+		return nil, fmt.Errorf("getdeploymentinfo failed: %w", err)
+	}
+
+	var result struct {
+		Deployments map[string]Deployment `json:"deployments"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deployment info: %w", err)
+	}
+	return result.Deployments, nil
+}
+
+// NodeWarnings returns the warnings getblockchaininfo reports, see
+// rpc.WarningReporter.
+func (c *BitcoinClient) NodeWarnings(ctx context.Context) ([]string, error) {
+	info, err := c.GetBlockchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return info.Warnings, nil
+}
+
+// GetIndexInfo returns the state of every optional index the node runs,
+// keyed by index name, e.g. "txindex".
+func (c *BitcoinClient) GetIndexInfo(ctx context.Context) (map[string]IndexInfo, error) {
+	resp, err := c.CallRPC(ctx, "getindexinfo", nil)
+	if err != nil {
+		return nil, fmt.Errorf("getindexinfo failed: %w", err)
+	}
+
+	var result map[string]IndexInfo
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index info: %w", err)
+	}
+	return result, nil
+}
+
+// Uptime returns how long the node has been running.
+func (c *BitcoinClient) Uptime(ctx context.Context) (time.Duration, error) {
+	resp, err := c.CallRPC(ctx, "uptime", nil)
+	if err != nil {
+		return 0, fmt.Errorf("uptime failed: %w", err)
+	}
+
+	var seconds int64
+	if err := json.Unmarshal(resp.Result, &seconds); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal uptime: %w", err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// GetNetTotals returns the node's network traffic counters.
+func (c *BitcoinClient) GetNetTotals(ctx context.Context) (*NetTotals, error) {
+	resp, err := c.CallRPC(ctx, "getnettotals", nil)
+	if err != nil {
+		return nil, fmt.Errorf("getnettotals failed: %w", err)
+	}
+
+	var result NetTotals
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal net totals: %w", err)
+	}
+	return &result, nil
+}
+
+// Diagnose reports the node's txindex sync status, uptime, traffic
+// counters, warnings and softfork deployments; the latter come from
+// getblockchaininfo on nodes older than Core 23.0. Calls that fail are
+// noted in the report, with node URLs redacted, rather than failing it.
+// Runs are at least DiagnoseInterval apart, so the endpoints serving
+// reports cannot be used to flood the node; callers within the interval,
+// or waiting on a run in progress, get the last report.
+func (c *BitcoinClient) Diagnose(ctx context.Context) Diagnostics {
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+	if c.lastDiag != nil && time.Since(c.lastDiag.CheckedAt) < DiagnoseInterval {
+		return *c.lastDiag
+	}
+
+	report := Diagnostics{CheckedAt: time.Now().UTC(), TxIndex: c.TxIndex().String()}
+	fail := func(method string, err error) {
+		if report.Errors == nil {
+			report.Errors = make(map[string]string)
+		}
+		report.Errors[method] = rpc.RedactURLs(err.Error())
+	}
+
+	if indexes, err := c.GetIndexInfo(ctx); err != nil {
+		fail("getindexinfo", err)
+	} else {
+		report.Indexes = indexes
+		report.TxIndex = txIndexStatus(indexes).String()
+	}
+	if uptime, err := c.Uptime(ctx); err != nil {
+		fail("uptime", err)
+	} else {
+		report.UptimeSeconds = int64(uptime / time.Second)
+	}
+	if totals, err := c.GetNetTotals(ctx); err != nil {
+		fail("getnettotals", err)
+	} else {
+		report.NetTotals = totals
+	}
+	info, err := c.GetBlockchainInfo(ctx)
+	if err != nil {
+		fail("getblockchaininfo", err)
+	} else {
+		report.Warnings = info.Warnings
+	}
+	if deployments, err := c.GetDeploymentInfo(ctx); err == nil {
+		report.Deployments = deployments
+	} else if info != nil && len(info.Softforks) > 0 {
+		report.Deployments = info.Softforks
+	} else {
+		fail("getdeploymentinfo", err)
+	}
+
+	c.lastDiag = &report
+	return report
+}
@@ -0,0 +1,29 @@
+package bitcoin
+
+import "fmt"
+
+// PartialEnrichmentError reports that some transaction inputs were left
+// without prevout data. The transactions keep every prevout that did
+// resolve, so callers may choose to proceed with them. It deliberately does
+// not unwrap to Err: a not-found prevout must not read as a missing block.
+type PartialEnrichmentError struct {
+	Missing int   // inputs still lacking a prevout
+	Total   int   // inputs examined
+	Err     error // first fetch error seen, for the message only
+}
+
+func (e *PartialEnrichmentError) Error() string {
+	msg := fmt.Sprintf("prevout enrichment incomplete: %d of %d inputs unresolved", e.Missing, e.Total)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Ratio returns the fraction of inputs left unresolved.
+func (e *PartialEnrichmentError) Ratio() float64 {
+	if e.Total == 0 {
+		return 0
+	}
+	return float64(e.Missing) / float64(e.Total)
+}
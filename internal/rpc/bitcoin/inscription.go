@@ -0,0 +1,133 @@
+package bitcoin
+
+import (
+	"bytes"
+	"mime"
+)
+
+// Inscription is an ordinals inscription revealed by a taproot script-path
+// spend, in the envelope "OP_FALSE OP_IF "ord" <fields> OP_0 <body>
+// OP_ENDIF" of its tapscript.
+type Inscription struct {
+	ContentType string `json:"content_type,omitempty"`
+	// Size is the body size in bytes.
+	Size int `json:"size"`
+	// Body is set for text/plain and application/json bodies of at most
+	// the size limit given to ParseInscription.
+	Body []byte `json:"-"`
+}
+
+// inscriptionProtocolID is the first push of an inscription envelope.
+var inscriptionProtocolID = []byte("ord")
+
+// inscriptionTagContentType is the envelope field tag of the content type;
+// an empty tag starts the body.
+const inscriptionTagContentType = 1
+
+// taproot annex and tapscript leaf markers (BIP-341, BIP-342).
+const (
+	annexTag          = 0x50
+	tapscriptLeafMask = 0xfe
+	tapscriptLeaf     = 0xc0
+)
+
+// ParseInscription returns the first inscription revealed by vin, keeping
+// its body when it is text up to maxBody bytes. Anything unexpected in the
+// envelope means no inscription rather than an error.
+func ParseInscription(vin *Input, maxBody int) (*Inscription, bool) {
+	if vin == nil || len(vin.Witness) < 2 {
+		return nil, false
+	}
+	if vin.PrevOut != nil && vin.PrevOut.ScriptPubKey.Type != "witness_v1_taproot" {
+		return nil, false
+	}
+	witness := vin.Witness
+	if last := hexBytes(witness[len(witness)-1]); len(last) > 0 && last[0] == annexTag {
+		witness = witness[:len(witness)-1]
+	}
+	if len(witness) < 2 {
+		return nil, false
+	}
+	control := hexBytes(witness[len(witness)-1])
+	if len(control) == 0 || control[0]&tapscriptLeafMask != tapscriptLeaf {
+		return nil, false
+	}
+	ops, err := parseScript(hexBytes(witness[len(witness)-2]))
+	if err != nil {
+		return nil, false
+	}
+
+	for i := 0; i+2 < len(ops); i++ {
+		if ops[i].op != op0 || ops[i+1].op != opIf || !bytes.Equal(ops[i+2].data, inscriptionProtocolID) {
+			continue
+		}
+		if ins, ok := parseEnvelope(ops[i+3:], maxBody); ok {
+			return ins, true
+		}
+	}
+	return nil, false
+}
+
+// parseEnvelope reads the fields and body of an envelope up to OP_ENDIF.
+func parseEnvelope(ops []scriptOp, maxBody int) (*Inscription, bool) {
+	ins := &Inscription{}
+	var body [][]byte
+	inBody := false
+	for i := 0; i < len(ops); i++ {
+		o := ops[i]
+		if o.op == opEndIf {
+			if isTextContent(ins.ContentType) && ins.Size <= maxBody {
+				ins.Body = bytes.Join(body, nil)
+			}
+			return ins, true
+		}
+		data, ok := envelopePush(o)
+		if !ok {
+			return nil, false
+		}
+		if inBody {
+			ins.Size += len(data)
+			if ins.Size <= maxBody {
+				body = append(body, data)
+			}
+			continue
+		}
+		if len(data) == 0 {
+			inBody = true
+			continue
+		}
+		if i+1 >= len(ops) {
+			return nil, false
+		}
+		value, ok := envelopePush(ops[i+1])
+		if !ok {
+			return nil, false
+		}
+		i++
+		if len(data) == 1 && data[0] == inscriptionTagContentType && ins.ContentType == "" {
+			ins.ContentType = string(value)
+		}
+	}
+	return nil, false
+}
+
+// envelopePush returns the data an envelope op pushes, reading OP_1 through
+// OP_16 as the single byte they stand for, as ord does.
+func envelopePush(o scriptOp) ([]byte, bool) {
+	switch {
+	case o.op == op0:
+		return nil, true
+	case o.op >= op1 && o.op <= op16:
+		return []byte{o.op - op1 + 1}, true
+	case o.op <= opPushData4:
+		return o.data, true
+	}
+	return nil, false
+}
+
+// isTextContent reports whether contentType is text/plain or
+// application/json, whatever its parameters.
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/plain" || mediaType == "application/json")
+}
@@ -0,0 +1,134 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pushHex encodes a minimal push of data.
+func pushHex(data []byte) string {
+	switch n := len(data); {
+	case n < opPushData1:
+		return hex.EncodeToString([]byte{byte(n)}) + hex.EncodeToString(data)
+	case n <= 0xff:
+		return hex.EncodeToString([]byte{opPushData1, byte(n)}) + hex.EncodeToString(data)
+	default:
+		return hex.EncodeToString([]byte{opPushData2, byte(n), byte(n >> 8)}) + hex.EncodeToString(data)
+	}
+}
+
+// revealInput builds the script-path spend revealing an inscription the way
+// ord does: "<key> OP_CHECKSIG OP_FALSE OP_IF "ord" 1 <type> 0 <body...>
+// OP_ENDIF". Bodies are pushed in 520-byte chunks. The signature is elided.
+func revealInput(contentType string, body []byte) *Input {
+	script := pushHex(make([]byte, 32)) + "ac" + "0063" + pushHex([]byte("ord")) +
+		"0101" + pushHex([]byte(contentType)) + "00"
+	for len(body) > 0 {
+		n := min(len(body), 520)
+		script += pushHex(body[:n])
+		body = body[n:]
+	}
+	script += "68"
+	return &Input{
+		TxID:    "commit",
+		Witness: []string{elidedSig, script, "c0" + strings.Repeat("00", 32)},
+		PrevOut: &Output{Value: 0.0001, ScriptPubKey: ScriptPubKey{Type: "witness_v1_taproot"}},
+	}
+}
+
+const brc20Mint = `{"p":"brc-20","op":"mint","tick":"ORDI","amt":"1000"}`
+
+func TestParseInscription(t *testing.T) {
+	ins, ok := ParseInscription(revealInput("text/plain;charset=utf-8", []byte(brc20Mint)), 4096)
+	require.True(t, ok)
+	assert.Equal(t, "text/plain;charset=utf-8", ins.ContentType)
+	assert.Equal(t, len(brc20Mint), ins.Size)
+	assert.Equal(t, brc20Mint, string(ins.Body))
+
+	// Bodies split over several pushes are joined.
+	long := []byte(strings.Repeat("a", 1200))
+	ins, ok = ParseInscription(revealInput("application/json", long), 4096)
+	require.True(t, ok)
+	assert.Equal(t, long, ins.Body)
+
+	// With an annex, the script and control block move up one item.
+	vin := revealInput("text/plain", []byte("hi"))
+	vin.Witness = append(vin.Witness, "50")
+	ins, ok = ParseInscription(vin, 4096)
+	require.True(t, ok)
+	assert.Equal(t, "hi", string(ins.Body))
+}
+
+func TestParseInscription_BodyKeptForSmallTextOnly(t *testing.T) {
+	ins, ok := ParseInscription(revealInput("text/plain", []byte(brc20Mint)), 10)
+	require.True(t, ok, "oversized bodies still yield the inscription")
+	assert.Equal(t, len(brc20Mint), ins.Size)
+	assert.Nil(t, ins.Body)
+
+	ins, ok = ParseInscription(revealInput("image/png", []byte{0x89, 'P', 'N', 'G'}), 4096)
+	require.True(t, ok)
+	assert.Equal(t, 4, ins.Size)
+	assert.Nil(t, ins.Body)
+}
+
+func TestParseInscription_None(t *testing.T) {
+	keyPath := &Input{
+		Witness: []string{strings.Repeat("11", 64)},
+		PrevOut: &Output{ScriptPubKey: ScriptPubKey{Type: "witness_v1_taproot"}},
+	}
+	unterminated := revealInput("text/plain", []byte("hi"))
+	unterminated.Witness[1] = strings.TrimSuffix(unterminated.Witness[1], "68")
+	notOrd := revealInput("text/plain", []byte("hi"))
+	notOrd.Witness[1] = strings.Replace(notOrd.Witness[1], pushHex([]byte("ord")), pushHex([]byte("xyz")), 1)
+	p2wsh := revealInput("text/plain", []byte("hi"))
+	p2wsh.PrevOut.ScriptPubKey.Type = "witness_v0_scripthash"
+
+	for name, vin := range map[string]*Input{
+		"key path spend": keyPath,
+		"no OP_ENDIF":    unterminated,
+		"other protocol": notOrd,
+		"not taproot":    p2wsh,
+		"no witness":     {TxID: "legacy"},
+		"nil":            nil,
+	} {
+		_, ok := ParseInscription(vin, 4096)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestParseBRC20(t *testing.T) {
+	op, ok := ParseBRC20([]byte(brc20Mint))
+	require.True(t, ok)
+	assert.Equal(t, &BRC20Op{Op: BRC20Mint, Tick: "ordi", Amt: "1000"}, op)
+
+	op, ok = ParseBRC20([]byte(`{"p":"brc-20","op":"transfer","tick":"sats","amt":"0.5"}`))
+	require.True(t, ok)
+	assert.Equal(t, &BRC20Op{Op: BRC20Transfer, Tick: "sats", Amt: "0.5"}, op)
+
+	op, ok = ParseBRC20([]byte(`{"p":"brc-20","op":"deploy","tick":"ordi","max":"21000000","lim":"1000"}`))
+	require.True(t, ok)
+	assert.Equal(t, &BRC20Op{Op: BRC20Deploy, Tick: "ordi", Max: "21000000", Lim: "1000"}, op)
+}
+
+func TestParseBRC20_Invalid(t *testing.T) {
+	for _, body := range []string{
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":1000}`,     // number, not string
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"0"}`,      // zero
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"-5"}`,     // signed
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1e3"}`,    // exponent
+		`{"p":"brc-20","op":"mint","tick":"ordi"}`,                // no amount
+		`{"p":"brc-20","op":"mint","tick":"abc","amt":"1"}`,       // short tick
+		`{"p":"brc-20","op":"burn","tick":"ordi","amt":"1"}`,      // unknown op
+		`{"p":"brc-21","op":"mint","tick":"ordi","amt":"1"}`,      // other protocol
+		`{"p":"brc-20","op":"deploy","tick":"ordi","lim":"1000"}`, // no max
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1"`,       // truncated
+		`hello`,
+	} {
+		_, ok := ParseBRC20([]byte(body))
+		assert.False(t, ok, body)
+	}
+}
@@ -0,0 +1,115 @@
+package bitcoin
+
+import "bytes"
+
+// Lightning channel heuristics. A channel is funded by a P2WSH output of a
+// 2-of-2 multisig script (BOLT-3), which only shows when the output is
+// spent, so fundings are guessed from the transaction shape and closes are
+// recognized by the revealed script. Taproot channels are not detected.
+
+// ChannelConfidence grades how likely a detection is right.
+type ChannelConfidence string
+
+const (
+	ConfidenceLow    ChannelConfidence = "low"
+	ConfidenceMedium ChannelConfidence = "medium"
+	ConfidenceHigh   ChannelConfidence = "high"
+)
+
+// ChannelCloseType is how a channel was closed.
+type ChannelCloseType string
+
+const (
+	// CloseCooperative is a closing transaction both parties signed.
+	CloseCooperative ChannelCloseType = "cooperative"
+	// CloseForce is a commitment transaction broadcast by one party.
+	CloseForce ChannelCloseType = "force"
+)
+
+// BOLT-3 commitment transactions spread the obscured commitment number
+// over the locktime and sequence, under these fixed upper bytes.
+const (
+	commitmentLocktimeTag = 0x20
+	commitmentSequenceTag = 0x80
+)
+
+// ChannelFundingOutputs returns the outputs of tx that may fund a channel,
+// by index, with a confidence. BOLT-2 requires funding transactions to
+// spend segwit inputs only, and funding outputs are P2WSH; a single P2WSH
+// output beside at most a change output is the typical open. The P2WSH
+// outputs of a channel close are its own, never fundings.
+func ChannelFundingOutputs(tx *Transaction) map[uint32]ChannelConfidence {
+	if tx == nil || tx.IsCoinbase() {
+		return nil
+	}
+	if _, ok := DetectChannelClose(tx); ok {
+		return nil
+	}
+	for _, vin := range tx.Vin {
+		if len(vin.Witness) == 0 {
+			return nil
+		}
+	}
+	var candidates []uint32
+	for _, vout := range tx.Vout {
+		if vout.ScriptPubKey.Type == "witness_v0_scripthash" && vout.Value > 0 {
+			candidates = append(candidates, vout.N)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	confidence := ConfidenceLow
+	if len(candidates) == 1 && len(tx.Vout) <= 2 {
+		confidence = ConfidenceMedium
+	}
+	found := make(map[uint32]ChannelConfidence, len(candidates))
+	for _, n := range candidates {
+		found[n] = confidence
+	}
+	return found
+}
+
+// ChannelClose is a transaction spending a channel funding output.
+type ChannelClose struct {
+	Vin        uint32
+	Type       ChannelCloseType
+	Confidence ChannelConfidence
+}
+
+// DetectChannelClose reports whether tx spends a channel funding output:
+// its only input reveals a 2-of-2 multisig witness script with the keys in
+// BOLT-3 order. A commitment transaction's locktime and sequence tags make
+// a force close all but certain; other 2-of-2 spends are taken as
+// cooperative closes, with the doubt that other multisig wallets leave.
+func DetectChannelClose(tx *Transaction) (ChannelClose, bool) {
+	if tx == nil || len(tx.Vin) != 1 || tx.IsCoinbase() {
+		return ChannelClose{}, false
+	}
+	vin := &tx.Vin[0]
+	if vin.PrevOut != nil && vin.PrevOut.ScriptPubKey.Type != "witness_v0_scripthash" {
+		return ChannelClose{}, false
+	}
+	// OP_0 (CHECKMULTISIG's extra pop), two signatures, the script.
+	if len(vin.Witness) != 4 || vin.Witness[0] != "" || !isFundingScript(hexBytes(vin.Witness[3])) {
+		return ChannelClose{}, false
+	}
+	if tx.LockTime>>24 == commitmentLocktimeTag && vin.Sequence>>24 == commitmentSequenceTag {
+		return ChannelClose{Type: CloseForce, Confidence: ConfidenceHigh}, true
+	}
+	return ChannelClose{Type: CloseCooperative, Confidence: ConfidenceMedium}, true
+}
+
+// isFundingScript matches "OP_2 <key1> <key2> OP_2 OP_CHECKMULTISIG" with
+// compressed keys, key1 sorting first.
+func isFundingScript(script []byte) bool {
+	ops, err := parseScript(script)
+	if err != nil || len(ops) != 5 {
+		return false
+	}
+	if ops[0].op != op2 || ops[3].op != op2 || ops[4].op != opCheckMultisig {
+		return false
+	}
+	key1, key2 := ops[1].data, ops[2].data
+	return len(key1) == 33 && len(key2) == 33 && bytes.Compare(key1, key2) < 0
+}
@@ -0,0 +1,96 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Funding keys of the BOLT-3 test vectors, in script order.
+const (
+	boltLocalFundingKey  = "023da092f6980e58d2c037173180e9a465476026ee50f96695963e8efe436f54eb"
+	boltRemoteFundingKey = "030e9f7b623d2ccc7c9bd44d66d5ce21ce504c0acf6385a132cec6d3c39fa711c1"
+	boltFundingScript    = "5221" + boltLocalFundingKey + "21" + boltRemoteFundingKey + "52ae"
+
+	// elidedSig stands in for signatures the detectors never read.
+	elidedSig = ""
+)
+
+func fundingSpend(locktime, sequence uint64) *Transaction {
+	return &Transaction{
+		TxID:     "close",
+		Version:  2,
+		LockTime: locktime,
+		Vin: []Input{{
+			TxID:     "funding",
+			Vout:     0,
+			Sequence: sequence,
+			Witness:  []string{"", elidedSig, elidedSig, boltFundingScript},
+			PrevOut:  &Output{Value: 0.1, ScriptPubKey: ScriptPubKey{Type: "witness_v0_scripthash"}},
+		}},
+		Vout: []Output{
+			{Value: 0.06, N: 0, ScriptPubKey: ScriptPubKey{Type: "witness_v0_keyhash"}},
+			{Value: 0.0399, N: 1, ScriptPubKey: ScriptPubKey{Type: "witness_v0_scripthash"}},
+		},
+	}
+}
+
+func TestDetectChannelClose(t *testing.T) {
+	c, ok := DetectChannelClose(fundingSpend(0, 0xffffffff))
+	assert.True(t, ok)
+	assert.Equal(t, ChannelClose{Vin: 0, Type: CloseCooperative, Confidence: ConfidenceMedium}, c)
+
+	// Commitment transaction: obscured commitment number 42 under the tags.
+	c, ok = DetectChannelClose(fundingSpend(0x2000002a, 0x80000000))
+	assert.True(t, ok)
+	assert.Equal(t, ChannelClose{Vin: 0, Type: CloseForce, Confidence: ConfidenceHigh}, c)
+}
+
+func TestDetectChannelClose_NotAClose(t *testing.T) {
+	unsorted := fundingSpend(0, 0xffffffff)
+	unsorted.Vin[0].Witness[3] = "5221" + boltRemoteFundingKey + "21" + boltLocalFundingKey + "52ae"
+
+	twoOfThree := fundingSpend(0, 0xffffffff)
+	twoOfThree.Vin[0].Witness[3] = "5221" + boltLocalFundingKey + "21" + boltRemoteFundingKey +
+		"21" + p2wpkhPubKey + "53ae"
+
+	wrapped := fundingSpend(0, 0xffffffff)
+	wrapped.Vin[0].PrevOut.ScriptPubKey.Type = "scripthash"
+
+	twoInputs := fundingSpend(0, 0xffffffff)
+	twoInputs.Vin = append(twoInputs.Vin, twoInputs.Vin[0])
+
+	for name, tx := range map[string]*Transaction{
+		"keys not in BOLT-3 order": unsorted,
+		"2-of-3":                   twoOfThree,
+		"not a P2WSH prevout":      wrapped,
+		"two inputs":               twoInputs,
+		"nil":                      nil,
+	} {
+		_, ok := DetectChannelClose(tx)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestChannelFundingOutputs(t *testing.T) {
+	segwitIn := Input{TxID: "prev", Witness: []string{p2wpkhSig, p2wpkhPubKey}}
+	p2wsh := func(n uint32) Output {
+		return Output{Value: 0.1, N: n, ScriptPubKey: ScriptPubKey{Type: "witness_v0_scripthash"}}
+	}
+	change := Output{Value: 0.5, N: 1, ScriptPubKey: ScriptPubKey{Type: "witness_v0_keyhash"}}
+
+	open := &Transaction{TxID: "open", Vin: []Input{segwitIn}, Vout: []Output{p2wsh(0), change}}
+	assert.Equal(t, map[uint32]ChannelConfidence{0: ConfidenceMedium}, ChannelFundingOutputs(open))
+
+	batch := &Transaction{TxID: "batch", Vin: []Input{segwitIn}, Vout: []Output{p2wsh(0), change, p2wsh(2)}}
+	assert.Equal(t, map[uint32]ChannelConfidence{0: ConfidenceLow, 2: ConfidenceLow}, ChannelFundingOutputs(batch))
+
+	legacyIn := &Transaction{TxID: "legacy", Vin: []Input{{TxID: "prev"}}, Vout: []Output{p2wsh(0)}}
+	assert.Empty(t, ChannelFundingOutputs(legacyIn), "fundings spend segwit inputs only")
+
+	noP2WSH := &Transaction{TxID: "plain", Vin: []Input{segwitIn}, Vout: []Output{change}}
+	assert.Empty(t, ChannelFundingOutputs(noP2WSH))
+
+	assert.Empty(t, ChannelFundingOutputs(fundingSpend(0x2000002a, 0x80000000)),
+		"a commitment's to_local output is not a funding")
+}
@@ -0,0 +1,209 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrBlockIntegrity is returned by VerifyBlock for a block whose body
+	// or hash does not match its header: the node serving it is faulty or
+	// compromised.
+	ErrBlockIntegrity = errors.New("block fails integrity check")
+
+	// ErrBlockUnverifiable is returned by VerifyBlock for a block lacking
+	// the header fields or transaction hashes to verify it.
+	ErrBlockUnverifiable = errors.New("block cannot be verified")
+)
+
+// witnessCommitmentPrefix starts the coinbase output committing to the
+// block's witness data (BIP-141): OP_RETURN, a 36-byte push and the
+// 0xaa21a9ed header.
+const witnessCommitmentPrefix = "6a24aa21a9ed"
+
+// VerifyBlock checks that b's transactions are the ones its header commits
+// to and that the header hashes to b.Hash: the merkle root recomputed from
+// the txids must equal MerkleRoot, without the duplicated-subtree mutation
+// (CVE-2012-2459) that lets a block list a transaction twice under the
+// same root, and the witness commitment, if any, must match the wtxids.
+// Without a commitment no transaction may carry witness data.
+func VerifyBlock(b *Block) error {
+	if b.MerkleRoot == "" || b.Bits == "" || len(b.Tx) == 0 {
+		return fmt.Errorf("%w: no header fields or transactions", ErrBlockUnverifiable)
+	}
+	if b.NTx > 0 && b.NTx != len(b.Tx) {
+		return fmt.Errorf("%w: %d transactions, header reports %d", ErrBlockIntegrity, len(b.Tx), b.NTx)
+	}
+
+	txids := make([][32]byte, len(b.Tx))
+	for i := range b.Tx {
+		h, err := internalHash(b.Tx[i].TxID)
+		if err != nil {
+			return fmt.Errorf("%w: tx %d: %w", ErrBlockUnverifiable, i, err)
+		}
+		txids[i] = h
+	}
+	root, mutated := merkleRoot(txids)
+	if mutated {
+		return fmt.Errorf("%w: merkle tree repeats a subtree", ErrBlockIntegrity)
+	}
+	if got := displayHash(root); !strings.EqualFold(got, b.MerkleRoot) {
+		return fmt.Errorf("%w: merkle root %s, header has %s", ErrBlockIntegrity, got, b.MerkleRoot)
+	}
+	// An unverifiable witness commitment still leaves the header to check.
+	witnessErr := verifyWitnessCommitment(b)
+	if witnessErr != nil && !errors.Is(witnessErr, ErrBlockUnverifiable) {
+		return witnessErr
+	}
+
+	hash, err := headerHash(b, root)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBlockUnverifiable, err)
+	}
+	if got := displayHash(hash); !strings.EqualFold(got, b.Hash) {
+		return fmt.Errorf("%w: header hashes to %s, not %s", ErrBlockIntegrity, got, b.Hash)
+	}
+	return witnessErr
+}
+
+// merkleRoot computes the merkle root of hashes as Bitcoin Core's
+// ComputeMerkleRoot does, reporting whether any level pairs two identical
+// hashes, which a duplicated odd last hash cannot be told apart from.
+func merkleRoot(hashes [][32]byte) (root [32]byte, mutated bool) {
+	level := append([][32]byte(nil), hashes...)
+	for len(level) > 1 {
+		for i := 0; i+1 < len(level); i += 2 {
+			if level[i] == level[i+1] {
+				mutated = true
+			}
+		}
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashPair(level[i][:], level[i+1][:]))
+		}
+		level = next
+	}
+	return level[0], mutated
+}
+
+// verifyWitnessCommitment checks the coinbase's witness commitment, the
+// last output starting with witnessCommitmentPrefix, against the merkle
+// root of the wtxids, the coinbase's counting as zero, and the witness
+// reserved value in the coinbase's witness. Transactions without a wtxid
+// are not checked. A coinbase without txinwitness, which some backends
+// leave out, makes the commitment unverifiable; a reserved value that is
+// not 32 bytes fails it.
+func verifyWitnessCommitment(b *Block) error {
+	coinbase := &b.Tx[0]
+	var commitment string
+	for _, out := range coinbase.Vout {
+		script := strings.ToLower(out.ScriptPubKey.Hex)
+		if strings.HasPrefix(script, witnessCommitmentPrefix) && len(script) >= 76 {
+			commitment = script[len(witnessCommitmentPrefix):76]
+		}
+	}
+
+	wtxids := make([][32]byte, len(b.Tx))
+	for i := 1; i < len(b.Tx); i++ {
+		tx := &b.Tx[i]
+		if tx.Hash == "" {
+			return nil
+		}
+		if commitment == "" {
+			if !strings.EqualFold(tx.Hash, tx.TxID) {
+				return fmt.Errorf("%w: tx %s has witness data without a witness commitment", ErrBlockIntegrity, tx.TxID)
+			}
+			continue
+		}
+		h, err := internalHash(tx.Hash)
+		if err != nil {
+			return fmt.Errorf("%w: tx %s wtxid: %w", ErrBlockUnverifiable, tx.TxID, err)
+		}
+		wtxids[i] = h
+	}
+	if commitment == "" {
+		return nil
+	}
+
+	if len(coinbase.Vin) != 1 || len(coinbase.Vin[0].Witness) == 0 {
+		// Some backends leave txinwitness out: the node is not at fault.
+		return fmt.Errorf("%w: coinbase txinwitness missing", ErrBlockUnverifiable)
+	}
+	var reserved []byte
+	if len(coinbase.Vin[0].Witness) == 1 {
+		reserved, _ = hex.DecodeString(coinbase.Vin[0].Witness[0])
+	}
+	if len(reserved) != 32 {
+		return fmt.Errorf("%w: coinbase lacks the witness reserved value", ErrBlockIntegrity)
+	}
+	root, _ := merkleRoot(wtxids)
+	want := hashPair(root[:], reserved)
+	if got := hex.EncodeToString(want[:]); got != commitment {
+		return fmt.Errorf("%w: witness commitment %s, wtxids commit to %s", ErrBlockIntegrity, commitment, got)
+	}
+	return nil
+}
+
+// headerHash serializes b's 80-byte header with merkle root root and
+// returns its hash.
+func headerHash(b *Block, root [32]byte) ([32]byte, error) {
+	var prev [32]byte
+	if b.PreviousBlockHash != "" { // empty for the genesis block
+		var err error
+		if prev, err = internalHash(b.PreviousBlockHash); err != nil {
+			return [32]byte{}, fmt.Errorf("previous block hash: %w", err)
+		}
+	}
+	bits, err := strconv.ParseUint(b.Bits, 16, 32)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("bits %q: %w", b.Bits, err)
+	}
+	if b.Time > 0xffffffff {
+		return [32]byte{}, fmt.Errorf("time %d out of range", b.Time)
+	}
+
+	var header bytes.Buffer
+	header.Grow(80)
+	_ = binary.Write(&header, binary.LittleEndian, b.Version)
+	header.Write(prev[:])
+	header.Write(root[:])
+	_ = binary.Write(&header, binary.LittleEndian, uint32(b.Time))
+	_ = binary.Write(&header, binary.LittleEndian, uint32(bits))
+	_ = binary.Write(&header, binary.LittleEndian, b.Nonce)
+	return [32]byte(doubleSHA256(header.Bytes())), nil
+}
+
+// hashPair returns the double SHA-256 of a followed by b.
+func hashPair(a, b []byte) [32]byte {
+	return [32]byte(doubleSHA256(append(append(make([]byte, 0, len(a)+len(b)), a...), b...)))
+}
+
+// internalHash decodes a hash in display order, as RPC results carry them,
+// into the byte order it is hashed in.
+func internalHash(display string) ([32]byte, error) {
+	var h [32]byte
+	raw, err := hex.DecodeString(display)
+	if err != nil || len(raw) != 32 {
+		return h, fmt.Errorf("invalid hash %q", display)
+	}
+	for i := range raw {
+		h[i] = raw[31-i]
+	}
+	return h, nil
+}
+
+func displayHash(h [32]byte) string {
+	var out [32]byte
+	for i := range h {
+		out[i] = h[31-i]
+	}
+	return hex.EncodeToString(out[:])
+}
@@ -0,0 +1,109 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Multisig is a parsed "<m> <key>... <n> OP_CHECKMULTISIG" script.
+type Multisig struct {
+	Required int
+	Keys     []string // hex public keys, in script order
+}
+
+// ParseMultisig parses script as a standard m-of-n multisig script.
+func ParseMultisig(script []byte) (Multisig, bool) {
+	ops, err := parseScript(script)
+	if err != nil || len(ops) < 4 || ops[len(ops)-1].op != opCheckMultisig {
+		return Multisig{}, false
+	}
+	m, okM := ops[0].number(1)
+	n, okN := ops[len(ops)-2].number(1)
+	keys := ops[1 : len(ops)-2]
+	if !okM || !okN || m < 1 || m > n || int(n) != len(keys) {
+		return Multisig{}, false
+	}
+	ms := Multisig{Required: int(m), Keys: make([]string, len(keys))}
+	for i, k := range keys {
+		if _, err := btcec.ParsePubKey(k.data); err != nil {
+			return Multisig{}, false
+		}
+		ms.Keys[i] = hex.EncodeToString(k.data)
+	}
+	return ms, true
+}
+
+// PaysToScript reports whether scriptPubKey, hex-encoded, pays to
+// redeemScript as P2SH, P2WSH or P2SH-wrapped P2WSH.
+func PaysToScript(scriptPubKey string, redeemScript []byte) bool {
+	spk := hexBytes(scriptPubKey)
+	if len(spk) == 0 || len(redeemScript) == 0 {
+		return false
+	}
+	scriptHash := sha256.Sum256(redeemScript)
+	p2wsh := witnessProgramScript(0, scriptHash[:])
+	return bytes.Equal(spk, scriptHashScript(redeemScript)) ||
+		bytes.Equal(spk, p2wsh) ||
+		bytes.Equal(spk, scriptHashScript(p2wsh))
+}
+
+// MultisigSigners returns the keys of multisig redeemScript that signed
+// input idx of tx, in script order, matching its signatures to the keys as
+// OP_CHECKMULTISIG does. ok is false when the input does not spend
+// redeemScript, or its signatures cannot be checked, e.g. a witness spend
+// whose prevout value is unknown.
+func MultisigSigners(tx *Transaction, idx int, redeemScript []byte) (signers []string, ok bool) {
+	ms, isMultisig := ParseMultisig(redeemScript)
+	if !isMultisig || idx < 0 || idx >= len(tx.Vin) {
+		return nil, false
+	}
+	vin := &tx.Vin[idx]
+
+	var sigs [][]byte
+	var sigHash func(hashType txscript.SigHashType) ([]byte, error)
+	if n := len(vin.Witness); n > 0 && bytes.Equal(hexBytes(vin.Witness[n-1]), redeemScript) {
+		for _, item := range vin.Witness[:n-1] {
+			sigs = append(sigs, hexBytes(item))
+		}
+		sigHash = func(hashType txscript.SigHashType) ([]byte, error) {
+			return sigHashWitnessV0(tx, idx, redeemScript, hashType)
+		}
+	} else {
+		ops, err := parseScript(hexBytes(vin.ScriptSig.Hex))
+		if err != nil || len(ops) == 0 || !bytes.Equal(ops[len(ops)-1].data, redeemScript) {
+			return nil, false
+		}
+		for _, o := range ops[:len(ops)-1] {
+			sigs = append(sigs, o.data)
+		}
+		sigHash = func(hashType txscript.SigHashType) ([]byte, error) {
+			return sigHashLegacy(tx, idx, redeemScript, hashType)
+		}
+	}
+
+	// OP_CHECKMULTISIG takes signatures in key order, each key once; the
+	// first item is the dummy element its off-by-one bug pops.
+	k := 0
+	for _, sig := range sigs {
+		if len(sig) < 2 {
+			continue // the dummy element or an empty placeholder
+		}
+		hash, err := sigHash(txscript.SigHashType(sig[len(sig)-1]))
+		if err != nil {
+			return nil, false
+		}
+		for k < len(ms.Keys) {
+			key := ms.Keys[k]
+			k++
+			if verifyECDSA(hexBytes(key), sig[:len(sig)-1], hash) {
+				signers = append(signers, key)
+				break
+			}
+		}
+	}
+	return signers, true
+}
@@ -0,0 +1,187 @@
+package bitcoin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reversedHex turns serialized txid bytes into the displayed txid.
+func reversedHex(t *testing.T, s string) string {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	slices.Reverse(b)
+	return hex.EncodeToString(b)
+}
+
+func TestSigHashWitnessV0_BIP143Vector(t *testing.T) {
+	// BIP-143 "Native P2WPKH" example, second input.
+	tx := &Transaction{
+		Version: 1,
+		Vin: []Input{
+			{TxID: reversedHex(t, "fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f"), Vout: 0, Sequence: 0xffffffee},
+			{TxID: reversedHex(t, "ef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a"), Vout: 1, Sequence: 0xffffffff,
+				PrevOut: &Output{Value: 6}},
+		},
+		Vout: []Output{
+			{Value: 1.1234, ScriptPubKey: ScriptPubKey{Hex: "76a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac"}},
+			{Value: 2.2345, N: 1, ScriptPubKey: ScriptPubKey{Hex: "76a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac"}},
+		},
+		LockTime: 17,
+	}
+	scriptCode := hexBytes("76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+	hash, err := sigHashWitnessV0(tx, 1, scriptCode, txscript.SigHashAll)
+	require.NoError(t, err)
+	assert.Equal(t, "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670", hex.EncodeToString(hash))
+
+	_, err = sigHashWitnessV0(tx, 0, scriptCode, txscript.SigHashAll)
+	assert.ErrorIs(t, err, errMissingPrevout)
+}
+
+// testKey is a private key to sign test spends with.
+type testKey struct {
+	priv *btcec.PrivateKey
+}
+
+func newTestKey(d uint32) testKey {
+	return testKey{btcec.PrivKeyFromScalar(new(btcec.ModNScalar).SetInt(d))}
+}
+
+func (k testKey) pub() []byte { return k.priv.PubKey().SerializeCompressed() }
+
+// sign returns a DER signature of hash with hashType appended.
+func (k testKey) sign(hash []byte, hashType txscript.SigHashType) []byte {
+	return append(ecdsa.Sign(k.priv, hash).Serialize(), byte(hashType))
+}
+
+// multisigScript builds an m-of-n multisig script over keys.
+func multisigScript(m int, keys ...testKey) []byte {
+	script := []byte{byte(op1 + m - 1)}
+	for _, k := range keys {
+		script = append(script, 33)
+		script = append(script, k.pub()...)
+	}
+	return append(script, byte(op1+len(keys)-1), opCheckMultisig)
+}
+
+func multisigSpendTx(prevOut *Output) *Transaction {
+	return &Transaction{
+		Version: 2,
+		Vin: []Input{{
+			TxID:     "11" + hex.EncodeToString(make([]byte, 31)),
+			Vout:     1,
+			Sequence: 0xfffffffd,
+			PrevOut:  prevOut,
+		}},
+		Vout: []Output{
+			{Value: 0.4, ScriptPubKey: ScriptPubKey{Hex: "0014" + hex.EncodeToString(make([]byte, 20))}},
+			{Value: 0.0999, N: 1, ScriptPubKey: ScriptPubKey{Hex: "0020" + hex.EncodeToString(make([]byte, 32))}},
+		},
+	}
+}
+
+func TestParseMultisig(t *testing.T) {
+	a, b, c := newTestKey(101), newTestKey(202), newTestKey(303)
+	ms, ok := ParseMultisig(multisigScript(2, a, b, c))
+	require.True(t, ok)
+	assert.Equal(t, 2, ms.Required)
+	assert.Equal(t, []string{hex.EncodeToString(a.pub()), hex.EncodeToString(b.pub()), hex.EncodeToString(c.pub())}, ms.Keys)
+
+	_, ok = ParseMultisig(multisigScript(4, a, b, c))
+	assert.False(t, ok, "m above n")
+	bad := multisigScript(1, a)
+	bad[2] ^= 0xff
+	_, ok = ParseMultisig(bad)
+	assert.False(t, ok, "not a curve point")
+	_, ok = ParseMultisig(hexBytes("0014" + hex.EncodeToString(make([]byte, 20))))
+	assert.False(t, ok)
+}
+
+func TestPaysToScript(t *testing.T) {
+	redeem := multisigScript(1, newTestKey(7))
+	scriptHash := sha256.Sum256(redeem)
+	p2wsh := hex.EncodeToString(witnessProgramScript(0, scriptHash[:]))
+
+	assert.True(t, PaysToScript(hex.EncodeToString(scriptHashScript(redeem)), redeem))
+	assert.True(t, PaysToScript(p2wsh, redeem))
+	assert.True(t, PaysToScript(hex.EncodeToString(scriptHashScript(hexBytes(p2wsh))), redeem))
+	assert.False(t, PaysToScript(p2wsh, multisigScript(1, newTestKey(8))))
+	assert.False(t, PaysToScript("", redeem))
+}
+
+func TestMultisigSigners_Witness(t *testing.T) {
+	a, b, c := newTestKey(101), newTestKey(202), newTestKey(303)
+	redeem := multisigScript(2, a, b, c)
+	scriptHash := sha256.Sum256(redeem)
+	tx := multisigSpendTx(&Output{Value: 0.5, ScriptPubKey: ScriptPubKey{Hex: hex.EncodeToString(witnessProgramScript(0, scriptHash[:]))}})
+
+	hash, err := sigHashWitnessV0(tx, 0, redeem, txscript.SigHashAll)
+	require.NoError(t, err)
+	tx.Vin[0].Witness = []string{
+		"",
+		hex.EncodeToString(a.sign(hash, txscript.SigHashAll)),
+		hex.EncodeToString(c.sign(hash, txscript.SigHashAll)),
+		hex.EncodeToString(redeem),
+	}
+	signers, ok := MultisigSigners(tx, 0, redeem)
+	require.True(t, ok)
+	assert.Equal(t, []string{hex.EncodeToString(a.pub()), hex.EncodeToString(c.pub())}, signers)
+
+	// A signature over another transaction matches no key.
+	tx.Vin[0].Witness[2] = hex.EncodeToString(b.sign(doubleSHA256([]byte("other")), txscript.SigHashAll))
+	signers, ok = MultisigSigners(tx, 0, redeem)
+	require.True(t, ok)
+	assert.Equal(t, []string{hex.EncodeToString(a.pub())}, signers)
+
+	_, ok = MultisigSigners(tx, 0, multisigScript(1, a))
+	assert.False(t, ok, "input spends another script")
+	tx.Vin[0].PrevOut = nil
+	_, ok = MultisigSigners(tx, 0, redeem)
+	assert.False(t, ok, "witness signatures commit to the unknown prevout value")
+}
+
+func TestMultisigSigners_Legacy(t *testing.T) {
+	a, b, c := newTestKey(101), newTestKey(202), newTestKey(303)
+	redeem := multisigScript(2, a, b, c)
+	tx := multisigSpendTx(nil)
+	tx.Vin = append(tx.Vin, Input{TxID: "22" + hex.EncodeToString(make([]byte, 31)), Sequence: 0xffffffff})
+
+	hashAll, err := sigHashLegacy(tx, 0, redeem, txscript.SigHashAll)
+	require.NoError(t, err)
+	hashSingle, err := sigHashLegacy(tx, 0, redeem, txscript.SigHashSingle|txscript.SigHashAnyOneCanPay)
+	require.NoError(t, err)
+	assert.NotEqual(t, hashAll, hashSingle)
+
+	sigB, sigC := b.sign(hashAll, txscript.SigHashAll), c.sign(hashSingle, txscript.SigHashSingle|txscript.SigHashAnyOneCanPay)
+	scriptSig := []byte{op0, byte(len(sigB))}
+	scriptSig = append(scriptSig, sigB...)
+	scriptSig = append(scriptSig, byte(len(sigC)))
+	scriptSig = append(scriptSig, sigC...)
+	scriptSig = append(scriptSig, opPushData1, byte(len(redeem)))
+	scriptSig = append(scriptSig, redeem...)
+	tx.Vin[0].ScriptSig.Hex = hex.EncodeToString(scriptSig)
+
+	signers, ok := MultisigSigners(tx, 0, redeem)
+	require.True(t, ok)
+	assert.Equal(t, []string{hex.EncodeToString(b.pub()), hex.EncodeToString(c.pub())}, signers)
+
+	// Out of key order, OP_CHECKMULTISIG would fail: c's key is used up
+	// before b's signature is tried.
+	swapped := []byte{op0, byte(len(sigC))}
+	swapped = append(swapped, sigC...)
+	swapped = append(swapped, byte(len(sigB)))
+	swapped = append(swapped, sigB...)
+	swapped = append(swapped, opPushData1, byte(len(redeem)))
+	swapped = append(swapped, redeem...)
+	tx.Vin[0].ScriptSig.Hex = hex.EncodeToString(swapped)
+	signers, ok = MultisigSigners(tx, 0, redeem)
+	require.True(t, ok)
+	assert.Equal(t, []string{hex.EncodeToString(c.pub())}, signers)
+}
@@ -0,0 +1,105 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Network names a Bitcoin network as selected by ChainConfig.BitcoinNetwork.
+type Network string
+
+const (
+	NetworkMainnet  Network = "mainnet"
+	NetworkTestnet3 Network = "testnet3"
+	NetworkTestnet4 Network = "testnet4"
+	NetworkSignet   Network = "signet"
+	NetworkRegtest  Network = "regtest"
+)
+
+// NetworkParams describes what tells Bitcoin networks apart. The testnets
+// and signet share the "tb" HRP and base58 prefixes, so only the node's
+// chain name and genesis block can tell them apart.
+type NetworkParams struct {
+	Network Network
+	// CoreChain is the "chain" getblockchaininfo reports.
+	CoreChain        string
+	Bech32HRP        string
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+	GenesisHash      string
+	// SubsidyHalvingInterval is the number of blocks between halvings of
+	// the block subsidy.
+	SubsidyHalvingInterval uint64
+}
+
+var networkParams = []NetworkParams{
+	{
+		Network:                NetworkMainnet,
+		CoreChain:              "main",
+		Bech32HRP:              "bc",
+		PubKeyHashAddrID:       0x00,
+		ScriptHashAddrID:       0x05,
+		GenesisHash:            "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+		SubsidyHalvingInterval: 210_000,
+	},
+	{
+		Network:                NetworkTestnet3,
+		CoreChain:              "test",
+		Bech32HRP:              "tb",
+		PubKeyHashAddrID:       0x6f,
+		ScriptHashAddrID:       0xc4,
+		GenesisHash:            "000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943",
+		SubsidyHalvingInterval: 210_000,
+	},
+	{
+		Network:                NetworkTestnet4,
+		CoreChain:              "testnet4",
+		Bech32HRP:              "tb",
+		PubKeyHashAddrID:       0x6f,
+		ScriptHashAddrID:       0xc4,
+		GenesisHash:            "00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043",
+		SubsidyHalvingInterval: 210_000,
+	},
+	{
+		Network:                NetworkSignet,
+		CoreChain:              "signet",
+		Bech32HRP:              "tb",
+		PubKeyHashAddrID:       0x6f,
+		ScriptHashAddrID:       0xc4,
+		GenesisHash:            "00000008819873e925422c1ff0f99f7cc9bbb232af63a077a480a3633bee1ef6",
+		SubsidyHalvingInterval: 210_000,
+	},
+	{
+		Network:                NetworkRegtest,
+		CoreChain:              "regtest",
+		Bech32HRP:              "bcrt",
+		PubKeyHashAddrID:       0x6f,
+		ScriptHashAddrID:       0xc4,
+		GenesisHash:            "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afcd81cad0a35f9e4206",
+		SubsidyHalvingInterval: 150,
+	},
+}
+
+// ParamsFor returns the parameters of network n.
+func ParamsFor(n Network) (NetworkParams, error) {
+	i := slices.IndexFunc(networkParams, func(p NetworkParams) bool { return p.Network == n })
+	if i < 0 {
+		return NetworkParams{}, fmt.Errorf("unknown bitcoin network %q", n)
+	}
+	return networkParams[i], nil
+}
+
+// ChainID returns the chain getblockchaininfo reports, e.g. "main".
+func (c *BitcoinClient) ChainID(ctx context.Context) (string, error) {
+	info, err := c.GetBlockchainInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.Chain, nil
+}
+
+// GenesisHash returns the hash of block 0.
+func (c *BitcoinClient) GenesisHash(ctx context.Context) (string, error) {
+	return c.GetBlockHash(ctx, 0)
+}
@@ -0,0 +1,103 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+)
+
+// scanAbortTimeout bounds the scantxoutset abort sent when a scan outlives
+// its caller.
+const scanAbortTimeout = 10 * time.Second
+
+// ScanTxOutSetResult is the result of scantxoutset start: the unspent
+// outputs matching the scanned descriptors as of BestBlock.
+type ScanTxOutSetResult struct {
+	Success     bool          `json:"success"`
+	TxOuts      uint64        `json:"txouts"`
+	Height      uint64        `json:"height"`
+	BestBlock   string        `json:"bestblock"`
+	Unspents    []ScanUnspent `json:"unspents"`
+	TotalAmount float64       `json:"total_amount"`
+}
+
+// ScanUnspent is one unspent output found by scantxoutset.
+type ScanUnspent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Desc         string  `json:"desc"`
+	Amount       float64 `json:"amount"`
+	Coinbase     bool    `json:"coinbase"`
+	Height       uint64  `json:"height"`
+}
+
+// ScanTxOutSet scans the node's UTXO set for the outputs matching
+// descriptors. The scan reads the whole UTXO set and Core runs one at a
+// time, so calls on a client wait for each other; one cut short by ctx is
+// aborted on the node rather than left running.
+func (c *BitcoinClient) ScanTxOutSet(ctx context.Context, descriptors []string) (*ScanTxOutSetResult, error) {
+	select {
+	case c.scanSlot <- struct{}{}:
+		defer func() { <-c.scanSlot }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wait for scantxoutset slot: %w", ctx.Err())
+	}
+
+	resp, err := c.CallRPC(ctx, "scantxoutset", []any{"start", descriptors})
+	if err != nil {
+		if ctx.Err() != nil {
+			c.abortScan(context.WithoutCancel(ctx))
+		}
+		return nil, fmt.Errorf("scantxoutset failed: %w", err)
+	}
+
+	var result ScanTxOutSetResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scantxoutset result: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("scantxoutset did not complete")
+	}
+	return &result, nil
+}
+
+// abortScan stops the scan this client started on the node.
+func (c *BitcoinClient) abortScan(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, scanAbortTimeout)
+	defer cancel()
+	_, _ = c.CallRPC(ctx, "scantxoutset", []any{"abort"})
+}
+
+// AddressScript returns the hex scriptPubKey paying addr, a base58 P2PKH
+// or P2SH address or a segwit address of any witness version, with
+// Bitcoin's HRP or one of hrps.
+func AddressScript(addr string, hrps ...string) (string, error) {
+	addr, err := btcaddr.NormalizeBTCAddress(addr, hrps...)
+	if err != nil {
+		return "", err
+	}
+	if btcaddr.HasSegwitPrefix(strings.ToLower(addr), hrps...) {
+		_, version, program, err := btcaddr.DecodeSegwitAddress(addr, hrps...)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(witnessProgramScript(version, program)), nil
+	}
+
+	decoded := base58.Decode(addr)
+	version, hash := decoded[0], decoded[1:21]
+	switch version {
+	case 0x00, 0x6f: // P2PKH: OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+		return "76a914" + hex.EncodeToString(hash) + "88ac", nil
+	case 0x05, 0xc4: // P2SH: OP_HASH160 <hash> OP_EQUAL
+		return "a914" + hex.EncodeToString(hash) + "87", nil
+	}
+	return "", fmt.Errorf("unsupported address version 0x%02x", version)
+}
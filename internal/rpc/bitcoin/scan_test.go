@@ -0,0 +1,116 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressScript(t *testing.T) {
+	tests := map[string]string{
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":                             "76a91477bff20c60e522dfaa3350c39b030a5d004e839a88ac",
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn":                             "76a914243f1394f44554f4ce3fd68649c19adc483ce92488ac",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":                             "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0": "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+	}
+	for addr, want := range tests {
+		got, err := AddressScript(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, want, got, addr)
+	}
+
+	_, err := AddressScript("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5")
+	assert.Error(t, err)
+}
+
+// scanNode answers scantxoutset start after delay, or when the request is
+// cancelled, recording the actions requested and the most scans it ran at
+// once.
+type scanNode struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	actions []string
+	running atomic.Int32
+	maxRun  atomic.Int32
+}
+
+func (n *scanNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     any   `json:"id"`
+		Params []any `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	action, _ := req.Params[0].(string)
+	n.mu.Lock()
+	n.actions = append(n.actions, action)
+	n.mu.Unlock()
+
+	result := `true`
+	if action == "start" {
+		running := n.running.Add(1)
+		defer n.running.Add(-1)
+		if running > n.maxRun.Load() {
+			n.maxRun.Store(running)
+		}
+		select {
+		case <-time.After(n.delay):
+		case <-r.Context().Done():
+			return
+		}
+		result = `{"success":true,"txouts":100,"height":900,"bestblock":"00ab","unspents":[` +
+			`{"txid":"aa","vout":1,"scriptPubKey":"0014751e76e8199196d454941c45d1b3a323f1433bd6","desc":"raw(0014751e76e8199196d454941c45d1b3a323f1433bd6)#x","amount":0.5,"coinbase":false,"height":800}` +
+			`],"total_amount":0.5}`
+	}
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": json.RawMessage(result)})
+}
+
+func (n *scanNode) requested() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.actions...)
+}
+
+func TestScanTxOutSet_OneAtATime(t *testing.T) {
+	node := &scanNode{delay: 20 * time.Millisecond}
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+	client := NewBitcoinClient(srv.URL, nil, 10*time.Second, nil)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.ScanTxOutSet(context.Background(), []string{"raw(0014751e76e8199196d454941c45d1b3a323f1433bd6)"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, uint64(900), res.Height)
+				assert.Len(t, res.Unspents, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), node.maxRun.Load(), "scans on one node must not overlap")
+}
+
+func TestScanTxOutSet_AbortsOnTimeout(t *testing.T) {
+	node := &scanNode{delay: time.Minute}
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+	client := NewBitcoinClient(srv.URL, nil, 10*time.Second, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.ScanTxOutSet(ctx, []string{"raw(00)"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"start", "abort"}, node.requested())
+}
@@ -0,0 +1,162 @@
+package bitcoin
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Opcodes the script parser needs to tell apart.
+const (
+	op0                   = 0x00
+	opPushData1           = 0x4c
+	opPushData2           = 0x4d
+	opPushData4           = 0x4e
+	op1Negate             = 0x4f
+	op1                   = 0x51
+	op2                   = 0x52
+	op16                  = 0x60
+	opIf                  = 0x63
+	opNotIf               = 0x64
+	opElse                = 0x67
+	opEndIf               = 0x68
+	opRipemd160           = 0xa6
+	opSha256              = 0xa8
+	opHash160             = 0xa9
+	opHash256             = 0xaa
+	opCheckMultisig       = 0xae
+	opCheckLockTimeVerify = 0xb1
+	opCheckSequenceVerify = 0xb2
+)
+
+var errMalformedScript = errors.New("malformed script")
+
+// scriptOp is one parsed opcode and, for pushes, its data.
+type scriptOp struct {
+	op   byte
+	data []byte
+}
+
+// parseScript splits script into opcodes. It fails on pushes running past
+// the end of the script, the only way a script can be unparseable.
+func parseScript(script []byte) ([]scriptOp, error) {
+	var ops []scriptOp
+	for i := 0; i < len(script); {
+		op := script[i]
+		i++
+		var n int
+		switch {
+		case op > op0 && op < opPushData1:
+			n = int(op)
+		case op == opPushData1 && i+1 <= len(script):
+			n, i = int(script[i]), i+1
+		case op == opPushData2 && i+2 <= len(script):
+			n, i = int(binary.LittleEndian.Uint16(script[i:])), i+2
+		case op == opPushData4 && i+4 <= len(script):
+			n, i = int(binary.LittleEndian.Uint32(script[i:])), i+4
+		case op >= opPushData1 && op <= opPushData4:
+			return nil, errMalformedScript
+		}
+		if n > len(script)-i {
+			return nil, errMalformedScript
+		}
+		ops = append(ops, scriptOp{op: op, data: script[i : i+n]})
+		i += n
+	}
+	return ops, nil
+}
+
+// number returns the script number o pushes, for numbers of up to maxLen
+// bytes (5 for lock time operands).
+func (o scriptOp) number(maxLen int) (int64, bool) {
+	switch {
+	case o.op == op0:
+		return 0, true
+	case o.op == op1Negate:
+		return -1, true
+	case o.op >= op1 && o.op <= op16:
+		return int64(o.op - op1 + 1), true
+	case o.op >= opPushData1 || len(o.data) > maxLen:
+		return 0, false
+	}
+	var n int64
+	for i, b := range o.data {
+		n |= int64(b) << (8 * i)
+	}
+	if last := o.data[len(o.data)-1]; last&0x80 != 0 {
+		n &^= int64(0x80) << (8 * (len(o.data) - 1))
+		n = -n
+	}
+	return n, true
+}
+
+// ScriptTemplate names a script shape classifyScript recognizes.
+type ScriptTemplate string
+
+const (
+	// TemplateHTLC is a hashed timelock contract: a hash lock and a
+	// timelock on separate branches, as in BOLT-3 HTLC outputs and atomic
+	// swaps.
+	TemplateHTLC ScriptTemplate = "htlc"
+	// TemplateTimelock is a script with a timelock and no hash lock, e.g.
+	// "<n> OP_CHECKLOCKTIMEVERIFY OP_DROP <key> OP_CHECKSIG" or a BOLT-3
+	// to_local output.
+	TemplateTimelock ScriptTemplate = "timelock"
+)
+
+// scriptClass is what classifyScript makes of a script.
+type scriptClass struct {
+	template  ScriptTemplate
+	timelocks []Timelock
+}
+
+// classifyScript finds the timelocks of a script: each constant operand
+// directly followed by OP_CHECKLOCKTIMEVERIFY or OP_CHECKSEQUENCEVERIFY,
+// which is how every common template writes them. A timelock inside an
+// OP_IF branch is marked conditional, since another branch may spend
+// without it. Nothing else of the script is interpreted, so an unknown
+// script yields its timelocks but no template.
+func classifyScript(script []byte) scriptClass {
+	ops, err := parseScript(script)
+	if err != nil {
+		return scriptClass{}
+	}
+	var (
+		class    scriptClass
+		depth    int
+		hashLock bool
+	)
+	for i, o := range ops {
+		switch o.op {
+		case opIf, opNotIf:
+			depth++
+		case opEndIf:
+			depth = max(depth-1, 0)
+		case opRipemd160, opSha256, opHash160, opHash256:
+			hashLock = true
+		case opCheckLockTimeVerify, opCheckSequenceVerify:
+			if i == 0 {
+				continue
+			}
+			operand, ok := ops[i-1].number(5)
+			if !ok || operand < 0 {
+				continue
+			}
+			var lock Timelock
+			if o.op == opCheckLockTimeVerify {
+				lock = absoluteTimelock(TimelockCLTV, uint64(operand))
+			} else if lock, ok = relativeTimelock(TimelockCSV, uint64(operand)); !ok {
+				continue
+			}
+			lock.Conditional = depth > 0
+			class.timelocks = append(class.timelocks, lock)
+		}
+	}
+	switch {
+	case len(class.timelocks) == 0:
+	case hashLock:
+		class.template = TemplateHTLC
+	default:
+		class.template = TemplateTimelock
+	}
+	return class
+}
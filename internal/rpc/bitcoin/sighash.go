@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/shopspring/decimal"
+)
+
+var errMissingPrevout = errors.New("prevout not known")
+
+// wireTx rebuilds tx, as decoded by Core, in wire format for txscript to
+// hash. Scripts of inputs and witnesses are left out: no signature hash
+// commits to them.
+func wireTx(tx *Transaction) (*wire.MsgTx, error) {
+	msgTx := wire.NewMsgTx(int32(tx.Version))
+	msgTx.LockTime = uint32(tx.LockTime)
+	for i := range tx.Vin {
+		vin := &tx.Vin[i]
+		if len(vin.TxID) != 2*chainhash.HashSize {
+			return nil, fmt.Errorf("input spends invalid txid %q", vin.TxID)
+		}
+		hash, err := chainhash.NewHashFromStr(vin.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("input spends invalid txid %q", vin.TxID)
+		}
+		in := wire.NewTxIn(wire.NewOutPoint(hash, vin.Vout), nil, nil)
+		in.Sequence = uint32(vin.Sequence)
+		msgTx.AddTxIn(in)
+	}
+	for i := range tx.Vout {
+		out := &tx.Vout[i]
+		script, err := hex.DecodeString(out.ScriptPubKey.Hex)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: invalid script hex: %w", out.N, err)
+		}
+		msgTx.AddTxOut(wire.NewTxOut(outputSats(out.Value), script))
+	}
+	return msgTx, nil
+}
+
+// sigHashLegacy is the pre-segwit signature hash of input idx of tx,
+// signed with hashType over scriptCode (the redeem script of a P2SH input).
+func sigHashLegacy(tx *Transaction, idx int, scriptCode []byte, hashType txscript.SigHashType) ([]byte, error) {
+	msgTx, err := wireTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.CalcSignatureHash(scriptCode, hashType, msgTx, idx)
+}
+
+// sigHashWitnessV0 is the BIP-143 signature hash of input idx of tx,
+// spending a v0 witness output with scriptCode (the witness script of a
+// P2WSH input). It needs the value of the spent output.
+func sigHashWitnessV0(tx *Transaction, idx int, scriptCode []byte, hashType txscript.SigHashType) ([]byte, error) {
+	if tx.Vin[idx].PrevOut == nil {
+		return nil, errMissingPrevout
+	}
+	msgTx, err := wireTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	// BIP-143 hashes commit to no other prevout, only taproot's do.
+	sigHashes := txscript.NewTxSigHashes(msgTx, txscript.NewCannedPrevOutputFetcher(nil, 0))
+	return txscript.CalcWitnessSigHash(scriptCode, sigHashes, hashType, msgTx, idx,
+		outputSats(tx.Vin[idx].PrevOut.Value))
+}
+
+// verifyECDSA reports whether DER signature sig (without its hash type
+// byte) by pubKey signs hash. High-S signatures are accepted, as consensus
+// does.
+func verifyECDSA(pubKey, sig, hash []byte) bool {
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return false
+	}
+	signature, err := ecdsa.ParseDERSignature(sig)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(hash, key)
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// outputSats converts a BTC value as reported by Core to satoshis.
+func outputSats(value float64) int64 {
+	return decimal.NewFromFloat(value).Shift(8).Round(0).IntPart()
+}
@@ -0,0 +1,32 @@
+package bitcoin
+
+// InitialSubsidy is the block subsidy before the first halving, in satoshis.
+const InitialSubsidy int64 = 50 * 1e8
+
+// maxHalvings is the number of halvings after which the subsidy is zero, as
+// Core's GetBlockSubsidy treats it.
+const maxHalvings = 64
+
+// BlockSubsidy returns the subsidy of the block at height in satoshis: the
+// coinbase may claim it on top of the block's fees.
+func BlockSubsidy(height, halvingInterval uint64) int64 {
+	halvings := height / halvingInterval
+	if halvings >= maxHalvings {
+		return 0
+	}
+	return InitialSubsidy >> halvings
+}
+
+// ScheduledSupply returns the subsidies of blocks 0 through height in
+// satoshis. The genesis subsidy is counted although its output cannot be
+// spent.
+func ScheduledSupply(height, halvingInterval uint64) int64 {
+	var supply int64
+	blocks := height + 1
+	for era := uint64(0); era < maxHalvings && blocks > 0; era++ {
+		n := min(blocks, halvingInterval)
+		supply += int64(n) * (InitialSubsidy >> era)
+		blocks -= n
+	}
+	return supply
+}
@@ -0,0 +1,37 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockSubsidy(t *testing.T) {
+	assert.Equal(t, int64(5_000_000_000), BlockSubsidy(0, 210_000))
+	assert.Equal(t, int64(5_000_000_000), BlockSubsidy(209_999, 210_000))
+	assert.Equal(t, int64(2_500_000_000), BlockSubsidy(210_000, 210_000))
+	assert.Equal(t, int64(312_500_000), BlockSubsidy(840_000, 210_000))
+	assert.Equal(t, int64(1), BlockSubsidy(32*210_000, 210_000))
+	assert.Zero(t, BlockSubsidy(33*210_000, 210_000))
+	assert.Zero(t, BlockSubsidy(64*210_000, 210_000))
+
+	assert.Equal(t, int64(2_500_000_000), BlockSubsidy(150, 150), "regtest halves every 150 blocks")
+}
+
+func TestScheduledSupply(t *testing.T) {
+	assert.Equal(t, int64(5_000_000_000), ScheduledSupply(0, 210_000))
+	assert.Equal(t, int64(210_000*5_000_000_000), ScheduledSupply(209_999, 210_000))
+	assert.Equal(t, int64(210_000*5_000_000_000+2_500_000_000), ScheduledSupply(210_000, 210_000))
+
+	// Brute force across a few halvings of a short interval.
+	var want int64
+	for h := uint64(0); h < 1_000; h++ {
+		want += BlockSubsidy(h, 150)
+		if got := ScheduledSupply(h, 150); got != want {
+			t.Fatalf("ScheduledSupply(%d) = %d, want %d", h, got, want)
+		}
+	}
+
+	// The 21M cap, less the satoshis lost to rounding.
+	assert.Equal(t, int64(2_099_999_997_690_000), ScheduledSupply(1<<40, 210_000))
+}
@@ -0,0 +1,115 @@
+package bitcoin
+
+// TimelockType says where a timelock comes from.
+type TimelockType string
+
+const (
+	// TimelockCLTV is an absolute lock in a script (BIP-65).
+	TimelockCLTV TimelockType = "cltv"
+	// TimelockCSV is a relative lock in a script (BIP-112).
+	TimelockCSV TimelockType = "csv"
+	// TimelockLocktime is the transaction's nLockTime.
+	TimelockLocktime TimelockType = "locktime"
+	// TimelockSequence is a relative lock in an input's nSequence (BIP-68).
+	TimelockSequence TimelockType = "sequence"
+)
+
+// TimelockUnit is what a timelock's value counts.
+type TimelockUnit string
+
+const (
+	TimelockHeight TimelockUnit = "height" // block height, or blocks when relative
+	TimelockTime   TimelockUnit = "time"   // unix time, or seconds when relative
+)
+
+const (
+	// locktimeThreshold splits lock times into heights and unix times.
+	locktimeThreshold = 500_000_000
+	// finalSequence disables nLockTime when every input has it.
+	finalSequence = 0xffffffff
+
+	// BIP-68 relative lock encoding.
+	sequenceDisableFlag = 1 << 31
+	sequenceTypeFlag    = 1 << 22
+	sequenceMask        = 0xffff
+	sequenceGranularity = 512 // seconds per unit of time-based locks
+)
+
+// Timelock is an absolute or relative timelock of a transaction or output.
+// Relative values count from the confirmation of the output spent.
+type Timelock struct {
+	Type  TimelockType `json:"type"`
+	Unit  TimelockUnit `json:"unit"`
+	Value uint64       `json:"value"`
+	// Vin is the input of a sequence lock.
+	Vin *uint32 `json:"vin,omitempty"`
+	// Conditional marks a script lock on one branch of the script only.
+	Conditional bool `json:"conditional,omitempty"`
+	// Template is the recognized shape of the script holding a script lock.
+	Template ScriptTemplate `json:"template,omitempty"`
+}
+
+func absoluteTimelock(typ TimelockType, value uint64) Timelock {
+	if value < locktimeThreshold {
+		return Timelock{Type: typ, Unit: TimelockHeight, Value: value}
+	}
+	return Timelock{Type: typ, Unit: TimelockTime, Value: value}
+}
+
+// relativeTimelock decodes a BIP-68 sequence or CSV operand. It returns
+// false when the disable flag is set.
+func relativeTimelock(typ TimelockType, sequence uint64) (Timelock, bool) {
+	if sequence&sequenceDisableFlag != 0 {
+		return Timelock{}, false
+	}
+	if sequence&sequenceTypeFlag != 0 {
+		return Timelock{Type: typ, Unit: TimelockTime, Value: (sequence & sequenceMask) * sequenceGranularity}, true
+	}
+	return Timelock{Type: typ, Unit: TimelockHeight, Value: sequence & sequenceMask}, true
+}
+
+// TransactionTimelocks returns the locks tx itself sets: its nLockTime when
+// enforced, i.e. non-zero with some input not final, and the relative lock
+// of each input of a version 2 or later transaction. Once tx is mined they
+// are met, but they tell how its outputs were held back.
+func TransactionTimelocks(tx *Transaction) []Timelock {
+	if tx == nil || tx.IsCoinbase() {
+		return nil
+	}
+	var locks []Timelock
+	if tx.LockTime != 0 {
+		for _, vin := range tx.Vin {
+			if vin.Sequence != finalSequence {
+				locks = append(locks, absoluteTimelock(TimelockLocktime, tx.LockTime))
+				break
+			}
+		}
+	}
+	if tx.Version < 2 {
+		return locks
+	}
+	for i, vin := range tx.Vin {
+		lock, ok := relativeTimelock(TimelockSequence, vin.Sequence)
+		if !ok || lock.Value == 0 {
+			continue
+		}
+		n := uint32(i)
+		lock.Vin = &n
+		locks = append(locks, lock)
+	}
+	return locks
+}
+
+// OutputTimelocks returns the CLTV and CSV locks of out's script. Only bare
+// scripts show them: P2SH, P2WSH and P2TR outputs commit to a hash of their
+// script, revealed when spent.
+func OutputTimelocks(out *Output) []Timelock {
+	if out == nil {
+		return nil
+	}
+	class := classifyScript(hexBytes(out.ScriptPubKey.Hex))
+	for i := range class.timelocks {
+		class.timelocks[i].Template = class.template
+	}
+	return class.timelocks
+}
@@ -0,0 +1,114 @@
+package bitcoin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Placeholder keys and hashes: only the script shape matters here.
+var (
+	testKeyA = "21" + "02" + strings.Repeat("11", 32)
+	testKeyB = "21" + "03" + strings.Repeat("22", 32)
+	testHash = "14" + strings.Repeat("33", 20)
+)
+
+func scriptOutput(hex string) *Output {
+	return &Output{ScriptPubKey: ScriptPubKey{Type: "nonstandard", Hex: hex}}
+}
+
+func TestOutputTimelocks_Templates(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		want   []Timelock
+	}{
+		{
+			// <700000> OP_CLTV OP_DROP <key> OP_CHECKSIG
+			"cltv key", "0360ae0a" + "b175" + testKeyA + "ac",
+			[]Timelock{{Type: TimelockCLTV, Unit: TimelockHeight, Value: 700_000, Template: TemplateTimelock}},
+		},
+		{
+			// BOLT-3 to_local: OP_IF <revocation> OP_ELSE <144> OP_CSV
+			// OP_DROP <delayed> OP_ENDIF OP_CHECKSIG
+			"lightning to_local", "63" + testKeyA + "67" + "029000" + "b275" + testKeyB + "68ac",
+			[]Timelock{{Type: TimelockCSV, Unit: TimelockHeight, Value: 144, Conditional: true, Template: TemplateTimelock}},
+		},
+		{
+			// BOLT-3 anchor: <key> OP_CHECKSIG OP_IFDUP OP_NOTIF OP_16
+			// OP_CSV OP_ENDIF
+			"lightning anchor", testKeyA + "ac7364" + "60b2" + "68",
+			[]Timelock{{Type: TimelockCSV, Unit: TimelockHeight, Value: 16, Conditional: true, Template: TemplateTimelock}},
+		},
+		{
+			// BOLT-3 received HTLC with anchors: the timeout branch is
+			// locked until block 500000, every branch by one block.
+			"lightning received htlc",
+			"76a9" + testHash + "8763ac67" + testKeyB + "7c8201208763a9" + testHash + "88527c" + testKeyA + "52ae67" +
+				"750320a107b175ac68" + "51b27568",
+			[]Timelock{
+				{Type: TimelockCLTV, Unit: TimelockHeight, Value: 500_000, Conditional: true, Template: TemplateHTLC},
+				{Type: TimelockCSV, Unit: TimelockHeight, Value: 1, Conditional: true, Template: TemplateHTLC},
+			},
+		},
+		{
+			// Atomic swap: OP_IF OP_SHA256 <hash> OP_EQUALVERIFY <key>
+			// OP_ELSE <1700000000> OP_CLTV OP_DROP <key> OP_ENDIF OP_CHECKSIG
+			"atomic swap", "63a8" + testHash + "88" + testKeyA + "67" + "0400f15365" + "b175" + testKeyB + "68ac",
+			[]Timelock{{Type: TimelockCLTV, Unit: TimelockTime, Value: 1_700_000_000, Conditional: true, Template: TemplateHTLC}},
+		},
+		{
+			// <10 × 512s> OP_CSV OP_DROP <key> OP_CHECKSIG
+			"time-based csv", "030a0040" + "b275" + testKeyA + "ac",
+			[]Timelock{{Type: TimelockCSV, Unit: TimelockTime, Value: 5120, Template: TemplateTimelock}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, OutputTimelocks(scriptOutput(tc.script)))
+		})
+	}
+}
+
+func TestOutputTimelocks_NotClaimed(t *testing.T) {
+	for name, script := range map[string]string{
+		"p2wpkh":            "0014" + strings.Repeat("33", 20),
+		"hash lock only":    "a9" + testHash + "87",
+		"csv disable flag":  "050000008000" + "b275" + testKeyA + "ac",
+		"negative operand":  "0181" + "b175" + testKeyA + "ac",
+		"computed operand":  "93b1", // OP_ADD OP_CLTV
+		"lock at start":     "b1",
+		"truncated push":    "4c",
+		"truncated operand": "0360ae",
+	} {
+		assert.Empty(t, OutputTimelocks(scriptOutput(script)), name)
+	}
+	assert.Empty(t, OutputTimelocks(nil))
+}
+
+func TestTransactionTimelocks(t *testing.T) {
+	vin := func(sequence uint64) Input { return Input{TxID: "prev", Sequence: sequence} }
+	one := uint32(1)
+
+	cases := []struct {
+		name string
+		tx   *Transaction
+		want []Timelock
+	}{
+		{"final inputs disable locktime", &Transaction{Version: 2, LockTime: 800_000, Vin: []Input{vin(finalSequence)}}, nil},
+		{"anti-fee-sniping locktime", &Transaction{Version: 1, LockTime: 800_000, Vin: []Input{vin(0xfffffffd)}},
+			[]Timelock{{Type: TimelockLocktime, Unit: TimelockHeight, Value: 800_000}}},
+		{"time locktime", &Transaction{Version: 1, LockTime: 1_700_000_000, Vin: []Input{vin(0)}},
+			[]Timelock{{Type: TimelockLocktime, Unit: TimelockTime, Value: 1_700_000_000}}},
+		{"relative lock", &Transaction{Version: 2, Vin: []Input{vin(finalSequence), vin(144)}},
+			[]Timelock{{Type: TimelockSequence, Unit: TimelockHeight, Value: 144, Vin: &one}}},
+		{"relative lock needs version 2", &Transaction{Version: 1, Vin: []Input{vin(finalSequence), vin(144)}}, nil},
+		{"coinbase", &Transaction{Version: 2, LockTime: 800_000, Vin: []Input{{Sequence: 0}}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, TransactionTimelocks(tc.tx))
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package bitcoin
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
 	"github.com/shopspring/decimal"
 )
 
@@ -37,42 +42,136 @@ func (tx *Transaction) CalculateFee() decimal.Decimal {
 	return fee
 }
 
-// GetOutputAddress extracts the address from an output's scriptPubKey
-func GetOutputAddress(output *Output) string {
-	if output == nil {
-		return ""
+// SizeBasis names what a transaction's vsize was taken from.
+type SizeBasis string
+
+const (
+	// SizeBasisVSize is the vsize the node reported.
+	SizeBasisVSize SizeBasis = "vsize"
+	// SizeBasisWeight is a vsize computed from the reported weight, as
+	// (weight+3)/4.
+	SizeBasisWeight SizeBasis = "weight"
+	// SizeBasisSize is the raw size: exact for legacy transactions, it
+	// overstates the vsize of segwit ones by their witness discount.
+	SizeBasisSize SizeBasis = "size"
+)
+
+// VirtualSize returns tx's vsize in vbytes and what it was taken from.
+// Some forks report no vsize: it is then computed from the weight, or
+// taken to be the raw size as a last resort. It returns 0 and "" when the
+// node reported no size at all.
+func (tx *Transaction) VirtualSize() (int, SizeBasis) {
+	switch {
+	case tx.VSize > 0:
+		return tx.VSize, SizeBasisVSize
+	case tx.Weight > 0:
+		return (tx.Weight + 3) / 4, SizeBasisWeight
+	case tx.Size > 0:
+		return tx.Size, SizeBasisSize
 	}
+	return 0, ""
+}
 
-	if output.ScriptPubKey.Address != "" {
-		return output.ScriptPubKey.Address
+// CalculateFeeRate returns tx's fee rate in sat/vB, rounded to 3 decimals,
+// and the basis of the vsize it divided by, see VirtualSize. It returns 0
+// and "" when no size is known rather than dividing by zero.
+func (tx *Transaction) CalculateFeeRate() (decimal.Decimal, SizeBasis) {
+	vsize, basis := tx.VirtualSize()
+	if vsize == 0 {
+		return decimal.Zero, ""
 	}
+	return tx.CalculateFee().Shift(8).DivRound(decimal.NewFromInt(int64(vsize)), 3), basis
+}
 
-	if len(output.ScriptPubKey.Addresses) > 0 {
-		return output.ScriptPubKey.Addresses[0]
+// FeePerWeightUnit returns tx's fee in sat/WU, rounded to 3 decimals, for
+// comparing with policies set in weight units. Without a reported weight,
+// four times the vsize stands for it, which may exceed the weight by up to
+// 3 WU; the basis is then that of the vsize. It returns 0 and "" when no
+// size is known.
+func (tx *Transaction) FeePerWeightUnit() (decimal.Decimal, SizeBasis) {
+	weight, basis := tx.Weight, SizeBasisWeight
+	if weight <= 0 {
+		var vsize int
+		vsize, basis = tx.VirtualSize()
+		weight = 4 * vsize
+	}
+	if weight == 0 {
+		return decimal.Zero, ""
 	}
+	return tx.CalculateFee().Shift(8).DivRound(decimal.NewFromInt(int64(weight)), 3), basis
+}
 
+// GetOutputAddress extracts the address from an output's scriptPubKey: the
+// first of GetOutputAddresses.
+func GetOutputAddress(output *Output) string {
+	if addrs := GetOutputAddresses(output); len(addrs) > 0 {
+		return addrs[0]
+	}
 	return ""
 }
 
 // GetOutputAddresses returns all addresses from an output's scriptPubKey.
 // For standard outputs this returns a single address. For bare multisig (P2MS)
 // it returns all participant addresses. Returns nil for unspendable outputs.
+//
+// Address and the legacy Addresses are merged, as older Core versions and
+// forks fill both with overlapping entries. Each address is returned once,
+// in canonical form (see canonicalAddress), Address first.
 func GetOutputAddresses(output *Output) []string {
 	if output == nil {
 		return nil
 	}
 
-	if output.ScriptPubKey.Address != "" {
-		return []string{output.ScriptPubKey.Address}
+	var result []string
+	seen := make(map[string]bool)
+	for _, addr := range append([]string{output.ScriptPubKey.Address}, output.ScriptPubKey.Addresses...) {
+		addr, ok := canonicalAddress(addr)
+		if !ok || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		result = append(result, addr)
 	}
+	return result
+}
 
-	if len(output.ScriptPubKey.Addresses) > 0 {
-		result := make([]string, len(output.ScriptPubKey.Addresses))
-		copy(result, output.ScriptPubKey.Addresses)
-		return result
+// canonicalAddress trims addr and puts segwit addresses in their
+// canonical form, see btcaddr.CanonicalSegwitAddress. Segwit addresses in mixed
+// case are invalid (BIP-173) and rejected, as is an empty addr. One that
+// otherwise fails to decode is only lowercased: the node reported it.
+func canonicalAddress(addr string) (string, bool) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", false
+	}
+	if laddr := strings.ToLower(addr); btcaddr.HasSegwitPrefix(laddr) {
+		if addr != laddr && addr != strings.ToUpper(addr) {
+			return "", false
+		}
+		if canonical, err := btcaddr.CanonicalSegwitAddress(addr); err == nil {
+			return canonical, true
+		}
+		return laddr, true
 	}
+	return addr, true
+}
+
+// ScriptIDPrefix marks synthetic identifiers derived from a scriptPubKey.
+const ScriptIDPrefix = "script:"
 
-	return nil
+// ScriptID returns a stable identifier for an output Core reports no address
+// for: "script:" followed by the hex SHA-256 of the script bytes. It returns
+// "" for an empty script.
+func ScriptID(scriptHex string) string {
+	if scriptHex == "" {
+		return ""
+	}
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		script = []byte(scriptHex)
+	}
+	sum := sha256.Sum256(script)
+	return ScriptIDPrefix + hex.EncodeToString(sum[:])
 }
 
 // GetInputAddress extracts the address from an input's previous output
@@ -0,0 +1,110 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	p2wpkhAddr = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	p2pkhAddr  = "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+)
+
+func TestGetOutputAddresses_MergesAndDedups(t *testing.T) {
+	for name, tc := range map[string]struct {
+		spk  ScriptPubKey
+		want []string
+	}{
+		"modern address only": {
+			spk:  ScriptPubKey{Address: p2wpkhAddr},
+			want: []string{p2wpkhAddr},
+		},
+		"legacy addresses only": {
+			spk:  ScriptPubKey{Addresses: []string{p2pkhAddr}},
+			want: []string{p2pkhAddr},
+		},
+		// Forks (BCH, LTC nodes) and older Core versions fill both fields.
+		"same address in both fields": {
+			spk:  ScriptPubKey{Address: p2pkhAddr, Addresses: []string{p2pkhAddr}},
+			want: []string{p2pkhAddr},
+		},
+		"overlapping multisig": {
+			spk:  ScriptPubKey{Address: "ms_addr0", Addresses: []string{"ms_addr0", "ms_addr1", "ms_addr0"}},
+			want: []string{"ms_addr0", "ms_addr1"},
+		},
+		"bech32 case variants": {
+			spk:  ScriptPubKey{Address: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", Addresses: []string{p2wpkhAddr}},
+			want: []string{p2wpkhAddr},
+		},
+		"mixed case bech32 rejected": {
+			spk:  ScriptPubKey{Address: "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Addresses: []string{p2pkhAddr}},
+			want: []string{p2pkhAddr},
+		},
+		"blank entries skipped": {
+			spk:  ScriptPubKey{Addresses: []string{" ", p2pkhAddr + " "}},
+			want: []string{p2pkhAddr},
+		},
+		"no address": {},
+	} {
+		out := &Output{ScriptPubKey: tc.spk}
+		assert.Equal(t, tc.want, GetOutputAddresses(out), name)
+		if len(tc.want) > 0 {
+			assert.Equal(t, tc.want[0], GetOutputAddress(out), name)
+		} else {
+			assert.Empty(t, GetOutputAddress(out), name)
+		}
+	}
+}
+
+func TestGetOutputAddresses_LegacyCaseKept(t *testing.T) {
+	// Base58 addresses are case-sensitive.
+	out := &Output{ScriptPubKey: ScriptPubKey{Address: p2pkhAddr}}
+	assert.Equal(t, []string{p2pkhAddr}, GetOutputAddresses(out))
+}
+
+func TestFeeRateBasis(t *testing.T) {
+	// 0.00002 BTC in, 0.00001 BTC out: a 1000 sat fee.
+	tx := func(size, vsize, weight int) *Transaction {
+		return &Transaction{
+			Size: size, VSize: vsize, Weight: weight,
+			Vin:  []Input{{TxID: "aa", PrevOut: &Output{Value: 0.00002}}},
+			Vout: []Output{{Value: 0.00001}},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		tx              *Transaction
+		vsize           int
+		basis           SizeBasis
+		perVByte, perWU string
+		perWUBasis      SizeBasis
+	}{
+		"reported vsize and weight": {tx(222, 141, 561), 141, SizeBasisVSize, "7.092", "1.783", SizeBasisWeight},
+		"vsize from weight":         {tx(222, 0, 561), 141, SizeBasisWeight, "7.092", "1.783", SizeBasisWeight},
+		"vsize only":                {tx(0, 141, 0), 141, SizeBasisVSize, "7.092", "1.773", SizeBasisVSize},
+		"raw size last":             {tx(250, 0, 0), 250, SizeBasisSize, "4", "1", SizeBasisSize},
+	} {
+		t.Run(name, func(t *testing.T) {
+			vsize, basis := tc.tx.VirtualSize()
+			assert.Equal(t, tc.vsize, vsize)
+			assert.Equal(t, tc.basis, basis)
+
+			rate, basis := tc.tx.CalculateFeeRate()
+			assert.Equal(t, tc.perVByte, rate.String())
+			assert.Equal(t, tc.basis, basis)
+
+			perWU, basis := tc.tx.FeePerWeightUnit()
+			assert.Equal(t, tc.perWU, perWU.String())
+			assert.Equal(t, tc.perWUBasis, basis)
+		})
+	}
+
+	// No size at all: no rate rather than a silent zero from dividing.
+	rate, basis := tx(0, 0, 0).CalculateFeeRate()
+	assert.True(t, rate.IsZero())
+	assert.Empty(t, basis)
+	perWU, basis := tx(0, 0, 0).FeePerWeightUnit()
+	assert.True(t, perWU.IsZero())
+	assert.Empty(t, basis)
+}
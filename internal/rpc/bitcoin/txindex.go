@@ -0,0 +1,120 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+)
+
+// TxIndexStatus is what is known about a node's -txindex, which
+// getrawtransaction needs to look up arbitrary confirmed transactions such
+// as the ones prevouts come from.
+type TxIndexStatus int32
+
+const (
+	TxIndexUnknown  TxIndexStatus = iota // not probed, or the probe was inconclusive
+	TxIndexEnabled                       // txindex present and synced
+	TxIndexDisabled                      // node runs without txindex
+)
+
+func (s TxIndexStatus) String() string {
+	switch s {
+	case TxIndexEnabled:
+		return "enabled"
+	case TxIndexDisabled:
+		return "disabled"
+	}
+	return "unknown"
+}
+
+// IsTxIndexError reports whether err is Core refusing a getrawtransaction
+// lookup because the node has no txindex. Without txindex Core answers
+// "No such mempool transaction. Use -txindex or provide a block hash ...";
+// a node with txindex only says "No such mempool or blockchain
+// transaction" for a txid that really is unknown, so only the -txindex
+// hint is treated as a capability error.
+func IsTxIndexError(err error) bool {
+	var rpcErr *rpc.RPCError
+	return errors.As(err, &rpcErr) &&
+		rpcErr.Code == rpcInvalidAddressOrKey &&
+		strings.Contains(rpcErr.Message, "-txindex")
+}
+
+// rpcInvalidAddressOrKey is Core's RPC_INVALID_ADDRESS_OR_KEY, used for
+// unknown transactions and blocks.
+const rpcInvalidAddressOrKey = -5
+
+// TxIndex returns the node's txindex status as last probed or observed.
+func (c *BitcoinClient) TxIndex() TxIndexStatus {
+	return TxIndexStatus(c.txIndex.Load())
+}
+
+// ProbeTxIndex asks the node for its txindex state with getindexinfo and
+// records the result. Nodes too old for getindexinfo, or proxies that block
+// it, leave the status unknown and return the error.
+func (c *BitcoinClient) ProbeTxIndex(ctx context.Context) (TxIndexStatus, error) {
+	resp, err := c.CallRPC(ctx, "getindexinfo", []any{"txindex"})
+	if err != nil {
+		return c.TxIndex(), fmt.Errorf("getindexinfo failed: %w", err)
+	}
+
+	var result map[string]IndexInfo
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return c.TxIndex(), fmt.Errorf("failed to unmarshal index info: %w", err)
+	}
+
+	status := txIndexStatus(result)
+	c.txIndex.Store(int32(status))
+	return status, nil
+}
+
+// txIndexStatus reads the txindex status from a getindexinfo result.
+func txIndexStatus(indexes map[string]IndexInfo) TxIndexStatus {
+	info, ok := indexes["txindex"]
+	switch {
+	case !ok:
+		return TxIndexDisabled
+	case info.Synced:
+		return TxIndexEnabled
+	}
+	return TxIndexUnknown // still building; lookups may or may not work
+}
+
+// noteTxIndexError marks the node as lacking txindex when err says so.
+func (c *BitcoinClient) noteTxIndexError(err error) {
+	if IsTxIndexError(err) {
+		c.txIndex.Store(int32(TxIndexDisabled))
+	}
+}
+
+// capabilityTxIndex is the txindex key in Capabilities.
+const capabilityTxIndex = "txindex"
+
+// Capabilities implements rpc.CapabilityClient with the txindex status,
+// once known.
+func (c *BitcoinClient) Capabilities() map[string]string {
+	status := c.TxIndex()
+	if status == TxIndexUnknown {
+		return nil
+	}
+	return map[string]string{capabilityTxIndex: status.String()}
+}
+
+// RestoreCapabilities implements rpc.CapabilityClient. A status already
+// probed or observed in this run is kept.
+func (c *BitcoinClient) RestoreCapabilities(caps map[string]string) {
+	var status TxIndexStatus
+	switch caps[capabilityTxIndex] {
+	case TxIndexEnabled.String():
+		status = TxIndexEnabled
+	case TxIndexDisabled.String():
+		status = TxIndexDisabled
+	default:
+		return
+	}
+	c.txIndex.CompareAndSwap(int32(TxIndexUnknown), int32(status))
+}
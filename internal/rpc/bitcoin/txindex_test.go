@@ -0,0 +1,94 @@
+package bitcoin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTxIndexError(t *testing.T) {
+	noTxIndex := &rpc.RPCError{Code: -5, Message: "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries. Use gettransaction for wallet transactions."}
+	unknownTx := &rpc.RPCError{Code: -5, Message: "No such mempool or blockchain transaction. Use gettransaction for wallet transactions."}
+
+	assert.True(t, bitcoin.IsTxIndexError(noTxIndex))
+	assert.True(t, bitcoin.IsTxIndexError(rpc.WithClass(rpc.ErrNotFound, noTxIndex)))
+	assert.False(t, bitcoin.IsTxIndexError(unknownTx), "txindex nodes report unknown txids this way")
+	assert.False(t, bitcoin.IsTxIndexError(errors.New("-txindex")))
+	assert.False(t, bitcoin.IsTxIndexError(nil))
+}
+
+func TestProbeTxIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		want    bitcoin.TxIndexStatus
+		wantErr bool
+	}{
+		{"enabled", `{"txindex":{"synced":true,"best_block_height":850000}}`, bitcoin.TxIndexEnabled, false},
+		{"syncing", `{"txindex":{"synced":false,"best_block_height":1000}}`, bitcoin.TxIndexUnknown, false},
+		{"disabled", `{}`, bitcoin.TxIndexDisabled, false},
+		{"not supported", "", bitcoin.TxIndexUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.result != "" {
+				require.NoError(t, bitcointest.WriteFixture(dir, "getindexinfo", []any{"txindex"},
+					bitcointest.Fixture{Result: json.RawMessage(tt.result)}))
+			}
+			client := bitcointest.NewClient(bitcointest.NewServer(t, dir))
+			assert.Equal(t, bitcoin.TxIndexUnknown, client.TxIndex())
+
+			got, err := client.ProbeTxIndex(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, client.TxIndex())
+		})
+	}
+}
+
+func TestGetRawTransaction_MarksMissingTxIndex(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, bitcointest.WriteFixture(dir, "getrawtransaction", []any{"aa", 2}, bitcointest.Fixture{
+		Error: &rpc.RPCError{Code: -5, Message: "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries."},
+	}))
+	client := bitcointest.NewClient(bitcointest.NewServer(t, dir))
+
+	_, err := client.GetRawTransaction(context.Background(), "bb", true)
+	require.Error(t, err)
+	assert.Equal(t, bitcoin.TxIndexUnknown, client.TxIndex(), "an ordinary miss says nothing about txindex")
+
+	_, err = client.GetRawTransaction(context.Background(), "aa", true)
+	require.Error(t, err)
+	assert.Equal(t, bitcoin.TxIndexDisabled, client.TxIndex())
+}
+
+func TestCapabilities_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, bitcointest.WriteFixture(dir, "getindexinfo", []any{"txindex"},
+		bitcointest.Fixture{Result: json.RawMessage(`{}`)}))
+	probed := bitcointest.NewClient(bitcointest.NewServer(t, dir))
+	assert.Nil(t, probed.Capabilities(), "nothing to persist before the status is known")
+	_, err := probed.ProbeTxIndex(context.Background())
+	require.NoError(t, err)
+	caps := probed.Capabilities()
+	assert.Equal(t, map[string]string{"txindex": "disabled"}, caps)
+
+	restored := bitcoin.NewBitcoinClient("http://unused", nil, 0, nil)
+	restored.RestoreCapabilities(caps)
+	assert.Equal(t, bitcoin.TxIndexDisabled, restored.TxIndex())
+
+	restored.RestoreCapabilities(map[string]string{"txindex": "enabled"})
+	assert.Equal(t, bitcoin.TxIndexDisabled, restored.TxIndex(), "a known status is not overwritten")
+}
@@ -4,12 +4,28 @@ package bitcoin
 type Block struct {
 	Hash              string        `json:"hash"`
 	Height            uint64        `json:"height"`
+	Version           int32         `json:"version"`
 	PreviousBlockHash string        `json:"previousblockhash"`
+	MerkleRoot        string        `json:"merkleroot"`
 	Time              uint64        `json:"time"`
+	Bits              string        `json:"bits"` // compact target, hex
+	Nonce             uint32        `json:"nonce"`
 	Tx                []Transaction `json:"tx"`
-	Confirmations     uint64        `json:"confirmations"`
+	Confirmations     int64         `json:"confirmations"` // -1 off the main chain
 	Size              int           `json:"size"`
 	Weight            int           `json:"weight"`
+	Difficulty        float64       `json:"difficulty"`
+	NTx               int           `json:"nTx"`
+}
+
+// BlockHeader is a block's getblockheader result.
+type BlockHeader struct {
+	Hash              string `json:"hash"`
+	Height            uint64 `json:"height"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	Time              uint64 `json:"time"`       // miner-set, may go backwards
+	MedianTime        uint64 `json:"mediantime"` // median of the last 11 times, never decreasing
+	Confirmations     int64  `json:"confirmations"`
 }
 
 // Transaction represents a Bitcoin transaction
@@ -17,11 +33,16 @@ type Transaction struct {
 	TxID     string   `json:"txid"`
 	Hash     string   `json:"hash"` // Witness hash
 	Size     int      `json:"size"`
-	VSize    int      `json:"vsize"` // Virtual size (for SegWit)
+	VSize    int      `json:"vsize"`  // Virtual size (for SegWit)
+	Weight   int      `json:"weight"` // BIP-141 weight units
 	Version  int      `json:"version"`
 	LockTime uint64   `json:"locktime"`
 	Vin      []Input  `json:"vin"`
 	Vout     []Output `json:"vout"`
+
+	// BlockHash is the block holding the transaction, as reported by
+	// getrawtransaction; empty in getblock results and for mempool ones.
+	BlockHash string `json:"blockhash,omitempty"`
 }
 
 // Input represents a transaction input
@@ -58,10 +79,23 @@ type ScriptPubKey struct {
 
 // BlockchainInfo represents blockchain information
 type BlockchainInfo struct {
-	Chain         string `json:"chain"`
-	Blocks        uint64 `json:"blocks"`
-	Headers       uint64 `json:"headers"`
-	BestBlockHash string `json:"bestblockhash"`
+	Chain         string   `json:"chain"`
+	Blocks        uint64   `json:"blocks"`
+	Headers       uint64   `json:"headers"`
+	BestBlockHash string   `json:"bestblockhash"`
+	Warnings      Warnings `json:"warnings"`
+	// Softforks is reported by Core before 23.0, which moved it to
+	// getdeploymentinfo.
+	Softforks map[string]Deployment `json:"softforks,omitempty"`
+}
+
+// BlockStats holds the getblockstats fields the indexer requests. Amounts
+// are in satoshis.
+type BlockStats struct {
+	BlockHash string `json:"blockhash"`
+	Height    uint64 `json:"height"`
+	Subsidy   int64  `json:"subsidy"`
+	TotalFee  int64  `json:"totalfee"`
 }
 
 // MempoolEntry represents a mempool transaction entry
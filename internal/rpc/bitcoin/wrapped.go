@@ -0,0 +1,132 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+)
+
+// SpendType classifies how an input spends a P2SH output, which its
+// prevout's scriptPubKey alone cannot tell: a P2SH output may wrap a
+// segwit program (BIP-141 P2SH-P2WPKH and P2SH-P2WSH) or a legacy script.
+type SpendType string
+
+const (
+	SpendP2SHP2WPKH SpendType = "p2sh-p2wpkh"
+	SpendP2SHP2WSH  SpendType = "p2sh-p2wsh"
+	SpendP2SH       SpendType = "p2sh" // legacy redeem script
+)
+
+// WrappedProgram returns the witness program a P2SH-wrapped segwit input
+// reveals: its scriptSig is a single push of a v0 witness program as the
+// redeem script. When the prevout is known, the redeem script must hash to
+// its script hash.
+func WrappedProgram(vin *Input) (version byte, program []byte, ok bool) {
+	if vin == nil {
+		return 0, nil, false
+	}
+	scriptSig, err := hex.DecodeString(vin.ScriptSig.Hex)
+	if err != nil || len(scriptSig) < 2 || int(scriptSig[0]) != len(scriptSig)-1 {
+		return 0, nil, false
+	}
+	redeemScript := scriptSig[1:]
+	version, program, ok = parseWitnessProgram(redeemScript)
+	if !ok || version != 0 {
+		return 0, nil, false
+	}
+	if vin.PrevOut != nil && !bytes.Equal(scriptHashScript(redeemScript), hexBytes(vin.PrevOut.ScriptPubKey.Hex)) {
+		return 0, nil, false
+	}
+	return version, program, true
+}
+
+// ClassifySpend tells wrapped segwit spends of P2SH outputs from legacy
+// ones, checking the witness against the wrapped program: the key hashing
+// to a P2WPKH program, the script hashing to a P2WSH one. It returns "" for
+// inputs that do not spend P2SH outputs, or whose witness does not match.
+func ClassifySpend(vin *Input) SpendType {
+	if vin == nil {
+		return ""
+	}
+	_, program, ok := WrappedProgram(vin)
+	switch {
+	case ok && len(program) == 20:
+		if len(vin.Witness) == 2 && bytes.Equal(hash160(hexBytes(vin.Witness[1])), program) {
+			return SpendP2SHP2WPKH
+		}
+		return ""
+	case ok && len(program) == 32:
+		if n := len(vin.Witness); n > 0 {
+			if script := sha256.Sum256(hexBytes(vin.Witness[n-1])); bytes.Equal(script[:], program) {
+				return SpendP2SHP2WSH
+			}
+		}
+		return ""
+	case vin.PrevOut != nil && vin.PrevOut.ScriptPubKey.Type == "scripthash" && len(vin.Witness) == 0:
+		return SpendP2SH
+	}
+	return ""
+}
+
+// WrappedSegwitAddress returns the P2SH address wrapping witness program
+// version and program on the network params, the address a P2SH-wrapped
+// spend of the program pays from.
+func WrappedSegwitAddress(version byte, program []byte, params NetworkParams) (string, error) {
+	if version > 16 || len(program) < 2 || len(program) > 40 {
+		return "", fmt.Errorf("invalid witness program v%d of %d bytes", version, len(program))
+	}
+	return base58.CheckEncode(hash160(witnessProgramScript(version, program)), params.ScriptHashAddrID), nil
+}
+
+// WrappedAddressOf returns the P2SH address wrapping the program of the
+// native segwit address addr, correlating a key's P2WPKH or script's P2WSH
+// address with its P2SH-wrapped form.
+func WrappedAddressOf(addr string, params NetworkParams) (string, error) {
+	hrp, version, program, err := btcaddr.DecodeSegwitAddress(addr, params.Bech32HRP)
+	if err != nil {
+		return "", err
+	}
+	if hrp != params.Bech32HRP {
+		return "", fmt.Errorf("address %s is not on %s", addr, params.Network)
+	}
+	return WrappedSegwitAddress(version, program, params)
+}
+
+// witnessProgramScript is the scriptPubKey of a native witness program:
+// the version opcode and a push of the program.
+func witnessProgramScript(version byte, program []byte) []byte {
+	op := version
+	if version > 0 {
+		op = 0x50 + version // OP_1 through OP_16
+	}
+	return append([]byte{op, byte(len(program))}, program...)
+}
+
+// parseWitnessProgram is the inverse of witnessProgramScript.
+func parseWitnessProgram(script []byte) (version byte, program []byte, ok bool) {
+	if len(script) < 4 || len(script) > 42 || int(script[1]) != len(script)-2 {
+		return 0, nil, false
+	}
+	switch op := script[0]; {
+	case op == 0x00:
+		return 0, script[2:], true
+	case op >= 0x51 && op <= 0x60:
+		return op - 0x50, script[2:], true
+	}
+	return 0, nil, false
+}
+
+// scriptHashScript is the P2SH scriptPubKey paying to redeemScript.
+func scriptHashScript(redeemScript []byte) []byte {
+	script := append([]byte{0xa9, 0x14}, hash160(redeemScript)...) // OP_HASH160 <20>
+	return append(script, 0x87)                                    // OP_EQUAL
+}
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
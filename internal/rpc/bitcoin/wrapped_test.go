@@ -0,0 +1,150 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Wrapped segwit spends from the BIP-143 examples, witnesses in full.
+const (
+	// P2SH-P2WPKH: the key 03ad1d8e… behind program 79091972….
+	p2wpkhSig          = "3044022047ac8e878352d3ebbde1c94ce3a10d057c24175747116f8288e5d794d12d482f0220217f36a485cae903c713331d877c1f64677e3622ad4010726870540656fe9dcb01"
+	p2wpkhPubKey       = "03ad1d8e89212f0b92c74d23bb710c00662ad1470198ac48c43f7d6f93a2a26873"
+	p2wpkhRedeemScript = "001479091972186c449eb1ded22b78e40d009bdf0089"
+	p2wpkhPrevOutSPK   = "a9144733f37cf4db86fbc2efed2500b4f4e49f31202387"
+	p2wpkhWrapped      = "38BW8nqpHSWpkf5sXrQd2xYwvnPJwP59ic"
+
+	// P2SH-P2WSH: a 6-of-6 multisig witness script.
+	p2wshWitnessScript = "56210307b8ae49ac90a048e9b53357a2354b3334e9c8bee813ecb98e99a7e07e8c3ba3" +
+		"2103b28f0c28bfab54554ae8c658ac5c3e0ce6e79ad336331f78c428dd43eea8449b" +
+		"21034b8113d703413d57761b8b9781957b8c0ac1dfe69f492580ca4195f50376ba4a" +
+		"21033400f6afecb833092a9a21cfdf1ed1376e58c5d1f47de74683123987e967a8f4" +
+		"2103a6d48b1131e94ba04d9737d61acdaa1322008af9602b3b14862c07a1789aac16" +
+		"2102d8b661b0b3302ee2f162b09e07a55ad5dfbe673a9f01d9f0c19617681024306b56ae"
+	p2wshRedeemScript = "0020a16b5755f7f6f96dbd65f5f0d6ab9418b89af4b1f14a1bb8a09062c35f0dcb54"
+	p2wshPrevOutSPK   = "a9149993a429037b5d912407a71c252019287b8d27a587"
+	p2wshWrapped      = "3Fh4BBqrshHn1qc7pFf294rFGbNsXNDcDa"
+)
+
+// p2wshSigs are the six signatures of the P2SH-P2WSH example, in witness
+// order.
+var p2wshSigs = []string{
+	"304402206ac44d672dac41f9b00e28f4df20c52eeb087207e8d758d76d92c6fab3b73e2b0220367750dbbe19290069cba53d096f44530e4f98acaa594810388cf7409a1870ce01",
+	"3044022068c7946a43232757cbdf9176f009a928e1cd9a1a8c212f15c1e11ac9f2925d9002205b75f937ff2f9f3c1246e547e54f62e027f64eefa2695578cc6432cdabce271502",
+	"3044022059ebf56d98010a932cf8ecfec54c48e6139ed6adb0728c09cbe1e4fa0915302e022007cd986c8fa870ff5d2b3a89139c9fe7e499259875357e20fcbb15571c76795403",
+	"3045022100fbefd94bd0a488d50b79102b5dad4ab6ced30c4069f1eaa69a4b5a763414067e02203156c6a5c9cf88f91265f5a942e96213afae16d83321c8b31bb342142a14d16381",
+	"3045022100a5263ea0553ba89221984bd7f0b13613db16e7a70c549a86de0cc0444141a407022005c360ef0ae5a5d4f9f2f87a56c1546cc8268cab08c73501d6b3be2e1e1a8a0882",
+	"30440220525406a1482936d5a21888260dc165497a90a15669636d8edca6b9fe490d309c022032af0c646a34a44d1f4576bf6a4a74b67940f8faa84c7df9abe12a01a11e2b4783",
+}
+
+func p2shPrevOut(spk string) *Output {
+	return &Output{Value: 10, ScriptPubKey: ScriptPubKey{Type: "scripthash", Hex: spk}}
+}
+
+func p2shP2WPKHInput() *Input {
+	return &Input{
+		ScriptSig: ScriptSig{Hex: "16" + p2wpkhRedeemScript},
+		Witness:   []string{p2wpkhSig, p2wpkhPubKey},
+		PrevOut:   p2shPrevOut(p2wpkhPrevOutSPK),
+	}
+}
+
+func p2shP2WSHInput() *Input {
+	witness := append([]string{""}, p2wshSigs...) // "" is CHECKMULTISIG's extra pop
+	return &Input{
+		ScriptSig: ScriptSig{Hex: "22" + p2wshRedeemScript},
+		Witness:   append(witness, p2wshWitnessScript),
+		PrevOut:   p2shPrevOut(p2wshPrevOutSPK),
+	}
+}
+
+func TestClassifySpend(t *testing.T) {
+	assert.Equal(t, SpendP2SHP2WPKH, ClassifySpend(p2shP2WPKHInput()))
+	assert.Equal(t, SpendP2SHP2WSH, ClassifySpend(p2shP2WSHInput()))
+
+	// Without the prevout the redeem script alone decides.
+	vin := p2shP2WPKHInput()
+	vin.PrevOut = nil
+	assert.Equal(t, SpendP2SHP2WPKH, ClassifySpend(vin))
+
+	legacy := &Input{
+		ScriptSig: ScriptSig{Hex: "00483045"},
+		PrevOut:   p2shPrevOut(p2wpkhPrevOutSPK),
+	}
+	assert.Equal(t, SpendP2SH, ClassifySpend(legacy))
+
+	native := &Input{
+		Witness: []string{p2wpkhSig, p2wpkhPubKey},
+		PrevOut: &Output{ScriptPubKey: ScriptPubKey{Type: "witness_v0_keyhash", Hex: p2wpkhRedeemScript}},
+	}
+	assert.Empty(t, ClassifySpend(native))
+	assert.Empty(t, ClassifySpend(nil))
+}
+
+func TestClassifySpend_Mismatches(t *testing.T) {
+	// The witness key does not hash to the program.
+	vin := p2shP2WPKHInput()
+	vin.Witness[1] = "02" + p2wpkhPubKey[2:]
+	assert.Empty(t, ClassifySpend(vin))
+
+	// The witness script does not hash to the program.
+	vin = p2shP2WSHInput()
+	vin.Witness[len(vin.Witness)-1] = p2wshWitnessScript[:len(p2wshWitnessScript)-2] + "ad"
+	assert.Empty(t, ClassifySpend(vin))
+
+	// The redeem script does not hash to the prevout's script hash.
+	vin = p2shP2WPKHInput()
+	vin.PrevOut = p2shPrevOut(p2wshPrevOutSPK)
+	assert.Empty(t, ClassifySpend(vin))
+	_, _, ok := WrappedProgram(vin)
+	assert.False(t, ok)
+}
+
+func TestWrappedProgram(t *testing.T) {
+	version, program, ok := WrappedProgram(p2shP2WSHInput())
+	require.True(t, ok)
+	assert.Equal(t, byte(0), version)
+	assert.Equal(t, p2wshRedeemScript[4:], hex.EncodeToString(program))
+}
+
+func TestWrappedSegwitAddress(t *testing.T) {
+	mainnet, err := ParamsFor(NetworkMainnet)
+	require.NoError(t, err)
+
+	got, err := WrappedSegwitAddress(0, hexBytes(p2wpkhRedeemScript[4:]), mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, p2wpkhWrapped, got)
+
+	got, err = WrappedSegwitAddress(0, hexBytes(p2wshRedeemScript[4:]), mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, p2wshWrapped, got)
+
+	testnet, err := ParamsFor(NetworkTestnet3)
+	require.NoError(t, err)
+	got, err = WrappedSegwitAddress(0, hexBytes(p2wpkhRedeemScript[4:]), testnet)
+	require.NoError(t, err)
+	assert.Equal(t, "2MyjiCXmqtu2AxSiRCz2VeuYD98bUhXRzNR", got)
+
+	_, err = WrappedSegwitAddress(0, []byte{1}, mainnet)
+	assert.Error(t, err)
+}
+
+func TestWrappedAddressOf(t *testing.T) {
+	mainnet, err := ParamsFor(NetworkMainnet)
+	require.NoError(t, err)
+
+	native, err := btcaddr.EncodeSegwitAddress(mainnet.Bech32HRP, 0, hexBytes(p2wpkhRedeemScript[4:]))
+	require.NoError(t, err)
+	got, err := WrappedAddressOf(native, mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, p2wpkhWrapped, got)
+
+	testnet, err := ParamsFor(NetworkTestnet3)
+	require.NoError(t, err)
+	_, err = WrappedAddressOf(native, testnet)
+	assert.Error(t, err)
+}
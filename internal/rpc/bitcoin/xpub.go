@@ -0,0 +1,173 @@
+package bitcoin
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// DerivationScheme selects the address type derived from an account-level
+// extended public key, after the BIP defining its derivation path.
+type DerivationScheme string
+
+const (
+	SchemeBIP44 DerivationScheme = "bip44" // P2PKH
+	SchemeBIP49 DerivationScheme = "bip49" // P2SH-P2WPKH
+	SchemeBIP84 DerivationScheme = "bip84" // P2WPKH
+	SchemeBIP86 DerivationScheme = "bip86" // P2TR, key path only
+)
+
+// Branches of an account key: receive addresses and change addresses.
+const (
+	BranchExternal uint32 = 0
+	BranchChange   uint32 = 1
+)
+
+// hardenedOffset is the first hardened child index; hardened children need
+// the private key and cannot be derived here.
+const hardenedOffset = 1 << 31
+
+var (
+	ErrInvalidExtendedKey = errors.New("invalid extended public key")
+	ErrPrivateExtendedKey = errors.New("extended private keys are not accepted")
+)
+
+// extendedKeyVersion describes a SLIP-132 version prefix.
+type extendedKeyVersion struct {
+	prefix  string
+	testnet bool
+	scheme  DerivationScheme
+	private bool
+}
+
+var extendedKeyVersions = map[uint32]extendedKeyVersion{
+	0x0488b21e: {"xpub", false, SchemeBIP44, false},
+	0x049d7cb2: {"ypub", false, SchemeBIP49, false},
+	0x04b24746: {"zpub", false, SchemeBIP84, false},
+	0x043587cf: {"tpub", true, SchemeBIP44, false},
+	0x044a5262: {"upub", true, SchemeBIP49, false},
+	0x045f1cf6: {"vpub", true, SchemeBIP84, false},
+	0x0488ade4: {"xprv", false, SchemeBIP44, true},
+	0x049d7878: {"yprv", false, SchemeBIP49, true},
+	0x04b2430c: {"zprv", false, SchemeBIP84, true},
+	0x04358394: {"tprv", true, SchemeBIP44, true},
+	0x044a4e28: {"uprv", true, SchemeBIP49, true},
+	0x045f18bc: {"vprv", true, SchemeBIP84, true},
+}
+
+// ExtendedPublicKey is a BIP-32 extended public key. Only public (non-
+// hardened) derivation is supported, so it never involves a private key.
+type ExtendedPublicKey struct {
+	// Testnet reports a testnet version prefix (tpub, upub, vpub).
+	Testnet bool
+	// Scheme is the scheme implied by the version prefix: xpub and tpub
+	// keys imply BIP-44 but are also used for BIP-86.
+	Scheme DerivationScheme
+
+	key *hdkeychain.ExtendedKey
+}
+
+// ParseExtendedPublicKey decodes a base58check xpub, ypub, zpub or their
+// testnet counterparts tpub, upub, vpub.
+func ParseExtendedPublicKey(s string) (*ExtendedPublicKey, error) {
+	key, err := hdkeychain.NewKeyFromString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExtendedKey, err)
+	}
+	version, ok := extendedKeyVersions[binary.BigEndian.Uint32(key.Version())]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: unknown version 0x%x", ErrInvalidExtendedKey, key.Version())
+	case version.private || key.IsPrivate():
+		return nil, fmt.Errorf("%w: got %s", ErrPrivateExtendedKey, version.prefix)
+	}
+	return &ExtendedPublicKey{Testnet: version.testnet, Scheme: version.scheme, key: key}, nil
+}
+
+// Child derives the non-hardened child i of k (BIP-32 CKDpub). An invalid
+// child has odds below 2^-127; BIP-32 says to move on to the next index,
+// left to the caller here.
+func (k *ExtendedPublicKey) Child(i uint32) (*ExtendedPublicKey, error) {
+	if i >= hardenedOffset {
+		return nil, fmt.Errorf("cannot derive hardened child %d from a public key", i-hardenedOffset)
+	}
+	child, err := k.key.Derive(i)
+	if err != nil {
+		return nil, fmt.Errorf("%w: child %d: %v", ErrInvalidExtendedKey, i, err)
+	}
+	return &ExtendedPublicKey{Testnet: k.Testnet, Scheme: k.Scheme, key: child}, nil
+}
+
+// PublicKey returns k's compressed public key.
+func (k *ExtendedPublicKey) PublicKey() []byte {
+	pub, _ := k.key.ECPubKey() // parsed when k was decoded or derived
+	return pub.SerializeCompressed()
+}
+
+// Address encodes k's public key as a scheme address on the network params.
+func (k *ExtendedPublicKey) Address(scheme DerivationScheme, params NetworkParams) (string, error) {
+	switch scheme {
+	case SchemeBIP44:
+		return base58.CheckEncode(hash160(k.PublicKey()), params.PubKeyHashAddrID), nil
+	case SchemeBIP49:
+		return WrappedSegwitAddress(0, hash160(k.PublicKey()), params)
+	case SchemeBIP84:
+		return btcaddr.EncodeSegwitAddress(params.Bech32HRP, 0, hash160(k.PublicKey()))
+	case SchemeBIP86:
+		pub, err := k.key.ECPubKey()
+		if err != nil {
+			return "", err
+		}
+		outputKey := txscript.ComputeTaprootKeyNoScript(pub)
+		return btcaddr.EncodeSegwitAddress(params.Bech32HRP, 1, schnorr.SerializePubKey(outputKey))
+	}
+	return "", fmt.Errorf("unknown derivation scheme %q", scheme)
+}
+
+// DeriveAddresses derives count addresses of branch (BranchExternal or
+// BranchChange) of account key k, starting at index start, i.e. the
+// addresses at paths k/branch/start through k/branch/start+count-1.
+// The same key, scheme and indexes always yield the same addresses.
+func DeriveAddresses(
+	k *ExtendedPublicKey,
+	scheme DerivationScheme,
+	params NetworkParams,
+	branch, start, count uint32,
+) ([]string, error) {
+	if k.Testnet != (params.Network != NetworkMainnet) {
+		return nil, fmt.Errorf("extended key is for another network than %s", params.Network)
+	}
+	branchKey, err := k.Child(branch)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, count)
+	for i := start; i < start+count; i++ {
+		child, err := branchKey.Child(i)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := child.Address(scheme, params)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func hash160(b []byte) []byte {
+	sha := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
@@ -0,0 +1,133 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Account keys of the BIP-44/49/84/86 test vectors, for the mnemonic
+// "abandon abandon ... about".
+const (
+	bip44Xpub = "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj"
+	bip49Ypub = "ypub6Ww3ibxVfGzLrAH1PNcjyAWenMTbbAosGNB6VvmSEgytSER9azLDWCxoJwW7Ke7icmizBMXrzBx9979FfaHxHcrArf3zbeJJJUZPf663zsP"
+	bip84Zpub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	bip86Xpub = "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+)
+
+func TestDeriveAddresses_TestVectors(t *testing.T) {
+	mainnet, err := ParamsFor(NetworkMainnet)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		key    string
+		scheme DerivationScheme
+		branch uint32
+		want   []string
+	}{
+		{"bip44 receive", bip44Xpub, SchemeBIP44, BranchExternal, []string{
+			"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+			"1Ak8PffB2meyfYnbXZR9EGfLfFZVpzJvQP",
+		}},
+		{"bip44 change", bip44Xpub, SchemeBIP44, BranchChange, []string{
+			"1J3J6EvPrv8q6AC3VCjWV45Uf3nssNMRtH",
+		}},
+		{"bip49 receive", bip49Ypub, SchemeBIP49, BranchExternal, []string{
+			"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+		}},
+		{"bip84 receive", bip84Zpub, SchemeBIP84, BranchExternal, []string{
+			"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+			"bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g",
+		}},
+		{"bip84 change", bip84Zpub, SchemeBIP84, BranchChange, []string{
+			"bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el",
+		}},
+		{"bip86 receive", bip86Xpub, SchemeBIP86, BranchExternal, []string{
+			"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+			"bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+		}},
+		{"bip86 change", bip86Xpub, SchemeBIP86, BranchChange, []string{
+			"bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ParseExtendedPublicKey(tc.key)
+			require.NoError(t, err)
+			got, err := DeriveAddresses(key, tc.scheme, mainnet, tc.branch, 0, uint32(len(tc.want)))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDeriveAddresses_StartOffset(t *testing.T) {
+	mainnet, _ := ParamsFor(NetworkMainnet)
+	key, err := ParseExtendedPublicKey(bip84Zpub)
+	require.NoError(t, err)
+
+	all, err := DeriveAddresses(key, SchemeBIP84, mainnet, BranchExternal, 0, 5)
+	require.NoError(t, err)
+	tail, err := DeriveAddresses(key, SchemeBIP84, mainnet, BranchExternal, 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, all[3:], tail)
+}
+
+func TestParseExtendedPublicKey_Versions(t *testing.T) {
+	key, err := ParseExtendedPublicKey(bip84Zpub)
+	require.NoError(t, err)
+	assert.Equal(t, SchemeBIP84, key.Scheme)
+	assert.False(t, key.Testnet)
+
+	// The same key under the vpub prefix derives testnet addresses.
+	vpub := reversion(t, bip84Zpub, []byte{0x04, 0x5f, 0x1c, 0xf6})
+	key, err = ParseExtendedPublicKey(vpub)
+	require.NoError(t, err)
+	assert.True(t, key.Testnet)
+	assert.Equal(t, SchemeBIP84, key.Scheme)
+
+	testnet, _ := ParamsFor(NetworkTestnet4)
+	addrs, err := DeriveAddresses(key, SchemeBIP84, testnet, BranchExternal, 0, 1)
+	require.NoError(t, err)
+	_, _, program, err := btcaddr.DecodeSegwitAddress(addrs[0])
+	require.NoError(t, err)
+	mainnet, _ := ParamsFor(NetworkMainnet)
+	mainAddr, err := btcaddr.EncodeSegwitAddress(mainnet.Bech32HRP, 0, program)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", mainAddr)
+
+	_, err = DeriveAddresses(key, SchemeBIP84, mainnet, BranchExternal, 0, 1)
+	assert.Error(t, err, "testnet key on mainnet")
+}
+
+func TestParseExtendedPublicKey_Rejects(t *testing.T) {
+	_, err := ParseExtendedPublicKey(reversion(t, bip44Xpub, []byte{0x04, 0x88, 0xad, 0xe4}))
+	assert.ErrorIs(t, err, ErrPrivateExtendedKey)
+
+	_, err = ParseExtendedPublicKey(bip44Xpub[:len(bip44Xpub)-1] + "k")
+	assert.ErrorIs(t, err, ErrInvalidExtendedKey)
+
+	_, err = ParseExtendedPublicKey("not a key")
+	assert.ErrorIs(t, err, ErrInvalidExtendedKey)
+}
+
+func TestChild_RejectsHardened(t *testing.T) {
+	key, err := ParseExtendedPublicKey(bip44Xpub)
+	require.NoError(t, err)
+	_, err = key.Child(hardenedOffset)
+	assert.Error(t, err)
+}
+
+// reversion re-encodes the extended key s under another version prefix.
+func reversion(t *testing.T, s string, version []byte) string {
+	t.Helper()
+	decoded := base58.Decode(s)
+	require.Len(t, decoded, 82)
+	payload := append(append([]byte{}, version...), decoded[4:78]...)
+	// CheckEncode prepends a one-byte version, so pass the first byte apart.
+	return base58.CheckEncode(payload[1:], payload[0])
+}
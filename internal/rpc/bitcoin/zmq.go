@@ -0,0 +1,172 @@
+package bitcoin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// zmqHandshakeTimeout bounds the connection and ZMTP handshake with the
+// node.
+const zmqHandshakeTimeout = 10 * time.Second
+
+// zmqMaxFrame caps the frames accepted from the node; hashblock frames are
+// at most 32 bytes.
+const zmqMaxFrame = 1 << 16
+
+const (
+	zmtpMore    = 0x01
+	zmtpLong    = 0x02
+	zmtpCommand = 0x04
+)
+
+// SubscribeHashBlock subscribes to the hashblock topic of a bitcoind
+// -zmqpubhashblock endpoint, e.g. tcp://127.0.0.1:28332, and delivers the
+// hash of each block connected to the node's chain. It speaks ZMTP 3.0 as
+// a SUB socket with the NULL mechanism. The channel is closed when ctx
+// ends or the connection fails.
+func SubscribeHashBlock(ctx context.Context, endpoint string) (<-chan string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "tcp" || u.Host == "" {
+		return nil, fmt.Errorf("zmq endpoint %q: want tcp://host:port", endpoint)
+	}
+	dialer := net.Dialer{Timeout: zmqHandshakeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("zmq dial: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	_ = conn.SetDeadline(time.Now().Add(zmqHandshakeTimeout))
+	if err := zmtpHandshake(conn, r, "hashblock"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("zmq handshake: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	hashes := make(chan string, 1)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	go func() {
+		defer close(hashes)
+		defer stop()
+		defer conn.Close()
+		for {
+			parts, err := zmtpReadMessage(r)
+			if err != nil {
+				return
+			}
+			// topic, 32-byte hash in display order, 4-byte sequence
+			if len(parts) < 2 || string(parts[0]) != "hashblock" || len(parts[1]) != 32 {
+				continue
+			}
+			select {
+			case hashes <- hex.EncodeToString(parts[1]):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return hashes, nil
+}
+
+// zmtpHandshake exchanges greetings and READY commands as a SUB socket,
+// then subscribes to topic.
+func zmtpHandshake(w io.Writer, r *bufio.Reader, topic string) error {
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9] = 0xff, 0x7f
+	greeting[10], greeting[11] = 3, 0 // ZMTP 3.0
+	copy(greeting[12:32], "NULL")
+	if _, err := w.Write(greeting); err != nil {
+		return err
+	}
+	peer := make([]byte, 64)
+	if _, err := io.ReadFull(r, peer); err != nil {
+		return err
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f || peer[10] < 3 {
+		return errors.New("peer does not speak ZMTP 3")
+	}
+	if mechanism := string(bytes.TrimRight(peer[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("unsupported security mechanism %q", mechanism)
+	}
+
+	var ready bytes.Buffer
+	ready.WriteByte(5)
+	ready.WriteString("READY")
+	ready.WriteByte(11)
+	ready.WriteString("Socket-Type")
+	_ = binary.Write(&ready, binary.BigEndian, uint32(3))
+	ready.WriteString("SUB")
+	if err := zmtpWriteFrame(w, zmtpCommand, ready.Bytes()); err != nil {
+		return err
+	}
+	flags, body, err := zmtpReadFrame(r)
+	if err != nil {
+		return err
+	}
+	if flags&zmtpCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return errors.New("peer did not send READY")
+	}
+	// ZMTP 3.0 subscriptions are messages starting with 1.
+	return zmtpWriteFrame(w, 0, append([]byte{1}, topic...))
+}
+
+func zmtpWriteFrame(w io.Writer, flags byte, body []byte) error {
+	var frame []byte
+	if len(body) > 255 {
+		frame = binary.BigEndian.AppendUint64([]byte{flags | zmtpLong}, uint64(len(body)))
+	} else {
+		frame = []byte{flags, byte(len(body))}
+	}
+	_, err := w.Write(append(frame, body...))
+	return err
+}
+
+func zmtpReadFrame(r *bufio.Reader) (flags byte, body []byte, err error) {
+	if flags, err = r.ReadByte(); err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&zmtpLong != 0 {
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return 0, nil, err
+		}
+	} else {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > zmqMaxFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes over the %d byte limit", size, zmqMaxFrame)
+	}
+	body = make([]byte, size)
+	_, err = io.ReadFull(r, body)
+	return flags, body, err
+}
+
+// zmtpReadMessage reads the frames of the next message, skipping commands.
+func zmtpReadMessage(r *bufio.Reader) ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := zmtpReadFrame(r)
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmtpCommand != 0 {
+			continue
+		}
+		parts = append(parts, body)
+		if flags&zmtpMore == 0 {
+			return parts, nil
+		}
+	}
+}
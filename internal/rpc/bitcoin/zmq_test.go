@@ -0,0 +1,89 @@
+package bitcoin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zmqPublisher accepts one subscriber as bitcoind's PUB socket would, and
+// publishes hashes once it subscribed.
+func zmqPublisher(t *testing.T, hashes ...string) (endpoint string, subscribed <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	topics := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		greeting := make([]byte, 64)
+		if _, err := io.ReadFull(r, greeting); err != nil {
+			return
+		}
+		greeting[11] = 1 // ZMTP 3.1
+		greeting[32] = 1 // as-server
+		conn.Write(greeting)
+		if _, _, err := zmtpReadFrame(r); err != nil {
+			return
+		}
+		zmtpWriteFrame(conn, zmtpCommand, []byte("\x05READY\x0bSocket-Type\x00\x00\x00\x03PUB"))
+		_, sub, err := zmtpReadFrame(r)
+		if err != nil {
+			return
+		}
+		topics <- string(sub)
+		for i, hash := range hashes {
+			raw, _ := hex.DecodeString(hash)
+			zmtpWriteFrame(conn, zmtpMore, []byte("hashblock"))
+			zmtpWriteFrame(conn, zmtpMore, raw)
+			zmtpWriteFrame(conn, 0, []byte{byte(i), 0, 0, 0})
+		}
+	}()
+	return "tcp://" + ln.Addr().String(), topics
+}
+
+func TestSubscribeHashBlock(t *testing.T) {
+	hash := "00000000000000000001" + hex.EncodeToString(bytes.Repeat([]byte{0xab}, 22))
+	endpoint, subscribed := zmqPublisher(t, hash, hash)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hashes, err := SubscribeHashBlock(ctx, endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "\x01hashblock", <-subscribed)
+
+	var got []string
+	for h := range hashes {
+		got = append(got, h)
+	}
+	assert.Equal(t, []string{hash, hash}, got, "the channel closes with the connection")
+}
+
+func TestSubscribeHashBlock_Rejects(t *testing.T) {
+	_, err := SubscribeHashBlock(context.Background(), "http://127.0.0.1:28332")
+	assert.ErrorContains(t, err, "want tcp://host:port")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n" + string(make([]byte, 64))))
+			conn.Close()
+		}
+	}()
+	_, err = SubscribeHashBlock(context.Background(), "tcp://"+ln.Addr().String())
+	assert.ErrorContains(t, err, "ZMTP 3")
+}
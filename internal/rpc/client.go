@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -38,6 +39,7 @@ type BaseClient struct {
 	auth          *AuthConfig
 	rateLimiter   *ratelimiter.PooledRateLimiter
 	customHeaders map[string]string
+	maxResponse   int64
 	mu            sync.Mutex
 }
 
@@ -77,6 +79,9 @@ func (c *BaseClient) CallRPC(ctx context.Context, method string, params any) (*R
 
 	req := &RPCRequest{ID: id, JSONRPC: "2.0", Method: method, Params: params}
 	raw, err := c.Do(ctx, http.MethodPost, "", req, nil)
+	if rpcErr := rpcErrorFromHTTP(err, raw); rpcErr != nil {
+		return nil, fmt.Errorf("%s RPC error: %w", method, rpcErr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s failed: %w", method, err)
 	}
@@ -91,14 +96,86 @@ func (c *BaseClient) CallRPC(ctx context.Context, method string, params any) (*R
 	return &resp, nil
 }
 
+// CallRPCStream sends a JSON-RPC request like CallRPC, but hands the result
+// to decodeResult as it is read off the response body instead of buffering
+// it, for results too large to hold twice in memory. decodeResult must read
+// exactly one JSON value from dec, which is null when the call failed.
+func (c *BaseClient) CallRPCStream(
+	ctx context.Context,
+	method string,
+	params any,
+	decodeResult func(dec *json.Decoder) error,
+) error {
+	if c.clientType != ClientTypeRPC {
+		return fmt.Errorf("client is %s, not RPC", c.clientType)
+	}
+	c.mu.Lock()
+	id := c.rpcID
+	c.rpcID++
+	c.mu.Unlock()
+
+	req := &RPCRequest{ID: id, JSONRPC: "2.0", Method: method, Params: params}
+	resp, err := c.send(ctx, http.MethodPost, "", req, nil)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		raw, err := c.readBody(resp)
+		if rpcErr := rpcErrorFromHTTP(err, raw); rpcErr != nil {
+			return fmt.Errorf("%s RPC error: %w", method, rpcErr)
+		}
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	if err := decodeRPCStream(newLimitedReader(resp.Body, c.maxResponse), decodeResult); err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return fmt.Errorf("%s RPC error: %w", method, err)
+		}
+		return classifyTransportError(fmt.Errorf("%s decode error: %w", method, err))
+	}
+	return nil
+}
+
+// rpcErrorFromHTTP returns the JSON-RPC error carried by a non-2xx response,
+// or nil when err is not an HTTPError or raw holds none. Bitcoin Core
+// answers a failed call with HTTP 404 or 500 and the error in the body.
+func rpcErrorFromHTTP(err error, raw []byte) *RPCError {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || len(raw) == 0 {
+		return nil
+	}
+	var resp RPCResponse
+	if json.Unmarshal(raw, &resp) != nil {
+		return nil
+	}
+	return resp.Error
+}
+
 func (c *BaseClient) doRaw(
 	ctx context.Context,
 	method, endpoint string,
 	body any,
 	params map[string]string,
 ) ([]byte, error) {
+	resp, err := c.send(ctx, method, endpoint, body, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return c.readBody(resp)
+}
+
+// send issues the HTTP request; the caller must close the response body.
+func (c *BaseClient) send(
+	ctx context.Context,
+	method, endpoint string,
+	body any,
+	params map[string]string,
+) (*http.Response, error) {
 	if c.rateLimiter != nil {
-		if err := c.rateLimiter.Wait(ctx, c.baseURL); err != nil {
+		if err := c.WaitRateLimit(ctx); err != nil {
 			return nil, fmt.Errorf("rate limit: %w", err)
 		}
 	}
@@ -147,11 +224,19 @@ func (c *BaseClient) doRaw(
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, classifyTransportError(fmt.Errorf("HTTP request failed: %w", err))
 	}
-	defer resp.Body.Close()
+	if c.maxResponse > 0 && resp.ContentLength > c.maxResponse {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %w", resp.StatusCode, responseTooLarge(c.maxResponse))
+	}
+	return resp, nil
+}
 
-	data, err := io.ReadAll(resp.Body)
+// readBody reads the whole response body, failing non-2xx responses with an
+// HTTPError.
+func (c *BaseClient) readBody(resp *http.Response) ([]byte, error) {
+	data, err := io.ReadAll(newLimitedReader(resp.Body, c.maxResponse))
 	if err != nil {
 		return data, fmt.Errorf("HTTP %d: failed to read response body: %w", resp.StatusCode, err)
 	}
@@ -162,7 +247,7 @@ func (c *BaseClient) doRaw(
 			bodyStr = "(empty response body)"
 		}
 		// Return full error message without truncation
-		return data, fmt.Errorf("HTTP %s: %s", resp.Status, bodyStr)
+		return data, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: bodyStr}
 	}
 
 	if len(data) == 0 {
@@ -241,12 +326,22 @@ func (c *BaseClient) NextRequestIDs(n int) []int64 {
 	return ids
 }
 
-// WaitRateLimit blocks until rate limiter allows request
+// WaitRateLimit blocks until rate limiter allows request. A wait that would
+// eat too much of ctx's deadline fails at once with ErrRateLimited and
+// ErrThrottled, so failover can move on to a less contended node; an
+// optional request refused by the request budget fails with ErrOverBudget.
 func (c *BaseClient) WaitRateLimit(ctx context.Context) error {
 	if c.rateLimiter == nil {
 		return nil
 	}
-	return c.rateLimiter.Wait(ctx, c.baseURL)
+	err := c.rateLimiter.Wait(ctx, c.baseURL)
+	switch {
+	case errors.Is(err, ratelimiter.ErrWaitBudgetExceeded):
+		return WithClass(ErrRateLimited, WithClass(ErrThrottled, err))
+	case errors.Is(err, ratelimiter.ErrBudgetExhausted):
+		return WithClass(ErrOverBudget, err)
+	}
+	return err
 }
 
 // SetCustomHeaders sets additional HTTP headers to include in every request.
@@ -254,6 +349,12 @@ func (c *BaseClient) SetCustomHeaders(headers map[string]string) {
 	c.customHeaders = headers
 }
 
+// SetMaxResponseSize caps the size of response bodies; reading past n bytes
+// fails the call with ErrResponseTooLarge. 0, the default, means no limit.
+func (c *BaseClient) SetMaxResponseSize(n int64) {
+	c.maxResponse = n
+}
+
 func (c *BaseClient) GetNetworkType() string { return c.network }
 func (c *BaseClient) GetClientType() string  { return c.clientType }
 func (c *BaseClient) GetURL() string         { return c.baseURL }
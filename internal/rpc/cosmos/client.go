@@ -58,6 +58,15 @@ func (c *Client) GetLatestHeight(ctx context.Context) (uint64, error) {
 	return height, nil
 }
 
+// ChainID returns the CometBFT network the node is on, e.g. cosmoshub-4.
+func (c *Client) ChainID(ctx context.Context) (string, error) {
+	result, err := getResponse[StatusResponse](ctx, c, "/status", nil)
+	if err != nil {
+		return "", err
+	}
+	return result.NodeInfo.Network, nil
+}
+
 func (c *Client) GetBlock(ctx context.Context, height uint64) (*BlockResponse, error) {
 	return getResponse[BlockResponse](ctx, c, "/block", map[string]string{
 		"height": strconv.FormatUint(height, 10),
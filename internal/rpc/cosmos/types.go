@@ -1,9 +1,14 @@
 package cosmos
 
 type StatusResponse struct {
+	NodeInfo NodeInfo `json:"node_info"`
 	SyncInfo SyncInfo `json:"sync_info"`
 }
 
+type NodeInfo struct {
+	Network string `json:"network"`
+}
+
 type SyncInfo struct {
 	LatestBlockHeight string `json:"latest_block_height"`
 }
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+	"golang.org/x/sync/singleflight"
+)
+
+type freshReadKey struct{}
+
+// WithFreshRead returns a context whose calls bypass CallGroup
+// deduplication, for callers that must not share an in-flight result.
+func WithFreshRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshReadKey{}, true)
+}
+
+func isFreshRead(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshReadKey{}).(bool)
+	return fresh
+}
+
+// CallGroup collapses concurrent identical JSON-RPC reads into one request:
+// callers asking for the same method and params while a request is in
+// flight wait for it and all get its response or error. Only methods listed
+// as read-only are shared; everything else, and calls on a WithFreshRead
+// context, go straight through.
+type CallGroup struct {
+	group    singleflight.Group
+	readOnly map[string]bool
+}
+
+// NewCallGroup returns a CallGroup sharing calls to the given read-only
+// methods.
+func NewCallGroup(readOnly ...string) *CallGroup {
+	g := &CallGroup{readOnly: make(map[string]bool, len(readOnly))}
+	for _, method := range readOnly {
+		g.readOnly[method] = true
+	}
+	return g
+}
+
+// Do runs call for method and params, sharing it with identical concurrent
+// calls when method is read-only. The shared request runs detached from any
+// one caller's cancellation so an impatient caller cannot fail the others;
+// each caller still stops waiting when its own ctx is done. Optional calls,
+// see ratelimiter.WithOptional, are only shared with one another, so a
+// request budget refusing one cannot fail a call that must go through.
+func (g *CallGroup) Do(
+	ctx context.Context,
+	method string,
+	params any,
+	call func(ctx context.Context) (*RPCResponse, error),
+) (*RPCResponse, error) {
+	if !g.readOnly[method] || isFreshRead(ctx) {
+		return call(ctx)
+	}
+	key, err := callKey(method, params)
+	if err != nil {
+		return call(ctx)
+	}
+	if ratelimiter.IsOptional(ctx) {
+		key += ":optional"
+	}
+
+	ch := g.group.DoChan(key, func() (any, error) {
+		return call(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-ch:
+		resp, _ := res.Val.(*RPCResponse)
+		return resp, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func callKey(method string, params any) (string, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return method + ":" + hex.EncodeToString(sum[:]), nil
+}
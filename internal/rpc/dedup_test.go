@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedCall counts invocations and blocks each until release is closed.
+type gatedCall struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	resp    *RPCResponse
+	err     error
+}
+
+func newGatedCall(resp *RPCResponse, err error) *gatedCall {
+	return &gatedCall{started: make(chan struct{}, 16), release: make(chan struct{}), resp: resp, err: err}
+}
+
+func (g *gatedCall) call(context.Context) (*RPCResponse, error) {
+	g.calls.Add(1)
+	g.started <- struct{}{}
+	<-g.release
+	return g.resp, g.err
+}
+
+// runConcurrent starts n callers of group.Do and waits until the first
+// call is in flight.
+func runConcurrent(
+	t *testing.T,
+	ctx context.Context,
+	group *CallGroup,
+	n int,
+	method string,
+	g *gatedCall,
+) (wait func() ([]*RPCResponse, []error)) {
+	t.Helper()
+	resps := make([]*RPCResponse, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = group.Do(ctx, method, []any{"txid", 2}, g.call)
+		}()
+	}
+	<-g.started
+	return func() ([]*RPCResponse, []error) {
+		wg.Wait()
+		return resps, errs
+	}
+}
+
+func TestCallGroup_SharesConcurrentReads(t *testing.T) {
+	want := &RPCResponse{Result: json.RawMessage(`"ok"`)}
+	g := newGatedCall(want, nil)
+	group := NewCallGroup("getrawtransaction")
+
+	wait := runConcurrent(t, context.Background(), group, 5, "getrawtransaction", g)
+	time.Sleep(20 * time.Millisecond) // let the other callers join the flight
+	close(g.release)
+	resps, errs := wait()
+
+	assert.Equal(t, int32(1), g.calls.Load())
+	for i := range resps {
+		require.NoError(t, errs[i])
+		assert.Same(t, want, resps[i])
+	}
+}
+
+func TestCallGroup_SharesError(t *testing.T) {
+	boom := errors.New("boom")
+	g := newGatedCall(nil, boom)
+	group := NewCallGroup("getrawtransaction")
+
+	wait := runConcurrent(t, context.Background(), group, 3, "getrawtransaction", g)
+	time.Sleep(20 * time.Millisecond)
+	close(g.release)
+	_, errs := wait()
+
+	assert.Equal(t, int32(1), g.calls.Load())
+	for _, err := range errs {
+		assert.ErrorIs(t, err, boom)
+	}
+}
+
+func TestCallGroup_WritesAndFreshReadsBypass(t *testing.T) {
+	group := NewCallGroup("getrawtransaction")
+
+	g := newGatedCall(&RPCResponse{}, nil)
+	close(g.release)
+	wait := runConcurrent(t, context.Background(), group, 3, "sendrawtransaction", g)
+	wait()
+	assert.Equal(t, int32(3), g.calls.Load(), "methods not listed as read-only are never shared")
+
+	g = newGatedCall(&RPCResponse{}, nil)
+	close(g.release)
+	wait = runConcurrent(t, WithFreshRead(context.Background()), group, 3, "getrawtransaction", g)
+	wait()
+	assert.Equal(t, int32(3), g.calls.Load(), "WithFreshRead bypasses sharing")
+}
+
+func TestCallGroup_OptionalCallsSharedOnlyWithEachOther(t *testing.T) {
+	group := NewCallGroup("getrawtransaction")
+	refused := newGatedCall(nil, ErrOverBudget)
+	optional := runConcurrent(t, ratelimiter.WithOptional(context.Background()), group, 2, "getrawtransaction", refused)
+
+	g := newGatedCall(&RPCResponse{}, nil)
+	close(g.release)
+	wait := runConcurrent(t, context.Background(), group, 1, "getrawtransaction", g)
+	_, errs := wait()
+	require.NoError(t, errs[0], "the optional call in flight is not shared")
+
+	close(refused.release)
+	_, errs = optional()
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrOverBudget)
+	}
+	assert.Equal(t, int32(1), refused.calls.Load())
+}
+
+func TestCallGroup_CallerCancelDoesNotFailOthers(t *testing.T) {
+	g := newGatedCall(&RPCResponse{Result: json.RawMessage(`1`)}, nil)
+	group := NewCallGroup("getblockcount")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := group.Do(ctx, "getblockcount", nil, g.call)
+		cancelled <- err
+	}()
+	<-g.started
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := group.Do(context.Background(), "getblockcount", nil, g.call)
+		done <- err
+	}()
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(g.release)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Error classes returned (wrapped) by BaseClient and the chain clients.
+// Match them with errors.Is; the original error message is preserved.
+var (
+	ErrRateLimited = errors.New("rate limited")
+	ErrNotFound    = errors.New("not found")
+	ErrTimeout     = errors.New("timeout")
+	ErrAuth        = errors.New("authentication failed")
+	ErrNodeBehind  = errors.New("node behind")
+
+	// ErrResponseTooLarge is returned for response bodies over the client's
+	// limit, see BaseClient.SetMaxResponseSize. Retrying will not help.
+	ErrResponseTooLarge = errors.New("response too large")
+
+	// ErrThrottled marks an ErrRateLimited raised by our own rate limiter,
+	// not the node: the request was never sent.
+	ErrThrottled = errors.New("throttled")
+
+	// ErrBadData marks a response that fails an integrity check, e.g. a
+	// block whose transactions do not match its header: the node serving
+	// it is faulty and another should be asked.
+	ErrBadData = errors.New("bad data")
+
+	// ErrOverBudget marks an optional request refused by the request
+	// budget of its limiter group, see ratelimiter.Budget: it was never
+	// sent, and no node sharing the budget would take it either.
+	ErrOverBudget = errors.New("over request budget")
+)
+
+// classError attaches an error class to err without changing its message.
+type classError struct {
+	class error
+	err   error
+}
+
+func (e *classError) Error() string   { return e.err.Error() }
+func (e *classError) Unwrap() []error { return []error{e.class, e.err} }
+
+// WithClass wraps err so that errors.Is(err, class) reports true while keeping
+// the original message and error chain. A nil class or err returns err as is.
+func WithClass(class, err error) error {
+	if class == nil || err == nil {
+		return err
+	}
+	return &classError{class: class, err: err}
+}
+
+// ClassOf returns the error class of err, or nil if it is unclassified.
+func ClassOf(err error) error {
+	for _, class := range []error{ErrNotFound, ErrRateLimited, ErrAuth, ErrTimeout, ErrNodeBehind, ErrBadData, ErrOverBudget} {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+	return nil
+}
+
+// HTTPError is returned for non-2xx HTTP responses.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return "HTTP " + e.Status + ": " + e.Body
+}
+
+// Unwrap maps the status code to an error class.
+func (e *HTTPError) Unwrap() error {
+	return classFromHTTPStatus(e.StatusCode)
+}
+
+// classFromHTTPStatus leaves 404 unclassified: it means a wrong node URL,
+// a fault of the provider, while a block or transaction the node does not
+// have comes back as a JSON-RPC error, see rpcErrorFromHTTP.
+func classFromHTTPStatus(code int) error {
+	switch code {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	}
+	return nil
+}
+
+// Well-known JSON-RPC error codes.
+const (
+	// Bitcoin Core
+	btcInvalidAddressOrKey = -5  // "Block not found", "No such mempool or blockchain transaction"
+	btcInvalidParameter    = -8  // "Block height out of range"
+	btcInInitialDownload   = -10 // node is still syncing
+	btcInWarmup            = -28 // node is starting up
+
+	// EVM (EIP-1474 and common provider extensions)
+	evmLimitExceeded = -32005
+)
+
+// Unwrap maps well-known RPC error codes to an error class.
+func (e *RPCError) Unwrap() error {
+	msg := strings.ToLower(e.Message)
+	switch e.Code {
+	case btcInvalidAddressOrKey:
+		return ErrNotFound
+	case btcInvalidParameter:
+		if strings.Contains(msg, "out of range") {
+			return ErrNotFound
+		}
+	case btcInInitialDownload, btcInWarmup:
+		return ErrNodeBehind
+	case evmLimitExceeded, http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	switch {
+	case strings.Contains(msg, "header not found"),
+		strings.Contains(msg, "block not found"),
+		strings.Contains(msg, "unknown block"):
+		return ErrNotFound
+	case strings.Contains(msg, "rate limit"), strings.Contains(msg, "too many requests"):
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// classifyTransportError attaches ErrTimeout to deadline and network timeouts.
+func classifyTransportError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return WithClass(ErrTimeout, err)
+	}
+	return err
+}
+
+// urlPattern matches the URLs an error message may quote, such as the node
+// URL in a failed request's error.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// RedactURLs masks what the URLs in msg may carry credentials in: their
+// userinfo, and their path and query, where hosted providers put API keys.
+// The scheme and host are kept to tell the nodes apart. Error messages are
+// passed through it before they are served on a status endpoint or sent in
+// an alert.
+func RedactURLs(msg string) string {
+	return urlPattern.ReplaceAllStringFunc(msg, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return "[redacted url]"
+		}
+		if u.User == nil && strings.Trim(u.Path, "/") == "" && u.RawQuery == "" {
+			return raw
+		}
+		return u.Scheme + "://" + u.Host + "/***"
+	})
+}
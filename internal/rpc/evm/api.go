@@ -11,6 +11,7 @@ type FilterQuery struct {
 	ToBlock   string     // hex block number or "latest"
 	Addresses []string   // contract addresses to filter
 	Topics    [][]string // topics to filter (e.g., Transfer event signature)
+	BlockHash string     // a single block, in place of FromBlock and ToBlock (EIP-234)
 }
 
 type EthereumAPI interface {
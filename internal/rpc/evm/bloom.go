@@ -0,0 +1,70 @@
+package evm
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Bloom is a logsBloom: 2048 bits in which the address and each topic of
+// every log set three, see BloomBits. A value whose bits are not all set
+// is in none of the logs; one whose bits are may still be in none.
+type Bloom [256]byte
+
+// BloomBits are the three bits of a Bloom set for a value.
+type BloomBits [3]uint16
+
+// ParseBloom decodes a hex logsBloom, reporting false when it is missing
+// or malformed.
+func ParseBloom(s string) (Bloom, bool) {
+	var b Bloom
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(raw) != len(b) {
+		return b, false
+	}
+	copy(b[:], raw)
+	return b, true
+}
+
+// BloomBitsOf returns the bits set for value, a log address or topic as
+// raw bytes: the low 11 bits of the first three byte pairs of its
+// keccak256 hash.
+func BloomBitsOf(value []byte) BloomBits {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(value)
+	sum := h.Sum(nil)
+	var bits BloomBits
+	for i := range bits {
+		bits[i] = (uint16(sum[2*i])<<8 | uint16(sum[2*i+1])) & 2047
+	}
+	return bits
+}
+
+// HexBloomBits is BloomBitsOf a hex-encoded address or topic, false if it
+// does not decode.
+func HexBloomBits(s string) (BloomBits, bool) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(s), "0x"))
+	if err != nil || len(raw) == 0 {
+		return BloomBits{}, false
+	}
+	return BloomBitsOf(raw), true
+}
+
+// Add sets bits in b.
+func (b *Bloom) Add(bits BloomBits) {
+	for _, bit := range bits {
+		b[len(b)-1-int(bit/8)] |= 1 << (bit % 8)
+	}
+}
+
+// Has reports whether every one of bits is set in b, i.e. whether the
+// value they are of may be in its logs.
+func (b *Bloom) Has(bits BloomBits) bool {
+	for _, bit := range bits {
+		if b[len(b)-1-int(bit/8)]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package evm
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloom(t *testing.T) {
+	usdt, ok := HexBloomBits("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	require.True(t, ok)
+	topic, ok := HexBloomBits(ERC20_TRANSFER_TOPIC)
+	require.True(t, ok)
+	for _, bit := range append(usdt[:], topic[:]...) {
+		assert.Less(t, bit, uint16(2048))
+	}
+
+	var bloom Bloom
+	assert.False(t, bloom.Has(topic))
+	bloom.Add(usdt)
+	bloom.Add(topic)
+	assert.True(t, bloom.Has(usdt))
+	assert.True(t, bloom.Has(topic))
+	other, _ := HexBloomBits("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	assert.False(t, bloom.Has(other))
+
+	parsed, ok := ParseBloom("0x" + hex.EncodeToString(bloom[:]))
+	require.True(t, ok)
+	assert.Equal(t, bloom, parsed)
+
+	_, ok = ParseBloom("")
+	assert.False(t, ok)
+	_, ok = ParseBloom("0x" + strings.Repeat("00", 255))
+	assert.False(t, ok)
+}
@@ -56,6 +56,34 @@ func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
 	return blockNum, nil
 }
 
+// ChainID returns the node's eth_chainId in decimal.
+func (c *Client) ChainID(ctx context.Context) (string, error) {
+	resp, err := c.CallRPC(ctx, "eth_chainId", nil)
+	if err != nil {
+		return "", fmt.Errorf("eth_chainId failed: %w", err)
+	}
+
+	var idHex string
+	if err := json.Unmarshal(resp.Result, &idHex); err != nil {
+		return "", fmt.Errorf("failed to unmarshal chain id: %w", err)
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(idHex, "0x"), 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse chain id: %w", err)
+	}
+	return strconv.FormatUint(id, 10), nil
+}
+
+// GenesisHash returns the hash of block 0.
+func (c *Client) GenesisHash(ctx context.Context) (string, error) {
+	block, err := c.GetBlockByNumber(ctx, "0x0", false)
+	if err != nil {
+		return "", err
+	}
+	return block.Hash, nil
+}
+
 // GetBlockByNumber returns a block with full transaction data
 func (c *Client) GetBlockByNumber(
 	ctx context.Context,
@@ -164,12 +192,17 @@ func (c *Client) DebugTraceTransaction(ctx context.Context, txHash string) (*Cal
 	return &trace, nil
 }
 
-// FilterLogs queries event logs matching the filter criteria
+// FilterLogs queries event logs matching the filter criteria. A query over
+// more blocks or matching more logs than the node serves at once fails
+// with ErrLogRangeTooLarge.
 func (c *Client) FilterLogs(ctx context.Context, query FilterQuery) ([]Log, error) {
 	params := map[string]any{
 		"fromBlock": query.FromBlock,
 		"toBlock":   query.ToBlock,
 	}
+	if query.BlockHash != "" {
+		params = map[string]any{"blockHash": query.BlockHash}
+	}
 
 	if len(query.Addresses) > 0 {
 		params["address"] = query.Addresses
@@ -189,6 +222,9 @@ func (c *Client) FilterLogs(ctx context.Context, query FilterQuery) ([]Log, erro
 
 	responses, err := c.DoBatch(ctx, []*rpc.RPCRequest{req})
 	if err != nil {
+		if isLogRangeError(err.Error()) {
+			return nil, fmt.Errorf("%w: %w", ErrLogRangeTooLarge, err)
+		}
 		return nil, fmt.Errorf("failed to filter logs: %w", err)
 	}
 
@@ -198,6 +234,9 @@ func (c *Client) FilterLogs(ctx context.Context, query FilterQuery) ([]Log, erro
 
 	resp := responses[0]
 	if resp.Error != nil {
+		if isLogRangeError(resp.Error.Message) {
+			return nil, fmt.Errorf("%w: %s", ErrLogRangeTooLarge, resp.Error.Error())
+		}
 		return nil, fmt.Errorf("rpc error: %s", resp.Error.Error())
 	}
 
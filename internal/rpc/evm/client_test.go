@@ -65,3 +65,22 @@ func TestDebugTraceTransaction_MalformedJSON(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "decode error")
 }
+
+func TestFilterLogs_RangeTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"jsonrpc":"2.0","error":{"code":-32005,"message":"query returned more than 10000 results"},"id":1}]`)
+	}))
+	defer server.Close()
+
+	c := NewEthereumClient(server.URL, nil, 5*time.Second, nil)
+	_, err := c.FilterLogs(context.Background(), FilterQuery{FromBlock: "0x1", ToBlock: "0x3e8"})
+	assert.ErrorIs(t, err, ErrLogRangeTooLarge)
+
+	assert.False(t, isLogRangeError("execution reverted"))
+	assert.True(t, isLogRangeError("exceed maximum block range: 5000"))
+	assert.True(t, isLogRangeError("block range is too wide"))
+	// Mentioning a block range does not make an error a range limit.
+	assert.False(t, isLogRangeError("invalid block range params"))
+	assert.False(t, isLogRangeError("fromBlock is after toBlock in block range"))
+}
@@ -0,0 +1,35 @@
+package evm
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrLogRangeTooLarge is returned by FilterLogs when the node refuses a
+// query for spanning too many blocks or matching too many logs. The limits
+// differ by provider; a narrower range may succeed.
+var ErrLogRangeTooLarge = errors.New("log query range too large")
+
+// logRangeErrors are fragments of the errors providers return for
+// eth_getLogs queries over their range or result limits.
+var logRangeErrors = []string{
+	"query returned more than",      // geth, Infura
+	"response size exceeded",        // Alchemy
+	"exceed maximum block range",    // "exceed maximum block range: 5000"
+	"block range is too wide",       // BSC
+	"block range too large",         // "block range too large, max 10000"
+	"range is too large",            // Nethermind
+	"too many logs",                 // Erigon
+	"logs matched by query exceeds", // Besu
+	"is limited to",                 // QuickNode: "eth_getLogs is limited to a 10,000 range"
+}
+
+func isLogRangeError(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, fragment := range logRangeErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
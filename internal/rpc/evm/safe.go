@@ -2,6 +2,7 @@ package evm
 
 import (
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 )
 
@@ -70,8 +71,8 @@ func ExtractSafeTransfers(
 			NetworkId:     network,
 			BlockNumber:   blockNumber,
 			TransferIndex: txIdx + ":safe:0",
-			FromAddress:   ToChecksumAddress(tx.To), // Safe contract is the sender
-			ToAddress:     params.To,                // decoded recipient
+			FromAddress:   evmaddr.LowerAddress(tx.To), // Safe contract is the sender
+			ToAddress:     params.To,                   // decoded recipient
 			Amount:        params.Value.String(),
 			Type:          constant.TxTypeNativeTransfer,
 			TxFee:         fee,
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -76,7 +77,7 @@ func TestDecodeGnosisSafeExecTransaction(t *testing.T) {
 	params, err := DecodeGnosisSafeExecTransaction(safeExecInput)
 	require.NoError(t, err)
 
-	assert.Equal(t, ToChecksumAddress("0xc26dc13d057824342d5480b153f288bd1c5e3e9d"), params.To)
+	assert.Equal(t, evmaddr.LowerAddress("0xc26dc13d057824342d5480b153f288bd1c5e3e9d"), params.To)
 	assert.Equal(t, "100000000000000000", params.Value.String()) // 0.1 ETH
 	assert.Empty(t, params.Data, "data should be empty for pure ETH transfer")
 	assert.Equal(t, uint8(0), params.Operation, "operation should be Call (0)")
@@ -112,8 +113,8 @@ func TestExtractSafeTransfers_ValidNativeTransfer(t *testing.T) {
 
 	require.Len(t, transfers, 1)
 	assert.Equal(t, constant.TxTypeNativeTransfer, transfers[0].Type)
-	assert.Equal(t, ToChecksumAddress(safeContractAddr), transfers[0].FromAddress)
-	assert.Equal(t, ToChecksumAddress("0xc26dc13d057824342d5480b153f288bd1c5e3e9d"), transfers[0].ToAddress)
+	assert.Equal(t, evmaddr.LowerAddress(safeContractAddr), transfers[0].FromAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xc26dc13d057824342d5480b153f288bd1c5e3e9d"), transfers[0].ToAddress)
 	assert.Equal(t, "100000000000000000", transfers[0].Amount)
 }
 
@@ -5,19 +5,20 @@ import (
 	"strings"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"github.com/shopspring/decimal"
 )
 
 // ExtractInternalTransfers extracts native value transfers from a debug_traceTransaction call tree.
-// Returns transfers as native_transfer type (same as ExtractTransfers) for downstream compatibility.
+// Returns transfers as native_transfer type (same as ExtractTransfers) for downstream compatibility,
+// except SELFDESTRUCT frames, whose balance sweeps are emitted as selfdestruct.
 //
-// Root call dedup: the root CALL is skipped when the top-level tx is a plain native transfer
-// (Input == "" && To != ""), because ExtractTransfers() already captures it.
+// Root call dedup: the root frame is skipped when ExtractTransfers() already captures it:
+// for plain native transfers (Input == "" && To != "") and for CREATE txs (To == ""),
+// whose value ExtractTransfers() credits to the created contract.
 // For contract calls with value (non-empty input), the root is NOT skipped.
-// For CREATE txs (To == ""), the root is NOT skipped — ExtractTransfers() doesn't emit
-// a native transfer for CREATE txs, so the trace entry is a genuinely new event.
 func ExtractInternalTransfers(
 	trace *CallTrace,
 	tx Txn,
@@ -29,9 +30,9 @@ func ExtractInternalTransfers(
 		return nil
 	}
 
-	// Skip root only for plain native transfers where ExtractTransfers already emits one.
+	// Skip root only where ExtractTransfers already emits it.
 	isPlainNativeTransfer := tx.To != "" && (strings.TrimPrefix(tx.Input, "0x") == "")
-	skipRoot := isPlainNativeTransfer
+	skipRoot := isPlainNativeTransfer || tx.IsContractCreation()
 
 	txIdx := hexIndexToDecimal(tx.TransactionIndex)
 	counter := 0
@@ -63,10 +64,15 @@ func walkCallTrace(
 	}
 
 	shouldExtract := false
+	txType := constant.TxTypeNativeTransfer
 	if !isRoot || !skipRoot {
 		switch call.Type {
 		case "CALL", "CREATE", "CREATE2":
 			shouldExtract = true
+		case "SELFDESTRUCT":
+			// From is the destroyed contract, To the beneficiary of its balance.
+			shouldExtract = true
+			txType = constant.TxTypeSelfDestruct
 		// DELEGATECALL: no value transfer (runs in caller's context)
 		// STATICCALL: read-only, cannot transfer value
 		}
@@ -80,10 +86,10 @@ func walkCallTrace(
 				NetworkId:     networkId,
 				BlockNumber:   blockNumber,
 				TransferIndex: txIdx + ":trace:" + strconv.Itoa(*counter),
-				FromAddress:   ToChecksumAddress(call.From),
-				ToAddress:     ToChecksumAddress(call.To),
+				FromAddress:   evmaddr.LowerAddress(call.From),
+				ToAddress:     evmaddr.LowerAddress(call.To),
 				Amount:        val.String(),
-				Type:          constant.TxTypeNativeTransfer,
+				Type:          txType,
 				TxFee:         fee,
 				Timestamp:     timestamp,
 			})
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -132,8 +133,8 @@ func TestExtractInternalTransfers_RealSafeTx_Integration(t *testing.T) {
 		assert.Equal(t, constant.TxTypeNativeTransfer, tr.Type, "type should be native_transfer")
 		if tr.Amount == "100000000000000000" { // 0.1 ETH
 			found = true
-			safeAddr := ToChecksumAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c")
-			recipientAddr := ToChecksumAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d")
+			safeAddr := evmaddr.LowerAddress("0x84ba2321d46814fb1aa69a7b71882efea50f700c")
+			recipientAddr := evmaddr.LowerAddress("0xc26dC13d057824342D5480b153f288bd1C5e3e9d")
 			assert.Equal(t, safeAddr, tr.FromAddress, "from should be Safe contract")
 			assert.Equal(t, recipientAddr, tr.ToAddress, "to should be recipient")
 		}
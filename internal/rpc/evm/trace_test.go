@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -47,13 +48,13 @@ func TestExtractInternalTransfers_NestedCALLs(t *testing.T) {
 	require.Len(t, transfers, 2)
 
 	assert.Equal(t, "1000000000000000000", transfers[0].Amount)
-	assert.Equal(t, ToChecksumAddress("0xaaaa"), transfers[0].FromAddress)
-	assert.Equal(t, ToChecksumAddress("0xbbbb"), transfers[0].ToAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xaaaa"), transfers[0].FromAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xbbbb"), transfers[0].ToAddress)
 	assert.Equal(t, constant.TxTypeNativeTransfer, transfers[0].Type)
 
 	assert.Equal(t, "500000000000000000", transfers[1].Amount)
-	assert.Equal(t, ToChecksumAddress("0xbbbb"), transfers[1].FromAddress)
-	assert.Equal(t, ToChecksumAddress("0xcccc"), transfers[1].ToAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xbbbb"), transfers[1].FromAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xcccc"), transfers[1].ToAddress)
 }
 
 func TestExtractInternalTransfers_RootSkipForPlainNativeTransfer(t *testing.T) {
@@ -101,7 +102,7 @@ func TestExtractInternalTransfers_RootNotSkippedForContractCallWithValue(t *test
 	assert.Equal(t, "1000000000000000000", transfers[0].Amount)
 }
 
-func TestExtractInternalTransfers_CREATEWithValue(t *testing.T) {
+func TestExtractInternalTransfers_RootCREATESkipped(t *testing.T) {
 	trace := &CallTrace{
 		From:  "0xaaaa",
 		To:    "0xnewcontract",
@@ -120,31 +121,36 @@ func TestExtractInternalTransfers_CREATEWithValue(t *testing.T) {
 
 	transfers := ExtractInternalTransfers(trace, tx, decimal.Zero, "eth", 100, 1000)
 
-	// CREATE with value is extracted — ExtractTransfers doesn't emit native for CREATE txs
-	require.Len(t, transfers, 1)
-	assert.Equal(t, "1000000000000000000", transfers[0].Amount)
-	assert.Equal(t, ToChecksumAddress("0xaaaa"), transfers[0].FromAddress)
-	assert.Equal(t, ToChecksumAddress("0xnewcontract"), transfers[0].ToAddress)
+	// Root CREATE skipped — ExtractTransfers credits the value to the created contract
+	assert.Empty(t, transfers)
 }
 
-func TestExtractInternalTransfers_CREATE2WithValue(t *testing.T) {
+func TestExtractInternalTransfers_NestedCREATEWithValue(t *testing.T) {
 	trace := &CallTrace{
-		From:  "0xfactory",
-		To:    "0xdeployed",
-		Value: "0x1",
-		Type:  "CREATE2",
+		From:  "0xuser",
+		To:    "0xfactory",
+		Value: "0x0",
+		Type:  "CALL",
+		Calls: []CallTrace{
+			{From: "0xfactory", To: "0xdeployed1", Value: "0xde0b6b3a7640000", Type: "CREATE"},
+			{From: "0xfactory", To: "0xdeployed2", Value: "0x1", Type: "CREATE2"},
+		},
 	}
 
 	tx := Txn{
 		Hash:  "0xtx5",
-		From:  "0xfactory",
-		To:    "",
+		From:  "0xuser",
+		To:    "0xfactory",
 		Input: "0x6080...",
 	}
 
 	transfers := ExtractInternalTransfers(trace, tx, decimal.Zero, "eth", 100, 1000)
-	require.Len(t, transfers, 1)
-	assert.Equal(t, "1", transfers[0].Amount)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, "1000000000000000000", transfers[0].Amount)
+	assert.Equal(t, evmaddr.LowerAddress("0xfactory"), transfers[0].FromAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xdeployed1"), transfers[0].ToAddress)
+	assert.Equal(t, "1", transfers[1].Amount)
+	assert.Equal(t, evmaddr.LowerAddress("0xdeployed2"), transfers[1].ToAddress)
 }
 
 func TestExtractInternalTransfers_SkipDELEGATECALL(t *testing.T) {
@@ -320,3 +326,31 @@ func TestExtractInternalTransfers_AllTransfersAreNativeType(t *testing.T) {
 		assert.Equal(t, constant.TxTypeNativeTransfer, tr.Type, "all trace transfers must be native_transfer")
 	}
 }
+
+func TestExtractInternalTransfers_ContractCreationNotDoubleCounted(t *testing.T) {
+	tx := readFixture[Txn](t, "contract_creation", "tx.json")
+	receipt := readFixture[TxnReceipt](t, "contract_creation", "receipt.json")
+	trace := readFixture[CallTrace](t, "contract_creation", "trace.json")
+
+	transfers := ExtractInternalTransfers(&trace, tx, decimal.Zero, "eth", 20000000, 1000)
+	transfers = append(transfers, tx.ExtractTransfers("eth", &receipt, 20000000, 1000)...)
+	transfers = utils.DedupTransfers(transfers)
+
+	require.Len(t, transfers, 1)
+	assert.Equal(t, evmaddr.LowerAddress(receipt.ContractAddress), transfers[0].ToAddress)
+}
+
+func TestExtractInternalTransfers_SelfDestruct(t *testing.T) {
+	tx := readFixture[Txn](t, "selfdestruct", "tx.json")
+	trace := readFixture[CallTrace](t, "selfdestruct", "trace.json")
+
+	transfers := ExtractInternalTransfers(&trace, tx, decimal.Zero, "eth", 19530226, 1000)
+
+	// The zero-value CALL is skipped; the balance sweep is its own type.
+	require.Len(t, transfers, 1)
+	assert.Equal(t, constant.TxTypeSelfDestruct, transfers[0].Type)
+	assert.Equal(t, evmaddr.LowerAddress("0x5b1f6e9fd5f2a7e6c5f8a26e4d1a3f0c8b2e7d44"), transfers[0].FromAddress)
+	assert.Equal(t, evmaddr.LowerAddress("0xe3c1f5a8b9d2c4e6f7a8b9c0d1e2f3a4b5c6d7e8"), transfers[0].ToAddress)
+	assert.Equal(t, "5000000000000000000", transfers[0].Amount)
+	assert.Equal(t, "2:trace:0", transfers[0].TransferIndex)
+}
@@ -5,12 +5,20 @@ import (
 	"strings"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"github.com/shopspring/decimal"
 )
 
 func (t *Txn) NeedReceipt() bool {
+	if t.IsBlobTx() {
+		// Only the receipt reports the blob gas paid, see CalcFee.
+		return true
+	}
+	if t.IsContractCreation() {
+		return t.hasValue()
+	}
 	inputLen := len(strings.TrimSpace(t.Input))
 	if inputLen <= 2 {
 		return true
@@ -24,15 +32,59 @@ func (t *Txn) NeedReceipt() bool {
 	return false
 }
 
+// IsBlobTx reports whether tx is a blob transaction (type 0x3, EIP-4844).
+func (t *Txn) IsBlobTx() bool {
+	return t.Type == "0x3" || len(t.BlobVersionedHashes) > 0
+}
+
+// IsContractCreation reports whether tx deploys a contract, i.e. has no recipient.
+func (t *Txn) IsContractCreation() bool {
+	return t.To == ""
+}
+
+// CreatedAddress returns the contract deployed by a creation transaction:
+// the receipt's contractAddress if known, else the address derived from the
+// sender and nonce. It is empty for other transactions, or if neither is
+// available.
+func (t *Txn) CreatedAddress(receipt *TxnReceipt) string {
+	if !t.IsContractCreation() {
+		return ""
+	}
+	if receipt != nil && receipt.ContractAddress != "" {
+		return evmaddr.LowerAddress(receipt.ContractAddress)
+	}
+	nonce, err := utils.ParseHexUint64(t.Nonce)
+	if err != nil {
+		return ""
+	}
+	addr, err := CreateAddress(t.From, nonce)
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+func (t *Txn) hasValue() bool {
+	val, err := utils.ParseHexBigInt(t.Value)
+	return err == nil && val.Sign() > 0
+}
+
 const WEI_PER_ETH = 1e18
 
 // CalcFee computes the transaction fee from receipt if available, otherwise fallback to Txn Gas*GasPrice.
+// Blob transactions also pay for their blob gas, blobGasUsed*blobGasPrice, which is charged apart
+// from the execution gas and only known from the receipt. A receipt assembled from logs
+// (FromLogs) leaves the fee unknown: zero, rather than the gas limit's worth.
 // Returns the fee in ETH (divided by 1e18 from Wei).
 func (tx Txn) CalcFee(receipt *TxnReceipt) decimal.Decimal {
+	if receipt != nil && receipt.FromLogs {
+		return decimal.Zero
+	}
 	if receipt != nil {
 		if gasUsed, err1 := utils.ParseHexBigInt(receipt.GasUsed); err1 == nil {
 			if gasPrice, err2 := utils.ParseHexBigInt(receipt.EffectiveGasPrice); err2 == nil {
 				weiAmount := new(big.Int).Mul(gasUsed, gasPrice)
+				weiAmount.Add(weiAmount, receipt.blobFee())
 				result := decimal.NewFromBigInt(weiAmount, 0).Div(decimal.NewFromInt(WEI_PER_ETH))
 				return result
 			}
@@ -47,6 +99,16 @@ func (tx Txn) CalcFee(receipt *TxnReceipt) decimal.Decimal {
 	return decimal.Zero
 }
 
+// blobFee returns the wei paid for blob gas, zero for transactions without blobs.
+func (r *TxnReceipt) blobFee() *big.Int {
+	gasUsed, err1 := utils.ParseHexBigInt(r.BlobGasUsed)
+	gasPrice, err2 := utils.ParseHexBigInt(r.BlobGasPrice)
+	if err1 != nil || err2 != nil {
+		return new(big.Int)
+	}
+	return gasUsed.Mul(gasUsed, gasPrice)
+}
+
 // parseERC20Input decodes ERC20 transfer / transferFrom from tx.Input.
 func (tx Txn) parseERC20Input(
 	fee decimal.Decimal,
@@ -73,9 +135,9 @@ func (tx Txn) parseERC20Input(
 			NetworkId:     network,
 			BlockNumber:   blockNumber,
 			TransferIndex: txIdx,
-			FromAddress:   ToChecksumAddress(tx.From),
+			FromAddress:   evmaddr.LowerAddress(tx.From),
 			ToAddress:     to,
-			AssetAddress:  ToChecksumAddress(tx.To),
+			AssetAddress:  evmaddr.LowerAddress(tx.To),
 			Amount:        amount.String(),
 			Type:          constant.TxTypeTokenTransfer,
 			TxFee:         fee,
@@ -94,7 +156,7 @@ func (tx Txn) parseERC20Input(
 			TransferIndex: txIdx,
 			FromAddress:   from,
 			ToAddress:     to,
-			AssetAddress:  ToChecksumAddress(tx.To),
+			AssetAddress:  evmaddr.LowerAddress(tx.To),
 			Amount:        amount.String(),
 			Type:          constant.TxTypeTokenTransfer,
 			TxFee:         fee,
@@ -139,8 +201,24 @@ func (tx Txn) ExtractTransfers(
 			NetworkId:     network,
 			BlockNumber:   blockNumber,
 			TransferIndex: txIdx,
-			FromAddress:   ToChecksumAddress(tx.From),
-			ToAddress:     ToChecksumAddress(tx.To),
+			FromAddress:   evmaddr.LowerAddress(tx.From),
+			ToAddress:     evmaddr.LowerAddress(tx.To),
+			Amount:        val.String(),
+			Type:          constant.TxTypeNativeTransfer,
+			TxFee:         fee,
+			Timestamp:     ts,
+		})
+	}
+	// value sent with a contract creation, credited to the new contract
+	if to := tx.CreatedAddress(receipt); to != "" && tx.hasValue() {
+		val, _ := utils.ParseHexBigInt(tx.Value)
+		out = append(out, types.Transaction{
+			TxHash:        tx.Hash,
+			NetworkId:     network,
+			BlockNumber:   blockNumber,
+			TransferIndex: txIdx,
+			FromAddress:   evmaddr.LowerAddress(tx.From),
+			ToAddress:     to,
 			Amount:        val.String(),
 			Type:          constant.TxTypeNativeTransfer,
 			TxFee:         fee,
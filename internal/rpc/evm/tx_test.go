@@ -0,0 +1,135 @@
+package evm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFixture decodes testdata/<path> into a T.
+func readFixture[T any](t *testing.T, path ...string) T {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(append([]string{"testdata"}, path...)...))
+	require.NoError(t, err)
+	var v T
+	require.NoError(t, json.Unmarshal(raw, &v))
+	return v
+}
+
+// loadBlobBlock decodes testdata/blob_block: a post-Dencun block holding a
+// type-2 transfer and a type-3 transaction carrying three blobs, as returned
+// by eth_getBlockByNumber and eth_getTransactionReceipt.
+func loadBlobBlock(t *testing.T) (*Block, map[string]*TxnReceipt) {
+	t.Helper()
+	block := readFixture[Block](t, "blob_block", "block.json")
+	list := readFixture[[]TxnReceipt](t, "blob_block", "receipts.json")
+	receipts := make(map[string]*TxnReceipt, len(list))
+	for i := range list {
+		receipts[list[i].TransactionHash] = &list[i]
+	}
+	return &block, receipts
+}
+
+func TestBlobBlock_Decodes(t *testing.T) {
+	block, receipts := loadBlobBlock(t)
+	require.Len(t, block.Transactions, 2)
+
+	blobTx := block.Transactions[1]
+	assert.Equal(t, "0x3", blobTx.Type)
+	assert.Equal(t, "0x77359400", blobTx.MaxFeePerBlobGas)
+	assert.Len(t, blobTx.BlobVersionedHashes, 3)
+
+	receipt := receipts[blobTx.Hash]
+	require.NotNil(t, receipt)
+	assert.Equal(t, "0x60000", receipt.BlobGasUsed)
+	assert.Equal(t, "0x3b9aca00", receipt.BlobGasPrice)
+
+	transfer := block.Transactions[0]
+	assert.Equal(t, "0x2", transfer.Type)
+	assert.Empty(t, transfer.BlobVersionedHashes)
+	assert.Empty(t, receipts[transfer.Hash].BlobGasUsed)
+}
+
+func TestCalcFee_BlobTransaction(t *testing.T) {
+	block, receipts := loadBlobBlock(t)
+	transfer, blobTx := block.Transactions[0], block.Transactions[1]
+
+	// 21000 gas at 30 gwei, no blob gas.
+	assert.Equal(t, "0.00063", transfer.CalcFee(receipts[transfer.Hash]).String())
+
+	// 21000 gas at 20 gwei plus 3 blobs * 131072 blob gas at 1 gwei.
+	assert.Equal(t, "0.000813216", blobTx.CalcFee(receipts[blobTx.Hash]).String())
+
+	// Without the receipt the blob gas paid is unknown.
+	assert.Equal(t, "0.00042", blobTx.CalcFee(nil).String())
+}
+
+func TestNeedReceipt_BlobTransaction(t *testing.T) {
+	block, _ := loadBlobBlock(t)
+	blobTx := block.Transactions[1]
+	blobTx.Input = "0x12345678"
+	assert.True(t, blobTx.IsBlobTx())
+	assert.True(t, blobTx.NeedReceipt(), "only the receipt reports the blob gas paid")
+
+	transfer := block.Transactions[0]
+	transfer.Input = "0x12345678"
+	assert.False(t, transfer.IsBlobTx())
+	assert.False(t, transfer.NeedReceipt())
+}
+
+func TestCreateAddress(t *testing.T) {
+	sender := "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"
+	for nonce, want := range map[uint64]string{
+		0: "0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d",
+		1: "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8",
+		2: "0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91",
+		3: "0xfffd933a0bc612844eaf0c6fe3e5b8e9b6c1d19c",
+	} {
+		got, err := CreateAddress(sender, nonce)
+		require.NoError(t, err)
+		assert.Equal(t, evmaddr.LowerAddress(want), got, "nonce %d", nonce)
+	}
+
+	_, err := CreateAddress("0x1234", 0)
+	assert.Error(t, err)
+}
+
+// testdata/contract_creation and testdata/selfdestruct are hand-built in the
+// shape of eth_getTransactionByHash, eth_getTransactionReceipt and
+// callTracer results, their hashes and addresses made up. Re-record them
+// from a node with cmd/devtools/evmfixtures.
+func TestExtractTransfers_ContractCreation(t *testing.T) {
+	tx := readFixture[Txn](t, "contract_creation", "tx.json")
+	receipt := readFixture[TxnReceipt](t, "contract_creation", "receipt.json")
+	require.True(t, tx.IsContractCreation())
+	assert.True(t, tx.NeedReceipt(), "creation with value needs its receipt")
+
+	contract := evmaddr.LowerAddress("0x343c43a37d37dff08ae8c4a11544c718abb4fcf8")
+	for name, r := range map[string]*TxnReceipt{
+		"receipt contractAddress": &receipt,
+		"derived from nonce":      nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			transfers := tx.ExtractTransfers("eth", r, 20000000, 1000)
+			require.Len(t, transfers, 1)
+			assert.Equal(t, constant.TxTypeNativeTransfer, transfers[0].Type)
+			assert.Equal(t, evmaddr.LowerAddress(tx.From), transfers[0].FromAddress)
+			assert.Equal(t, contract, transfers[0].ToAddress)
+			assert.Equal(t, "10000000000000000", transfers[0].Amount)
+		})
+	}
+}
+
+func TestExtractTransfers_ContractCreationWithoutValue(t *testing.T) {
+	tx := readFixture[Txn](t, "contract_creation", "tx.json")
+	tx.Value = "0x0"
+
+	assert.False(t, tx.NeedReceipt())
+	assert.Empty(t, tx.ExtractTransfers("eth", nil, 20000000, 1000))
+}
@@ -3,6 +3,7 @@ package evm
 import (
 	"strings"
 
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
@@ -26,6 +27,10 @@ type (
 		Hash         string `json:"hash"`
 		ParentHash   string `json:"parentHash"`
 		Timestamp    string `json:"timestamp"`
+		Size         string `json:"size"`
+		GasUsed      string `json:"gasUsed"`
+		GasLimit     string `json:"gasLimit"`
+		LogsBloom    string `json:"logsBloom"`
 		Transactions []Txn  `json:"transactions"`
 	}
 
@@ -37,8 +42,13 @@ type (
 		Input            string `json:"input"`
 		Gas              string `json:"gas"`
 		GasPrice         string `json:"gasPrice"`
+		Nonce            string `json:"nonce"`
 		BlockNumber      string `json:"blockNumber"`
 		TransactionIndex string `json:"transactionIndex"`
+		Type             string `json:"type"`
+		// Blob transactions (type 0x3, EIP-4844) only.
+		MaxFeePerBlobGas    string   `json:"maxFeePerBlobGas,omitempty"`
+		BlobVersionedHashes []string `json:"blobVersionedHashes,omitempty"`
 	}
 
 	TxnReceipt struct {
@@ -47,6 +57,15 @@ type (
 		EffectiveGasPrice string `json:"effectiveGasPrice"`
 		Status            string `json:"status"`
 		Logs              []Log  `json:"logs"`
+		// ContractAddress is the contract a creation transaction deployed.
+		ContractAddress string `json:"contractAddress"`
+		// Blob transactions (type 0x3, EIP-4844) only.
+		BlobGasUsed  string `json:"blobGasUsed,omitempty"`
+		BlobGasPrice string `json:"blobGasPrice,omitempty"`
+		// FromLogs marks a receipt assembled from eth_getLogs results
+		// rather than fetched: it carries no gas used, so the fee of its
+		// transaction is unknown.
+		FromLogs bool `json:"-"`
 	}
 
 	Log struct {
@@ -56,6 +75,10 @@ type (
 		BlockNumber     string   `json:"blockNumber"`
 		TransactionHash string   `json:"transactionHash"`
 		LogIndex        string   `json:"logIndex"`
+		// BlockHash and Removed are set in eth_getLogs results: Removed
+		// marks a log of a block reorged out.
+		BlockHash string `json:"blockHash,omitempty"`
+		Removed   bool   `json:"removed,omitempty"`
 	}
 )
 
@@ -64,7 +87,7 @@ type CallTrace struct {
 	From    string      `json:"from"`
 	To      string      `json:"to"`
 	Value   string      `json:"value"`    // hex-encoded wei
-	Type    string      `json:"type"`     // CALL, DELEGATECALL, STATICCALL, CREATE, CREATE2
+	Type    string      `json:"type"`     // CALL, DELEGATECALL, STATICCALL, CREATE, CREATE2, SELFDESTRUCT
 	Input   string      `json:"input"`
 	Output  string      `json:"output"`
 	Gas     string      `json:"gas"`
@@ -84,9 +107,9 @@ func (l Log) parseERC20Transfers(
 	}
 
 	from := "0x" + l.Topics[1][len(l.Topics[1])-40:]
-	from = ToChecksumAddress(from)
+	from = evmaddr.LowerAddress(from)
 	to := "0x" + l.Topics[2][len(l.Topics[2])-40:]
-	to = ToChecksumAddress(to)
+	to = evmaddr.LowerAddress(to)
 	amount, err := utils.ParseHexBigInt(l.Data)
 	if err != nil {
 		return nil, err
@@ -99,7 +122,7 @@ func (l Log) parseERC20Transfers(
 		TransferIndex: txIdx + ":" + hexIndexToDecimal(l.LogIndex),
 		FromAddress:   from,
 		ToAddress:     to,
-		AssetAddress:  ToChecksumAddress(l.Address),
+		AssetAddress:  evmaddr.LowerAddress(l.Address),
 		Amount:        amount.String(),
 		Type:          "erc20_transfer",
 		TxFee:         fee,
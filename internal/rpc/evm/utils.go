@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/utils"
 	"golang.org/x/crypto/sha3"
 )
@@ -45,7 +46,7 @@ func DecodeERC20TransferInput(input string) (string, *big.Int, error) {
 	}
 	// last 20 bytes are the address
 	toAddr := "0x" + hex.EncodeToString(addrData[12:])
-	toAddr = ToChecksumAddress(toAddr)
+	toAddr = evmaddr.LowerAddress(toAddr)
 
 	// next 32 bytes = amount
 	amountData, err := hex.DecodeString(data[64:128])
@@ -72,7 +73,7 @@ func DecodeERC20TransferFromInput(input string) (string, string, *big.Int, error
 		return "", "", nil, err
 	}
 	fromAddr := "0x" + hex.EncodeToString(fromData[12:])
-	fromAddr = ToChecksumAddress(fromAddr)
+	fromAddr = evmaddr.LowerAddress(fromAddr)
 
 	// to
 	toData, err := hex.DecodeString(data[64:128])
@@ -80,7 +81,7 @@ func DecodeERC20TransferFromInput(input string) (string, string, *big.Int, error
 		return "", "", nil, err
 	}
 	toAddr := "0x" + hex.EncodeToString(toData[12:])
-	toAddr = ToChecksumAddress(toAddr)
+	toAddr = evmaddr.LowerAddress(toAddr)
 
 	// amount
 	amtData, err := hex.DecodeString(data[128:192])
@@ -125,7 +126,7 @@ func DecodeGnosisSafeExecTransaction(input string) (*GnosisSafeExecParams, error
 		return nil, fmt.Errorf("decode 'to': %w", err)
 	}
 	to := "0x" + hex.EncodeToString(toBytes[12:])
-	to = ToChecksumAddress(to)
+	to = evmaddr.LowerAddress(to)
 
 	// Param 1: value (uint256)
 	valueBytes, err := hex.DecodeString(data[64:128])
@@ -182,44 +183,29 @@ func DecodeGnosisSafeExecTransaction(input string) (*GnosisSafeExecParams, error
 	}, nil
 }
 
-// ToChecksumAddress converts an Ethereum address to EIP-55 checksummed format
-func ToChecksumAddress(addr string) string {
-	// Remove 0x prefix if present
-	addr = strings.TrimPrefix(strings.ToLower(addr), "0x")
-
-	// Handle empty or invalid addresses
-	if len(addr) != 40 {
-		return "0x" + addr
+// CreateAddress returns the address of the contract deployed by a creation
+// transaction from sender with the given nonce: the last 20 bytes of
+// keccak256(rlp([sender, nonce])).
+func CreateAddress(sender string, nonce uint64) (string, error) {
+	from, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(sender), "0x"))
+	if err != nil || len(from) != 20 {
+		return "", fmt.Errorf("invalid sender address %q", sender)
 	}
 
-	// Compute keccak256 hash of the lowercase address
-	hash := sha3.NewLegacyKeccak256()
-	hash.Write([]byte(addr))
-	hashBytes := hash.Sum(nil)
-
-	// Build checksummed address
-	result := make([]byte, 42)
-	result[0] = '0'
-	result[1] = 'x'
-
-	for i := 0; i < 40; i++ {
-		c := addr[i]
-		// Get the corresponding nibble from hash
-		hashByte := hashBytes[i/2]
-		var nibble byte
-		if i%2 == 0 {
-			nibble = hashByte >> 4
-		} else {
-			nibble = hashByte & 0x0f
-		}
-
-		// If hash nibble >= 8, capitalize the character (if it's a letter)
-		if nibble >= 8 && c >= 'a' && c <= 'f' {
-			result[2+i] = c - 32 // Convert to uppercase
-		} else {
-			result[2+i] = c
-		}
+	// RLP: a 20-byte string, then the nonce as a minimal big-endian integer.
+	var nonceRLP []byte
+	switch {
+	case nonce == 0:
+		nonceRLP = []byte{0x80}
+	case nonce < 0x80:
+		nonceRLP = []byte{byte(nonce)}
+	default:
+		b := new(big.Int).SetUint64(nonce).Bytes()
+		nonceRLP = append([]byte{0x80 + byte(len(b))}, b...)
 	}
+	payload := append(append([]byte{0x80 + 20}, from...), nonceRLP...)
 
-	return string(result)
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(append([]byte{0xc0 + byte(len(payload))}, payload...))
+	return evmaddr.LowerAddress(hex.EncodeToString(hash.Sum(nil)[12:])), nil
 }
@@ -0,0 +1,99 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"golang.org/x/net/websocket"
+)
+
+// wsHandshakeTimeout bounds the connection and subscription with the node.
+const wsHandshakeTimeout = 10 * time.Second
+
+// Head is a block header announced by a newHeads subscription.
+type Head struct {
+	Number string `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// SubscribeNewHeads subscribes to newHeads over the node's websocket
+// endpoint, e.g. wss://host/ws, and delivers each new chain head. Heads of
+// a reorg are delivered as the node announces them. The channel is closed
+// when ctx ends or the connection fails.
+func SubscribeNewHeads(ctx context.Context, url string) (<-chan Head, error) {
+	cfg, err := websocket.NewConfig(url, "http://localhost")
+	if err != nil {
+		return nil, fmt.Errorf("websocket endpoint %q: %w", url, err)
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, wsHandshakeTimeout)
+	defer cancel()
+	conn, err := cfg.DialContext(dialCtx)
+	if err != nil {
+		return nil, fmt.Errorf("websocket dial: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(wsHandshakeTimeout))
+	subID, err := wsSubscribe(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("eth_subscribe newHeads: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	heads := make(chan Head, 1)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	go func() {
+		defer close(heads)
+		defer stop()
+		defer conn.Close()
+		for {
+			var msg struct {
+				Method string `json:"method"`
+				Params struct {
+					Subscription string `json:"subscription"`
+					Result       Head   `json:"result"`
+				} `json:"params"`
+			}
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				return
+			}
+			if msg.Method != "eth_subscription" || msg.Params.Subscription != subID {
+				continue
+			}
+			select {
+			case heads <- msg.Params.Result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return heads, nil
+}
+
+// wsSubscribe sends eth_subscribe for newHeads and returns the
+// subscription id.
+func wsSubscribe(conn *websocket.Conn) (string, error) {
+	req := rpc.RPCRequest{JSONRPC: "2.0", Method: "eth_subscribe", Params: []any{"newHeads"}, ID: 1}
+	if err := websocket.JSON.Send(conn, req); err != nil {
+		return "", err
+	}
+	for {
+		var resp struct {
+			ID     json.RawMessage `json:"id"`
+			Result string          `json:"result"`
+			Error  *rpc.RPCError   `json:"error"`
+		}
+		if err := websocket.JSON.Receive(conn, &resp); err != nil {
+			return "", err
+		}
+		if string(resp.ID) != "1" {
+			continue
+		}
+		if resp.Error != nil {
+			return "", resp.Error
+		}
+		return resp.Result, nil
+	}
+}
@@ -0,0 +1,66 @@
+package evm
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestSubscribeNewHeads(t *testing.T) {
+	subscribed := make(chan any, 1)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		if websocket.JSON.Receive(conn, &req) != nil {
+			return
+		}
+		subscribed <- req.Params
+		websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0xsub"})
+		notify := func(sub, number string) {
+			websocket.JSON.Send(conn, map[string]any{
+				"jsonrpc": "2.0", "method": "eth_subscription",
+				"params": map[string]any{"subscription": sub, "result": map[string]string{"number": number, "hash": "0xh" + number}},
+			})
+		}
+		notify("0xsub", "0x10")
+		notify("0xother", "0x11")
+		notify("0xsub", "0x12")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	heads, err := SubscribeNewHeads(ctx, "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	assert.Equal(t, []any{"newHeads"}, <-subscribed)
+
+	var got []Head
+	for head := range heads {
+		got = append(got, head)
+	}
+	assert.Equal(t, []Head{{Number: "0x10", Hash: "0xh0x10"}, {Number: "0x12", Hash: "0xh0x12"}}, got,
+		"other subscriptions are ignored, and the channel closes with the connection")
+}
+
+func TestSubscribeNewHeads_Error(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var req map[string]any
+		if websocket.JSON.Receive(conn, &req) != nil {
+			return
+		}
+		websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": 1, "error": map[string]any{"code": -32601, "message": "notifications not supported"}})
+	}))
+	defer server.Close()
+
+	_, err := SubscribeNewHeads(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	assert.ErrorContains(t, err, "notifications not supported")
+}
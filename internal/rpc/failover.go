@@ -2,7 +2,9 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"sort"
 	"strings"
@@ -15,11 +17,29 @@ import (
 
 // FailoverConfig defines runtime behavior of the failover system.
 type FailoverConfig struct {
-	HealthCheckInterval time.Duration
-	EnableBlacklisting  bool
-	MinActiveProviders  int
-	ErrorThreshold      int
-	DefaultTimeout      time.Duration
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+	EnableBlacklisting  bool          `yaml:"enable_blacklisting"`
+	MinActiveProviders  int           `yaml:"min_active_providers"`
+	ErrorThreshold      int           `yaml:"error_threshold"`
+	DefaultTimeout      time.Duration `yaml:"default_timeout"`
+	// MaxBlockLag is how many blocks a provider may trail the pool maximum
+	// before it is demoted to degraded. 0 disables height checks.
+	MaxBlockLag int `yaml:"max_block_lag"`
+
+	// ExecuteWithRetry makes up to MaxAttempts attempts, waiting a random
+	// backoff between 0 and InitialBackoff doubled per retry (capped at
+	// MaxBackoff) before each retry, within the caller's context deadline.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+
+	// HealthStateTTL is how long persisted provider health outlives its
+	// last save, see UseHealthStore. 0 disables persistence.
+	HealthStateTTL time.Duration `yaml:"health_state_ttl"`
+
+	// WarmUp probes each provider with a cheap call before it first serves
+	// requests, and again when its blacklist expires, see Failover.WarmUp.
+	WarmUp bool `yaml:"warm_up"`
 }
 
 func DefaultFailoverConfig() FailoverConfig {
@@ -29,6 +49,12 @@ func DefaultFailoverConfig() FailoverConfig {
 		MinActiveProviders:  2,
 		ErrorThreshold:      5,
 		DefaultTimeout:      10 * time.Second,
+		MaxBlockLag:         3,
+		MaxAttempts:         DefaultRetryMaxAttempts,
+		InitialBackoff:      DefaultInitialBackoff,
+		MaxBackoff:          DefaultMaxBackoff,
+		HealthStateTTL:      time.Hour,
+		WarmUp:              true,
 	}
 }
 
@@ -186,6 +212,39 @@ type Failover[T NetworkClient] struct {
 	lastHealthCheck time.Time
 	metrics         *FailoverMetrics
 	logThrottler    *LogThrottler
+	log             *slog.Logger
+
+	// retry backoff, replaceable in tests
+	clock clock
+	randN func(int64) int64
+
+	// health persistence, see UseHealthStore
+	healthMu       sync.Mutex
+	healthStore    HealthStore
+	healthSaving   bool
+	healthDirty    bool
+	lastHealthSave time.Time
+
+	// height checks, see maybeCheckHeights
+	heightMu           sync.Mutex
+	heightCheckRunning bool
+	lastHeightCheck    time.Time
+	heightSpread       uint64
+
+	// network checks, see ExpectNetwork
+	networkMu           sync.Mutex
+	expectedNetwork     NetworkIdentity
+	unverified          []*Provider
+	rejected            []string
+	networkCheckRunning bool
+	lastNetworkCheck    time.Time
+
+	// warm-up probes, see WarmUp
+	warmMu        sync.Mutex
+	warmUpEnabled bool
+	warming       []*Provider
+	warmUpRunning bool
+	lastWarmUp    time.Time
 }
 
 // NewFailover creates a new type-safe Failover[T]
@@ -200,19 +259,70 @@ func NewFailover[T NetworkClient](config *FailoverConfig) *Failover[T] {
 		config:       *config,
 		metrics:      NewFailoverMetrics(),
 		logThrottler: NewLogThrottler(30 * time.Second),
+		log:          logger.With(),
+		clock:        realClock{},
+		randN:        defaultRandN,
 	}
 }
 
-// GetMetrics returns a snapshot of current metrics
+// SetLogger makes the failover log to l, e.g. a logger scoped to the chain
+// the providers serve.
+func (f *Failover[T]) SetLogger(l *slog.Logger) {
+	f.log = l
+}
+
+// GetMetrics returns a snapshot of current metrics, including sampled
+// provider heights, the height spread across the pool and the providers
+// kept out of it by ExpectNetwork and WarmUp.
 func (f *Failover[T]) GetMetrics() map[string]interface{} {
-	return f.metrics.GetSnapshot()
+	snapshot := f.metrics.GetSnapshot()
+	heights, spread := f.heightSnapshot()
+	snapshot["provider_heights"] = heights
+	snapshot["height_spread"] = spread
+	unverified, rejected := f.networkSnapshot()
+	snapshot["unverified_providers"] = unverified
+	snapshot["rejected_providers"] = rejected
+	snapshot["warming_providers"] = f.warmingSnapshot()
+	return snapshot
+}
+
+// ProviderStatuses returns a status snapshot of every provider, in pool
+// order.
+func (f *Failover[T]) ProviderStatuses() []ProviderStatus {
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	curIdx := f.currentIndex
+	f.mu.RUnlock()
+
+	statuses := make([]ProviderStatus, len(providers))
+	for i, p := range providers {
+		statuses[i] = p.Status()
+		statuses[i].Current = i == curIdx
+	}
+	return statuses
+}
+
+// Providers returns every provider, blacklisted or not, in pool order.
+func (f *Failover[T]) Providers() []*Provider {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]*Provider(nil), f.providers...)
 }
 
-// AddProvider adds a provider, ensuring its Client is of type T
+// AddProvider adds a provider, ensuring its Client is of type T. Once
+// ExpectNetwork is set, the provider's network is checked first, see
+// ExpectNetwork, and once WarmUp is on it is probed, see WarmUp; an error
+// means it was not added.
 func (f *Failover[T]) AddProvider(p *Provider) error {
 	if _, ok := p.Client.(T); !ok {
 		return fmt.Errorf("invalid provider client type: expected %T, got %T", *new(T), p.Client)
 	}
+	if ok, err := f.admitProvider(p); !ok {
+		return err
+	}
+	if !f.admitWarm(p) {
+		return nil
+	}
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -220,12 +330,17 @@ func (f *Failover[T]) AddProvider(p *Provider) error {
 	if f.currentIndex == -1 {
 		f.currentIndex = 0
 	}
-	logger.Info("Added provider", "name", p.Name, "url", p.URL)
+	f.log.Info("Added provider", "name", p.Name, "url", p.URL)
 	return nil
 }
 
 // GetBestProvider returns the current best provider
 func (f *Failover[T]) GetBestProvider() (*Provider, error) {
+	f.maybeCheckNetworks()
+	f.maybeWarmUp()
+	// Check for expired blacklists and recover
+	f.recoverExpiredBlacklists(f.Providers())
+
 	f.mu.RLock()
 	if len(f.providers) == 0 {
 		f.mu.RUnlock()
@@ -235,14 +350,17 @@ func (f *Failover[T]) GetBestProvider() (*Provider, error) {
 	curIdx := f.currentIndex
 	f.mu.RUnlock()
 
-	// Check for expired blacklists and recover
-	f.recoverExpiredBlacklists(providers)
+	f.maybeCheckHeights()
 
 	if curIdx >= 0 && curIdx < len(providers) {
 		cur := providers[curIdx]
-		if cur.IsAvailable() {
+		if cur.IsAvailable() && !cur.IsLagging() {
 			return cur, nil
 		}
+		if cur.IsAvailable() {
+			// Lagging providers are a last resort
+			return f.findNextAvailableProvider()
+		}
 
 		if f.logThrottler.ShouldLog(fmt.Sprintf("unavailable_%s", cur.Name)) {
 			cur.mu.RLock()
@@ -250,7 +368,7 @@ func (f *Failover[T]) GetBestProvider() (*Provider, error) {
 			blacklistedUntil := cur.BlacklistedUntil
 			cur.mu.RUnlock()
 
-			logger.Warn("Current provider not available, finding alternative",
+			f.log.Warn("Current provider not available, finding alternative",
 				"provider", cur.Name,
 				"url", curURL,
 				"state", cur.State,
@@ -262,11 +380,17 @@ func (f *Failover[T]) GetBestProvider() (*Provider, error) {
 	return f.findNextAvailableProvider()
 }
 
-// recoverExpiredBlacklists checks and recovers any expired blacklisted providers
+// recoverExpiredBlacklists checks and recovers any expired blacklisted
+// providers. Once WarmUp is on they are probed first, out of the pool.
 func (f *Failover[T]) recoverExpiredBlacklists(providers []*Provider) {
+	warm := f.warmingUp()
 	for _, p := range providers {
 		if p.IsExpiredBlacklist() {
-			logger.Info("Recovering expired blacklisted provider", "provider", p.Name)
+			if warm {
+				f.rewarm(p)
+				continue
+			}
+			f.log.Info("Recovering expired blacklisted provider", "provider", p.Name)
 			p.Recover()
 			f.metrics.IncrementRecovery()
 		}
@@ -275,15 +399,13 @@ func (f *Failover[T]) recoverExpiredBlacklists(providers []*Provider) {
 
 // GetAvailableProviders returns all currently available providers
 func (f *Failover[T]) GetAvailableProviders() []*Provider {
+	f.recoverExpiredBlacklists(f.Providers())
+
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
 	var available []*Provider
 	for _, p := range f.providers {
-		if p.IsExpiredBlacklist() {
-			p.Recover()
-			f.metrics.IncrementRecovery()
-		}
 		if p.IsAvailable() {
 			available = append(available, p)
 		}
@@ -301,22 +423,24 @@ func (f *Failover[T]) findNextAvailableProvider() (*Provider, error) {
 	}
 
 	start := f.currentIndex
-	logger.Info("Searching for available provider",
+	f.log.Info("Searching for available provider",
 		"start_index", start,
 		"total_providers", len(f.providers))
 
-	for i := 0; i < len(f.providers); i++ {
+	// First pass skips lagging providers, second pass accepts them.
+	for i := 0; i < 2*len(f.providers); i++ {
 		idx := (start + i + 1) % len(f.providers)
 		provider := f.providers[idx]
+		allowLagging := i >= len(f.providers)
 
-		logger.Debug("Checking provider",
+		f.log.Debug("Checking provider",
 			"index", idx,
 			"provider", provider.Name,
 			"state", provider.State,
 			"available", provider.IsAvailable())
 
-		if provider.IsAvailable() {
-			logger.Info("Switching to provider",
+		if provider.IsAvailable() && (allowLagging || !provider.IsLagging()) {
+			f.log.Info("Switching to provider",
 				"from_index", f.currentIndex,
 				"to_index", idx,
 				"provider", provider.Name,
@@ -328,7 +452,7 @@ func (f *Failover[T]) findNextAvailableProvider() (*Provider, error) {
 		}
 	}
 
-	logger.Warn("No available providers found, attempting emergency recovery")
+	f.log.Warn("No available providers found, attempting emergency recovery")
 	return f.performEmergencyRecoveryLocked()
 }
 
@@ -359,7 +483,7 @@ func (f *Failover[T]) performEmergencyRecoveryLocked() (*Provider, error) {
 	f.currentIndex = 0
 	f.metrics.IncrementEmergencyRecovery()
 
-	logger.Info("Emergency recovery", "name", first.Name)
+	f.log.Info("Emergency recovery", "name", first.Name)
 	return first, nil
 }
 
@@ -384,9 +508,13 @@ func (f *Failover[T]) executeCore(ctx context.Context, provider *Provider, fn fu
 		issue := f.analyzeError(err, elapsed)
 		f.metrics.IncrementErrorType(issue.Reason)
 
-		if issue.MarkUnhealthy {
+		switch {
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrOverBudget):
+			// The data does not exist (yet), or the request was never sent;
+			// the provider is not at fault.
+		case issue.MarkUnhealthy:
 			f.handleUnhealthyProvider(provider, issue)
-		} else {
+		default:
 			f.handleProviderFailure(provider, err)
 		}
 		return err
@@ -394,6 +522,7 @@ func (f *Failover[T]) executeCore(ctx context.Context, provider *Provider, fn fu
 
 	f.metrics.IncrementSuccess()
 	provider.Success(elapsed)
+	f.maybeSaveHealth(false)
 	return nil
 }
 
@@ -407,7 +536,7 @@ func (f *Failover[T]) handleUnhealthyProvider(provider *Provider, issue Provider
 		state := provider.State
 		provider.mu.RUnlock()
 
-		logger.Warn("Switching provider due to error",
+		f.log.Warn("Switching provider due to error",
 			"provider", provider.Name,
 			"url", providerURL,
 			"state", state,
@@ -420,6 +549,7 @@ func (f *Failover[T]) handleUnhealthyProvider(provider *Provider, issue Provider
 
 	provider.Blacklist(issue.Cooldown)
 	f.metrics.IncrementBlacklist()
+	f.maybeSaveHealth(true)
 }
 
 // handleProviderFailure handles non-blacklist failures
@@ -430,7 +560,7 @@ func (f *Failover[T]) handleProviderFailure(provider *Provider, err error) {
 	consecutiveErrors := provider.ConsecutiveErrors
 	provider.mu.RUnlock()
 
-	logger.Debug("Provider failed but not switching",
+	f.log.Debug("Provider failed but not switching",
 		"provider", provider.Name,
 		"url", providerURL,
 		"state", state,
@@ -438,17 +568,58 @@ func (f *Failover[T]) handleProviderFailure(provider *Provider, err error) {
 		"consecutive_errors", consecutiveErrors,
 	)
 	provider.Fail(&f.config)
-}
-
-// ExecuteWithRetry runs fn with automatic failover & retry
+	f.maybeSaveHealth(false)
+}
+
+// ExecuteWithRetry runs fn with automatic failover & retry, see
+// FailoverConfig.MaxAttempts. The error class decides the next attempt:
+// not-found, over-budget and response-too-large errors are returned without
+// retrying, as another attempt would meet them again; rate-limit, auth,
+// timeout and connection errors blacklist the provider, so the next attempt
+// rotates to another one straight away; other errors retry the same
+// provider after a jittered exponential backoff. No backoff is started that would end past
+// ctx's deadline. Within a WithSession context the same provider is reused
+// until it errors.
 func (f *Failover[T]) ExecuteWithRetry(ctx context.Context, fn func(T) error) error {
-	return retry.Constant(func() error {
-		provider, err := f.GetBestProvider()
-		if err != nil {
-			return fmt.Errorf("no available provider: %w", err)
+	policy := f.config.retryPolicy()
+
+	var err error
+	rotate := false
+	for attempt := 1; attempt <= policy.attempts; attempt++ {
+		if attempt > 1 {
+			wait := policy.backoff(attempt-1, f.randN)
+			if rotate && len(f.GetAvailableProviders()) > 0 {
+				wait = 0
+			}
+			if !f.sleep(ctx, wait) {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return fmt.Errorf("%w after %d attempts: %w", ctxErr, attempt-1, err)
+				}
+				return fmt.Errorf("retry budget exhausted after %d attempts: %w", attempt-1, err)
+			}
 		}
-		return f.executeCore(ctx, provider, fn)
-	}, retry.DefaultInterval, retry.DefaultMaxAttempts)
+
+		provider, perr := f.GetSessionProvider(ctx)
+		if perr != nil {
+			err = fmt.Errorf("no available provider: %w", perr)
+			rotate = false
+			continue
+		}
+		err = f.executeCore(ctx, provider, fn)
+		releaseSession(ctx, provider, err)
+		if err == nil || isFinal(err) {
+			return err
+		}
+		rotate = !provider.IsAvailable()
+	}
+	return fmt.Errorf("failed after %d attempts: %w", policy.attempts, err)
+}
+
+// isFinal reports whether err would recur on retry: the data is not
+// there, the request budget is spent, or the response is over our own
+// size limit, which applies to every provider.
+func isFinal(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrOverBudget) || errors.Is(err, ErrResponseTooLarge)
 }
 
 // ExecuteWithRetryProvider runs fn against a specific provider with optional fallback
@@ -467,6 +638,9 @@ func (f *Failover[T]) ExecuteWithRetryProvider(
 		}
 
 		err := f.executeCore(ctx, provider, fn)
+		if isFinal(err) {
+			return retry.Permanent(err)
+		}
 		if err != nil && allowFallback {
 			// Skip fallback if already blacklisted
 			if provider.State == StateBlacklisted {
@@ -504,7 +678,7 @@ func (f *Failover[T]) attemptProviderSwitch(current *Provider) *Provider {
 		altState := alt.State
 		alt.mu.RUnlock()
 
-		logger.Warn("Provider already blacklisted, switching immediately",
+		f.log.Warn("Provider already blacklisted, switching immediately",
 			"from_provider", current.Name,
 			"from_url", currentURL,
 			"from_state", state,
@@ -536,7 +710,7 @@ func (f *Failover[T]) attemptFallback(current *Provider, err error) *Provider {
 	altState := alt.State
 	alt.mu.RUnlock()
 
-	logger.Warn("Fallback to alternative provider",
+	f.log.Warn("Fallback to alternative provider",
 		"from_provider", current.Name,
 		"from_url", currentURL,
 		"from_state", currentState,
@@ -575,7 +749,7 @@ func (f *Failover[T]) logProviderMetrics(p *Provider, elapsed time.Duration) {
 		StateBlacklisted: "🚫",
 	}[state]
 
-	logger.Info("Provider metrics",
+	f.log.Info("Provider metrics",
 		"name", p.Name,
 		"state", state,
 		"emoji", statusEmoji,
@@ -596,6 +770,43 @@ func (f *Failover[T]) analyzeError(err error, elapsed time.Duration) ProviderIss
 		MarkUnhealthy: false,
 	}
 
+	// Classified errors take precedence over message matching.
+	classPolicies := []struct {
+		class    error
+		reason   string
+		cooldown time.Duration
+	}{
+		// Our own limiter is back to full within seconds.
+		{ErrThrottled, "throttled", 5 * time.Second},
+		{ErrRateLimited, "rate_limit", 5 * time.Minute},
+		{ErrAuth, "auth", 24 * time.Hour},
+		{ErrTimeout, "timeout", 3 * time.Minute},
+		{ErrNodeBehind, "node_behind", 1 * time.Minute},
+		// A node serving corrupt blocks is unlikely to mend itself soon.
+		{ErrBadData, "bad_data", 30 * time.Minute},
+	}
+	if errors.Is(err, ErrNotFound) {
+		issue.Reason = "not_found"
+		return issue
+	}
+	if errors.Is(err, ErrOverBudget) {
+		issue.Reason = "over_budget"
+		return issue
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		// The node answered; a long read of an oversized body is not slowness.
+		issue.Reason = "response_too_large"
+		return issue
+	}
+	for _, policy := range classPolicies {
+		if errors.Is(err, policy.class) {
+			issue.Reason = policy.reason
+			issue.Cooldown = policy.cooldown
+			issue.MarkUnhealthy = true
+			return issue
+		}
+	}
+
 	errorPatterns := []struct {
 		patterns      []string
 		reason        string
@@ -674,9 +885,11 @@ func (f *Failover[T]) AnalyzeAndHandleError(provider *Provider, err error, elaps
 	issue := f.analyzeError(err, elapsed)
 	f.metrics.IncrementErrorType(issue.Reason)
 
-	if issue.MarkUnhealthy {
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case issue.MarkUnhealthy:
 		f.handleUnhealthyProvider(provider, issue)
-	} else {
+	default:
 		f.handleProviderFailure(provider, err)
 	}
 
@@ -687,6 +900,7 @@ func (f *Failover[T]) AnalyzeAndHandleError(provider *Provider, err error, elaps
 // Mirrors executeCore's success path: provider.Success(elapsed) + metric increments.
 func (f *Failover[T]) RecordSuccess(provider *Provider, elapsed time.Duration) {
 	provider.Success(elapsed)
+	f.maybeSaveHealth(false)
 	f.metrics.IncrementTotal()
 	f.metrics.IncrementProviderRequest(provider.Name)
 	f.metrics.IncrementSuccess()
@@ -704,6 +918,7 @@ func (f *Failover[T]) HandleCapabilityError(provider *Provider, elapsed time.Dur
 	f.metrics.IncrementErrorType("capability_error")
 	provider.Blacklist(cooldown)
 	f.metrics.IncrementBlacklist()
+	f.maybeSaveHealth(true)
 	f.logProviderMetrics(provider, elapsed)
 }
 
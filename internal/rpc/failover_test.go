@@ -2,10 +2,12 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -130,3 +132,255 @@ func TestHandleCapabilityError(t *testing.T) {
 	errorsByType := metrics["errors_by_type"].(map[string]int64)
 	assert.Equal(t, int64(1), errorsByType["capability_error"])
 }
+
+func TestAnalyzeAndHandleError_ClassifiedAuth(t *testing.T) {
+	f, p := newTestFailover()
+
+	err := &HTTPError{StatusCode: 401, Status: "401 Unauthorized", Body: "invalid api key"}
+	f.AnalyzeAndHandleError(p, err, 100*time.Millisecond)
+
+	assert.Equal(t, StateBlacklisted, p.State)
+	errorsByType := f.GetMetrics()["errors_by_type"].(map[string]int64)
+	assert.Equal(t, int64(1), errorsByType["auth"])
+}
+
+func TestAnalyzeAndHandleError_ThrottledCoolsDownBriefly(t *testing.T) {
+	f, p := newTestFailover()
+
+	err := WithClass(ErrRateLimited, WithClass(ErrThrottled, ratelimiter.ErrWaitBudgetExceeded))
+	f.AnalyzeAndHandleError(p, err, time.Millisecond)
+
+	assert.Equal(t, StateBlacklisted, p.State)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second), p.BlacklistedUntil, time.Second)
+	errorsByType := f.GetMetrics()["errors_by_type"].(map[string]int64)
+	assert.Equal(t, int64(1), errorsByType["throttled"])
+}
+
+func TestExecuteWithRetry_NotFoundIsNotRetried(t *testing.T) {
+	f, p := newTestFailover()
+
+	calls := 0
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		calls++
+		return &RPCError{Code: -5, Message: "Block not found"}
+	})
+
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, calls, "not-found must not be retried")
+	assert.Equal(t, StateHealthy, p.State, "not-found must not penalize the provider")
+	assert.Zero(t, p.ConsecutiveErrors)
+}
+
+func TestExecuteWithRetry_OverBudgetIsNotRetried(t *testing.T) {
+	f, p := newTestFailover()
+
+	calls := 0
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		calls++
+		return WithClass(ErrOverBudget, ratelimiter.ErrBudgetExhausted)
+	})
+
+	require.ErrorIs(t, err, ratelimiter.ErrBudgetExhausted)
+	assert.Equal(t, 1, calls, "another provider shares the budget")
+	assert.Equal(t, StateHealthy, p.State, "the budget is not the provider's fault")
+	assert.Zero(t, p.ConsecutiveErrors)
+}
+
+func TestExecuteWithRetry_ResponseTooLargeIsNotRetried(t *testing.T) {
+	f, p := newTestFailover()
+
+	calls := 0
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		calls++
+		return responseTooLarge(1024)
+	})
+
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Equal(t, 1, calls, "the limit is ours, the same request would exceed it again")
+	assert.Equal(t, StateHealthy, p.State)
+}
+
+// heightClient reports a fixed chain height via getblockcount.
+type heightClient struct {
+	mockNetworkClient
+	height string
+}
+
+func (c *heightClient) CallRPC(_ context.Context, method string, _ any) (*RPCResponse, error) {
+	if method != "getblockcount" {
+		return nil, fmt.Errorf("unexpected method %s", method)
+	}
+	return &RPCResponse{Result: json.RawMessage(c.height)}, nil
+}
+func (c *heightClient) GetNetworkType() string { return "bitcoin" }
+
+func newHeightFailover(heights ...string) (*Failover[NetworkClient], []*Provider) {
+	cfg := DefaultFailoverConfig()
+	cfg.MaxBlockLag = 3
+	f := NewFailover[NetworkClient](&cfg)
+	var providers []*Provider
+	for i, h := range heights {
+		p := &Provider{
+			Name:       fmt.Sprintf("node-%d", i),
+			URL:        "http://mock",
+			Network:    "bitcoin",
+			ClientType: "rpc",
+			Client:     &heightClient{height: h},
+			State:      StateHealthy,
+		}
+		f.AddProvider(p)
+		providers = append(providers, p)
+	}
+	return f, providers
+}
+
+func TestCheckHeights_DemotesLaggingProvider(t *testing.T) {
+	f, providers := newHeightFailover("90", "100", "98")
+
+	f.checkHeights(context.Background())
+
+	assert.Equal(t, StateDegraded, providers[0].State)
+	assert.True(t, providers[0].Lagging)
+	assert.Equal(t, uint64(10), providers[0].Lag)
+	assert.Equal(t, StateHealthy, providers[1].State)
+	assert.Equal(t, StateHealthy, providers[2].State, "lag within max_block_lag is tolerated")
+
+	metrics := f.GetMetrics()
+	assert.Equal(t, uint64(10), metrics["height_spread"])
+	heights := metrics["provider_heights"].(map[string]map[string]uint64)
+	assert.Equal(t, map[string]uint64{"height": 90, "lag": 10}, heights["node-0"])
+	assert.Equal(t, map[string]uint64{"height": 98, "lag": 2}, heights["node-2"])
+
+	// A successful call must not promote a lagging provider back to healthy.
+	providers[0].Success(10 * time.Millisecond)
+	assert.Equal(t, StateDegraded, providers[0].State)
+}
+
+func TestCheckHeights_RecoversWhenCaughtUp(t *testing.T) {
+	f, providers := newHeightFailover("90", "100")
+	f.checkHeights(context.Background())
+	require.True(t, providers[0].Lagging)
+
+	providers[0].Client.(*heightClient).height = "100"
+	f.checkHeights(context.Background())
+
+	assert.False(t, providers[0].Lagging)
+	assert.Equal(t, StateHealthy, providers[0].State)
+}
+
+// warningClient is a heightClient whose node reports warnings.
+type warningClient struct {
+	heightClient
+	warnings []string
+}
+
+func (c *warningClient) NodeWarnings(context.Context) ([]string, error) {
+	return c.warnings, nil
+}
+
+func TestCheckHeights_DegradesProviderWithWarnings(t *testing.T) {
+	f, providers := newHeightFailover("100")
+	client := &warningClient{heightClient: heightClient{height: "100"}, warnings: []string{"Unknown new rules activated (versionbit 28)"}}
+	providers[0].Client = client
+
+	f.checkHeights(context.Background())
+	assert.Equal(t, StateDegraded, providers[0].State)
+	assert.Equal(t, client.warnings, providers[0].Status().Warnings)
+	assert.True(t, providers[0].IsAvailable(), "warnings must not blacklist the provider")
+
+	providers[0].Success(10 * time.Millisecond)
+	assert.Equal(t, StateDegraded, providers[0].State)
+	assert.False(t, providers[0].SetWarnings(client.warnings), "unchanged warnings are no transition")
+
+	client.warnings = nil
+	f.checkHeights(context.Background())
+	assert.Equal(t, StateHealthy, providers[0].State)
+	assert.Empty(t, providers[0].Status().Warnings)
+}
+
+func TestGetBestProvider_PrefersNonLagging(t *testing.T) {
+	f, providers := newHeightFailover("90", "100")
+	f.checkHeights(context.Background())
+
+	best, err := f.GetBestProvider()
+	require.NoError(t, err)
+	assert.Equal(t, providers[1].Name, best.Name)
+
+	// With only lagging providers left, they are still used as a last resort.
+	providers[1].Blacklist(time.Hour)
+	best, err = f.GetBestProvider()
+	require.NoError(t, err)
+	assert.Equal(t, providers[0].Name, best.Name)
+}
+
+func newTwoProviderFailover(t *testing.T) (*Failover[NetworkClient], *Provider, *Provider) {
+	t.Helper()
+	f := NewFailover[NetworkClient](nil)
+	a := &Provider{Name: "a", URL: "http://a", ClientType: "rpc", Client: &mockNetworkClient{}, State: StateHealthy}
+	b := &Provider{Name: "b", URL: "http://b", ClientType: "rpc", Client: &mockNetworkClient{}, State: StateHealthy}
+	require.NoError(t, f.AddProvider(a))
+	require.NoError(t, f.AddProvider(b))
+	return f, a, b
+}
+
+func TestSession_PinsProvider(t *testing.T) {
+	f, a, b := newTwoProviderFailover(t)
+	ctx := WithSession(context.Background())
+
+	var used []string
+	call := func(ctx context.Context) {
+		require.NoError(t, f.ExecuteWithRetry(ctx, func(NetworkClient) error { return nil }))
+		p, err := f.GetSessionProvider(ctx)
+		require.NoError(t, err)
+		used = append(used, p.Name)
+	}
+
+	call(ctx)
+	// Another caller moves the pool to b; the session keeps using a.
+	f.mu.Lock()
+	f.currentIndex = 1
+	f.mu.Unlock()
+	call(ctx)
+	call(context.Background())
+
+	assert.Equal(t, []string{a.Name, a.Name, b.Name}, used)
+	assert.Same(t, ctx, WithSession(ctx), "nested sessions reuse the outer one")
+}
+
+func TestSession_SwitchesOnlyOnError(t *testing.T) {
+	f, a, b := newTwoProviderFailover(t)
+	ctx := WithSession(context.Background())
+
+	p, err := f.GetSessionProvider(ctx)
+	require.NoError(t, err)
+	require.Same(t, a, p)
+
+	// Not-found keeps the pin.
+	releaseSession(ctx, a, WithClass(ErrNotFound, fmt.Errorf("block not found")))
+	p, _ = f.GetSessionProvider(ctx)
+	assert.Same(t, a, p)
+
+	// A provider error releases it and the next pick is re-pinned.
+	a.Blacklist(time.Hour)
+	releaseSession(ctx, a, WithClass(ErrRateLimited, fmt.Errorf("429")))
+	p, err = f.GetSessionProvider(ctx)
+	require.NoError(t, err)
+	assert.Same(t, b, p)
+
+	a.Recover()
+	p, _ = f.GetSessionProvider(ctx)
+	assert.Same(t, b, p, "session stays on the new provider")
+}
+
+func TestProviderStatuses_MarksCurrent(t *testing.T) {
+	f, providers := newHeightFailover("100", "100")
+	providers[0].Blacklist(time.Minute)
+
+	best, err := f.GetBestProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", best.Name)
+
+	statuses := f.ProviderStatuses()
+	assert.False(t, statuses[0].Current)
+	assert.True(t, statuses[1].Current)
+}
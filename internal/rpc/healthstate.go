@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// healthSaveInterval bounds how often routine outcomes are persisted;
+	// blacklisting a provider saves right away.
+	healthSaveInterval = 30 * time.Second
+	// healthStoreTimeout bounds each load or save round, so a slow or
+	// unreachable store delays neither startup nor RPC calls for long.
+	healthStoreTimeout = 2 * time.Second
+	// degradedErrorRate is the restored error rate from which a provider
+	// starts degraded instead of healthy.
+	degradedErrorRate = 0.5
+)
+
+// ProviderHealth is the part of a provider's state kept across restarts.
+type ProviderHealth struct {
+	BlacklistedUntil time.Time         `json:"blacklisted_until,omitzero"`
+	ErrorRate        float64           `json:"error_rate"`
+	Capabilities     map[string]string `json:"capabilities,omitempty"`
+}
+
+// HealthStore persists ProviderHealth keyed by node URL. Entries expire
+// ttl after they were last saved.
+type HealthStore interface {
+	LoadHealth(ctx context.Context, url string) (ProviderHealth, bool, error)
+	SaveHealth(ctx context.Context, url string, health ProviderHealth, ttl time.Duration) error
+}
+
+// CapabilityClient is implemented by clients that learn at runtime what
+// their node supports, e.g. whether a Bitcoin node has txindex, so that it
+// is persisted with the provider's health.
+type CapabilityClient interface {
+	Capabilities() map[string]string
+	RestoreCapabilities(caps map[string]string)
+}
+
+// health returns the provider's persistable state.
+func (p *Provider) health() ProviderHealth {
+	p.mu.RLock()
+	h := ProviderHealth{ErrorRate: p.ErrorRate}
+	if p.State == StateBlacklisted {
+		h.BlacklistedUntil = p.BlacklistedUntil
+	}
+	p.mu.RUnlock()
+
+	if cc, ok := p.Client.(CapabilityClient); ok {
+		h.Capabilities = cc.Capabilities()
+	}
+	return h
+}
+
+// restoreHealth applies state saved by a previous run. An expired blacklist
+// is dropped, and a provider that was mostly failing starts degraded.
+func (p *Provider) restoreHealth(h ProviderHealth) {
+	p.mu.Lock()
+	p.ErrorRate = h.ErrorRate
+	switch {
+	case time.Now().Before(h.BlacklistedUntil):
+		p.State = StateBlacklisted
+		p.BlacklistedUntil = h.BlacklistedUntil
+	case h.ErrorRate >= degradedErrorRate && p.State == StateHealthy:
+		p.State = StateDegraded
+	}
+	p.mu.Unlock()
+
+	if cc, ok := p.Client.(CapabilityClient); ok && len(h.Capabilities) > 0 {
+		cc.RestoreCapabilities(h.Capabilities)
+	}
+}
+
+// UseHealthStore restores the providers' health saved by a previous run and
+// persists it from then on. Persistence is best effort: load errors are
+// logged and skipped, and saves run in the background. It is a no-op when
+// FailoverConfig.HealthStateTTL is 0.
+func (f *Failover[T]) UseHealthStore(ctx context.Context, store HealthStore) {
+	if store == nil || f.config.HealthStateTTL <= 0 {
+		return
+	}
+
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	f.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, healthStoreTimeout)
+	defer cancel()
+	for _, p := range providers {
+		h, found, err := store.LoadHealth(ctx, p.URL)
+		if err != nil {
+			f.log.Warn("Failed to load provider health", "provider", p.Name, "error", err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if !found {
+			continue
+		}
+		p.restoreHealth(h)
+		f.log.Info("Restored provider health",
+			"provider", p.Name,
+			"state", p.Status().State,
+			"error_rate", h.ErrorRate,
+			"capabilities", h.Capabilities,
+		)
+	}
+
+	f.healthMu.Lock()
+	f.healthStore = store
+	f.lastHealthSave = time.Now()
+	f.healthMu.Unlock()
+}
+
+// maybeSaveHealth starts a background save of every provider's health when
+// healthSaveInterval has passed since the last one, or right away when
+// force is set. At most one save runs at a time; a forced save requested
+// meanwhile runs once the current one is done.
+func (f *Failover[T]) maybeSaveHealth(force bool) {
+	f.healthMu.Lock()
+	defer f.healthMu.Unlock()
+
+	if f.healthStore == nil || (!force && time.Since(f.lastHealthSave) < healthSaveInterval) {
+		return
+	}
+	if f.healthSaving {
+		f.healthDirty = f.healthDirty || force
+		return
+	}
+	f.healthSaving = true
+	f.lastHealthSave = time.Now()
+	go f.saveHealth(f.healthStore)
+}
+
+func (f *Failover[T]) saveHealth(store HealthStore) {
+	for {
+		f.saveHealthOnce(store)
+
+		f.healthMu.Lock()
+		if !f.healthDirty {
+			f.healthSaving = false
+			f.healthMu.Unlock()
+			return
+		}
+		f.healthDirty = false
+		f.lastHealthSave = time.Now()
+		f.healthMu.Unlock()
+	}
+}
+
+func (f *Failover[T]) saveHealthOnce(store HealthStore) {
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	f.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthStoreTimeout)
+	defer cancel()
+	for _, p := range providers {
+		if err := store.SaveHealth(ctx, p.URL, p.health(), f.config.HealthStateTTL); err != nil {
+			if f.logThrottler.ShouldLog("save_health") {
+				f.log.Warn("Failed to save provider health", "provider", p.Name, "error", err)
+			}
+			return
+		}
+	}
+}
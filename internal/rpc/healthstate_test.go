@@ -0,0 +1,142 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memHealthStore is an in-memory HealthStore; err fails every call.
+type memHealthStore struct {
+	mu      sync.Mutex
+	entries map[string]ProviderHealth
+	ttls    map[string]time.Duration
+	err     error
+}
+
+func newMemHealthStore() *memHealthStore {
+	return &memHealthStore{entries: map[string]ProviderHealth{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *memHealthStore) LoadHealth(_ context.Context, url string) (ProviderHealth, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return ProviderHealth{}, false, s.err
+	}
+	h, ok := s.entries[url]
+	return h, ok, nil
+}
+
+func (s *memHealthStore) SaveHealth(_ context.Context, url string, h ProviderHealth, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.entries[url] = h
+	s.ttls[url] = ttl
+	return nil
+}
+
+func (s *memHealthStore) get(url string) (ProviderHealth, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.entries[url]
+	return h, ok
+}
+
+// capClient records restored capabilities.
+type capClient struct {
+	mockNetworkClient
+	caps map[string]string
+}
+
+func (c *capClient) Capabilities() map[string]string            { return c.caps }
+func (c *capClient) RestoreCapabilities(caps map[string]string) { c.caps = caps }
+
+func TestUseHealthStore_RestoresState(t *testing.T) {
+	f, a, b := newTwoProviderFailover(t)
+	client := &capClient{}
+	b.Client = client
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	store := newMemHealthStore()
+	store.entries[a.URL] = ProviderHealth{BlacklistedUntil: until, ErrorRate: 0.9}
+	store.entries[b.URL] = ProviderHealth{
+		BlacklistedUntil: time.Now().Add(-time.Minute),
+		ErrorRate:        0.6,
+		Capabilities:     map[string]string{"txindex": "disabled"},
+	}
+	f.UseHealthStore(context.Background(), store)
+
+	assert.Equal(t, StateBlacklisted, a.State)
+	assert.Equal(t, until, a.BlacklistedUntil)
+	assert.False(t, a.IsAvailable())
+	assert.Equal(t, StateDegraded, b.State, "an expired blacklist is dropped, the error rate kept")
+	assert.InDelta(t, 0.6, b.ErrorRate, 1e-9)
+	assert.Equal(t, map[string]string{"txindex": "disabled"}, client.caps)
+
+	best, err := f.GetBestProvider()
+	require.NoError(t, err)
+	assert.Same(t, b, best, "the node blacklisted before the restart is skipped")
+}
+
+func TestUseHealthStore_SavesBlacklistRightAway(t *testing.T) {
+	f, p := newTestFailover()
+	f.config.MaxAttempts = 1
+	store := newMemHealthStore()
+	f.UseHealthStore(context.Background(), store)
+
+	err := f.ExecuteWithRetry(context.Background(), func(NetworkClient) error {
+		return WithClass(ErrAuth, errors.New("401 unauthorized"))
+	})
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		h, ok := store.get(p.URL)
+		return ok && h.BlacklistedUntil.After(time.Now())
+	}, time.Second, 5*time.Millisecond)
+	h, _ := store.get(p.URL)
+	assert.Greater(t, h.ErrorRate, 0.0)
+	store.mu.Lock()
+	assert.Equal(t, time.Hour, store.ttls[p.URL])
+	store.mu.Unlock()
+}
+
+func TestUseHealthStore_StoreDownDoesNotBlockCalls(t *testing.T) {
+	f, p := newTestFailover()
+	store := newMemHealthStore()
+	store.err = errors.New("connection refused")
+	f.UseHealthStore(context.Background(), store)
+
+	assert.Equal(t, StateHealthy, p.State)
+	p.Blacklist(time.Minute)
+	p.Recover()
+	require.NoError(t, f.ExecuteWithRetry(context.Background(), func(NetworkClient) error { return nil }))
+	f.HandleCapabilityError(p, 0, time.Minute)
+	assert.False(t, p.IsAvailable())
+}
+
+func TestUseHealthStore_DisabledByZeroTTL(t *testing.T) {
+	cfg := DefaultFailoverConfig()
+	cfg.HealthStateTTL = 0
+	f := NewFailover[NetworkClient](&cfg)
+	p := &Provider{Name: "p", URL: "http://p", Client: &mockNetworkClient{}, State: StateHealthy}
+	require.NoError(t, f.AddProvider(p))
+
+	store := newMemHealthStore()
+	store.entries[p.URL] = ProviderHealth{BlacklistedUntil: time.Now().Add(time.Hour)}
+	f.UseHealthStore(context.Background(), store)
+
+	assert.True(t, p.IsAvailable())
+	f.HandleCapabilityError(p, 0, time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	h, _ := store.get(p.URL)
+	assert.True(t, h.BlacklistedUntil.After(time.Now().Add(30*time.Minute)), "the entry is left untouched")
+}
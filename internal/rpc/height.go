@@ -0,0 +1,174 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type heightProbe struct {
+	method string
+	parse  func(raw json.RawMessage) (uint64, error)
+}
+
+// heightProbes maps a client network type to the JSON-RPC call that returns
+// the node's chain height. Network types without a probe are not sampled.
+var heightProbes = map[string]heightProbe{
+	NetworkBitcoin: {method: "getblockcount", parse: parseDecimalHeight},
+	NetworkEVM:     {method: "eth_blockNumber", parse: parseHexHeight},
+}
+
+func parseDecimalHeight(raw json.RawMessage) (uint64, error) {
+	var h uint64
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return 0, fmt.Errorf("decode height: %w", err)
+	}
+	return h, nil
+}
+
+func parseHexHeight(raw json.RawMessage) (uint64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, fmt.Errorf("decode height: %w", err)
+	}
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
+
+// probeHeight asks a client for its chain height. ok is false when the
+// client's network type has no height probe.
+func probeHeight(ctx context.Context, client NetworkClient) (height uint64, ok bool, err error) {
+	probe, ok := heightProbes[client.GetNetworkType()]
+	if !ok || client.GetClientType() != ClientTypeRPC {
+		return 0, false, nil
+	}
+	resp, err := client.CallRPC(ctx, probe.method, nil)
+	if err != nil {
+		return 0, true, err
+	}
+	if resp == nil {
+		return 0, true, fmt.Errorf("%s: empty response", probe.method)
+	}
+	height, err = probe.parse(resp.Result)
+	return height, true, err
+}
+
+// maybeCheckHeights starts a background height check when the health check
+// interval has elapsed since the last one. At most one check runs at a time.
+func (f *Failover[T]) maybeCheckHeights() {
+	if f.config.MaxBlockLag <= 0 || f.config.HealthCheckInterval <= 0 {
+		return
+	}
+	f.heightMu.Lock()
+	if f.heightCheckRunning || time.Since(f.lastHeightCheck) < f.config.HealthCheckInterval {
+		f.heightMu.Unlock()
+		return
+	}
+	f.heightCheckRunning = true
+	f.lastHeightCheck = time.Now()
+	f.heightMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), f.config.DefaultTimeout)
+		defer cancel()
+		f.checkHeights(ctx)
+
+		f.heightMu.Lock()
+		f.heightCheckRunning = false
+		f.heightMu.Unlock()
+	}()
+}
+
+// checkHeights samples every provider's chain height, records the lag behind
+// the pool maximum and demotes providers lagging more than MaxBlockLag. The
+// warnings of providers whose client is a WarningReporter are checked too.
+func (f *Failover[T]) checkHeights(ctx context.Context) {
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	f.mu.RUnlock()
+
+	heights := make(map[*Provider]uint64, len(providers))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, p := range providers {
+		wg.Add(1)
+		go func(p *Provider) {
+			defer wg.Done()
+			if wr, ok := p.Client.(WarningReporter); ok {
+				f.checkWarnings(ctx, p, wr)
+			}
+			h, ok, err := probeHeight(ctx, p.Client)
+			if !ok {
+				return
+			}
+			if err != nil {
+				f.log.Debug("Height probe failed", "provider", p.Name, "error", err)
+				return
+			}
+			mu.Lock()
+			heights[p] = h
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	if len(heights) == 0 {
+		return
+	}
+
+	var poolMax, poolMin uint64
+	for _, h := range heights {
+		if h > poolMax {
+			poolMax = h
+		}
+		if poolMin == 0 || h < poolMin {
+			poolMin = h
+		}
+	}
+
+	f.heightMu.Lock()
+	f.heightSpread = poolMax - poolMin
+	f.lastHeightCheck = time.Now()
+	f.heightMu.Unlock()
+
+	maxLag := uint64(f.config.MaxBlockLag)
+	for p, h := range heights {
+		lag := poolMax - h
+		if p.SetHeight(h, lag, lag > maxLag) {
+			f.log.Warn("Demoting lagging provider",
+				"provider", p.Name,
+				"url", p.URL,
+				"height", h,
+				"pool_max", poolMax,
+				"lag", lag,
+				"max_lag", maxLag,
+			)
+		}
+	}
+}
+
+// heightSnapshot returns per-provider height and lag plus the pool spread.
+func (f *Failover[T]) heightSnapshot() (map[string]map[string]uint64, uint64) {
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	f.mu.RUnlock()
+
+	out := make(map[string]map[string]uint64, len(providers))
+	for _, p := range providers {
+		p.mu.RLock()
+		if !p.HeightCheckedAt.IsZero() {
+			out[p.Name] = map[string]uint64{"height": p.Height, "lag": p.Lag}
+		}
+		p.mu.RUnlock()
+	}
+
+	f.heightMu.Lock()
+	spread := f.heightSpread
+	f.heightMu.Unlock()
+	return out, spread
+}
@@ -0,0 +1,315 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NetworkIdentity names the network a chain's nodes must serve. Empty
+// fields are not checked.
+type NetworkIdentity struct {
+	// ChainID is the node's chain identifier: getblockchaininfo's chain on
+	// Bitcoin, eth_chainId in decimal on EVM chains, the CometBFT network
+	// on Cosmos chains and the ledger chain_id on Aptos.
+	ChainID string
+	// GenesisHash is the hash of the node's block 0: getGenesisHash on
+	// Solana, the genesis blockID on Tron.
+	GenesisHash string
+}
+
+// IsZero reports whether there is nothing to check.
+func (n NetworkIdentity) IsZero() bool {
+	return n == NetworkIdentity{}
+}
+
+// ChainIDReporter is implemented by clients that can ask their node for its
+// chain ID.
+type ChainIDReporter interface {
+	ChainID(ctx context.Context) (string, error)
+}
+
+// GenesisHashReporter is implemented by clients that can ask their node for
+// its genesis block hash.
+type GenesisHashReporter interface {
+	GenesisHash(ctx context.Context) (string, error)
+}
+
+// ErrWrongNetwork is returned for a node serving another network than
+// expected.
+var ErrWrongNetwork = errors.New("node serves another network")
+
+// canCheckNetwork returns an error when client cannot report an identifier
+// want sets.
+func canCheckNetwork(client NetworkClient, want NetworkIdentity) error {
+	if _, ok := client.(ChainIDReporter); want.ChainID != "" && !ok {
+		return fmt.Errorf("%s nodes do not report a chain ID", client.GetNetworkType())
+	}
+	if _, ok := client.(GenesisHashReporter); want.GenesisHash != "" && !ok {
+		return fmt.Errorf("%s nodes do not report a genesis hash", client.GetNetworkType())
+	}
+	return nil
+}
+
+// checkNetwork verifies that client's node serves want, returning an error
+// wrapping ErrWrongNetwork when it does not.
+func checkNetwork(ctx context.Context, client NetworkClient, want NetworkIdentity) error {
+	if err := canCheckNetwork(client, want); err != nil {
+		return err
+	}
+	if want.ChainID != "" {
+		got, err := client.(ChainIDReporter).ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("get chain ID: %w", err)
+		}
+		if !sameNetworkID(got, want.ChainID) {
+			return fmt.Errorf("%w: chain ID is %s, expected %s", ErrWrongNetwork, got, want.ChainID)
+		}
+	}
+	if want.GenesisHash != "" {
+		got, err := client.(GenesisHashReporter).GenesisHash(ctx)
+		if err != nil {
+			return fmt.Errorf("get genesis hash: %w", err)
+		}
+		if !sameNetworkID(got, want.GenesisHash) {
+			return fmt.Errorf("%w: genesis hash is %s, expected %s", ErrWrongNetwork, got, want.GenesisHash)
+		}
+	}
+	return nil
+}
+
+// sameNetworkID compares identifiers, hex ones regardless of case and of a
+// 0x prefix.
+func sameNetworkID(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == b {
+		return true
+	}
+	a, b = strings.TrimPrefix(a, "0x"), strings.TrimPrefix(b, "0x")
+	return isHex(a) && isHex(b) && strings.EqualFold(a, b)
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectNetwork makes the pool admit only providers whose node serves want.
+// Every provider is checked now, and every one added later before it joins.
+// A provider on another network is removed from the pool and logged as an
+// error; one that cannot be checked is held out of the pool and checked
+// again each health check interval. It returns an error when the providers'
+// nodes cannot report an identifier want sets, or when every provider is on
+// another network.
+func (f *Failover[T]) ExpectNetwork(ctx context.Context, want NetworkIdentity) error {
+	if want.IsZero() {
+		return nil
+	}
+	f.mu.RLock()
+	providers := append([]*Provider(nil), f.providers...)
+	f.mu.RUnlock()
+	for _, p := range providers {
+		if err := canCheckNetwork(p.Client, want); err != nil {
+			return err
+		}
+	}
+
+	f.networkMu.Lock()
+	f.expectedNetwork = want
+	f.networkMu.Unlock()
+
+	results := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func(i int, p *Provider) {
+			defer wg.Done()
+			results[i] = checkNetwork(ctx, p.Client, want)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var wrong []error
+	for i, p := range providers {
+		switch err := results[i]; {
+		case errors.Is(err, ErrWrongNetwork):
+			f.rejectProvider(p, err)
+			wrong = append(wrong, fmt.Errorf("provider %s: %w", p.Name, err))
+		case err != nil:
+			f.holdProvider(p, err)
+		default:
+			f.log.Info("Provider network verified", "provider", p.Name)
+		}
+	}
+	if len(providers) > 0 && len(wrong) == len(providers) {
+		return fmt.Errorf("no provider serves the expected network: %w", errors.Join(wrong...))
+	}
+	return nil
+}
+
+// expected returns the network providers must serve, zero when unchecked.
+func (f *Failover[T]) expected() NetworkIdentity {
+	f.networkMu.Lock()
+	defer f.networkMu.Unlock()
+	return f.expectedNetwork
+}
+
+// admitProvider checks a provider about to be added against the expected
+// network. It reports whether the provider may join the pool now; an error
+// means it never will.
+func (f *Failover[T]) admitProvider(p *Provider) (bool, error) {
+	want := f.expected()
+	if want.IsZero() {
+		return true, nil
+	}
+	if err := canCheckNetwork(p.Client, want); err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.DefaultTimeout)
+	defer cancel()
+	err := checkNetwork(ctx, p.Client, want)
+	switch {
+	case errors.Is(err, ErrWrongNetwork):
+		f.rejectProvider(p, err)
+		return false, fmt.Errorf("provider %s: %w", p.Name, err)
+	case err != nil:
+		f.holdProvider(p, err)
+		return false, nil
+	}
+	f.log.Info("Provider network verified", "provider", p.Name)
+	return true, nil
+}
+
+// rejectProvider removes a provider on another network from the pool for
+// good.
+func (f *Failover[T]) rejectProvider(p *Provider, err error) {
+	f.removeProvider(p)
+	f.log.Error("Excluding provider serving another network",
+		"provider", p.Name,
+		"url", p.URL,
+		"expected_chain_id", f.expected().ChainID,
+		"expected_genesis_hash", f.expected().GenesisHash,
+		"error", err,
+	)
+	f.networkMu.Lock()
+	f.rejected = append(f.rejected, p.Name)
+	f.networkMu.Unlock()
+}
+
+// holdProvider moves a provider whose network could not be checked out of
+// the pool until a later check succeeds.
+func (f *Failover[T]) holdProvider(p *Provider, err error) {
+	f.removeProvider(p)
+	f.log.Warn("Could not check provider network, holding it out of the pool", "provider", p.Name, "error", err)
+	f.networkMu.Lock()
+	f.unverified = append(f.unverified, p)
+	f.networkMu.Unlock()
+}
+
+// removeProvider drops p from the pool, keeping the current provider
+// current when it is another one.
+func (f *Failover[T]) removeProvider(p *Provider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var current *Provider
+	if f.currentIndex >= 0 && f.currentIndex < len(f.providers) {
+		current = f.providers[f.currentIndex]
+	}
+	kept := f.providers[:0]
+	for _, q := range f.providers {
+		if q != p {
+			kept = append(kept, q)
+		}
+	}
+	f.providers = kept
+	f.currentIndex = -1
+	for i, q := range f.providers {
+		if q == current {
+			f.currentIndex = i
+		}
+	}
+	if f.currentIndex == -1 && len(f.providers) > 0 {
+		f.currentIndex = 0
+	}
+}
+
+// maybeCheckNetworks starts a background check of the providers held out of
+// the pool when the health check interval has elapsed since the last one.
+// At most one check runs at a time.
+func (f *Failover[T]) maybeCheckNetworks() {
+	f.networkMu.Lock()
+	if len(f.unverified) == 0 || f.networkCheckRunning ||
+		time.Since(f.lastNetworkCheck) < f.config.HealthCheckInterval {
+		f.networkMu.Unlock()
+		return
+	}
+	f.networkCheckRunning = true
+	f.lastNetworkCheck = time.Now()
+	f.networkMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), f.config.DefaultTimeout)
+		defer cancel()
+		f.checkUnverified(ctx)
+
+		f.networkMu.Lock()
+		f.networkCheckRunning = false
+		f.networkMu.Unlock()
+	}()
+}
+
+// checkUnverified checks the providers held out of the pool, adding those
+// on the expected network to it and dropping those on another.
+func (f *Failover[T]) checkUnverified(ctx context.Context) {
+	f.networkMu.Lock()
+	pending := f.unverified
+	f.unverified = nil
+	want := f.expectedNetwork
+	f.networkMu.Unlock()
+
+	var held []*Provider
+	for _, p := range pending {
+		err := checkNetwork(ctx, p.Client, want)
+		switch {
+		case errors.Is(err, ErrWrongNetwork):
+			f.rejectProvider(p, err)
+		case err != nil:
+			f.log.Debug("Provider network still unchecked", "provider", p.Name, "error", err)
+			held = append(held, p)
+		default:
+			f.log.Info("Provider network verified, adding it to the pool", "provider", p.Name)
+			f.mu.Lock()
+			f.providers = append(f.providers, p)
+			if f.currentIndex == -1 {
+				f.currentIndex = 0
+			}
+			f.mu.Unlock()
+		}
+	}
+
+	f.networkMu.Lock()
+	f.unverified = append(f.unverified, held...)
+	f.networkMu.Unlock()
+}
+
+// networkSnapshot returns the names of the providers held out of the pool
+// and of those rejected for serving another network.
+func (f *Failover[T]) networkSnapshot() (unverified, rejected []string) {
+	f.networkMu.Lock()
+	defer f.networkMu.Unlock()
+	unverified = make([]string, 0, len(f.unverified))
+	for _, p := range f.unverified {
+		unverified = append(unverified, p.Name)
+	}
+	return unverified, append([]string{}, f.rejected...)
+}
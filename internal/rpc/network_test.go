@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainIDClient reports a chain ID, or fails while down.
+type chainIDClient struct {
+	mockNetworkClient
+	mu      sync.Mutex
+	chainID string
+	down    bool
+}
+
+func (c *chainIDClient) ChainID(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return "", errors.New("connection refused")
+	}
+	return c.chainID, nil
+}
+
+func (c *chainIDClient) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func chainIDProvider(name string, client *chainIDClient) *Provider {
+	return &Provider{Name: name, URL: "http://" + name, ClientType: "rpc", Client: client, State: StateHealthy}
+}
+
+func poolNames(f *Failover[NetworkClient]) []string {
+	var names []string
+	for _, p := range f.Providers() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestExpectNetwork_ExcludesWrongAndHoldsUnchecked(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	down := &chainIDClient{chainID: "1", down: true}
+	require.NoError(t, f.AddProvider(chainIDProvider("mainnet", &chainIDClient{chainID: "1"})))
+	require.NoError(t, f.AddProvider(chainIDProvider("sepolia", &chainIDClient{chainID: "11155111"})))
+	require.NoError(t, f.AddProvider(chainIDProvider("down", down)))
+
+	require.NoError(t, f.ExpectNetwork(context.Background(), NetworkIdentity{ChainID: "1"}))
+	assert.Equal(t, []string{"mainnet"}, poolNames(f))
+	metrics := f.GetMetrics()
+	assert.Equal(t, []string{"sepolia"}, metrics["rejected_providers"])
+	assert.Equal(t, []string{"down"}, metrics["unverified_providers"])
+
+	// Once it answers, the held provider joins the pool.
+	down.setDown(false)
+	f.checkUnverified(context.Background())
+	assert.Equal(t, []string{"mainnet", "down"}, poolNames(f))
+	assert.Empty(t, f.GetMetrics()["unverified_providers"])
+
+	p, err := f.GetBestProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "mainnet", p.Name)
+}
+
+func TestExpectNetwork_ChecksProvidersAddedLater(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	require.NoError(t, f.ExpectNetwork(context.Background(), NetworkIdentity{ChainID: "56"}))
+
+	err := f.AddProvider(chainIDProvider("eth", &chainIDClient{chainID: "1"}))
+	require.ErrorIs(t, err, ErrWrongNetwork)
+	assert.EqualError(t, err, "provider eth: node serves another network: chain ID is 1, expected 56")
+	require.NoError(t, f.AddProvider(chainIDProvider("bsc", &chainIDClient{chainID: "56"})))
+	assert.Equal(t, []string{"bsc"}, poolNames(f))
+}
+
+func TestExpectNetwork_Errors(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	require.NoError(t, f.AddProvider(chainIDProvider("eth", &chainIDClient{chainID: "1"})))
+
+	err := f.ExpectNetwork(context.Background(), NetworkIdentity{GenesisHash: "0xd4e5"})
+	assert.EqualError(t, err, "evm nodes do not report a genesis hash")
+	assert.Equal(t, []string{"eth"}, poolNames(f), "nothing checked")
+
+	err = f.ExpectNetwork(context.Background(), NetworkIdentity{ChainID: "137"})
+	require.ErrorIs(t, err, ErrWrongNetwork, "no provider serves the network")
+	assert.Empty(t, poolNames(f))
+}
+
+func TestSameNetworkID(t *testing.T) {
+	assert.True(t, sameNetworkID("main", "main"))
+	assert.True(t, sameNetworkID("0xD4E56740", "d4e56740"))
+	assert.False(t, sameNetworkID("main", "MAIN"), "only hex ignores case")
+	assert.False(t, sameNetworkID("5eykt4Us", "5EYKT4US"))
+	assert.False(t, sameNetworkID("1", "56"))
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
@@ -13,6 +14,10 @@ type Provider struct {
 	ClientType string        `json:"client_type"`
 	Client     NetworkClient `json:"-"`
 
+	// warming marks a provider held out of the pool until it answers a
+	// warm-up probe; guarded by the Failover's warmMu.
+	warming bool
+
 	mu sync.RWMutex // protect all fields below
 
 	// Health metrics
@@ -21,6 +26,16 @@ type Provider struct {
 	AverageResponseTime time.Duration `json:"average_response_time"`
 	BlacklistedUntil    time.Time     `json:"blacklisted_until"`
 	ConsecutiveErrors   int           `json:"consecutive_errors"`
+	ErrorRate           float64       `json:"error_rate"` // moving average of failed calls, 0..1
+
+	// Chain height sampled by the failover health checker
+	Height          uint64    `json:"height"`
+	Lag             uint64    `json:"lag"`
+	Lagging         bool      `json:"lagging"`
+	HeightCheckedAt time.Time `json:"height_checked_at"`
+
+	// Warnings the node reports, see WarningReporter.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // IsAvailable returns true if the provider is not blacklisted or blacklist expired.
@@ -43,6 +58,7 @@ func (p *Provider) Fail(cfg *FailoverConfig) {
 	defer p.mu.Unlock()
 
 	p.ConsecutiveErrors++
+	p.observeCall(true)
 	switch {
 	case p.ConsecutiveErrors >= cfg.ErrorThreshold:
 		p.State = StateUnhealthy
@@ -58,6 +74,7 @@ func (p *Provider) Blacklist(d time.Duration) {
 
 	p.State = StateBlacklisted
 	p.BlacklistedUntil = time.Now().Add(d)
+	p.observeCall(true)
 }
 
 // Recover reactivates a previously blacklisted provider.
@@ -76,7 +93,11 @@ func (p *Provider) Success(elapsed time.Duration) {
 	defer p.mu.Unlock()
 
 	p.ConsecutiveErrors = 0
+	p.observeCall(false)
 	p.State = StateHealthy
+	if p.Lagging || len(p.Warnings) > 0 {
+		p.State = StateDegraded
+	}
 	if p.AverageResponseTime == 0 {
 		p.AverageResponseTime = elapsed
 	} else {
@@ -84,3 +105,116 @@ func (p *Provider) Success(elapsed time.Duration) {
 	}
 	p.LastHealthCheck = time.Now()
 }
+
+// warmedUp records the latency of the provider's warm-up probe as its
+// initial response time. The probe serves no request, so the error rate and
+// state are left alone.
+func (p *Provider) warmedUp(elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.AverageResponseTime = elapsed
+	p.LastHealthCheck = time.Now()
+}
+
+// errorRateWeight is the weight of the latest call in ErrorRate, so the
+// rate reflects roughly the last 1/errorRateWeight calls.
+const errorRateWeight = 0.05
+
+// observeCall folds a call outcome into ErrorRate. Callers hold p.mu.
+func (p *Provider) observeCall(failed bool) {
+	sample := 0.0
+	if failed {
+		sample = 1
+	}
+	p.ErrorRate += errorRateWeight * (sample - p.ErrorRate)
+}
+
+// SetHeight records a sampled chain height and the lag behind the pool
+// maximum. A lagging provider is demoted to degraded; it reports true when
+// the provider has just become lagging.
+func (p *Provider) SetHeight(height, lag uint64, lagging bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	demoted := lagging && !p.Lagging
+	p.Height = height
+	p.Lag = lag
+	p.Lagging = lagging
+	p.HeightCheckedAt = time.Now()
+
+	switch {
+	case lagging && p.State == StateHealthy:
+		p.State = StateDegraded
+	case !lagging && p.State == StateDegraded && p.ConsecutiveErrors == 0 && len(p.Warnings) == 0:
+		p.State = StateHealthy
+	}
+	return demoted
+}
+
+// SetWarnings records the warnings the node reports. A provider with
+// warnings is demoted to degraded rather than blacklisted: it still serves
+// data, but an operator should look at it. It reports whether the warnings
+// changed.
+func (p *Provider) SetWarnings(warnings []string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := !slices.Equal(p.Warnings, warnings)
+	p.Warnings = warnings
+
+	switch {
+	case len(warnings) > 0 && p.State == StateHealthy:
+		p.State = StateDegraded
+	case len(warnings) == 0 && changed && p.State == StateDegraded && !p.Lagging && p.ConsecutiveErrors == 0:
+		p.State = StateHealthy
+	}
+	return changed
+}
+
+// IsLagging reports whether the provider was last seen behind the pool.
+func (p *Provider) IsLagging() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.Lagging
+}
+
+// ProviderStatus is a point-in-time view of a provider for status reporting.
+// The URL is left out since it often carries an API key.
+type ProviderStatus struct {
+	Name              string     `json:"name"`
+	State             string     `json:"state"`
+	Available         bool       `json:"available"`
+	LatencyMs         int64      `json:"latency_ms"`
+	ConsecutiveErrors int        `json:"consecutive_errors"`
+	ErrorRate         float64    `json:"error_rate"`
+	BlacklistedUntil  *time.Time `json:"blacklisted_until,omitempty"`
+	Height            uint64     `json:"height,omitempty"`
+	Lagging           bool       `json:"lagging,omitempty"`
+	Warnings          []string   `json:"warnings,omitempty"`
+	// Current marks the provider the pool sends requests to first.
+	Current bool `json:"current,omitempty"`
+}
+
+// Status returns a snapshot of the provider's health.
+func (p *Provider) Status() ProviderStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := ProviderStatus{
+		Name:              p.Name,
+		State:             p.State,
+		Available:         p.State != StateBlacklisted || time.Now().After(p.BlacklistedUntil),
+		LatencyMs:         p.AverageResponseTime.Milliseconds(),
+		ConsecutiveErrors: p.ConsecutiveErrors,
+		ErrorRate:         p.ErrorRate,
+		Height:            p.Height,
+		Lagging:           p.Lagging,
+		Warnings:          slices.Clone(p.Warnings),
+	}
+	if p.State == StateBlacklisted {
+		until := p.BlacklistedUntil
+		status.BlacklistedUntil = &until
+	}
+	return status
+}
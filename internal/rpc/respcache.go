@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+)
+
+// ResponseStore is a second cache tier shared between processes, e.g.
+// Redis, see ResponseCache. Results are stored with the height of the
+// block they belong to, so they can be dropped when it is rolled back.
+type ResponseStore interface {
+	Get(ctx context.Context, key string) (height uint64, result json.RawMessage, ok bool, err error)
+	Put(ctx context.Context, key string, height uint64, result json.RawMessage) error
+	DeleteFrom(ctx context.Context, height uint64) error
+}
+
+// ResponseCache is a read-through cache of JSON-RPC results that can no
+// longer change, keyed by method and params. It keeps the most recently
+// used results in memory and, with a ResponseStore, shares them with other
+// processes. Which results are immutable is the client's call; the cache
+// stores whatever it is given. Calls on a WithFreshRead context bypass it.
+type ResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+	store   ResponseStore
+
+	hits, storeHits, misses, storeErrors atomic.Uint64
+}
+
+type cachedResponse struct {
+	key    string
+	height uint64
+	result json.RawMessage
+}
+
+// ResponseCacheStats counts cache lookups. HitRate is the share of
+// lookups served from memory or the store.
+type ResponseCacheStats struct {
+	Entries     int     `json:"entries"`
+	Hits        uint64  `json:"hits"`
+	StoreHits   uint64  `json:"store_hits"`
+	Misses      uint64  `json:"misses"`
+	StoreErrors uint64  `json:"store_errors,omitempty"`
+	HitRate     float64 `json:"hit_rate"`
+}
+
+// NewResponseCache returns a cache holding up to size results in memory.
+func NewResponseCache(size int) *ResponseCache {
+	return &ResponseCache{
+		size:    max(size, 1),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// UseStore adds store as the cache's second tier.
+func (c *ResponseCache) UseStore(store ResponseStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+}
+
+// Get returns the cached response to method with params, if any.
+func (c *ResponseCache) Get(ctx context.Context, method string, params any) (*RPCResponse, bool) {
+	if isFreshRead(ctx) {
+		return nil, false
+	}
+	key, err := callKey(method, params)
+	if err != nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		result := el.Value.(*cachedResponse).result
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return &RPCResponse{JSONRPC: "2.0", Result: result}, true
+	}
+	store := c.store
+	c.mu.Unlock()
+
+	if store != nil {
+		height, result, ok, err := store.Get(ctx, key)
+		if err != nil {
+			c.storeErrors.Add(1)
+		} else if ok {
+			c.storeHits.Add(1)
+			c.add(key, height, result)
+			return &RPCResponse{JSONRPC: "2.0", Result: result}, true
+		}
+	}
+	c.misses.Add(1)
+	return nil, false
+}
+
+// Put caches result as the response to method with params, belonging to
+// the block at height.
+func (c *ResponseCache) Put(ctx context.Context, method string, params any, height uint64, result json.RawMessage) {
+	key, err := callKey(method, params)
+	if err != nil {
+		return
+	}
+	c.add(key, height, result)
+
+	c.mu.Lock()
+	store := c.store
+	c.mu.Unlock()
+	if store != nil {
+		if err := store.Put(ctx, key, height, result); err != nil {
+			c.storeErrors.Add(1)
+		}
+	}
+}
+
+func (c *ResponseCache) add(key string, height uint64, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cachedResponse{key: key, height: height, result: result}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cachedResponse{key: key, height: height, result: result})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// InvalidateFrom drops the results of blocks at height and above, e.g.
+// after they were rolled back.
+func (c *ResponseCache) InvalidateFrom(ctx context.Context, height uint64) error {
+	c.mu.Lock()
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if entry := el.Value.(*cachedResponse); entry.height >= height {
+			c.lru.Remove(el)
+			delete(c.entries, entry.key)
+		}
+		el = next
+	}
+	store := c.store
+	c.mu.Unlock()
+
+	if store != nil {
+		return store.DeleteFrom(ctx, height)
+	}
+	return nil
+}
+
+// Stats reports the cache's size and lookups.
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	s := ResponseCacheStats{
+		Entries:     entries,
+		Hits:        c.hits.Load(),
+		StoreHits:   c.storeHits.Load(),
+		Misses:      c.misses.Load(),
+		StoreErrors: c.storeErrors.Load(),
+	}
+	if lookups := s.Hits + s.StoreHits + s.Misses; lookups > 0 {
+		s.HitRate = float64(s.Hits+s.StoreHits) / float64(lookups)
+	}
+	return s
+}
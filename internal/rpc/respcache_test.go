@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memResponseStore is a ResponseStore in a map.
+type memResponseStore struct {
+	entries map[string]cachedResponse
+	failGet bool
+}
+
+func (s *memResponseStore) Get(_ context.Context, key string) (uint64, json.RawMessage, bool, error) {
+	if s.failGet {
+		return 0, nil, false, errors.New("connection refused")
+	}
+	e, ok := s.entries[key]
+	return e.height, e.result, ok, nil
+}
+
+func (s *memResponseStore) Put(_ context.Context, key string, height uint64, result json.RawMessage) error {
+	s.entries[key] = cachedResponse{key: key, height: height, result: result}
+	return nil
+}
+
+func (s *memResponseStore) DeleteFrom(_ context.Context, height uint64) error {
+	for key, e := range s.entries {
+		if e.height >= height {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewResponseCache(2)
+	c.Put(ctx, "getblockhash", []any{1}, 1, json.RawMessage(`"a"`))
+	c.Put(ctx, "getblockhash", []any{2}, 2, json.RawMessage(`"b"`))
+	_, ok := c.Get(ctx, "getblockhash", []any{1})
+	require.True(t, ok)
+	c.Put(ctx, "getblockhash", []any{3}, 3, json.RawMessage(`"c"`))
+
+	_, ok = c.Get(ctx, "getblockhash", []any{2})
+	assert.False(t, ok, "the least recently used entry is evicted")
+	resp, ok := c.Get(ctx, "getblockhash", []any{1})
+	require.True(t, ok)
+	assert.JSONEq(t, `"a"`, string(resp.Result))
+
+	_, ok = c.Get(WithFreshRead(ctx), "getblockhash", []any{1})
+	assert.False(t, ok, "fresh reads bypass the cache")
+
+	assert.Equal(t, ResponseCacheStats{Entries: 2, Hits: 2, Misses: 1, HitRate: 2.0 / 3}, c.Stats())
+}
+
+func TestResponseCache_Store(t *testing.T) {
+	ctx := context.Background()
+	store := &memResponseStore{entries: make(map[string]cachedResponse)}
+	writer := NewResponseCache(10)
+	writer.UseStore(store)
+	writer.Put(ctx, "getrawtransaction", []any{"tx", 2}, 150, json.RawMessage(`{"txid":"tx"}`))
+	writer.Put(ctx, "getblockhash", []any{100}, 100, json.RawMessage(`"h"`))
+
+	reader := NewResponseCache(10)
+	reader.UseStore(store)
+	_, ok := reader.Get(ctx, "getrawtransaction", []any{"tx", 2})
+	require.True(t, ok, "served from the store")
+	_, ok = reader.Get(ctx, "getrawtransaction", []any{"tx", 2})
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), reader.Stats().StoreHits)
+	assert.Equal(t, uint64(1), reader.Stats().Hits, "and kept in memory")
+
+	require.NoError(t, reader.InvalidateFrom(ctx, 120))
+	_, ok = reader.Get(ctx, "getrawtransaction", []any{"tx", 2})
+	assert.False(t, ok, "dropped from memory and the store")
+	_, ok = reader.Get(ctx, "getblockhash", []any{100})
+	assert.True(t, ok)
+
+	store.failGet = true
+	fresh := NewResponseCache(10)
+	fresh.UseStore(store)
+	_, ok = fresh.Get(ctx, "getblockhash", []any{100})
+	assert.False(t, ok, "a failing store is a miss")
+	assert.Equal(t, uint64(1), fresh.Stats().StoreErrors)
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type sessionKey struct{}
+
+// session pins failover calls made within one logical operation to a single
+// provider, so related lookups (e.g. block hash, block body, prevouts) see
+// the same view of the chain.
+type session struct {
+	mu       sync.Mutex
+	provider *Provider
+}
+
+// WithSession returns a context whose ExecuteWithRetry calls prefer the same
+// provider until it errors. An existing session on ctx is reused.
+func WithSession(ctx context.Context) context.Context {
+	if sessionFrom(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func sessionFrom(ctx context.Context) *session {
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
+
+// GetSessionProvider returns the provider pinned to ctx's session, pinning
+// the current best provider if none is set or the pinned one became
+// unavailable. Without a session it behaves like GetBestProvider.
+func (f *Failover[T]) GetSessionProvider(ctx context.Context) (*Provider, error) {
+	s := sessionFrom(ctx)
+	if s == nil {
+		return f.GetBestProvider()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil && f.hasProvider(s.provider) && s.provider.IsAvailable() {
+		return s.provider, nil
+	}
+	provider, err := f.GetBestProvider()
+	if err != nil {
+		return nil, err
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+// releaseSession unpins provider from ctx's session after it errored, so the
+// next attempt picks a new one. Not-found errors keep the pin: the data is
+// missing on the chain view the session is bound to, not the node's fault.
+func releaseSession(ctx context.Context, provider *Provider, err error) {
+	s := sessionFrom(ctx)
+	if s == nil || err == nil || errors.Is(err, ErrNotFound) {
+		return
+	}
+	s.mu.Lock()
+	if s.provider == provider {
+		s.provider = nil
+	}
+	s.mu.Unlock()
+}
+
+func (f *Failover[T]) hasProvider(p *Provider) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, candidate := range f.providers {
+		if candidate == p {
+			return true
+		}
+	}
+	return false
+}
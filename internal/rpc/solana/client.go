@@ -50,6 +50,19 @@ func (c *Client) GetSlot(ctx context.Context) (uint64, error) {
 	return slot, nil
 }
 
+// GenesisHash returns the node's getGenesisHash.
+func (c *Client) GenesisHash(ctx context.Context) (string, error) {
+	resp, err := c.base.CallRPC(ctx, "getGenesisHash", nil)
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if err := json.Unmarshal(resp.Result, &hash); err != nil {
+		return "", fmt.Errorf("decode getGenesisHash result: %w", err)
+	}
+	return hash, nil
+}
+
 func (c *Client) GetTransaction(ctx context.Context, signature string) (*GetTransactionResult, error) {
 	cfg := map[string]any{
 		"encoding":                       "jsonParsed",
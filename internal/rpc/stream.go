@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+func responseTooLarge(limit int64) error {
+	return fmt.Errorf("%w: over the %d byte limit", ErrResponseTooLarge, limit)
+}
+
+// limitedReader reads at most limit bytes of r, then fails with
+// ErrResponseTooLarge if r has more.
+type limitedReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+// newLimitedReader returns r capped at limit bytes, or r itself when limit
+// is not positive.
+func newLimitedReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	// One byte past the limit tells a body of exactly limit bytes apart.
+	return &limitedReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if over := l.read - l.limit; over > 0 {
+		return n - int(over), responseTooLarge(l.limit)
+	}
+	return n, err
+}
+
+// decodeRPCStream reads a JSON-RPC response envelope from r, handing its
+// result to decodeResult. An error member is returned as an *RPCError.
+func decodeRPCStream(r io.Reader, decodeResult func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	var rpcErr *RPCError
+	sawResult := false
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case "result":
+			sawResult = true
+			if err := decodeResult(dec); err != nil {
+				return err
+			}
+		case "error":
+			if err := dec.Decode(&rpcErr); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	if rpcErr != nil {
+		return rpcErr
+	}
+	if !sawResult {
+		return errors.New("response has no result")
+	}
+	return nil
+}
+
+// expectDelim reads the next token of dec, failing unless it is want.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
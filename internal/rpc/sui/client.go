@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
@@ -200,7 +202,7 @@ func (c *SuiClient) GetLatestCheckpointSequence(ctx context.Context) (uint64, er
 	// Use lightweight GetServiceInfo fallback
 	resp, err := c.ledgerClient.GetServiceInfo(ctx, &v2.GetServiceInfoRequest{})
 	if err != nil {
-		return 0, fmt.Errorf("GetServiceInfo failed (fallback): %w", err)
+		return 0, classifyGRPCError(fmt.Errorf("GetServiceInfo failed (fallback): %w", err))
 	}
 
 	return resp.GetCheckpointHeight(), nil
@@ -241,11 +243,11 @@ func (c *SuiClient) GetCheckpoint(ctx context.Context, sequenceNumber uint64) (*
 
 	resp, err := c.ledgerClient.GetCheckpoint(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("GetCheckpoint failed for sequence %d: %w", sequenceNumber, err)
+		return nil, classifyGRPCError(fmt.Errorf("GetCheckpoint failed for sequence %d: %w", sequenceNumber, err))
 	}
 
 	if resp.Checkpoint == nil {
-		return nil, fmt.Errorf("checkpoint %d not found", sequenceNumber)
+		return nil, rpc.WithClass(rpc.ErrNotFound, fmt.Errorf("checkpoint %d not found", sequenceNumber))
 	}
 
 	return &Checkpoint{Checkpoint: resp.Checkpoint}, nil
@@ -305,11 +307,11 @@ func (c *SuiClient) GetTransaction(ctx context.Context, digest string) (*Transac
 
 	resp, err := c.ledgerClient.GetTransaction(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("GetTransaction failed for digest %s: %w", digest, err)
+		return nil, classifyGRPCError(fmt.Errorf("GetTransaction failed for digest %s: %w", digest, err))
 	}
 
 	if resp.Transaction == nil {
-		return nil, fmt.Errorf("transaction %s not found", digest)
+		return nil, rpc.WithClass(rpc.ErrNotFound, fmt.Errorf("transaction %s not found", digest))
 	}
 
 	return &Transaction{ExecutedTransaction: resp.Transaction}, nil
@@ -332,7 +334,7 @@ func (c *SuiClient) BatchGetTransactions(ctx context.Context, digests []string)
 
 	resp, err := c.ledgerClient.BatchGetTransactions(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("BatchGetTransactions failed: %w", err)
+		return nil, classifyGRPCError(fmt.Errorf("BatchGetTransactions failed: %w", err))
 	}
 
 	for i, txResult := range resp.Transactions {
@@ -382,3 +384,22 @@ func (c *SuiClient) Close() error {
 	}
 	return nil
 }
+
+// classifyGRPCError attaches an rpc error class based on the gRPC status code.
+func classifyGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.NotFound:
+		return rpc.WithClass(rpc.ErrNotFound, err)
+	case codes.ResourceExhausted:
+		return rpc.WithClass(rpc.ErrRateLimited, err)
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return rpc.WithClass(rpc.ErrAuth, err)
+	case codes.DeadlineExceeded:
+		return rpc.WithClass(rpc.ErrTimeout, err)
+	}
+	return err
+}
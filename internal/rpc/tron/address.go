@@ -1,14 +1,24 @@
 package tron
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"strings"
 
-	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/tronaddr"
 )
 
-// HexToTronAddress converts hex addresses (41...) to TRON base58 format (T...)
+// payloadAddress converts an address from a node payload to base58check.
+// Payloads carry hex addresses, or base58check ones for requests made with
+// visible=true.
+func payloadAddress(addr string) (string, error) {
+	if _, err := tronaddr.Base58ToHex(addr); err == nil {
+		return strings.TrimSpace(addr), nil
+	}
+	return tronaddr.HexToBase58(addr)
+}
+
+// HexToTronAddress converts hex addresses (41...) to TRON base58 format
+// (T...), returning addr unchanged if it is in neither form. Use
+// tronaddr.HexToBase58 where a bad address must be reported.
 func HexToTronAddress(addr string) string {
 	cleaned := strings.TrimSpace(addr)
 
@@ -16,42 +26,27 @@ func HexToTronAddress(addr string) string {
 	if len(cleaned) >= 34 && (cleaned[0] == 'T' || cleaned[0] == 't') {
 		return cleaned
 	}
-
-	// Handle hex addresses starting with 41 (TRON hex format)
-	if len(cleaned) == 42 && strings.HasPrefix(cleaned, "41") {
-		raw, err := hex.DecodeString(cleaned)
-		if err != nil {
-			return addr
-		}
-		h1 := sha256.Sum256(raw)
-		h2 := sha256.Sum256(h1[:])
-		checksum := h2[:4]
-		full := append(raw, checksum...)
-		return base58.Encode(full)
-	}
-
-	if strings.HasPrefix(cleaned, "0x") {
-		return EVMToTronAddress(cleaned)
-	}
-
-	if len(cleaned) == 40 {
-		return EVMToTronAddress("0x" + cleaned)
+	if b58, err := tronaddr.HexToBase58(cleaned); err == nil {
+		return b58
 	}
-
 	return addr
 }
 
-// EVMToTronAddress converts 0x41... to T...
+// EVMToTronAddress converts 0x41... to T..., taking the last 20 bytes of
+// longer input, and returns evmAddr unchanged if it is not hex.
 func EVMToTronAddress(evmAddr string) string {
 	hexAddr := strings.TrimPrefix(strings.ToLower(evmAddr), "0x")
-	raw, err := hex.DecodeString(hexAddr[len(hexAddr)-40:])
-	if err != nil {
+	if len(hexAddr) < 40 {
 		return evmAddr
 	}
-	payload := append([]byte{0x41}, raw...)
-	h1 := sha256.Sum256(payload)
-	h2 := sha256.Sum256(h1[:])
-	checksum := h2[:4]
-	full := append(payload, checksum...)
-	return base58.Encode(full)
+	if b58, err := tronaddr.HexToBase58(hexAddr[len(hexAddr)-40:]); err == nil {
+		return b58
+	}
+	return evmAddr
+}
+
+// IsValidTronAddress reports whether addr is a base58check TRON address (T...).
+func IsValidTronAddress(addr string) bool {
+	_, err := tronaddr.Base58ToHex(addr)
+	return err == nil
 }
@@ -0,0 +1,26 @@
+package tron
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/tronaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTRC20Transfers_MalformedTopic(t *testing.T) {
+	l := Log{
+		Address: "a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		Topics:  []string{TRC20_TRANSFER_TOPIC, "00ab", "000000000000000000000000a614f803b6fd780986a42c78ec9c7f77e6ded13c"},
+		Data:    "01",
+	}
+	_, err := l.ParseTRC20Transfers("tx", "tron", 1, 1)
+	assert.ErrorIs(t, err, tronaddr.ErrInvalidAddress)
+
+	l.Topics[1] = l.Topics[2]
+	transfers, err := l.ParseTRC20Transfers("tx", "tron", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", transfers[0].FromAddress)
+	assert.Equal(t, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", transfers[0].AssetAddress)
+}
@@ -47,6 +47,15 @@ func (t *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
 	return uint64(block.BlockHeader.RawData.Number), nil
 }
 
+// GenesisHash returns the blockID of block 0.
+func (t *Client) GenesisHash(ctx context.Context) (string, error) {
+	block, err := t.GetBlockByNumber(ctx, "0", false)
+	if err != nil {
+		return "", err
+	}
+	return block.BlockID, nil
+}
+
 // GetBlockByNumber returns a block with full transaction data
 func (t *Client) GetBlockByNumber(
 	ctx context.Context,
@@ -0,0 +1,115 @@
+package tron
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/shopspring/decimal"
+)
+
+// ParseTransfers returns the value a contract moves: TRX sent by a
+// TransferContract, a TRC-10 asset sent by a TransferAssetContract, and TRX
+// and TRC-10 call values sent along a TriggerSmartContract. TRC-20 transfers
+// made by smart contracts are in the transaction's logs instead, see
+// Log.ParseTRC20Transfers. Other contract types move no value and return
+// nil.
+func (c Contract) ParseTransfers(
+	txID, network string,
+	blockNum, ts uint64,
+) ([]types.Transaction, error) {
+	base := types.Transaction{
+		TxHash:      txID,
+		NetworkId:   network,
+		BlockNumber: blockNum,
+		TxFee:       decimal.Zero,
+		Timestamp:   ts,
+	}
+	native := func(from, to string, amount int64) (types.Transaction, error) {
+		tr := base
+		var err error
+		if tr.FromAddress, err = payloadAddress(from); err != nil {
+			return tr, fmt.Errorf("owner address: %w", err)
+		}
+		if tr.ToAddress, err = payloadAddress(to); err != nil {
+			return tr, fmt.Errorf("to address: %w", err)
+		}
+		tr.Amount = decimal.NewFromInt(amount).String()
+		tr.Type = constant.TxTypeNativeTransfer
+		return tr, nil
+	}
+	asset := func(from, to, assetID string, amount int64) (types.Transaction, error) {
+		tr, err := native(from, to, amount)
+		tr.AssetAddress = assetID
+		tr.Type = constant.TxTypeTokenTransfer
+		return tr, err
+	}
+
+	switch c.Type {
+	case ContractTypeTransfer:
+		var transfer TransferContract
+		if err := json.Unmarshal(c.Parameter.Value, &transfer); err != nil {
+			return nil, err
+		}
+		tr, err := native(transfer.OwnerAddress, transfer.ToAddress, transfer.Amount)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Transaction{tr}, nil
+
+	case ContractTypeTransferAsset:
+		var transfer TransferAssetContract
+		if err := json.Unmarshal(c.Parameter.Value, &transfer); err != nil {
+			return nil, err
+		}
+		tr, err := asset(transfer.OwnerAddress, transfer.ToAddress, AssetID(transfer.AssetName), transfer.Amount)
+		if err != nil {
+			return nil, err
+		}
+		return []types.Transaction{tr}, nil
+
+	case ContractTypeTriggerSmartContract:
+		var trigger TriggerSmartContract
+		if err := json.Unmarshal(c.Parameter.Value, &trigger); err != nil {
+			return nil, err
+		}
+		var out []types.Transaction
+		if trigger.CallValue > 0 {
+			tr, err := native(trigger.OwnerAddress, trigger.ContractAddress, trigger.CallValue)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, tr)
+		}
+		if trigger.CallTokenValue > 0 && trigger.TokenID > 0 {
+			tr, err := asset(trigger.OwnerAddress, trigger.ContractAddress,
+				strconv.FormatInt(trigger.TokenID, 10), trigger.CallTokenValue)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, tr)
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+// AssetID returns the ID of a TRC-10 asset from the asset_name of a
+// TransferAssetContract, which the API returns hex-encoded unless asked for
+// visible output, e.g. "31303032303030" for asset 1002000. Asset IDs have
+// seven digits, so an ID already in visible form never decodes as hex.
+func AssetID(assetName string) string {
+	raw, err := hex.DecodeString(assetName)
+	if err != nil || len(raw) == 0 {
+		return assetName
+	}
+	for _, b := range raw {
+		if b < '0' || b > '9' {
+			return assetName
+		}
+	}
+	return string(raw)
+}
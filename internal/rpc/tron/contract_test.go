@@ -0,0 +1,86 @@
+package tron
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture[T any](t *testing.T, name string) T {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	var v T
+	require.NoError(t, json.Unmarshal(raw, &v))
+	return v
+}
+
+func TestContractParseTransfers(t *testing.T) {
+	block := readFixture[Block](t, "block_multi_contract.json")
+	require.Len(t, block.Transactions, 1)
+	contracts := block.Transactions[0].RawData.Contract
+	require.Len(t, contracts, 4)
+
+	owner := HexToTronAddress("414c1029697ee358715d3a14a2add817c4b0165144")
+	to := HexToTronAddress("41663ea1bfffe5038f3f0cf667f14c4257eff52d77")
+	contract := HexToTronAddress("41cc8321d6375c494d043fdd0260f21bc0ec51dacc")
+
+	type transfer struct {
+		from, to, asset, amount string
+		typ                     constant.TxType
+	}
+	parse := func(i int) []transfer {
+		parsed, err := contracts[i].ParseTransfers("tx", "tron", 61234567, 1714000000)
+		require.NoError(t, err)
+		var got []transfer
+		for _, tr := range parsed {
+			got = append(got, transfer{
+				tr.FromAddress, tr.ToAddress, tr.AssetAddress, tr.Amount, tr.Type})
+		}
+		return got
+	}
+
+	assert.Equal(t, []transfer{
+		{owner, to, "", "2500000", constant.TxTypeNativeTransfer},
+	}, parse(0), "TransferContract")
+	assert.Equal(t, []transfer{
+		{owner, to, "1002000", "700", constant.TxTypeTokenTransfer},
+	}, parse(1), "TransferAssetContract")
+	assert.Equal(t, []transfer{
+		{owner, contract, "", "1000000", constant.TxTypeNativeTransfer},
+		{owner, contract, "1002000", "50", constant.TxTypeTokenTransfer},
+	}, parse(2), "TriggerSmartContract")
+	assert.Empty(t, parse(3), "FreezeBalanceV2Contract moves no value")
+}
+
+func TestAssetID(t *testing.T) {
+	assert.Equal(t, "1002000", AssetID("31303032303030"))
+	assert.Equal(t, "1002000", AssetID("1002000"))
+	assert.Equal(t, "BitTorrent", AssetID("BitTorrent"))
+	assert.Equal(t, "", AssetID(""))
+}
+
+func TestFeeBreakdown(t *testing.T) {
+	acct := readFixture[TxnInfo](t, "txinfo_account_creation.json")
+	got := acct.FeeBreakdown()
+	assert.Equal(t, "0.1", got.Bandwidth.String())
+	assert.Equal(t, "0", got.Energy.String())
+	assert.Equal(t, "1", got.AccountCreation.String())
+	assert.Equal(t, "1.1", acct.TotalFeeTRX().String())
+
+	energy := readFixture[TxnInfo](t, "txinfo_energy.json")
+	got = energy.FeeBreakdown()
+	assert.Equal(t, "0.345", got.Bandwidth.String())
+	assert.Equal(t, "13.49985", got.Energy.String())
+	assert.Equal(t, "0", got.AccountCreation.String())
+	assert.Equal(t, int64(64285), got.EnergyUsage)
+	assert.Equal(t, energy.TotalFeeTRX(), got.Bandwidth.Add(got.Energy).Add(got.AccountCreation))
+
+	var none *TxnInfo
+	assert.True(t, none.FeeBreakdown().Energy.IsZero())
+}
@@ -18,3 +18,35 @@ func (ti *TxnInfo) TotalFeeTRX() decimal.Decimal {
 	}
 	return total.Div(decimal.NewFromInt(SUN))
 }
+
+// FeeBreakdown splits a transaction's fee, in TRX, into what paid for its
+// resources, along with the resources consumed.
+type FeeBreakdown struct {
+	// Bandwidth is the TRX burnt for bandwidth not covered by staking or
+	// the free allowance.
+	Bandwidth decimal.Decimal `json:"bandwidth"`
+	// Energy is the TRX burnt for energy not covered by staking.
+	Energy decimal.Decimal `json:"energy"`
+	// AccountCreation is the rest of the fee: the charge for creating the
+	// recipient's account, along with any memo or multi-signature fee.
+	AccountCreation decimal.Decimal `json:"account_creation"`
+	BandwidthUsage  int64           `json:"bandwidth_usage"`
+	EnergyUsage     int64           `json:"energy_usage"`
+}
+
+// FeeBreakdown splits the fee of the transaction reported by
+// gettransactioninfobyid (or gettransactioninfobyblocknum).
+func (ti *TxnInfo) FeeBreakdown() FeeBreakdown {
+	if ti == nil {
+		return FeeBreakdown{}
+	}
+	sun := decimal.NewFromInt(SUN)
+	netFee, energyFee := ti.Receipt.NetFee, ti.Receipt.EnergyFee
+	return FeeBreakdown{
+		Bandwidth:       decimal.NewFromInt(netFee).Div(sun),
+		Energy:          decimal.NewFromInt(energyFee).Div(sun),
+		AccountCreation: decimal.NewFromInt(max(ti.Fee-netFee-energyFee, 0)).Div(sun),
+		BandwidthUsage:  ti.Receipt.NetUsage,
+		EnergyUsage:     ti.Receipt.EnergyUsageTotal,
+	}
+}
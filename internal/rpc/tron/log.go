@@ -1,10 +1,12 @@
 package tron
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/tronaddr"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/shopspring/decimal"
 )
@@ -19,8 +21,18 @@ func (l Log) ParseTRC20Transfers(
 		strings.ToLower(strings.TrimPrefix(l.Topics[0], "0x")) != TRC20_TRANSFER_TOPIC {
 		return nil, nil
 	}
-	from := "41" + l.Topics[1][len(l.Topics[1])-40:]
-	to := "41" + l.Topics[2][len(l.Topics[2])-40:]
+	from, err := topicAddress(l.Topics[1])
+	if err != nil {
+		return nil, fmt.Errorf("from topic: %w", err)
+	}
+	to, err := topicAddress(l.Topics[2])
+	if err != nil {
+		return nil, fmt.Errorf("to topic: %w", err)
+	}
+	token, err := tronaddr.HexToBase58(l.Address)
+	if err != nil {
+		return nil, fmt.Errorf("log address: %w", err)
+	}
 	amount := new(big.Int)
 	amount.SetString(strings.TrimPrefix(l.Data, "0x"), 16)
 
@@ -28,9 +40,9 @@ func (l Log) ParseTRC20Transfers(
 		TxHash:       txID,
 		NetworkId:    network,
 		BlockNumber:  blockNum,
-		FromAddress:  HexToTronAddress(from),
-		ToAddress:    HexToTronAddress(to),
-		AssetAddress: HexToTronAddress(l.Address),
+		FromAddress:  from,
+		ToAddress:    to,
+		AssetAddress: token,
 		Amount:       amount.String(),
 		Type:         constant.TxTypeTokenTransfer,
 		TxFee:        decimal.Zero,
@@ -38,3 +50,13 @@ func (l Log) ParseTRC20Transfers(
 	}
 	return []types.Transaction{tr}, nil
 }
+
+// topicAddress converts an indexed address topic, the 20-byte address left
+// padded to 32 bytes, to base58check.
+func topicAddress(topic string) (string, error) {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) < 40 {
+		return "", fmt.Errorf("%w: topic %q too short", tronaddr.ErrInvalidAddress, topic)
+	}
+	return tronaddr.HexToBase58(topic[len(topic)-40:])
+}
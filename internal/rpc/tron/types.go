@@ -70,6 +70,9 @@ type (
 		OwnerAddress    string `json:"owner_address"`
 		ContractAddress string `json:"contract_address"`
 		Data            string `json:"data"`
+		CallValue       int64  `json:"call_value"`       // TRX sent with the call, in sun
+		CallTokenValue  int64  `json:"call_token_value"` // TRC-10 amount sent with the call
+		TokenID         int64  `json:"token_id"`         // TRC-10 asset of CallTokenValue
 	}
 
 	// Simplified transaction info for transfer analysis
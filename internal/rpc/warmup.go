@@ -0,0 +1,218 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// warmUpProbes maps a client network type to a cheap JSON-RPC call that
+// still goes through the node's authentication. Providers of network types
+// without a probe join the pool unprobed.
+var warmUpProbes = map[string]string{
+	NetworkBitcoin: "getblockcount",
+	NetworkEVM:     "eth_chainId",
+}
+
+// probeWarmUp makes a client's warm-up call and returns how long it took.
+// ok is false when the client's network type has no warm-up probe.
+func probeWarmUp(ctx context.Context, client NetworkClient) (elapsed time.Duration, ok bool, err error) {
+	method, ok := warmUpProbes[client.GetNetworkType()]
+	if !ok || client.GetClientType() != ClientTypeRPC {
+		return 0, false, nil
+	}
+	start := time.Now()
+	resp, err := client.CallRPC(ctx, method, nil)
+	elapsed = time.Since(start)
+	if err == nil && resp == nil {
+		err = fmt.Errorf("%s: empty response", method)
+	}
+	return elapsed, true, err
+}
+
+// WarmUp probes every provider with a cheap call before it serves a real
+// one, so the first request after startup does not pay for DNS, the TLS
+// handshake and authentication and fail against the provider's health.
+// Providers are probed concurrently. Those answering stay in the pool with
+// the probe's latency as their initial response time; the others are held
+// out of it, without counting the failure against them, and probed again
+// each health check interval. From then on, providers added later and
+// providers whose blacklist expires are probed before they (re)join the
+// pool. It does nothing unless FailoverConfig.WarmUp is set.
+func (f *Failover[T]) WarmUp(ctx context.Context) {
+	if !f.config.WarmUp {
+		return
+	}
+	f.warmMu.Lock()
+	f.warmUpEnabled = true
+	f.warmMu.Unlock()
+
+	providers := f.Providers()
+	results := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func(i int, p *Provider) {
+			defer wg.Done()
+			results[i] = f.warmUpProvider(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	cold := 0
+	for i, p := range providers {
+		if err := results[i]; err != nil {
+			f.holdCold(p, err)
+			cold++
+		}
+	}
+	if len(providers) > 0 && cold == len(providers) {
+		f.log.Warn("No provider answered its warm-up probe, retrying each health check interval",
+			"providers", len(providers))
+	}
+}
+
+// warmingUp reports whether providers are probed before they join the pool.
+func (f *Failover[T]) warmingUp() bool {
+	f.warmMu.Lock()
+	defer f.warmMu.Unlock()
+	return f.warmUpEnabled
+}
+
+// warmUpProvider probes p, recording the probe's latency as its response
+// time on success. Providers without a probe succeed at once.
+func (f *Failover[T]) warmUpProvider(ctx context.Context, p *Provider) error {
+	if f.config.DefaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.config.DefaultTimeout)
+		defer cancel()
+	}
+	elapsed, ok, err := probeWarmUp(ctx, p.Client)
+	if !ok || err != nil {
+		return err
+	}
+	p.warmedUp(elapsed)
+	f.log.Info("Provider warmed up", "provider", p.Name, "latency", elapsed)
+	return nil
+}
+
+// admitWarm probes a provider about to be added once WarmUp is on. It
+// reports whether the provider may join the pool now.
+func (f *Failover[T]) admitWarm(p *Provider) bool {
+	if !f.warmingUp() {
+		return true
+	}
+	if err := f.warmUpProvider(context.Background(), p); err != nil {
+		f.holdCold(p, err)
+		return false
+	}
+	return true
+}
+
+// holdCold moves a provider that failed its warm-up probe out of the pool
+// until a later probe succeeds.
+func (f *Failover[T]) holdCold(p *Provider, err error) {
+	if f.queueWarmUp(p) {
+		f.log.Warn("Provider failed its warm-up probe, holding it out of the pool", "provider", p.Name, "error", err)
+	}
+}
+
+// rewarm moves a provider whose blacklist expired out of the pool and has
+// it probed at once, so it rejoins only once it answers.
+func (f *Failover[T]) rewarm(p *Provider) {
+	if !f.queueWarmUp(p) {
+		return
+	}
+	f.log.Info("Blacklist expired, warming up provider before it rejoins the pool", "provider", p.Name)
+	f.warmMu.Lock()
+	f.lastWarmUp = time.Time{}
+	f.warmMu.Unlock()
+}
+
+// queueWarmUp moves p out of the pool to be probed. It reports false,
+// doing nothing, when p is already held out, e.g. as concurrent callers
+// found its blacklist expired.
+func (f *Failover[T]) queueWarmUp(p *Provider) bool {
+	f.warmMu.Lock()
+	defer f.warmMu.Unlock()
+	if p.warming {
+		return false
+	}
+	p.warming = true
+	f.removeProvider(p)
+	f.warming = append(f.warming, p)
+	return true
+}
+
+// maybeWarmUp starts a background probe of the providers held out of the
+// pool when the health check interval has elapsed since the last one, or
+// at once after a blacklist expired. At most one probe round runs at a
+// time.
+func (f *Failover[T]) maybeWarmUp() {
+	f.warmMu.Lock()
+	if len(f.warming) == 0 || f.warmUpRunning ||
+		time.Since(f.lastWarmUp) < f.config.HealthCheckInterval {
+		f.warmMu.Unlock()
+		return
+	}
+	f.warmUpRunning = true
+	f.lastWarmUp = time.Now()
+	f.warmMu.Unlock()
+
+	go func() {
+		f.warmUpHeld(context.Background())
+
+		f.warmMu.Lock()
+		f.warmUpRunning = false
+		f.warmMu.Unlock()
+	}()
+}
+
+// warmUpHeld probes the providers held out of the pool, adding those that
+// answer to it. A provider whose blacklist expired is recovered first.
+func (f *Failover[T]) warmUpHeld(ctx context.Context) {
+	f.warmMu.Lock()
+	pending := f.warming
+	f.warming = nil
+	f.warmMu.Unlock()
+
+	var held []*Provider
+	for _, p := range pending {
+		if err := f.warmUpProvider(ctx, p); err != nil {
+			f.log.Debug("Provider still failing its warm-up probe", "provider", p.Name, "error", err)
+			held = append(held, p)
+			continue
+		}
+		if p.IsExpiredBlacklist() {
+			p.Recover()
+			f.metrics.IncrementRecovery()
+		}
+		f.log.Info("Adding warmed up provider to the pool", "provider", p.Name)
+		f.warmMu.Lock()
+		f.mu.Lock()
+		f.providers = append(f.providers, p)
+		if f.currentIndex == -1 {
+			f.currentIndex = 0
+		}
+		f.mu.Unlock()
+		p.warming = false
+		f.warmMu.Unlock()
+	}
+
+	f.warmMu.Lock()
+	f.warming = append(f.warming, held...)
+	f.warmMu.Unlock()
+}
+
+// warmingSnapshot returns the names of the providers held out of the pool
+// until they answer a warm-up probe.
+func (f *Failover[T]) warmingSnapshot() []string {
+	f.warmMu.Lock()
+	defer f.warmMu.Unlock()
+	names := make([]string, 0, len(f.warming))
+	for _, p := range f.warming {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warmUpClient answers its warm-up probe after delay, or fails while down,
+// recording the methods called.
+type warmUpClient struct {
+	mockNetworkClient
+	delay time.Duration
+
+	mu    sync.Mutex
+	down  bool
+	calls []string
+}
+
+func (c *warmUpClient) CallRPC(_ context.Context, method string, _ any) (*RPCResponse, error) {
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, method)
+	if c.down {
+		return nil, errors.New("tls handshake timeout")
+	}
+	return &RPCResponse{Result: []byte(`"0x1"`)}, nil
+}
+
+func (c *warmUpClient) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func (c *warmUpClient) called() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+func warmUpProvider(name string, client *warmUpClient) *Provider {
+	return &Provider{Name: name, URL: "http://" + name, ClientType: "rpc", Client: client, State: StateHealthy}
+}
+
+func TestWarmUp_AdmitsAnsweringProviders(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	fast := &warmUpClient{delay: 40 * time.Millisecond}
+	cold := &warmUpClient{delay: 40 * time.Millisecond, down: true}
+	require.NoError(t, f.AddProvider(warmUpProvider("fast", fast)))
+	require.NoError(t, f.AddProvider(warmUpProvider("cold", cold)))
+	require.NoError(t, f.AddProvider(warmUpProvider("other", &warmUpClient{delay: 40 * time.Millisecond})))
+
+	start := time.Now()
+	f.WarmUp(context.Background())
+	assert.Less(t, time.Since(start), 110*time.Millisecond, "probes run concurrently")
+
+	assert.Equal(t, []string{"eth_chainId"}, fast.called())
+	assert.Equal(t, []string{"fast", "other"}, poolNames(f))
+	assert.Equal(t, []string{"cold"}, f.GetMetrics()["warming_providers"])
+
+	status := f.ProviderStatuses()[0]
+	assert.GreaterOrEqual(t, status.LatencyMs, int64(40), "probe latency is the initial score")
+	assert.Equal(t, StateHealthy, status.State)
+	assert.Zero(t, status.ErrorRate)
+
+	// The failed probe is not held against the provider.
+	p := f.warming[0]
+	assert.Equal(t, StateHealthy, p.State)
+	assert.Zero(t, p.ConsecutiveErrors)
+	assert.Zero(t, p.ErrorRate)
+
+	// Once it answers, the held provider joins the pool.
+	cold.setDown(false)
+	f.warmUpHeld(context.Background())
+	assert.Equal(t, []string{"fast", "other", "cold"}, poolNames(f))
+	assert.Empty(t, f.GetMetrics()["warming_providers"])
+}
+
+func TestWarmUp_ProbesAddedAndRecoveredProviders(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	flaky := &warmUpClient{}
+	require.NoError(t, f.AddProvider(warmUpProvider("flaky", flaky)))
+	require.NoError(t, f.AddProvider(warmUpProvider("stable", &warmUpClient{})))
+	f.WarmUp(context.Background())
+
+	// Added later while down: held out, not an error.
+	late := &warmUpClient{down: true}
+	require.NoError(t, f.AddProvider(warmUpProvider("late", late)))
+	assert.Equal(t, []string{"flaky", "stable"}, poolNames(f))
+
+	// An expired blacklist sends the provider through a probe before it
+	// rejoins.
+	p := f.Providers()[0]
+	p.Blacklist(-time.Second)
+	flaky.setDown(true)
+	f.GetAvailableProviders()
+	assert.Equal(t, []string{"stable"}, poolNames(f))
+	assert.ElementsMatch(t, []string{"late", "flaky"}, f.GetMetrics()["warming_providers"])
+
+	flaky.setDown(false)
+	f.warmUpHeld(context.Background())
+	assert.Equal(t, []string{"stable", "flaky"}, poolNames(f))
+	assert.Equal(t, StateDegraded, p.State, "recovered once warmed up")
+	assert.Equal(t, int64(1), f.GetMetrics()["recovery_events"])
+}
+
+func TestWarmUp_ExpiredBlacklistQueuedOnce(t *testing.T) {
+	f := NewFailover[NetworkClient](nil)
+	client := &warmUpClient{}
+	require.NoError(t, f.AddProvider(warmUpProvider("node", client)))
+	require.NoError(t, f.AddProvider(warmUpProvider("other", &warmUpClient{})))
+	f.WarmUp(context.Background())
+
+	p := f.Providers()[0]
+	p.Blacklist(-time.Second)
+	client.setDown(true)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.GetAvailableProviders()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"node"}, f.GetMetrics()["warming_providers"])
+
+	client.setDown(false)
+	f.warmUpHeld(context.Background())
+	assert.Equal(t, []string{"other", "node"}, poolNames(f))
+
+	// Once back in the pool it can be held out again.
+	p.Blacklist(-time.Second)
+	f.GetAvailableProviders()
+	assert.Equal(t, []string{"node"}, f.GetMetrics()["warming_providers"])
+}
+
+func TestWarmUp_Disabled(t *testing.T) {
+	cfg := DefaultFailoverConfig()
+	cfg.WarmUp = false
+	f := NewFailover[NetworkClient](&cfg)
+	client := &warmUpClient{down: true}
+	require.NoError(t, f.AddProvider(warmUpProvider("node", client)))
+
+	f.WarmUp(context.Background())
+	assert.Empty(t, client.called())
+	assert.Equal(t, []string{"node"}, poolNames(f))
+}
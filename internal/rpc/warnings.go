@@ -0,0 +1,29 @@
+package rpc
+
+import "context"
+
+// WarningReporter is implemented by clients whose node reports warnings
+// for its operator, e.g. Bitcoin Core's "Unknown new rules activated",
+// which often precedes blocks the indexer cannot parse.
+type WarningReporter interface {
+	NodeWarnings(ctx context.Context) ([]string, error)
+}
+
+// checkWarnings records the warnings p's node reports, see
+// Provider.SetWarnings. Changes are logged once, when they happen, rather
+// than on every check.
+func (f *Failover[T]) checkWarnings(ctx context.Context, p *Provider, client WarningReporter) {
+	warnings, err := client.NodeWarnings(ctx)
+	if err != nil {
+		f.log.Debug("Warnings probe failed", "provider", p.Name, "error", err)
+		return
+	}
+	if !p.SetWarnings(warnings) {
+		return
+	}
+	if len(warnings) > 0 {
+		f.log.Warn("Node reports warnings, demoting provider", "provider", p.Name, "warnings", warnings)
+	} else {
+		f.log.Info("Node warnings cleared", "provider", p.Name)
+	}
+}
@@ -0,0 +1,385 @@
+// Package snapshot moves the indexer's state between deployments, e.g. to
+// migrate to another KV store: per chain its checkpoints, recent block
+// hashes and remembered Lightning funding outpoints, and the derivation
+// cursors of imported xpubs. Watched addresses live in the database and
+// are migrated with it.
+//
+// Node health is not part of a snapshot: it is kept by node URL, which
+// often changes with the deployment, and is learned again from the nodes
+// within minutes. Nor are the outputs and mempool transactions a running
+// indexer remembers, which are kept in memory only and rebuilt from the
+// blocks indexed after the restore, see Excluded.
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/watchaddress"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/kvstore"
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/fystack/multichain-indexer/pkg/store/channelstore"
+)
+
+// Version is the version of the snapshots Write produces, the only one
+// Read accepts. It changes whenever the meaning of a field does.
+const Version = 1
+
+// journalKey holds the state a restore replaces until the restore has
+// completed, for one interrupted part way to be rolled back.
+const journalKey = "snapshot/restore_journal"
+
+// Excluded names the state a snapshot leaves out, see the package doc.
+var Excluded = []string{
+	"node health and capabilities",
+	"watched outputs and mempool transactions seen",
+}
+
+var (
+	ErrVersionMismatch = errors.New("snapshot version mismatch")
+	ErrUnknownChain    = errors.New("snapshot chain is not configured")
+)
+
+// Snapshot is the indexer's state at CreatedAt. Chains are keyed by name.
+type Snapshot struct {
+	Version   int                       `json:"version"`
+	CreatedAt time.Time                 `json:"created_at"`
+	Chains    map[string]Chain          `json:"chains"`
+	Xpubs     []*watchaddress.XpubState `json:"xpubs,omitempty"`
+}
+
+// Chain is a chain's state. InternalCode is the code its block store
+// state was kept under; a restore keys it by the target's code instead.
+type Chain struct {
+	InternalCode    string                 `json:"internal_code"`
+	State           blockstore.ChainState  `json:"state"`
+	ChannelFundings []channelstore.Funding `json:"channel_fundings,omitempty"`
+}
+
+// FundingStore lists and restores remembered channel funding outpoints.
+// *channelstore.Store implements it.
+type FundingStore interface {
+	ListFundings(ctx context.Context, chain string) ([]channelstore.Funding, error)
+	RestoreFundings(ctx context.Context, chain string, fundings []channelstore.Funding) error
+}
+
+// Stores are where the state lives. Fundings is nil without Redis.
+type Stores struct {
+	KV       infra.KVStore
+	Fundings FundingStore
+}
+
+// Export reads the state of chains, internal codes by name, from stores.
+func Export(ctx context.Context, stores Stores, chains map[string]string) (*Snapshot, error) {
+	blocks := blockstore.NewBlockStore(stores.KV)
+	snap := &Snapshot{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Chains:    make(map[string]Chain, len(chains)),
+	}
+	for name, code := range chains {
+		state, err := blockstore.LoadChainState(blocks, code)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		chain := Chain{InternalCode: code, State: state}
+		if stores.Fundings != nil {
+			if chain.ChannelFundings, err = stores.Fundings.ListFundings(ctx, name); err != nil {
+				return nil, fmt.Errorf("chain %s: %w", name, err)
+			}
+		}
+		snap.Chains[name] = chain
+	}
+	if _, err := stores.KV.GetAny(watchaddress.XpubStateKey, &snap.Xpubs); err != nil {
+		return nil, fmt.Errorf("get xpub state: %w", err)
+	}
+	return snap, nil
+}
+
+// Write writes snap to w as gzipped JSON.
+func Write(w io.Writer, snap *Snapshot) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	return zw.Close()
+}
+
+// Read reads a snapshot written by Write, failing with ErrVersionMismatch
+// on one of another version.
+func Read(r io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer zr.Close()
+	// The version is checked before the rest is decoded, which may not
+	// fit this version's types.
+	var header struct {
+		Version int `json:"version"`
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if header.Version != Version {
+		return nil, versionMismatch(header.Version)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// Filter returns snap restricted to the named chains, all if none.
+func (snap *Snapshot) Filter(names []string) (*Snapshot, error) {
+	if len(names) == 0 {
+		return snap, nil
+	}
+	filtered := *snap
+	filtered.Chains = make(map[string]Chain, len(names))
+	for _, name := range names {
+		chain, ok := snap.Chains[name]
+		if !ok {
+			return nil, fmt.Errorf("chain %s is not in the snapshot", name)
+		}
+		filtered.Chains[name] = chain
+	}
+	return &filtered, nil
+}
+
+// Report tells what a restore replaced, or would replace in a dry run.
+type Report struct {
+	DryRun    bool                   `json:"dry_run"`
+	Version   int                    `json:"version"`
+	CreatedAt time.Time              `json:"created_at"`
+	Chains    map[string]ChainReport `json:"chains"`
+	Xpubs     []XpubReport           `json:"xpubs,omitempty"`
+	// Keys counts the KV keys written and deleted.
+	Keys        int `json:"keys"`
+	DeletedKeys int `json:"deleted_keys"`
+}
+
+// ChainReport compares a chain's current state to the restored one.
+type ChainReport struct {
+	InternalCode    string `json:"internal_code"`
+	LatestBlock     uint64 `json:"latest_block"`
+	PreviousLatest  uint64 `json:"previous_latest_block"`
+	FailedBlocks    int    `json:"failed_blocks"`
+	CatchupRanges   int    `json:"catchup_ranges"`
+	BlockHashes     int    `json:"block_hashes"`
+	HashWindowTip   uint64 `json:"hash_window_tip,omitempty"`
+	ReorgHalted     bool   `json:"reorg_halted,omitempty"`
+	ChannelFundings int    `json:"channel_fundings,omitempty"`
+}
+
+// XpubReport is an imported key's restored branch cursors.
+type XpubReport struct {
+	Xpub     string    `json:"xpub"`
+	Derived  [2]uint32 `json:"derived"`
+	LastUsed [2]int64  `json:"last_used"`
+}
+
+// Restore replaces the state of snap's chains in stores, keying each by its
+// internal code in chains, the target's codes by name, and replaces the
+// xpub state if snap has any. The indexer must not be running. A dry run
+// only reports what would be restored.
+//
+// The KV keys are replaced all or none: the values replaced are journaled
+// first, and put back when writing fails, or by RecoverInterrupted when
+// the restore did not complete. Lightning funding outpoints are restored
+// before the KV keys; those restored before a failure are kept, as they
+// only add to the outpoints remembered.
+func Restore(ctx context.Context, stores Stores, snap *Snapshot, chains map[string]string, dryRun bool) (*Report, error) {
+	if snap.Version != Version {
+		return nil, versionMismatch(snap.Version)
+	}
+	report := &Report{
+		DryRun:    dryRun,
+		Version:   snap.Version,
+		CreatedAt: snap.CreatedAt,
+		Chains:    make(map[string]ChainReport, len(snap.Chains)),
+	}
+	blocks := blockstore.NewBlockStore(stores.KV)
+	var pairs []infra.KVPair
+	var stale []string
+	for _, name := range slices.Sorted(maps.Keys(snap.Chains)) {
+		chain := snap.Chains[name]
+		code, ok := chains[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownChain, name)
+		}
+		if len(chain.ChannelFundings) > 0 && stores.Fundings == nil {
+			return nil, fmt.Errorf("chain %s: channel fundings need Redis to be restored", name)
+		}
+		current, err := blockstore.LoadChainState(blocks, code)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		chainPairs, err := blockstore.ChainStatePairs(code, chain.State)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		chainStale, err := blockstore.StaleKeys(code, current, chain.State)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		pairs = append(pairs, chainPairs...)
+		stale = append(stale, chainStale...)
+
+		cr := ChainReport{
+			InternalCode:    code,
+			LatestBlock:     chain.State.LatestBlock,
+			PreviousLatest:  current.LatestBlock,
+			FailedBlocks:    len(chain.State.FailedBlocks),
+			CatchupRanges:   len(chain.State.CatchupRanges),
+			BlockHashes:     len(chain.State.BlockHashes),
+			ReorgHalted:     chain.State.ReorgHalt != nil,
+			ChannelFundings: len(chain.ChannelFundings),
+		}
+		if n := len(chain.State.BlockHashes); n > 0 {
+			cr.HashWindowTip = chain.State.BlockHashes[n-1].BlockNumber
+		}
+		report.Chains[name] = cr
+	}
+	if len(snap.Xpubs) > 0 {
+		data, err := infra.JSON.Marshal(snap.Xpubs)
+		if err != nil {
+			return nil, fmt.Errorf("encode xpub state: %w", err)
+		}
+		pairs = append(pairs, infra.KVPair{Key: watchaddress.XpubStateKey, Value: data})
+		for _, state := range snap.Xpubs {
+			report.Xpubs = append(report.Xpubs, XpubReport{Xpub: state.Xpub, Derived: state.Derived, LastUsed: state.LastUsed})
+		}
+	}
+	report.Keys, report.DeletedKeys = len(pairs), len(stale)
+	if dryRun {
+		return report, nil
+	}
+
+	if stores.Fundings != nil {
+		for _, name := range slices.Sorted(maps.Keys(snap.Chains)) {
+			fundings := unexpired(snap.Chains[name].ChannelFundings, time.Since(snap.CreatedAt))
+			if err := stores.Fundings.RestoreFundings(ctx, name, fundings); err != nil {
+				return nil, fmt.Errorf("chain %s: %w", name, err)
+			}
+		}
+	}
+
+	j, err := newJournal(stores.KV, pairs, stale)
+	if err != nil {
+		return nil, err
+	}
+	if err := stores.KV.SetAny(journalKey, j); err != nil {
+		return nil, fmt.Errorf("write restore journal: %w", err)
+	}
+	if err := replace(stores.KV, pairs, stale); err != nil {
+		if rbErr := j.rollBack(stores.KV); rbErr != nil {
+			return nil, fmt.Errorf("%w; rolling back failed, retried on the next start: %v", err, rbErr)
+		}
+		return nil, fmt.Errorf("%w; rolled back", err)
+	}
+	if err := stores.KV.Delete(journalKey); err != nil {
+		return nil, fmt.Errorf("delete restore journal, the restore is rolled back on the next start: %w", err)
+	}
+	return report, nil
+}
+
+// replace writes pairs, then deletes the stale keys.
+func replace(kv infra.KVStore, pairs []infra.KVPair, stale []string) error {
+	if err := kv.BatchSet(pairs); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	for _, key := range stale {
+		if err := kv.Delete(key); err != nil {
+			return fmt.Errorf("delete replaced state %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// journal is the state a restore replaces: the values of the keys it
+// writes or deletes, and the keys it creates.
+type journal struct {
+	Values map[string][]byte `json:"values"`
+	Absent []string          `json:"absent,omitempty"`
+}
+
+func newJournal(kv infra.KVStore, pairs []infra.KVPair, stale []string) (*journal, error) {
+	j := &journal{Values: make(map[string][]byte)}
+	keys := make([]string, 0, len(pairs)+len(stale))
+	for _, p := range pairs {
+		keys = append(keys, p.Key)
+	}
+	for _, key := range append(keys, stale...) {
+		value, err := kv.Get(key)
+		switch {
+		case errors.Is(err, kvstore.ErrKeyNotFound):
+			j.Absent = append(j.Absent, key)
+		case err != nil:
+			return nil, fmt.Errorf("read replaced state %s: %w", key, err)
+		default:
+			j.Values[key] = []byte(value)
+		}
+	}
+	return j, nil
+}
+
+// rollBack puts the journaled state back, then deletes the journal.
+func (j *journal) rollBack(kv infra.KVStore) error {
+	pairs := make([]infra.KVPair, 0, len(j.Values))
+	for _, key := range slices.Sorted(maps.Keys(j.Values)) {
+		pairs = append(pairs, infra.KVPair{Key: key, Value: j.Values[key]})
+	}
+	if err := replace(kv, pairs, j.Absent); err != nil {
+		return err
+	}
+	return kv.Delete(journalKey)
+}
+
+// RecoverInterrupted rolls back a restore that did not complete, e.g. as
+// the process was killed while writing, reporting whether there was one.
+// Run it before anything reads the state.
+func RecoverInterrupted(kv infra.KVStore) (bool, error) {
+	var j journal
+	found, err := kv.GetAny(journalKey, &j)
+	if err != nil {
+		return false, fmt.Errorf("read restore journal: %w", err)
+	}
+	if !found {
+		return false, nil
+	}
+	if err := j.rollBack(kv); err != nil {
+		return true, fmt.Errorf("roll back interrupted restore: %w", err)
+	}
+	return true, nil
+}
+
+// unexpired returns the fundings remembered for longer than elapsed, with
+// the time they have left.
+func unexpired(fundings []channelstore.Funding, elapsed time.Duration) []channelstore.Funding {
+	var left []channelstore.Funding
+	for _, f := range fundings {
+		if f.TTL > elapsed {
+			left = append(left, channelstore.Funding{Outpoint: f.Outpoint, TTL: f.TTL - elapsed})
+		}
+	}
+	return left
+}
+
+func versionMismatch(version int) error {
+	return fmt.Errorf("%w: snapshot is version %d, this build reads version %d",
+		ErrVersionMismatch, version, Version)
+}
@@ -0,0 +1,255 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/watchaddress"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/kvstore"
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/fystack/multichain-indexer/pkg/store/channelstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memKV struct {
+	infra.KVStore
+	values  map[string][]byte
+	batches int
+	// failDelete fails deleting the key, to interrupt a restore.
+	failDelete string
+}
+
+func newMemKV() *memKV { return &memKV{values: make(map[string][]byte)} }
+
+func (kv *memKV) Get(k string) (string, error) {
+	v, ok := kv.values[k]
+	if !ok {
+		return "", kvstore.ErrKeyNotFound
+	}
+	return string(v), nil
+}
+
+func (kv *memKV) Set(k, v string) error {
+	kv.values[k] = []byte(v)
+	return nil
+}
+
+func (kv *memKV) GetAny(k string, v any) (bool, error) {
+	data, ok := kv.values[k]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func (kv *memKV) SetAny(k string, v any) error {
+	data, err := json.Marshal(v)
+	kv.values[k] = data
+	return err
+}
+
+func (kv *memKV) List(prefix string) ([]*infra.KVPair, error) {
+	var pairs []*infra.KVPair
+	for k, v := range kv.values {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, &infra.KVPair{Key: k, Value: v})
+		}
+	}
+	return pairs, nil
+}
+
+func (kv *memKV) Delete(k string) error {
+	if k == kv.failDelete {
+		return errors.New("connection reset")
+	}
+	delete(kv.values, k)
+	return nil
+}
+
+func (kv *memKV) BatchSet(pairs []infra.KVPair) error {
+	kv.batches++
+	for _, p := range pairs {
+		kv.values[p.Key] = p.Value
+	}
+	return nil
+}
+
+type memFundings map[string][]channelstore.Funding
+
+func (m memFundings) ListFundings(_ context.Context, chain string) ([]channelstore.Funding, error) {
+	return m[chain], nil
+}
+
+func (m memFundings) RestoreFundings(_ context.Context, chain string, fundings []channelstore.Funding) error {
+	m[chain] = append(m[chain], fundings...)
+	return nil
+}
+
+func seedSource(t *testing.T) Stores {
+	kv := newMemKV()
+	blocks := blockstore.NewBlockStore(kv)
+	require.NoError(t, blocks.SaveLatestBlock("BTC", 850_000))
+	require.NoError(t, blocks.SaveFailedBlocks("BTC", []uint64{849_990, 849_995}))
+	require.NoError(t, blocks.SaveCatchupProgress("BTC", 840_000, 845_000, 842_000))
+	require.NoError(t, blocks.SaveBlockHashes("BTC", []blockstore.BlockHashEntry{
+		{BlockNumber: 849_999, Hash: "aa"}, {BlockNumber: 850_000, Hash: "bb"},
+	}))
+	require.NoError(t, blocks.SaveLatestBlock("ETH", 20_000_000))
+	require.NoError(t, kv.SetAny(watchaddress.XpubStateKey, []*watchaddress.XpubState{{
+		Xpub: "xpub-1", GapLimit: 20, Derived: [2]uint32{45, 21}, LastUsed: [2]int64{24, 0},
+	}}))
+	return Stores{KV: kv, Fundings: memFundings{
+		"bitcoin": {{Outpoint: "ab:0", TTL: time.Hour}},
+	}}
+}
+
+func roundTrip(t *testing.T, snap *Snapshot) *Snapshot {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, snap))
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	return read
+}
+
+func TestExportRestore(t *testing.T) {
+	ctx := context.Background()
+	snap, err := Export(ctx, seedSource(t), map[string]string{"bitcoin": "BTC", "ethereum": "ETH"})
+	require.NoError(t, err)
+	snap = roundTrip(t, snap)
+
+	// The target keys bitcoin under another code and has state the
+	// snapshot lacks.
+	target := newMemKV()
+	targetBlocks := blockstore.NewBlockStore(target)
+	require.NoError(t, targetBlocks.SaveLatestBlock("BTC2", 10))
+	require.NoError(t, targetBlocks.SaveCatchupProgress("BTC2", 1, 5, 2))
+	require.NoError(t, targetBlocks.SaveReorgHalt("BTC2", blockstore.ReorgHalt{Block: 9}))
+	fundings := memFundings{}
+	stores := Stores{KV: target, Fundings: fundings}
+	codes := map[string]string{"bitcoin": "BTC2", "ethereum": "ETH"}
+
+	report, err := Restore(ctx, stores, snap, codes, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, ChainReport{
+		InternalCode: "BTC2", LatestBlock: 850_000, PreviousLatest: 10,
+		FailedBlocks: 2, CatchupRanges: 1, BlockHashes: 2, HashWindowTip: 850_000, ChannelFundings: 1,
+	}, report.Chains["bitcoin"])
+	assert.Equal(t, []XpubReport{{Xpub: "xpub-1", Derived: [2]uint32{45, 21}, LastUsed: [2]int64{24, 0}}}, report.Xpubs)
+	assert.Equal(t, 2, report.DeletedKeys, "the target's catchup range and reorg halt")
+	assert.Zero(t, target.batches, "a dry run writes nothing")
+	assert.Empty(t, fundings)
+
+	report, err = Restore(ctx, stores, snap, codes, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, target.batches)
+	assert.NotContains(t, target.values, journalKey)
+
+	restored, err := blockstore.LoadChainState(targetBlocks, "BTC2")
+	require.NoError(t, err)
+	assert.Equal(t, snap.Chains["bitcoin"].State, restored)
+	latest, err := targetBlocks.GetLatestBlock("ETH")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20_000_000), latest)
+
+	var xpubs []*watchaddress.XpubState
+	_, err = target.GetAny(watchaddress.XpubStateKey, &xpubs)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Xpubs, xpubs)
+
+	require.Len(t, fundings["bitcoin"], 1)
+	assert.Equal(t, "ab:0", fundings["bitcoin"][0].Outpoint)
+	assert.Less(t, fundings["bitcoin"][0].TTL, time.Hour, "the time since the export has elapsed")
+}
+
+func TestRead_VersionMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(`{"version": 2, "chains": {"bitcoin": {"state": "v2 layout"}}}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	_, err = Read(&buf)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	_, err = Restore(context.Background(), Stores{KV: newMemKV()}, &Snapshot{Version: 0}, nil, true)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+}
+
+func TestRestore_Rejects(t *testing.T) {
+	ctx := context.Background()
+	snap, err := Export(ctx, seedSource(t), map[string]string{"bitcoin": "BTC"})
+	require.NoError(t, err)
+
+	_, err = Restore(ctx, Stores{KV: newMemKV(), Fundings: memFundings{}}, snap, map[string]string{"ethereum": "ETH"}, false)
+	assert.ErrorIs(t, err, ErrUnknownChain)
+
+	target := newMemKV()
+	_, err = Restore(ctx, Stores{KV: target}, snap, map[string]string{"bitcoin": "BTC"}, false)
+	assert.ErrorContains(t, err, "need Redis")
+	assert.Empty(t, target.values, "nothing is written when a chain cannot be restored")
+
+	_, err = snap.Filter([]string{"litecoin"})
+	assert.Error(t, err)
+}
+
+func TestRestore_RollsBack(t *testing.T) {
+	ctx := context.Background()
+	snap, err := Export(ctx, seedSource(t), map[string]string{"bitcoin": "BTC"})
+	require.NoError(t, err)
+
+	target := newMemKV()
+	targetBlocks := blockstore.NewBlockStore(target)
+	require.NoError(t, targetBlocks.SaveLatestBlock("BTC", 10))
+	require.NoError(t, targetBlocks.SaveReorgHalt("BTC", blockstore.ReorgHalt{Block: 9}))
+	before := maps.Clone(target.values)
+
+	// Deleting the replaced reorg halt fails after the state was written.
+	for k := range before {
+		if strings.HasSuffix(k, constant.KVPrefixReorgHalt) {
+			target.failDelete = k
+		}
+	}
+	require.NotEmpty(t, target.failDelete)
+
+	_, err = Restore(ctx, Stores{KV: target, Fundings: memFundings{}}, snap, map[string]string{"bitcoin": "BTC"}, false)
+	require.ErrorContains(t, err, "rolled back")
+	assert.Equal(t, before, target.values)
+}
+
+func TestRecoverInterrupted(t *testing.T) {
+	ctx := context.Background()
+	snap, err := Export(ctx, seedSource(t), map[string]string{"bitcoin": "BTC"})
+	require.NoError(t, err)
+
+	target := newMemKV()
+	require.NoError(t, blockstore.NewBlockStore(target).SaveLatestBlock("BTC", 10))
+	before := maps.Clone(target.values)
+
+	found, err := RecoverInterrupted(target)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// The restore is interrupted, e.g. as the process was killed, with the
+	// state written but the journal still there.
+	target.failDelete = journalKey
+	_, err = Restore(ctx, Stores{KV: target, Fundings: memFundings{}}, snap, map[string]string{"bitcoin": "BTC"}, false)
+	require.Error(t, err)
+	require.Contains(t, target.values, journalKey)
+	target.failDelete = ""
+
+	found, err = RecoverInterrupted(target)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, before, target.values)
+}
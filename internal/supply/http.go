@@ -0,0 +1,60 @@
+package supply
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+// Handler serves GET /v1/networks/{id}/supply[?height=N] with the Supply of
+// the tracker whose network ID is id, at height N or at the highest recorded
+// block. It must be registered on a pattern binding {id}.
+func Handler(trackers ...*Tracker) http.Handler {
+	byNetwork := make(map[string]*Tracker, len(trackers))
+	for _, t := range trackers {
+		byNetwork[t.NetworkID()] = t
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		networkID := r.PathValue("id")
+		tracker, ok := byNetwork[networkID]
+		if !ok {
+			http.Error(w, "supply not tracked for network "+strconv.Quote(networkID), http.StatusNotFound)
+			return
+		}
+
+		var supply *Supply
+		var err error
+		if h := r.URL.Query().Get("height"); h != "" {
+			height, perr := strconv.ParseUint(h, 10, 64)
+			if perr != nil {
+				http.Error(w, "invalid height", http.StatusBadRequest)
+				return
+			}
+			supply, err = tracker.At(r.Context(), height)
+		} else {
+			supply, err = tracker.Latest(r.Context())
+		}
+		switch {
+		case errors.Is(err, ErrNotTracked):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			logger.Error("Supply lookup failed", "network_id", networkID, "error", err)
+			http.Error(w, "failed to look up supply", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(supply)
+	})
+}
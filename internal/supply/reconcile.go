@@ -0,0 +1,95 @@
+package supply
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/model"
+)
+
+// Drift is a stored record disagreeing with the node.
+type Drift struct {
+	Height uint64 `json:"height"`
+	Field  string `json:"field"` // block_hash, subsidy or fees
+	Stored string `json:"stored"`
+	Node   string `json:"node"`
+}
+
+// Report is the outcome of Reconcile.
+type Report struct {
+	Checked int      `json:"checked"`
+	Missing []uint64 `json:"missing,omitempty"` // heights without a record
+	Drift   []Drift  `json:"drift,omitempty"`
+	Fixed   int      `json:"fixed"` // records written, with fix
+}
+
+// Clean reports whether every checked height had a record matching the node.
+func (r Report) Clean() bool {
+	return len(r.Missing) == 0 && len(r.Drift) == 0
+}
+
+// Reconcile recomputes the records of heights from through to from the
+// node's getblockstats and compares them with the stored ones. A node
+// subsidy off the schedule means the tracker's halving interval is wrong for
+// the network and fails the run. With fix, missing and drifted records are
+// rewritten from the node; the cumulative totals are sums of the records, so
+// fixing the records fixes them.
+func (t *Tracker) Reconcile(ctx context.Context, stats StatsSource, from, to uint64, fix bool) (Report, error) {
+	var report Report
+	for height := from; height <= to; height++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		node, err := stats.BlockStats(ctx, height)
+		if err != nil {
+			return report, fmt.Errorf("block stats at height %d: %w", height, err)
+		}
+		if node.Subsidy != bitcoin.BlockSubsidy(height, t.halvingInterval) {
+			return report, fmt.Errorf("node subsidy %d at height %d is not the scheduled %d",
+				node.Subsidy, height, bitcoin.BlockSubsidy(height, t.halvingInterval))
+		}
+		stored, err := t.repo.Get(ctx, t.networkID, height)
+		if err != nil {
+			return report, err
+		}
+		report.Checked++
+
+		var drift []Drift
+		if stored == nil {
+			report.Missing = append(report.Missing, height)
+		} else {
+			drift = compare(stored, node)
+			report.Drift = append(report.Drift, drift...)
+		}
+		if !fix || (stored != nil && len(drift) == 0) {
+			continue
+		}
+		err = t.repo.Upsert(ctx, &model.SupplyBlock{
+			NetworkID: t.networkID,
+			Height:    height,
+			BlockHash: node.BlockHash,
+			Subsidy:   node.Subsidy,
+			Fees:      node.TotalFee,
+		})
+		if err != nil {
+			return report, fmt.Errorf("fix height %d: %w", height, err)
+		}
+		report.Fixed++
+	}
+	return report, nil
+}
+
+func compare(stored *model.SupplyBlock, node *bitcoin.BlockStats) []Drift {
+	var drift []Drift
+	add := func(field, s, n string) {
+		if s != n {
+			drift = append(drift, Drift{Height: stored.Height, Field: field, Stored: s, Node: n})
+		}
+	}
+	add("block_hash", stored.BlockHash, node.BlockHash)
+	add("subsidy", strconv.FormatInt(stored.Subsidy, 10), strconv.FormatInt(node.Subsidy, 10))
+	add("fees", strconv.FormatInt(stored.Fees, 10), strconv.FormatInt(node.TotalFee, 10))
+	return drift
+}
@@ -0,0 +1,161 @@
+// Package supply tracks the issued supply and fees of a Bitcoin chain as it
+// is indexed: each processed block's subsidy and fees are stored per height,
+// and cumulative totals are read from them.
+package supply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+)
+
+// ErrNotTracked is returned for a height without a supply record.
+var ErrNotTracked = errors.New("supply not tracked")
+
+// FeeSource reports the total fee of a block.
+type FeeSource interface {
+	// BlockFees returns block's total fee in satoshis.
+	BlockFees(ctx context.Context, block *types.Block) (int64, error)
+}
+
+// StatsSource reports a node's stats for the block at a height.
+type StatsSource interface {
+	BlockStats(ctx context.Context, height uint64) (*bitcoin.BlockStats, error)
+}
+
+// Tracker records the supply of one network's blocks.
+type Tracker struct {
+	repo            repository.SupplyRepository
+	fees            FeeSource
+	networkID       string
+	halvingInterval uint64
+}
+
+// NewTracker returns a Tracker storing networkID's blocks in repo, their
+// subsidy following a schedule halving every halvingInterval blocks.
+func NewTracker(
+	repo repository.SupplyRepository,
+	fees FeeSource,
+	networkID string,
+	halvingInterval uint64,
+) *Tracker {
+	return &Tracker{repo: repo, fees: fees, networkID: networkID, halvingInterval: halvingInterval}
+}
+
+// ForChain returns the Tracker of a Bitcoin chain, recording under its
+// network_id with the subsidy schedule of its bitcoin_network, mainnet's if
+// unset. fees may be nil for a tracker that is only read.
+func ForChain(repo repository.SupplyRepository, fees FeeSource, chainCfg config.ChainConfig) (*Tracker, error) {
+	if chainCfg.Type != enum.NetworkTypeBtc {
+		return nil, fmt.Errorf("supply tracking is not supported on %s chains", chainCfg.Type)
+	}
+	if chainCfg.NetworkId == "" {
+		return nil, errors.New("supply tracking needs the chain's network_id")
+	}
+	network := bitcoin.NetworkMainnet
+	if chainCfg.BitcoinNetwork != "" {
+		network = bitcoin.Network(chainCfg.BitcoinNetwork)
+	}
+	params, err := bitcoin.ParamsFor(network)
+	if err != nil {
+		return nil, err
+	}
+	return NewTracker(repo, fees, chainCfg.NetworkId, params.SubsidyHalvingInterval), nil
+}
+
+// NetworkID returns the network the tracker records.
+func (t *Tracker) NetworkID() string {
+	return t.networkID
+}
+
+// Record stores block's subsidy and fees, replacing any record of its
+// height.
+func (t *Tracker) Record(ctx context.Context, block *types.Block) error {
+	fees, err := t.fees.BlockFees(ctx, block)
+	if err != nil {
+		return fmt.Errorf("block fees: %w", err)
+	}
+	return t.repo.Upsert(ctx, &model.SupplyBlock{
+		NetworkID: t.networkID,
+		Height:    block.Number,
+		BlockHash: block.Hash,
+		Subsidy:   bitcoin.BlockSubsidy(block.Number, t.halvingInterval),
+		Fees:      fees,
+	})
+}
+
+// Rollback deletes the records at height from and above, so blocks replacing
+// them after a reorg are not counted twice.
+func (t *Tracker) Rollback(ctx context.Context, from uint64) error {
+	_, err := t.repo.DeleteFrom(ctx, t.networkID, from)
+	return err
+}
+
+// Supply is a network's supply at a height. Amounts are in satoshis.
+type Supply struct {
+	NetworkID string `json:"network_id"`
+	Height    uint64 `json:"height"`
+	BlockHash string `json:"block_hash"`
+	Subsidy   int64  `json:"subsidy"`
+	Fees      int64  `json:"fees"`
+	// CumulativeSubsidy is the scheduled subsidy of blocks 0 through Height,
+	// the supply issued by then.
+	CumulativeSubsidy int64 `json:"cumulative_subsidy"`
+	// CumulativeFees sums the fees of the FeesTrackedBlocks recorded blocks
+	// from FeesTrackedFrom through Height; it covers the whole chain only
+	// when tracking started at genesis without gaps.
+	CumulativeFees    int64  `json:"cumulative_fees"`
+	FeesTrackedFrom   uint64 `json:"fees_tracked_from"`
+	FeesTrackedBlocks int64  `json:"fees_tracked_blocks"`
+}
+
+// At returns the supply at height, ErrNotTracked if the block at height was
+// not recorded.
+func (t *Tracker) At(ctx context.Context, height uint64) (*Supply, error) {
+	block, err := t.repo.Get(ctx, t.networkID, height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%w at height %d", ErrNotTracked, height)
+	}
+	return t.supply(ctx, block)
+}
+
+// Latest returns the supply at the highest recorded block, ErrNotTracked if
+// there is none.
+func (t *Tracker) Latest(ctx context.Context) (*Supply, error) {
+	block, err := t.repo.Latest(ctx, t.networkID)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%w: no block recorded", ErrNotTracked)
+	}
+	return t.supply(ctx, block)
+}
+
+func (t *Tracker) supply(ctx context.Context, block *model.SupplyBlock) (*Supply, error) {
+	totals, err := t.repo.Totals(ctx, t.networkID, block.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &Supply{
+		NetworkID:         t.networkID,
+		Height:            block.Height,
+		BlockHash:         block.BlockHash,
+		Subsidy:           block.Subsidy,
+		Fees:              block.Fees,
+		CumulativeSubsidy: bitcoin.ScheduledSupply(block.Height, t.halvingInterval),
+		CumulativeFees:    totals.Fees,
+		FeesTrackedFrom:   totals.FirstHeight,
+		FeesTrackedBlocks: totals.Blocks,
+	}, nil
+}
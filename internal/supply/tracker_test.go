@@ -0,0 +1,243 @@
+package supply
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memRepo is an in-memory repository.SupplyRepository.
+type memRepo struct {
+	mu   sync.Mutex
+	rows map[string]map[uint64]model.SupplyBlock
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{rows: make(map[string]map[uint64]model.SupplyBlock)}
+}
+
+func (r *memRepo) Upsert(_ context.Context, blocks ...*model.SupplyBlock) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range blocks {
+		if r.rows[b.NetworkID] == nil {
+			r.rows[b.NetworkID] = make(map[uint64]model.SupplyBlock)
+		}
+		r.rows[b.NetworkID][b.Height] = *b
+	}
+	return nil
+}
+
+func (r *memRepo) Get(_ context.Context, networkID string, height uint64) (*model.SupplyBlock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b, ok := r.rows[networkID][height]; ok {
+		return &b, nil
+	}
+	return nil, nil
+}
+
+func (r *memRepo) Latest(_ context.Context, networkID string) (*model.SupplyBlock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *model.SupplyBlock
+	for _, b := range r.rows[networkID] {
+		if latest == nil || b.Height > latest.Height {
+			latest = &b
+		}
+	}
+	return latest, nil
+}
+
+func (r *memRepo) DeleteFrom(_ context.Context, networkID string, height uint64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for h := range r.rows[networkID] {
+		if h >= height {
+			delete(r.rows[networkID], h)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) Totals(_ context.Context, networkID string, height uint64) (repository.SupplyTotals, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var t repository.SupplyTotals
+	for h, b := range r.rows[networkID] {
+		if h > height {
+			continue
+		}
+		if t.Blocks == 0 || h < t.FirstHeight {
+			t.FirstHeight = h
+		}
+		t.Blocks++
+		t.Fees += b.Fees
+	}
+	return t, nil
+}
+
+func (r *memRepo) heights(networkID string) []uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var hs []uint64
+	for h := range r.rows[networkID] {
+		hs = append(hs, h)
+	}
+	slices.Sort(hs)
+	return hs
+}
+
+// feesByHash reports fees by block hash.
+type feesByHash map[string]int64
+
+func (f feesByHash) BlockFees(_ context.Context, block *types.Block) (int64, error) {
+	return f[block.Hash], nil
+}
+
+type statsFunc func(ctx context.Context, height uint64) (*bitcoin.BlockStats, error)
+
+func (f statsFunc) BlockStats(ctx context.Context, height uint64) (*bitcoin.BlockStats, error) {
+	return f(ctx, height)
+}
+
+func TestTrackerRecordsAndRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	fees := feesByHash{"a": 100, "b": 200, "c": 300, "c'": 50}
+	tracker := NewTracker(repo, fees, "btc", 210_000)
+
+	for _, b := range []types.Block{{Number: 209_999, Hash: "a"}, {Number: 210_000, Hash: "b"}, {Number: 210_001, Hash: "c"}} {
+		require.NoError(t, tracker.Record(ctx, &b))
+	}
+
+	got, err := tracker.At(ctx, 210_000)
+	require.NoError(t, err)
+	assert.Equal(t, &Supply{
+		NetworkID:         "btc",
+		Height:            210_000,
+		BlockHash:         "b",
+		Subsidy:           2_500_000_000,
+		Fees:              200,
+		CumulativeSubsidy: 210_000*5_000_000_000 + 2_500_000_000,
+		CumulativeFees:    300,
+		FeesTrackedFrom:   209_999,
+		FeesTrackedBlocks: 2,
+	}, got)
+
+	// A reorg replaces the tip; its fees are counted once.
+	require.NoError(t, tracker.Rollback(ctx, 210_001))
+	require.NoError(t, tracker.Record(ctx, &types.Block{Number: 210_001, Hash: "c'"}))
+	got, err = tracker.Latest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c'", got.BlockHash)
+	assert.Equal(t, int64(350), got.CumulativeFees)
+	assert.Equal(t, int64(3), got.FeesTrackedBlocks)
+
+	_, err = tracker.At(ctx, 5)
+	assert.ErrorIs(t, err, ErrNotTracked)
+	_, err = NewTracker(repo, fees, "other", 210_000).Latest(ctx)
+	assert.ErrorIs(t, err, ErrNotTracked)
+}
+
+func TestTrackerReconcile(t *testing.T) {
+	ctx := context.Background()
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{
+		Start:  840_000,
+		Blocks: 5,
+		Pay:    []string{"bc1qpay1", "bc1qpay2"},
+	})
+	client := bitcointest.NewSimClient(sim)
+	stats := statsFunc(client.GetBlockStats)
+
+	repo := newMemRepo()
+	tracker := NewTracker(repo, nil, "btc", 210_000)
+	for h := uint64(840_000); h <= 840_004; h++ {
+		if h == 840_002 {
+			continue // never recorded
+		}
+		require.NoError(t, repo.Upsert(ctx, &model.SupplyBlock{
+			NetworkID: "btc", Height: h, BlockHash: sim.BlockHash(h), Subsidy: 312_500_000, Fees: 2_000,
+		}))
+	}
+	// Recorded from a block reorged out since.
+	require.NoError(t, repo.Upsert(ctx, &model.SupplyBlock{
+		NetworkID: "btc", Height: 840_004, BlockHash: "stale", Subsidy: 312_500_000, Fees: 9_000,
+	}))
+
+	report, err := tracker.Reconcile(ctx, stats, 840_000, 840_004, false)
+	require.NoError(t, err)
+	assert.False(t, report.Clean())
+	assert.Equal(t, 5, report.Checked)
+	assert.Equal(t, []uint64{840_002}, report.Missing)
+	assert.Equal(t, []Drift{
+		{Height: 840_004, Field: "block_hash", Stored: "stale", Node: sim.BlockHash(840_004)},
+		{Height: 840_004, Field: "fees", Stored: "9000", Node: "2000"},
+	}, report.Drift)
+	assert.Zero(t, report.Fixed)
+
+	report, err = tracker.Reconcile(ctx, stats, 840_000, 840_004, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Fixed)
+	assert.Equal(t, []uint64{840_000, 840_001, 840_002, 840_003, 840_004}, repo.heights("btc"))
+
+	report, err = tracker.Reconcile(ctx, stats, 840_000, 840_004, false)
+	require.NoError(t, err)
+	assert.True(t, report.Clean())
+
+	// The sim pays the fourth-era subsidy, off a regtest schedule.
+	_, err = NewTracker(repo, nil, "btc", 150).Reconcile(ctx, stats, 840_000, 840_000, false)
+	assert.ErrorContains(t, err, "is not the scheduled")
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemRepo()
+	tracker := NewTracker(repo, feesByHash{"a": 100, "b": 200}, "bitcoin_mainnet", 210_000)
+	require.NoError(t, tracker.Record(ctx, &types.Block{Number: 1, Hash: "a"}))
+	require.NoError(t, tracker.Record(ctx, &types.Block{Number: 2, Hash: "b"}))
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/networks/{id}/supply", Handler(tracker))
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/v1/networks/bitcoin_mainnet/supply?height=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got Supply
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, uint64(1), got.Height)
+	assert.Equal(t, int64(10_000_000_000), got.CumulativeSubsidy)
+	assert.Equal(t, int64(100), got.CumulativeFees)
+
+	rec = get("/v1/networks/bitcoin_mainnet/supply")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, uint64(2), got.Height, "latest without a height")
+	assert.Equal(t, int64(300), got.CumulativeFees)
+
+	assert.Equal(t, http.StatusNotFound, get("/v1/networks/bitcoin_mainnet/supply?height=3").Code)
+	assert.Equal(t, http.StatusNotFound, get("/v1/networks/solana/supply").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/v1/networks/bitcoin_mainnet/supply?height=tip").Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/networks/bitcoin_mainnet/supply", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
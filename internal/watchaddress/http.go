@@ -0,0 +1,209 @@
+package watchaddress
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+// MaxBatchSize caps the number of addresses accepted per request.
+const MaxBatchSize = 1000
+
+type registerRequest struct {
+	NetworkType enum.NetworkType `json:"network_type"`
+	Addresses   []string         `json:"addresses"`
+}
+
+type registerResponse struct {
+	Results []Result `json:"results"`
+}
+
+// Handler serves POST requests of the form
+//
+//	{"network_type": "evm", "addresses": ["0x...", ...]}
+//
+// and responds with one Result per submitted address.
+func (s *Service) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req registerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(enum.AllNetworkTypes, req.NetworkType) {
+			http.Error(w, "unknown network_type", http.StatusBadRequest)
+			return
+		}
+		if len(req.Addresses) == 0 || len(req.Addresses) > MaxBatchSize {
+			http.Error(w, "addresses must contain 1 to 1000 entries", http.StatusBadRequest)
+			return
+		}
+
+		results, err := s.RegisterAddresses(r.Context(), req.NetworkType, req.Addresses)
+		if err != nil {
+			logger.Error("Register addresses failed", "networkType", req.NetworkType, "error", err)
+			http.Error(w, "failed to register addresses", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(registerResponse{Results: results})
+	})
+}
+
+type scriptRequest struct {
+	NetworkType enum.NetworkType `json:"network_type"`
+	Scripts     []ScriptEntry    `json:"scripts"`
+}
+
+// ScriptHandler serves POST requests of the form
+//
+//	{"network_type": "btc", "scripts": [{"script": "5121...52ae", "label": "escrow"}, ...]}
+//
+// and responds with one Result per submitted script, whose Normalized is
+// the script ID transfers paying it carry as ToAddress.
+func (s *Service) ScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req scriptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(enum.AllNetworkTypes, req.NetworkType) {
+			http.Error(w, "unknown network_type", http.StatusBadRequest)
+			return
+		}
+		if len(req.Scripts) == 0 || len(req.Scripts) > MaxBatchSize {
+			http.Error(w, "scripts must contain 1 to 1000 entries", http.StatusBadRequest)
+			return
+		}
+
+		results, err := s.RegisterScripts(r.Context(), req.NetworkType, req.Scripts)
+		switch {
+		case errors.Is(err, ErrScriptsDisabled):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			logger.Error("Register scripts failed", "networkType", req.NetworkType, "error", err)
+			http.Error(w, "failed to register scripts", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(registerResponse{Results: results})
+	})
+}
+
+type redeemScriptRequest struct {
+	NetworkType enum.NetworkType    `json:"network_type"`
+	Scripts     []RedeemScriptEntry `json:"scripts"`
+}
+
+// RedeemScriptHandler serves POST requests of the form
+//
+//	{"network_type": "btc", "scripts": [{"address": "bc1q...", "redeem_script": "5221...53ae"}, ...]}
+//
+// and responds with one Result per submitted pair, by address.
+func (s *Service) RedeemScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req redeemScriptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(enum.AllNetworkTypes, req.NetworkType) {
+			http.Error(w, "unknown network_type", http.StatusBadRequest)
+			return
+		}
+		if len(req.Scripts) == 0 || len(req.Scripts) > MaxBatchSize {
+			http.Error(w, "scripts must contain 1 to 1000 entries", http.StatusBadRequest)
+			return
+		}
+
+		results, err := s.RegisterRedeemScripts(r.Context(), req.NetworkType, req.Scripts)
+		switch {
+		case errors.Is(err, ErrRedeemScriptsDisabled):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			logger.Error("Register redeem scripts failed", "networkType", req.NetworkType, "error", err)
+			http.Error(w, "failed to register redeem scripts", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(registerResponse{Results: results})
+	})
+}
+
+type xpubResponse struct {
+	State   *XpubState `json:"state"`
+	Results []Result   `json:"results"`
+}
+
+// XpubHandler serves POST requests of the form
+//
+//	{"xpub": "zpub...", "scheme": "bip84", "network": "mainnet", "gap_limit": 20}
+//
+// where all but xpub are optional, and responds with the key's derivation
+// state and one Result per address derived by the request.
+func (s *Service) XpubHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req XpubRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		state, results, err := s.RegisterXpub(r.Context(), req)
+		switch {
+		case errors.Is(err, ErrXpubsDisabled):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, bitcoin.ErrInvalidExtendedKey), errors.Is(err, bitcoin.ErrPrivateExtendedKey),
+			errors.Is(err, errInvalidXpubRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Error("Register xpub failed", "error", err)
+			http.Error(w, "failed to register xpub", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(xpubResponse{State: state, Results: results})
+	})
+}
@@ -0,0 +1,34 @@
+package watchaddress
+
+import (
+	"context"
+
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/events"
+)
+
+// usageEmitter reports the addresses of every emitted transfer to
+// Service.ObserveUsed, so imported keys derive more addresses as their
+// earlier ones get used.
+type usageEmitter struct {
+	events.Emitter
+	service *Service
+}
+
+// ObservingEmitter wraps emitter to report the addresses of emitted
+// transfers to s. It returns emitter itself while xpub import is disabled.
+func ObservingEmitter(emitter events.Emitter, s *Service) events.Emitter {
+	if s == nil || s.xpubs == nil {
+		return emitter
+	}
+	return &usageEmitter{Emitter: emitter, service: s}
+}
+
+func (e *usageEmitter) EmitTransaction(chain string, tx *types.Transaction) error {
+	err := e.Emitter.EmitTransaction(chain, tx)
+	if obsErr := e.service.ObserveUsed(context.Background(), tx.ToAddress, tx.FromAddress); obsErr != nil {
+		logger.Error("Extend xpub gap failed", "chain", chain, "txhash", tx.TxHash, "error", obsErr)
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package watchaddress
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+)
+
+var ErrRedeemScriptsDisabled = errors.New("redeem script registration is not enabled")
+
+// RedeemScriptEntry pairs a multisig P2SH or P2WSH address with its redeem
+// (or witness) script, hex-encoded.
+type RedeemScriptEntry struct {
+	Address      string `json:"address"`
+	RedeemScript string `json:"redeem_script"`
+}
+
+// redeemScripts holds the registered redeem scripts: rows in repo, scripts
+// by address in set.
+type redeemScripts struct {
+	repo repository.Repository[model.RedeemScript]
+	set  *addressbloomfilter.ScriptSet
+}
+
+// TrackRedeemScripts enables redeem script registration, keeping the
+// scripts in repo and set, and loads the scripts registered before into
+// set.
+func (s *Service) TrackRedeemScripts(
+	ctx context.Context,
+	repo repository.Repository[model.RedeemScript],
+	set *addressbloomfilter.ScriptSet,
+) error {
+	rows, err := repo.Find(ctx, repository.FindOptions{})
+	if err != nil {
+		return fmt.Errorf("load redeem scripts: %w", err)
+	}
+	byType := make(map[enum.NetworkType]map[string]string)
+	for _, row := range rows {
+		if byType[row.Type] == nil {
+			byType[row.Type] = make(map[string]string)
+		}
+		byType[row.Type][row.Address] = row.Script
+	}
+	for networkType, scripts := range byType {
+		set.AddRedeemScripts(scripts, networkType)
+	}
+	s.redeem = &redeemScripts{repo: repo, set: set}
+	logger.Info("Loaded redeem scripts", "count", len(rows))
+	return nil
+}
+
+// RegisterRedeemScripts validates and stores the redeem scripts of
+// multisig addresses, reported per entry like RegisterAddresses. Deposits
+// to an address are then checked to pay its script. An address keeps the
+// script it was first registered with; registering another one is reported
+// as "exists" with an error.
+func (s *Service) RegisterRedeemScripts(
+	ctx context.Context,
+	networkType enum.NetworkType,
+	entries []RedeemScriptEntry,
+) ([]Result, error) {
+	if s.redeem == nil {
+		return nil, ErrRedeemScriptsDisabled
+	}
+	results := make([]Result, len(entries))
+	firstIndex := make(map[string]int, len(entries))
+	var addresses []string
+	var rows []*model.RedeemScript
+
+	for i, entry := range entries {
+		results[i].Address = entry.Address
+		address, script, err := normalizeRedeemScript(networkType, entry, s.segwitHRPs())
+		if err != nil {
+			results[i].Status = StatusInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Normalized = address
+		if _, dup := firstIndex[address]; dup {
+			results[i].Status = StatusExists
+			continue
+		}
+		firstIndex[address] = i
+		addresses = append(addresses, address)
+		rows = append(rows, &model.RedeemScript{Address: address, Type: networkType, Script: script})
+	}
+	if len(addresses) == 0 {
+		return results, nil
+	}
+
+	existing, err := s.redeem.repo.Find(ctx, repository.FindOptions{
+		Select: repository.Select("address", "script"),
+		Where:  repository.WhereType{"type": networkType, "address": addresses},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookup existing redeem scripts: %w", err)
+	}
+	known := make(map[string]string, len(existing))
+	for _, row := range existing {
+		known[row.Address] = row.Script
+	}
+	var added []*model.RedeemScript
+	scripts := make(map[string]string, len(rows))
+	for _, row := range rows {
+		i := firstIndex[row.Address]
+		if script, ok := known[row.Address]; ok {
+			results[i].Status = StatusExists
+			if script != row.Script {
+				results[i].Error = "address is registered with another redeem script"
+			}
+			continue
+		}
+		results[i].Status = StatusAdded
+		added = append(added, row)
+		scripts[row.Address] = row.Script
+	}
+
+	inserted, err := s.redeem.repo.CreateMany(ctx, added)
+	if err != nil {
+		return nil, fmt.Errorf("insert redeem scripts: %w", err)
+	}
+	s.redeem.set.AddRedeemScripts(scripts, networkType)
+
+	logger.Info("Registered redeem scripts",
+		"networkType", networkType,
+		"submitted", len(entries),
+		"inserted", inserted,
+		"valid", len(addresses),
+	)
+	return results, nil
+}
+
+// normalizeRedeemScript returns entry's canonical address and lowercase
+// hex script, checking the script is a multisig one. Whether the address
+// pays to the script is checked on each deposit instead, where a mismatch
+// is flagged. hrps are accepted as by addressutil.Normalize.
+func normalizeRedeemScript(networkType enum.NetworkType, entry RedeemScriptEntry, hrps []string) (address, script string, err error) {
+	if networkType != enum.NetworkTypeBtc {
+		return "", "", fmt.Errorf("redeem scripts cannot be registered on %s", networkType)
+	}
+	if address, err = addressutil.Normalize(networkType, entry.Address, hrps...); err != nil {
+		return "", "", err
+	}
+	if script, err = normalizeScript(networkType, entry.RedeemScript); err != nil {
+		return "", "", err
+	}
+	raw, _ := hex.DecodeString(script)
+	if _, ok := bitcoin.ParseMultisig(raw); !ok {
+		return "", "", errors.New("redeem script is not a multisig script")
+	}
+	return address, script, nil
+}
@@ -0,0 +1,106 @@
+package watchaddress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultAddr is a P2WSH address; the scripts below need not hash to it, that
+// is checked on deposits.
+const vaultAddr = "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"
+
+// multisig1of1 is a 1-of-1 multisig script over the key G.
+const multisig1of1 = "51210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179851ae"
+
+// multisig1of1Other is a 1-of-1 multisig script over the key 2G.
+const multisig1of1Other = "512102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee551ae"
+
+type fakeRedeemRepo struct {
+	rows map[string]*model.RedeemScript
+}
+
+func (r *fakeRedeemRepo) Find(_ context.Context, opts repository.FindOptions) ([]*model.RedeemScript, error) {
+	var out []*model.RedeemScript
+	addresses, filtered := opts.Where["address"].([]string)
+	for _, row := range r.rows {
+		if !filtered {
+			out = append(out, row)
+			continue
+		}
+		for _, addr := range addresses {
+			if row.Address == addr && row.Type == opts.Where["type"] {
+				out = append(out, row)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRedeemRepo) CreateMany(_ context.Context, rows []*model.RedeemScript) (int64, error) {
+	var n int64
+	for _, row := range rows {
+		if _, ok := r.rows[row.Address]; !ok {
+			r.rows[row.Address] = row
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestRegisterRedeemScripts(t *testing.T) {
+	svc := NewService(&fakeRepo{rows: map[string]enum.NetworkType{}}, nil)
+	_, err := svc.RegisterRedeemScripts(context.Background(), enum.NetworkTypeBtc, []RedeemScriptEntry{{Address: vaultAddr, RedeemScript: multisig1of1}})
+	require.ErrorIs(t, err, ErrRedeemScriptsDisabled)
+
+	repo := &fakeRedeemRepo{rows: map[string]*model.RedeemScript{}}
+	set := addressbloomfilter.NewScriptSet()
+	require.NoError(t, svc.TrackRedeemScripts(context.Background(), repo, set))
+
+	results, err := svc.RegisterRedeemScripts(context.Background(), enum.NetworkTypeBtc, []RedeemScriptEntry{
+		{Address: " " + vaultAddr, RedeemScript: multisig1of1},
+		{Address: "not-an-address", RedeemScript: multisig1of1},
+		{Address: vaultAddr[:4] + "qqqq", RedeemScript: multisig1of1},
+		{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", RedeemScript: "0014" + multisig1of1[4:44]},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, StatusAdded, results[0].Status)
+	assert.Equal(t, vaultAddr, results[0].Normalized)
+	for _, r := range results[1:] {
+		assert.Equal(t, StatusInvalid, r.Status, r.Address)
+	}
+	assert.Equal(t, "redeem script is not a multisig script", results[3].Error)
+
+	script, ok := set.RedeemScript(vaultAddr, enum.NetworkTypeBtc)
+	assert.True(t, ok)
+	assert.Equal(t, multisig1of1, script)
+
+	// The first registration stands; another script is reported.
+	results, err = svc.RegisterRedeemScripts(context.Background(), enum.NetworkTypeBtc, []RedeemScriptEntry{
+		{Address: vaultAddr, RedeemScript: multisig1of1Other},
+		{Address: vaultAddr, RedeemScript: multisig1of1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusExists, results[0].Status)
+	assert.Equal(t, "address is registered with another redeem script", results[0].Error)
+	assert.Equal(t, StatusExists, results[1].Status, "duplicate within the request")
+	script, _ = set.RedeemScript(vaultAddr, enum.NetworkTypeBtc)
+	assert.Equal(t, multisig1of1, script)
+
+	results, err = svc.RegisterRedeemScripts(context.Background(), enum.NetworkTypeEVM, []RedeemScriptEntry{{Address: vaultAddr, RedeemScript: multisig1of1}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusInvalid, results[0].Status)
+
+	// A restart loads the registered scripts again.
+	reloaded := addressbloomfilter.NewScriptSet()
+	require.NoError(t, NewService(&fakeRepo{}, nil).TrackRedeemScripts(context.Background(), repo, reloaded))
+	_, ok = reloaded.RedeemScript(vaultAddr, enum.NetworkTypeBtc)
+	assert.True(t, ok)
+}
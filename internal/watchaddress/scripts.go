@@ -0,0 +1,171 @@
+package watchaddress
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+)
+
+// maxScriptSize is the largest scriptPubKey consensus lets be spent.
+const maxScriptSize = 10000
+
+var ErrScriptsDisabled = errors.New("script watching is not enabled")
+
+// ScriptEntry is a scriptPubKey to watch, hex-encoded, with a label that
+// transfers paying it carry as their "script_label" metadata.
+type ScriptEntry struct {
+	Script string `json:"script"`
+	Label  string `json:"label,omitempty"`
+}
+
+// scriptWatch holds the watched scripts: rows in repo, labels in set.
+type scriptWatch struct {
+	repo repository.Repository[model.WatchedScript]
+	set  *addressbloomfilter.ScriptSet
+}
+
+// WatchScripts enables script watching, keeping scripts in repo and their
+// labels in set, and loads the scripts registered before into set and the
+// bloom filter's script namespace.
+func (s *Service) WatchScripts(
+	ctx context.Context,
+	repo repository.Repository[model.WatchedScript],
+	set *addressbloomfilter.ScriptSet,
+) error {
+	rows, err := repo.Find(ctx, repository.FindOptions{})
+	if err != nil {
+		return fmt.Errorf("load watched scripts: %w", err)
+	}
+	byType := make(map[enum.NetworkType]map[string]string)
+	for _, row := range rows {
+		if byType[row.Type] == nil {
+			byType[row.Type] = make(map[string]string)
+		}
+		byType[row.Type][row.ScriptID] = row.Label
+	}
+	s.scripts = &scriptWatch{repo: repo, set: set}
+	for networkType, labels := range byType {
+		s.addScripts(networkType, labels)
+	}
+	logger.Info("Loaded watched scripts", "count", len(rows))
+	return nil
+}
+
+// RegisterScripts validates scripts, inserts the new ones and starts
+// watching all valid ones, like RegisterAddresses. Results report each
+// script's ID as Normalized. A script registered again keeps its first
+// label.
+func (s *Service) RegisterScripts(
+	ctx context.Context,
+	networkType enum.NetworkType,
+	scripts []ScriptEntry,
+) ([]Result, error) {
+	if s.scripts == nil {
+		return nil, ErrScriptsDisabled
+	}
+	results := make([]Result, len(scripts))
+	labels := make(map[string]string, len(scripts))
+	firstIndex := make(map[string]int, len(scripts))
+	var ids []string
+	var rows []*model.WatchedScript
+
+	for i, entry := range scripts {
+		results[i].Address = entry.Script
+		script, err := normalizeScript(networkType, entry.Script)
+		if err != nil {
+			results[i].Status = StatusInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+		id := bitcoin.ScriptID(script)
+		results[i].Normalized = id
+		if _, dup := firstIndex[id]; dup {
+			results[i].Status = StatusExists
+			continue
+		}
+		firstIndex[id] = i
+		labels[id] = entry.Label
+		ids = append(ids, id)
+		rows = append(rows, &model.WatchedScript{ScriptID: id, Script: script, Type: networkType, Label: entry.Label})
+	}
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	existing, err := s.scripts.repo.Find(ctx, repository.FindOptions{
+		Select: repository.Select("script_id", "label"),
+		Where:  repository.WhereType{"type": networkType, "script_id": ids},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookup existing scripts: %w", err)
+	}
+	known := make(map[string]bool, len(existing))
+	for _, row := range existing {
+		known[row.ScriptID] = true
+		labels[row.ScriptID] = row.Label
+	}
+	var added []*model.WatchedScript
+	for _, row := range rows {
+		if known[row.ScriptID] {
+			results[firstIndex[row.ScriptID]].Status = StatusExists
+			continue
+		}
+		results[firstIndex[row.ScriptID]].Status = StatusAdded
+		added = append(added, row)
+	}
+
+	inserted, err := s.scripts.repo.CreateMany(ctx, added)
+	if err != nil {
+		return nil, fmt.Errorf("insert scripts: %w", err)
+	}
+	s.addScripts(networkType, labels)
+
+	logger.Info("Registered watch scripts",
+		"networkType", networkType,
+		"submitted", len(scripts),
+		"inserted", inserted,
+		"valid", len(ids),
+	)
+	return results, nil
+}
+
+// addScripts starts matching the scripts of networkType, label by ID.
+func (s *Service) addScripts(networkType enum.NetworkType, labels map[string]string) {
+	s.scripts.set.Watch(labels, networkType)
+	if s.bloom == nil {
+		return
+	}
+	ids := make([]string, 0, len(labels))
+	for id := range labels {
+		ids = append(ids, id)
+	}
+	s.bloom.AddBatch(ids, addressbloomfilter.ScriptNamespace(networkType))
+}
+
+// normalizeScript returns script as lowercase hex, checking it is a script
+// networkType's outputs can carry.
+func normalizeScript(networkType enum.NetworkType, script string) (string, error) {
+	if networkType != enum.NetworkTypeBtc {
+		return "", fmt.Errorf("scripts cannot be watched on %s", networkType)
+	}
+	script = strings.ToLower(strings.TrimSpace(script))
+	raw, err := hex.DecodeString(script)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("script is not hex: %w", err)
+	case len(raw) == 0:
+		return "", errors.New("script is empty")
+	case len(raw) > maxScriptSize:
+		return "", fmt.Errorf("script exceeds %d bytes", maxScriptSize)
+	}
+	return script, nil
+}
@@ -0,0 +1,88 @@
+package watchaddress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bareMultisig is a 1-of-1 bare multisig scriptPubKey.
+const bareMultisig = "512102aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa51ae"
+
+type fakeScriptRepo struct {
+	rows map[string]*model.WatchedScript
+}
+
+func (r *fakeScriptRepo) Find(_ context.Context, opts repository.FindOptions) ([]*model.WatchedScript, error) {
+	var out []*model.WatchedScript
+	ids, filtered := opts.Where["script_id"].([]string)
+	for _, row := range r.rows {
+		if !filtered {
+			out = append(out, row)
+			continue
+		}
+		for _, id := range ids {
+			if row.ScriptID == id && row.Type == opts.Where["type"] {
+				out = append(out, row)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeScriptRepo) CreateMany(_ context.Context, rows []*model.WatchedScript) (int64, error) {
+	var n int64
+	for _, row := range rows {
+		if _, ok := r.rows[row.ScriptID]; !ok {
+			r.rows[row.ScriptID] = row
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestRegisterScripts(t *testing.T) {
+	bloom := &fakeBloom{added: map[enum.NetworkType][]string{}}
+	svc := NewService(&fakeRepo{rows: map[string]enum.NetworkType{}}, bloom)
+	_, err := svc.RegisterScripts(context.Background(), enum.NetworkTypeBtc, []ScriptEntry{{Script: bareMultisig}})
+	require.ErrorIs(t, err, ErrScriptsDisabled)
+
+	repo := &fakeScriptRepo{rows: map[string]*model.WatchedScript{}}
+	scripts := addressbloomfilter.NewScriptSet()
+	require.NoError(t, svc.WatchScripts(context.Background(), repo, scripts))
+
+	results, err := svc.RegisterScripts(context.Background(), enum.NetworkTypeBtc, []ScriptEntry{
+		{Script: "  " + bareMultisig[:10] + "AAAA" + bareMultisig[14:], Label: "escrow"}, // normalized to lowercase
+		{Script: "zz"},
+		{Script: bareMultisig, Label: "duplicate"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	id := bitcoin.ScriptID(bareMultisig)
+	assert.Equal(t, StatusAdded, results[0].Status)
+	assert.Equal(t, id, results[0].Normalized)
+	assert.Equal(t, StatusInvalid, results[1].Status)
+	assert.Equal(t, StatusExists, results[2].Status)
+
+	assert.Equal(t, bareMultisig, repo.rows[id].Script)
+	assert.Equal(t, []string{id}, bloom.added[addressbloomfilter.ScriptNamespace(enum.NetworkTypeBtc)])
+	label, ok := scripts.Label(id, enum.NetworkTypeBtc)
+	assert.True(t, ok)
+	assert.Equal(t, "escrow", label)
+
+	results, err = svc.RegisterScripts(context.Background(), enum.NetworkTypeEVM, []ScriptEntry{{Script: bareMultisig}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusInvalid, results[0].Status, "only Bitcoin outputs are matched by script")
+
+	// A restart loads the registered scripts again.
+	reloaded := addressbloomfilter.NewScriptSet()
+	require.NoError(t, NewService(&fakeRepo{}, nil).WatchScripts(context.Background(), repo, reloaded))
+	assert.Equal(t, 1, reloaded.Count(enum.NetworkTypeBtc))
+}
@@ -0,0 +1,168 @@
+package watchaddress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+)
+
+// Status is the per-address outcome of RegisterAddresses.
+type Status string
+
+const (
+	StatusAdded   Status = "added"
+	StatusExists  Status = "exists"
+	StatusInvalid Status = "invalid"
+)
+
+// Result reports what happened to one submitted address.
+type Result struct {
+	Address    string `json:"address"`
+	Normalized string `json:"normalized,omitempty"`
+	Display    string `json:"display,omitempty"` // see addressutil.Display
+	Type       string `json:"type,omitempty"`    // see addressutil.Validation
+	Status     Status `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Service registers watch addresses in the database and the bloom filter
+// in one step, so matching starts without waiting for the next bloom sync.
+type Service struct {
+	repo    repository.Repository[model.WalletAddress]
+	bloom   addressbloomfilter.WalletAddressBloomFilter
+	xpubs   *xpubTracker                   // nil until TrackXpubs
+	scripts *scriptWatch                   // nil until WatchScripts
+	redeem  *redeemScripts                 // nil until TrackRedeemScripts
+	retired *addressbloomfilter.RetiredSet // nil until TrackRetired
+
+	hrpMu sync.RWMutex
+	hrps  []string // see SetChains
+}
+
+func NewService(
+	repo repository.Repository[model.WalletAddress],
+	bloom addressbloomfilter.WalletAddressBloomFilter,
+) *Service {
+	return &Service{repo: repo, bloom: bloom}
+}
+
+// SetChains sets the segwit HRPs Bitcoin addresses are accepted with
+// besides Bitcoin's: those of every chain in chains, which all watch the
+// addresses registered, see config.Chains.SegwitHRPs. Call it with the
+// config loaded, and again with each reloaded one.
+func (s *Service) SetChains(chains config.Chains) {
+	hrps := chains.SegwitHRPs()
+	s.hrpMu.Lock()
+	defer s.hrpMu.Unlock()
+	s.hrps = hrps
+}
+
+func (s *Service) segwitHRPs() []string {
+	s.hrpMu.RLock()
+	defer s.hrpMu.RUnlock()
+	return s.hrps
+}
+
+// TrackRetired drops addresses registered again from retired, so they are
+// matched again without waiting for the next bloom sync.
+func (s *Service) TrackRetired(retired *addressbloomfilter.RetiredSet) {
+	s.retired = retired
+}
+
+// RegisterAddresses validates and normalizes addresses with
+// addressutil.ValidateAll, inserts the new ones and adds all valid ones to
+// the bloom filter. Invalid addresses are reported per entry;
+// re-registering an address is a no-op reported as "exists". A retired,
+// i.e. soft-deleted, address is restored when the repository is a
+// repository.Restorer, and reported as "added".
+// An error is returned only when the database write fails.
+func (s *Service) RegisterAddresses(
+	ctx context.Context,
+	networkType enum.NetworkType,
+	addresses []string,
+) ([]Result, error) {
+	results := make([]Result, len(addresses))
+	var valid []string
+	firstIndex := make(map[string]int, len(addresses))
+
+	for i, v := range addressutil.ValidateAll(networkType, addresses, s.segwitHRPs()...) {
+		results[i].Address = v.Address
+		if !v.Valid {
+			results[i].Status = StatusInvalid
+			results[i].Error = v.Error
+			continue
+		}
+		normalized := v.Normalized
+		results[i].Normalized, results[i].Display, results[i].Type = normalized, v.Display, v.Type
+		if _, dup := firstIndex[normalized]; dup {
+			results[i].Status = StatusExists
+			continue
+		}
+		firstIndex[normalized] = i
+		valid = append(valid, normalized)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	existing, err := s.repo.Find(ctx, repository.FindOptions{
+		Select: repository.Select("address"),
+		Where:  repository.WhereType{"type": networkType, "address": valid},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookup existing addresses: %w", err)
+	}
+	known := make(map[string]bool, len(existing))
+	for _, row := range existing {
+		known[row.Address] = true
+	}
+
+	var rows []*model.WalletAddress
+	for _, addr := range valid {
+		if known[addr] {
+			results[firstIndex[addr]].Status = StatusExists
+			continue
+		}
+		results[firstIndex[addr]].Status = StatusAdded
+		rows = append(rows, &model.WalletAddress{Address: addr, Type: networkType})
+	}
+
+	inserted, err := s.repo.CreateMany(ctx, rows)
+	if err != nil {
+		return nil, fmt.Errorf("insert addresses: %w", err)
+	}
+	var restored int64
+	if restorer, ok := s.repo.(repository.Restorer); ok && inserted < int64(len(rows)) {
+		addrs := make([]string, len(rows))
+		for i, row := range rows {
+			addrs[i] = row.Address
+		}
+		restored, err = restorer.Restore(ctx, repository.FindOptions{
+			Where: repository.WhereType{"type": networkType, "address": addrs},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("restore retired addresses: %w", err)
+		}
+	}
+	if s.bloom != nil {
+		s.bloom.AddBatch(valid, networkType)
+	}
+	s.retired.Reinstate(valid, networkType)
+
+	logger.Info("Registered watch addresses",
+		"networkType", networkType,
+		"submitted", len(addresses),
+		"inserted", inserted,
+		"restored", restored,
+		"valid", len(valid),
+	)
+	return results, nil
+}
@@ -0,0 +1,145 @@
+package watchaddress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const evmAddr = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+
+type fakeRepo struct {
+	rows map[string]enum.NetworkType
+}
+
+func (r *fakeRepo) Find(_ context.Context, opts repository.FindOptions) ([]*model.WalletAddress, error) {
+	var out []*model.WalletAddress
+	for _, addr := range opts.Where["address"].([]string) {
+		if nt, ok := r.rows[addr]; ok && nt == opts.Where["type"] {
+			out = append(out, &model.WalletAddress{Address: addr, Type: nt})
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) CreateMany(_ context.Context, rows []*model.WalletAddress) (int64, error) {
+	var n int64
+	for _, row := range rows {
+		if _, ok := r.rows[row.Address]; !ok {
+			r.rows[row.Address] = row.Type
+			n++
+		}
+	}
+	return n, nil
+}
+
+// restoringRepo is a fakeRepo whose deleted rows are soft-deleted: they are
+// left out of Find and block inserting them again until restored.
+type restoringRepo struct {
+	fakeRepo
+	deleted map[string]bool
+}
+
+func (r *restoringRepo) Find(ctx context.Context, opts repository.FindOptions) ([]*model.WalletAddress, error) {
+	rows, err := r.fakeRepo.Find(ctx, opts)
+	live := rows[:0]
+	for _, row := range rows {
+		if !r.deleted[row.Address] {
+			live = append(live, row)
+		}
+	}
+	return live, err
+}
+
+func (r *restoringRepo) Restore(_ context.Context, opts repository.FindOptions) (int64, error) {
+	var n int64
+	for _, addr := range opts.Where["address"].([]string) {
+		if r.deleted[addr] && r.rows[addr] == opts.Where["type"] {
+			delete(r.deleted, addr)
+			n++
+		}
+	}
+	return n, nil
+}
+
+type fakeBloom struct {
+	added map[enum.NetworkType][]string
+}
+
+func (b *fakeBloom) Initialize(context.Context) error { return nil }
+func (b *fakeBloom) Add(addr string, nt enum.NetworkType) {
+	b.AddBatch([]string{addr}, nt)
+}
+func (b *fakeBloom) AddBatch(addrs []string, nt enum.NetworkType) {
+	b.added[nt] = append(b.added[nt], addrs...)
+}
+func (b *fakeBloom) Contains(addr string, nt enum.NetworkType) bool {
+	for _, a := range b.added[nt] {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+func (b *fakeBloom) Ready() <-chan struct{} {
+	ready := make(chan struct{})
+	close(ready)
+	return ready
+}
+func (b *fakeBloom) IsReady() bool                         { return true }
+func (b *fakeBloom) LoadError() error                      { return nil }
+func (b *fakeBloom) Clear(enum.NetworkType)                {}
+func (b *fakeBloom) Stats(enum.NetworkType) map[string]any { return nil }
+
+func TestRegisterAddresses(t *testing.T) {
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	bloom := &fakeBloom{added: map[enum.NetworkType][]string{}}
+	svc := NewService(repo, bloom)
+
+	results, err := svc.RegisterAddresses(context.Background(), enum.NetworkTypeEVM, []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", // checksum, normalized to lowercase
+		"not-an-address",
+		evmAddr, // duplicate within the batch
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, StatusAdded, results[0].Status)
+	assert.Equal(t, evmAddr, results[0].Normalized)
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", results[0].Display)
+	assert.Equal(t, StatusInvalid, results[1].Status)
+	assert.NotEmpty(t, results[1].Error)
+	assert.Equal(t, StatusExists, results[2].Status)
+
+	assert.Contains(t, repo.rows, evmAddr)
+	assert.True(t, bloom.Contains(evmAddr, enum.NetworkTypeEVM), "bloom filter updated in the same call")
+
+	// Re-registering is idempotent.
+	results, err = svc.RegisterAddresses(context.Background(), enum.NetworkTypeEVM, []string{evmAddr})
+	require.NoError(t, err)
+	assert.Equal(t, StatusExists, results[0].Status)
+	assert.Len(t, repo.rows, 1)
+}
+
+func TestRegisterAddresses_ReinstatesRetired(t *testing.T) {
+	repo := &restoringRepo{
+		fakeRepo: fakeRepo{rows: map[string]enum.NetworkType{evmAddr: enum.NetworkTypeEVM}},
+		deleted:  map[string]bool{evmAddr: true},
+	}
+	retired := addressbloomfilter.NewRetiredSet()
+	retired.Retire([]string{evmAddr}, enum.NetworkTypeEVM)
+	svc := NewService(repo, &fakeBloom{added: map[enum.NetworkType][]string{}})
+	svc.TrackRetired(retired)
+
+	results, err := svc.RegisterAddresses(context.Background(), enum.NetworkTypeEVM, []string{evmAddr})
+	require.NoError(t, err)
+	assert.Equal(t, StatusAdded, results[0].Status)
+	assert.Empty(t, repo.deleted, "the soft-deleted row is restored")
+	assert.False(t, retired.Contains(evmAddr, enum.NetworkTypeEVM))
+}
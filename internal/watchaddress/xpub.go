@@ -0,0 +1,337 @@
+package watchaddress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+)
+
+// DefaultGapLimit is how many unused addresses are kept derived past the
+// last used one on each branch, the gap limit BIP-44 wallets scan with.
+const DefaultGapLimit = 20
+
+// MaxGapLimit caps the gap limit of an imported key.
+const MaxGapLimit = MaxBatchSize
+
+var derivationSchemes = []bitcoin.DerivationScheme{
+	bitcoin.SchemeBIP44, bitcoin.SchemeBIP49, bitcoin.SchemeBIP84, bitcoin.SchemeBIP86,
+}
+
+// XpubStateKey is the KV key holding every imported key's XpubState.
+const XpubStateKey = "watch_xpubs"
+
+var (
+	ErrXpubsDisabled = errors.New("xpub import is not enabled")
+
+	errInvalidXpubRequest = errors.New("invalid xpub request")
+)
+
+// XpubRequest imports an account-level extended public key.
+type XpubRequest struct {
+	Xpub string `json:"xpub"`
+	// Scheme defaults to the one implied by the key's version prefix.
+	Scheme bitcoin.DerivationScheme `json:"scheme,omitempty"`
+	// Network defaults to mainnet, or testnet3 for testnet keys; the
+	// testnets and signet share their addresses.
+	Network bitcoin.Network `json:"network,omitempty"`
+	// GapLimit defaults to the lookahead given to TrackXpubs.
+	GapLimit uint32 `json:"gap_limit,omitempty"`
+}
+
+// XpubState is the derivation state of an imported key: on each branch,
+// external then change, how many addresses were derived and the index of
+// the last one seen used, -1 if none. Derived is kept GapLimit past
+// LastUsed, so the addresses between them are the unused gap.
+type XpubState struct {
+	Xpub     string                   `json:"xpub"`
+	Scheme   bitcoin.DerivationScheme `json:"scheme"`
+	Network  bitcoin.Network          `json:"network"`
+	GapLimit uint32                   `json:"gap_limit"`
+	Derived  [2]uint32                `json:"derived"`
+	LastUsed [2]int64                 `json:"last_used"`
+}
+
+// gapAddress locates a derived address still in its key's unused gap.
+type gapAddress struct {
+	state  *XpubState
+	branch uint32
+	index  uint32
+}
+
+// xpubTracker holds the imported keys and the addresses of their gaps, the
+// only ones whose use moves a gap forward.
+type xpubTracker struct {
+	mu        sync.Mutex
+	kv        infra.KVStore
+	lookahead uint32
+	states    []*XpubState
+	keys      map[*XpubState]*bitcoin.ExtendedPublicKey
+	gap       map[string]gapAddress
+}
+
+// TrackXpubs enables xpub import, keeping key state in kv, and loads the
+// keys imported before, registering their gaps again in case a crash cut a
+// registration short, see commit. lookahead is the gap limit of imports
+// that set none; 0 means DefaultGapLimit.
+func (s *Service) TrackXpubs(ctx context.Context, kv infra.KVStore, lookahead uint32) error {
+	if lookahead == 0 {
+		lookahead = DefaultGapLimit
+	}
+	t := &xpubTracker{
+		kv:        kv,
+		lookahead: min(lookahead, MaxGapLimit),
+		keys:      make(map[*XpubState]*bitcoin.ExtendedPublicKey),
+		gap:       make(map[string]gapAddress),
+	}
+	if _, err := kv.GetAny(XpubStateKey, &t.states); err != nil {
+		return fmt.Errorf("load xpub state: %w", err)
+	}
+	var addrs []string
+	for _, state := range t.states {
+		key, err := bitcoin.ParseExtendedPublicKey(state.Xpub)
+		if err != nil {
+			return fmt.Errorf("load xpub state: %w", err)
+		}
+		t.keys[state] = key
+		for _, branch := range []uint32{bitcoin.BranchExternal, bitcoin.BranchChange} {
+			start := uint32(state.LastUsed[branch] + 1)
+			derived, err := t.derive(state, branch, start, state.Derived[branch]-start)
+			if err != nil {
+				return fmt.Errorf("load xpub state: %w", err)
+			}
+			addrs = append(addrs, derived...)
+		}
+	}
+	if len(addrs) > 0 {
+		if _, err := s.RegisterAddresses(ctx, enum.NetworkTypeBtc, addrs); err != nil {
+			return fmt.Errorf("register xpub gaps: %w", err)
+		}
+	}
+	s.xpubs = t
+	logger.Info("Loaded watched xpubs", "count", len(t.states))
+	return nil
+}
+
+// RegisterXpub imports an extended public key: it derives the first
+// GapLimit addresses of both branches and registers them like
+// RegisterAddresses. Importing a key again registers nothing new unless
+// the gap limit grows.
+func (s *Service) RegisterXpub(ctx context.Context, req XpubRequest) (*XpubState, []Result, error) {
+	if s.xpubs == nil {
+		return nil, nil, ErrXpubsDisabled
+	}
+	key, err := bitcoin.ParseExtendedPublicKey(req.Xpub)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Scheme == "" {
+		req.Scheme = key.Scheme
+	}
+	if req.Network == "" {
+		req.Network = bitcoin.NetworkMainnet
+		if key.Testnet {
+			req.Network = bitcoin.NetworkTestnet3
+		}
+	}
+	if req.GapLimit == 0 {
+		req.GapLimit = s.xpubs.lookahead
+	}
+	if req.GapLimit > MaxGapLimit {
+		return nil, nil, fmt.Errorf("%w: gap limit %d exceeds %d", errInvalidXpubRequest, req.GapLimit, MaxGapLimit)
+	}
+	if _, err := bitcoin.ParamsFor(req.Network); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errInvalidXpubRequest, err)
+	}
+	if key.Testnet != (req.Network != bitcoin.NetworkMainnet) {
+		return nil, nil, fmt.Errorf("%w: key is not for %s", errInvalidXpubRequest, req.Network)
+	}
+	if !slices.Contains(derivationSchemes, req.Scheme) {
+		return nil, nil, fmt.Errorf("%w: unknown scheme %q", errInvalidXpubRequest, req.Scheme)
+	}
+
+	t := s.xpubs
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := t.snapshot()
+	state := t.find(req)
+	if state == nil {
+		state = &XpubState{
+			Xpub:     req.Xpub,
+			Scheme:   req.Scheme,
+			Network:  req.Network,
+			LastUsed: [2]int64{-1, -1},
+		}
+		t.keys[state] = key
+		t.states = append(t.states, state)
+	}
+	state.GapLimit = max(state.GapLimit, req.GapLimit)
+
+	var addrs []string
+	for _, branch := range []uint32{bitcoin.BranchExternal, bitcoin.BranchChange} {
+		derived, err := t.extend(state, branch)
+		if err != nil {
+			t.restore(snapshot)
+			return nil, nil, err
+		}
+		addrs = append(addrs, derived...)
+	}
+	results, err := t.commit(ctx, s, snapshot, addrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	copied := *state
+	return &copied, results, nil
+}
+
+// ObserveUsed marks addresses seen in transfers as used. When one is in an
+// imported key's gap, the gap moves past it: the addresses needed to keep
+// GapLimit unused ones are derived and registered.
+func (s *Service) ObserveUsed(ctx context.Context, addresses ...string) error {
+	if s.xpubs == nil {
+		return nil
+	}
+	t := s.xpubs
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := t.snapshot()
+	var addrs []string
+	for _, addr := range addresses {
+		at, ok := t.gap[addr]
+		if !ok || int64(at.index) <= at.state.LastUsed[at.branch] {
+			continue
+		}
+		at.state.LastUsed[at.branch] = int64(at.index)
+		derived, err := t.extend(at.state, at.branch)
+		if err != nil {
+			t.restore(snapshot)
+			return err
+		}
+		addrs = append(addrs, derived...)
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+	_, err := t.commit(ctx, s, snapshot, addrs)
+	return err
+}
+
+// find returns the state imported for req's key, scheme and network.
+func (t *xpubTracker) find(req XpubRequest) *XpubState {
+	for _, state := range t.states {
+		if state.Xpub == req.Xpub && state.Scheme == req.Scheme && state.Network == req.Network {
+			return state
+		}
+	}
+	return nil
+}
+
+// extend derives the addresses needed for GapLimit unused ones past
+// LastUsed on branch.
+func (t *xpubTracker) extend(state *XpubState, branch uint32) ([]string, error) {
+	want := uint32(state.LastUsed[branch]+1) + state.GapLimit
+	if want <= state.Derived[branch] {
+		return nil, nil
+	}
+	addrs, err := t.derive(state, branch, state.Derived[branch], want-state.Derived[branch])
+	if err != nil {
+		return nil, err
+	}
+	state.Derived[branch] = want
+	return addrs, nil
+}
+
+// derive derives count addresses of branch from start and adds them to the
+// gap.
+func (t *xpubTracker) derive(state *XpubState, branch, start, count uint32) ([]string, error) {
+	params, err := bitcoin.ParamsFor(state.Network)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := bitcoin.DeriveAddresses(t.keys[state], state.Scheme, params, branch, start, count)
+	if err != nil {
+		return nil, err
+	}
+	for i, addr := range addrs {
+		t.gap[addr] = gapAddress{state: state, branch: branch, index: start + uint32(i)}
+	}
+	return addrs, nil
+}
+
+// prune drops the addresses no longer in their key's gap.
+func (t *xpubTracker) prune() {
+	for addr, at := range t.gap {
+		if int64(at.index) <= at.state.LastUsed[at.branch] {
+			delete(t.gap, addr)
+		}
+	}
+}
+
+// commit saves the cursors, then registers the addresses derived since
+// snapshot. Saving first means a crash in between leaves addresses derived
+// but not registered, which TrackXpubs registers on load, rather than
+// registered but past the saved cursors and never extended from. On
+// failure the cursors are restored, so the same addresses are derived and
+// registered on the next attempt.
+func (t *xpubTracker) commit(ctx context.Context, s *Service, snap xpubSnapshot, addrs []string) ([]Result, error) {
+	if err := t.save(); err != nil {
+		t.restore(snap)
+		return nil, err
+	}
+	results, err := s.RegisterAddresses(ctx, enum.NetworkTypeBtc, addrs)
+	if err != nil {
+		t.restore(snap)
+		return nil, err
+	}
+	t.prune()
+	return results, nil
+}
+
+// xpubSnapshot is the tracker's key states at some point.
+type xpubSnapshot struct {
+	states []*XpubState
+	values []XpubState
+}
+
+func (t *xpubTracker) snapshot() xpubSnapshot {
+	snap := xpubSnapshot{states: t.states, values: make([]XpubState, len(t.states))}
+	for i, state := range t.states {
+		snap.values[i] = *state
+	}
+	return snap
+}
+
+// restore resets the states to snap and forgets keys imported since. Gap
+// entries derived since for the other keys are left: they are only ever
+// derived again identically.
+func (t *xpubTracker) restore(snap xpubSnapshot) {
+	t.states = snap.states
+	for i, state := range snap.states {
+		*state = snap.values[i]
+	}
+	for state := range t.keys {
+		if !slices.Contains(t.states, state) {
+			delete(t.keys, state)
+		}
+	}
+	for addr, at := range t.gap {
+		if t.keys[at.state] == nil {
+			delete(t.gap, addr)
+		}
+	}
+}
+
+func (t *xpubTracker) save() error {
+	if err := t.kv.SetAny(XpubStateKey, t.states); err != nil {
+		return fmt.Errorf("save xpub state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package watchaddress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/events"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// BIP-84 test vector account key, for the mnemonic "abandon ... about".
+const testZpub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+
+type memKV struct {
+	infra.KVStore
+	values map[string][]byte
+}
+
+func (kv *memKV) SetAny(k string, v any) error {
+	data, err := json.Marshal(v)
+	kv.values[k] = data
+	return err
+}
+
+func (kv *memKV) GetAny(k string, v any) (bool, error) {
+	data, ok := kv.values[k]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func newXpubService(t *testing.T, kv *memKV, repo *fakeRepo) *Service {
+	t.Helper()
+	svc := NewService(repo, &fakeBloom{added: map[enum.NetworkType][]string{}})
+	require.NoError(t, svc.TrackXpubs(context.Background(), kv, 0))
+	return svc
+}
+
+func zpubAddresses(t *testing.T, branch, start, count uint32) []string {
+	t.Helper()
+	key, err := bitcoin.ParseExtendedPublicKey(testZpub)
+	require.NoError(t, err)
+	params, _ := bitcoin.ParamsFor(bitcoin.NetworkMainnet)
+	addrs, err := bitcoin.DeriveAddresses(key, bitcoin.SchemeBIP84, params, branch, start, count)
+	require.NoError(t, err)
+	return addrs
+}
+
+func TestRegisterXpub_DerivesGapOfBothBranches(t *testing.T) {
+	kv := &memKV{values: map[string][]byte{}}
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	svc := newXpubService(t, kv, repo)
+
+	state, results, err := svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub, GapLimit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.SchemeBIP84, state.Scheme)
+	assert.Equal(t, bitcoin.NetworkMainnet, state.Network)
+	assert.Equal(t, [2]uint32{3, 3}, state.Derived)
+	assert.Equal(t, [2]int64{-1, -1}, state.LastUsed)
+
+	require.Len(t, results, 6)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", results[0].Normalized)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", results[3].Normalized)
+	for _, res := range results {
+		assert.Equal(t, StatusAdded, res.Status)
+		assert.Equal(t, enum.NetworkTypeBtc, repo.rows[res.Normalized])
+	}
+
+	// Importing again derives nothing new.
+	_, results, err = svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub, GapLimit: 3})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Len(t, repo.rows, 6)
+}
+
+func TestObserveUsed_MovesGap(t *testing.T) {
+	kv := &memKV{values: map[string][]byte{}}
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	svc := newXpubService(t, kv, repo)
+	_, _, err := svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub, GapLimit: 3})
+	require.NoError(t, err)
+
+	receive := zpubAddresses(t, bitcoin.BranchExternal, 0, 8)
+	require.NoError(t, svc.ObserveUsed(context.Background(), receive[1], "bc1qunrelated"))
+	assert.Len(t, repo.rows, 8, "receive branch extended to index 4")
+	assert.Contains(t, repo.rows, receive[4])
+
+	// An address before the last used one changes nothing.
+	require.NoError(t, svc.ObserveUsed(context.Background(), receive[0]))
+	assert.Len(t, repo.rows, 8)
+
+	// The state survives a restart, gap included.
+	svc = newXpubService(t, kv, repo)
+	require.NoError(t, svc.ObserveUsed(context.Background(), receive[4]))
+	assert.Contains(t, repo.rows, receive[7])
+	assert.Len(t, repo.rows, 11)
+
+	var states []*XpubState
+	_, err = kv.GetAny(XpubStateKey, &states)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, [2]uint32{8, 3}, states[0].Derived)
+	assert.Equal(t, [2]int64{4, -1}, states[0].LastUsed)
+}
+
+func TestRegisterXpub_Rejects(t *testing.T) {
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	_, _, err := NewService(repo, nil).RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub})
+	assert.ErrorIs(t, err, ErrXpubsDisabled)
+
+	svc := newXpubService(t, &memKV{values: map[string][]byte{}}, repo)
+	cases := []XpubRequest{
+		{Xpub: testZpub, Network: bitcoin.NetworkTestnet4},
+		{Xpub: testZpub, Scheme: "bip32"},
+		{Xpub: testZpub, GapLimit: MaxGapLimit + 1},
+		{Xpub: "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+	}
+	for _, req := range cases {
+		_, _, err := svc.RegisterXpub(context.Background(), req)
+		assert.Error(t, err, "%+v", req)
+	}
+	assert.Empty(t, repo.rows)
+}
+
+type recordingEmitter struct {
+	events.Emitter
+	txs []*types.Transaction
+}
+
+func (e *recordingEmitter) EmitTransaction(_ string, tx *types.Transaction) error {
+	e.txs = append(e.txs, tx)
+	return nil
+}
+
+func TestObservingEmitter(t *testing.T) {
+	inner := &recordingEmitter{}
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	assert.Same(t, inner, ObservingEmitter(inner, NewService(repo, nil)), "unwrapped without xpubs")
+
+	svc := newXpubService(t, &memKV{values: map[string][]byte{}}, repo)
+	_, _, err := svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub, GapLimit: 2})
+	require.NoError(t, err)
+
+	change := zpubAddresses(t, bitcoin.BranchChange, 0, 3)
+	emitter := ObservingEmitter(inner, svc)
+	require.NoError(t, emitter.EmitTransaction("btc", &types.Transaction{ToAddress: change[0]}))
+	assert.Len(t, inner.txs, 1)
+	assert.Contains(t, repo.rows, change[2])
+}
+
+func TestObserveUsed_ConcurrentMatchesAreIdempotent(t *testing.T) {
+	kv := &memKV{values: map[string][]byte{}}
+	repo := &lockedRepo{fakeRepo: fakeRepo{rows: map[string]enum.NetworkType{}}}
+	svc := NewService(repo, nil)
+	require.NoError(t, svc.TrackXpubs(context.Background(), kv, 5))
+	_, _, err := svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub})
+	require.NoError(t, err)
+
+	receive := zpubAddresses(t, bitcoin.BranchExternal, 0, 5)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, svc.ObserveUsed(context.Background(), receive[i%len(receive)]))
+		}()
+	}
+	wg.Wait()
+
+	// Whatever the order, the highest used index is 4 and the lookahead of
+	// 5 runs to index 9.
+	var states []*XpubState
+	_, err = kv.GetAny(XpubStateKey, &states)
+	require.NoError(t, err)
+	assert.Equal(t, [2]uint32{10, 5}, states[0].Derived)
+	assert.Equal(t, [2]int64{4, -1}, states[0].LastUsed)
+	assert.Len(t, repo.rows, 15)
+}
+
+func TestObserveUsed_FailedRegistrationIsRetried(t *testing.T) {
+	kv := &memKV{values: map[string][]byte{}}
+	repo := &lockedRepo{fakeRepo: fakeRepo{rows: map[string]enum.NetworkType{}}}
+	svc := NewService(repo, nil)
+	require.NoError(t, svc.TrackXpubs(context.Background(), kv, 2))
+	_, _, err := svc.RegisterXpub(context.Background(), XpubRequest{Xpub: testZpub})
+	require.NoError(t, err)
+
+	receive := zpubAddresses(t, bitcoin.BranchExternal, 0, 4)
+	repo.fail = errors.New("db down")
+	assert.Error(t, svc.ObserveUsed(context.Background(), receive[1]))
+	assert.NotContains(t, repo.rows, receive[3])
+
+	repo.fail = nil
+	require.NoError(t, svc.ObserveUsed(context.Background(), receive[1]))
+	assert.Contains(t, repo.rows, receive[3])
+}
+
+func TestTrackXpubs_RegistersSavedGap(t *testing.T) {
+	// A crash after saving the cursors but before registering leaves the
+	// gap unregistered; loading registers it.
+	kv := &memKV{values: map[string][]byte{}}
+	require.NoError(t, kv.SetAny(XpubStateKey, []*XpubState{{
+		Xpub: testZpub, Scheme: bitcoin.SchemeBIP84, Network: bitcoin.NetworkMainnet,
+		GapLimit: 2, Derived: [2]uint32{4, 2}, LastUsed: [2]int64{1, -1},
+	}}))
+	repo := &fakeRepo{rows: map[string]enum.NetworkType{}}
+	newXpubService(t, kv, repo)
+
+	receive := zpubAddresses(t, bitcoin.BranchExternal, 0, 4)
+	assert.Len(t, repo.rows, 4)
+	assert.Contains(t, repo.rows, receive[2])
+	assert.Contains(t, repo.rows, receive[3])
+}
+
+// lockedRepo is a fakeRepo safe for concurrent use that can be made to fail.
+type lockedRepo struct {
+	mu sync.Mutex
+	fakeRepo
+	fail error
+}
+
+func (r *lockedRepo) Find(ctx context.Context, opts repository.FindOptions) ([]*model.WalletAddress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fakeRepo.Find(ctx, opts)
+}
+
+func (r *lockedRepo) CreateMany(ctx context.Context, rows []*model.WalletAddress) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return 0, r.fail
+	}
+	return r.fakeRepo.CreateMany(ctx, rows)
+}
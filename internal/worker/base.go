@@ -2,20 +2,29 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"log/slog"
 
 	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/amount"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/events"
 	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
 	"github.com/fystack/multichain-indexer/pkg/retry"
+	"github.com/fystack/multichain-indexer/pkg/sink"
 	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
 	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BlockStatus represents the outcome of processing a single block.
@@ -37,6 +46,9 @@ type BaseWorker struct {
 	cancel context.CancelFunc
 	mode   WorkerMode
 	logger *slog.Logger
+	// blockLog picks the level of the per-block success log, so busy
+	// chains can log only every Nth block at info.
+	blockLog *logger.Sampler
 
 	config      config.ChainConfig
 	chain       indexer.Indexer
@@ -46,14 +58,67 @@ type BaseWorker struct {
 	emitter     events.Emitter
 	failedChan  chan FailedBlockEvent
 	observer    BlockResultObserver
+	poll        *pollSchedule
+	progress    *progress
+	// emitted records emitted transfers for reorg events; set for
+	// regular workers only.
+	emitted *emittedTransfers
+	// sink, if set, persists the transfers emitted for each block.
+	sink sink.Sink
+	// amounts formats the amounts of emitted transfers; nil formats them
+	// as amount.FormatLegacy.
+	amounts *amount.Formatter
+	// supply, if set, records the supply of each processed block.
+	supply supplyTracker
+	// emitGuard, if set, suppresses transfer events already emitted.
+	emitGuard *emitGuard
+	// heads, if set, starts the worker's job on each new chain head in
+	// place of its poll schedule; set for regular workers only.
+	heads indexer.HeadSource
+	// backlog, if set, reports after a job run whether it left blocks below
+	// the last head, to run it again at once rather than on the next head.
+	backlog func() bool
+	// loops tracks the worker's goroutines, see spawn, for Stop to wait on.
+	loops sync.WaitGroup
 }
 
-// Stop stops the worker and cleans up internal resources
+// supplyTracker records the supply of processed blocks; see supply.Tracker.
+type supplyTracker interface {
+	Record(ctx context.Context, block *types.Block) error
+	Rollback(ctx context.Context, from uint64) error
+}
+
+// Stop stops the worker and cleans up internal resources. It returns once
+// the worker's goroutines exited, so a worker built again for the chain
+// does not run alongside them.
 func (bw *BaseWorker) Stop() {
 	bw.cancel()
+	bw.loops.Wait()
 	bw.logger.Info("Worker stopped", "chain", bw.chain.GetName())
 }
 
+// spawn runs fn in a goroutine Stop waits for.
+func (bw *BaseWorker) spawn(fn func()) {
+	bw.loops.Add(1)
+	go func() {
+		defer bw.loops.Done()
+		fn()
+	}()
+}
+
+// reloadChainConfig applies the settings of a reloaded chain config that
+// take effect on a running worker: the indexer's asset filter and segwit
+// HRP. Workers of a chain share its indexer, so it is applied once per
+// worker.
+func (bw *BaseWorker) reloadChainConfig(cfg config.ChainConfig) {
+	if updater, ok := bw.chain.(indexer.AssetFilterUpdater); ok {
+		updater.UpdateAssetFilter(cfg.Assets)
+	}
+	if updater, ok := bw.chain.(indexer.SegwitHRPUpdater); ok {
+		updater.UpdateSegwitHRPs(cfg)
+	}
+}
+
 // newWorkerWithMode constructs a BaseWorker with the given mode and logger.
 func newWorkerWithMode(
 	ctx context.Context,
@@ -67,9 +132,15 @@ func newWorkerWithMode(
 	failedChan chan FailedBlockEvent,
 ) *BaseWorker {
 	ctx, cancel := context.WithCancel(ctx)
-	log := logger.With(
+	if mode.optional() {
+		ctx = ratelimiter.WithOptional(ctx)
+	}
+	var pushActive func() bool
+	if push, ok := chain.(indexer.PushSource); ok {
+		pushActive = push.PushActive
+	}
+	log := indexer.ChainLogger(chain.GetName(), cfg).With(
 		slog.String("mode", strings.ToUpper(string(mode))),
-		slog.String("chain", chain.GetName()),
 	)
 
 	return &BaseWorker{
@@ -77,6 +148,7 @@ func newWorkerWithMode(
 		cancel:      cancel,
 		mode:        mode,
 		logger:      log,
+		blockLog:    logger.NewSampler(cfg.Logging.SampleEvery),
 		config:      cfg,
 		chain:       chain,
 		kvstore:     kv,
@@ -84,51 +156,100 @@ func newWorkerWithMode(
 		pubkeyStore: pubkeyStore,
 		emitter:     emitter,
 		failedChan:  failedChan,
+		poll:        newPollSchedule(cfg.PollInterval, cfg.Poll, pushActive),
+		progress:    newProgress(),
 	}
 }
 
-// run executes the given job repeatedly at PollInterval with error handling.
+// run executes the given job repeatedly with error handling: on each new
+// head of the worker's head source while it delivers them, otherwise on
+// the worker's poll schedule. The job runs once on the schedule at start,
+// as a push source only announces the next head.
 func (bw *BaseWorker) run(job func() error) {
-	ticker := time.NewTicker(bw.config.PollInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(bw.poll.next())
+	defer timer.Stop()
 
 	const retryInterval = 2 * time.Second
 
+	var wake <-chan indexer.HeadEvent
+	if bw.heads != nil {
+		events, err := bw.heads.Subscribe(bw.ctx)
+		if err != nil {
+			bw.logger.Warn("Head source unavailable, polling only", "err", err)
+		}
+		wake = events
+	}
+
+	tick := func() {
+		start := time.Now()
+
+		// Use Exponential retry for the job
+		if err := retry.Exponential(func() error {
+			return bw.executeRecoverable("worker job", job)
+		}, retry.ExponentialConfig{
+			InitialInterval: retryInterval,
+			MaxElapsedTime:  bw.config.PollInterval * 4,
+			OnRetry: func(err error, next time.Duration) {
+				bw.logger.Debug("Retrying job",
+					"err", err,
+					"next_retry_in", next)
+			},
+		}); err != nil {
+			failures := bw.progress.setError(err)
+			bw.logger.Log(bw.ctx, bw.failureLevel(failures), "Job error",
+				"err", err,
+				"error_type", indexer.ErrorTypeOf(err),
+				"consecutive_failures", failures,
+			)
+			_ = bw.emitter.EmitError(bw.chain.GetName(), err)
+		}
+
+		switch {
+		case wake == nil:
+			// Keep the scheduled wait between job starts
+			timer.Reset(max(bw.poll.next()-time.Since(start), 0))
+		case bw.backlog != nil && bw.backlog():
+			// No head announces the blocks left behind
+			timer.Reset(0)
+		}
+	}
+
 	for {
 		select {
 		case <-bw.ctx.Done():
 			bw.logger.Info("Context done, stopping worker loop")
 			return
 
-		case <-ticker.C:
-			start := time.Now()
-
-			// Use Exponential retry for the job
-			if err := retry.Exponential(func() error {
-				return bw.executeRecoverable("worker job", job)
-			}, retry.ExponentialConfig{
-				InitialInterval: retryInterval,
-				MaxElapsedTime:  bw.config.PollInterval * 4,
-				OnRetry: func(err error, next time.Duration) {
-					bw.logger.Debug("Retrying job",
-						"err", err,
-						"next_retry_in", next)
-				},
-			}); err != nil {
-				bw.logger.Error("Job error",
-					"err", err,
-				)
-				_ = bw.emitter.EmitError(bw.chain.GetName(), err)
-			}
+		case <-timer.C:
+			tick()
 
-			// Maintain minimum PollInterval between job starts
-			if elapsed := time.Since(start); elapsed < bw.config.PollInterval {
-				time.Sleep(bw.config.PollInterval - elapsed)
+		case _, ok := <-wake:
+			if !ok {
+				// The head source ended; keep polling.
+				wake = nil
+				timer.Reset(bw.poll.next())
+				continue
 			}
+			timer.Stop()
+			tick()
 		}
 	}
 }
 
+// failureLevel returns the level to log the failures-th consecutive
+// failure at: warn until ChainConfig.ErrorAfterFailures is reached, so
+// transient node errors stay quiet, then error.
+func (bw *BaseWorker) failureLevel(failures int) slog.Level {
+	threshold := bw.config.ErrorAfterFailures
+	if threshold <= 0 {
+		threshold = constant.DefaultErrorAfterFailures
+	}
+	if failures < threshold {
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
 // notifyObserver calls the observer callback if set.
 func (bw *BaseWorker) notifyObserver(blockNumber uint64, status BlockStatus) {
 	if bw.observer != nil {
@@ -136,9 +257,38 @@ func (bw *BaseWorker) notifyObserver(blockNumber uint64, status BlockStatus) {
 	}
 }
 
+// overBudgetPause is how long optional work waits once the request budget
+// of its limiter group refuses it, before trying again.
+const overBudgetPause = 30 * time.Second
+
+// overBudget reports whether res failed only because the request budget
+// refused its optional requests: the block is not at fault and must not
+// be recorded as failed.
+func overBudget(res indexer.BlockResult) bool {
+	return res.Error != nil && res.Error.ErrorType == indexer.ErrorTypeOverBudget
+}
+
+// pauseOverBudget waits out overBudgetPause or until the worker stops.
+func (bw *BaseWorker) pauseOverBudget() {
+	bw.logger.Warn("Request budget refuses optional work, pausing",
+		"chain", bw.chain.GetName(),
+		"pause", overBudgetPause,
+	)
+	select {
+	case <-time.After(overBudgetPause):
+	case <-bw.ctx.Done():
+	}
+}
+
 // handleBlockResult processes a block result and persists/forwards errors if needed.
+// It ends the result's trace span, see blockSpan.
 func (bw *BaseWorker) handleBlockResult(result indexer.BlockResult) bool {
+	ctx, span := bw.blockSpan(result)
+	var spanErr error
+	defer func() { tracing.End(span, spanErr) }()
+
 	if result.Error != nil {
+		spanErr = errors.New(result.Error.Message)
 		_ = bw.blockStore.SaveFailedBlock(bw.chain.GetNetworkInternalCode(), result.Number)
 
 		// Non-blocking push to failedChan
@@ -152,11 +302,21 @@ func (bw *BaseWorker) handleBlockResult(result indexer.BlockResult) bool {
 			bw.logger.Warn("failedChan full, dropping block event", "block", result.Number)
 		}
 
-		bw.logger.Error("Failed to process block",
+		failures := bw.progress.setBlockError(result.Error)
+		attrs := []any{
 			"chain", bw.chain.GetName(),
 			"block", result.Number,
 			"err", result.Error.Message,
-		)
+			"error_type", result.Error.ErrorType,
+			"consecutive_failures", failures,
+		}
+		if result.Error.Stack != "" {
+			attrs = append(attrs, "stack", result.Error.Stack)
+		}
+		if len(result.Error.Issues) > 0 {
+			attrs = append(attrs, "issues", result.Error.Issues)
+		}
+		bw.logger.Log(bw.ctx, bw.failureLevel(failures), "Failed to process block", attrs...)
 
 		if result.Error.ErrorType == indexer.ErrorTypeBlockNotFound {
 			bw.notifyObserver(result.Number, BlockStatusNotFound)
@@ -167,6 +327,7 @@ func (bw *BaseWorker) handleBlockResult(result indexer.BlockResult) bool {
 	}
 
 	if result.Block == nil {
+		spanErr = errors.New("nil block")
 		bw.logger.Error("Nil block result",
 			"chain", bw.chain.GetName(),
 			"block", result.Number,
@@ -176,71 +337,215 @@ func (bw *BaseWorker) handleBlockResult(result indexer.BlockResult) bool {
 	}
 
 	// Emit transactions if relevant
-	bw.emitBlock(result.Block)
+	transfers := bw.emitBlock(ctx, result.Block)
+	if err := bw.writeSink(ctx, transfers); err != nil {
+		spanErr = err
+		// The transfers went out on the stream; the rescanner emits them
+		// again, idempotently, along with the write.
+		_ = bw.blockStore.SaveFailedBlock(bw.chain.GetNetworkInternalCode(), result.Number)
+		bw.logger.Error("Failed to write block transfers to sink",
+			"chain", bw.chain.GetName(),
+			"block", result.Number,
+			"transfers", len(transfers),
+			"err", err,
+		)
+		bw.notifyObserver(result.Number, BlockStatusFailed)
+		return false
+	}
 
-	bw.logger.Info("Processed block successfully",
-		"chain", bw.chain.GetName(),
+	if len(transfers) == 0 && bw.config.EmitEmptyBlocks {
+		bw.emitEmptyBlock(result.Block)
+	}
+	bw.recordSupply(result.Block)
+
+	bw.logger.Log(bw.ctx, bw.blockLog.Level(), "Processed block successfully",
 		"block", result.Block.Number,
 	)
 	bw.notifyObserver(result.Number, BlockStatusProcessed)
 	return true
 }
 
+// blockSpan returns the trace span of result, started by the indexer when it
+// fetched the block, or a new one covering its handling only, and a context
+// carrying it.
+func (bw *BaseWorker) blockSpan(result indexer.BlockResult) (context.Context, trace.Span) {
+	span := result.Span
+	if span == nil {
+		_, span = tracing.Start(bw.ctx, "block", attribute.Int64("block.number", int64(result.Number)))
+	}
+	span.SetAttributes(attribute.String("chain", bw.chain.GetName()))
+	return trace.ContextWithSpan(bw.ctx, span), span
+}
+
 // emitBlock emits relevant transactions for subscribed addresses.
 // When two_way_indexing is enabled, both incoming (to) and outgoing (from) transfers are emitted.
 // For internal transfers where both addresses are monitored, two events are emitted — one per direction.
-func (bw *BaseWorker) emitBlock(block *types.Block) {
+// Every emitted copy carries the transfer's Role, computed from which sides matched.
+// It returns the matched transfers, once each.
+func (bw *BaseWorker) emitBlock(ctx context.Context, block *types.Block) []types.Transaction {
 	if block == nil || bw.pubkeyStore == nil {
-		return
+		return nil
+	}
+
+	matched, directions, retired := bw.matchBlock(ctx, block)
+
+	_, span := tracing.Start(ctx, "publish", attribute.Int("transfers", len(matched)))
+	defer span.End()
+	for i, tx := range matched {
+		if directions[i].in {
+			bw.emitTransfer(block.Number, tx, types.DirectionIn)
+		}
+		if directions[i].out {
+			bw.emitTransfer(block.Number, tx, types.DirectionOut)
+		}
 	}
 
+	for i := range retired {
+		bw.emitRetiredActivity(block, &retired[i])
+	}
+
+	bw.emitUTXOs(block)
+	return matched
+}
+
+// emitDirections are the directions a matched transfer is emitted in.
+type emitDirections struct{ in, out bool }
+
+// matchBlock canonicalizes and formats the transfers of block and returns
+// those touching a monitored address, with the directions of each. With
+// EmitRetiredActivity set it also returns the activity of retired
+// addresses.
+func (bw *BaseWorker) matchBlock(
+	ctx context.Context,
+	block *types.Block,
+) ([]types.Transaction, []emitDirections, []types.RetiredAddressActivity) {
+	_, span := tracing.Start(ctx, "match_addresses", attribute.Int("transfers", len(block.Transactions)))
+	defer span.End()
+
 	addressType := bw.chain.GetNetworkType()
+	retiredChecker, _ := bw.pubkeyStore.(pubkeystore.RetiredChecker)
+	if !bw.config.EmitRetiredActivity {
+		retiredChecker = nil
+	}
+	var matched []types.Transaction
+	var directions []emitDirections
+	var retired []types.RetiredAddressActivity
 	for _, tx := range block.Transactions {
-		toMonitored := tx.ToAddress != "" && bw.pubkeyStore.Exist(addressType, tx.ToAddress)
-		fromMonitored := false
-		if bw.config.TwoWayIndexing {
-			for _, addr := range tx.AllSenderAddresses() {
-				if bw.pubkeyStore.Exist(addressType, addr) {
-					fromMonitored = true
-					break
-				}
+		canonicalizeTransfer(addressType, &tx)
+		if !handleSelfTransfer(bw.config, addressType, &tx) {
+			continue
+		}
+		bw.amounts.Format(&tx)
+		match := matchTransfer(bw.pubkeyStore, addressType, &tx)
+		tx.Role = match.role()
+		toMonitored, fromMonitored := match.directions(bw.config.TwoWayIndexing, &tx)
+		if toMonitored || fromMonitored {
+			matched = append(matched, tx)
+			directions = append(directions, emitDirections{in: toMonitored, out: fromMonitored})
+		}
+		if retiredChecker != nil {
+			if addrs := retiredAddresses(retiredChecker, addressType, &tx); len(addrs) > 0 {
+				retired = append(retired, types.RetiredAddressActivity{Addresses: addrs, Transfer: tx})
 			}
 		}
+	}
+	span.SetAttributes(attribute.Int("matched", len(matched)), attribute.Int("retired", len(retired)))
+	return matched, directions, retired
+}
 
-		if toMonitored {
-			inTx := tx
-			inTx.Direction = types.DirectionIn
-			bw.logger.Info("Emitting matched transaction",
-				"direction", types.DirectionIn,
-				"from", tx.FromAddress,
-				"to", tx.ToAddress,
-				"chain", bw.chain.GetName(),
-				"type", tx.Type,
-				"txhash", tx.TxHash,
-				"status", tx.Status,
-				"confirmations", tx.Confirmations,
-			)
-			_ = bw.emitter.EmitTransaction(bw.chain.GetName(), &inTx)
-		}
+// emitRetiredActivity publishes activity, found in block. Like transfers,
+// it is published best effort.
+func (bw *BaseWorker) emitRetiredActivity(block *types.Block, activity *types.RetiredAddressActivity) {
+	activity.NetworkId = bw.chain.GetName()
+	activity.InternalCode = bw.chain.GetNetworkInternalCode()
+	activity.BlockNumber = block.Number
+	activity.Transfer.EnsureTransferID()
+	bw.logger.Info("Emitting retired address activity",
+		"addresses", activity.Addresses,
+		"txhash", activity.Transfer.TxHash,
+		"block", block.Number,
+	)
+	if err := bw.emitter.EmitRetiredActivity(bw.chain.GetName(), activity); err != nil {
+		bw.logger.Warn("Failed to emit retired address activity", "block", block.Number, "err", err)
+	}
+}
 
-		if fromMonitored {
-			outTx := tx
-			outTx.Direction = types.DirectionOut
-			bw.logger.Info("Emitting matched transaction",
-				"direction", types.DirectionOut,
-				"from", tx.FromAddress,
-				"to", tx.ToAddress,
-				"chain", bw.chain.GetName(),
-				"type", tx.Type,
-				"txhash", tx.TxHash,
-				"status", tx.Status,
-				"confirmations", tx.Confirmations,
-			)
-			_ = bw.emitter.EmitTransaction(bw.chain.GetName(), &outTx)
-		}
+// emitTransfer emits tx in direction, unless the chain's emitGuard saw the
+// event already. Suppressed events are still recorded for reorg events, as
+// they may have been emitted by another worker.
+func (bw *BaseWorker) emitTransfer(blockNumber uint64, tx types.Transaction, direction string) {
+	tx.Direction = direction
+	if bw.emitGuard != nil && bw.emitGuard.duplicate(&tx) {
+		bw.logger.Debug("Suppressed duplicate transaction event",
+			"direction", direction,
+			"txhash", tx.TxHash,
+			"transfer_index", tx.TransferIndex,
+			"block", blockNumber,
+		)
+		bw.recordEmitted(blockNumber, &tx)
+		return
 	}
 
-	bw.emitUTXOs(block)
+	bw.logger.Info("Emitting matched transaction",
+		"direction", direction,
+		"role", tx.Role,
+		"from", tx.FromAddress,
+		"to", tx.ToAddress,
+		"chain", bw.chain.GetName(),
+		"type", tx.Type,
+		"txhash", tx.TxHash,
+		"status", tx.Status,
+		"confirmations", tx.Confirmations,
+	)
+	if err := bw.emitter.EmitTransaction(bw.chain.GetName(), &tx); err == nil && bw.emitGuard != nil {
+		bw.emitGuard.record(&tx)
+	}
+	bw.recordEmitted(blockNumber, &tx)
+}
+
+// emitEmptyBlock publishes block without its transactions, for consumers
+// tracking every block. Like transfers, it is published best effort and
+// does not hold back the checkpoint.
+func (bw *BaseWorker) emitEmptyBlock(block *types.Block) {
+	empty := *block
+	empty.Transactions = []types.Transaction{}
+	if empty.InternalCode == "" {
+		empty.InternalCode = bw.chain.GetNetworkInternalCode()
+	}
+	if err := bw.emitter.EmitBlock(bw.chain.GetName(), &empty); err != nil {
+		bw.logger.Warn("Failed to emit empty block", "block", block.Number, "err", err)
+	}
+}
+
+// recordSupply records block's supply, if a tracker is set. A failure only
+// leaves a gap, which supply reconciliation finds and fills.
+func (bw *BaseWorker) recordSupply(block *types.Block) {
+	if bw.supply == nil {
+		return
+	}
+	if err := bw.supply.Record(bw.ctx, block); err != nil {
+		bw.logger.Error("Failed to record block supply", "block", block.Number, "err", err)
+	}
+}
+
+// writeSink persists transfers to the sink, if one is set.
+func (bw *BaseWorker) writeSink(ctx context.Context, transfers []types.Transaction) error {
+	if bw.sink == nil || len(transfers) == 0 {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "sink.write", attribute.Int("transfers", len(transfers)))
+	err := bw.sink.WriteTransfers(ctx, bw.chain.GetName(), transfers)
+	tracing.End(span, err)
+	return err
+}
+
+// recordEmitted notes tx, as emitted for block, for reorg events.
+func (bw *BaseWorker) recordEmitted(block uint64, tx *types.Transaction) {
+	if bw.emitted == nil {
+		return
+	}
+	tx.EnsureTransferID()
+	bw.emitted.add(block, tx.TransferID)
 }
 
 // emitUTXOs emits UTXO events for monitored addresses.
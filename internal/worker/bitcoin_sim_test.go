@@ -0,0 +1,289 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin/bitcointest"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var simPayees = []string{
+	"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+	"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+}
+
+// newSimWorker wires a RegularWorker to a real BitcoinIndexer and Failover
+// over sims, watching simPayees.
+func newSimWorker(t *testing.T, start uint64, sims ...*bitcointest.Sim) (*RegularWorker, *recordingEmitter, *stubBlockStore) {
+	t.Helper()
+	initTestLogger()
+
+	cfg := testChainConfig()
+	cfg.NetworkId = "btc_sim"
+	cfg.InternalCode = "BTC_SIM"
+	cfg.Throttle.BatchSize = 4
+	cfg.Throttle.Concurrency = 2
+	cfg.ReorgRollbackWindow = 3
+	cfg.ValueCheck = config.ValueCheckConfig{SampleRate: 1, Strict: true}
+	chain := indexer.NewBitcoinIndexer("btc_sim", cfg, bitcointest.NewSimFailover(t, sims...), nil)
+
+	watched := stubPubkeyStore{}
+	for _, addr := range simPayees {
+		watched[addr] = true
+	}
+	emitter := &recordingEmitter{}
+	store := &stubBlockStore{}
+	rw := &RegularWorker{
+		BaseWorker: &BaseWorker{
+			ctx:         context.Background(),
+			cancel:      func() {},
+			logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+			config:      cfg,
+			chain:       chain,
+			blockStore:  store,
+			emitter:     emitter,
+			pubkeyStore: watched,
+			failedChan:  make(chan FailedBlockEvent, 16),
+			poll:        newPollSchedule(cfg.PollInterval, cfg.Poll, nil),
+			progress:    newProgress(),
+		},
+		currentBlock: start,
+		blockHashes:  make([]blockstore.BlockHashEntry, 0, MaxBlockHashSize),
+	}
+	rw.emitted = newEmittedTransfers(rw.emittedCapacity())
+	return rw, emitter, store
+}
+
+// indexToTip runs ticks until rw has indexed the sim's tip.
+func indexToTip(t *testing.T, rw *RegularWorker, sim *bitcointest.Sim) {
+	t.Helper()
+	for range 20 {
+		if rw.currentBlock > sim.Tip() {
+			return
+		}
+		require.NoError(t, rw.processRegularBlocks())
+	}
+	t.Fatalf("stuck at block %d, tip %d", rw.currentBlock, sim.Tip())
+}
+
+// requireEmitted checks that every payment of heights from..to was emitted
+// as a deposit on the sim's current branch.
+func requireEmitted(t *testing.T, emitted []types.Transaction, sim *bitcointest.Sim, from, to uint64) {
+	t.Helper()
+	byTxID := make(map[string]types.Transaction)
+	for _, tx := range emitted {
+		byTxID[tx.TxHash] = tx
+	}
+	for h := from; h <= to; h++ {
+		for _, p := range sim.Payments(h) {
+			tx, ok := byTxID[p.TxID]
+			require.True(t, ok, "payment %s in block %d not emitted", p.TxID, h)
+			assert.Equal(t, h, tx.BlockNumber)
+			assert.Equal(t, sim.BlockHash(h), tx.BlockHash)
+			assert.Equal(t, p.To, tx.ToAddress)
+			assert.Equal(t, p.From, tx.FromAddress)
+			assert.Equal(t, strconv.FormatInt(p.Sats, 10), tx.Amount)
+			assert.Equal(t, "BTC_SIM", tx.InternalCode)
+			assert.Equal(t, "in", tx.Direction)
+		}
+	}
+}
+
+func TestBitcoinSim_EmitsTransfers(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 10, Pay: simPayees, PaymentsPerBlock: 3})
+	rw, emitter, store := newSimWorker(t, 100, sim)
+
+	indexToTip(t, rw, sim)
+
+	assert.Len(t, emitter.txs, 30)
+	requireEmitted(t, emitter.txs, sim, 100, 109)
+	assert.Empty(t, store.failedBlocks)
+	assert.Equal(t, sim.BlockHash(109), rw.getBlockHash(109))
+
+	outputs := chainStatus([]Worker{rw}).Outputs
+	require.NotNil(t, outputs)
+	assert.Equal(t, uint64(10), outputs.Blocks)
+	assert.Equal(t, uint64(70), outputs.ScriptTypes["witness_v0_keyhash"], "a coinbase and two outputs per payment")
+	assert.Equal(t, uint64(30), outputs.ValueChecked)
+	assert.Zero(t, outputs.ValueDiscrepancies)
+}
+
+func TestBitcoinSim_Reorg(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 10, Pay: simPayees})
+	rw, emitter, store := newSimWorker(t, 100, sim)
+	indexToTip(t, rw, sim)
+	stale := sim.Payments(107)
+	oldTip := sim.BlockHash(109)
+	var voided []string
+	for _, tx := range emitter.txs {
+		if tx.BlockNumber >= 106 {
+			voided = append(voided, tx.TransferID)
+		}
+	}
+
+	sim.Reorg(107)
+	sim.Mine(1)
+	indexToTip(t, rw, sim)
+
+	assert.Contains(t, store.savedLatest, uint64(105), "rolled back ReorgRollbackWindow blocks from the old tip")
+	require.Len(t, emitter.reorgs, 1)
+	reorg := emitter.reorgs[0]
+	assert.Equal(t, "btc_sim", reorg.NetworkId)
+	assert.Equal(t, "BTC_SIM", reorg.InternalCode)
+	assert.Equal(t, [2]uint64{106, 109}, [2]uint64{reorg.FromBlock, reorg.ToBlock})
+	assert.Equal(t, oldTip, reorg.OldHash)
+	assert.Equal(t, sim.BlockHash(109), reorg.NewHash)
+	assert.Len(t, voided, 8)
+	assert.Equal(t, voided, reorg.TransferIDs)
+	requireEmitted(t, emitter.txs, sim, 100, 110)
+	assert.NotEqual(t, stale[0].TxID, sim.Payments(107)[0].TxID)
+	assert.Equal(t, sim.BlockHash(110), rw.getBlockHash(110))
+	assert.Empty(t, store.failedBlocks)
+
+	queues := chainStatus([]Worker{rw}).FetchQueues
+	assert.GreaterOrEqual(t, queues["reorg"].Completed, uint64(4), "rolled back blocks re-fetched at reorg priority")
+	assert.Positive(t, queues["tip"].Completed)
+	assert.Zero(t, queues["backfill"].Completed)
+}
+
+// TestBitcoinSim_DeepReorg checks a fork older than the rollback window is
+// caught at the block after the rollback, which halts the chain until an
+// operator rolls back further.
+func TestBitcoinSim_DeepReorg(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 10, Pay: simPayees})
+	rw, emitter, store := newSimWorker(t, 100, sim)
+	indexToTip(t, rw, sim)
+	require.ErrorIs(t, rw.resolveReorgHalt(ReorgResolution{}), ErrChainNotHalted)
+
+	sim.Reorg(103)
+	sim.Mine(1)
+	haltDeep(t, rw, store)
+	require.Len(t, emitter.reorgs, 1)
+	assert.Equal(t, [2]uint64{106, 109}, [2]uint64{emitter.reorgs[0].FromBlock, emitter.reorgs[0].ToBlock})
+	snap := rw.progress.snapshot()
+	assert.Equal(t, uint64(2), snap.Reorgs)
+	assert.Equal(t, uint64(1), snap.DeepReorgs)
+	assert.NotNil(t, snap.LastDeepReorgAt)
+
+	require.NoError(t, rw.resolveReorgHalt(ReorgResolution{}))
+	indexToTip(t, rw, sim)
+
+	require.Len(t, emitter.reorgs, 2)
+	assert.Equal(t, [2]uint64{102, 105}, [2]uint64{emitter.reorgs[1].FromBlock, emitter.reorgs[1].ToBlock})
+	assert.Nil(t, store.halt)
+	assert.Equal(t, ChainStateRunning, chainStatus([]Worker{rw}).State)
+	requireEmitted(t, emitter.txs, sim, 100, 110)
+	assert.Empty(t, store.failedBlocks)
+}
+
+func TestBitcoinSim_DeepReorgResync(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 10, Pay: simPayees})
+	rw, emitter, store := newSimWorker(t, 100, sim)
+	indexToTip(t, rw, sim)
+	sim.Reorg(103)
+	sim.Mine(1)
+	haltDeep(t, rw, store)
+
+	// A restart keeps the chain halted.
+	rw.halt = nil
+	rw.loadReorgHalt()
+	require.NoError(t, rw.processRegularBlocks())
+	assert.Equal(t, uint64(106), rw.currentBlock)
+
+	require.NoError(t, rw.resolveReorgHalt(ReorgResolution{ResyncFrom: 103}))
+	indexToTip(t, rw, sim)
+
+	require.Len(t, emitter.reorgs, 2)
+	assert.Equal(t, [2]uint64{103, 105}, [2]uint64{emitter.reorgs[1].FromBlock, emitter.reorgs[1].ToBlock})
+	assert.Contains(t, store.savedLatest, uint64(102))
+	assert.Nil(t, store.halt)
+	requireEmitted(t, emitter.txs, sim, 100, 110)
+}
+
+// haltDeep runs ticks over a fork older than the rollback window, checking
+// they halt the chain above the rollback rather than roll back further.
+func haltDeep(t *testing.T, rw *RegularWorker, store *stubBlockStore) {
+	t.Helper()
+	for range 3 {
+		require.NoError(t, rw.processRegularBlocks())
+	}
+	require.NotNil(t, store.halt)
+	assert.Equal(t, uint64(105), store.halt.Block)
+	assert.Equal(t, 3, store.halt.RollbackWindow)
+	assert.Equal(t, uint64(106), rw.currentBlock)
+
+	status := chainStatus([]Worker{rw})
+	assert.Equal(t, ChainStateHalted, status.State)
+	assert.Equal(t, store.halt, status.ReorgHalt)
+	assert.Equal(t, "halted at block 105 by a reorg deeper than the rollback window", status.notReady(100))
+}
+
+func TestBitcoinSim_RetriesFaults(t *testing.T) {
+	cfg := bitcointest.SimConfig{Start: 100, Blocks: 6, Pay: simPayees}
+	flaky, healthy := bitcointest.NewSim(t, cfg), bitcointest.NewSim(t, cfg)
+	flaky.Fail("getblockcount", bitcointest.FaultMalformed, 1)
+	flaky.Fail("getblockhash", bitcointest.FaultTimeout, 1)
+	flaky.Fail("getblock", bitcointest.FaultRateLimit, 1)
+
+	rw, emitter, store := newSimWorker(t, 100, flaky, healthy)
+	indexToTip(t, rw, flaky)
+
+	requireEmitted(t, emitter.txs, flaky, 100, 105)
+	assert.Empty(t, store.failedBlocks)
+	assert.Positive(t, healthy.Calls("getblock"), "the rate-limited node was rotated out")
+
+	statuses := rw.chain.(*indexer.BitcoinIndexer).ProviderStatuses()
+	require.Len(t, statuses, 2)
+	assert.Positive(t, statuses[0].ErrorRate, "every fault reached the flaky node")
+	assert.Zero(t, statuses[1].ErrorRate)
+}
+
+// recordingSupply keeps the hash recorded per height and the heights rolled
+// back from.
+type recordingSupply struct {
+	hashes    map[uint64]string
+	rollbacks []uint64
+}
+
+func (s *recordingSupply) Record(_ context.Context, block *types.Block) error {
+	s.hashes[block.Number] = block.Hash
+	return nil
+}
+
+func (s *recordingSupply) Rollback(_ context.Context, from uint64) error {
+	s.rollbacks = append(s.rollbacks, from)
+	for h := range s.hashes {
+		if h >= from {
+			delete(s.hashes, h)
+		}
+	}
+	return nil
+}
+
+func TestBitcoinSim_SupplyFollowsReorg(t *testing.T) {
+	sim := bitcointest.NewSim(t, bitcointest.SimConfig{Start: 100, Blocks: 10, Pay: simPayees})
+	rw, _, _ := newSimWorker(t, 100, sim)
+	recorded := &recordingSupply{hashes: make(map[uint64]string)}
+	rw.supply = recorded
+	indexToTip(t, rw, sim)
+	require.Len(t, recorded.hashes, 10)
+
+	sim.Reorg(107)
+	sim.Mine(1)
+	indexToTip(t, rw, sim)
+
+	assert.Equal(t, []uint64{106}, recorded.rollbacks)
+	require.Len(t, recorded.hashes, 11)
+	for h := uint64(100); h <= 110; h++ {
+		assert.Equal(t, sim.BlockHash(h), recorded.hashes[h], "block %d", h)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
@@ -17,10 +18,19 @@ type AddressLoader interface {
 	LoadAddresses(ctx context.Context, params AddressLoaderParams) ([]LoadedAddress, error)
 }
 
+// RetiredAddressLoader is implemented by AddressLoaders that can also load
+// the addresses retired, e.g. soft-deleted, so the sync stops matching them.
+// LoadAddresses must not return retired addresses.
+type RetiredAddressLoader interface {
+	// LoadRetiredAddresses returns addresses retired after params.After,
+	// oldest retirement first, with RetiredAt set.
+	LoadRetiredAddresses(ctx context.Context, params AddressLoaderParams) ([]LoadedAddress, error)
+}
+
 // AddressLoaderParams contains the parameters for loading addresses.
 type AddressLoaderParams struct {
 	NetworkType enum.NetworkType
-	After       time.Time // Load addresses created after this time
+	After       time.Time // Load addresses created (retired) after this time
 	Limit       int
 }
 
@@ -28,6 +38,7 @@ type AddressLoaderParams struct {
 type LoadedAddress struct {
 	Address   string
 	CreatedAt time.Time
+	RetiredAt time.Time // set by LoadRetiredAddresses only
 }
 
 // BloomSyncConfig holds configuration for the bloom filter sync worker.
@@ -52,37 +63,47 @@ func NewBloomSyncConfig(syncCfg config.BloomSyncConfig) BloomSyncConfig {
 	return cfg
 }
 
-// BloomSyncWorker continuously syncs new addresses to the bloom filter.
+// BloomSyncWorker continuously syncs new addresses to the bloom filter,
+// dropping them from the RetiredSet in case they were retired before.
+// When its loader is a RetiredAddressLoader it also adds retired addresses
+// to the RetiredSet, starting with every address retired before it
+// started: a Redis filter keeps addresses loaded by earlier runs.
 // Implements the Worker interface for unified lifecycle management.
 type BloomSyncWorker struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	bloomFilter addressbloomfilter.WalletAddressBloomFilter
+	retired     *addressbloomfilter.RetiredSet
 	loader      AddressLoader
 	config      BloomSyncConfig
 
-	mu              sync.RWMutex
-	lastSyncedTimes map[enum.NetworkType]time.Time
-	totalSynced     map[enum.NetworkType]uint64
+	mu               sync.RWMutex
+	lastSyncedTimes  map[enum.NetworkType]time.Time
+	totalSynced      map[enum.NetworkType]uint64
+	lastRetiredTimes map[enum.NetworkType]time.Time
 }
 
-// NewBloomSyncWorker creates a new bloom filter sync worker.
+// NewBloomSyncWorker creates a new bloom filter sync worker. retired may
+// be nil to ignore retirements.
 func NewBloomSyncWorker(
 	ctx context.Context,
 	bloomFilter addressbloomfilter.WalletAddressBloomFilter,
+	retired *addressbloomfilter.RetiredSet,
 	loader AddressLoader,
 	config BloomSyncConfig,
 ) *BloomSyncWorker {
 	workerCtx, cancel := context.WithCancel(ctx)
 	return &BloomSyncWorker{
-		ctx:             workerCtx,
-		cancel:          cancel,
-		bloomFilter:     bloomFilter,
-		loader:          loader,
-		config:          config,
-		lastSyncedTimes: make(map[enum.NetworkType]time.Time),
-		totalSynced:     make(map[enum.NetworkType]uint64),
+		ctx:              workerCtx,
+		cancel:           cancel,
+		bloomFilter:      bloomFilter,
+		retired:          retired,
+		loader:           loader,
+		config:           config,
+		lastSyncedTimes:  make(map[enum.NetworkType]time.Time),
+		totalSynced:      make(map[enum.NetworkType]uint64),
+		lastRetiredTimes: make(map[enum.NetworkType]time.Time),
 	}
 }
 
@@ -137,6 +158,63 @@ func (w *BloomSyncWorker) syncAllNetworks() {
 				"error", err,
 			)
 		}
+		if err := w.syncRetired(nt); err != nil {
+			logger.Error("Bloom sync of retired addresses failed for network",
+				"networkType", nt,
+				"error", err,
+			)
+		}
+	}
+}
+
+// syncRetired adds the addresses retired since the last call to the
+// RetiredSet, looping until caught up like syncNetwork.
+func (w *BloomSyncWorker) syncRetired(networkType enum.NetworkType) error {
+	loader, ok := w.loader.(RetiredAddressLoader)
+	if !ok || w.retired == nil {
+		return nil
+	}
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		default:
+		}
+
+		w.mu.RLock()
+		lastRetired := w.lastRetiredTimes[networkType]
+		w.mu.RUnlock()
+
+		addresses, err := loader.LoadRetiredAddresses(w.ctx, AddressLoaderParams{
+			NetworkType: networkType,
+			After:       lastRetired,
+			Limit:       w.config.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if len(addresses) == 0 {
+			return nil
+		}
+
+		canonical := make([]string, len(addresses))
+		for i, a := range addresses {
+			canonical[i] = addressutil.Canonical(networkType, a.Address)
+		}
+		w.retired.Retire(canonical, networkType)
+
+		w.mu.Lock()
+		w.lastRetiredTimes[networkType] = addresses[len(addresses)-1].RetiredAt
+		w.mu.Unlock()
+
+		logger.Info("Bloom sync retired addresses",
+			"networkType", networkType,
+			"retired", len(addresses),
+		)
+		if len(addresses) < w.config.BatchSize {
+			return nil
+		}
 	}
 }
 
@@ -178,12 +256,16 @@ func (w *BloomSyncWorker) syncNetwork(networkType enum.NetworkType) error {
 			return nil
 		}
 
-		// Add to bloom filter (idempotent)
+		// Add to bloom filter (idempotent), reinstating addresses retired
+		// before they were registered again.
 		addressStrings := make([]string, len(addresses))
+		canonical := make([]string, len(addresses))
 		for i, a := range addresses {
 			addressStrings[i] = a.Address
+			canonical[i] = addressutil.Canonical(networkType, a.Address)
 		}
 		w.bloomFilter.AddBatch(addressStrings, networkType)
+		w.retired.Reinstate(canonical, networkType)
 
 		latestTime := addresses[len(addresses)-1].CreatedAt
 
@@ -223,6 +305,7 @@ func (w *BloomSyncWorker) Stats() map[string]any {
 		stats[string(nt)] = map[string]any{
 			"totalSynced":  count,
 			"lastSyncedAt": w.lastSyncedTimes[nt],
+			"retired":      w.retired.Count(nt),
 		}
 	}
 	return stats
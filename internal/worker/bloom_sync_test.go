@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retiringLoader is an AddressLoader whose addresses are registered by
+// setting created and retired by setting retired.
+type retiringLoader struct {
+	created map[string]time.Time
+	retired map[string]time.Time
+}
+
+func (l retiringLoader) LoadAddresses(_ context.Context, params AddressLoaderParams) ([]LoadedAddress, error) {
+	var out []LoadedAddress
+	for addr, at := range l.created {
+		if at.After(params.After) {
+			out = append(out, LoadedAddress{Address: addr, CreatedAt: at})
+		}
+	}
+	return out, nil
+}
+
+func (l retiringLoader) LoadRetiredAddresses(_ context.Context, params AddressLoaderParams) ([]LoadedAddress, error) {
+	var out []LoadedAddress
+	for addr, at := range l.retired {
+		if at.After(params.After) {
+			out = append(out, LoadedAddress{Address: addr, RetiredAt: at})
+		}
+	}
+	return out, nil
+}
+
+func TestBaseWorkerStopsEmittingForRetiredAddress(t *testing.T) {
+	const (
+		retiring = "0x1111111111111111111111111111111111111111"
+		kept     = "0x2222222222222222222222222222222222222222"
+		external = "0x3333333333333333333333333333333333333333"
+	)
+	bloom := addressbloomfilter.NewAddressBloomFilter(addressbloomfilter.Config{
+		ExpectedItems:     100,
+		FalsePositiveRate: 0.0001,
+	})
+	bloom.AddBatch([]string{retiring, kept}, enum.NetworkTypeEVM)
+	retired := addressbloomfilter.NewRetiredSet()
+	loader := retiringLoader{created: map[string]time.Time{}, retired: map[string]time.Time{}}
+	syncer := NewBloomSyncWorker(context.Background(), bloom, retired, loader, BloomSyncConfig{BatchSize: 10})
+
+	emitter := &recordingEmitter{}
+	cfg := testChainConfig()
+	cfg.EmitRetiredActivity = true
+	bw := &BaseWorker{
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		config:      cfg,
+		chain:       &stubIndexer{name: "eth", networkType: enum.NetworkTypeEVM},
+		pubkeyStore: pubkeystore.NewPublicKeyStore(bloom, retired, nil),
+		emitter:     emitter,
+	}
+	block := func(n uint64) *types.Block {
+		return &types.Block{Number: n, Transactions: []types.Transaction{
+			{TxHash: "a", FromAddress: external, ToAddress: retiring},
+			{TxHash: "b", FromAddress: retiring, ToAddress: kept},
+		}}
+	}
+
+	bw.emitBlock(context.Background(), block(1))
+	require.Len(t, emitter.txs, 2)
+	assert.Empty(t, emitter.retired)
+
+	// The address is soft-deleted between the two blocks.
+	loader.retired[retiring] = time.Now()
+	require.NoError(t, syncer.syncRetired(enum.NetworkTypeEVM))
+	emitter.txs = nil
+
+	bw.emitBlock(context.Background(), block(2))
+	require.Len(t, emitter.txs, 1, "only the transfer to a monitored address is emitted")
+	assert.Equal(t, "b", emitter.txs[0].TxHash)
+	assert.Equal(t, "deposit", emitter.txs[0].Role, "the retired sender no longer counts as ours")
+
+	require.Len(t, emitter.retired, 2)
+	for i, txHash := range []string{"a", "b"} {
+		activity := emitter.retired[i]
+		assert.Equal(t, txHash, activity.Transfer.TxHash)
+		assert.Equal(t, []string{retiring}, activity.Addresses)
+		assert.Equal(t, uint64(2), activity.BlockNumber)
+		assert.Equal(t, "eth", activity.NetworkId)
+	}
+
+	require.NoError(t, syncer.syncRetired(enum.NetworkTypeEVM), "already synced retirements are not loaded again")
+	assert.Equal(t, 1, retired.Count(enum.NetworkTypeEVM))
+
+	// Registered again, the address is reinstated.
+	delete(loader.retired, retiring)
+	loader.created[retiring] = time.Now()
+	require.NoError(t, syncer.syncNetwork(enum.NetworkTypeEVM))
+	assert.Zero(t, retired.Count(enum.NetworkTypeEVM))
+	emitter.txs, emitter.retired = nil, nil
+
+	bw.emitBlock(context.Background(), block(3))
+	assert.Len(t, emitter.txs, 2)
+	assert.Empty(t, emitter.retired)
+}
@@ -266,7 +266,11 @@ func (cw *CatchupWorker) processRange(r blockstore.CatchupRange, workerID int) e
 		end := min(current+batchSize-1, r.End)
 
 		// Process batch
-		results, err := cw.chain.GetBlocks(cw.ctx, current, end, true)
+		results, err := cw.chain.GetBlocks(indexer.WithFetchPriority(cw.ctx, indexer.PriorityBackfill), current, end, true)
+		if indexer.ErrorTypeOf(err) == indexer.ErrorTypeOverBudget {
+			cw.pauseOverBudget()
+			continue
+		}
 		if err != nil {
 			cw.logger.Warn("Failed to get blocks, retrying",
 				"worker_id", workerID,
@@ -279,7 +283,12 @@ func (cw *CatchupWorker) processRange(r blockstore.CatchupRange, workerID int) e
 
 		// Process results
 		batchSuccess := current - 1
+		resumeAt := uint64(0) // first block the request budget refused
 		for _, res := range results {
+			if overBudget(res) {
+				resumeAt = res.Number
+				break
+			}
 			if res.Error != nil && res.Error.ErrorType == indexer.ErrorTypeBlockNotFound && cw.chain.GetNetworkType() == enum.NetworkTypeSol {
 				// Solana skipped slots are normal — the validator didn't produce a block
 				// for this slot. Skip without retry.
@@ -295,7 +304,12 @@ func (cw *CatchupWorker) processRange(r blockstore.CatchupRange, workerID int) e
 			}
 		}
 
-		if batchSuccess >= current {
+		if resumeAt > 0 {
+			// Blocks after it are fetched again once the budget allows.
+			lastSuccess = max(lastSuccess, resumeAt-1)
+			current = resumeAt
+			cw.pauseOverBudget()
+		} else if batchSuccess >= current {
 			lastSuccess = batchSuccess
 			current = batchSuccess + 1
 		} else {
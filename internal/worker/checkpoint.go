@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// checkpoint tracks the regular worker's checkpoint, the last indexed
+// block as written to the block store, which a restart resumes after. It is
+// written per config.CheckpointConfig rather than after every batch, and
+// always on shutdown. Falling behind only makes a restart index the blocks
+// since again, re-emitting their transfers under the same TransferIDs;
+// writing a block not yet indexed would skip it, so the checkpoint never
+// runs ahead of indexing. The zero value holds nothing to write.
+type checkpoint struct {
+	mu        sync.Mutex
+	indexed   uint64 // last indexed block
+	written   uint64 // last block written
+	dirty     bool   // indexed has not been written
+	writtenAt time.Time
+}
+
+// CheckpointStatus describes a chain's checkpoint: the block a restart
+// would resume after, and how many indexed blocks it lags behind.
+type CheckpointStatus struct {
+	Height    uint64    `json:"height"`
+	Lag       uint64    `json:"lag"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// advanceCheckpoint records that blocks up to height are indexed, writing
+// the checkpoint if the chain's policy says it is due.
+func (rw *RegularWorker) advanceCheckpoint(height uint64) {
+	c := &rw.checkpoint
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexed, c.dirty = height, true
+	if rw.checkpointDue() {
+		rw.writeCheckpoint()
+	}
+}
+
+// resumeCheckpoint records the block the worker starts after as indexed,
+// to be written like any other.
+func (rw *RegularWorker) resumeCheckpoint(height uint64) {
+	c := &rw.checkpoint
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexed, c.dirty = height, true
+}
+
+// saveCheckpoint writes height as the checkpoint right away, for moves
+// other than indexing forward: rollbacks and skip-aheads.
+func (rw *RegularWorker) saveCheckpoint(height uint64) error {
+	c := &rw.checkpoint
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexed, c.dirty = height, true
+	return rw.writeCheckpoint()
+}
+
+// flushCheckpoint writes the checkpoint if it lags behind indexing and
+// force is set or the policy says it is due, e.g. as its interval passed
+// while no block arrived.
+func (rw *RegularWorker) flushCheckpoint(force bool) {
+	c := &rw.checkpoint
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirty && (force || rw.checkpointDue()) {
+		rw.writeCheckpoint()
+	}
+}
+
+// checkpointDue reports whether the policy calls for a write: after every
+// batch when unset, otherwise once EveryBlocks blocks or Interval passed
+// since the last write. Callers hold checkpoint.mu.
+func (rw *RegularWorker) checkpointDue() bool {
+	c := &rw.checkpoint
+	policy := rw.config.Checkpoint
+	if policy.EveryBlocks <= 0 && policy.Interval <= 0 {
+		return true
+	}
+	if policy.EveryBlocks > 0 && (c.indexed < c.written || c.indexed-c.written >= uint64(policy.EveryBlocks)) {
+		return true
+	}
+	return policy.Interval > 0 && time.Since(c.writtenAt) >= policy.Interval
+}
+
+// writeCheckpoint writes the indexed block as the checkpoint. Callers hold
+// checkpoint.mu.
+func (rw *RegularWorker) writeCheckpoint() error {
+	c := &rw.checkpoint
+	if err := rw.blockStore.SaveLatestBlock(rw.chain.GetNetworkInternalCode(), c.indexed); err != nil {
+		rw.logger.Warn("Failed to write checkpoint", "chain", rw.chain.GetName(), "block", c.indexed, "error", err)
+		return err
+	}
+	c.written, c.dirty, c.writtenAt = c.indexed, false, time.Now()
+	return nil
+}
+
+// checkpointStatus reports the checkpoint, nil before this process wrote
+// one.
+func (rw *RegularWorker) checkpointStatus() *CheckpointStatus {
+	c := &rw.checkpoint
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writtenAt.IsZero() {
+		return nil
+	}
+	s := &CheckpointStatus{Height: c.written, WrittenAt: c.writtenAt}
+	if c.dirty && c.indexed > c.written {
+		s.Lag = c.indexed - c.written
+	}
+	return s
+}
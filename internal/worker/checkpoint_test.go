@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequentialIndexer serves a linked chain of empty blocks up to latest.
+func sequentialIndexer(latest uint64) *stubIndexer {
+	return &stubIndexer{
+		name:         "solana",
+		internalCode: "sol",
+		networkType:  enum.NetworkTypeSol,
+		latest:       latest,
+		getBlocksFunc: func(_ context.Context, from, to uint64, _ bool) ([]indexer.BlockResult, error) {
+			var results []indexer.BlockResult
+			for n := from; n <= to; n++ {
+				results = append(results, indexer.BlockResult{Number: n, Block: &types.Block{
+					Number:     n,
+					Hash:       fmt.Sprintf("0x%d", n),
+					ParentHash: fmt.Sprintf("0x%d", n-1),
+				}})
+			}
+			return results, nil
+		},
+	}
+}
+
+func TestRegularWorkerCheckpointEveryBlocks(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(sequentialIndexer(200), store, 100, 2)
+	rw.config.Checkpoint = config.CheckpointConfig{EveryBlocks: 5}
+
+	for range 5 { // blocks 100-109
+		require.NoError(t, rw.processRegularBlocks())
+	}
+	assert.Equal(t, []uint64{101, 107}, store.savedLatest)
+	cp := rw.checkpointStatus()
+	require.NotNil(t, cp)
+	assert.Equal(t, uint64(107), cp.Height)
+	assert.Equal(t, uint64(2), cp.Lag)
+	assert.Equal(t, uint64(2), rw.Stats()["checkpoint_lag"])
+
+	rw.flushCheckpoint(true)
+	assert.Equal(t, []uint64{101, 107, 109}, store.savedLatest, "shutdown writes what the policy held back")
+	assert.Equal(t, uint64(0), rw.checkpointStatus().Lag)
+}
+
+func TestRegularWorkerCheckpointInterval(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(sequentialIndexer(200), store, 100, 2)
+	rw.config.Checkpoint = config.CheckpointConfig{Interval: time.Hour}
+
+	for range 3 { // blocks 100-105
+		require.NoError(t, rw.processRegularBlocks())
+	}
+	assert.Equal(t, []uint64{101}, store.savedLatest)
+
+	// The interval passes while no block arrives.
+	rw.checkpoint.writtenAt = time.Now().Add(-2 * time.Hour)
+	rw.chain.(*stubIndexer).latest = 105
+	require.NoError(t, rw.processRegularBlocks())
+	assert.Equal(t, []uint64{101, 105}, store.savedLatest)
+}
+
+func TestRegularWorkerCheckpointNeverAheadOfIndexing(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(sequentialIndexer(200), store, 100, 2)
+	rw.config.Checkpoint = config.CheckpointConfig{EveryBlocks: 1000, Interval: time.Hour}
+	rw.resumeCheckpoint(rw.currentBlock - 1)
+
+	rw.flushCheckpoint(true)
+	require.Equal(t, []uint64{99}, store.savedLatest, "block 100 is not indexed yet")
+
+	require.NoError(t, rw.processRegularBlocks())
+	require.NoError(t, rw.processRegularBlocks())
+	require.Equal(t, uint64(104), rw.currentBlock)
+	rw.flushCheckpoint(true)
+	assert.Equal(t, []uint64{99, 103}, store.savedLatest)
+
+	// A rollback is written at once, whatever the policy.
+	require.NoError(t, rw.saveCheckpoint(101))
+	assert.Equal(t, []uint64{99, 103, 101}, store.savedLatest)
+}
@@ -18,6 +18,8 @@ func NewDefaultDBLoader(db *gorm.DB) *DefaultDBLoader {
 	return &DefaultDBLoader{db: db}
 }
 
+// LoadAddresses returns addresses created after params.After. Soft-deleted
+// rows are left out by gorm's default scope.
 func (l *DefaultDBLoader) LoadAddresses(ctx context.Context, params AddressLoaderParams) ([]LoadedAddress, error) {
 	var rows []model.WalletAddress
 	err := l.db.WithContext(ctx).
@@ -40,3 +42,28 @@ func (l *DefaultDBLoader) LoadAddresses(ctx context.Context, params AddressLoade
 	}
 	return addresses, nil
 }
+
+// LoadRetiredAddresses returns addresses soft-deleted after params.After.
+func (l *DefaultDBLoader) LoadRetiredAddresses(ctx context.Context, params AddressLoaderParams) ([]LoadedAddress, error) {
+	var rows []model.WalletAddress
+	err := l.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.WalletAddress{}).
+		Select("address", "deleted_at").
+		Where("type = ? AND deleted_at IS NOT NULL AND deleted_at > ?", params.NetworkType, params.After).
+		Order("deleted_at ASC").
+		Limit(params.Limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]LoadedAddress, len(rows))
+	for i, r := range rows {
+		addresses[i] = LoadedAddress{
+			Address:   r.Address,
+			RetiredAt: r.DeletedAt.Time,
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+var (
+	// ErrDiscoveryUnsupported is returned by DiscoverAddress for chains
+	// whose indexer cannot scan an address.
+	ErrDiscoveryUnsupported = errors.New("address discovery not supported")
+	// ErrInvalidBackfill is returned by DiscoverAddress for a backfill range
+	// that is inverted, starts at 0 or is longer than the chain allows.
+	ErrInvalidBackfill = errors.New("invalid backfill range")
+	// ErrAddressNotWatched is returned by DiscoverAddress for an address
+	// not registered on the chain.
+	ErrAddressNotWatched = errors.New("address not registered")
+)
+
+// AddressDiscovery asks for the history of an address registered after it
+// was used: its unspent outputs, and optionally a backfill of the blocks
+// from FromBlock to ToBlock to recover its past transfers.
+type AddressDiscovery struct {
+	Address   string `json:"address"`
+	FromBlock uint64 `json:"from_block,omitempty"`
+	ToBlock   uint64 `json:"to_block,omitempty"`
+}
+
+// AddressDiscoveryResult is what DiscoverAddress found and scheduled.
+type AddressDiscoveryResult struct {
+	Scan *indexer.AddressScan `json:"scan"`
+	// Backfill is the range queued for the chain's manual worker, nil if
+	// none was asked for.
+	Backfill *BlockRange `json:"backfill,omitempty"`
+}
+
+// BlockRange is an inclusive range of block heights.
+type BlockRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// DiscoverAddress scans the UTXO set of the named running chain for the
+// unspent outputs of req.Address, which must be registered, emitting them as UTXO events when the
+// chain indexes UTXOs, so they are tracked at once. A range in req is
+// queued for the chain's manual worker, bounded by
+// config.AddressScanConfig.MaxBackfillBlocks. It returns false for a chain
+// not registered or not running.
+func (m *Manager) DiscoverAddress(ctx context.Context, name string, req AddressDiscovery) (*AddressDiscoveryResult, bool, error) {
+	m.mu.Lock()
+	cw, ok := m.chains[name]
+	var workers []Worker
+	if ok && cw.enabled {
+		workers = append(workers, cw.workers...)
+	}
+	m.mu.Unlock()
+	if len(workers) == 0 {
+		return nil, false, nil
+	}
+
+	var bw *BaseWorker
+	var scanner indexer.AddressScanner
+	for _, w := range workers {
+		if b := baseWorkerOf(w); b != nil {
+			if s, ok := b.chain.(indexer.AddressScanner); ok {
+				bw, scanner = b, s
+				break
+			}
+		}
+	}
+	if scanner == nil {
+		return nil, true, ErrDiscoveryUnsupported
+	}
+
+	var backfill *BlockRange
+	if req.FromBlock != 0 || req.ToBlock != 0 {
+		maxBlocks := bw.config.AddressScan.MaxBackfillBlocks
+		if maxBlocks == 0 {
+			maxBlocks = config.DefaultAddressScanMaxBackfill
+		}
+		if req.FromBlock == 0 || req.ToBlock < req.FromBlock || req.ToBlock-req.FromBlock >= maxBlocks {
+			return nil, true, fmt.Errorf("%w: %d-%d, at most %d blocks from block 1 on",
+				ErrInvalidBackfill, req.FromBlock, req.ToBlock, maxBlocks)
+		}
+		backfill = &BlockRange{Start: req.FromBlock, End: req.ToBlock}
+	}
+
+	if bw.pubkeyStore == nil || !bw.pubkeyStore.Exist(bw.chain.GetNetworkType(), req.Address) {
+		return nil, true, fmt.Errorf("%w: %s", ErrAddressNotWatched, req.Address)
+	}
+
+	scan, err := scanner.ScanAddress(ctx, req.Address)
+	if err != nil {
+		return nil, true, fmt.Errorf("scan address: %w", err)
+	}
+	if bw.config.IndexUTXO {
+		for _, event := range scannedUTXOEvents(bw.config.NetworkId, scan) {
+			if err := bw.emitter.EmitUTXO(bw.chain.GetName(), &event); err != nil {
+				return nil, true, fmt.Errorf("emit utxo event: %w", err)
+			}
+		}
+	}
+
+	if backfill != nil {
+		if m.backfills == nil {
+			return nil, true, fmt.Errorf("schedule backfill: no missing blocks store")
+		}
+		if err := m.backfills.AddMissingBlockRange(ctx, bw.chain.GetNetworkInternalCode(), backfill.Start, backfill.End); err != nil {
+			return nil, true, fmt.Errorf("schedule backfill: %w", err)
+		}
+		bw.logger.Info("Address backfill scheduled",
+			"chain", name,
+			"address", scan.Address,
+			"start", backfill.Start,
+			"end", backfill.End,
+		)
+	}
+	return &AddressDiscoveryResult{Scan: scan, Backfill: backfill}, true, nil
+}
+
+// scannedUTXOEvents groups the outputs of scan by transaction into UTXO
+// events, confirmed as of the scan's height. The block hash is not known.
+func scannedUTXOEvents(networkID string, scan *indexer.AddressScan) []types.UTXOEvent {
+	var events []types.UTXOEvent
+	byTx := make(map[string]int)
+	for _, u := range scan.UTXOs {
+		i, ok := byTx[u.TxHash]
+		if !ok {
+			i = len(events)
+			byTx[u.TxHash] = i
+			var confirmations uint64
+			if scan.Height >= u.Height {
+				confirmations = scan.Height - u.Height + 1
+			}
+			events = append(events, types.UTXOEvent{
+				TxHash:        u.TxHash,
+				NetworkId:     networkID,
+				BlockNumber:   u.Height,
+				Status:        types.StatusConfirmed,
+				Confirmations: confirmations,
+			})
+		}
+		events[i].Created = append(events[i].Created, u.UTXO)
+	}
+	return events
+}
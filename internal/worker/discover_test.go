@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/store/missingblockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanningIndexer is a stubIndexer whose address scans find scan.
+type scanningIndexer struct {
+	*stubIndexer
+	scan  indexer.AddressScan
+	scans int
+}
+
+func (s *scanningIndexer) ScanAddress(_ context.Context, address string) (*indexer.AddressScan, error) {
+	s.scans++
+	scan := s.scan
+	scan.Address = address
+	return &scan, nil
+}
+
+// recordingRangeStore records the ranges queued for the manual worker.
+type recordingRangeStore struct {
+	missingblockstore.MissingBlocksStore
+	ranges []BlockRange
+}
+
+func (r *recordingRangeStore) AddMissingBlockRange(_ context.Context, _ string, start, end uint64) error {
+	r.ranges = append(r.ranges, BlockRange{Start: start, End: end})
+	return nil
+}
+
+func TestManagerDiscoverAddress(t *testing.T) {
+	initTestLogger()
+	emitter := &recordingEmitter{}
+	chain := &scanningIndexer{
+		stubIndexer: &stubIndexer{name: "btc"},
+		scan: indexer.AddressScan{Height: 900, UTXOs: []indexer.ScannedUTXO{
+			{UTXO: types.UTXO{TxHash: "aa", Vout: 0, Amount: "1000"}, Height: 800},
+			{UTXO: types.UTXO{TxHash: "bb", Vout: 1, Amount: "2000"}, Height: 900},
+			{UTXO: types.UTXO{TxHash: "aa", Vout: 2, Amount: "3000"}, Height: 800},
+		}},
+	}
+	cfg := testChainConfig()
+	cfg.NetworkId = "bitcoin_testnet"
+	cfg.IndexUTXO = true
+	cfg.AddressScan.MaxBackfillBlocks = 1000
+
+	m := NewManager(context.Background(), nil, nil, nil, nil)
+	ranges := &recordingRangeStore{}
+	m.backfills = ranges
+	m.AddChain("btc", cfg, func(cfg config.ChainConfig) ([]Worker, error) {
+		watched := stubPubkeyStore{"tb1qaddr": true}
+		return []Worker{NewRegularWorker(context.Background(), chain, cfg, noopKVStore{}, &stubBlockStore{}, emitter, watched, nil)}, nil
+	})
+	addStatusChain(m, "eth", testChainConfig(), &reportingIndexer{stubIndexer: &stubIndexer{name: "eth"}}, 0, 0)
+
+	res, found, err := m.DiscoverAddress(context.Background(), "btc", AddressDiscovery{Address: "tb1qaddr", FromBlock: 100, ToBlock: 1099})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Len(t, res.Scan.UTXOs, 3)
+	assert.Equal(t, &BlockRange{Start: 100, End: 1099}, res.Backfill)
+	assert.Equal(t, []BlockRange{{Start: 100, End: 1099}}, ranges.ranges)
+
+	require.Len(t, emitter.utxos, 2, "one UTXO event per transaction")
+	assert.Equal(t, "aa", emitter.utxos[0].TxHash)
+	assert.Equal(t, "bitcoin_testnet", emitter.utxos[0].NetworkId)
+	assert.Equal(t, uint64(101), emitter.utxos[0].Confirmations)
+	assert.Len(t, emitter.utxos[0].Created, 2)
+	assert.Equal(t, uint64(1), emitter.utxos[1].Confirmations)
+
+	// Ranges longer than max_backfill_blocks are refused before scanning.
+	_, _, err = m.DiscoverAddress(context.Background(), "btc", AddressDiscovery{Address: "tb1qaddr", FromBlock: 100, ToBlock: 1100})
+	assert.ErrorIs(t, err, ErrInvalidBackfill)
+	assert.Equal(t, 1, chain.scans)
+
+	// Addresses not registered are refused before scanning.
+	_, found, err = m.DiscoverAddress(context.Background(), "btc", AddressDiscovery{Address: "tb1qother"})
+	assert.True(t, found)
+	assert.ErrorIs(t, err, ErrAddressNotWatched)
+	assert.Equal(t, 1, chain.scans)
+	assert.Len(t, emitter.utxos, 2)
+
+	_, found, err = m.DiscoverAddress(context.Background(), "eth", AddressDiscovery{Address: "0xaddr"})
+	assert.True(t, found)
+	assert.ErrorIs(t, err, ErrDiscoveryUnsupported)
+
+	_, found, err = m.DiscoverAddress(context.Background(), "unknown", AddressDiscovery{Address: "tb1qaddr"})
+	assert.False(t, found)
+	assert.NoError(t, err)
+}
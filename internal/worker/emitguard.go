@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// emitGuardPerBlock is how many transfer events per block of the reorg
+// window an emitGuard remembers.
+const emitGuardPerBlock = 256
+
+// emitGuard suppresses transfer events already emitted by this process,
+// e.g. when the rescanner replays a block the regular worker indexed, or a
+// reorg re-fetch returns the block that was already emitted. Events are
+// keyed by their identity, types.Transaction.Hash: the transfer's
+// TransferID in its block and direction. A transfer a reorg moves to
+// another block is therefore emitted again, and so is a transfer repeated
+// by another block, such as the duplicate coinbases of BIP30 blocks 91842
+// and 91880. The least recently emitted events are forgotten first.
+//
+// One guard is shared by the block workers of a chain.
+type emitGuard struct {
+	mu         sync.Mutex
+	capacity   int
+	order      *list.List // of keys, most recently emitted first
+	keys       map[string]*list.Element
+	suppressed uint64
+}
+
+func newEmitGuard(capacity int) *emitGuard {
+	return &emitGuard{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		keys:     make(map[string]*list.Element),
+	}
+}
+
+// emitGuardCapacity sizes a chain's emitGuard to cover the blocks a reorg
+// can roll back.
+func emitGuardCapacity(cfg config.ChainConfig) int {
+	return reorgReach(cfg) * emitGuardPerBlock
+}
+
+// duplicate reports whether tx was emitted already, counting it as
+// suppressed if so.
+func (g *emitGuard) duplicate(tx *types.Transaction) bool {
+	key := tx.Hash()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	el, ok := g.keys[key]
+	if !ok {
+		return false
+	}
+	g.order.MoveToFront(el)
+	g.suppressed++
+	return true
+}
+
+// record notes that tx was emitted.
+func (g *emitGuard) record(tx *types.Transaction) {
+	key := tx.Hash()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if el, ok := g.keys[key]; ok {
+		g.order.MoveToFront(el)
+		return
+	}
+	g.keys[key] = g.order.PushFront(key)
+	for g.order.Len() > g.capacity {
+		oldest := g.order.Back()
+		g.order.Remove(oldest)
+		delete(g.keys, oldest.Value.(string))
+	}
+}
+
+// suppressedCount returns how many events were suppressed.
+func (g *emitGuard) suppressedCount() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.suppressed
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitGuardKeysEventIdentity(t *testing.T) {
+	g := newEmitGuard(10)
+	tx := types.Transaction{NetworkId: "btc", TxHash: "a", TransferIndex: "0:0", ToAddress: "ours", BlockHash: "h1", Direction: types.DirectionIn}
+
+	require.False(t, g.duplicate(&tx))
+	g.record(&tx)
+	assert.True(t, g.duplicate(&tx))
+
+	out := tx
+	out.Direction = types.DirectionOut
+	assert.False(t, g.duplicate(&out), "the other direction is another event")
+	moved := tx
+	moved.BlockHash = "h2"
+	assert.False(t, g.duplicate(&moved), "a transfer a reorg moved to another block is emitted again")
+	pending := tx
+	pending.Confirmations = 5
+	assert.True(t, g.duplicate(&pending), "confirmations are not part of the identity")
+
+	assert.Equal(t, uint64(2), g.suppressedCount())
+}
+
+func TestEmitGuardEvictsLeastRecent(t *testing.T) {
+	g := newEmitGuard(2)
+	txs := []types.Transaction{{TxHash: "a"}, {TxHash: "b"}, {TxHash: "c"}}
+
+	g.record(&txs[0])
+	g.record(&txs[1])
+	require.True(t, g.duplicate(&txs[0]), "seeing a refreshes it")
+	g.record(&txs[2])
+
+	assert.True(t, g.duplicate(&txs[0]))
+	assert.False(t, g.duplicate(&txs[1]), "b was the least recent")
+	assert.True(t, g.duplicate(&txs[2]))
+}
+
+func TestEmitGuardCapacityCoversReorgWindow(t *testing.T) {
+	cfg := testChainConfig()
+	cfg.ReorgRollbackWindow = 100
+	// 100 blocks of rollback from the oldest of the 102 hashes kept.
+	assert.Equal(t, 203*emitGuardPerBlock, emitGuardCapacity(cfg))
+}
+
+// A block replayed, here by the rescanner after a failed sink write, is
+// written to the sink again but its transfers are not emitted twice.
+func TestBaseWorkerSuppressesReplayedTransfers(t *testing.T) {
+	s := &recordingSink{}
+	bw := sinkTestWorker(s, &stubBlockStore{})
+	bw.emitGuard = newEmitGuard(100)
+	emitter := bw.emitter.(*recordingEmitter)
+	block := func(hash string) indexer.BlockResult {
+		return indexer.BlockResult{Number: 7, Block: &types.Block{
+			Number: 7,
+			Hash:   hash,
+			Transactions: []types.Transaction{
+				{TxHash: "internal", FromAddress: "ours1", ToAddress: "ours2", BlockHash: hash},
+				{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1", BlockHash: hash},
+			},
+		}}
+	}
+
+	require.True(t, bw.handleBlockResult(block("h7")))
+	require.Len(t, emitter.txs, 3, "internal in and out, deposit in")
+
+	require.True(t, bw.handleBlockResult(block("h7")))
+	assert.Len(t, emitter.txs, 3)
+	assert.Equal(t, uint64(3), bw.emitGuard.suppressedCount())
+	require.Len(t, s.writes, 2)
+	assert.Len(t, s.writes[1], 2, "the sink still gets every matched transfer")
+
+	require.True(t, bw.handleBlockResult(block("h7b")))
+	assert.Len(t, emitter.txs, 6, "the block replaced by a reorg is emitted")
+}
+
+// Two workers of a chain share its guard: the rescanner emitting a block
+// first does not keep the regular worker from listing its transfers in a
+// reorg event.
+func TestSharedEmitGuardKeepsReorgTransferIDs(t *testing.T) {
+	g := newEmitGuard(100)
+	rescanner := sinkTestWorker(&recordingSink{}, &stubBlockStore{})
+	rescanner.emitGuard = g
+	regular := sinkTestWorker(&recordingSink{}, &stubBlockStore{})
+	regular.emitGuard = g
+	regular.emitted = newEmittedTransfers(10)
+
+	block := &types.Block{Number: 7, Hash: "h7", Transactions: []types.Transaction{
+		{NetworkId: "btc", TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1", BlockHash: "h7"},
+	}}
+	rescanner.emitBlock(context.Background(), block)
+	regular.emitBlock(context.Background(), block)
+
+	assert.Len(t, rescanner.emitter.(*recordingEmitter).txs, 1)
+	assert.Empty(t, regular.emitter.(*recordingEmitter).txs)
+	want := block.Transactions[0]
+	assert.Equal(t, []string{want.ComputeTransferID()}, regular.emitted.between(7, 7))
+}
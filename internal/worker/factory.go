@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -18,7 +19,9 @@ import (
 	"github.com/fystack/multichain-indexer/internal/rpc/sui"
 	tonrpc "github.com/fystack/multichain-indexer/internal/rpc/ton"
 	"github.com/fystack/multichain-indexer/internal/rpc/tron"
+	"github.com/fystack/multichain-indexer/internal/supply"
 	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/amount"
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
@@ -27,22 +30,37 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/model"
 	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
 	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/fystack/multichain-indexer/pkg/sink"
 	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/fystack/multichain-indexer/pkg/store/channelstore"
+	"github.com/fystack/multichain-indexer/pkg/store/missingblockstore"
+	"github.com/fystack/multichain-indexer/pkg/store/providerhealthstore"
 	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/fystack/multichain-indexer/pkg/store/responsecachestore"
 	tonaddr "github.com/xssnick/tonutils-go/address"
 	"gorm.io/gorm"
 )
 
 // WorkerDeps bundles dependencies injected into workers.
 type WorkerDeps struct {
-	Ctx        context.Context
-	KVStore    infra.KVStore
-	BlockStore blockstore.Store
-	Emitter    events.Emitter
-	Pubkey     pubkeystore.Store
-	Redis      infra.RedisClient
-	FailedChan chan FailedBlockEvent
-	Observer   BlockResultObserver
+	Ctx          context.Context
+	KVStore      infra.KVStore
+	BlockStore   blockstore.Store
+	Emitter      events.Emitter
+	Pubkey       pubkeystore.Store
+	Redis        infra.RedisClient
+	FailedChan   chan FailedBlockEvent
+	Observer     BlockResultObserver
+	Sink         sink.Sink
+	AmountFormat amount.Format
+	Supply       *supply.Tracker
+
+	// emitGuard is shared by the block workers of the chain; nil emits
+	// every event.
+	emitGuard *emitGuard
+	// heads is the chain's head source for the regular worker, see
+	// indexer.NewHeadSource.
+	heads indexer.HeadSource
 }
 
 // ManagerConfig defines which workers to enable per chain.
@@ -54,6 +72,15 @@ type ManagerConfig struct {
 	EnableManual    bool
 	Observer        BlockResultObserver
 	BloomSync       *BloomSyncConfig // nil = disabled
+	Sink            sink.Sink        // nil = disabled
+	AmountFormat    amount.Format    // empty = amount.FormatLegacy
+	// Scripts are the labels of the watched scriptPubKeys, which the
+	// watch-address service fills; nil = none watched.
+	Scripts *addressbloomfilter.ScriptSet
+	// Retired holds the addresses retired while running, which the bloom
+	// sync worker fills and the watch-address service drops addresses
+	// registered again from; nil = a set of the manager's own.
+	Retired *addressbloomfilter.RetiredSet
 }
 
 // setObserverOnWorkers injects the observer callback into each worker's BaseWorker.
@@ -81,6 +108,11 @@ const (
 	tonAssetCachePrefix                = "assetcache"
 	tonPreloadJettonResolveTimeout     = 6 * time.Second
 	tonPreloadJettonConcurrencyDefault = 8
+
+	// networkCheckTimeout bounds the startup check of a chain's nodes'
+	// network, and their warm-up; nodes not answering in time are held out
+	// of use until they do.
+	networkCheckTimeout = 15 * time.Second
 )
 
 // BuildWorkers constructs workers for a given mode.
@@ -165,16 +197,132 @@ func BuildWorkers(
 	}
 
 	setObserverOnWorkers(workers, deps.Observer)
+	setSinkOnWorkers(workers, deps.Sink)
+	setSupplyOnWorkers(workers, deps.Supply)
+	setEmitGuardOnWorkers(workers, deps.emitGuard)
+	setHeadSourceOnWorkers(workers, deps.heads)
+	setAmountFormatOnWorkers(workers, amount.NewFormatter(
+		deps.AmountFormat, cfg.Type, cfg.NativeDenom, cfg.NativeDecimals, cfg.TokenDecimals,
+	))
 	return workers
 }
 
-func newEVMProvider(chainName string, idx int, node config.NodeConfig, timeout time.Duration,
+// setSinkOnWorkers injects the transfer sink into each block worker's
+// BaseWorker. Mempool transfers are not persisted.
+func setSinkOnWorkers(workers []Worker, s sink.Sink) {
+	if s == nil {
+		return
+	}
+	for _, w := range workers {
+		switch wt := w.(type) {
+		case *RegularWorker:
+			wt.BaseWorker.sink = s
+		case *CatchupWorker:
+			wt.BaseWorker.sink = s
+		case *RescannerWorker:
+			wt.BaseWorker.sink = s
+		case *ManualWorker:
+			wt.BaseWorker.sink = s
+		}
+	}
+}
+
+// setSupplyOnWorkers injects the chain's supply tracker into each block
+// worker's BaseWorker. Mempool transactions have no block.
+func setSupplyOnWorkers(workers []Worker, t *supply.Tracker) {
+	if t == nil {
+		return
+	}
+	for _, w := range workers {
+		switch wt := w.(type) {
+		case *RegularWorker:
+			wt.BaseWorker.supply = t
+		case *CatchupWorker:
+			wt.BaseWorker.supply = t
+		case *RescannerWorker:
+			wt.BaseWorker.supply = t
+		case *ManualWorker:
+			wt.BaseWorker.supply = t
+		}
+	}
+}
+
+// setHeadSourceOnWorkers injects the chain's head source into its regular
+// worker. The source polls on the worker's poll schedule, which in turn
+// follows the source's push state.
+func setHeadSourceOnWorkers(workers []Worker, heads indexer.HeadSource) {
+	if heads == nil {
+		return
+	}
+	for _, w := range workers {
+		if rw, ok := w.(*RegularWorker); ok {
+			rw.heads = heads
+			indexer.SchedulePolls(heads, rw.poll.next)
+			if push, ok := heads.(indexer.PushSource); ok {
+				rw.poll.pushActive = push.PushActive
+			}
+		}
+	}
+}
+
+// setEmitGuardOnWorkers injects the chain's emitGuard into each block
+// worker's BaseWorker. The mempool worker tracks the transactions it has
+// seen itself.
+func setEmitGuardOnWorkers(workers []Worker, g *emitGuard) {
+	if g == nil {
+		return
+	}
+	for _, w := range workers {
+		switch wt := w.(type) {
+		case *RegularWorker:
+			wt.BaseWorker.emitGuard = g
+		case *CatchupWorker:
+			wt.BaseWorker.emitGuard = g
+		case *RescannerWorker:
+			wt.BaseWorker.emitGuard = g
+		case *ManualWorker:
+			wt.BaseWorker.emitGuard = g
+		}
+	}
+}
+
+// setAmountFormatOnWorkers injects the chain's amount formatter into each
+// worker's BaseWorker.
+func setAmountFormatOnWorkers(workers []Worker, f *amount.Formatter) {
+	for _, w := range workers {
+		switch wt := w.(type) {
+		case *RegularWorker:
+			wt.BaseWorker.amounts = f
+		case *CatchupWorker:
+			wt.BaseWorker.amounts = f
+		case *RescannerWorker:
+			wt.BaseWorker.amounts = f
+		case *ManualWorker:
+			wt.BaseWorker.amounts = f
+		case *MempoolWorker:
+			wt.BaseWorker.amounts = f
+		}
+	}
+}
+
+// failoverConfigFor returns the chain's resolved failover config, or nil
+// (built-in defaults) when the chain config did not go through the loader.
+func failoverConfigFor(chainCfg config.ChainConfig) *rpc.FailoverConfig {
+	if chainCfg.Failover == (rpc.FailoverConfig{}) {
+		return nil
+	}
+	fc := chainCfg.Failover
+	return &fc
+}
+
+func newEVMProvider(chainName string, idx int, node config.NodeConfig, clientCfg config.ClientConfig,
 	rl *ratelimiter.PooledRateLimiter) *rpc.Provider {
 	client := evm.NewEthereumClient(
 		node.URL,
 		&rpc.AuthConfig{Type: rpc.AuthType(node.Auth.Type), Key: node.Auth.Key, Value: node.Auth.Value},
-		timeout, rl,
+		clientCfg.Timeout, rl,
 	)
+	client.SetMaxResponseSize(int64(clientCfg.MaxResponseBytes))
 	if len(node.Headers) > 0 {
 		client.SetCustomHeaders(node.Headers)
 	}
@@ -190,13 +338,15 @@ func newEVMProvider(chainName string, idx int, node config.NodeConfig, timeout t
 
 // buildEVMIndexer constructs an EVM indexer with failover and providers.
 func buildEVMIndexer(chainName string, chainCfg config.ChainConfig, mode WorkerMode, pubkeyStore pubkeystore.Store) indexer.Indexer {
-	failover := rpc.NewFailover[evm.EthereumAPI](nil)
+	failover := rpc.NewFailover[evm.EthereumAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 	var traceFailover *rpc.Failover[evm.EthereumAPI]
 
 	// Main pool rate limiter
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 
 	// Trace pool rate limiter — only created when debug_trace is enabled.
 	// Dedicated budget to avoid starving main pool.
@@ -214,19 +364,22 @@ func buildEVMIndexer(chainName string, chainCfg config.ChainConfig, mode WorkerM
 		// Note: keyed by chainName (not node URL), so all trace providers for this chain
 		// share one budget. This is intentional — trace_rps/trace_burst is a chain-level
 		// cap, not per-node. Same pattern as the main rate limiter.
-		traceRL = ratelimiter.GetOrCreateScopedPooledRateLimiter(chainName, "trace", traceRPS, traceBurst)
+		traceRL = ratelimiter.GetOrCreateScopedPooledRateLimiter(chainName, "trace", traceRPS, traceBurst).
+			WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+			WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 	}
 
 	for i, node := range chainCfg.Nodes {
 		// Main pool provider
-		failover.AddProvider(newEVMProvider(chainName, i+1, node, chainCfg.Client.Timeout, rl))
+		failover.AddProvider(newEVMProvider(chainName, i+1, node, chainCfg.Client, rl))
 
 		// Trace pool: SEPARATE provider instance — no shared mutable state
 		if node.DebugTrace && chainCfg.DebugTrace {
 			if traceFailover == nil {
-				traceFailover = rpc.NewFailover[evm.EthereumAPI](nil)
+				traceFailover = rpc.NewFailover[evm.EthereumAPI](failoverConfigFor(chainCfg))
+				traceFailover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 			}
-			traceFailover.AddProvider(newEVMProvider(chainName+"-trace", i+1, node, chainCfg.Client.Timeout, traceRL))
+			traceFailover.AddProvider(newEVMProvider(chainName+"-trace", i+1, node, chainCfg.Client, traceRL))
 		}
 	}
 
@@ -239,12 +392,14 @@ func buildEVMIndexer(chainName string, chainCfg config.ChainConfig, mode WorkerM
 
 // buildTronIndexer constructs a Tron indexer with failover and providers.
 func buildTronIndexer(chainName string, chainCfg config.ChainConfig, mode WorkerMode, pubkeyStore pubkeystore.Store) indexer.Indexer {
-	failover := rpc.NewFailover[tron.TronAPI](nil)
+	failover := rpc.NewFailover[tron.TronAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	// Shared rate limiter for all workers of this chain (global across regular, catchup, etc.)
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 
 	for i, node := range chainCfg.Nodes {
 		client := tron.NewTronClient(
@@ -257,6 +412,7 @@ func buildTronIndexer(chainName string, chainCfg config.ChainConfig, mode Worker
 			chainCfg.Client.Timeout,
 			rl,
 		)
+		client.SetMaxResponseSize(int64(chainCfg.Client.MaxResponseBytes))
 
 		failover.AddProvider(&rpc.Provider{
 			Name:       chainName + "-" + strconv.Itoa(i+1),
@@ -278,12 +434,31 @@ func buildBitcoinIndexer(
 	mode WorkerMode,
 	pubkeyStore pubkeystore.Store,
 ) indexer.Indexer {
-	failover := rpc.NewFailover[bitcoin.BitcoinAPI](nil)
+	failover := rpc.NewFailover[bitcoin.BitcoinAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	// Shared rate limiter for all workers of this chain (global across regular, catchup, etc.)
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
+
+	var cache *bitcoin.ImmutableCache
+	if chainCfg.RPCCache.Enabled {
+		size := chainCfg.RPCCache.Size
+		if size == 0 {
+			size = config.DefaultRPCCacheSize
+		}
+		cache = bitcoin.NewImmutableCache(size, uint64(rollbackWindow(chainCfg)))
+	}
+
+	// Address scans go only to nodes with heavy_scans, see
+	// indexer.BitcoinIndexer.ScanAddress.
+	var scanFailover *rpc.Failover[bitcoin.BitcoinAPI]
+	scanTimeout := chainCfg.AddressScan.Timeout
+	if scanTimeout <= 0 {
+		scanTimeout = config.DefaultAddressScanTimeout
+	}
 
 	for i, node := range chainCfg.Nodes {
 		client := bitcoin.NewBitcoinClient(
@@ -296,6 +471,10 @@ func buildBitcoinIndexer(
 			chainCfg.Client.Timeout,
 			rl,
 		)
+		client.SetMaxResponseSize(int64(chainCfg.Client.MaxResponseBytes))
+		if cache != nil {
+			client.UseCache(cache)
+		}
 
 		failover.AddProvider(&rpc.Provider{
 			Name:       chainName + "-" + strconv.Itoa(i+1),
@@ -305,18 +484,52 @@ func buildBitcoinIndexer(
 			Client:     client,
 			State:      rpc.StateHealthy, // Initialize as healthy
 		})
+
+		// Scan pool: SEPARATE client whose HTTP timeout lets scantxoutset
+		// run its course
+		if node.HeavyScans {
+			if scanFailover == nil {
+				scanFailover = rpc.NewFailover[bitcoin.BitcoinAPI](failoverConfigFor(chainCfg))
+				scanFailover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
+			}
+			scanClient := bitcoin.NewBitcoinClient(
+				node.URL,
+				&rpc.AuthConfig{
+					Type:  rpc.AuthType(node.Auth.Type),
+					Key:   node.Auth.Key,
+					Value: node.Auth.Value,
+				},
+				scanTimeout,
+				rl,
+			)
+			scanFailover.AddProvider(&rpc.Provider{
+				Name:       chainName + "-scan-" + strconv.Itoa(i+1),
+				URL:        node.URL,
+				Network:    chainName,
+				ClientType: "rpc",
+				Client:     scanClient,
+				State:      rpc.StateHealthy,
+			})
+		}
 	}
 
-	return indexer.NewBitcoinIndexer(chainName, chainCfg, failover, pubkeyStore)
+	btc := indexer.NewBitcoinIndexer(chainName, chainCfg, failover, pubkeyStore)
+	btc.UseResponseCache(cache)
+	if scanFailover != nil {
+		btc.UseScanFailover(scanFailover)
+	}
+	return btc
 }
 
 // buildSolanaIndexer constructs a Solana indexer with failover and providers.
 func buildSolanaIndexer(chainName string, chainCfg config.ChainConfig, mode WorkerMode, pubkeyStore pubkeystore.Store) indexer.Indexer {
-	failover := rpc.NewFailover[solana.SolanaAPI](nil)
+	failover := rpc.NewFailover[solana.SolanaAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 
 	for i, node := range chainCfg.Nodes {
 		client := solana.NewSolanaClient(
@@ -350,7 +563,8 @@ func buildSuiIndexer(
 	mode WorkerMode,
 	pubkeyStore pubkeystore.Store,
 ) indexer.Indexer {
-	failover := rpc.NewFailover[sui.SuiAPI](nil)
+	failover := rpc.NewFailover[sui.SuiAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	for i, node := range chainCfg.Nodes {
 		client := sui.NewSuiClient(node.URL)
@@ -375,11 +589,13 @@ func buildCosmosIndexer(
 	mode WorkerMode,
 	pubkeyStore pubkeystore.Store,
 ) indexer.Indexer {
-	failover := rpc.NewFailover[cosmos.CosmosAPI](nil)
+	failover := rpc.NewFailover[cosmos.CosmosAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 
 	for i, node := range chainCfg.Nodes {
 		client := cosmos.NewCosmosClient(
@@ -413,11 +629,13 @@ func buildAptosIndexer(
 	mode WorkerMode,
 	pubkeyStore pubkeystore.Store,
 ) indexer.Indexer {
-	failover := rpc.NewFailover[aptos.AptosAPI](nil)
+	failover := rpc.NewFailover[aptos.AptosAPI](failoverConfigFor(chainCfg))
+	failover.SetLogger(indexer.ChainLogger(chainName, chainCfg))
 
 	rl := ratelimiter.GetOrCreateSharedPooledRateLimiter(
 		chainName, chainCfg.Throttle.RPS, chainCfg.Throttle.Burst,
-	)
+	).WithMaxWaitFraction(chainCfg.Throttle.MaxWaitFraction).
+		WithBudget(ratelimiter.GetBudget(chainCfg.Throttle.LimiterGroup))
 
 	for i, node := range chainCfg.Nodes {
 		client := aptos.NewAptosClient(
@@ -771,9 +989,16 @@ func CreateManagerWithWorkers(
 ) *Manager {
 	// Shared stores
 	blockStore := blockstore.NewBlockStore(kvstore)
-	pubkeyStore := pubkeystore.NewPublicKeyStore(addressBF)
+	// Addresses retired while running are dropped from matching through
+	// retired, which the bloom sync worker fills.
+	retired := managerCfg.Retired
+	if retired == nil {
+		retired = addressbloomfilter.NewRetiredSet()
+	}
+	pubkeyStore := pubkeystore.NewPublicKeyStore(addressBF, retired, managerCfg.Scripts)
 
 	manager := NewManager(ctx, kvstore, blockStore, emitter, pubkeyStore)
+	manager.backfills = missingblockstore.NewMissingBlocksStore(redisClient)
 
 	// Loop each chain
 	for _, chainName := range managerCfg.Chains {
@@ -783,71 +1008,20 @@ func CreateManagerWithWorkers(
 			continue
 		}
 
-		// Build indexer once - shared across all worker modes with global rate limiter
-		var idxr indexer.Indexer
-		switch chainCfg.Type {
-		case enum.NetworkTypeEVM:
-			idxr = buildEVMIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeTron:
-			idxr = buildTronIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeBtc:
-			idxr = buildBitcoinIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeSol:
-			idxr = buildSolanaIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeSui:
-			idxr = buildSuiIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeCosmos:
-			idxr = buildCosmosIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeApt:
-			idxr = buildAptosIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
-		case enum.NetworkTypeTon:
-			idxr = buildTonIndexer(chainName, chainCfg, pubkeyStore, db, redisClient)
-		default:
-			logger.Fatal("Unsupported network type", "chain", chainName, "type", chainCfg.Type)
-		}
-
-		failedChan := make(chan FailedBlockEvent, 100)
-
-		// Worker deps
-		deps := WorkerDeps{
-			Ctx:        ctx,
-			KVStore:    kvstore,
-			BlockStore: blockStore,
-			Emitter:    emitter,
-			Pubkey:     pubkeyStore,
-			Redis:      redisClient,
-			FailedChan: failedChan,
-			Observer:   managerCfg.Observer,
-		}
-
-		// Helper: add workers if enabled (all modes share the same indexer and global rate limiter)
-		addIfEnabled := func(mode WorkerMode, enabled bool) {
-			if enabled {
-				ws := BuildWorkers(idxr, chainCfg, mode, deps)
-				manager.AddWorkers(ws...)
-				logger.Info("Worker enabled", "chain", chainName, "mode", mode)
-			} else {
-				logger.Info("Worker disabled", "chain", chainName, "mode", mode)
-			}
-		}
-
-		addIfEnabled(ModeRegular, managerCfg.EnableRegular || cfg.Services.Worker.Regular.Enabled)
-		addIfEnabled(
-			ModeRescanner,
-			managerCfg.EnableRescanner || cfg.Services.Worker.Rescanner.Enabled,
-		)
-		addIfEnabled(ModeCatchup, managerCfg.EnableCatchup || cfg.Services.Worker.Catchup.Enabled)
-		addIfEnabled(ModeManual, managerCfg.EnableManual || cfg.Services.Worker.Manual.Enabled)
-
-		// Mempool worker is Bitcoin-specific (0-conf transaction tracking)
-		if chainCfg.Type == enum.NetworkTypeBtc {
-			addIfEnabled(ModeMempool, cfg.Services.Worker.Mempool.Enabled)
+		err = manager.AddChain(chainName, chainCfg, func(chainCfg config.ChainConfig) ([]Worker, error) {
+			return buildChainWorkers(
+				ctx, chainName, chainCfg, cfg.Services.Worker, managerCfg,
+				kvstore, blockStore, pubkeyStore, db, emitter, redisClient,
+			)
+		})
+		if err != nil {
+			logger.Fatal("Failed to start chain", "chain", chainName, "error", err)
 		}
 	}
 
 	// Bloom filter sync worker (global, not per-chain)
 	if managerCfg.BloomSync != nil && db != nil && addressBF != nil {
-		bloomWorker := NewBloomSyncWorker(ctx, addressBF, NewDefaultDBLoader(db), *managerCfg.BloomSync)
+		bloomWorker := NewBloomSyncWorker(ctx, addressBF, retired, NewDefaultDBLoader(db), *managerCfg.BloomSync)
 		manager.AddWorkers(bloomWorker)
 		logger.Info("Bloom filter sync worker enabled",
 			"interval", managerCfg.BloomSync.Interval,
@@ -857,3 +1031,245 @@ func CreateManagerWithWorkers(
 
 	return manager
 }
+
+// checkChainNetwork keeps the chain's nodes serving another network than
+// configured out of use. A node on another network would have us emit its
+// transfers as this chain's, so it fails when no node serves the
+// configured one, for the chain not to be started.
+func checkChainNetwork(ctx context.Context, chainCfg config.ChainConfig, idxr indexer.Indexer) error {
+	want, err := expectedNetwork(chainCfg)
+	if err != nil {
+		return fmt.Errorf("invalid expected network: %w", err)
+	}
+	if want.IsZero() {
+		return nil
+	}
+	checker, ok := idxr.(indexer.NetworkChecker)
+	if !ok {
+		return fmt.Errorf("expected_chain_id and expected_genesis_hash are not supported for chain type %s", chainCfg.Type)
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+	defer cancel()
+	if err := checker.ExpectNetwork(checkCtx, want); err != nil {
+		return fmt.Errorf("chain nodes do not serve the configured network: %w", err)
+	}
+	return nil
+}
+
+// warmUpChainNodes probes the chain's nodes before the workers' first
+// requests, holding those not answering out of use until they do.
+func warmUpChainNodes(ctx context.Context, idxr indexer.Indexer) {
+	warmer, ok := idxr.(indexer.NodeWarmer)
+	if !ok {
+		return
+	}
+	warmCtx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+	defer cancel()
+	warmer.WarmUp(warmCtx)
+}
+
+// expectedNetwork returns the network a chain's nodes must serve, as set by
+// expected_chain_id and expected_genesis_hash. On Bitcoin chains either
+// defaults to that of bitcoin_network.
+func expectedNetwork(chainCfg config.ChainConfig) (rpc.NetworkIdentity, error) {
+	want := rpc.NetworkIdentity{
+		ChainID:     chainCfg.ExpectedChainID,
+		GenesisHash: chainCfg.ExpectedGenesisHash,
+	}
+	if chainCfg.Type != enum.NetworkTypeBtc || chainCfg.BitcoinNetwork == "" {
+		return want, nil
+	}
+	params, err := bitcoin.ParamsFor(bitcoin.Network(chainCfg.BitcoinNetwork))
+	if err != nil {
+		return want, err
+	}
+	if want.ChainID == "" {
+		want.ChainID = params.CoreChain
+	}
+	if want.GenesisHash == "" {
+		want.GenesisHash = params.GenesisHash
+	}
+	return want, nil
+}
+
+// buildSupplyTracker returns the supply tracker of a chain with supply
+// tracking enabled, nil on chains that cannot track it. An error means the
+// chain cannot be started as configured.
+func buildSupplyTracker(chainName string, chainCfg config.ChainConfig, idxr indexer.Indexer, db *gorm.DB) (*supply.Tracker, error) {
+	if db == nil {
+		return nil, errors.New("supply tracking requires the database to be configured")
+	}
+	btc, ok := idxr.(*indexer.BitcoinIndexer)
+	if !ok {
+		logger.Warn("Supply tracking is only supported on Bitcoin chains", "chain", chainName)
+		return nil, nil
+	}
+	t, err := supply.ForChain(repository.NewSupplyRepository(db), btc, chainCfg)
+	if err != nil {
+		return nil, fmt.Errorf("supply tracking config invalid: %w", err)
+	}
+	logger.Info("Supply tracking enabled", "chain", chainName, "network_id", t.NetworkID())
+	return t, nil
+}
+
+// BuildBitcoinIndexer builds a Bitcoin chain's indexer outside a manager,
+// for tools querying its nodes such as supply reconciliation.
+func BuildBitcoinIndexer(chainName string, chainCfg config.ChainConfig) *indexer.BitcoinIndexer {
+	return buildBitcoinIndexer(chainName, chainCfg, ModeRegular, nil).(*indexer.BitcoinIndexer)
+}
+
+// BuildIndexer builds a chain's indexer outside a manager, for one-off tools
+// such as the CLI's inspection commands. Nothing is preloaded from the
+// database or Redis, and pubkeyStore may be nil for Bitcoin chains.
+func BuildIndexer(chainName string, chainCfg config.ChainConfig, pubkeyStore pubkeystore.Store) indexer.Indexer {
+	return buildIndexer(chainName, chainCfg, pubkeyStore, nil, nil)
+}
+
+func buildIndexer(
+	chainName string,
+	chainCfg config.ChainConfig,
+	pubkeyStore pubkeystore.Store,
+	db *gorm.DB,
+	redisClient infra.RedisClient,
+) indexer.Indexer {
+	var idxr indexer.Indexer
+	switch chainCfg.Type {
+	case enum.NetworkTypeEVM:
+		idxr = buildEVMIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeTron:
+		idxr = buildTronIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeBtc:
+		idxr = buildBitcoinIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeSol:
+		idxr = buildSolanaIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeSui:
+		idxr = buildSuiIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeCosmos:
+		idxr = buildCosmosIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeApt:
+		idxr = buildAptosIndexer(chainName, chainCfg, ModeRegular, pubkeyStore)
+	case enum.NetworkTypeTon:
+		idxr = buildTonIndexer(chainName, chainCfg, pubkeyStore, db, redisClient)
+	default:
+		logger.Fatal("Unsupported network type", "chain", chainName, "type", chainCfg.Type)
+	}
+	return idxr
+}
+
+// buildChainWorkers builds the indexer for a chain and the workers for every
+// enabled mode. All modes share the same indexer and global rate limiter.
+// It fails, building no worker, when the chain's nodes serve another
+// network than configured.
+func buildChainWorkers(
+	ctx context.Context,
+	chainName string,
+	chainCfg config.ChainConfig,
+	workerCfg config.WorkerConfig,
+	managerCfg ManagerConfig,
+	kvstore infra.KVStore,
+	blockStore blockstore.Store,
+	pubkeyStore pubkeystore.Store,
+	db *gorm.DB,
+	emitter events.Emitter,
+	redisClient infra.RedisClient,
+) ([]Worker, error) {
+	var workers []Worker
+
+	// Build indexer once - shared across all worker modes with global rate limiter
+	idxr := buildIndexer(chainName, chainCfg, pubkeyStore, db, redisClient)
+
+	if err := checkChainNetwork(ctx, chainCfg, idxr); err != nil {
+		return nil, err
+	}
+	warmUpChainNodes(ctx, idxr)
+
+	if btc, ok := idxr.(*indexer.BitcoinIndexer); ok && chainCfg.RPCCache.Enabled && chainCfg.RPCCache.Redis {
+		ttl := chainCfg.RPCCache.TTL
+		if ttl <= 0 {
+			ttl = config.DefaultRPCCacheTTL
+		}
+		if store := responsecachestore.New(redisClient, chainCfg.NetworkId, ttl); store != nil {
+			btc.UseResponseStore(store)
+		} else {
+			logger.Warn("RPC cache without Redis: responses are cached in memory only", "chain", chainName)
+		}
+	}
+
+	if btc, ok := idxr.(*indexer.BitcoinIndexer); ok {
+		// Probe in the background so unreachable nodes don't delay startup.
+		go func() {
+			probeCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			btc.ProbeTxIndex(probeCtx)
+		}()
+	}
+
+	if btc, ok := idxr.(*indexer.BitcoinIndexer); ok && chainCfg.Lightning.Enabled {
+		store := channelstore.New(redisClient)
+		if store == nil {
+			return nil, errors.New("lightning detection requires Redis to remember channel fundings")
+		}
+		btc.UseChannelStore(store)
+	}
+
+	var supplyTracker *supply.Tracker
+	if chainCfg.Supply.Enabled {
+		var err error
+		if supplyTracker, err = buildSupplyTracker(chainName, chainCfg, idxr, db); err != nil {
+			return nil, err
+		}
+	}
+
+	// Restore node health from the previous run so known-bad nodes stay
+	// blacklisted; a restored capability never overrides a fresh probe.
+	if p, ok := idxr.(indexer.HealthPersister); ok {
+		if store := providerhealthstore.New(redisClient); store != nil {
+			p.UseHealthStore(ctx, store)
+		}
+	}
+
+	failedChan := make(chan FailedBlockEvent, 100)
+
+	// Worker deps
+	deps := WorkerDeps{
+		Ctx:          ctx,
+		KVStore:      kvstore,
+		BlockStore:   blockStore,
+		Emitter:      emitter,
+		Pubkey:       pubkeyStore,
+		Redis:        redisClient,
+		FailedChan:   failedChan,
+		Observer:     managerCfg.Observer,
+		Sink:         managerCfg.Sink,
+		AmountFormat: managerCfg.AmountFormat,
+		Supply:       supplyTracker,
+		emitGuard:    newEmitGuard(emitGuardCapacity(chainCfg)),
+		heads:        indexer.NewHeadSource(chainName, chainCfg, idxr),
+	}
+
+	// Helper: add workers if enabled (all modes share the same indexer and global rate limiter)
+	addIfEnabled := func(mode WorkerMode, enabled bool) {
+		if enabled {
+			ws := BuildWorkers(idxr, chainCfg, mode, deps)
+			workers = append(workers, ws...)
+			logger.Info("Worker enabled", "chain", chainName, "mode", mode)
+		} else {
+			logger.Info("Worker disabled", "chain", chainName, "mode", mode)
+		}
+	}
+
+	addIfEnabled(ModeRegular, managerCfg.EnableRegular || workerCfg.Regular.Enabled)
+	addIfEnabled(
+		ModeRescanner,
+		managerCfg.EnableRescanner || workerCfg.Rescanner.Enabled,
+	)
+	addIfEnabled(ModeCatchup, managerCfg.EnableCatchup || workerCfg.Catchup.Enabled)
+	addIfEnabled(ModeManual, managerCfg.EnableManual || workerCfg.Manual.Enabled)
+
+	// Mempool worker is Bitcoin-specific (0-conf transaction tracking)
+	if chainCfg.Type == enum.NetworkTypeBtc {
+		addIfEnabled(ModeMempool, workerCfg.Mempool.Enabled)
+	}
+
+	return workers, nil
+}
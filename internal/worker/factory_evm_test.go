@@ -23,7 +23,7 @@ func TestNewEVMProvider(t *testing.T) {
 		Headers: map[string]string{"Origin": "https://test.com"},
 	}
 
-	p := newEVMProvider("ethereum", 1, node, 30*time.Second, nil)
+	p := newEVMProvider("ethereum", 1, node, config.ClientConfig{Timeout: 30 * time.Second}, nil)
 
 	assert.Equal(t, "ethereum-1", p.Name)
 	assert.Equal(t, "http://localhost:8545", p.URL)
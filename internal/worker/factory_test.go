@@ -77,7 +77,7 @@ func TestCreateManagerWithWorkersUsesPerChainFailedChannels(t *testing.T) {
 	)
 
 	channelsByChain := make(map[string]map[WorkerMode]chan FailedBlockEvent)
-	for _, worker := range manager.workers {
+	for _, worker := range manager.allWorkers() {
 		switch w := worker.(type) {
 		case *ManualWorker:
 			if channelsByChain[w.chain.GetName()] == nil {
@@ -103,6 +103,165 @@ func TestCreateManagerWithWorkersUsesPerChainFailedChannels(t *testing.T) {
 	require.True(t, channelsByChain["CHAIN-A"][ModeManual] != channelsByChain["CHAIN-B"][ModeManual])
 }
 
+func TestManagerChainEnableToggle(t *testing.T) {
+	t.Parallel()
+	initTestLogger()
+
+	disabled := false
+	chainCfg := testChainConfig()
+	chainCfg.Enabled = &disabled
+
+	builds := 0
+	m := NewManager(context.Background(), noopKVStore{}, nil, nil, nil)
+	m.AddChain("chain-a", chainCfg, func(config.ChainConfig) ([]Worker, error) {
+		builds++
+		return []Worker{&countingWorker{}}, nil
+	})
+
+	require.Zero(t, builds, "disabled chain must not build workers")
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-a"])
+
+	m.Start()
+
+	enabled := true
+	chainCfg.Enabled = &enabled
+	m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+	require.Equal(t, 1, builds)
+	require.Equal(t, ChainStateRunning, m.ChainStates()["chain-a"])
+	w := m.chains["chain-a"].workers[0].(*countingWorker)
+	require.Equal(t, 1, w.starts)
+
+	// Re-applying the same config must not rebuild.
+	m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+	require.Equal(t, 1, builds)
+
+	chainCfg.Enabled = &disabled
+	m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+	require.Equal(t, 1, w.stops)
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-a"])
+	require.Empty(t, m.allWorkers())
+}
+
+func TestManagerDisableStopsOutsideLock(t *testing.T) {
+	t.Parallel()
+	initTestLogger()
+
+	w := &blockingWorker{stopping: make(chan struct{}), release: make(chan struct{})}
+	chainCfg := testChainConfig()
+	m := NewManager(context.Background(), noopKVStore{}, nil, nil, nil)
+	m.AddChain("chain-a", chainCfg, func(config.ChainConfig) ([]Worker, error) {
+		return []Worker{w}, nil
+	})
+	m.Start()
+
+	disabled := false
+	chainCfg.Enabled = &disabled
+	applied := make(chan struct{})
+	go func() {
+		m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+		close(applied)
+	}()
+
+	<-w.stopping
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-a"], "status is served while the worker stops")
+	select {
+	case <-applied:
+		t.Fatal("ApplyChainConfigs returned before the worker exited")
+	default:
+	}
+	close(w.release)
+	<-applied
+}
+
+func TestManagerEnableBuildsOutsideLock(t *testing.T) {
+	t.Parallel()
+	initTestLogger()
+
+	disabled := false
+	chainCfg := testChainConfig()
+	chainCfg.Enabled = &disabled
+	building, release := make(chan struct{}), make(chan struct{})
+	m := NewManager(context.Background(), noopKVStore{}, nil, nil, nil)
+	m.AddChain("chain-a", chainCfg, func(config.ChainConfig) ([]Worker, error) {
+		// Stands in for the network check and warm-up of its nodes.
+		close(building)
+		<-release
+		return []Worker{&countingWorker{}}, nil
+	})
+	m.Start()
+
+	enabled := true
+	chainCfg.Enabled = &enabled
+	applied := make(chan struct{})
+	go func() {
+		m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+		close(applied)
+	}()
+
+	<-building
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-a"], "status is served while the chain builds")
+	close(release)
+	<-applied
+	require.Equal(t, ChainStateRunning, m.ChainStates()["chain-a"])
+	require.Equal(t, 1, m.chains["chain-a"].workers[0].(*countingWorker).starts)
+}
+
+func TestManagerRejectsChainFailingToBuild(t *testing.T) {
+	t.Parallel()
+	initTestLogger()
+
+	disabled := false
+	chainCfg := testChainConfig()
+	chainCfg.Enabled = &disabled
+	buildErr := errors.New("chain nodes do not serve the configured network")
+	m := NewManager(context.Background(), noopKVStore{}, nil, nil, nil)
+	m.AddChain("chain-a", chainCfg, func(config.ChainConfig) ([]Worker, error) {
+		return nil, buildErr
+	})
+	m.Start()
+
+	enabled := true
+	chainCfg.Enabled = &enabled
+	m.ApplyChainConfigs(config.Chains{"chain-a": chainCfg})
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-a"])
+	require.Empty(t, m.allWorkers())
+
+	require.ErrorIs(t, m.AddChain("chain-b", chainCfg, func(config.ChainConfig) ([]Worker, error) {
+		return nil, buildErr
+	}), buildErr)
+	require.Equal(t, ChainStateDisabled, m.ChainStates()["chain-b"])
+}
+
+func TestBuildSupplyTrackerWithoutDatabaseErrs(t *testing.T) {
+	initTestLogger()
+
+	cfg := testChainConfig()
+	cfg.Supply.Enabled = true
+	tracker, err := buildSupplyTracker("chain-a", cfg, nil, nil)
+	require.Error(t, err, "a reload enabling supply tracking must be rejected, not exit")
+	require.Nil(t, tracker)
+}
+
+// blockingWorker's Stop signals stopping, then blocks until release.
+type blockingWorker struct {
+	stopping chan struct{}
+	release  chan struct{}
+}
+
+func (w *blockingWorker) Start() {}
+func (w *blockingWorker) Stop() {
+	close(w.stopping)
+	<-w.release
+}
+
+type countingWorker struct {
+	starts int
+	stops  int
+}
+
+func (w *countingWorker) Start() { w.starts++ }
+func (w *countingWorker) Stop()  { w.stops++ }
+
 func TestRescannerFailedChannelIsolationByChain(t *testing.T) {
 	t.Parallel()
 	initTestLogger()
@@ -2,19 +2,48 @@ package worker
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/events"
 	"github.com/fystack/multichain-indexer/pkg/infra"
 	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
 	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+	"github.com/fystack/multichain-indexer/pkg/store/missingblockstore"
 	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
 )
 
 const defaultShutdownTimeout = 30 * time.Second
 
+// ChainState describes whether a chain's workers are running.
+type ChainState string
+
+const (
+	ChainStateRunning  ChainState = "running"
+	ChainStateDisabled ChainState = "disabled"
+	// ChainStateHalted is a running chain whose regular indexing stopped at
+	// a reorg deeper than its rollback window, see Manager.ResolveReorgHalt.
+	ChainStateHalted ChainState = "halted"
+)
+
+// ChainWorkerBuilder constructs the workers (and their indexer) for a chain,
+// failing when the chain's nodes cannot be used, e.g. serve another network.
+// It is only invoked while the chain is enabled, so a disabled chain opens
+// no RPC connections, and may block on the chain's nodes: on reload the
+// manager invokes it without holding its lock.
+type ChainWorkerBuilder func(cfg config.ChainConfig) ([]Worker, error)
+
+// chainWorkers tracks the workers owned by a single chain.
+type chainWorkers struct {
+	build   ChainWorkerBuilder
+	cfg     config.ChainConfig
+	workers []Worker
+	enabled bool
+}
+
 type Manager struct {
 	ctx         context.Context
 	workers     []Worker
@@ -22,6 +51,16 @@ type Manager struct {
 	blockStore  blockstore.Store
 	emitter     events.Emitter
 	pubkeyStore pubkeystore.Store
+	// backfills queues ranges for the manual workers, see DiscoverAddress.
+	backfills missingblockstore.MissingBlocksStore
+
+	mu      sync.Mutex
+	started bool
+	chains  map[string]*chainWorkers
+	// reloadMu serializes ApplyChainConfigs, which builds and stops
+	// workers outside mu, so a chain is not built again before its old
+	// workers exited.
+	reloadMu sync.Mutex
 }
 
 func NewManager(
@@ -37,12 +76,17 @@ func NewManager(
 		blockStore:  blockStore,
 		emitter:     emitter,
 		pubkeyStore: pubkeyStore,
+		chains:      make(map[string]*chainWorkers),
 	}
 }
 
 // Start launches all injected workers
 func (m *Manager) Start() {
-	for _, w := range m.workers {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.started = true
+	for _, w := range m.allWorkers() {
 		w.Start()
 	}
 }
@@ -50,10 +94,42 @@ func (m *Manager) Start() {
 // Stop shuts down all workers concurrently with a timeout, then closes resources.
 func (m *Manager) Stop() {
 	// Stop all workers concurrently with timeout
+	m.mu.Lock()
+	workers := m.allWorkers()
+	m.started = false
+	m.mu.Unlock()
+
+	if stopWorkers(workers) {
+		logger.Info("All workers stopped")
+	} else {
+		logger.Warn("Worker shutdown timed out, proceeding with resource cleanup",
+			"timeout", defaultShutdownTimeout)
+	}
+
+	// Close resources
+	m.closeResource("emitter", m.emitter, func() error { m.emitter.Close(); return nil })
+	m.closeResource("block store", m.blockStore, m.blockStore.Close)
+	m.closeResource("pubkey store", m.pubkeyStore, m.pubkeyStore.Close)
+	m.closeResource("KV store", m.kvstore, m.kvstore.Close)
+
+	// Count the last requests against the limiter groups' budgets
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ratelimiter.FlushBudgets(flushCtx)
+	cancel()
+
+	// Close all rate limiters
+	ratelimiter.CloseAllRateLimiters()
+
+	logger.Info("Manager stopped")
+}
+
+// stopWorkers stops workers concurrently and waits for them to exit, up to
+// defaultShutdownTimeout. It reports whether they all exited in time.
+func stopWorkers(workers []Worker) bool {
 	done := make(chan struct{})
 	go func() {
 		var wg sync.WaitGroup
-		for _, w := range m.workers {
+		for _, w := range workers {
 			if w != nil {
 				wg.Add(1)
 				go func(w Worker) {
@@ -68,22 +144,10 @@ func (m *Manager) Stop() {
 
 	select {
 	case <-done:
-		logger.Info("All workers stopped")
+		return true
 	case <-time.After(defaultShutdownTimeout):
-		logger.Warn("Worker shutdown timed out, proceeding with resource cleanup",
-			"timeout", defaultShutdownTimeout)
+		return false
 	}
-
-	// Close resources
-	m.closeResource("emitter", m.emitter, func() error { m.emitter.Close(); return nil })
-	m.closeResource("block store", m.blockStore, m.blockStore.Close)
-	m.closeResource("pubkey store", m.pubkeyStore, m.pubkeyStore.Close)
-	m.closeResource("KV store", m.kvstore, m.kvstore.Close)
-
-	// Close all rate limiters
-	ratelimiter.CloseAllRateLimiters()
-
-	logger.Info("Manager stopped")
 }
 
 // closeResource is a helper to close resources with consistent error handling
@@ -97,5 +161,174 @@ func (m *Manager) closeResource(name string, resource interface{}, closer func()
 
 // Inject workers into manager
 func (m *Manager) AddWorkers(workers ...Worker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.workers = append(m.workers, workers...)
 }
+
+// AddChain registers a chain with the manager. Workers are built right away
+// when the chain is enabled; a disabled chain is tracked but nothing is built
+// until it is enabled via ApplyChainConfigs. A chain failing to build is
+// tracked as disabled, and the error returned.
+func (m *Manager) AddChain(name string, cfg config.ChainConfig, build ChainWorkerBuilder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &chainWorkers{build: build, cfg: cfg}
+	m.chains[name] = cw
+	if !cfg.IsEnabled() {
+		logger.Info("Chain disabled, skipping workers", "chain", name)
+		return nil
+	}
+	workers, err := build(cfg)
+	if err != nil {
+		return err
+	}
+	cw.workers = workers
+	cw.enabled = true
+	if m.started {
+		for _, w := range cw.workers {
+			w.Start()
+		}
+	}
+	return nil
+}
+
+// ApplyChainConfigs reconciles registered chains with a reloaded config:
+// chains that became enabled get fresh workers, chains that became disabled
+// have their workers stopped, and chains still running get the settings
+// that apply without a restart, see BaseWorker.reloadChainConfig. Checkpoints are left untouched, so a chain
+// resumes where it left off when re-enabled. Chains not registered with the
+// manager are ignored, and a chain failing to build, e.g. as its nodes
+// serve another network, stays disabled. Workers are built, their nodes
+// probed, and stopped without holding up ChainStates and the other
+// readers; it returns once the stopped workers exited.
+func (m *Manager) ApplyChainConfigs(chains config.Chains) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	m.mu.Lock()
+	var (
+		enabling []string
+		stopping []Worker
+	)
+	for name, cw := range m.chains {
+		cfg, ok := chains[name]
+		if !ok {
+			continue
+		}
+		cw.cfg = cfg
+		switch {
+		case cfg.IsEnabled() && !cw.enabled:
+			enabling = append(enabling, name)
+		case !cfg.IsEnabled() && cw.enabled:
+			stopping = append(stopping, cw.workers...)
+			cw.workers = nil
+			cw.enabled = false
+			logger.Info("Chain disabled", "chain", name)
+		case cfg.IsEnabled():
+			for _, w := range cw.workers {
+				if r, ok := w.(chainConfigReloader); ok {
+					r.reloadChainConfig(cfg)
+				}
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if !stopWorkers(stopping) {
+		logger.Warn("Disabled chains' workers did not stop in time", "timeout", defaultShutdownTimeout)
+	}
+
+	// Building a chain checks and warms up its nodes, which takes as long
+	// as they take to answer.
+	for _, name := range enabling {
+		m.mu.Lock()
+		cw := m.chains[name]
+		cfg := cw.cfg
+		m.mu.Unlock()
+
+		workers, err := cw.build(cfg)
+		if err != nil {
+			logger.Error("Chain not enabled", "chain", name, "error", err)
+			continue
+		}
+
+		m.mu.Lock()
+		cw.workers = workers
+		cw.enabled = true
+		if m.started {
+			for _, w := range cw.workers {
+				w.Start()
+			}
+		}
+		m.mu.Unlock()
+		logger.Info("Chain enabled", "chain", name, "workers", len(workers))
+	}
+}
+
+// chainConfigReloader is implemented by workers taking settings from a
+// reloaded chain config while running.
+type chainConfigReloader interface {
+	reloadChainConfig(cfg config.ChainConfig)
+}
+
+// ChainStates returns the run state of every registered chain.
+func (m *Manager) ChainStates() map[string]ChainState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make(map[string]ChainState, len(m.chains))
+	for name, cw := range m.chains {
+		if cw.enabled {
+			states[name] = ChainStateRunning
+		} else {
+			states[name] = ChainStateDisabled
+		}
+	}
+	return states
+}
+
+// statsReporter is implemented by workers that expose runtime statistics.
+type statsReporter interface {
+	Stats() map[string]any
+}
+
+// ChainStats returns, per running chain, the stats its workers report.
+func (m *Manager) ChainStats() map[string]map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]map[string]any)
+	for name, cw := range m.chains {
+		for _, w := range cw.workers {
+			reporter, ok := w.(statsReporter)
+			if !ok {
+				continue
+			}
+			if stats[name] == nil {
+				stats[name] = make(map[string]any)
+			}
+			for k, v := range reporter.Stats() {
+				stats[name][k] = v
+			}
+		}
+	}
+	return stats
+}
+
+// allWorkers returns global workers followed by those of running chains.
+// Callers must hold m.mu.
+func (m *Manager) allWorkers() []Worker {
+	names := make([]string, 0, len(m.chains))
+	for name := range m.chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	workers := append([]Worker(nil), m.workers...)
+	for _, name := range names {
+		workers = append(workers, m.chains[name].workers...)
+	}
+	return workers
+}
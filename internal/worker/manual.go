@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/indexer"
@@ -122,6 +123,10 @@ func (mw *ManualWorker) loop() {
 	}
 }
 
+// errManualOverBudget stops streaming a range at a block the request budget
+// refused.
+var errManualOverBudget = errors.New("request budget refuses optional work")
+
 func (mw *ManualWorker) handleRange(ctx context.Context, start, end uint64) {
 	mw.logger.Info("Processing range",
 		"chain", mw.chain.GetName(),
@@ -129,18 +134,25 @@ func (mw *ManualWorker) handleRange(ctx context.Context, start, end uint64) {
 		"end", end,
 	)
 
-	results, err := mw.chain.GetBlocks(ctx, start, end, false)
-	if err != nil {
+	// Ranges can be arbitrarily large, so blocks are streamed in bounded
+	// windows; progress made before a failure is still recorded below.
+	lastSuccess := start - 1
+	err := indexer.StreamBlocks(indexer.WithFetchPriority(ctx, indexer.PriorityGap), mw.chain, start, end, mw.BaseWorker.config.Throttle,
+		func(res indexer.BlockResult) error {
+			if overBudget(res) {
+				return errManualOverBudget // the rest of the range waits for the budget
+			}
+			if mw.handleBlockResult(res) {
+				lastSuccess = res.Number
+			}
+			return nil
+		})
+	switch {
+	case errors.Is(err, errManualOverBudget), indexer.ErrorTypeOf(err) == indexer.ErrorTypeOverBudget:
+		mw.pauseOverBudget()
+	case err != nil:
 		mw.logger.Error("GetBlocks failed", "err", err, "chain", mw.chain.GetName())
 		time.Sleep(time.Second)
-		return
-	}
-
-	lastSuccess := start - 1
-	for _, res := range results {
-		if mw.handleBlockResult(res) {
-			lastSuccess = res.Number
-		}
 	}
 
 	mw.logger.Info("Finished processing",
@@ -72,7 +72,7 @@ func (mw *MempoolWorker) Start() {
 		"chain", mw.chain.GetName(),
 		"poll_interval", mw.pollInterval,
 	)
-	go mw.run(mw.processMempool)
+	mw.spawn(func() { mw.run(mw.processMempool) })
 }
 
 // Stop stops the mempool worker
@@ -96,16 +96,14 @@ func (mw *MempoolWorker) processMempool() error {
 	networkType := mw.chain.GetNetworkType()
 
 	for _, tx := range transactions {
-		toMonitored := tx.ToAddress != "" && mw.pubkeyStore.Exist(networkType, tx.ToAddress)
-		fromMonitored := false
-		if mw.config.TwoWayIndexing {
-			for _, addr := range tx.AllSenderAddresses() {
-				if mw.pubkeyStore.Exist(networkType, addr) {
-					fromMonitored = true
-					break
-				}
-			}
+		canonicalizeTransfer(networkType, &tx)
+		if !handleSelfTransfer(mw.config, networkType, &tx) {
+			continue
 		}
+		mw.amounts.Format(&tx)
+		match := matchTransfer(mw.pubkeyStore, networkType, &tx)
+		tx.Role = match.role()
+		toMonitored, fromMonitored := match.directions(mw.config.TwoWayIndexing, &tx)
 
 		if !toMonitored && !fromMonitored {
 			continue
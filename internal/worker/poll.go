@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+)
+
+// pollBackoff is the factor an adaptive interval grows by per quiet poll.
+const pollBackoff = 1.5
+
+// pollSchedule decides how long a worker waits between job starts: the
+// chain's PollInterval, shaped by config.PollConfig unless a push source is
+// active.
+type pollSchedule struct {
+	mu       sync.Mutex
+	base     time.Duration
+	min, max time.Duration
+	jitter   float64
+	adaptive bool
+	current  time.Duration
+
+	pushActive func() bool    // nil when the indexer has no push source
+	rand       func() float64 // uniform in [0, 1)
+}
+
+func newPollSchedule(interval time.Duration, cfg config.PollConfig, pushActive func() bool) *pollSchedule {
+	s := &pollSchedule{
+		base:       interval,
+		min:        cfg.MinInterval,
+		max:        cfg.MaxInterval,
+		jitter:     cfg.Jitter,
+		adaptive:   cfg.Adaptive,
+		current:    interval,
+		pushActive: pushActive,
+		rand:       rand.Float64,
+	}
+	if s.min <= 0 {
+		s.min = interval / 4
+	}
+	if s.max <= 0 {
+		s.max = interval * 4
+	}
+	s.max = max(s.max, s.min)
+	return s
+}
+
+// shaped reports whether jitter and adaptation apply right now.
+// Callers must hold s.mu.
+func (s *pollSchedule) shaped() bool {
+	return s.pushActive == nil || !s.pushActive()
+}
+
+// next returns the wait before the next job start.
+func (s *pollSchedule) next() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.shaped() {
+		return s.base
+	}
+	d := s.current
+	if s.jitter > 0 {
+		d += time.Duration((s.rand()*2 - 1) * s.jitter * float64(d))
+	}
+	return d
+}
+
+// observe adapts the interval to a poll's outcome: straight to min after new
+// blocks, otherwise one backoff step towards max.
+func (s *pollSchedule) observe(newBlocks bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.adaptive || !s.shaped() {
+		return
+	}
+	if newBlocks {
+		s.current = s.min
+		return
+	}
+	s.current = min(max(time.Duration(float64(s.current)*pollBackoff), s.min), s.max)
+}
+
+// isAdaptive reports whether the interval currently adapts to new blocks.
+func (s *pollSchedule) isAdaptive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.adaptive && s.shaped()
+}
+
+// interval returns the effective interval before jitter.
+func (s *pollSchedule) interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.shaped() {
+		return s.base
+	}
+	return s.current
+}
+
+// stats describes the schedule for worker stats.
+func (s *pollSchedule) stats() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shaped := s.shaped()
+	current := s.base
+	if shaped {
+		current = s.current
+	}
+	return map[string]any{
+		"poll_interval_ms": current.Milliseconds(),
+		"adaptive":         s.adaptive && shaped,
+		"jitter":           s.jitter,
+		"push_active":      !shaped,
+	}
+}
@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollSchedule_FixedByDefault(t *testing.T) {
+	s := newPollSchedule(10*time.Second, config.PollConfig{}, nil)
+	s.observe(true)
+	assert.Equal(t, 10*time.Second, s.next())
+	s.observe(false)
+	assert.Equal(t, 10*time.Second, s.next())
+}
+
+func TestPollSchedule_Jitter(t *testing.T) {
+	s := newPollSchedule(10*time.Second, config.PollConfig{Jitter: 0.2}, nil)
+	s.rand = func() float64 { return 0 }
+	assert.Equal(t, 8*time.Second, s.next())
+	s.rand = func() float64 { return 0.5 }
+	assert.Equal(t, 10*time.Second, s.next())
+	s.rand = func() float64 { return 0.75 }
+	assert.Equal(t, 11*time.Second, s.next())
+	assert.Equal(t, 10*time.Second, s.interval(), "the effective interval is reported before jitter")
+}
+
+func TestPollSchedule_AdaptiveBurstThenBackoff(t *testing.T) {
+	s := newPollSchedule(8*time.Second, config.PollConfig{Adaptive: true}, nil)
+
+	s.observe(true)
+	assert.Equal(t, 2*time.Second, s.interval(), "new block drops to min (interval/4)")
+
+	var got []time.Duration
+	for range 6 {
+		s.observe(false)
+		got = append(got, s.interval())
+	}
+	assert.Equal(t, []time.Duration{
+		3 * time.Second, 4500 * time.Millisecond, 6750 * time.Millisecond,
+		10125 * time.Millisecond, 15187500 * time.Microsecond, 22781250 * time.Microsecond,
+	}, got)
+
+	for range 5 {
+		s.observe(false)
+	}
+	assert.Equal(t, 32*time.Second, s.interval(), "quiet periods stop at max (interval*4)")
+}
+
+func TestPollSchedule_AdaptiveBounds(t *testing.T) {
+	s := newPollSchedule(10*time.Second, config.PollConfig{
+		Adaptive:    true,
+		MinInterval: 3 * time.Second,
+		MaxInterval: 12 * time.Second,
+	}, nil)
+	s.observe(true)
+	assert.Equal(t, 3*time.Second, s.interval())
+	for range 10 {
+		s.observe(false)
+	}
+	assert.Equal(t, 12*time.Second, s.interval())
+}
+
+func TestPollSchedule_PushSourceDisablesShaping(t *testing.T) {
+	push := true
+	s := newPollSchedule(10*time.Second, config.PollConfig{Adaptive: true, Jitter: 0.5},
+		func() bool { return push })
+	s.rand = func() float64 { return 0 }
+
+	s.observe(true)
+	assert.Equal(t, 10*time.Second, s.next())
+	assert.False(t, s.isAdaptive())
+	assert.Equal(t, true, s.stats()["push_active"])
+	assert.Equal(t, int64(10000), s.stats()["poll_interval_ms"])
+
+	push = false
+	assert.Equal(t, 5*time.Second, s.next(), "shaping resumes once the push source drops")
+	s.observe(true)
+	assert.Equal(t, 2500*time.Millisecond, s.interval())
+}
+
+func TestRegularWorker_QuietPollBacksOff(t *testing.T) {
+	chain := &stubIndexer{name: "test", latest: 5}
+	rw := newTestRegularWorker(chain, &stubBlockStore{}, 6, 2)
+	rw.poll = newPollSchedule(8*time.Second, config.PollConfig{Adaptive: true}, nil)
+
+	assert.NoError(t, rw.processRegularBlocks())
+	assert.Equal(t, 12*time.Second, rw.poll.interval(), "no new block backs off")
+	assert.Equal(t, int64(12000), rw.Stats()["poll_interval_ms"])
+}
+
+// headFeed is a head source handing out events.
+type headFeed chan indexer.HeadEvent
+
+func (f headFeed) Subscribe(context.Context) (<-chan indexer.HeadEvent, error) {
+	return f, nil
+}
+
+func TestRegularWorker_HeadSourceWakes(t *testing.T) {
+	rw := newTestRegularWorker(&stubIndexer{name: "test"}, &stubBlockStore{}, 1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	rw.ctx, rw.cancel = ctx, cancel
+	rw.poll = newPollSchedule(time.Hour, config.PollConfig{}, nil)
+	feed := make(headFeed, 1)
+	rw.heads = feed
+	assert.Equal(t, config.HeadSourcePoll, rw.Stats()["head_source"])
+
+	jobs := make(chan struct{}, 2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rw.run(func() error {
+			jobs <- struct{}{}
+			return nil
+		})
+	}()
+
+	feed <- indexer.HeadEvent{Height: 2, Source: config.HeadSourceWebsocket}
+	select {
+	case <-jobs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a new head did not start the job before the poll interval")
+	}
+	close(feed)
+	cancel()
+	<-done
+}
+
+func TestRegularWorker_HeadSourceStopsTimer(t *testing.T) {
+	rw := newTestRegularWorker(&stubIndexer{name: "test"}, &stubBlockStore{}, 1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	rw.ctx, rw.cancel = ctx, cancel
+	rw.poll = newPollSchedule(5*time.Millisecond, config.PollConfig{}, nil)
+	feed := make(headFeed, 1)
+	rw.heads = feed
+	backlog := 3
+	rw.BaseWorker.backlog = func() bool { return backlog > 0 }
+
+	jobs := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rw.run(func() error {
+			backlog--
+			jobs <- struct{}{}
+			return nil
+		})
+	}()
+
+	// The scheduled start, then at once twice more for the backlog.
+	for range 3 {
+		select {
+		case <-jobs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the job did not run")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, jobs, "no polls between heads while the head source is active")
+
+	feed <- indexer.HeadEvent{Height: 2, Source: config.HeadSourcePoll}
+	<-jobs
+	close(feed)
+	select {
+	case <-jobs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("polling did not resume once the head source ended")
+	}
+	cancel()
+	<-done
+}
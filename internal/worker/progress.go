@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/rpc"
+)
+
+// progressWindow is how far back blocksPerMinute looks.
+const progressWindow = 10 * time.Minute
+
+// progress tracks a worker's indexing progress for status reports.
+type progress struct {
+	mu        sync.Mutex
+	latest    uint64
+	indexed   uint64
+	lastErr   string
+	lastErrAt time.Time
+
+	// lastErrType classifies lastErr, see indexer.ErrorTypeOf. failures
+	// counts failures since blocks were last indexed, at lastSuccessAt.
+	lastErrType   indexer.ErrorType
+	failures      int
+	lastSuccessAt time.Time
+
+	// reorgs counts rollbacks; deepReorgs those of forks older than the
+	// rollback window, the last at lastDeepReorgAt.
+	reorgs          uint64
+	deepReorgs      uint64
+	lastDeepReorgAt time.Time
+
+	// minutes holds processed-block counts per wall-clock minute, keyed by
+	// Unix minute modulo its length.
+	minutes [progressWindow / time.Minute]minuteCount
+
+	now func() time.Time
+}
+
+type minuteCount struct {
+	minute int64
+	blocks int
+}
+
+func newProgress() *progress {
+	return &progress{now: time.Now}
+}
+
+// ProgressSnapshot is a point-in-time view of a worker's progress.
+type ProgressSnapshot struct {
+	LatestHeight    uint64     `json:"latest_height"`
+	IndexedHeight   uint64     `json:"indexed_height"`
+	Lag             uint64     `json:"lag"`
+	BlocksPerMinute float64    `json:"blocks_per_minute"`
+	LastSuccessAt   *time.Time `json:"last_success_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorType   string     `json:"last_error_type,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+	// ConsecutiveFailures counts failed jobs and blocks since blocks were
+	// last indexed.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// Reorgs counts rollbacks since start; DeepReorgs those of forks older
+	// than the rollback window.
+	Reorgs          uint64     `json:"reorgs,omitempty"`
+	DeepReorgs      uint64     `json:"deep_reorgs,omitempty"`
+	LastDeepReorgAt *time.Time `json:"last_deep_reorg_at,omitempty"`
+}
+
+// setLatest records the chain tip last seen.
+func (p *progress) setLatest(height uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latest = height
+}
+
+// setIndexed records the highest indexed block and how many blocks the
+// step indexed. Indexing any block resets the failure count.
+func (p *progress) setIndexed(height uint64, blocks int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.indexed = height
+	if blocks > 0 {
+		p.failures = 0
+		p.lastSuccessAt = p.now()
+	}
+
+	minute := p.now().Unix() / 60
+	slot := &p.minutes[minute%int64(len(p.minutes))]
+	if slot.minute != minute {
+		*slot = minuteCount{minute: minute}
+	}
+	slot.blocks += blocks
+}
+
+// setReorg records a reorg, deep if the fork was older than the rollback
+// window.
+func (p *progress) setReorg(deep bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reorgs++
+	if deep {
+		p.deepReorgs++
+		p.lastDeepReorgAt = p.now()
+	}
+}
+
+// setError records a failed job and returns the failure count.
+func (p *progress) setError(err error) int {
+	return p.fail(indexer.ErrorTypeOf(err), err.Error())
+}
+
+// setBlockError records a block that failed to index and returns the
+// failure count.
+func (p *progress) setBlockError(err *indexer.Error) int {
+	return p.fail(err.ErrorType, err.Message)
+}
+
+// fail records a failure. The message is served on /status, so the node
+// URLs it may quote are redacted.
+func (p *progress) fail(errType indexer.ErrorType, msg string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = rpc.RedactURLs(msg)
+	p.lastErrType = errType
+	p.lastErrAt = p.now()
+	p.failures++
+	return p.failures
+}
+
+func (p *progress) snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snap := ProgressSnapshot{
+		LatestHeight:        p.latest,
+		IndexedHeight:       p.indexed,
+		LastError:           p.lastErr,
+		LastErrorType:       string(p.lastErrType),
+		ConsecutiveFailures: p.failures,
+		Reorgs:              p.reorgs,
+		DeepReorgs:          p.deepReorgs,
+	}
+	if p.latest > p.indexed {
+		snap.Lag = p.latest - p.indexed
+	}
+	if !p.lastErrAt.IsZero() {
+		at := p.lastErrAt
+		snap.LastErrorAt = &at
+	}
+	if !p.lastSuccessAt.IsZero() {
+		at := p.lastSuccessAt
+		snap.LastSuccessAt = &at
+	}
+	if !p.lastDeepReorgAt.IsZero() {
+		at := p.lastDeepReorgAt
+		snap.LastDeepReorgAt = &at
+	}
+
+	now := p.now().Unix() / 60
+	blocks := 0
+	for _, slot := range p.minutes {
+		if now-slot.minute < int64(len(p.minutes)) {
+			blocks += slot.blocks
+		}
+	}
+	snap.BlocksPerMinute = float64(blocks) / progressWindow.Minutes()
+	return snap
+}
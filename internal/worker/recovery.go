@@ -20,10 +20,10 @@ func (bw *BaseWorker) executeRecoverable(task string, fn func() error) (err erro
 }
 
 func (bw *BaseWorker) executeWithRecovery(task string, fn func()) {
-	go func() {
+	bw.spawn(func() {
 		defer bw.recoverPanic(task)
 		fn()
-	}()
+	})
 }
 
 func (bw *BaseWorker) recoverPanic(task string) {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/indexer"
@@ -14,6 +16,8 @@ import (
 	"github.com/fystack/multichain-indexer/pkg/infra"
 	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
 	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 
@@ -33,6 +37,21 @@ type RegularWorker struct {
 	blockHashes    []blockstore.BlockHashEntry
 	hashesModified    bool
 	persistTicker  *time.Ticker
+	checkpoint     checkpoint
+	// lastReorgStart is the first block of the last rollback, 0 if none.
+	lastReorgStart uint64
+	// refetchEnd is the last block rolled back by the last reorg: blocks up
+	// to it are fetched again with indexer.PriorityReorg.
+	refetchEnd uint64
+	// backlog is set when the last tick indexed a full batch and left
+	// blocks below the chain head.
+	backlog bool
+
+	// halt is set while indexing is stopped by a reorg deeper than the
+	// rollback window, resolution once an operator resolved it.
+	haltMu     sync.Mutex
+	halt       *blockstore.ReorgHalt
+	resolution *ReorgResolution
 }
 
 func NewRegularWorker(
@@ -57,7 +76,19 @@ func NewRegularWorker(
 		failedChan,
 	)
 	rw := &RegularWorker{BaseWorker: worker}
-	rw.currentBlock = rw.determineStartingBlock()
+	rw.BaseWorker.backlog = func() bool { return rw.backlog }
+	rw.emitted = newEmittedTransfers(rw.emittedCapacity())
+	rw.loadReorgHalt()
+	if rw.halt != nil {
+		// Stay put: catching up above a halt would index past the fork.
+		rw.currentBlock = rw.halt.Block + 1
+	} else {
+		rw.currentBlock = rw.determineStartingBlock()
+	}
+	if rw.currentBlock > 0 {
+		rw.progress.setIndexed(rw.currentBlock-1, 0)
+		rw.resumeCheckpoint(rw.currentBlock - 1)
+	}
 	rw.loadBlockHashes()
 	return rw
 }
@@ -68,27 +99,71 @@ func (rw *RegularWorker) Start() {
 		"start_block", rw.currentBlock,
 	)
 	rw.persistTicker = time.NewTicker(blockHashPersistInterval)
-	go rw.runBlockHashPersist()
-	go rw.run(rw.processRegularBlocks)
+	rw.spawn(rw.runBlockHashPersist)
+	rw.spawn(func() { rw.run(rw.processRegularBlocks) })
+}
+
+// Stats reports the worker's effective poll schedule, head source and
+// checkpoint, and when it last indexed or failed and on which node.
+func (rw *RegularWorker) Stats() map[string]any {
+	stats := rw.poll.stats()
+	snap := rw.progress.snapshot()
+	stats["last_success_height"] = snap.IndexedHeight
+	stats["last_success_at"] = snap.LastSuccessAt
+	stats["last_error"] = snap.LastError
+	stats["last_error_type"] = snap.LastErrorType
+	stats["consecutive_failures"] = snap.ConsecutiveFailures
+	if reporter, ok := rw.chain.(indexer.ProviderReporter); ok {
+		stats["current_node"] = currentNode(reporter.ProviderStatuses())
+	}
+	stats["head_source"] = rw.headSource()
+	if cp := rw.checkpointStatus(); cp != nil {
+		stats["checkpoint_height"] = cp.Height
+		stats["checkpoint_lag"] = cp.Lag
+	}
+	return stats
+}
+
+// headSource returns the name of the head source in use: the push source,
+// or config.HeadSourcePoll while it is down or none is configured.
+func (rw *RegularWorker) headSource() string {
+	if reporter, ok := rw.heads.(indexer.HeadSourceReporter); ok {
+		return reporter.ActiveHeadSource()
+	}
+	return config.HeadSourcePoll
 }
 
 // Stop stops the worker and cleans up resources
 func (rw *RegularWorker) Stop() {
-	// Save current block state before stopping
-	if rw.currentBlock > 0 {
-		_ = rw.blockStore.SaveLatestBlock(rw.chain.GetNetworkInternalCode(), rw.currentBlock)
-	}
-	// Flush block hashes to KV before shutdown
-	rw.flushBlockHashes()
+	// Call base worker stop to cancel context and wait for the loops
+	rw.BaseWorker.Stop()
 	if rw.persistTicker != nil {
 		rw.persistTicker.Stop()
 	}
-	// Call base worker stop to cancel context and clean up
-	rw.BaseWorker.Stop()
+	// Write the checkpoint held back by the flush policy once the loop
+	// indexing blocks has exited
+	rw.flushCheckpoint(true)
+	// Flush block hashes to KV before shutdown
+	rw.flushBlockHashes()
 }
 
 func (rw *RegularWorker) processRegularBlocks() error {
 	rw.logger.Info("Starting tick", "currentBlock", rw.currentBlock)
+	rw.backlog = false
+	// A checkpoint held back by its policy is written once its interval
+	// passes, even while no block arrives.
+	rw.flushCheckpoint(false)
+
+	if err := rw.applyReorgResolution(); err != nil {
+		return fmt.Errorf("resolve reorg halt: %w", err)
+	}
+	if halt := rw.reorgHalt(); halt != nil {
+		rw.logger.Warn("Halted by a reorg deeper than the rollback window; awaiting operator",
+			"chain", rw.chain.GetName(),
+			"block", halt.Block,
+		)
+		return nil
+	}
 
 	latest, err := rw.chain.GetLatestBlockNumber(rw.ctx)
 	if err != nil {
@@ -96,6 +171,7 @@ func (rw *RegularWorker) processRegularBlocks() error {
 	}
 
 	rw.logger.Info("Got latest block", "latest", latest, "current", rw.currentBlock)
+	rw.progress.setLatest(latest)
 
 	// Lag detection: if we're too far behind, jump to chain head and queue skipped range for catchup
 	if rw.skipAheadIfLagging(latest) {
@@ -104,7 +180,10 @@ func (rw *RegularWorker) processRegularBlocks() error {
 
 	if rw.currentBlock > latest {
 		rw.logger.Info("Waiting for new blocks...", "current", rw.currentBlock, "latest", latest)
-		time.Sleep(rw.config.PollInterval)
+		rw.poll.observe(false)
+		if !rw.poll.isAdaptive() {
+			time.Sleep(rw.config.PollInterval)
+		}
 		return nil
 	}
 
@@ -126,11 +205,13 @@ func (rw *RegularWorker) processRegularBlocks() error {
 	originalEnd := end
 	startTime := time.Now()
 
-	results, err := rw.chain.GetBlocks(rw.ctx, rw.currentBlock, end, rw.config.Throttle.Parallel)
+	results, err := rw.chain.GetBlocks(rw.fetchCtx(), rw.currentBlock, end, rw.config.Throttle.Parallel)
 	if err != nil {
 		return fmt.Errorf("get blocks: %w", err)
 	}
 
+	// Wraps below block 0, which indexing from genesis starts at: compare
+	// lastSuccess+1 with the range start to tell whether anything indexed.
 	lastSuccess := rw.currentBlock - 1
 	var lastSuccessHash string
 	var (
@@ -141,24 +222,28 @@ func (rw *RegularWorker) processRegularBlocks() error {
 		stopTick, processErr = rw.processReorgCheckedBatch(results, end, &lastSuccess, &lastSuccessHash)
 	} else {
 		for _, res := range results {
+			if isBlockNotReady(res) {
+				rw.logger.Debug("Block not ready at tip, waiting for next poll", "block", res.Number)
+				break
+			}
 			if rw.handleBlockResult(res) {
 				lastSuccess = res.Number
 				lastSuccessHash = res.Block.Hash
+				rw.addBlockHash(res.Number, res.Block.Hash)
 			}
 		}
 	}
 
+	rw.poll.observe(lastSuccess+1 > originalStart)
+
 	if stopTick {
 		return nil
 	}
 
-	if lastSuccess >= rw.currentBlock {
+	if lastSuccess+1 > rw.currentBlock {
+		rw.progress.setIndexed(lastSuccess, int(lastSuccess-rw.currentBlock+1))
 		rw.currentBlock = lastSuccess + 1
-		_ = rw.blockStore.SaveLatestBlock(rw.chain.GetNetworkInternalCode(), lastSuccess)
-
-		if lastSuccessHash != "" {
-			rw.addBlockHash(lastSuccess, lastSuccessHash)
-		}
+		rw.advanceCheckpoint(lastSuccess)
 	}
 
 	rw.logger.Info("Processed latest blocks",
@@ -170,6 +255,7 @@ func (rw *RegularWorker) processRegularBlocks() error {
 		"expected", originalEnd-originalStart+1,
 		"got", len(results),
 	)
+	rw.backlog = processErr == nil && lastSuccess == originalEnd && originalEnd < latest
 	return processErr
 }
 
@@ -229,16 +315,22 @@ func (rw *RegularWorker) determineStartingBlock() uint64 {
 }
 
 func (rw *RegularWorker) detectAndHandleReorg(res *indexer.BlockResult) (bool, error) {
+	if res.Block.Number == 0 {
+		return false, nil // the genesis block has no parent to fork from
+	}
 	prevNum := res.Block.Number - 1
 	storedHash := rw.getBlockHash(prevNum)
 	if storedHash != "" && storedHash != res.Block.ParentHash {
-		rollbackWindow := uint64(rw.config.ReorgRollbackWindow)
-		if rollbackWindow == 0 {
-			rollbackWindow = constant.DefaultReorgRollbackWindow
-		}
+		window := uint64(rollbackWindow(rw.config))
 		reorgStart := uint64(1)
-		if prevNum > rollbackWindow {
-			reorgStart = prevNum - rollbackWindow
+		if prevNum > window {
+			reorgStart = prevNum - window
+		}
+		// The block after a rollback not linking to the hash kept below it
+		// means the fork is older than the rollback window: stop there
+		// rather than roll back past the window unattended.
+		if rw.lastReorgStart != 0 && prevNum+1 == rw.lastReorgStart {
+			return true, rw.haltForReorg(prevNum, storedHash, res.Block.ParentHash)
 		}
 		rw.logger.Warn("Reorg detected; rolling back",
 			"chain", rw.chain.GetName(),
@@ -248,33 +340,108 @@ func (rw *RegularWorker) detectAndHandleReorg(res *indexer.BlockResult) (bool, e
 			"rollback_start", reorgStart,
 			"rollback_end", prevNum,
 		)
-
-		// Clear all block hashes on reorg
-		rw.clearBlockHashes()
-
-		if err := rw.blockStore.SaveLatestBlock(rw.chain.GetNetworkInternalCode(), reorgStart-1); err != nil {
-			return true, fmt.Errorf("save latest block: %w", err)
+		if err := rw.rollBack(reorgStart, prevNum, storedHash, res.Block.ParentHash); err != nil {
+			return true, err
 		}
-		rw.currentBlock = reorgStart
+		rw.progress.setReorg(false)
 		return true, nil
 	}
 	return false, nil
 }
 
+// rollBack resumes indexing at from, voiding the blocks from..to, which
+// replaced oldHash at to with newHash.
+func (rw *RegularWorker) rollBack(from, to uint64, oldHash, newHash string) error {
+	// Void the rolled-back transfers before their replacements are
+	// emitted; on failure the next tick detects the reorg again.
+	if err := rw.emitReorg(from, to, oldHash, newHash); err != nil {
+		return fmt.Errorf("emit reorg: %w", err)
+	}
+
+	if rw.supply != nil {
+		if err := rw.supply.Rollback(rw.ctx, from); err != nil {
+			return fmt.Errorf("roll back supply: %w", err)
+		}
+	}
+	if err := rw.rollBackSink(from); err != nil {
+		return err
+	}
+	if err := rw.rollBackIndexer(from); err != nil {
+		return err
+	}
+
+	// Keep the hashes below the rollback so its first block is checked.
+	rw.dropBlockHashesFrom(from)
+
+	if err := rw.saveCheckpoint(from - 1); err != nil {
+		return fmt.Errorf("save latest block: %w", err)
+	}
+	rw.currentBlock = from
+	rw.lastReorgStart = from
+	rw.refetchEnd = to
+	rw.progress.setIndexed(from-1, 0)
+	return nil
+}
+
+// emittedCapacity is how many blocks of emitted transfers to remember: enough
+// to cover a rollback from the oldest block hash a reorg can be detected at.
+func (rw *RegularWorker) emittedCapacity() uint64 {
+	return uint64(reorgReach(rw.config))
+}
+
+// reorgReach is how many blocks below the tip a reorg can roll back: a
+// rollback window from the oldest block hash it can be detected at.
+func reorgReach(cfg config.ChainConfig) int {
+	return rollbackWindow(cfg) + blockHashCapacity(cfg) + 1
+}
+
+// blockHashCapacity is how many block hashes to keep: MaxBlockHashSize, or
+// enough to still hold the hash below a full rollback from the newest one.
+func (rw *RegularWorker) blockHashCapacity() int {
+	return blockHashCapacity(rw.config)
+}
+
+func blockHashCapacity(cfg config.ChainConfig) int {
+	return max(MaxBlockHashSize, rollbackWindow(cfg)+2)
+}
+
+// rollBackIndexer drops the indexer's state of heights from from on, see
+// indexer.RollbackObserver.
+func (rw *RegularWorker) rollBackIndexer(from uint64) error {
+	observer, ok := rw.chain.(indexer.RollbackObserver)
+	if !ok {
+		return nil
+	}
+	if err := observer.RolledBack(rw.ctx, from); err != nil {
+		return fmt.Errorf("roll back indexer state: %w", err)
+	}
+	return nil
+}
+
+func rollbackWindow(cfg config.ChainConfig) int {
+	if cfg.ReorgRollbackWindow == 0 {
+		return constant.DefaultReorgRollbackWindow
+	}
+	return cfg.ReorgRollbackWindow
+}
+
 func (rw *RegularWorker) isReorgCheckRequired() bool {
 	networkType := rw.chain.GetNetworkType()
-	return networkType == enum.NetworkTypeEVM || networkType == enum.NetworkTypeBtc
+	return networkType == enum.NetworkTypeEVM || networkType == enum.NetworkTypeBtc || networkType == enum.NetworkTypeTron
 }
 
 // addBlockHash adds a block hash to the in-memory array, maintaining max size.
+// Hashes recorded at or above blockNumber are dropped first, so a replayed
+// range replaces them and the array stays in ascending order.
 func (rw *RegularWorker) addBlockHash(blockNumber uint64, hash string) {
+	rw.dropBlockHashesFrom(blockNumber)
 	rw.blockHashes = append(rw.blockHashes, blockstore.BlockHashEntry{
 		BlockNumber: blockNumber,
 		Hash:        hash,
 	})
 
-	if len(rw.blockHashes) > MaxBlockHashSize {
-		rw.blockHashes = rw.blockHashes[len(rw.blockHashes)-MaxBlockHashSize:]
+	if size := rw.blockHashCapacity(); len(rw.blockHashes) > size {
+		rw.blockHashes = rw.blockHashes[len(rw.blockHashes)-size:]
 	}
 
 	rw.hashesModified = true
@@ -290,7 +457,15 @@ func (rw *RegularWorker) getBlockHash(blockNumber uint64) string {
 	return ""
 }
 
-// clearBlockHashes clears all block hashes (used on reorg).
+// dropBlockHashesFrom drops the hashes of blockNumber and above.
+func (rw *RegularWorker) dropBlockHashesFrom(blockNumber uint64) {
+	rw.blockHashes = slices.DeleteFunc(rw.blockHashes, func(e blockstore.BlockHashEntry) bool {
+		return e.BlockNumber >= blockNumber
+	})
+	rw.hashesModified = true
+}
+
+// clearBlockHashes clears all block hashes (used on skip-ahead).
 func (rw *RegularWorker) clearBlockHashes() {
 	rw.blockHashes = rw.blockHashes[:0]
 	rw.hashesModified = true
@@ -303,8 +478,13 @@ func (rw *RegularWorker) loadBlockHashes() {
 		rw.blockHashes = make([]blockstore.BlockHashEntry, 0, MaxBlockHashSize)
 		return
 	}
-	if len(hashes) > MaxBlockHashSize {
-		hashes = hashes[len(hashes)-MaxBlockHashSize:]
+	// Hashes at or past the resume point are from before a replay; they
+	// will be recorded again as the range is indexed.
+	hashes = slices.DeleteFunc(hashes, func(e blockstore.BlockHashEntry) bool {
+		return e.BlockNumber >= rw.currentBlock
+	})
+	if size := rw.blockHashCapacity(); len(hashes) > size {
+		hashes = hashes[len(hashes)-size:]
 	}
 	rw.blockHashes = hashes
 	rw.logger.Info("Loaded persisted block hashes",
@@ -380,7 +560,8 @@ func (rw *RegularWorker) skipAheadIfLagging(latest uint64) bool {
 	}
 
 	rw.currentBlock = latest
-	_ = rw.blockStore.SaveLatestBlock(rw.chain.GetNetworkInternalCode(), latest-1)
+	_ = rw.saveCheckpoint(latest - 1)
+	rw.progress.setIndexed(latest-1, 0)
 	rw.clearBlockHashes()
 
 	rw.logger.Info("Skip-ahead complete, queued catchup ranges",
@@ -412,6 +593,11 @@ func (rw *RegularWorker) processReorgCheckedBatch(
 			return rw.recoverRegularGap(expected, end, lastSuccess, lastSuccessHash)
 		}
 
+		if isBlockNotReady(res) {
+			rw.logger.Debug("Block not ready at tip, waiting for next poll", "block", expected)
+			return false, nil
+		}
+
 		if res.Error != nil || res.Block == nil {
 			rw.logger.Warn("Batch result unresolved, switching to single-block recovery",
 				"block", expected,
@@ -420,14 +606,8 @@ func (rw *RegularWorker) processReorgCheckedBatch(
 			return rw.recoverRegularGap(expected, end, lastSuccess, lastSuccessHash)
 		}
 
-		reorg, err := rw.detectAndHandleReorg(&res)
-		if err != nil {
-			return false, err
-		}
-		if reorg {
-			return true, nil
-		}
-
+		// A break within the batch may come from nodes disagreeing, so the
+		// block is fetched again before its predecessor is rolled back.
 		if *lastSuccessHash != "" && !matchesParentHash(*lastSuccessHash, res.Block.ParentHash) {
 			rw.logger.Warn("Batch continuity broken, switching to single-block recovery",
 				"expected", expected,
@@ -437,9 +617,18 @@ func (rw *RegularWorker) processReorgCheckedBatch(
 			return rw.recoverRegularGap(expected, end, lastSuccess, lastSuccessHash)
 		}
 
+		reorg, err := rw.detectAndHandleReorg(&res)
+		if err != nil {
+			return false, err
+		}
+		if reorg {
+			return true, nil
+		}
+
 		if rw.handleBlockResult(res) {
 			*lastSuccess = res.Number
 			*lastSuccessHash = res.Block.Hash
+			rw.addBlockHash(res.Number, res.Block.Hash)
 			expected = res.Number + 1
 		}
 	}
@@ -472,13 +661,14 @@ func (rw *RegularWorker) recoverRegularGap(
 			if errors.Is(err, errRegularRecoveryReorgHandled) {
 				return true, nil
 			}
+			if errors.Is(err, indexer.ErrBlockNotReady) {
+				rw.logger.Debug("Block not ready at tip, waiting for next poll", "block", blockNumber)
+				return false, nil
+			}
 
 			rw.handleBlockResult(indexer.BlockResult{
 				Number: blockNumber,
-				Error: &indexer.Error{
-					ErrorType: indexer.ErrorTypeUnknown,
-					Message:   err.Error(),
-				},
+				Error:  indexer.NewError(err),
 			})
 			return false, fmt.Errorf("recover block %d: %w", blockNumber, err)
 		}
@@ -496,6 +686,9 @@ func (rw *RegularWorker) recoverRegularBlock(
 
 	for attempt := 1; attempt <= regularGapRetryAttempts; attempt++ {
 		res, err := rw.fetchRegularBlock(blockNumber)
+		if errors.Is(err, indexer.ErrBlockNotReady) {
+			return err
+		}
 		if err == nil {
 			reorg, reorgErr := rw.detectAndHandleReorg(&res)
 			if reorgErr != nil {
@@ -515,6 +708,7 @@ func (rw *RegularWorker) recoverRegularBlock(
 			} else if rw.handleBlockResult(res) {
 				*lastSuccess = res.Number
 				*lastSuccessHash = res.Block.Hash
+				rw.addBlockHash(res.Number, res.Block.Hash)
 				return nil
 			} else {
 				err = fmt.Errorf("failed to process recovered block %d", blockNumber)
@@ -541,20 +735,40 @@ func (rw *RegularWorker) recoverRegularBlock(
 	return lastErr
 }
 
+// fetchCtx returns the context to fetch blocks from currentBlock with: at
+// the tip's priority, or the reorg's while re-fetching rolled back blocks.
+func (rw *RegularWorker) fetchCtx() context.Context {
+	if rw.currentBlock <= rw.refetchEnd {
+		return indexer.WithFetchPriority(rw.ctx, indexer.PriorityReorg)
+	}
+	return indexer.WithFetchPriority(rw.ctx, indexer.PriorityTip)
+}
+
+// fetchRegularBlock fetches blockNumber alone. The result's trace span
+// covers the fetch, as GetBlocks' do; a failed fetch ends it.
 func (rw *RegularWorker) fetchRegularBlock(blockNumber uint64) (indexer.BlockResult, error) {
-	block, err := rw.chain.GetBlock(rw.ctx, blockNumber)
+	ctx, span := tracing.Start(rw.fetchCtx(), "block", attribute.Int64("block.number", int64(blockNumber)))
+	block, err := rw.chain.GetBlock(ctx, blockNumber)
+	if err == nil && block == nil {
+		err = fmt.Errorf("nil block result for %d", blockNumber)
+	}
 	if err != nil {
+		tracing.End(span, err)
 		return indexer.BlockResult{Number: blockNumber}, err
 	}
-	if block == nil {
-		return indexer.BlockResult{Number: blockNumber}, fmt.Errorf("nil block result for %d", blockNumber)
-	}
 	return indexer.BlockResult{
 		Number: blockNumber,
 		Block:  block,
+		Span:   span,
 	}, nil
 }
 
+// isBlockNotReady reports whether res failed only because the block is not
+// yet available at the chain tip.
+func isBlockNotReady(res indexer.BlockResult) bool {
+	return res.Error != nil && res.Error.ErrorType == indexer.ErrorTypeBlockNotReady
+}
+
 func checkContinuity(prev, curr indexer.BlockResult) bool {
 	if prev.Error != nil || curr.Error != nil || prev.Block == nil || curr.Block == nil {
 		return false
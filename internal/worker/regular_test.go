@@ -3,8 +3,10 @@ package worker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 	"testing"
 	"time"
 
@@ -117,6 +119,154 @@ func TestRegularWorkerProcessRegularBlocksMarksUnresolvedGapFailed(t *testing.T)
 	require.Equal(t, []uint64{100, 100}, chain.getBlockCalls)
 }
 
+func TestRegularWorkerProcessRegularBlocksWaitsOnBlockNotReady(t *testing.T) {
+	t.Parallel()
+
+	chain := &stubIndexer{
+		name:         "bitcoin",
+		internalCode: "btc",
+		networkType:  enum.NetworkTypeBtc,
+		latest:       101,
+		getBlocksFunc: func(context.Context, uint64, uint64, bool) ([]indexer.BlockResult, error) {
+			return []indexer.BlockResult{
+				{
+					Number: 100,
+					Block: &types.Block{
+						Number:     100,
+						Hash:       "0x100",
+						ParentHash: "0x099",
+					},
+				},
+				{
+					Number: 101,
+					Error:  &indexer.Error{ErrorType: indexer.ErrorTypeBlockNotReady, Message: "block not ready"},
+				},
+			}, nil
+		},
+	}
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(chain, store, 100, 2)
+
+	err := rw.processRegularBlocks()
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), rw.currentBlock)
+	require.Equal(t, []uint64{100}, store.savedLatest)
+	require.Empty(t, store.failedBlocks)
+	require.Empty(t, chain.getBlockCalls, "not-ready block must not trigger single-block recovery")
+}
+
+func TestRegularWorkerProcessRegularBlocksRecordsEveryBlockHash(t *testing.T) {
+	t.Parallel()
+
+	chain := &stubIndexer{
+		name:         "ethereum",
+		internalCode: "eth",
+		networkType:  enum.NetworkTypeEVM,
+		latest:       102,
+		getBlocksFunc: func(context.Context, uint64, uint64, bool) ([]indexer.BlockResult, error) {
+			return []indexer.BlockResult{
+				{Number: 100, Block: &types.Block{Number: 100, Hash: "0x100", ParentHash: "0x099"}},
+				{Number: 101, Block: &types.Block{Number: 101, Hash: "0x101", ParentHash: "0x100"}},
+				{Number: 102, Block: &types.Block{Number: 102, Hash: "0x102", ParentHash: "0x101"}},
+			}, nil
+		},
+	}
+	rw := newTestRegularWorker(chain, &stubBlockStore{}, 100, 3)
+
+	require.NoError(t, rw.processRegularBlocks())
+	require.Equal(t, "0x100", rw.getBlockHash(100))
+	require.Equal(t, "0x101", rw.getBlockHash(101))
+	require.Equal(t, "0x102", rw.getBlockHash(102))
+}
+
+func TestRegularWorkerProcessRegularBlocksReportsBacklog(t *testing.T) {
+	t.Parallel()
+
+	chain := &stubIndexer{
+		name:         "ethereum",
+		internalCode: "eth",
+		networkType:  enum.NetworkTypeEVM,
+		latest:       102,
+		getBlocksFunc: func(_ context.Context, from, to uint64, _ bool) ([]indexer.BlockResult, error) {
+			var results []indexer.BlockResult
+			for n := from; n <= to; n++ {
+				block := &types.Block{Number: n, Hash: fmt.Sprintf("0x%d", n), ParentHash: fmt.Sprintf("0x%d", n-1)}
+				results = append(results, indexer.BlockResult{Number: n, Block: block})
+			}
+			return results, nil
+		},
+	}
+	rw := newTestRegularWorker(chain, &stubBlockStore{}, 100, 2)
+
+	require.NoError(t, rw.processRegularBlocks())
+	require.True(t, rw.backlog, "block 102 is left below the head")
+	require.NoError(t, rw.processRegularBlocks())
+	require.Equal(t, uint64(103), rw.currentBlock)
+	require.False(t, rw.backlog)
+}
+
+// TestRegularWorkerProcessRegularBlocksRollsBackOnBrokenParent checks a block
+// whose parent still differs from the hash recorded for its predecessor once
+// refetched rolls the worker back instead of being indexed or marked failed.
+func TestRegularWorkerProcessRegularBlocksRollsBackOnBrokenParent(t *testing.T) {
+	t.Parallel()
+
+	forked := &types.Block{Number: 101, Hash: "0x101b", ParentHash: "0x100b"}
+	chain := &stubIndexer{
+		name:         "ethereum",
+		internalCode: "eth",
+		networkType:  enum.NetworkTypeEVM,
+		latest:       101,
+		getBlocksFunc: func(context.Context, uint64, uint64, bool) ([]indexer.BlockResult, error) {
+			return []indexer.BlockResult{
+				{Number: 100, Block: &types.Block{Number: 100, Hash: "0x100", ParentHash: "0x099"}},
+				{Number: 101, Block: forked},
+			}, nil
+		},
+		getBlockFunc: func(context.Context, uint64) (*types.Block, error) {
+			return forked, nil
+		},
+	}
+	store := &stubBlockStore{}
+	emitter := &recordingEmitter{}
+	rw := newTestRegularWorker(chain, store, 100, 2)
+	rw.emitter = emitter
+	rw.config.ReorgRollbackWindow = 1
+
+	require.NoError(t, rw.processRegularBlocks())
+	require.Equal(t, []uint64{101}, chain.getBlockCalls, "the block is refetched before rolling back")
+	require.Len(t, emitter.reorgs, 1)
+	require.Equal(t, "0x100", emitter.reorgs[0].OldHash)
+	require.Equal(t, "0x100b", emitter.reorgs[0].NewHash)
+	require.Equal(t, uint64(99), rw.currentBlock)
+	require.Equal(t, []uint64{98}, store.savedLatest)
+	require.Empty(t, store.failedBlocks)
+	require.Empty(t, rw.blockHashes)
+}
+
+func TestRegularWorkerBlockHashesOnReplay(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{hashes: []blockstore.BlockHashEntry{
+		{BlockNumber: 99, Hash: "0x099"},
+		{BlockNumber: 100, Hash: "0x100"},
+		{BlockNumber: 101, Hash: "0x101"},
+	}}
+	rw := newTestRegularWorker(&stubIndexer{internalCode: "eth"}, store, 100, 2)
+
+	rw.loadBlockHashes()
+	require.Equal(t, "0x099", rw.getBlockHash(99))
+	require.Empty(t, rw.getBlockHash(100), "hashes past the resume point are dropped")
+
+	rw.addBlockHash(100, "0x100")
+	rw.addBlockHash(101, "0x101")
+	rw.addBlockHash(100, "0x100b")
+	require.Equal(t, []blockstore.BlockHashEntry{
+		{BlockNumber: 99, Hash: "0x099"},
+		{BlockNumber: 100, Hash: "0x100b"},
+	}, rw.blockHashes)
+}
+
 func TestCheckContinuityReturnsFalseForNilBlocks(t *testing.T) {
 	t.Parallel()
 
@@ -166,6 +316,8 @@ func newTestRegularWorker(chain *stubIndexer, store *stubBlockStore, currentBloc
 			chain:      chain,
 			blockStore: store,
 			failedChan: make(chan FailedBlockEvent, 1),
+			poll:       newPollSchedule(cfg.PollInterval, cfg.Poll, nil),
+			progress:   newProgress(),
 		},
 		currentBlock: currentBlock,
 		blockHashes:  make([]blockstore.BlockHashEntry, 0, MaxBlockHashSize),
@@ -224,6 +376,8 @@ func (s *stubIndexer) IsHealthy() bool {
 type stubBlockStore struct {
 	savedLatest  []uint64
 	failedBlocks []uint64
+	hashes       []blockstore.BlockHashEntry
+	halt         *blockstore.ReorgHalt
 }
 
 func (s *stubBlockStore) GetLatestBlock(string) (uint64, error) {
@@ -269,13 +423,128 @@ func (s *stubBlockStore) DeleteCatchupRange(string, uint64, uint64) error {
 }
 
 func (s *stubBlockStore) GetBlockHashes(string) ([]blockstore.BlockHashEntry, error) {
-	return nil, nil
+	return s.hashes, nil
 }
 
 func (s *stubBlockStore) SaveBlockHashes(string, []blockstore.BlockHashEntry) error {
 	return nil
 }
 
+func (s *stubBlockStore) SaveReorgHalt(_ string, halt blockstore.ReorgHalt) error {
+	s.halt = &halt
+	return nil
+}
+
+func (s *stubBlockStore) GetReorgHalt(string) (*blockstore.ReorgHalt, error) {
+	return s.halt, nil
+}
+
+func (s *stubBlockStore) DeleteReorgHalt(string) error {
+	s.halt = nil
+	return nil
+}
+
 func (s *stubBlockStore) Close() error {
 	return nil
 }
+
+// rollbackObservingIndexer records the heights RolledBack is called with.
+type rollbackObservingIndexer struct {
+	*stubIndexer
+	rolledBack []uint64
+}
+
+func (r *rollbackObservingIndexer) RolledBack(_ context.Context, from uint64) error {
+	r.rolledBack = append(r.rolledBack, from)
+	return nil
+}
+
+func TestRegularWorkerRollBackNotifiesIndexer(t *testing.T) {
+	t.Parallel()
+
+	chain := &rollbackObservingIndexer{stubIndexer: &stubIndexer{name: "bitcoin", internalCode: "btc"}}
+	rw := newTestRegularWorker(chain.stubIndexer, &stubBlockStore{}, 100, 2)
+	rw.chain = chain
+	rw.emitter = &recordingEmitter{}
+
+	require.NoError(t, rw.rollBack(95, 99, "old", "new"))
+	require.Equal(t, []uint64{95}, chain.rolledBack, "cached state of rolled-back heights is dropped")
+}
+
+func TestRegularWorkerRollBackOrphansSinkTransfers(t *testing.T) {
+	t.Parallel()
+
+	rw := newTestRegularWorker(&stubIndexer{name: "bitcoin", internalCode: "btc"}, &stubBlockStore{}, 100, 2)
+	rw.emitter = &recordingEmitter{}
+	s := &recordingSink{}
+	rw.sink = s
+
+	require.NoError(t, rw.rollBack(95, 99, "old", "new"))
+	require.Equal(t, []uint64{95}, s.rolledBack)
+
+	s.err = errors.New("db down")
+	require.ErrorContains(t, rw.rollBack(95, 99, "old", "new"), "roll back sink")
+}
+
+// genesisIndexer serves a chain from its genesis block, which has no parent,
+// failing the blocks in failing.
+func genesisIndexer(latest uint64, failing ...uint64) *stubIndexer {
+	fails := func(n uint64) bool { return slices.Contains(failing, n) }
+	block := func(n uint64) *types.Block {
+		b := &types.Block{Number: n, Hash: fmt.Sprintf("0x%d", n)}
+		if n > 0 {
+			b.ParentHash = fmt.Sprintf("0x%d", n-1)
+		}
+		return b
+	}
+	return &stubIndexer{
+		name:         "bitcoin",
+		internalCode: "btc",
+		networkType:  enum.NetworkTypeBtc,
+		latest:       latest,
+		getBlocksFunc: func(_ context.Context, from, to uint64, _ bool) ([]indexer.BlockResult, error) {
+			var results []indexer.BlockResult
+			for n := from; n <= to; n++ {
+				if fails(n) {
+					results = append(results, indexer.BlockResult{
+						Number: n,
+						Error:  &indexer.Error{ErrorType: indexer.ErrorTypeUnknown, Message: "rpc timeout"},
+					})
+					continue
+				}
+				results = append(results, indexer.BlockResult{Number: n, Block: block(n)})
+			}
+			return results, nil
+		},
+		getBlockFunc: func(_ context.Context, n uint64) (*types.Block, error) {
+			if fails(n) {
+				return nil, errors.New("rpc timeout")
+			}
+			return block(n), nil
+		},
+	}
+}
+
+func TestRegularWorkerProcessRegularBlocksFromGenesis(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(genesisIndexer(5), store, 0, 3)
+
+	require.NoError(t, rw.processRegularBlocks())
+	require.Equal(t, uint64(3), rw.currentBlock)
+	require.Equal(t, []uint64{2}, store.savedLatest)
+	require.Empty(t, store.failedBlocks)
+}
+
+func TestRegularWorkerProcessRegularBlocksGenesisFailure(t *testing.T) {
+	t.Parallel()
+
+	store := &stubBlockStore{}
+	rw := newTestRegularWorker(genesisIndexer(5, 0), store, 0, 3)
+
+	require.Error(t, rw.processRegularBlocks())
+	require.Equal(t, uint64(0), rw.currentBlock, "nothing is indexed before block 0")
+	require.Empty(t, store.savedLatest)
+	require.Equal(t, []uint64{0}, store.failedBlocks)
+}
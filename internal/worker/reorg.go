@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/sink"
+)
+
+// emittedTransfers remembers the IDs of the transfers emitted for recent
+// blocks, so a reorg event can list the transfers it voids. It holds the
+// last capacity heights below the highest one seen.
+type emittedTransfers struct {
+	capacity uint64
+	highest  uint64
+	blocks   map[uint64][]string
+}
+
+func newEmittedTransfers(capacity uint64) *emittedTransfers {
+	return &emittedTransfers{capacity: capacity, blocks: make(map[uint64][]string)}
+}
+
+// add records that transferID was emitted for block.
+func (e *emittedTransfers) add(block uint64, transferID string) {
+	if slices.Contains(e.blocks[block], transferID) {
+		return // emitted in both directions
+	}
+	e.blocks[block] = append(e.blocks[block], transferID)
+
+	if block > e.highest {
+		e.highest = block
+		for n := range e.blocks {
+			if n+e.capacity <= e.highest {
+				delete(e.blocks, n)
+			}
+		}
+	}
+}
+
+// between returns the transfer IDs recorded for from..to, by height.
+func (e *emittedTransfers) between(from, to uint64) []string {
+	ids := []string{}
+	for n := from; n <= to; n++ {
+		ids = append(ids, e.blocks[n]...)
+	}
+	return ids
+}
+
+// drop forgets every block from from on.
+func (e *emittedTransfers) drop(from uint64) {
+	for n := range e.blocks {
+		if n >= from {
+			delete(e.blocks, n)
+		}
+	}
+	e.highest = min(e.highest, from-1)
+}
+
+// emitReorg publishes the rollback of from..to, which replaced oldHash at
+// to with newHash, listing the transfers emitted for those blocks.
+func (rw *RegularWorker) emitReorg(from, to uint64, oldHash, newHash string) error {
+	if rw.emitted == nil {
+		rw.emitted = newEmittedTransfers(rw.emittedCapacity())
+	}
+	reorg := &types.BlockReorg{
+		NetworkId:    rw.config.NetworkId,
+		InternalCode: rw.config.InternalCode,
+		FromBlock:    from,
+		ToBlock:      to,
+		OldHash:      oldHash,
+		NewHash:      newHash,
+		TransferIDs:  rw.emitted.between(from, to),
+		DetectedAt:   time.Now().Unix(),
+	}
+	if err := rw.emitter.EmitReorg(rw.chain.GetName(), reorg); err != nil {
+		return err
+	}
+	rw.emitted.drop(from)
+	return nil
+}
+
+// rollBackSink orphans the transfers the sink stored for the blocks from
+// from on, if it is a sink.Rollbacker.
+func (rw *RegularWorker) rollBackSink(from uint64) error {
+	rb, ok := rw.sink.(sink.Rollbacker)
+	if !ok {
+		return nil
+	}
+	if err := rb.RollBack(rw.ctx, rw.config.NetworkId, from); err != nil {
+		return fmt.Errorf("roll back sink: %w", err)
+	}
+	return nil
+}
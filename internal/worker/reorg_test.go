@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmittedTransfers(t *testing.T) {
+	e := newEmittedTransfers(3)
+	e.add(10, "a")
+	e.add(10, "a")
+	e.add(11, "b")
+	e.add(12, "c")
+	e.add(12, "d")
+	assert.Equal(t, []string{"a", "b", "c", "d"}, e.between(10, 12))
+
+	e.add(13, "e")
+	assert.Equal(t, []string{"b", "c", "d", "e"}, e.between(10, 13), "block 10 fell out of the window")
+
+	e.drop(12)
+	assert.Equal(t, []string{"b"}, e.between(10, 13))
+	e.add(12, "f")
+	assert.Equal(t, []string{"b", "f"}, e.between(10, 13), "re-indexed blocks are recorded again")
+	assert.Equal(t, []string{}, e.between(20, 21))
+}
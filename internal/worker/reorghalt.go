@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+)
+
+var (
+	// ErrChainNotHalted is returned when resolving a reorg halt of a chain
+	// that is not halted.
+	ErrChainNotHalted = errors.New("chain not halted by a reorg")
+	// ErrChainNotRunning is returned when resolving a reorg halt of a chain
+	// that is not registered or not running.
+	ErrChainNotRunning = errors.New("chain not running")
+)
+
+// ReorgResolution is an operator's way out of a reorg halt: a one-time
+// rollback deeper than the rollback window, or a resync from a chosen
+// height.
+type ReorgResolution struct {
+	// Depth rolls back this many blocks below the halted block, once; the
+	// rollback window if 0. Should the fork be older still, the chain
+	// halts again at the block after the rollback.
+	Depth uint64 `json:"depth,omitempty"`
+	// ResyncFrom, when set, resumes indexing at this height instead,
+	// forgetting every recorded block hash.
+	ResyncFrom uint64 `json:"resync_from,omitempty"`
+}
+
+// ResolveReorgHalt resumes the named chain, halted by a reorg deeper than
+// its rollback window, as res directs. The regular worker applies it on its
+// next tick.
+func (m *Manager) ResolveReorgHalt(name string, res ReorgResolution) error {
+	m.mu.Lock()
+	cw, ok := m.chains[name]
+	var workers []Worker
+	if ok && cw.enabled {
+		workers = append(workers, cw.workers...)
+	}
+	m.mu.Unlock()
+
+	for _, w := range workers {
+		if rw, ok := w.(*RegularWorker); ok {
+			return rw.resolveReorgHalt(res)
+		}
+	}
+	return ErrChainNotRunning
+}
+
+// loadReorgHalt restores a halt persisted before a restart.
+func (rw *RegularWorker) loadReorgHalt() {
+	halt, err := rw.blockStore.GetReorgHalt(rw.chain.GetNetworkInternalCode())
+	if err != nil {
+		rw.logger.Error("Failed to load reorg halt",
+			"chain", rw.chain.GetName(),
+			"error", err,
+		)
+		return
+	}
+	if halt != nil {
+		rw.logger.Error("Chain halted by a reorg deeper than the rollback window; awaiting operator",
+			"chain", rw.chain.GetName(),
+			"block", halt.Block,
+			"halted_at", halt.HaltedAt,
+		)
+	}
+	rw.halt = halt
+}
+
+// reorgHalt returns the worker's halt, nil if it is indexing.
+func (rw *RegularWorker) reorgHalt() *blockstore.ReorgHalt {
+	rw.haltMu.Lock()
+	defer rw.haltMu.Unlock()
+	if rw.halt == nil {
+		return nil
+	}
+	halt := *rw.halt
+	return &halt
+}
+
+// haltForReorg stops indexing above block, whose recorded hash is not the
+// parent of the block above it although the rollback window was already
+// rolled back. The halt is persisted along with the block hashes, so it
+// holds across restarts.
+func (rw *RegularWorker) haltForReorg(block uint64, expectedParent, actualParent string) error {
+	halt := blockstore.ReorgHalt{
+		Block:          block,
+		ExpectedParent: expectedParent,
+		ActualParent:   actualParent,
+		RollbackWindow: rollbackWindow(rw.config),
+		HaltedAt:       time.Now().UTC(),
+	}
+	rw.logger.Error("Reorg deeper than the rollback window; halting until an operator resolves it",
+		"chain", rw.chain.GetName(),
+		"block", block,
+		"expected_parent", expectedParent,
+		"actual_parent", actualParent,
+		"rollback_window", halt.RollbackWindow,
+	)
+	rw.haltMu.Lock()
+	rw.halt = &halt
+	rw.haltMu.Unlock()
+	rw.progress.setReorg(true)
+
+	rw.flushBlockHashes()
+	if err := rw.blockStore.SaveReorgHalt(rw.chain.GetNetworkInternalCode(), halt); err != nil {
+		return fmt.Errorf("save reorg halt: %w", err)
+	}
+	return nil
+}
+
+// resolveReorgHalt queues res for the next tick.
+func (rw *RegularWorker) resolveReorgHalt(res ReorgResolution) error {
+	rw.haltMu.Lock()
+	defer rw.haltMu.Unlock()
+	if rw.halt == nil {
+		return ErrChainNotHalted
+	}
+	rw.resolution = &res
+	return nil
+}
+
+// applyReorgResolution applies the resolution queued by an operator, if
+// any, and lifts the halt.
+func (rw *RegularWorker) applyReorgResolution() error {
+	rw.haltMu.Lock()
+	halt, res := rw.halt, rw.resolution
+	rw.haltMu.Unlock()
+	if halt == nil || res == nil {
+		return nil
+	}
+
+	if res.ResyncFrom > 0 {
+		rw.logger.Warn("Resyncing chain halted by a deep reorg",
+			"chain", rw.chain.GetName(),
+			"halted_block", halt.Block,
+			"resync_from", res.ResyncFrom,
+		)
+		if err := rw.resync(res.ResyncFrom, halt); err != nil {
+			return err
+		}
+	} else {
+		depth := res.Depth
+		if depth == 0 {
+			depth = uint64(halt.RollbackWindow)
+		}
+		start := uint64(1)
+		if halt.Block > depth {
+			start = halt.Block - depth
+		}
+		rw.logger.Warn("Rolling back chain halted by a deep reorg",
+			"chain", rw.chain.GetName(),
+			"rollback_start", start,
+			"rollback_end", halt.Block,
+		)
+		if err := rw.rollBack(start, halt.Block, halt.ExpectedParent, halt.ActualParent); err != nil {
+			return err
+		}
+	}
+
+	if err := rw.blockStore.DeleteReorgHalt(rw.chain.GetNetworkInternalCode()); err != nil {
+		return fmt.Errorf("delete reorg halt: %w", err)
+	}
+	rw.haltMu.Lock()
+	rw.halt, rw.resolution = nil, nil
+	rw.haltMu.Unlock()
+	return nil
+}
+
+// resync resumes indexing at from, voiding the transfers emitted for the
+// blocks from it up to the halted block.
+func (rw *RegularWorker) resync(from uint64, halt *blockstore.ReorgHalt) error {
+	if from <= halt.Block {
+		if err := rw.emitReorg(from, halt.Block, halt.ExpectedParent, halt.ActualParent); err != nil {
+			return fmt.Errorf("emit reorg: %w", err)
+		}
+		if rw.supply != nil {
+			if err := rw.supply.Rollback(rw.ctx, from); err != nil {
+				return fmt.Errorf("roll back supply: %w", err)
+			}
+		}
+		if err := rw.rollBackSink(from); err != nil {
+			return err
+		}
+		if err := rw.rollBackIndexer(from); err != nil {
+			return err
+		}
+	}
+	rw.clearBlockHashes()
+	if from > 1 {
+		if err := rw.saveCheckpoint(from - 1); err != nil {
+			return fmt.Errorf("save latest block: %w", err)
+		}
+	}
+	rw.currentBlock = from
+	rw.lastReorgStart = 0
+	rw.progress.setIndexed(from-1, 0)
+	return nil
+}
@@ -81,7 +81,7 @@ func (rw *RescannerWorker) Start() {
 	})
 
 	// periodic rescan
-	go rw.run(rw.processRescan)
+	rw.spawn(func() { rw.run(rw.processRescan) })
 
 	// periodic flush
 	rw.executeWithRecovery("rescanner flush loop", rw.periodicBatchFlush)
@@ -188,15 +188,24 @@ func (rw *RescannerWorker) processBatch(blocks []uint64) error {
 		blocks = blocks[:batchSize]
 	}
 
-	results, err := rw.chain.GetBlocksByNumbers(rw.ctx, blocks)
+	results, err := rw.chain.GetBlocksByNumbers(indexer.WithFetchPriority(rw.ctx, indexer.PriorityGap), blocks)
+	if indexer.ErrorTypeOf(err) == indexer.ErrorTypeOverBudget {
+		rw.pauseOverBudget()
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("rescanner get blocks: %w", err)
 	}
 
 	var toRemove []uint64
 	success := 0
+	refused := false
 
 	for _, res := range results {
+		if overBudget(res) {
+			refused = true // not an attempt: retried as is once the budget allows
+			continue
+		}
 		if res.Error != nil && res.Error.ErrorType == indexer.ErrorTypeBlockNotFound && rw.chain.GetNetworkType() == enum.NetworkTypeSol {
 			// Solana skipped slots are normal. Do not retry them.
 			toRemove = append(toRemove, res.Number)
@@ -219,6 +228,9 @@ func (rw *RescannerWorker) processBatch(blocks []uint64) error {
 		"success", success,
 		"remaining", len(rw.failedBlocks),
 	)
+	if refused {
+		rw.pauseOverBudget()
+	}
 	return nil
 }
 
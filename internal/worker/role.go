@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"slices"
+
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+)
+
+// transferMatch records which sides of a transfer hit the pubkey store.
+type transferMatch struct {
+	to             bool
+	senders        int
+	sendersMatched int
+}
+
+// canonicalizeTransfer rewrites the addresses of tx in the canonical form
+// for networkType, the form addresses are registered in, so consumers can
+// join emitted transfers with registered addresses without normalizing.
+func canonicalizeTransfer(networkType enum.NetworkType, tx *types.Transaction) {
+	tx.FromAddress = addressutil.Canonical(networkType, tx.FromAddress)
+	tx.ToAddress = addressutil.Canonical(networkType, tx.ToAddress)
+	tx.AssetAddress = addressutil.Canonical(networkType, tx.AssetAddress)
+	if len(tx.FromAddresses) > 0 {
+		from := make([]string, len(tx.FromAddresses))
+		for i, addr := range tx.FromAddresses {
+			from[i] = addressutil.Canonical(networkType, addr)
+		}
+		tx.FromAddresses = from
+	}
+}
+
+// matchTransfer checks the recipient and every sender of tx against store.
+// Senders come from AllSenderAddresses, so multi-input (Bitcoin) transfers
+// are matched per input address rather than on the collapsed FromAddress.
+func matchTransfer(store pubkeystore.Store, networkType enum.NetworkType, tx *types.Transaction) transferMatch {
+	m := transferMatch{to: tx.ToAddress != "" && store.Exist(networkType, tx.ToAddress)}
+	for _, addr := range tx.AllSenderAddresses() {
+		m.senders++
+		if store.Exist(networkType, addr) {
+			m.sendersMatched++
+		}
+	}
+	return m
+}
+
+// retiredAddresses returns the recipient and senders of tx that checker
+// knows as retired, once each.
+func retiredAddresses(checker pubkeystore.RetiredChecker, networkType enum.NetworkType, tx *types.Transaction) []string {
+	var retired []string
+	for _, addr := range append([]string{tx.ToAddress}, tx.AllSenderAddresses()...) {
+		if addr != "" && !slices.Contains(retired, addr) && checker.Retired(networkType, addr) {
+			retired = append(retired, addr)
+		}
+	}
+	return retired
+}
+
+// fromMatched reports whether any sender is monitored.
+func (m transferMatch) fromMatched() bool { return m.sendersMatched > 0 }
+
+// role returns the transfer's role for the monitored set.
+func (m transferMatch) role() string {
+	return types.TransferRole(m.to, m.sendersMatched, m.senders)
+}
+
+// directions reports whether tx is emitted incoming, to its monitored
+// recipient, and outgoing, from its monitored senders when twoWay is set. A
+// consolidation moves funds between monitored addresses only, and a self
+// transfer back to its sender, so they are emitted once, incoming.
+func (m transferMatch) directions(twoWay bool, tx *types.Transaction) (in, out bool) {
+	in = m.to
+	once := tx.Type == constant.TxTypeConsolidation || tx.Type == constant.TxTypeSelfTransfer
+	out = twoWay && m.fromMatched() && !(in && once)
+	return in, out
+}
@@ -0,0 +1,249 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/watchaddress"
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/amount"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/events"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/fystack/multichain-indexer/pkg/store/pubkeystore"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseWorkerEmitBlockAnnotatesRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		twoWay bool
+		tx     types.Transaction
+		want   []string // "direction/role" per emitted copy
+	}{
+		{
+			name: "deposit",
+			tx:   types.Transaction{FromAddress: "ext", ToAddress: "ours1"},
+			want: []string{"in/deposit"},
+		},
+		{
+			name:   "withdrawal",
+			twoWay: true,
+			tx:     types.Transaction{FromAddress: "ours1", ToAddress: "ext"},
+			want:   []string{"out/withdrawal"},
+		},
+		{
+			name:   "internal",
+			twoWay: true,
+			tx:     types.Transaction{FromAddress: "ours1", ToAddress: "ours2"},
+			want:   []string{"in/internal", "out/internal"},
+		},
+		{
+			name:   "consolidation emitted once",
+			twoWay: true,
+			tx:     types.Transaction{FromAddress: "ours1", ToAddress: "ours2", Type: constant.TxTypeConsolidation},
+			want:   []string{"in/internal"},
+		},
+		{
+			name: "internal without two-way still labelled",
+			tx:   types.Transaction{FromAddress: "ours1", ToAddress: "ours2"},
+			want: []string{"in/internal"},
+		},
+		{
+			name:   "composite from with one of ours",
+			twoWay: true,
+			tx: types.Transaction{
+				FromAddress:   "ours1",
+				FromAddresses: []string{"ours1", "ext"},
+				ToAddress:     "ext2",
+			},
+			want: []string{"out/mixed"},
+		},
+		{
+			name:   "unmatched",
+			twoWay: true,
+			tx:     types.Transaction{FromAddress: "ext", ToAddress: "ext2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emitter := &recordingEmitter{}
+			cfg := testChainConfig()
+			cfg.TwoWayIndexing = tt.twoWay
+			bw := &BaseWorker{
+				logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				config:      cfg,
+				chain:       &stubIndexer{name: "test", networkType: enum.NetworkTypeBtc},
+				pubkeyStore: stubPubkeyStore{"ours1": true, "ours2": true},
+				emitter:     emitter,
+			}
+
+			bw.emitBlock(context.Background(), &types.Block{Transactions: []types.Transaction{tt.tx}})
+
+			var got []string
+			for _, tx := range emitter.txs {
+				got = append(got, tx.Direction+"/"+tx.Role)
+			}
+			require.Len(t, got, len(tt.want))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBaseWorkerEmitBlockMatchesAnyRegisteredRepresentation(t *testing.T) {
+	const (
+		evmLower  = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+		evmMixed  = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		tronB58   = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+		tronHex   = "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"
+		btcLower  = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+		btcUpper  = "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ"
+		solPubkey = "11111111111111111111111111111111"
+	)
+	tests := []struct {
+		name       string
+		nt         enum.NetworkType
+		registered string // as submitted to RegisterAddresses
+		indexed    string // as the indexer emits it
+		canonical  string
+	}{
+		{"evm checksum registered, lowercase indexed", enum.NetworkTypeEVM, evmMixed, evmLower, evmLower},
+		{"evm lowercase registered, checksum indexed", enum.NetworkTypeEVM, evmLower, evmMixed, evmLower},
+		{"evm uppercase registered", enum.NetworkTypeEVM, "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", evmMixed, evmLower},
+		{"tron hex registered, base58 indexed", enum.NetworkTypeTron, tronHex, tronB58, tronB58},
+		{"tron base58 registered, hex indexed", enum.NetworkTypeTron, tronB58, tronHex, tronB58},
+		{"btc uppercase bech32 registered", enum.NetworkTypeBtc, btcUpper, btcLower, btcLower},
+		{"btc lowercase bech32 registered, uppercase indexed", enum.NetworkTypeBtc, btcLower, btcUpper, btcLower},
+		{"solana", enum.NetworkTypeSol, " " + solPubkey, solPubkey, solPubkey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bloom := addressbloomfilter.NewAddressBloomFilter(addressbloomfilter.Config{
+				ExpectedItems:     1000,
+				FalsePositiveRate: 0.0001,
+			})
+			svc := watchaddress.NewService(memWalletRepo{}, bloom)
+			results, err := svc.RegisterAddresses(context.Background(), tt.nt, []string{tt.registered})
+			require.NoError(t, err)
+			require.Equal(t, watchaddress.StatusAdded, results[0].Status, results[0].Error)
+			assert.Equal(t, tt.canonical, results[0].Normalized)
+
+			emitter := &recordingEmitter{}
+			bw := &BaseWorker{
+				logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				config:      testChainConfig(),
+				chain:       &stubIndexer{name: "test", networkType: tt.nt},
+				pubkeyStore: pubkeystore.NewPublicKeyStore(bloom, nil, nil),
+				emitter:     emitter,
+			}
+			matched := bw.emitBlock(context.Background(), &types.Block{Transactions: []types.Transaction{
+				{FromAddress: "external", ToAddress: tt.indexed},
+			}})
+
+			require.Len(t, emitter.txs, 1)
+			assert.Equal(t, tt.canonical, emitter.txs[0].ToAddress, "emitted in the registered canonical form")
+			require.Len(t, matched, 1)
+			assert.Equal(t, tt.canonical, matched[0].ToAddress)
+		})
+	}
+}
+
+func TestBaseWorkerEmitBlockFormatsAmounts(t *testing.T) {
+	for _, tt := range []struct {
+		format          amount.Format
+		wantFee         string
+		wantUnit        *types.AmountUnit
+		wantFeeUnitName string
+	}{
+		{amount.FormatLegacy, "0.00063", nil, ""},
+		{amount.FormatRaw, "630000000000000", &types.AmountUnit{Name: "wei", Decimals: 18}, "wei"},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			emitter := &recordingEmitter{}
+			bw := &BaseWorker{
+				logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				config:      testChainConfig(),
+				chain:       &stubIndexer{name: "test", networkType: enum.NetworkTypeEVM},
+				pubkeyStore: stubPubkeyStore{"0x00000000000000000000000000000000000000aa": true},
+				emitter:     emitter,
+				amounts:     amount.NewFormatter(tt.format, enum.NetworkTypeEVM, "", 0, nil),
+			}
+			matched := bw.emitBlock(context.Background(), &types.Block{Transactions: []types.Transaction{{
+				ToAddress: "0x00000000000000000000000000000000000000AA",
+				Amount:    "1000",
+				TxFee:     decimal.RequireFromString("0.00063"),
+			}}})
+
+			require.Len(t, emitter.txs, 1)
+			got := emitter.txs[0]
+			assert.Equal(t, "1000", got.Amount)
+			assert.Equal(t, tt.wantFee, got.TxFee.String())
+			assert.Equal(t, tt.wantUnit, got.Unit)
+			if tt.wantFeeUnitName == "" {
+				assert.Nil(t, got.FeeUnit)
+			} else {
+				assert.Equal(t, tt.wantFeeUnitName, got.FeeUnit.Name)
+			}
+			require.Len(t, matched, 1)
+			assert.Equal(t, got.TxFee.String(), matched[0].TxFee.String(), "the sink gets the same amounts")
+		})
+	}
+}
+
+// memWalletRepo is an empty wallet address table.
+type memWalletRepo struct{}
+
+func (memWalletRepo) Find(context.Context, repository.FindOptions) ([]*model.WalletAddress, error) {
+	return nil, nil
+}
+
+func (memWalletRepo) CreateMany(_ context.Context, rows []*model.WalletAddress) (int64, error) {
+	return int64(len(rows)), nil
+}
+
+type stubPubkeyStore map[string]bool
+
+func (s stubPubkeyStore) Exist(_ enum.NetworkType, addr string) bool { return s[addr] }
+func (s stubPubkeyStore) Save(enum.NetworkType, string) error        { return nil }
+func (s stubPubkeyStore) Close() error                               { return nil }
+
+type recordingEmitter struct {
+	txs     []types.Transaction
+	reorgs  []types.BlockReorg
+	blocks  []types.Block
+	retired []types.RetiredAddressActivity
+	utxos   []types.UTXOEvent
+}
+
+func (e *recordingEmitter) EmitBlock(_ string, block *types.Block) error {
+	e.blocks = append(e.blocks, *block)
+	return nil
+}
+func (e *recordingEmitter) EmitTransaction(_ string, tx *types.Transaction) error {
+	e.txs = append(e.txs, *tx)
+	return nil
+}
+func (e *recordingEmitter) EmitUTXO(_ string, utxo *types.UTXOEvent) error {
+	e.utxos = append(e.utxos, *utxo)
+	return nil
+}
+func (e *recordingEmitter) EmitError(string, error) error  { return nil }
+func (e *recordingEmitter) Emit(events.IndexerEvent) error { return nil }
+func (e *recordingEmitter) Close()                         {}
+func (e *recordingEmitter) EmitReorg(_ string, reorg *types.BlockReorg) error {
+	e.reorgs = append(e.reorgs, *reorg)
+	return nil
+}
+func (e *recordingEmitter) EmitRetiredActivity(_ string, activity *types.RetiredAddressActivity) error {
+	e.retired = append(e.retired, *activity)
+	return nil
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"slices"
+
+	"github.com/fystack/multichain-indexer/pkg/common/amount"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// isSelfTransfer reports whether tx pays one of its own senders: Bitcoin
+// change, or an account sending to itself. Consolidations, fee records and
+// the other typed records are not transfers of this kind.
+func isSelfTransfer(tx *types.Transaction) bool {
+	if tx.Type != constant.TxTypeNativeTransfer && tx.Type != constant.TxTypeTokenTransfer {
+		return false
+	}
+	return tx.ToAddress != "" && slices.Contains(tx.AllSenderAddresses(), tx.ToAddress)
+}
+
+// handleSelfTransfer applies config.ChainConfig.EmitSelfTransfers to a
+// canonicalized transfer, before its amounts are formatted. When set, a
+// self-transfer is emitted as a self_transfer, once and incoming like a
+// consolidation. When cleared it is dropped, and keep is false, unless it
+// carried a fee: it then becomes a fee record from the same senders, so
+// the fee is still accounted for, emitted outgoing with two-way indexing
+// like other fee records. Unset, transfers are left as extracted.
+func handleSelfTransfer(cfg config.ChainConfig, networkType enum.NetworkType, tx *types.Transaction) (keep bool) {
+	if cfg.EmitSelfTransfers == nil || !isSelfTransfer(tx) {
+		return true
+	}
+	if *cfg.EmitSelfTransfers {
+		tx.Type = constant.TxTypeSelfTransfer
+		return true
+	}
+	if !tx.TxFee.IsPositive() {
+		return false
+	}
+	tx.Type = constant.TxTypeFee
+	tx.ToAddress = ""
+	tx.AssetAddress = ""
+	tx.SetRawAmount(amount.FeeAmount(networkType, cfg.NativeDenom, tx.TxFee))
+	tx.Unit = nil
+	tx.Metadata = nil
+	tx.TransferID = tx.ComputeTransferID()
+	return true
+}
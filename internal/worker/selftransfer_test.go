@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseWorkerEmitBlockSelfTransfers(t *testing.T) {
+	fee := decimal.RequireFromString("0.00021")
+	// An account sending to itself.
+	self := types.Transaction{
+		TxHash: "aa", TransferIndex: "0:0",
+		FromAddress: "ours1", ToAddress: "ours1",
+		Amount: "5", Type: constant.TxTypeNativeTransfer, TxFee: fee,
+	}
+	// Change of a Bitcoin payment: the fee went on the lowest vout, the
+	// change output, under first_output attribution.
+	btcChange := types.Transaction{
+		TxHash: "bb", TransferIndex: "0:0",
+		FromAddress: "ours1", FromAddresses: []string{"ours1", "ours2"}, ToAddress: "ours2",
+		Amount: "7000", Type: constant.TxTypeNativeTransfer, TxFee: fee,
+	}
+	btcPayment := types.Transaction{
+		TxHash: "bb", TransferIndex: "1:0",
+		FromAddress: "ours1", FromAddresses: []string{"ours1", "ours2"}, ToAddress: "ext",
+		Amount: "3000", Type: constant.TxTypeNativeTransfer, TxFee: decimal.Zero,
+	}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name string
+		emit *bool
+		txs  []types.Transaction
+		want []string // "direction/type/to/amount" per emitted copy
+	}{
+		{
+			name: "unset emits as extracted",
+			txs:  []types.Transaction{self},
+			want: []string{"in/native_transfer/ours1/5", "out/native_transfer/ours1/5"},
+		},
+		{
+			name: "enabled emits a self transfer once",
+			emit: &enabled,
+			txs:  []types.Transaction{self, btcChange, btcPayment},
+			want: []string{
+				"in/self_transfer/ours1/5",
+				"in/self_transfer/ours2/7000",
+				"out/native_transfer/ext/3000",
+			},
+		},
+		{
+			name: "disabled keeps only the fee",
+			emit: &disabled,
+			txs:  []types.Transaction{self, btcChange, btcPayment},
+			want: []string{
+				"out/fee//21000",
+				"out/fee//21000",
+				"out/native_transfer/ext/3000",
+			},
+		},
+		{
+			name: "disabled drops a self transfer without fee",
+			emit: &disabled,
+			txs:  []types.Transaction{btcPayment, {FromAddress: "ours1", ToAddress: "ours1", Amount: "1", Type: constant.TxTypeTokenTransfer, AssetAddress: "usdt"}},
+			want: []string{"out/native_transfer/ext/3000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testChainConfig()
+			cfg.TwoWayIndexing = true
+			cfg.EmitSelfTransfers = tt.emit
+			emitter := &recordingEmitter{}
+			bw := &BaseWorker{
+				logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				config:      cfg,
+				chain:       &stubIndexer{name: "test", networkType: enum.NetworkTypeBtc},
+				pubkeyStore: stubPubkeyStore{"ours1": true, "ours2": true},
+				emitter:     emitter,
+			}
+			bw.emitBlock(context.Background(), &types.Block{Transactions: tt.txs})
+
+			var got []string
+			for _, tx := range emitter.txs {
+				got = append(got, tx.Direction+"/"+string(tx.Type)+"/"+tx.ToAddress+"/"+tx.Amount)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandleSelfTransferFeeRecord(t *testing.T) {
+	disabled := false
+	cfg := testChainConfig()
+	cfg.EmitSelfTransfers = &disabled
+
+	tx := types.Transaction{
+		NetworkId: "btc", TxHash: "bb", TransferIndex: "0:0",
+		FromAddress: "ours1", FromAddresses: []string{"ours1", "ours2"}, ToAddress: "ours2",
+		Amount: "7000", Type: constant.TxTypeNativeTransfer, TxFee: decimal.RequireFromString("0.00021"),
+	}
+	tx.EnsureTransferID()
+	tx.SetMetadata("vout", 0)
+	extracted := tx.TransferID
+
+	require.True(t, handleSelfTransfer(cfg, enum.NetworkTypeBtc, &tx))
+	assert.Equal(t, constant.TxTypeFee, tx.Type)
+	assert.Equal(t, "21000", tx.Amount)
+	assert.Equal(t, []string{"ours1", "ours2"}, tx.FromAddresses, "attributed to the senders")
+	assert.Empty(t, tx.ToAddress)
+	assert.Nil(t, tx.Metadata)
+	assert.NotEqual(t, extracted, tx.TransferID)
+	assert.Equal(t, tx.ComputeTransferID(), tx.TransferID)
+
+	// Consolidations are typed already.
+	consolidation := types.Transaction{FromAddress: "ours1", ToAddress: "ours1", Type: constant.TxTypeConsolidation}
+	assert.True(t, handleSelfTransfer(cfg, enum.NetworkTypeBtc, &consolidation))
+	assert.Equal(t, constant.TxTypeConsolidation, consolidation.Type)
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	writes     [][]types.Transaction
+	rolledBack []uint64
+	err        error
+}
+
+func (s *recordingSink) RollBack(_ context.Context, _ string, from uint64) error {
+	s.rolledBack = append(s.rolledBack, from)
+	return s.err
+}
+
+func (s *recordingSink) WriteTransfers(_ context.Context, _ string, transfers []types.Transaction) error {
+	s.writes = append(s.writes, transfers)
+	return s.err
+}
+
+func sinkTestWorker(s *recordingSink, store *stubBlockStore) *BaseWorker {
+	cfg := testChainConfig()
+	cfg.TwoWayIndexing = true
+	return &BaseWorker{
+		ctx:         context.Background(),
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		config:      cfg,
+		chain:       &stubIndexer{name: "test", networkType: enum.NetworkTypeBtc},
+		blockStore:  store,
+		pubkeyStore: stubPubkeyStore{"ours1": true, "ours2": true},
+		emitter:     &recordingEmitter{},
+		progress:    newProgress(),
+		sink:        s,
+	}
+}
+
+func TestBaseWorkerWritesMatchedTransfersToSink(t *testing.T) {
+	s := &recordingSink{}
+	bw := sinkTestWorker(s, &stubBlockStore{})
+
+	ok := bw.handleBlockResult(indexer.BlockResult{Number: 7, Block: &types.Block{
+		Number: 7,
+		Transactions: []types.Transaction{
+			{TxHash: "internal", FromAddress: "ours1", ToAddress: "ours2"},
+			{TxHash: "unrelated", FromAddress: "ext", ToAddress: "ext2"},
+			{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1"},
+		},
+	}})
+
+	require.True(t, ok)
+	require.Len(t, s.writes, 1)
+	var hashes []string
+	for _, tx := range s.writes[0] {
+		hashes = append(hashes, tx.TxHash+"/"+tx.Role)
+	}
+	// Once per transfer, though the internal one is emitted both ways.
+	assert.Equal(t, []string{"internal/internal", "deposit/deposit"}, hashes)
+}
+
+func TestBaseWorkerSinkFailureFailsBlock(t *testing.T) {
+	s := &recordingSink{err: errors.New("db down")}
+	store := &stubBlockStore{}
+	bw := sinkTestWorker(s, store)
+
+	ok := bw.handleBlockResult(indexer.BlockResult{Number: 7, Block: &types.Block{
+		Number:       7,
+		Transactions: []types.Transaction{{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1"}},
+	}})
+
+	assert.False(t, ok)
+	assert.Equal(t, []uint64{7}, store.failedBlocks)
+}
+
+func TestBaseWorkerSkipsSinkWithoutMatches(t *testing.T) {
+	s := &recordingSink{err: errors.New("db down")}
+	bw := sinkTestWorker(s, &stubBlockStore{})
+
+	ok := bw.handleBlockResult(indexer.BlockResult{Number: 7, Block: &types.Block{
+		Number:       7,
+		Transactions: []types.Transaction{{TxHash: "unrelated", FromAddress: "ext", ToAddress: "ext2"}},
+	}})
+
+	assert.True(t, ok)
+	assert.Empty(t, s.writes)
+}
+
+func TestBaseWorkerEmitsEmptyBlocks(t *testing.T) {
+	block := func(txs ...types.Transaction) indexer.BlockResult {
+		return indexer.BlockResult{Number: 7, Block: &types.Block{
+			Number: 7, Hash: "h7", ParentHash: "h6", Timestamp: 1700000000, Transactions: txs,
+		}}
+	}
+	unrelated := types.Transaction{TxHash: "unrelated", FromAddress: "ext", ToAddress: "ext2"}
+	deposit := types.Transaction{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1"}
+
+	for _, emitEmpty := range []bool{false, true} {
+		bw := sinkTestWorker(&recordingSink{}, &stubBlockStore{})
+		bw.config.EmitEmptyBlocks = emitEmpty
+		emitter := bw.emitter.(*recordingEmitter)
+
+		require.True(t, bw.handleBlockResult(block(unrelated)), "processed in both modes")
+		require.True(t, bw.handleBlockResult(block(deposit)))
+
+		if !emitEmpty {
+			assert.Empty(t, emitter.blocks)
+			continue
+		}
+		require.Len(t, emitter.blocks, 1, "only the block without matched transfers")
+		got := emitter.blocks[0]
+		assert.Equal(t, "h7", got.Hash)
+		assert.Equal(t, "h6", got.ParentHash)
+		assert.Equal(t, uint64(1700000000), got.Timestamp)
+		assert.Empty(t, got.Transactions, "unmatched transfers are not published")
+	}
+}
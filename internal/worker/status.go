@@ -0,0 +1,211 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/store/blockstore"
+)
+
+// ChainStatus describes a chain for the status endpoints: its run state,
+// the regular worker's progress and the state of each RPC node.
+type ChainStatus struct {
+	State ChainState `json:"state"`
+	*ProgressSnapshot
+	Nodes []rpc.ProviderStatus `json:"nodes,omitempty"`
+	// CurrentNode names the node the failover pool is using.
+	CurrentNode string `json:"current_node,omitempty"`
+	// HeadSource names how the regular worker learns of new blocks right
+	// now, see config.HeadConfig.
+	HeadSource string `json:"head_source,omitempty"`
+	// Outputs tallies indexed outputs by script type, for UTXO chains.
+	Outputs *indexer.OutputStats `json:"outputs,omitempty"`
+	// FetchQueues describes the block fetches by priority, on chains that
+	// schedule them, e.g. to tell whether backfill is starved.
+	FetchQueues map[string]indexer.FetchQueueStats `json:"fetch_queues,omitempty"`
+	// ReceiptBloom counts the blocks whose token-call receipts were not
+	// fetched as their logsBloom ruled out a wanted Transfer log, on EVM
+	// chains.
+	ReceiptBloom *indexer.ReceiptBloomStats `json:"receipt_bloom,omitempty"`
+	// PrevoutLookups counts the transactions whose prevouts were looked up
+	// and those skipped past max_prevout_lookups, on Bitcoin chains.
+	PrevoutLookups *indexer.PrevoutLookupStats `json:"prevout_lookups,omitempty"`
+	// RPCCache counts the lookups of the cache of immutable node
+	// responses, on chains with one.
+	RPCCache *rpc.ResponseCacheStats `json:"rpc_cache,omitempty"`
+	// Checkpoint is the block a restart would resume after, and how many
+	// indexed blocks it lags behind, see config.CheckpointConfig.
+	Checkpoint *CheckpointStatus `json:"checkpoint,omitempty"`
+	// DuplicatesSuppressed counts transfer events not emitted again, as
+	// they were already emitted since start.
+	DuplicatesSuppressed uint64 `json:"duplicates_suppressed,omitempty"`
+	// ReorgHalt describes the reorg a halted chain stopped at.
+	ReorgHalt *blockstore.ReorgHalt `json:"reorg_halt,omitempty"`
+
+	// NotReady explains why the chain fails readiness; empty when ready.
+	NotReady string `json:"not_ready,omitempty"`
+}
+
+// ChainStatuses returns the status of every registered chain. A running
+// chain is not ready when its lag exceeds maxLag, or its own max_lag
+// (constant.DefaultMaxLag if unset) when maxLag is 0, when every one of its
+// nodes is blacklisted, or while it is halted by a deep reorg.
+func (m *Manager) ChainStatuses(maxLag uint64) map[string]ChainStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make(map[string]ChainStatus, len(m.chains))
+	for name, cw := range m.chains {
+		status := ChainStatus{State: ChainStateDisabled}
+		if cw.enabled {
+			status = chainStatus(cw.workers)
+			status.NotReady = status.notReady(chainMaxLag(maxLag, cw.cfg.MaxLag))
+		}
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// ErrDiagnosticsUnsupported is returned by DiagnoseChain for chains whose
+// indexer cannot diagnose its nodes.
+var ErrDiagnosticsUnsupported = errors.New("node diagnostics not supported")
+
+// DiagnoseChain queries the nodes of the named running chain for
+// troubleshooting details, reported by node name. It returns false for a
+// chain not registered or not running.
+func (m *Manager) DiagnoseChain(ctx context.Context, name string) (map[string]any, bool, error) {
+	m.mu.Lock()
+	cw, ok := m.chains[name]
+	var workers []Worker
+	if ok && cw.enabled {
+		workers = append(workers, cw.workers...)
+	}
+	m.mu.Unlock()
+	if len(workers) == 0 {
+		return nil, false, nil
+	}
+
+	for _, w := range workers {
+		if bw := baseWorkerOf(w); bw != nil {
+			if diagnoser, ok := bw.chain.(indexer.NodeDiagnoser); ok {
+				return diagnoser.DiagnoseNodes(ctx), true, nil
+			}
+		}
+	}
+	return nil, true, ErrDiagnosticsUnsupported
+}
+
+func chainMaxLag(maxLag, chainMaxLag uint64) uint64 {
+	switch {
+	case maxLag > 0:
+		return maxLag
+	case chainMaxLag > 0:
+		return chainMaxLag
+	}
+	return constant.DefaultMaxLag
+}
+
+func chainStatus(workers []Worker) ChainStatus {
+	status := ChainStatus{State: ChainStateRunning}
+	for _, w := range workers {
+		bw := baseWorkerOf(w)
+		if bw == nil {
+			continue
+		}
+		if status.Nodes == nil {
+			if reporter, ok := bw.chain.(indexer.ProviderReporter); ok {
+				status.Nodes = reporter.ProviderStatuses()
+				status.CurrentNode = currentNode(status.Nodes)
+			}
+		}
+		if status.Outputs == nil {
+			if reporter, ok := bw.chain.(indexer.OutputStatsReporter); ok {
+				stats := reporter.OutputStats()
+				status.Outputs = &stats
+			}
+		}
+		if status.FetchQueues == nil {
+			if reporter, ok := bw.chain.(indexer.FetchQueueReporter); ok {
+				status.FetchQueues = reporter.FetchQueueStats()
+			}
+		}
+		if status.ReceiptBloom == nil {
+			if reporter, ok := bw.chain.(indexer.ReceiptBloomReporter); ok {
+				stats := reporter.ReceiptBloomStats()
+				status.ReceiptBloom = &stats
+			}
+		}
+		if status.PrevoutLookups == nil {
+			if reporter, ok := bw.chain.(indexer.PrevoutLookupReporter); ok {
+				stats := reporter.PrevoutLookupStats()
+				status.PrevoutLookups = &stats
+			}
+		}
+		if status.RPCCache == nil {
+			if reporter, ok := bw.chain.(indexer.ResponseCacheReporter); ok {
+				status.RPCCache = reporter.ResponseCacheStats()
+			}
+		}
+		if bw.emitGuard != nil {
+			status.DuplicatesSuppressed = bw.emitGuard.suppressedCount()
+		}
+		if bw.mode == ModeRegular && bw.progress != nil {
+			snap := bw.progress.snapshot()
+			status.ProgressSnapshot = &snap
+		}
+		if rw, ok := w.(*RegularWorker); ok {
+			status.HeadSource = rw.headSource()
+			status.Checkpoint = rw.checkpointStatus()
+			if halt := rw.reorgHalt(); halt != nil {
+				status.State = ChainStateHalted
+				status.ReorgHalt = halt
+			}
+		}
+	}
+	return status
+}
+
+// currentNode returns the name of the node marked current, if any.
+func currentNode(nodes []rpc.ProviderStatus) string {
+	for _, n := range nodes {
+		if n.Current {
+			return n.Name
+		}
+	}
+	return ""
+}
+
+func (s ChainStatus) notReady(maxLag uint64) string {
+	if s.ReorgHalt != nil {
+		return fmt.Sprintf("halted at block %d by a reorg deeper than the rollback window", s.ReorgHalt.Block)
+	}
+	if s.ProgressSnapshot != nil && s.Lag > maxLag {
+		return fmt.Sprintf("lag %d exceeds %d", s.Lag, maxLag)
+	}
+	if len(s.Nodes) > 0 && !slices.ContainsFunc(s.Nodes, func(n rpc.ProviderStatus) bool { return n.Available }) {
+		return "all nodes blacklisted"
+	}
+	return ""
+}
+
+// baseWorkerOf returns the BaseWorker embedded in w, if any.
+func baseWorkerOf(w Worker) *BaseWorker {
+	switch wt := w.(type) {
+	case *RegularWorker:
+		return wt.BaseWorker
+	case *CatchupWorker:
+		return wt.BaseWorker
+	case *RescannerWorker:
+		return wt.BaseWorker
+	case *ManualWorker:
+		return wt.BaseWorker
+	case *MempoolWorker:
+		return wt.BaseWorker
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress_Snapshot(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newProgress()
+	p.now = func() time.Time { return now }
+
+	p.setLatest(120)
+	p.setIndexed(100, 30)
+	now = now.Add(5 * time.Minute)
+	p.setIndexed(110, 10)
+	p.setError(errors.New("get blocks: timeout"))
+
+	snap := p.snapshot()
+	assert.Equal(t, uint64(120), snap.LatestHeight)
+	assert.Equal(t, uint64(110), snap.IndexedHeight)
+	assert.Equal(t, uint64(10), snap.Lag)
+	assert.InDelta(t, 4.0, snap.BlocksPerMinute, 1e-9, "40 blocks over 10 minutes")
+	assert.Equal(t, "get blocks: timeout", snap.LastError)
+	require.NotNil(t, snap.LastErrorAt)
+	assert.Equal(t, now, *snap.LastErrorAt)
+
+	now = now.Add(6 * time.Minute)
+	assert.InDelta(t, 1.0, p.snapshot().BlocksPerMinute, 1e-9, "the first 30 blocks left the window")
+	now = now.Add(10 * time.Minute)
+	assert.Zero(t, p.snapshot().BlocksPerMinute)
+}
+
+func TestProgress_RedactsNodeURLs(t *testing.T) {
+	p := newProgress()
+	p.setError(errors.New(`get blocks: Post "https://eth-mainnet.g.alchemy.com/v2/KEY": EOF`))
+	assert.Equal(t, `get blocks: Post "https://eth-mainnet.g.alchemy.com/***": EOF`, p.snapshot().LastError)
+}
+
+func TestProgress_ConsecutiveFailures(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newProgress()
+	p.now = func() time.Time { return now }
+
+	p.setIndexed(100, 1)
+	assert.Equal(t, 1, p.setError(fmt.Errorf("get blocks: %w", rpc.ErrTimeout)))
+	assert.Equal(t, 2, p.setBlockError(&indexer.Error{ErrorType: indexer.ErrorTypeRateLimited, Message: "429"}))
+
+	snap := p.snapshot()
+	assert.Equal(t, 2, snap.ConsecutiveFailures)
+	assert.Equal(t, "429", snap.LastError)
+	assert.Equal(t, string(indexer.ErrorTypeRateLimited), snap.LastErrorType)
+	require.NotNil(t, snap.LastSuccessAt)
+	assert.Equal(t, now, *snap.LastSuccessAt)
+
+	p.setIndexed(100, 0)
+	assert.Equal(t, 2, p.snapshot().ConsecutiveFailures, "a rollback indexes nothing")
+	now = now.Add(time.Minute)
+	p.setIndexed(101, 1)
+	snap = p.snapshot()
+	assert.Zero(t, snap.ConsecutiveFailures)
+	assert.Equal(t, now, *snap.LastSuccessAt)
+	assert.Equal(t, "429", snap.LastError, "the last error is kept")
+}
+
+func TestBaseWorker_FailureLevel(t *testing.T) {
+	bw := &BaseWorker{}
+	assert.Equal(t, slog.LevelWarn, bw.failureLevel(constant.DefaultErrorAfterFailures-1))
+	assert.Equal(t, slog.LevelError, bw.failureLevel(constant.DefaultErrorAfterFailures))
+
+	bw.config.ErrorAfterFailures = 1
+	assert.Equal(t, slog.LevelError, bw.failureLevel(1))
+}
+
+// reportingIndexer is a stubIndexer with a failover pool to report.
+type reportingIndexer struct {
+	*stubIndexer
+	nodes []rpc.ProviderStatus
+}
+
+func (r *reportingIndexer) ProviderStatuses() []rpc.ProviderStatus { return r.nodes }
+
+func addStatusChain(m *Manager, name string, cfg config.ChainConfig, chain *reportingIndexer, latest, indexed uint64) {
+	m.AddChain(name, cfg, func(cfg config.ChainConfig) ([]Worker, error) {
+		rw := NewRegularWorker(context.Background(), chain, cfg, noopKVStore{}, &stubBlockStore{}, nil, nil, nil)
+		rw.progress.setLatest(latest)
+		rw.progress.setIndexed(indexed, 0)
+		return []Worker{rw}, nil
+	})
+}
+
+func TestManagerChainStatuses_Readiness(t *testing.T) {
+	initTestLogger()
+	m := NewManager(context.Background(), nil, nil, nil, nil)
+	healthy := []rpc.ProviderStatus{{Name: "n1", State: rpc.StateBlacklisted}, {Name: "n2", Available: true, Current: true}}
+	blacklisted := []rpc.ProviderStatus{{Name: "n1", State: rpc.StateBlacklisted}}
+
+	addStatusChain(m, "ok", testChainConfig(),
+		&reportingIndexer{&stubIndexer{name: "ok", latest: 10}, healthy}, 110, 100)
+	addStatusChain(m, "lagging", testChainConfig(),
+		&reportingIndexer{&stubIndexer{name: "lagging", latest: 10}, healthy}, 500, 100)
+	addStatusChain(m, "no_nodes", testChainConfig(),
+		&reportingIndexer{&stubIndexer{name: "no_nodes", latest: 10}, blacklisted}, 100, 100)
+	disabled := false
+	m.AddChain("off", config.ChainConfig{Enabled: &disabled}, nil)
+
+	statuses := m.ChainStatuses(0)
+	require.Len(t, statuses, 4)
+
+	ok := statuses["ok"]
+	assert.Equal(t, ChainStateRunning, ok.State)
+	assert.Empty(t, ok.NotReady)
+	require.NotNil(t, ok.ProgressSnapshot)
+	assert.Equal(t, uint64(10), ok.Lag)
+	assert.Len(t, ok.Nodes, 2)
+	assert.Equal(t, "n2", ok.CurrentNode)
+
+	assert.Equal(t, "lag 400 exceeds 100", statuses["lagging"].NotReady, "chain max_lag falls back to the default")
+	assert.Equal(t, "all nodes blacklisted", statuses["no_nodes"].NotReady)
+	assert.Equal(t, ChainStatus{State: ChainStateDisabled}, statuses["off"])
+
+	assert.Empty(t, m.ChainStatuses(1000)["lagging"].NotReady, "the services threshold overrides max_lag")
+}
+
+// diagnosingIndexer is a reportingIndexer that can diagnose its nodes.
+type diagnosingIndexer struct {
+	*reportingIndexer
+}
+
+func (d *diagnosingIndexer) DiagnoseNodes(context.Context) map[string]any {
+	return map[string]any{"n1": "ok"}
+}
+
+func TestManagerDiagnoseChain(t *testing.T) {
+	initTestLogger()
+	m := NewManager(context.Background(), nil, nil, nil, nil)
+	m.AddChain("btc", testChainConfig(), func(cfg config.ChainConfig) ([]Worker, error) {
+		chain := &diagnosingIndexer{&reportingIndexer{stubIndexer: &stubIndexer{name: "btc"}}}
+		return []Worker{NewRegularWorker(context.Background(), chain, cfg, noopKVStore{}, &stubBlockStore{}, nil, nil, nil)}, nil
+	})
+	addStatusChain(m, "eth", testChainConfig(), &reportingIndexer{stubIndexer: &stubIndexer{name: "eth"}}, 0, 0)
+	disabled := false
+	m.AddChain("off", config.ChainConfig{Enabled: &disabled}, nil)
+
+	nodes, found, err := m.DiagnoseChain(context.Background(), "btc")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{"n1": "ok"}, nodes)
+
+	_, found, err = m.DiagnoseChain(context.Background(), "eth")
+	assert.True(t, found)
+	assert.ErrorIs(t, err, ErrDiagnosticsUnsupported)
+
+	for _, name := range []string{"off", "unknown"} {
+		_, found, err = m.DiagnoseChain(context.Background(), name)
+		assert.False(t, found, name)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/internal/indexer"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/tracing"
+	"github.com/fystack/multichain-indexer/pkg/tracing/tracingtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestHandleBlockResultEndsIndexerSpan(t *testing.T) {
+	rec := tracingtest.Record(t)
+	bw := sinkTestWorker(&recordingSink{}, &stubBlockStore{})
+	_, span := tracing.Start(context.Background(), "block")
+
+	require.True(t, bw.handleBlockResult(indexer.BlockResult{Number: 7, Span: span, Block: &types.Block{
+		Number:       7,
+		Transactions: []types.Transaction{{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1"}},
+	}}))
+
+	block := tracingtest.Find(rec, "block")
+	require.NotNil(t, block, "the worker ends the span")
+	assert.Contains(t, block.Attributes(), attribute.String("chain", "test"))
+	assert.Equal(t, []string{"match_addresses", "publish", "sink.write"}, tracingtest.Children(rec, block))
+	assert.Contains(t, tracingtest.Find(rec, "match_addresses").Attributes(), attribute.Int("matched", 1))
+}
+
+func TestHandleBlockResultTracesFailures(t *testing.T) {
+	rec := tracingtest.Record(t)
+	bw := sinkTestWorker(&recordingSink{err: errors.New("db down")}, &stubBlockStore{})
+
+	// Without a span from the indexer the worker starts one.
+	require.False(t, bw.handleBlockResult(indexer.BlockResult{Number: 7, Block: &types.Block{
+		Number:       7,
+		Transactions: []types.Transaction{{TxHash: "deposit", FromAddress: "ext", ToAddress: "ours1"}},
+	}}))
+	require.False(t, bw.handleBlockResult(indexer.BlockResult{Number: 8, Error: &indexer.Error{Message: "fetch failed"}}))
+
+	var blocks []string
+	for _, s := range rec.Ended() {
+		if s.Name() == "block" {
+			assert.Equal(t, codes.Error, s.Status().Code)
+			blocks = append(blocks, s.Status().Description)
+		}
+	}
+	assert.Equal(t, []string{"db down", "fetch failed"}, blocks)
+	assert.Equal(t, codes.Error, tracingtest.Find(rec, "sink.write").Status().Code)
+}
@@ -11,6 +11,12 @@ const (
 	ModeMempool   WorkerMode = "mempool"
 )
 
+// optional reports whether the mode's requests are optional work, which
+// gives way to following the tip as a request budget runs low.
+func (m WorkerMode) optional() bool {
+	return m == ModeCatchup || m == ModeRescanner || m == ModeManual
+}
+
 type FailedBlockEvent struct {
 	Chain   string
 	Block   uint64
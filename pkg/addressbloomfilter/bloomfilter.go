@@ -2,6 +2,7 @@ package addressbloomfilter
 
 import (
 	"context"
+	"time"
 
 	"github.com/fystack/multichain-indexer/pkg/common/config"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
@@ -12,10 +13,26 @@ import (
 )
 
 // WalletAddressBloomFilter defines the interface for working with wallet address filters.
+//
+// A filter with a repository to load is not ready until Initialize has
+// succeeded once. Until then Contains answers by the filter's
+// NotReadyPolicy, as a half-loaded filter would miss watched addresses.
 type WalletAddressBloomFilter interface {
-	// Initialize fully resets the bloom filter from database state.
+	// Initialize loads the bloom filter from database state into a fresh
+	// filter swapped in once loaded, keeping the addresses added meanwhile.
+	// It may run in the background while the filter is in use. On failure
+	// the filter stays not ready, see LoadError.
 	Initialize(ctx context.Context) error
 
+	// Ready returns a channel closed once the filter is ready.
+	Ready() <-chan struct{}
+
+	// IsReady reports whether the filter is ready.
+	IsReady() bool
+
+	// LoadError returns why the last Initialize failed, nil unless it did.
+	LoadError() error
+
 	// Add inserts a single address into the bloom filter for a given address type.
 	Add(address string, addressType enum.NetworkType)
 
@@ -28,16 +45,22 @@ type WalletAddressBloomFilter interface {
 	// Clear deletes the bloom filter for a given address type.
 	Clear(addressType enum.NetworkType)
 
-	// Stats returns metadata and filter info for the given address type.
+	// Stats returns metadata and filter info for the given address type,
+	// with its readiness and loading progress (rowsLoaded, rowsTotal).
 	Stats(addressType enum.NetworkType) map[string]any
 }
 
+// NewBloomFilter returns the filter cfg selects, loading from db. Without a
+// database there is nothing to load and the filter is ready at once.
 func NewBloomFilter(
 	cfg config.BloomfilterConfig,
 	db *gorm.DB,
 	redisClient infra.RedisClient,
 ) WalletAddressBloomFilter {
-	walletAddressRepo := repository.NewRepository[model.WalletAddress](db)
+	var walletAddressRepo repository.Repository[model.WalletAddress]
+	if db != nil {
+		walletAddressRepo = repository.NewRepository[model.WalletAddress](db)
+	}
 	switch cfg.Type {
 	case enum.BFBackendRedis:
 		return NewRedisBloomFilter(RedisBloomConfig{
@@ -47,6 +70,12 @@ func NewBloomFilter(
 			KeyPrefix:         cfg.Redis.KeyPrefix,
 			ErrorRate:         cfg.Redis.ErrorRate,
 			Capacity:          cfg.Redis.Capacity,
+			NotReady:          NotReadyPolicy(cfg.NotReady),
+
+			MaddChunkSize:        cfg.Redis.MaddChunkSize,
+			InitRetryTimeout:     parseDuration(cfg.Redis.InitRetryTimeout),
+			RetryTimeout:         parseDuration(cfg.Redis.RetryTimeout),
+			RecreateIncompatible: cfg.Redis.RecreateIncompatible,
 		})
 	default:
 		return NewAddressBloomFilter(Config{
@@ -54,6 +83,17 @@ func NewBloomFilter(
 			ExpectedItems:     cfg.InMemory.ExpectedItems,
 			FalsePositiveRate: cfg.InMemory.FalsePositiveRate,
 			BatchSize:         cfg.BatchSize,
+			NotReady:          NotReadyPolicy(cfg.NotReady),
 		})
 	}
 }
+
+// parseDuration returns the duration s spells, or zero, i.e. the default,
+// when it is empty or invalid.
+func parseDuration(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0
+	}
+	return d
+}
@@ -6,11 +6,11 @@ import (
 	"sync"
 
 	"github.com/bits-and-blooms/bloom/v3"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/model"
 	"github.com/fystack/multichain-indexer/pkg/repository"
-	"github.com/samber/lo"
 )
 
 // Config holds dependencies and configuration for the Bloom filter container.
@@ -19,6 +19,7 @@ type Config struct {
 	ExpectedItems     uint                                       // Estimated number of addresses per address type
 	FalsePositiveRate float64                                    // Desired false positive rate
 	BatchSize         int                                        // Batch size for paginated DB fetches
+	NotReady          NotReadyPolicy                             // Contains' answer until Initialize succeeds, FailOpen if unset
 }
 
 type walletBloomFilter struct {
@@ -28,26 +29,50 @@ type walletBloomFilter struct {
 }
 
 type addressBloomFilter struct {
+	*lifecycle
 	mu      sync.RWMutex
 	filters map[enum.NetworkType]*walletBloomFilter
 	config  Config
+
+	// pending holds the addresses added while Initialize runs, nil when it
+	// does not, for the filters it loads to get them too.
+	pendingMu sync.Mutex
+	pending   map[enum.NetworkType][]string
 }
 
-// NewAddressBloomFilter creates a new singleton bloom filter container using the provided config.
+// NewAddressBloomFilter creates a new singleton bloom filter container using
+// the provided config. Without a WalletAddressRepo it is ready at once.
 func NewAddressBloomFilter(cfg Config) WalletAddressBloomFilter {
 	return &addressBloomFilter{
-		filters: make(map[enum.NetworkType]*walletBloomFilter),
-		config:  cfg,
+		lifecycle: newLifecycle(cfg.NotReady, cfg.WalletAddressRepo == nil),
+		filters:   make(map[enum.NetworkType]*walletBloomFilter),
+		config:    cfg,
 	}
 }
 
+// Initialize loads every wallet address into fresh filters, built without
+// holding the filters in use, then swaps them in. Addresses added while it
+// runs are kept.
 func (abf *addressBloomFilter) Initialize(ctx context.Context) error {
+	abf.pendingMu.Lock()
+	abf.pending = make(map[enum.NetworkType][]string)
+	abf.pendingMu.Unlock()
+	defer func() {
+		abf.pendingMu.Lock()
+		abf.pending = nil
+		abf.pendingMu.Unlock()
+	}()
+
+	abf.countRows(ctx, abf.config.WalletAddressRepo)
+	loaded := make(map[enum.NetworkType]*walletBloomFilter)
 	for _, addrType := range enum.AllNetworkTypes {
 		offset := 0
 		limit := abf.config.BatchSize
 		total := 0
+		var bf *walletBloomFilter
 
 		for {
+			// Retired (soft-deleted) rows are left out by gorm's default scope.
 			wallets, err := abf.config.WalletAddressRepo.Find(ctx, repository.FindOptions{
 				Where:  repository.WhereType{"type": addrType},
 				Select: []string{"address"},
@@ -55,93 +80,162 @@ func (abf *addressBloomFilter) Initialize(ctx context.Context) error {
 				Offset: uint(offset),
 			})
 			if err != nil {
+				abf.loadFailed(err)
 				return err
 			}
 			if len(wallets) == 0 {
 				break
 			}
 
-			addresses := lo.Map(wallets, func(w *model.WalletAddress, _ int) string {
-				return w.Address
-			})
-			abf.AddBatch(addresses, addrType)
+			// Rows written before addresses were canonicalized, e.g. EVM
+			// checksum addresses, are loaded in canonical form.
+			if bf == nil {
+				bf = abf.newFilter()
+			}
+			for _, w := range wallets {
+				bf.filter.Add([]byte(addressutil.Canonical(addrType, w.Address)))
+				bf.addressCount++
+			}
+			abf.rowsLoaded(addrType, len(wallets))
 
 			offset += limit
-			total += len(addresses)
+			total += len(wallets)
 		}
 
+		if bf != nil {
+			loaded[addrType] = bf
+		}
 		logger.Info("In-memory Bloom filter initialized", "addressType", addrType, "total", total)
 	}
+	abf.swapIn(loaded)
+	abf.markReady()
 	return nil
 }
 
-func (abf *addressBloomFilter) getOrCreateFilter(addressType enum.NetworkType) *walletBloomFilter {
+// swapIn replaces the filters in use with those Initialize loaded, after
+// adding to them the addresses added meanwhile.
+func (abf *addressBloomFilter) swapIn(loaded map[enum.NetworkType]*walletBloomFilter) {
 	abf.mu.Lock()
 	defer abf.mu.Unlock()
+	abf.pendingMu.Lock()
+	defer abf.pendingMu.Unlock()
+	for _, addrType := range enum.AllNetworkTypes {
+		bf, pending := loaded[addrType], abf.pending[addrType]
+		if bf == nil && len(pending) > 0 {
+			bf = abf.newFilter()
+		}
+		if bf == nil {
+			delete(abf.filters, addrType)
+			continue
+		}
+		for _, address := range pending {
+			bf.filter.Add([]byte(address))
+			bf.addressCount++
+		}
+		abf.filters[addrType] = bf
+	}
+}
 
-	if bf, ok := abf.filters[addressType]; ok {
-		return bf
+// addPending records addresses added while Initialize runs. Callers hold
+// abf.mu, for swapIn not to miss them.
+func (abf *addressBloomFilter) addPending(addressType enum.NetworkType, addresses ...string) {
+	abf.pendingMu.Lock()
+	defer abf.pendingMu.Unlock()
+	if abf.pending != nil {
+		abf.pending[addressType] = append(abf.pending[addressType], addresses...)
 	}
+}
 
+func (abf *addressBloomFilter) newFilter() *walletBloomFilter {
 	m, k := bloom.EstimateParameters(abf.config.ExpectedItems, abf.config.FalsePositiveRate)
-	filter := bloom.New(m, k)
+	return &walletBloomFilter{filter: bloom.New(m, k)}
+}
 
-	bf := &walletBloomFilter{
-		filter:       filter,
-		addressCount: 0,
+// withFilter runs fn on the filter of addressType, created if missing,
+// holding abf.mu so Initialize does not swap the filter meanwhile.
+func (abf *addressBloomFilter) withFilter(addressType enum.NetworkType, fn func(bf *walletBloomFilter)) {
+	abf.mu.RLock()
+	bf, ok := abf.filters[addressType]
+	if !ok {
+		abf.mu.RUnlock()
+		abf.mu.Lock()
+		if _, ok := abf.filters[addressType]; !ok {
+			abf.filters[addressType] = abf.newFilter()
+		}
+		abf.mu.Unlock()
+		abf.mu.RLock()
+		bf, ok = abf.filters[addressType]
 	}
-	abf.filters[addressType] = bf
-	return bf
+	defer abf.mu.RUnlock()
+	fn(bf)
 }
 
 func (abf *addressBloomFilter) Add(address string, addressType enum.NetworkType) {
-	bf := abf.getOrCreateFilter(addressType)
-	bf.mu.Lock()
-	defer bf.mu.Unlock()
-	bf.filter.Add([]byte(address))
-	bf.addressCount++
+	abf.withFilter(addressType, func(bf *walletBloomFilter) {
+		bf.mu.Lock()
+		defer bf.mu.Unlock()
+		bf.filter.Add([]byte(address))
+		bf.addressCount++
+		abf.addPending(addressType, address)
+	})
 }
 
 func (abf *addressBloomFilter) AddBatch(addresses []string, addressType enum.NetworkType) {
-	bf := abf.getOrCreateFilter(addressType)
-	bf.mu.Lock()
-	defer bf.mu.Unlock()
-	for _, address := range addresses {
-		bf.filter.Add([]byte(address))
-		bf.addressCount++
-	}
+	abf.withFilter(addressType, func(bf *walletBloomFilter) {
+		bf.mu.Lock()
+		defer bf.mu.Unlock()
+		for _, address := range addresses {
+			bf.filter.Add([]byte(address))
+			bf.addressCount++
+		}
+		abf.addPending(addressType, addresses...)
+	})
 }
 
-func (abf *addressBloomFilter) Contains(address string, addressType enum.NetworkType) bool {
-	bf := abf.getOrCreateFilter(addressType)
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-	return bf.filter.Test([]byte(address))
+func (abf *addressBloomFilter) Contains(address string, addressType enum.NetworkType) (found bool) {
+	if !abf.IsReady() {
+		return abf.notReadyAnswer()
+	}
+	abf.withFilter(addressType, func(bf *walletBloomFilter) {
+		bf.mu.RLock()
+		defer bf.mu.RUnlock()
+		found = bf.filter.Test([]byte(address))
+	})
+	return found
 }
 
 func (abf *addressBloomFilter) Clear(addressType enum.NetworkType) {
-	bf := abf.getOrCreateFilter(addressType)
-	bf.mu.Lock()
-	defer bf.mu.Unlock()
-	bf.filter.ClearAll()
-	bf.addressCount = 0
+	abf.withFilter(addressType, func(bf *walletBloomFilter) {
+		bf.mu.Lock()
+		defer bf.mu.Unlock()
+		bf.filter.ClearAll()
+		bf.addressCount = 0
+	})
 }
 
 func (abf *addressBloomFilter) Stats(addressType enum.NetworkType) map[string]any {
-	bf := abf.getOrCreateFilter(addressType)
+	stats := abf.stats(addressType)
+	stats["addressType"] = addressType
+
+	// Types never added to have no filter; don't allocate one to report it.
+	abf.mu.RLock()
+	bf, ok := abf.filters[addressType]
+	abf.mu.RUnlock()
+	if !ok {
+		stats["addressCount"] = uint(0)
+		return stats
+	}
 	bf.mu.RLock()
 	defer bf.mu.RUnlock()
 
 	fillRatio := bf.approximatedFillRatio()
-	return map[string]any{
-		"addressType":                addressType,
-		"addressCount":               bf.addressCount,
-		"bitsCount":                  bf.filter.Cap(),
-		"hashFunctions":              bf.filter.K(),
-		"approximateFillRatio":       fillRatio,
-		"fillPercentage":             fillRatio * 100,
-		"estimatedFalsePositiveRate": bf.estimateFalsePositiveRate(),
-	}
+	stats["addressCount"] = bf.addressCount
+	stats["bitsCount"] = bf.filter.Cap()
+	stats["hashFunctions"] = bf.filter.K()
+	stats["approximateFillRatio"] = fillRatio
+	stats["fillPercentage"] = fillRatio * 100
+	stats["estimatedFalsePositiveRate"] = bf.estimateFalsePositiveRate()
+	return stats
 }
 
 func (bf *walletBloomFilter) approximatedFillRatio() float64 {
@@ -0,0 +1,136 @@
+package addressbloomfilter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedRepo serves EVM addresses a page at a time, blocking before the page
+// at gateOffset until gate is closed.
+type pagedRepo struct {
+	addresses  []string
+	gateOffset uint
+	gate       chan struct{}
+	reached    chan struct{}
+}
+
+func (r *pagedRepo) Find(_ context.Context, opts repository.FindOptions) ([]*model.WalletAddress, error) {
+	if opts.Where["type"] != enum.NetworkTypeEVM {
+		return nil, nil
+	}
+	if opts.Offset == r.gateOffset {
+		close(r.reached)
+		<-r.gate
+	}
+	var page []*model.WalletAddress
+	for i := opts.Offset; i < opts.Offset+opts.Limit && i < uint(len(r.addresses)); i++ {
+		page = append(page, &model.WalletAddress{Address: r.addresses[i]})
+	}
+	return page, nil
+}
+
+func (r *pagedRepo) CreateMany(context.Context, []*model.WalletAddress) (int64, error) {
+	return 0, nil
+}
+
+func (r *pagedRepo) Count(_ context.Context, opts repository.FindOptions) (int64, error) {
+	if opts.Where["type"] != enum.NetworkTypeEVM {
+		return 0, nil
+	}
+	return int64(len(r.addresses)), nil
+}
+
+func TestAddressBloomFilterNotReadyWhileLoading(t *testing.T) {
+	const first, last = "0x1111111111111111111111111111111111111111", "0x2222222222222222222222222222222222222222"
+	const added = "0x4444444444444444444444444444444444444444"
+
+	for _, policy := range []NotReadyPolicy{FailOpen, FailClosed} {
+		t.Run(string(policy), func(t *testing.T) {
+			repo := &pagedRepo{
+				addresses:  []string{first, last},
+				gateOffset: 1,
+				gate:       make(chan struct{}),
+				reached:    make(chan struct{}),
+			}
+			bf := NewAddressBloomFilter(Config{
+				WalletAddressRepo: repo,
+				ExpectedItems:     100,
+				FalsePositiveRate: 0.0001,
+				BatchSize:         1,
+				NotReady:          policy,
+			})
+			require.False(t, bf.IsReady())
+
+			done := make(chan error, 1)
+			go func() { done <- bf.Initialize(context.Background()) }()
+			<-repo.reached
+
+			// Half loaded: the first page is in, the last is not.
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.Equal(t, policy == FailOpen, bf.Contains(last, enum.NetworkTypeEVM))
+				}()
+			}
+			wg.Wait()
+			// Registered while loading: kept once the loaded filter is in.
+			bf.Add(added, enum.NetworkTypeEVM)
+			stats := bf.Stats(enum.NetworkTypeEVM)
+			assert.Equal(t, false, stats["ready"])
+			assert.Equal(t, int64(1), stats["rowsLoaded"])
+			assert.Equal(t, int64(2), stats["rowsTotal"])
+			select {
+			case <-bf.Ready():
+				t.Fatal("ready before loading finished")
+			default:
+			}
+
+			close(repo.gate)
+			require.NoError(t, <-done)
+			<-bf.Ready()
+			assert.True(t, bf.IsReady())
+			assert.True(t, bf.Contains(first, enum.NetworkTypeEVM))
+			assert.True(t, bf.Contains(last, enum.NetworkTypeEVM))
+			assert.True(t, bf.Contains(added, enum.NetworkTypeEVM))
+			assert.False(t, bf.Contains("0x3333333333333333333333333333333333333333", enum.NetworkTypeEVM))
+			assert.Equal(t, int64(2), bf.Stats(enum.NetworkTypeEVM)["rowsLoaded"])
+		})
+	}
+}
+
+func TestAddressBloomFilterWithoutRepoIsReady(t *testing.T) {
+	bf := NewAddressBloomFilter(Config{ExpectedItems: 100, FalsePositiveRate: 0.0001})
+	assert.True(t, bf.IsReady())
+	assert.False(t, bf.Contains("0x1111111111111111111111111111111111111111", enum.NetworkTypeEVM))
+	assert.NotContains(t, bf.Stats(enum.NetworkTypeSol), "rowsTotal", "nothing counted")
+}
+
+type failingRepo struct{ pagedRepo }
+
+func (r *failingRepo) Find(context.Context, repository.FindOptions) ([]*model.WalletAddress, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestAddressBloomFilterLoadFailure(t *testing.T) {
+	bf := NewAddressBloomFilter(Config{
+		WalletAddressRepo: &failingRepo{},
+		ExpectedItems:     100,
+		FalsePositiveRate: 0.0001,
+		BatchSize:         1,
+	})
+	require.Error(t, bf.Initialize(context.Background()))
+	assert.False(t, bf.IsReady())
+	assert.EqualError(t, bf.LoadError(), "connection refused")
+	assert.Equal(t, "connection refused", bf.Stats(enum.NetworkTypeEVM)["loadError"])
+	assert.True(t, bf.Contains("0x1111111111111111111111111111111111111111", enum.NetworkTypeEVM), "fails open")
+}
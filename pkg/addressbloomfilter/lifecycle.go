@@ -0,0 +1,151 @@
+package addressbloomfilter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+)
+
+// NotReadyPolicy is what Contains answers while the filter is not ready, as
+// selected by BloomfilterConfig.NotReady.
+type NotReadyPolicy string
+
+const (
+	// FailOpen answers true: any address may be watched. It is the default,
+	// as a false positive costs an extra event where a false negative
+	// misses a deposit.
+	FailOpen NotReadyPolicy = "fail_open"
+	// FailClosed answers false: no address is watched yet.
+	FailClosed NotReadyPolicy = "fail_closed"
+)
+
+// rowCounter is implemented by repositories that can count rows, used to
+// report the progress of Initialize.
+type rowCounter interface {
+	Count(ctx context.Context, options repository.FindOptions) (int64, error)
+}
+
+// lifecycle tracks whether a filter has been loaded from the database and
+// how far its loading got. A filter without a repository to load from is
+// ready from the start.
+type lifecycle struct {
+	ready    chan struct{}
+	once     sync.Once
+	notReady NotReadyPolicy
+
+	mu      sync.Mutex
+	loaded  map[enum.NetworkType]int64
+	total   map[enum.NetworkType]int64
+	loadErr error
+}
+
+func newLifecycle(policy NotReadyPolicy, ready bool) *lifecycle {
+	if policy == "" {
+		policy = FailOpen
+	}
+	l := &lifecycle{
+		ready:    make(chan struct{}),
+		notReady: policy,
+		loaded:   make(map[enum.NetworkType]int64),
+		total:    make(map[enum.NetworkType]int64),
+	}
+	if ready {
+		l.markReady()
+	}
+	return l
+}
+
+// Ready returns a channel closed once the filter is ready.
+func (l *lifecycle) Ready() <-chan struct{} {
+	return l.ready
+}
+
+// IsReady reports whether the filter is ready.
+func (l *lifecycle) IsReady() bool {
+	select {
+	case <-l.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *lifecycle) markReady() {
+	l.mu.Lock()
+	l.loadErr = nil
+	l.mu.Unlock()
+	l.once.Do(func() { close(l.ready) })
+}
+
+// loadFailed records why Initialize failed, the filter staying not ready.
+func (l *lifecycle) loadFailed(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadErr = err
+}
+
+// LoadError returns why the last Initialize failed, nil unless it did.
+func (l *lifecycle) LoadError() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loadErr
+}
+
+// notReadyAnswer is Contains' answer while the filter is not ready.
+func (l *lifecycle) notReadyAnswer() bool {
+	return l.notReady != FailClosed
+}
+
+// countRows records the rows Initialize will load per network type, when
+// repo can count them.
+func (l *lifecycle) countRows(ctx context.Context, repo repository.Repository[model.WalletAddress]) {
+	counter, ok := repo.(rowCounter)
+	if !ok {
+		return
+	}
+	for _, addrType := range enum.AllNetworkTypes {
+		n, err := counter.Count(ctx, repository.FindOptions{Where: repository.WhereType{"type": addrType}})
+		if err != nil {
+			continue
+		}
+		l.mu.Lock()
+		l.total[addrType] = n
+		l.mu.Unlock()
+	}
+}
+
+// rowsLoaded adds n to the rows loaded for addrType.
+func (l *lifecycle) rowsLoaded(addrType enum.NetworkType, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded[addrType] += int64(n)
+}
+
+// resetLoaded forgets the rows loaded for addrType, when loading starts over.
+func (l *lifecycle) resetLoaded(addrType enum.NetworkType) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.loaded, addrType)
+}
+
+// stats returns the filter's readiness and the loading progress of
+// addrType; rowsTotal is absent when the rows could not be counted, and
+// loadError unless Initialize failed.
+func (l *lifecycle) stats(addrType enum.NetworkType) map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := map[string]any{
+		"ready":      l.IsReady(),
+		"rowsLoaded": l.loaded[addrType],
+	}
+	if total, ok := l.total[addrType]; ok {
+		stats["rowsTotal"] = total
+	}
+	if l.loadErr != nil {
+		stats["loadError"] = l.loadErr.Error()
+	}
+	return stats
+}
@@ -4,17 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
 	"github.com/fystack/multichain-indexer/pkg/infra"
 	"github.com/fystack/multichain-indexer/pkg/model"
 	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/fystack/multichain-indexer/pkg/retry"
+	"github.com/redis/go-redis/v9"
 	"github.com/samber/lo"
 )
 
+const (
+	defaultMaddChunkSize    = 1000
+	defaultInitRetryTimeout = time.Minute
+	defaultRetryTimeout     = 2 * time.Second
+	retryInitialInterval    = 100 * time.Millisecond
+)
+
 type redisBloomFilter struct {
+	*lifecycle
 	mu                sync.RWMutex // Add mutex for thread safety
 	redisClient       infra.RedisClient
 	walletAddressRepo repository.Repository[model.WalletAddress]
@@ -23,6 +39,15 @@ type redisBloomFilter struct {
 	ctx               context.Context
 	errorRate         float64
 	capacity          int
+	maddChunkSize     int
+	initRetryTimeout  time.Duration
+	retryTimeout      time.Duration
+	recreate          bool
+
+	// loading holds, per network type Initialize is loading, the addresses
+	// added meanwhile, added to the loaded key before it replaces the live
+	// one. Guarded by mu.
+	loading map[enum.NetworkType][]string
 }
 
 type RedisBloomConfig struct {
@@ -32,6 +57,12 @@ type RedisBloomConfig struct {
 	KeyPrefix         string
 	ErrorRate         float64
 	Capacity          int
+	NotReady          NotReadyPolicy // Contains' answer until Initialize succeeds, FailOpen if unset
+
+	MaddChunkSize        int           // addresses per BF.MADD, 1000 if unset
+	InitRetryTimeout     time.Duration // how long Initialize retries transient errors, 1m if unset
+	RetryTimeout         time.Duration // how long Add, AddBatch and Contains retry them, 2s if unset
+	RecreateIncompatible bool          // drop keys reserved with another error rate or capacity
 }
 
 func NewRedisBloomFilter(cfg RedisBloomConfig) WalletAddressBloomFilter {
@@ -47,8 +78,21 @@ func NewRedisBloomFilter(cfg RedisBloomConfig) WalletAddressBloomFilter {
 	if capacity <= 0 {
 		capacity = 10000
 	}
+	maddChunkSize := cfg.MaddChunkSize
+	if maddChunkSize <= 0 {
+		maddChunkSize = defaultMaddChunkSize
+	}
+	initRetryTimeout := cfg.InitRetryTimeout
+	if initRetryTimeout <= 0 {
+		initRetryTimeout = defaultInitRetryTimeout
+	}
+	retryTimeout := cfg.RetryTimeout
+	if retryTimeout <= 0 {
+		retryTimeout = defaultRetryTimeout
+	}
 
 	return &redisBloomFilter{
+		lifecycle:         newLifecycle(cfg.NotReady, cfg.WalletAddressRepo == nil),
 		redisClient:       cfg.RedisClient,
 		walletAddressRepo: cfg.WalletAddressRepo,
 		batchSize:         cfg.BatchSize,
@@ -56,6 +100,11 @@ func NewRedisBloomFilter(cfg RedisBloomConfig) WalletAddressBloomFilter {
 		ctx:               context.Background(),
 		errorRate:         errorRate,
 		capacity:          capacity,
+		maddChunkSize:     maddChunkSize,
+		initRetryTimeout:  initRetryTimeout,
+		retryTimeout:      retryTimeout,
+		recreate:          cfg.RecreateIncompatible,
+		loading:           make(map[enum.NetworkType][]string),
 	}
 }
 
@@ -63,86 +112,307 @@ func (rbf *redisBloomFilter) getKey(addressType enum.NetworkType) string {
 	return fmt.Sprintf("%s:%s", rbf.keyPrefix, addressType)
 }
 
+// paramsKey holds the error rate and capacity key was reserved with, which
+// BF.INFO does not report once the filter has scaled.
+func paramsKey(key string) string {
+	return key + ":params"
+}
+
+// loadingKey holds the filter Initialize loads to replace key.
+func loadingKey(key string) string {
+	return key + ":loading"
+}
+
+// Initialize loads every wallet address into fresh keys, without holding
+// the filter's lock, then renames each over the key in use. Addresses added
+// while it runs are kept. Keys in use reserved with other parameters are
+// refused unless the filter may recreate them. A network type whose load is
+// interrupted by a transient Redis error, e.g. a restart, is loaded again
+// from the start.
 func (rbf *redisBloomFilter) Initialize(ctx context.Context) error {
-	rbf.mu.Lock()
-	defer rbf.mu.Unlock()
+	if rbf.walletAddressRepo == nil {
+		err := errors.New("WalletAddressRepo was not provided in config")
+		rbf.loadFailed(err)
+		return err
+	}
+	rbf.countRows(ctx, rbf.walletAddressRepo)
 
 	for _, addrType := range enum.AllNetworkTypes {
 		key := rbf.getKey(addrType)
-		client := rbf.redisClient.GetClient()
-
-		// Check if the Bloom filter key exists, and delete it if so
-		exists, err := client.Do(ctx, "EXISTS", key).Int()
+		rbf.setLoading(addrType, true)
+		err := rbf.withRetry(ctx, rbf.initRetryTimeout, "initialize "+key, func() error {
+			return rbf.loadType(ctx, addrType)
+		})
+		if err == nil {
+			err = rbf.withRetry(ctx, rbf.initRetryTimeout, "swap in "+key, func() error {
+				return rbf.swapIn(ctx, addrType)
+			})
+		}
+		rbf.setLoading(addrType, false)
 		if err != nil {
-			return fmt.Errorf("failed to check existence of key %s: %w", key, err)
+			rbf.loadFailed(err)
+			return err
 		}
-		if exists == 1 {
-			_ = rbf.redisClient.Del(key)
+	}
+
+	rbf.markReady()
+	return nil
+}
+
+// setLoading starts or stops recording the addresses of addrType added
+// while Initialize loads it.
+func (rbf *redisBloomFilter) setLoading(addrType enum.NetworkType, loading bool) {
+	rbf.mu.Lock()
+	defer rbf.mu.Unlock()
+	if loading {
+		rbf.loading[addrType] = []string{}
+	} else {
+		delete(rbf.loading, addrType)
+	}
+}
+
+// addLoading records addresses added while their type is loaded. Callers
+// hold mu.
+func (rbf *redisBloomFilter) addLoading(addrType enum.NetworkType, addresses ...string) {
+	if pending, ok := rbf.loading[addrType]; ok {
+		rbf.loading[addrType] = append(pending, addresses...)
+	}
+}
+
+// swapIn adds the addresses added while addrType was loaded to its loaded
+// key, then renames it over the key in use.
+func (rbf *redisBloomFilter) swapIn(ctx context.Context, addrType enum.NetworkType) error {
+	rbf.mu.Lock()
+	defer rbf.mu.Unlock()
+
+	key := rbf.getKey(addrType)
+	loading := loadingKey(key)
+	if pending := rbf.loading[addrType]; len(pending) > 0 {
+		if err := rbf.addBatchToBloom(ctx, loading, pending); err != nil {
+			return err
 		}
+		rbf.loading[addrType] = pending[:0]
+	}
+	pipe := rbf.redisClient.GetClient().TxPipeline()
+	pipe.Rename(ctx, loading, key)
+	rbf.pipeParams(ctx, pipe, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to swap in Bloom filter %s: %w", key, err)
+	}
+	return nil
+}
 
-		// Reserve a new Bloom filter
-		_, err = client.Do(ctx, "BF.RESERVE", key, rbf.errorRate, rbf.capacity).Result()
+func (rbf *redisBloomFilter) loadType(ctx context.Context, addrType enum.NetworkType) error {
+	key := rbf.getKey(addrType)
+	if err := rbf.ensureKey(ctx, key); err != nil {
+		return err
+	}
+	// Loaded from the start into a key of its own, replacing what an
+	// interrupted load left.
+	loading := loadingKey(key)
+	pipe := rbf.redisClient.GetClient().TxPipeline()
+	pipe.Del(ctx, loading)
+	pipe.BFReserve(ctx, loading, rbf.errorRate, int64(rbf.capacity))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create Bloom filter %s: %w", loading, err)
+	}
+	rbf.resetLoaded(addrType)
+
+	offset := 0
+	limit := rbf.batchSize
+	total := 0
+
+	for {
+		// Retired (soft-deleted) rows are left out by gorm's default scope.
+		wallets, err := rbf.walletAddressRepo.Find(ctx, repository.FindOptions{
+			Where:  repository.WhereType{"type": addrType},
+			Select: []string{"address"},
+			Limit:  uint(limit),
+			Offset: uint(offset),
+		})
 		if err != nil {
-			return fmt.Errorf("failed to create Bloom filter for %s: %w", addrType, err)
+			return err
+		}
+		if len(wallets) == 0 {
+			break
 		}
 
-		if rbf.walletAddressRepo == nil {
-			return errors.New("WalletAddressRepo was not provided in config")
+		// Rows written before addresses were canonicalized, e.g. EVM
+		// checksum addresses, are loaded in canonical form.
+		addresses := lo.Map(wallets, func(w *model.WalletAddress, _ int) string {
+			return addressutil.Canonical(addrType, w.Address)
+		})
+
+		logger.Info("Processing addresses", "addressType", addrType, "count", len(addresses))
+
+		if err := rbf.addBatchToBloom(ctx, loading, addresses); err != nil {
+			return err
 		}
+		rbf.rowsLoaded(addrType, len(addresses))
 
-		offset := 0
-		limit := rbf.batchSize
-		total := 0
+		offset += limit
+		total += len(addresses)
+	}
 
-		for {
-			wallets, err := rbf.walletAddressRepo.Find(ctx, repository.FindOptions{
-				Where:  repository.WhereType{"type": addrType},
-				Select: []string{"address"},
-				Limit:  uint(limit),
-				Offset: uint(offset),
-			})
-			if err != nil {
-				return err
-			}
-			if len(wallets) == 0 {
-				break
-			}
+	logger.Info("Redis Bloom filter initialized", "addressType", addrType, "total", total)
+	return nil
+}
 
-			addresses := lo.Map(wallets, func(w *model.WalletAddress, _ int) string {
-				return w.Address
-			})
+// keyParams are the parameters a key was reserved with; zero when unknown.
+type keyParams struct {
+	errorRate float64
+	capacity  int64
+}
 
-			logger.Info("Processing addresses", "addressType", addrType, "count", len(addresses))
+// checkKeyParams reports why a key reserved with stored, of which BF.INFO
+// returned info, cannot serve a filter of errorRate and capacity. Keys
+// reserved before their parameters were recorded are judged by BF.INFO,
+// which is only conclusive while the filter has not scaled.
+func checkKeyParams(stored keyParams, info redis.BFInfo, errorRate float64, capacity int64) error {
+	if stored == (keyParams{}) {
+		if info.Filters != 1 || info.Capacity != capacity {
+			return fmt.Errorf("reserved with unknown parameters, BF.INFO reports capacity %d in %d filters",
+				info.Capacity, info.Filters)
+		}
+		return nil
+	}
+	if stored.errorRate != errorRate || stored.capacity != capacity {
+		return fmt.Errorf("reserved with error rate %v and capacity %d, configured %v and %d",
+			stored.errorRate, stored.capacity, errorRate, capacity)
+	}
+	return nil
+}
 
-			if err := rbf.addBatchToBloom(ctx, key, addresses); err != nil {
-				return err
-			}
+// ensureKey reserves key unless it already holds a compatible filter. An
+// incompatible one is an error unless the filter may recreate it.
+func (rbf *redisBloomFilter) ensureKey(ctx context.Context, key string) error {
+	client := rbf.redisClient.GetClient()
 
-			offset += limit
-			total += len(addresses)
+	exists, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check existence of key %s: %w", key, err)
+	}
+	if exists == 1 {
+		info, err := client.BFInfo(ctx, key).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read Bloom filter info of %s: %w", key, err)
+		}
+		fields, err := client.HGetAll(ctx, paramsKey(key)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read parameters of %s: %w", key, err)
+		}
+		var stored keyParams
+		stored.errorRate, _ = strconv.ParseFloat(fields["error_rate"], 64)
+		stored.capacity, _ = strconv.ParseInt(fields["capacity"], 10, 64)
+
+		mismatch := checkKeyParams(stored, info, rbf.errorRate, int64(rbf.capacity))
+		if mismatch == nil {
+			if stored == (keyParams{}) {
+				return rbf.recordParams(ctx, client, key)
+			}
+			return nil
 		}
+		if !rbf.recreate {
+			return fmt.Errorf("bloom filter key %s is incompatible: %w; delete it or set recreate_incompatible", key, mismatch)
+		}
+		logger.Warn("Recreating incompatible Redis Bloom filter", "key", key, "reason", mismatch)
+		if err := client.Del(ctx, key, paramsKey(key)).Err(); err != nil {
+			return fmt.Errorf("failed to delete key %s: %w", key, err)
+		}
+	}
 
-		logger.Info("Redis Bloom filter initialized", "addressType", addrType, "total", total)
+	pipe := client.TxPipeline()
+	pipe.BFReserve(ctx, key, rbf.errorRate, int64(rbf.capacity))
+	rbf.pipeParams(ctx, pipe, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create Bloom filter %s: %w", key, err)
 	}
+	return nil
+}
 
+func (rbf *redisBloomFilter) recordParams(ctx context.Context, client *redis.Client, key string) error {
+	pipe := client.TxPipeline()
+	rbf.pipeParams(ctx, pipe, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record parameters of %s: %w", key, err)
+	}
 	return nil
 }
 
+func (rbf *redisBloomFilter) pipeParams(ctx context.Context, pipe redis.Pipeliner, key string) {
+	pipe.HSet(ctx, paramsKey(key), "error_rate", rbf.errorRate, "capacity", rbf.capacity)
+}
+
+// addBatchToBloom adds addresses with BF.MADD commands of at most
+// maddChunkSize addresses, sent in a single pipeline.
 func (rbf *redisBloomFilter) addBatchToBloom(
 	ctx context.Context,
 	key string,
 	addresses []string,
 ) error {
-	client := rbf.redisClient.GetClient()
-	args := make([]any, 0, len(addresses)+2)
-	args = append(args, "BF.MADD", key)
-	for _, addr := range addresses {
-		args = append(args, addr)
+	pipe := rbf.redisClient.GetClient().Pipeline()
+	for _, chunk := range lo.Chunk(addresses, rbf.maddChunkSize) {
+		pipe.BFMAdd(ctx, key, lo.ToAnySlice(chunk)...)
 	}
-	_, err := client.Do(ctx, args...).Result()
+	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// withRetry runs fn until it succeeds, fails with an error that is not
+// transient, or timeout has elapsed.
+func (rbf *redisBloomFilter) withRetry(ctx context.Context, timeout time.Duration, op string, fn func() error) error {
+	return retry.Exponential(func() error {
+		err := fn()
+		if err != nil && (ctx.Err() != nil || !isTransientRedisError(err)) {
+			return retry.Permanent(err)
+		}
+		return err
+	}, retry.ExponentialConfig{
+		InitialInterval: retryInitialInterval,
+		MaxElapsedTime:  timeout,
+		OnRetry: func(err error, next time.Duration) {
+			logger.Warn("Redis Bloom filter operation failed, retrying", "op", op, "error", err, "retryIn", next)
+		},
+	})
+}
+
+// isTransientRedisError reports whether err may go away on its own: a lost
+// connection, or Redis loading, failing over or out of connections. It
+// follows the go-redis client's own retry rules.
+func isTransientRedisError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, redis.Nil),
+		errors.Is(err, redis.ErrClosed):
+		return false
+	case errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, redis.ErrPoolTimeout):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var redisErr redis.Error
+	if !errors.As(err, &redisErr) {
+		return false
+	}
+	msg := redisErr.Error()
+	if msg == "ERR max number of clients reached" {
+		return true
+	}
+	for _, prefix := range []string{"LOADING ", "READONLY ", "MASTERDOWN ", "CLUSTERDOWN ", "TRYAGAIN ", "BUSY "} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (rbf *redisBloomFilter) Add(address string, addressType enum.NetworkType) {
 	rbf.mu.Lock()
 	defer rbf.mu.Unlock()
@@ -150,10 +420,13 @@ func (rbf *redisBloomFilter) Add(address string, addressType enum.NetworkType) {
 	key := rbf.getKey(addressType)
 	client := rbf.redisClient.GetClient()
 
-	_, err := client.Do(rbf.ctx, "BF.ADD", key, address).Result()
+	err := rbf.withRetry(rbf.ctx, rbf.retryTimeout, "add", func() error {
+		return client.Do(rbf.ctx, "BF.ADD", key, address).Err()
+	})
 	if err != nil {
 		logger.Error("Failed to add address to Redis bloom filter", "error", err)
 	}
+	rbf.addLoading(addressType, address)
 }
 
 func (rbf *redisBloomFilter) AddBatch(addresses []string, addressType enum.NetworkType) {
@@ -164,19 +437,32 @@ func (rbf *redisBloomFilter) AddBatch(addresses []string, addressType enum.Netwo
 	defer rbf.mu.Unlock()
 
 	key := rbf.getKey(addressType)
-	if err := rbf.addBatchToBloom(rbf.ctx, key, addresses); err != nil {
+	err := rbf.withRetry(rbf.ctx, rbf.retryTimeout, "add batch", func() error {
+		return rbf.addBatchToBloom(rbf.ctx, key, addresses)
+	})
+	if err != nil {
 		logger.Error("Failed to add batch to Redis bloom filter", "error", err)
 	}
+	rbf.addLoading(addressType, addresses...)
 }
 
 func (rbf *redisBloomFilter) Contains(address string, addressType enum.NetworkType) bool {
+	// Keys are incomplete until Initialize has loaded them.
+	if !rbf.IsReady() {
+		return rbf.notReadyAnswer()
+	}
 	rbf.mu.RLock()
 	defer rbf.mu.RUnlock()
 
 	key := rbf.getKey(addressType)
 	client := rbf.redisClient.GetClient()
 
-	result, err := client.Do(rbf.ctx, "BF.EXISTS", key, address).Bool()
+	var result bool
+	err := rbf.withRetry(rbf.ctx, rbf.retryTimeout, "contains", func() error {
+		var err error
+		result, err = client.Do(rbf.ctx, "BF.EXISTS", key, address).Bool()
+		return err
+	})
 	if err != nil {
 		logger.Error("Error checking Redis bloom filter", "error", err)
 		return false
@@ -189,13 +475,28 @@ func (rbf *redisBloomFilter) Clear(addressType enum.NetworkType) {
 	defer rbf.mu.Unlock()
 
 	key := rbf.getKey(addressType)
-	_ = rbf.redisClient.Del(key)
+	_ = rbf.redisClient.Del(key, paramsKey(key))
 }
 
+// Stats reports readiness, loading progress and the BF.INFO output of the
+// key of addressType.
 func (rbf *redisBloomFilter) Stats(addressType enum.NetworkType) map[string]any {
-	rbf.mu.RLock()
-	defer rbf.mu.RUnlock()
+	stats := rbf.stats(addressType)
+	stats["addressType"] = addressType
 
-	logger.Info("Redis Bloom filter not supported yet")
-	return nil
+	key := rbf.getKey(addressType)
+	stats["key"] = key
+	info, err := rbf.redisClient.GetClient().BFInfo(rbf.ctx, key).Result()
+	if err != nil {
+		stats["bfInfoError"] = err.Error()
+		return stats
+	}
+	stats["bfInfo"] = map[string]any{
+		"capacity":      info.Capacity,
+		"size":          info.Size,
+		"filters":       info.Filters,
+		"itemsInserted": info.ItemsInserted,
+		"expansionRate": info.ExpansionRate,
+	}
+	return stats
 }
@@ -2,12 +2,18 @@ package addressbloomfilter
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
 	"testing"
 
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/fystack/multichain-indexer/pkg/infra"
 	"github.com/fystack/multichain-indexer/pkg/model"
 	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,6 +65,9 @@ func TestRedisBloomFilter_AddAndContains(t *testing.T) {
 		Capacity:          1000,
 		BatchSize:         100,
 	})
+	// Not ready, so answering true for everything, until loaded.
+	assert.NoError(t, rbf.Initialize(ctx))
+	assert.True(t, rbf.IsReady())
 
 	// Test addresses
 	testAddresses := []string{
@@ -162,3 +171,54 @@ func (m *MockWalletAddressRepo) Find(
 	// Return empty result for testing
 	return []*model.WalletAddress{}, nil
 }
+
+func (m *MockWalletAddressRepo) CreateMany(
+	ctx context.Context,
+	entities []*model.WalletAddress,
+) (int64, error) {
+	return int64(len(entities)), nil
+}
+
+func TestCheckKeyParams(t *testing.T) {
+	fresh := redis.BFInfo{Capacity: 1000, Filters: 1}
+	scaled := redis.BFInfo{Capacity: 3000, Filters: 2}
+
+	assert.NoError(t, checkKeyParams(keyParams{errorRate: 0.01, capacity: 1000}, scaled, 0.01, 1000))
+	assert.ErrorContains(t, checkKeyParams(keyParams{errorRate: 0.001, capacity: 1000}, fresh, 0.01, 1000),
+		"reserved with error rate 0.001 and capacity 1000, configured 0.01 and 1000")
+	assert.Error(t, checkKeyParams(keyParams{errorRate: 0.01, capacity: 500}, fresh, 0.01, 1000))
+
+	// Unrecorded parameters: only an unscaled filter can be judged.
+	assert.NoError(t, checkKeyParams(keyParams{}, fresh, 0.01, 1000))
+	assert.Error(t, checkKeyParams(keyParams{}, scaled, 0.01, 1000))
+	assert.Error(t, checkKeyParams(keyParams{}, fresh, 0.01, 2000))
+}
+
+// replyError is an error reply from Redis.
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestIsTransientRedisError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{io.EOF, true},
+		{fmt.Errorf("failed to create Bloom filter: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{redis.ErrPoolTimeout, true},
+		{replyError("LOADING Redis is loading the dataset in memory"), true},
+		{replyError("READONLY You can't write against a read only replica."), true},
+		{replyError("ERR max number of clients reached"), true},
+		{replyError("ERR item exists"), false},
+		{replyError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{redis.Nil, false},
+		{redis.ErrClosed, false},
+		{context.Canceled, false},
+		{errors.New("boom"), false},
+	} {
+		assert.Equal(t, tc.want, isTransientRedisError(tc.err), "%v", tc.err)
+	}
+}
@@ -0,0 +1,71 @@
+package addressbloomfilter
+
+import (
+	"sync"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// RetiredSet holds the addresses retired, i.e. soft-deleted from
+// wallet_addresses, after a filter may have loaded them. A bloom filter
+// cannot forget an address, so lookups consult this set too. Addresses are
+// held in the form they are looked up in, canonical for their network type.
+// An address registered again is reinstated, dropping it from the set.
+type RetiredSet struct {
+	mu    sync.RWMutex
+	addrs map[enum.NetworkType]map[string]struct{}
+}
+
+func NewRetiredSet() *RetiredSet {
+	return &RetiredSet{addrs: make(map[enum.NetworkType]map[string]struct{})}
+}
+
+// Retire adds addresses to the set for addressType.
+func (s *RetiredSet) Retire(addresses []string, addressType enum.NetworkType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.addrs[addressType]
+	if !ok {
+		set = make(map[string]struct{}, len(addresses))
+		s.addrs[addressType] = set
+	}
+	for _, addr := range addresses {
+		set[addr] = struct{}{}
+	}
+}
+
+// Reinstate removes addresses registered again from the set for
+// addressType. A nil set holds nothing to remove.
+func (s *RetiredSet) Reinstate(addresses []string, addressType enum.NetworkType) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := s.addrs[addressType]
+	for _, addr := range addresses {
+		delete(set, addr)
+	}
+}
+
+// Contains reports whether address of addressType was retired. A nil set
+// contains nothing.
+func (s *RetiredSet) Contains(address string, addressType enum.NetworkType) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.addrs[addressType][address]
+	return ok
+}
+
+// Count returns how many addresses of addressType were retired.
+func (s *RetiredSet) Count(addressType enum.NetworkType) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.addrs[addressType])
+}
@@ -0,0 +1,94 @@
+package addressbloomfilter
+
+import (
+	"sync"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// ScriptNamespace is the filter namespace holding the script IDs watched on
+// networkType, next to its addresses. Script IDs are "script:" followed by
+// the hex SHA-256 of a scriptPubKey.
+func ScriptNamespace(networkType enum.NetworkType) enum.NetworkType {
+	return networkType + ":script"
+}
+
+// ScriptSet holds the labels of the watched scripts, by script ID. The
+// filter's script namespace answers most lookups; this set confirms its
+// positives and supplies the label. It also holds the redeem scripts
+// registered for script-hash addresses, by address.
+type ScriptSet struct {
+	mu     sync.RWMutex
+	labels map[enum.NetworkType]map[string]string
+	redeem map[enum.NetworkType]map[string]string
+}
+
+func NewScriptSet() *ScriptSet {
+	return &ScriptSet{
+		labels: make(map[enum.NetworkType]map[string]string),
+		redeem: make(map[enum.NetworkType]map[string]string),
+	}
+}
+
+// Watch adds the scripts of networkType, label by script ID.
+func (s *ScriptSet) Watch(labels map[string]string, networkType enum.NetworkType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.labels[networkType]
+	if !ok {
+		set = make(map[string]string, len(labels))
+		s.labels[networkType] = set
+	}
+	for id, label := range labels {
+		set[id] = label
+	}
+}
+
+// Label returns the label scriptID is watched with on networkType. A nil
+// set watches nothing.
+func (s *ScriptSet) Label(scriptID string, networkType enum.NetworkType) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	label, ok := s.labels[networkType][scriptID]
+	return label, ok
+}
+
+// Count returns how many scripts of networkType are watched.
+func (s *ScriptSet) Count(networkType enum.NetworkType) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.labels[networkType])
+}
+
+// AddRedeemScripts adds the redeem scripts of networkType, hex-encoded by
+// canonical address.
+func (s *ScriptSet) AddRedeemScripts(scripts map[string]string, networkType enum.NetworkType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.redeem[networkType]
+	if !ok {
+		set = make(map[string]string, len(scripts))
+		s.redeem[networkType] = set
+	}
+	for address, script := range scripts {
+		set[address] = script
+	}
+}
+
+// RedeemScript returns the redeem script registered for address on
+// networkType. A nil set holds none.
+func (s *ScriptSet) RedeemScript(address string, networkType enum.NetworkType) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	script, ok := s.redeem[networkType][address]
+	return script, ok
+}
@@ -0,0 +1,49 @@
+// Package addressutil converts addresses to the one canonical form per
+// network type that is stored, looked up in the bloom filter and emitted,
+// so an address registered in any accepted representation matches the
+// transfers indexed for it.
+package addressutil
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Normalize validates addr for networkType and returns its canonical form:
+//
+//   - EVM: lowercase hex with a 0x prefix, from either case or a correct
+//     EIP-55 checksum.
+//   - Tron: base58check, from base58, 41-prefixed hex or 0x-prefixed hex.
+//   - Bitcoin: lowercase bech32/bech32m re-encoded from the witness program
+//     for segwit, base58check otherwise.
+//   - Solana: the base58 public key.
+//
+// Network types without a dedicated validator are only trimmed. See
+// ValidateAll for the checks and the address types detected. Bitcoin
+// segwit addresses must use Bitcoin's HRP or one of hrps, a chain's
+// config.ChainConfig.SegwitHRPs.
+func Normalize(networkType enum.NetworkType, addr string, hrps ...string) (string, error) {
+	normalized, _, err := validate(networkType, addr, hrps)
+	return normalized, err
+}
+
+// Validates reports whether Normalize validates addresses of networkType,
+// rather than only trimming them.
+func Validates(networkType enum.NetworkType) bool {
+	_, ok := validators[networkType]
+	return ok
+}
+
+// Canonical is Normalize for addresses taken from chain data: an address
+// that does not validate, such as a TRC-10 asset ID in an asset field, is
+// returned trimmed rather than dropped. hrps are accepted as by Normalize.
+func Canonical(networkType enum.NetworkType, addr string, hrps ...string) string {
+	if normalized, err := Normalize(networkType, addr, hrps...); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(addr)
+}
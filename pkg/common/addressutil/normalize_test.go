@@ -0,0 +1,74 @@
+package addressutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	evmAddr  = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	tronAddr = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	btcAddr  = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	solAddr  = "11111111111111111111111111111111"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		nt      enum.NetworkType
+		in      string
+		want    string
+		invalid bool
+	}{
+		{enum.NetworkTypeEVM, evmAddr, evmAddr, false},
+		{enum.NetworkTypeEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", evmAddr, false},
+		{enum.NetworkTypeEVM, " 0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED ", evmAddr, false},
+		{enum.NetworkTypeEVM, "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", evmAddr, false},
+		{enum.NetworkTypeEVM, "0x1234", "", true},
+		{enum.NetworkTypeTron, tronAddr, tronAddr, false},
+		{enum.NetworkTypeTron, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", tronAddr, false},
+		{enum.NetworkTypeTron, "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c", tronAddr, false},
+		{enum.NetworkTypeTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", "", true},
+		{enum.NetworkTypeBtc, btcAddr, btcAddr, false},
+		{enum.NetworkTypeBtc, "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", btcAddr, false},
+		{enum.NetworkTypeBtc, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false},
+		{enum.NetworkTypeBtc, "bc1qinvalid", "", true},
+		{enum.NetworkTypeSol, solAddr, solAddr, false},
+		{enum.NetworkTypeSol, "0OIl", "", true},
+		{enum.NetworkTypeSui, "", "", true},
+	}
+	for _, tc := range cases {
+		got, err := Normalize(tc.nt, tc.in)
+		if tc.invalid {
+			assert.ErrorIs(t, err, ErrInvalidAddress, "%s %q", tc.nt, tc.in)
+			continue
+		}
+		require.NoError(t, err, "%s %q", tc.nt, tc.in)
+		assert.Equal(t, tc.want, got, "%s %q", tc.nt, tc.in)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, evmAddr, Canonical(enum.NetworkTypeEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"))
+	assert.Equal(t, "1002000", Canonical(enum.NetworkTypeTron, " 1002000 "), "invalid input kept")
+	assert.Equal(t, "", Canonical(enum.NetworkTypeSol, ""))
+}
+
+func TestNormalize_SegwitHRPs(t *testing.T) {
+	taproot, err := btcaddr.EncodeSegwitAddress("sbt", 1, make([]byte, 32))
+	require.NoError(t, err)
+	got, err := Normalize(enum.NetworkTypeBtc, strings.ToUpper(taproot), "sbt")
+	require.NoError(t, err)
+	assert.Equal(t, taproot, got)
+	assert.Equal(t, "p2tr", Validate(enum.NetworkTypeBtc, taproot, "obt", "sbt").Type)
+
+	// Not on a chain using another HRP, nor without the chain's.
+	_, err = Normalize(enum.NetworkTypeBtc, taproot, "obt")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = Normalize(enum.NetworkTypeBtc, taproot)
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+}
@@ -0,0 +1,159 @@
+package addressutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fystack/multichain-indexer/pkg/common/btcaddr"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/evmaddr"
+	"github.com/fystack/multichain-indexer/pkg/common/tronaddr"
+)
+
+// Validation is the outcome of validating one address, see ValidateAll.
+// Type is the kind of address detected, e.g. "p2tr" or "p2wpkh" for
+// Bitcoin; Display is the form to show users, see Display; Hex is the
+// 41-prefixed hex form of a TRON address, as node payloads carry it; Error
+// says why an invalid address was rejected.
+type Validation struct {
+	Address    string `json:"address"`
+	Valid      bool   `json:"valid"`
+	Normalized string `json:"normalized,omitempty"`
+	Display    string `json:"display,omitempty"`
+	Hex        string `json:"hex,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// validator checks a trimmed, non-empty address of one network type and
+// returns its canonical form and detected type. hrps are the segwit HRPs
+// accepted besides Bitcoin's.
+type validator func(addr string, hrps []string) (normalized, addrType string, err error)
+
+// validators are the network types whose addresses are checked; the
+// others are only trimmed.
+var validators = map[enum.NetworkType]validator{
+	enum.NetworkTypeEVM:  validateEVM,
+	enum.NetworkTypeTron: validateTron,
+	enum.NetworkTypeBtc:  validateBitcoin,
+	enum.NetworkTypeSol:  validateSolana,
+}
+
+// ValidateAll validates addrs for networkType, one Validation per address
+// in order. It is how addresses from users are checked, by the
+// registration API and the CLI alike; Normalize applies the same checks.
+// Bitcoin segwit addresses must use Bitcoin's HRP or one of hrps.
+func ValidateAll(networkType enum.NetworkType, addrs []string, hrps ...string) []Validation {
+	results := make([]Validation, len(addrs))
+	for i, addr := range addrs {
+		results[i] = Validate(networkType, addr, hrps...)
+	}
+	return results
+}
+
+// Validate validates a single address, see ValidateAll.
+func Validate(networkType enum.NetworkType, addr string, hrps ...string) Validation {
+	result := Validation{Address: addr}
+	normalized, addrType, err := validate(networkType, addr, hrps)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Valid, result.Normalized, result.Type = true, normalized, addrType
+	result.Display = Display(networkType, normalized)
+	if networkType == enum.NetworkTypeTron {
+		result.Hex, _ = tronaddr.Base58ToHex(normalized)
+	}
+	return result
+}
+
+// displayForms are the network types whose addresses are shown to users in
+// a form other than the canonical one.
+var displayForms = map[enum.NetworkType]func(string) string{
+	enum.NetworkTypeEVM: evmaddr.ToChecksumAddress,
+}
+
+// Display returns the form of the canonical address addr to show users:
+// EIP-55 checksummed for EVM, which is stored and matched lowercase, and
+// addr itself for other network types.
+func Display(networkType enum.NetworkType, addr string) string {
+	if f, ok := displayForms[networkType]; ok && addr != "" {
+		return f(addr)
+	}
+	return addr
+}
+
+func validate(networkType enum.NetworkType, addr string, hrps []string) (string, string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", "", fmt.Errorf("%w: empty", ErrInvalidAddress)
+	}
+	v, ok := validators[networkType]
+	if !ok {
+		return addr, "", nil
+	}
+	normalized, addrType, err := v(addr, hrps)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+	return normalized, addrType, nil
+}
+
+// validateEVM accepts 20-byte hex addresses in one case, or mixed case
+// carrying a correct EIP-55 checksum, so a mistyped checksummed address is
+// caught rather than lowercased.
+func validateEVM(addr string, _ []string) (string, string, error) {
+	lower, checksummed, err := evmaddr.ParseAddress(addr)
+	if err != nil {
+		return "", "", err
+	}
+	if checksummed {
+		return lower, "checksummed", nil
+	}
+	return lower, "unchecksummed", nil
+}
+
+// validateTron accepts base58check addresses and their 41-prefixed or
+// 0x-prefixed hex form, reported as type "base58" or "hex". A base58
+// address with a bad checksum is reported as such rather than as hex.
+func validateTron(addr string, _ []string) (string, string, error) {
+	_, err := tronaddr.Base58ToHex(addr)
+	if err == nil {
+		return addr, "base58", nil
+	}
+	if errors.Is(err, tronaddr.ErrBadChecksum) {
+		return "", "", err
+	}
+	normalized, err := tronaddr.HexToBase58(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("not a TRON address")
+	}
+	return normalized, "hex", nil
+}
+
+// validateBitcoin accepts base58check and segwit addresses, typed by the
+// output they pay, see btcaddr.DetectAddressType.
+func validateBitcoin(addr string, hrps []string) (string, string, error) {
+	normalized, err := btcaddr.NormalizeBTCAddress(addr, hrps...)
+	if err != nil {
+		return "", "", err
+	}
+	return normalized, btcaddr.DetectAddressType(normalized, hrps...), nil
+}
+
+// validateSolana accepts 32-byte base58 public keys. Keys on the ed25519
+// curve are typed "wallet"; program derived addresses, which are off it
+// and have no private key, "pda".
+func validateSolana(addr string, _ []string) (string, string, error) {
+	key := base58.Decode(addr)
+	if len(key) != 32 {
+		return "", "", fmt.Errorf("not a base58 public key")
+	}
+	if _, err := new(edwards25519.Point).SetBytes(key); err != nil {
+		return addr, "pda", nil
+	}
+	return addr, "wallet", nil
+}
@@ -0,0 +1,95 @@
+package addressutil
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		nt         enum.NetworkType
+		in         string
+		normalized string
+		addrType   string
+		invalid    bool
+	}{
+		// EIP-55 test vectors: one case is accepted unchecksummed, mixed
+		// case only with the right checksum.
+		{enum.NetworkTypeEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", evmAddr, "checksummed", false},
+		{enum.NetworkTypeEVM, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "checksummed", false},
+		{enum.NetworkTypeEVM, "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", "0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb", "checksummed", false},
+		{enum.NetworkTypeEVM, evmAddr, evmAddr, "unchecksummed", false},
+		{enum.NetworkTypeEVM, "0x52908400098527886E0F7030069857D2E4169EE7", "0x52908400098527886e0f7030069857d2e4169ee7", "unchecksummed", false},
+		{enum.NetworkTypeEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", "", true},
+		{enum.NetworkTypeEVM, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae", "", "", true},
+		{enum.NetworkTypeEVM, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz", "", "", true},
+
+		{enum.NetworkTypeTron, tronAddr, tronAddr, "base58", false},
+		{enum.NetworkTypeTron, "TLa2f6VPqDgRE67v1736s7bJ8Ray5wYjU7", "TLa2f6VPqDgRE67v1736s7bJ8Ray5wYjU7", "base58", false},
+		{enum.NetworkTypeTron, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", tronAddr, "hex", false},
+		{enum.NetworkTypeTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", "", "", true},
+		{enum.NetworkTypeTron, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "", "", true},
+
+		{enum.NetworkTypeBtc, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "p2pkh", false},
+		{enum.NetworkTypeBtc, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "p2sh", false},
+		{enum.NetworkTypeBtc, "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", "p2sh", false},
+		{enum.NetworkTypeBtc, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "p2wpkh", false},
+		{enum.NetworkTypeBtc, "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", "p2wsh", false},
+		{enum.NetworkTypeBtc, "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "p2tr", false},
+		{enum.NetworkTypeBtc, "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "witness_v2", false},
+		{enum.NetworkTypeBtc, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "", "", true},
+		{enum.NetworkTypeBtc, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "", "", true},
+		{enum.NetworkTypeBtc, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", "", "", true},
+
+		// 32 zero bytes decode to a point on the curve.
+		{enum.NetworkTypeSol, solAddr, solAddr, "wallet", false},
+		{enum.NetworkTypeSol, "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM", "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM", "wallet", false},
+		{enum.NetworkTypeSol, "4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T", "4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T", "pda", false},
+		{enum.NetworkTypeSol, "1111111111111111111111111111111", "", "", true},
+		{enum.NetworkTypeSol, "0OIl1111111111111111111111111111", "", "", true},
+
+		{enum.NetworkTypeEVM, "  ", "", "", true},
+		{enum.NetworkTypeApt, " 0xabc ", "0xabc", "", false},
+	}
+	for _, c := range cases {
+		got := Validate(c.nt, c.in)
+		assert.Equal(t, c.in, got.Address)
+		if c.invalid {
+			assert.False(t, got.Valid, "%s %q", c.nt, c.in)
+			assert.NotEmpty(t, got.Error, "%s %q", c.nt, c.in)
+			continue
+		}
+		assert.True(t, got.Valid, "%s %q: %s", c.nt, c.in, got.Error)
+		assert.Equal(t, c.normalized, got.Normalized, "%s %q", c.nt, c.in)
+		assert.Equal(t, c.addrType, got.Type, "%s %q", c.nt, c.in)
+	}
+}
+
+func TestValidateAll_KeepsOrder(t *testing.T) {
+	got := ValidateAll(enum.NetworkTypeTron, []string{"nope", tronAddr, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"})
+	assert.Len(t, got, 3)
+	assert.False(t, got[0].Valid)
+	assert.Equal(t, tronAddr, got[1].Normalized)
+	assert.Equal(t, "hex", got[2].Type)
+}
+
+func TestDisplay(t *testing.T) {
+	got := Validate(enum.NetworkTypeEVM, "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED")
+	assert.Equal(t, evmAddr, got.Normalized)
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", got.Display)
+
+	assert.Equal(t, tronAddr, Display(enum.NetworkTypeTron, tronAddr))
+	assert.Empty(t, Display(enum.NetworkTypeEVM, ""))
+}
+
+func TestValidate_TronForms(t *testing.T) {
+	got := Validate(enum.NetworkTypeTron, "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	assert.Equal(t, tronAddr, got.Normalized)
+	assert.Equal(t, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", got.Hex)
+
+	got = Validate(enum.NetworkTypeTron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u")
+	assert.False(t, got.Valid)
+	assert.Contains(t, got.Error, "checksum")
+}
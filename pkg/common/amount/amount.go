@@ -0,0 +1,93 @@
+// Package amount converts amounts between the smallest unit of a coin or
+// token (raw, e.g. wei) and whole units (display, e.g. ether), and formats
+// emitted transfers for the services amount_format setting.
+package amount
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/shopspring/decimal"
+)
+
+// ToDisplay returns raw, an integer in the smallest unit, in whole units.
+func ToDisplay(raw string, decimals uint8) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(raw))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("raw amount %q: %w", raw, err)
+	}
+	if !d.IsInteger() {
+		return decimal.Zero, fmt.Errorf("raw amount %q is not an integer", raw)
+	}
+	return d.Shift(-int32(decimals)), nil
+}
+
+// ToRaw returns display, an amount in whole units, as an integer in the
+// smallest unit. An amount more precise than decimals allows is an error
+// rather than rounded.
+func ToRaw(display string, decimals uint8) (string, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(display))
+	if err != nil {
+		return "", fmt.Errorf("amount %q: %w", display, err)
+	}
+	raw := d.Shift(int32(decimals))
+	if !raw.IsInteger() {
+		return "", fmt.Errorf("amount %q has more than %d decimals", display, decimals)
+	}
+	return raw.StringFixed(0), nil
+}
+
+// nativeUnits are the smallest native units of each network type.
+var nativeUnits = map[enum.NetworkType]struct {
+	name     string
+	decimals uint8
+}{
+	enum.NetworkTypeEVM:    {"wei", 18},
+	enum.NetworkTypeTron:   {"sun", 6},
+	enum.NetworkTypeBtc:    {"sat", 8},
+	enum.NetworkTypeSol:    {"lamport", 9},
+	enum.NetworkTypeApt:    {"octa", 8},
+	enum.NetworkTypeSui:    {"mist", 9},
+	enum.NetworkTypeTon:    {"nanoton", 9},
+	enum.NetworkTypeCosmos: {"", 6},
+}
+
+// microDenomPrefix marks a Cosmos denom in millionths of the coin, e.g.
+// uatom.
+const microDenomPrefix = "u"
+
+// NativeDecimals returns the decimals of networkType's native coin. A
+// Cosmos chain's coin is named by denom; one not in micro units has no
+// known default and returns 0.
+func NativeDecimals(networkType enum.NetworkType, denom string) uint8 {
+	if networkType == enum.NetworkTypeCosmos && !strings.HasPrefix(denom, microDenomPrefix) {
+		return 0
+	}
+	return nativeUnits[networkType].decimals
+}
+
+// nativeUnitName returns the name of networkType's smallest native unit,
+// the denom itself on Cosmos chains.
+func nativeUnitName(networkType enum.NetworkType, denom string) string {
+	if networkType == enum.NetworkTypeCosmos {
+		return denom
+	}
+	return nativeUnits[networkType].name
+}
+
+// FeeAmount returns fee, a TxFee as the indexers state it, as the Amount of
+// a fee record: an integer in the smallest native unit of networkType.
+func FeeAmount(networkType enum.NetworkType, denom string, fee decimal.Decimal) string {
+	return fee.Shift(legacyFeeDecimals(networkType, denom)).StringFixed(0)
+}
+
+// legacyFeeDecimals returns how many decimals below the smallest unit the
+// indexers state TxFee in for networkType: whole coins, except on Solana,
+// in lamports, and on Cosmos chains whose fee denom is not in micro units.
+func legacyFeeDecimals(networkType enum.NetworkType, denom string) int32 {
+	if networkType == enum.NetworkTypeSol {
+		return 0
+	}
+	return int32(NativeDecimals(networkType, denom))
+}
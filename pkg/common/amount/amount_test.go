@@ -0,0 +1,116 @@
+package amount
+
+import (
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToRawToDisplay(t *testing.T) {
+	raw, err := ToRaw("1.5", 18)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", raw)
+
+	raw, err = ToRaw("1000", 8)
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000", raw)
+
+	_, err = ToRaw("0.000000001", 8)
+	assert.Error(t, err, "more precise than a satoshi")
+	_, err = ToRaw("abc", 8)
+	assert.Error(t, err)
+
+	display, err := ToDisplay("1500000000000000000", 18)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", display.String())
+
+	display, err = ToDisplay("12345", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", display.String())
+
+	_, err = ToDisplay("1.5", 6)
+	assert.Error(t, err, "raw amounts are integers")
+}
+
+func TestNativeDecimals(t *testing.T) {
+	assert.Equal(t, uint8(18), NativeDecimals(enum.NetworkTypeEVM, ""))
+	assert.Equal(t, uint8(8), NativeDecimals(enum.NetworkTypeBtc, ""))
+	assert.Equal(t, uint8(6), NativeDecimals(enum.NetworkTypeCosmos, "uatom"))
+	assert.Equal(t, uint8(0), NativeDecimals(enum.NetworkTypeCosmos, "aevmos"), "no default")
+}
+
+func TestFeeAmount(t *testing.T) {
+	assert.Equal(t, "630000000000000", FeeAmount(enum.NetworkTypeEVM, "", decimal.RequireFromString("0.00063")))
+	assert.Equal(t, "21000", FeeAmount(enum.NetworkTypeBtc, "", decimal.RequireFromString("0.00021")))
+	assert.Equal(t, "5000", FeeAmount(enum.NetworkTypeSol, "", decimal.NewFromInt(5000)), "Solana fees are in lamports")
+}
+
+func TestFormatterLegacy(t *testing.T) {
+	tx := types.Transaction{Amount: "1", TxFee: decimal.RequireFromString("0.00063")}
+	tx.SetDisplayAmount("1.5", types.AmountUnit{Name: "ordi", Decimals: 18})
+
+	NewFormatter(FormatLegacy, enum.NetworkTypeBtc, "", 0, nil).Format(&tx)
+
+	assert.Equal(t, "1.5", tx.Amount, "unchanged")
+	assert.Equal(t, "0.00063", tx.TxFee.String())
+	assert.Nil(t, tx.Unit)
+	assert.Nil(t, tx.FeeUnit)
+
+	var unset *Formatter
+	tx.Unit = &types.AmountUnit{}
+	unset.Format(&tx)
+	assert.Nil(t, tx.Unit, "no formatter is legacy")
+}
+
+func TestFormatterRaw(t *testing.T) {
+	const token = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	evm := NewFormatter(FormatRaw, enum.NetworkTypeEVM, "", 0, map[string]uint8{token: 6})
+
+	native := types.Transaction{Amount: "1000", Type: constant.TxTypeNativeTransfer, TxFee: decimal.RequireFromString("0.00063")}
+	evm.Format(&native)
+	assert.Equal(t, "1000", native.Amount)
+	assert.Equal(t, &types.AmountUnit{Name: "wei", Decimals: 18}, native.Unit)
+	assert.Equal(t, "630000000000000", native.TxFee.String())
+	assert.Equal(t, &types.AmountUnit{Name: "wei", Decimals: 18}, native.FeeUnit)
+
+	usdc := types.Transaction{AssetAddress: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Amount: "2500000"}
+	evm.Format(&usdc)
+	assert.Equal(t, &types.AmountUnit{Decimals: 6}, usdc.Unit, "configured token, matched in any address form")
+
+	unknown := types.Transaction{AssetAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7", Amount: "1"}
+	evm.Format(&unknown)
+	assert.Nil(t, unknown.Unit)
+	assert.NotNil(t, unknown.FeeUnit)
+
+	// BRC-20 amounts are converted from whole tokens.
+	brc20 := types.Transaction{AssetAddress: "brc20:ordi", TxFee: decimal.RequireFromString("0.0001")}
+	brc20.SetDisplayAmount("1.5", types.AmountUnit{Name: "ordi", Decimals: 18})
+	NewFormatter(FormatRaw, enum.NetworkTypeBtc, "", 0, nil).Format(&brc20)
+	assert.Equal(t, "1500000000000000000", brc20.Amount)
+	assert.False(t, brc20.DisplayAmount())
+	assert.Equal(t, &types.AmountUnit{Name: "ordi", Decimals: 18}, brc20.Unit)
+	assert.Equal(t, "10000", brc20.TxFee.String())
+	assert.Equal(t, &types.AmountUnit{Name: "sat", Decimals: 8}, brc20.FeeUnit)
+
+	// Solana fees are already in lamports.
+	sol := types.Transaction{Amount: "5", TxFee: decimal.NewFromInt(5000)}
+	NewFormatter(FormatRaw, enum.NetworkTypeSol, "", 0, nil).Format(&sol)
+	assert.Equal(t, "5000", sol.TxFee.String())
+	assert.Equal(t, &types.AmountUnit{Name: "lamport", Decimals: 9}, sol.FeeUnit)
+
+	// Cosmos fees are whole coins for micro denoms.
+	atom := types.Transaction{Amount: "1", TxFee: decimal.RequireFromString("0.005")}
+	NewFormatter(FormatRaw, enum.NetworkTypeCosmos, "uatom", 0, nil).Format(&atom)
+	assert.Equal(t, "5000", atom.TxFee.String())
+	assert.Equal(t, &types.AmountUnit{Name: "uatom", Decimals: 6}, atom.Unit)
+
+	// A configured native precision overrides the default.
+	evmos := types.Transaction{Amount: "1"}
+	NewFormatter(FormatRaw, enum.NetworkTypeCosmos, "aevmos", 18, nil).Format(&evmos)
+	assert.Equal(t, &types.AmountUnit{Name: "aevmos", Decimals: 18}, evmos.Unit)
+}
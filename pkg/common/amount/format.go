@@ -0,0 +1,97 @@
+package amount
+
+import (
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// Format selects how emitted transfers state their amounts.
+type Format string
+
+const (
+	// FormatLegacy emits amounts as the indexers always have: Amount in
+	// the smallest unit, except BRC-20 amounts in whole tokens, and TxFee
+	// in whole coins, except on Solana. No units are attached.
+	FormatLegacy Format = "legacy"
+	// FormatRaw emits Amount and TxFee as integers in the smallest unit,
+	// with Unit and FeeUnit stating it.
+	FormatRaw Format = "raw"
+)
+
+// Formatter formats one chain's transfers.
+type Formatter struct {
+	format      Format
+	networkType enum.NetworkType
+	native      types.AmountUnit
+	feeDecimals int32
+	tokens      map[string]uint8
+}
+
+// NewFormatter returns a Formatter for a chain of networkType whose native
+// coin is named by denom (Cosmos chains) and has nativeDecimals decimals,
+// 0 for the network type's default. tokenDecimals gives the decimals of
+// token assets by address, for tokens whose transfers do not state them.
+func NewFormatter(
+	format Format,
+	networkType enum.NetworkType,
+	denom string,
+	nativeDecimals uint8,
+	tokenDecimals map[string]uint8,
+) *Formatter {
+	if nativeDecimals == 0 {
+		nativeDecimals = NativeDecimals(networkType, denom)
+	}
+	tokens := make(map[string]uint8, len(tokenDecimals))
+	for asset, decimals := range tokenDecimals {
+		tokens[addressutil.Canonical(networkType, asset)] = decimals
+	}
+	return &Formatter{
+		format:      format,
+		networkType: networkType,
+		native:      types.AmountUnit{Name: nativeUnitName(networkType, denom), Decimals: nativeDecimals},
+		feeDecimals: legacyFeeDecimals(networkType, denom),
+		tokens:      tokens,
+	}
+}
+
+// Format rewrites tx's amounts in the formatter's format. In raw format an
+// Amount in whole units is converted when its unit is known, and left as
+// it is, with no Unit, otherwise; a token Amount of unknown decimals gets
+// no Unit either.
+func (f *Formatter) Format(tx *types.Transaction) {
+	if f == nil || f.format != FormatRaw {
+		tx.Unit, tx.FeeUnit = nil, nil
+		return
+	}
+
+	unit := tx.Unit
+	if unit == nil {
+		unit = f.unit(tx.AssetAddress)
+	}
+	if tx.DisplayAmount() && unit != nil {
+		if raw, err := ToRaw(tx.Amount, unit.Decimals); err == nil {
+			tx.SetRawAmount(raw)
+		}
+	}
+	if tx.DisplayAmount() {
+		unit = nil // not convertible, so not in the smallest unit
+	}
+	tx.Unit = unit
+
+	native := f.native
+	tx.TxFee = tx.TxFee.Shift(f.feeDecimals)
+	tx.FeeUnit = &native
+}
+
+// unit returns the unit of amounts of asset, nil when not known.
+func (f *Formatter) unit(asset string) *types.AmountUnit {
+	if asset == "" {
+		native := f.native
+		return &native
+	}
+	if decimals, ok := f.tokens[addressutil.Canonical(f.networkType, asset)]; ok {
+		return &types.AmountUnit{Decimals: decimals}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package btcaddr validates, encodes and classifies Bitcoin addresses:
+// base58check P2PKH and P2SH, and segwit addresses of every witness
+// version.
+package btcaddr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// NormalizeBTCAddress validates and normalizes a Bitcoin address
+// Supports both mainnet and testnet addresses in various formats, and
+// segwit addresses with one of hrps besides Bitcoin's
+func NormalizeBTCAddress(addr string, hrps ...string) (string, error) {
+	addr = strings.TrimSpace(addr)
+
+	if addr == "" {
+		return "", fmt.Errorf("empty address")
+	}
+
+	laddr := strings.ToLower(addr)
+
+	// Segwit addresses of any witness version (bech32 for v0, bech32m for
+	// v1-16 per BIP-350).
+	if HasSegwitPrefix(laddr, hrps...) {
+		canonical, err := CanonicalSegwitAddress(addr, hrps...)
+		if err != nil {
+			return "", fmt.Errorf("invalid bech32 address: %w", err)
+		}
+		return canonical, nil
+	}
+
+	// Base58Check validation for legacy addresses
+	decoded := base58.Decode(addr)
+	if len(decoded) != 25 {
+		return "", fmt.Errorf("invalid address length: expected 25, got %d", len(decoded))
+	}
+
+	// Verify checksum (double SHA256)
+	payload := decoded[:21]
+	checksum := decoded[21:]
+	hash := sha256.Sum256(payload)
+	hash = sha256.Sum256(hash[:])
+
+	if hash[0] != checksum[0] || hash[1] != checksum[1] || hash[2] != checksum[2] || hash[3] != checksum[3] {
+		return "", fmt.Errorf("invalid address checksum")
+	}
+
+	// Validate version byte
+	versionByte := decoded[0]
+	validVersions := map[byte]string{
+		0x00: "p2pkh_mainnet",
+		0x05: "p2sh_mainnet",
+		0x6f: "p2pkh_testnet",
+		0xc4: "p2sh_testnet",
+	}
+
+	if _, ok := validVersions[versionByte]; !ok {
+		return "", fmt.Errorf("invalid version byte: 0x%02x", versionByte)
+	}
+
+	return addr, nil
+}
+
+// GetAddressType determines the type of Bitcoin address. Segwit outputs
+// beyond v1 are reported as "witness_v<N>_<network>".
+func GetAddressType(addr string) string {
+	addr = strings.TrimSpace(addr)
+
+	if laddr := strings.ToLower(addr); HasSegwitPrefix(laddr) {
+		return segwitAddressType(laddr)
+	}
+
+	switch {
+	// Mainnet addresses
+	case strings.HasPrefix(addr, "1"):
+		return "p2pkh_mainnet"
+	case strings.HasPrefix(addr, "3"):
+		return "p2sh_mainnet"
+
+	// Testnet addresses
+	case strings.HasPrefix(addr, "m") || strings.HasPrefix(addr, "n"):
+		return "p2pkh_testnet"
+	case strings.HasPrefix(addr, "2"):
+		return "p2sh_testnet"
+
+	default:
+		return "unknown"
+	}
+}
+
+// DetectAddressType names the output type a valid address pays: "p2pkh",
+// "p2sh", "p2wpkh", "p2wsh", "p2tr" or "witness_v<N>" for later witness
+// versions. Unlike GetAddressType it tells v0 outputs apart by program
+// length and leaves the network out. It returns "unknown" for an address
+// that does not decode, accepting segwit HRPs as NormalizeBTCAddress.
+func DetectAddressType(addr string, hrps ...string) string {
+	addr = strings.TrimSpace(addr)
+	if HasSegwitPrefix(strings.ToLower(addr), hrps...) {
+		_, version, program, err := DecodeSegwitAddress(addr, hrps...)
+		switch {
+		case err != nil:
+			return "unknown"
+		case version == 0 && len(program) == 20:
+			return "p2wpkh"
+		case version == 0:
+			return "p2wsh"
+		case version == 1:
+			return "p2tr"
+		}
+		return fmt.Sprintf("witness_v%d", version)
+	}
+
+	decoded := base58.Decode(addr)
+	if len(decoded) != 25 {
+		return "unknown"
+	}
+	switch decoded[0] {
+	case 0x00, 0x6f:
+		return "p2pkh"
+	case 0x05, 0xc4:
+		return "p2sh"
+	}
+	return "unknown"
+}
+
+// HasSegwitPrefix reports whether laddr starts with the separator and
+// Bitcoin's segwit HRP or one of hrps. The charset has no '1', so the last
+// one is the separator.
+func HasSegwitPrefix(laddr string, hrps ...string) bool {
+	sep := strings.LastIndexByte(laddr, '1')
+	return sep > 0 && isSegwitHRP(laddr[:sep], hrps)
+}
+
+// segwitAddressType names a segwit address by witness version. Addresses
+// that fail to decode fall back to the version character, so callers still
+// get a network for node-reported addresses.
+func segwitAddressType(laddr string) string {
+	network := "testnet"
+	if strings.HasPrefix(laddr, "bc1") {
+		network = "mainnet"
+	}
+
+	_, version, _, err := DecodeSegwitAddress(laddr)
+	if err != nil {
+		sep := strings.LastIndexByte(laddr, '1')
+		if sep+1 >= len(laddr) {
+			return "unknown"
+		}
+		v := strings.IndexByte(bech32Charset, laddr[sep+1])
+		if v < 0 || v > 16 {
+			return "unknown"
+		}
+		version = byte(v)
+	}
+
+	switch version {
+	case 0:
+		return "p2wpkh_" + network
+	case 1:
+		return "p2tr_" + network
+	default:
+		return fmt.Sprintf("witness_v%d_%s", version, network)
+	}
+}
+
+// IsTestnetAddress checks if an address is for testnet
+func IsTestnetAddress(addr string) bool {
+	addrType := GetAddressType(addr)
+	return strings.Contains(addrType, "testnet")
+}
+
+// IsMainnetAddress checks if an address is for mainnet
+func IsMainnetAddress(addr string) bool {
+	addrType := GetAddressType(addr)
+	return strings.Contains(addrType, "mainnet")
+}
+
+// ValidateAddressFormat validates that an address has a valid format
+func ValidateAddressFormat(addr string) error {
+	_, err := NormalizeBTCAddress(addr)
+	return err
+}
@@ -0,0 +1,127 @@
+package btcaddr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeBTCAddress_P2TR_Mainnet verifies that a P2TR mainnet address
+// (witness v1, bech32m) is accepted and returned unchanged (Bug #4).
+func TestNormalizeBTCAddress_P2TR_Mainnet(t *testing.T) {
+	addr := "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	got, err := NormalizeBTCAddress(addr)
+	require.NoError(t, err)
+	assert.Equal(t, addr, got)
+}
+
+// TestNormalizeBTCAddress_P2TR_Testnet verifies that a P2TR testnet address
+// (witness v1, bech32m) is accepted and returned unchanged (Bug #4).
+func TestNormalizeBTCAddress_P2TR_Testnet(t *testing.T) {
+	addr := "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c"
+	got, err := NormalizeBTCAddress(addr)
+	require.NoError(t, err)
+	assert.Equal(t, addr, got)
+}
+
+// TestNormalizeBTCAddress_P2TR_NormalizesUppercase verifies that an uppercase
+// P2TR address is lowercased and returned without error (Bug #4).
+func TestNormalizeBTCAddress_P2TR_NormalizesUppercase(t *testing.T) {
+	upper := "BC1P5CYXNUXMEUWUVKWFEM96LQZSZD02N6XDCJRS20CAC6YQJJWUDPXQKEDRCR"
+	got, err := NormalizeBTCAddress(upper)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", got)
+}
+
+// TestNormalizeBTCAddress_P2WPKH_StillWorks verifies that a P2WPKH (witness v0,
+// bech32) address is still accepted alongside bech32m addresses.
+func TestNormalizeBTCAddress_P2WPKH_StillWorks(t *testing.T) {
+	addr := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	got, err := NormalizeBTCAddress(addr)
+	require.NoError(t, err)
+	assert.Equal(t, addr, got)
+}
+
+// TestNormalizeBTCAddress_P2PKH_StillWorks verifies that a P2PKH (legacy base58)
+// address is still accepted and returned unchanged.
+// Uses 12higDjoCCNXSA95xZMWUdPvXNmkAduhWv — a well-known valid mainnet P2PKH address.
+func TestNormalizeBTCAddress_P2PKH_StillWorks(t *testing.T) {
+	addr := "12higDjoCCNXSA95xZMWUdPvXNmkAduhWv"
+	got, err := NormalizeBTCAddress(addr)
+	require.NoError(t, err)
+	assert.Equal(t, addr, got)
+}
+
+// TestNormalizeBTCAddress_Empty_ReturnsError verifies that an empty string
+// returns an error.
+func TestNormalizeBTCAddress_Empty_ReturnsError(t *testing.T) {
+	_, err := NormalizeBTCAddress("")
+	require.Error(t, err)
+}
+
+// TestNormalizeBTCAddress_WitnessVersions checks BIP-350 vectors: v1-16 need
+// bech32m and a 2-40 byte program, v0 needs bech32 and 20 or 32 bytes.
+func TestNormalizeBTCAddress_WitnessVersions(t *testing.T) {
+	valid := map[string]string{
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y": "p2tr_mainnet",
+		"BC1SW50QGDZ25J":                       "witness_v16_mainnet",
+		"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs": "witness_v2_mainnet",
+		"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy": "p2wpkh_testnet",
+	}
+	for addr, addrType := range valid {
+		got, err := NormalizeBTCAddress(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, strings.ToLower(addr), got)
+		assert.Equal(t, addrType, GetAddressType(addr), addr)
+	}
+
+	invalid := []string{
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", // v1 with bech32 checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",                     // v0 with bech32m checksum
+		"bc1pw5dgrnzv", // 1-byte program
+		"bc10w508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kw5rljs90", // 41-byte program
+		"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P",                                         // v0 16-byte program
+		"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",               // bad checksum
+	}
+	for _, addr := range invalid {
+		_, err := NormalizeBTCAddress(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestEncodeSegwitAddress_RoundTrip(t *testing.T) {
+	for version := byte(0); version <= 16; version++ {
+		program := make([]byte, 32)
+		for i := range program {
+			program[i] = byte(i) + version
+		}
+		addr, err := EncodeSegwitAddress("bc", version, program)
+		require.NoError(t, err)
+
+		hrp, gotVersion, gotProgram, err := DecodeSegwitAddress(addr)
+		require.NoError(t, err)
+		assert.Equal(t, "bc", hrp)
+		assert.Equal(t, version, gotVersion)
+		assert.Equal(t, program, gotProgram)
+	}
+
+	_, err := EncodeSegwitAddress("bc", 0, make([]byte, 16))
+	assert.Error(t, err, "v0 programs must be 20 or 32 bytes")
+}
+
+func TestDetectAddressType(t *testing.T) {
+	tests := map[string]string{
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":                             "p2pkh",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":                             "p2sh",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     "p2wpkh",
+		"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy": "p2wsh",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0": "p2tr",
+		"BC1SW50QGDZ25J": "witness_v16",
+		"bc1qinvalid":    "unknown",
+	}
+	for addr, want := range tests {
+		assert.Equal(t, want, DetectAddressType(addr), addr)
+	}
+}
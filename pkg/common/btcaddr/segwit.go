@@ -0,0 +1,222 @@
+package btcaddr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Segwit address encoding per BIP-173 (bech32, witness v0) and BIP-350
+// (bech32m, witness v1-16). btcutil v1.0.2 only implements bech32, so the
+// codec lives here.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+type bech32Variant int
+
+const (
+	variantBech32 bech32Variant = iota
+	variantBech32m
+)
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bitcoinHRPs are the human-readable parts of Bitcoin's segwit addresses:
+// mainnet's, testnet's and signet's, and regtest's. Those of other
+// networks, set per chain by bech32_hrp, are passed to the functions
+// decoding addresses.
+var bitcoinHRPs = []string{"bc", "tb", "bcrt"}
+
+// isSegwitHRP reports whether hrp is Bitcoin's or one of hrps.
+func isSegwitHRP(hrp string, hrps []string) bool {
+	return slices.Contains(bitcoinHRPs, hrp) || slices.Contains(hrps, hrp)
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Checksum(hrp string, data []byte, variant bech32Variant) []byte {
+	c := uint32(bech32Const)
+	if variant == variantBech32m {
+		c = bech32mConst
+	}
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ c
+	out := make([]byte, 6)
+	for i := range out {
+		out[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return out
+}
+
+// bech32Decode splits s into hrp and 5-bit data (without checksum) and
+// reports which checksum variant it carries.
+func bech32Decode(s string) (string, []byte, bech32Variant, error) {
+	if len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("bech32 string too long: %d", len(s))
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("bech32 string has mixed case")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, fmt.Errorf("invalid bech32 separator position")
+	}
+	hrp := s[:pos]
+	data := make([]byte, 0, len(s)-pos-1)
+	for _, c := range s[pos+1:] {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(idx))
+	}
+
+	var variant bech32Variant
+	switch bech32Polymod(append(bech32HRPExpand(hrp), data...)) {
+	case bech32Const:
+		variant = variantBech32
+	case bech32mConst:
+		variant = variantBech32m
+	default:
+		return "", nil, 0, fmt.Errorf("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], variant, nil
+}
+
+// convertBits regroups a byte slice from fromBits-wide to toBits-wide values.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<toBits - 1
+	var out []byte
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data range")
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
+
+// DecodeSegwitAddress decodes a segwit address into its HRP, witness version
+// and witness program, enforcing BIP-141/173/350 rules: v0 uses bech32 with a
+// 20 or 32 byte program, v1-16 use bech32m with a 2-40 byte program. The
+// HRP must be Bitcoin's or one of hrps.
+func DecodeSegwitAddress(addr string, hrps ...string) (hrp string, version byte, program []byte, err error) {
+	hrp, data, variant, err := bech32Decode(addr)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if !isSegwitHRP(hrp, hrps) {
+		return "", 0, nil, fmt.Errorf("unknown segwit hrp %q", hrp)
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return "", 0, nil, fmt.Errorf("invalid witness version")
+	}
+	version = data[0]
+	program, err = convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return "", 0, nil, fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	switch {
+	case version == 0 && variant != variantBech32:
+		return "", 0, nil, fmt.Errorf("witness v0 must use bech32")
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return "", 0, nil, fmt.Errorf("invalid witness v0 program length %d", len(program))
+	case version > 0 && variant != variantBech32m:
+		return "", 0, nil, fmt.Errorf("witness v%d must use bech32m", version)
+	}
+	return hrp, version, program, nil
+}
+
+// EncodeSegwitAddress encodes a witness program as a segwit address, using
+// bech32 for v0 and bech32m for later versions.
+func EncodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	data, err := convertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data = append([]byte{version}, data...)
+	variant := variantBech32
+	if version > 0 {
+		variant = variantBech32m
+	}
+	data = append(data, bech32Checksum(hrp, data, variant)...)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	addr := sb.String()
+	if _, _, _, err := DecodeSegwitAddress(addr, hrp); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// CanonicalSegwitAddress returns the canonical form of segwit address
+// addr: lowercase, re-encoded from the HRP, witness version and program it
+// decodes to, so its checksum and program are checked. Every segwit
+// address stored, matched or emitted goes through it, so the same address
+// given in uppercase or derived from a script compares equal. hrps are
+// accepted as by DecodeSegwitAddress.
+func CanonicalSegwitAddress(addr string, hrps ...string) (string, error) {
+	hrp, version, program, err := DecodeSegwitAddress(strings.TrimSpace(addr), hrps...)
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegwitAddress(hrp, version, program)
+}
+
+// IsValidSegwitAddress reports whether addr is a valid segwit address of any
+// witness version, with Bitcoin's HRP or one of hrps.
+func IsValidSegwitAddress(addr string, hrps ...string) bool {
+	_, _, _, err := DecodeSegwitAddress(addr, hrps...)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+package btcaddr
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomWitnessProgram returns a random valid witness version and program.
+func randomWitnessProgram(r *rand.Rand) (byte, []byte) {
+	version := byte(r.IntN(17))
+	size := 2 + r.IntN(39)
+	if version == 0 {
+		size = []int{20, 32}[r.IntN(2)]
+	}
+	program := make([]byte, size)
+	for i := range program {
+		program[i] = byte(r.IntN(256))
+	}
+	return version, program
+}
+
+// encodeWithVariant is EncodeSegwitAddress without the variant rules, to
+// build addresses with the wrong checksum variant.
+func encodeWithVariant(hrp string, version byte, program []byte, variant bech32Variant) string {
+	data, _ := convertBits(program, 8, 5, true)
+	data = append([]byte{version}, data...)
+	data = append(data, bech32Checksum(hrp, data, variant)...)
+	var sb strings.Builder
+	sb.WriteString(hrp + "1")
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	return sb.String()
+}
+
+func TestCanonicalSegwitAddress_FixedPoint(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	hrps := []string{"bc", "tb", "bcrt"}
+	for range 2000 {
+		hrp := hrps[r.IntN(len(hrps))]
+		version, program := randomWitnessProgram(r)
+		addr, err := EncodeSegwitAddress(hrp, version, program)
+		require.NoError(t, err)
+
+		gotHRP, gotVersion, gotProgram, err := DecodeSegwitAddress(addr)
+		require.NoError(t, err)
+		canonical, err := CanonicalSegwitAddress(addr)
+		require.NoError(t, err)
+		reencoded, err := EncodeSegwitAddress(gotHRP, gotVersion, gotProgram)
+		require.NoError(t, err)
+		require.Equal(t, addr, canonical)
+		require.Equal(t, canonical, reencoded, "canonicalize(encode(decode(addr)))")
+
+		upper, err := CanonicalSegwitAddress(strings.ToUpper(addr))
+		require.NoError(t, err)
+		require.Equal(t, canonical, upper)
+		normalized, err := NormalizeBTCAddress(" " + strings.ToUpper(addr) + " ")
+		require.NoError(t, err)
+		require.Equal(t, canonical, normalized)
+	}
+}
+
+func TestCanonicalSegwitAddress_RejectsInvalid(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 2000 {
+		version, program := randomWitnessProgram(r)
+		addr, err := EncodeSegwitAddress("bc", version, program)
+		require.NoError(t, err)
+
+		// bech32 detects every single-character substitution.
+		i := len("bc1") + r.IntN(len(addr)-len("bc1"))
+		c := bech32Charset[r.IntN(len(bech32Charset))]
+		if c == addr[i] {
+			continue
+		}
+		corrupt := addr[:i] + string(c) + addr[i+1:]
+		_, err = CanonicalSegwitAddress(corrupt)
+		require.Error(t, err, "substituted %s", corrupt)
+		_, err = NormalizeBTCAddress(corrupt)
+		require.Error(t, err)
+	}
+
+	mixed := "bc1P5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	_, err := CanonicalSegwitAddress(mixed)
+	assert.Error(t, err, "mixed case")
+
+	program := make([]byte, 32)
+	_, err = CanonicalSegwitAddress(encodeWithVariant("bc", 1, program, variantBech32))
+	assert.Error(t, err, "taproot with a bech32 checksum")
+	_, err = CanonicalSegwitAddress(encodeWithVariant("bc", 0, program, variantBech32m))
+	assert.Error(t, err, "v0 with a bech32m checksum")
+	_, err = CanonicalSegwitAddress(encodeWithVariant("xx", 1, program, variantBech32m))
+	assert.Error(t, err, "unregistered hrp")
+}
+
+func TestSegwitHRPs(t *testing.T) {
+	program := make([]byte, 32)
+	addr := encodeWithVariant("tbs", 1, program, variantBech32m)
+	_, err := NormalizeBTCAddress(addr)
+	require.Error(t, err, "not one of Bitcoin's HRPs")
+	_, err = NormalizeBTCAddress(addr, "sbt")
+	require.Error(t, err, "another chain's HRP")
+
+	got, err := NormalizeBTCAddress(strings.ToUpper(addr), "tbs")
+	require.NoError(t, err)
+	assert.Equal(t, addr, got)
+	assert.Equal(t, "p2tr", DetectAddressType(addr, "tbs"))
+	assert.Equal(t, "unknown", DetectAddressType(addr))
+
+	encoded, err := EncodeSegwitAddress("tbs", 1, program)
+	require.NoError(t, err)
+	assert.Equal(t, addr, encoded)
+}
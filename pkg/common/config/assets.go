@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// AssetFilterConfig limits the token transfers a chain extracts to some
+// assets, e.g. the stablecoins a deployment cares about. Entries are token
+// contract addresses, or TRC-10 asset IDs on Tron, in any form the
+// network's addresses are accepted in. Native transfers are always
+// extracted. With Allow set only the listed assets are; Deny drops assets
+// otherwise extracted. Applies to EVM and Tron chains, and is reapplied to
+// running chains when the config is reloaded.
+type AssetFilterConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// IsZero reports whether every asset is extracted.
+func (a AssetFilterConfig) IsZero() bool {
+	return len(a.Allow) == 0 && len(a.Deny) == 0
+}
+
+// NormalizeAsset returns the canonical form of an asset entry for
+// networkType, the form transfers' asset addresses are matched in, see
+// addressutil.Normalize. On Tron an all-digit TRC-10 asset ID is kept as
+// is.
+func NormalizeAsset(networkType enum.NetworkType, asset string) (string, error) {
+	asset = strings.TrimSpace(asset)
+	if asset == "" {
+		return "", errors.New("empty asset")
+	}
+	if networkType == enum.NetworkTypeTron && isTRC10AssetID(asset) {
+		return asset, nil
+	}
+	return addressutil.Normalize(networkType, asset)
+}
+
+// isTRC10AssetID reports whether s is a TRC-10 asset ID, a decimal number.
+func isTRC10AssetID(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// validateAssets checks every entry of assets, naming each one that is not
+// an asset of networkType.
+func validateAssets(networkType enum.NetworkType, assets AssetFilterConfig) error {
+	var errs []error
+	for _, list := range []struct {
+		field   string
+		entries []string
+	}{{"allow", assets.Allow}, {"deny", assets.Deny}} {
+		for i, entry := range list.entries {
+			if _, err := NormalizeAsset(networkType, entry); err != nil {
+				errs = append(errs, fmt.Errorf("assets.%s[%d] %q: %w", list.field, i, entry, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
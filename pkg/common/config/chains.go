@@ -2,9 +2,12 @@ package config
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
-	"dario.cat/mergo"
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
 )
 
 // GetChain returns a chain config by name.
@@ -16,6 +19,28 @@ func (c Chains) GetChain(name string) (ChainConfig, error) {
 	return chain, nil
 }
 
+// GetChainByNetworkId returns the config of the chain with the given
+// network_id, which validation keeps unique.
+func (c *Config) GetChainByNetworkId(networkID string) (ChainConfig, error) {
+	for _, chain := range c.Chains {
+		if chain.NetworkId == networkID {
+			return chain, nil
+		}
+	}
+	return ChainConfig{}, fmt.Errorf("chain not found for network_id: %s", networkID)
+}
+
+// GetChainByInternalCode returns the config of the chain with the given
+// internal_code, which validation keeps unique.
+func (c *Config) GetChainByInternalCode(internalCode string) (ChainConfig, error) {
+	for _, chain := range c.Chains {
+		if chain.InternalCode == internalCode {
+			return chain, nil
+		}
+	}
+	return ChainConfig{}, fmt.Errorf("chain not found for internal_code: %s", internalCode)
+}
+
 // Names returns all chain names.
 func (c Chains) Names() []string {
 	names := make([]string, 0, len(c))
@@ -25,6 +50,22 @@ func (c Chains) Names() []string {
 	return names
 }
 
+// SegwitHRPs returns the HRPs of every chain's SegwitHRPs, once each and
+// sorted: those accepted for addresses registered by network type, which
+// every chain of the type watches.
+func (c Chains) SegwitHRPs() []string {
+	var hrps []string
+	for _, chain := range c {
+		for _, hrp := range chain.SegwitHRPs() {
+			if !slices.Contains(hrps, hrp) {
+				hrps = append(hrps, hrp)
+			}
+		}
+	}
+	slices.Sort(hrps)
+	return hrps
+}
+
 // Validate checks if given chain names exist in config.
 func (c Chains) Validate(names []string) error {
 	for _, name := range names {
@@ -45,7 +86,9 @@ func (c Chains) OverrideFromLatest(names []string) {
 	}
 }
 
-// ApplyDefaults merges global defaults into all chain configs.
+// ApplyDefaults resolves every chain config against the global defaults.
+// After this runs, chain configs are self-contained and the rest of the
+// code never needs to consult Defaults.
 func (c Chains) ApplyDefaults(def Defaults) error {
 	for name, chain := range c {
 		if strings.TrimSpace(chain.NetworkId) == "" {
@@ -54,25 +97,179 @@ func (c Chains) ApplyDefaults(def Defaults) error {
 		if strings.TrimSpace(chain.InternalCode) == "" {
 			chain.InternalCode = strings.ToUpper(name)
 		}
-		if !chain.FromLatest {
-			chain.FromLatest = def.FromLatest
-		}
-		if !chain.TwoWayIndexing {
-			chain.TwoWayIndexing = def.TwoWayIndexing
-		}
-		if chain.PollInterval == 0 {
-			chain.PollInterval = def.PollInterval
-		}
-		if chain.ReorgRollbackWindow == 0 {
-			chain.ReorgRollbackWindow = def.ReorgRollbackWindow
-		}
-		if err := mergo.Merge(&chain.Client, def.Client); err != nil {
-			return fmt.Errorf("merge client defaults for %s: %w", name, err)
-		}
-		if err := mergo.Merge(&chain.Throttle, def.Throttle); err != nil {
-			return fmt.Errorf("merge throttle defaults for %s: %w", name, err)
-		}
-		c[name] = chain
+		c[name] = ResolveChainConfig(def, chain)
 	}
 	return nil
 }
+
+// applyEnvDefaults sets the defaults that depend on the environment:
+// development checks every transaction's value conservation, production
+// samples.
+func (d *Defaults) applyEnvDefaults(env Env) {
+	if d.ValueCheck.SampleRate == 0 && !d.explicit["value_check.sample_rate"] {
+		d.ValueCheck.SampleRate = ProdValueCheckSampleRate
+		if env == DevEnv {
+			d.ValueCheck.SampleRate = 1
+		}
+	}
+}
+
+// ResolveChainConfig merges defaults into a chain config field by field.
+// A non-zero chain value always wins. A zero chain value inherits the
+// default unless the field was explicitly set (see MarkExplicit), which is
+// how a chain expresses "0" or "false" where that is meaningful, e.g.
+// client.max_retries: 0 or two_way_indexing: false.
+//
+// Failover fields unset at both chain and defaults level fall back to
+// rpc.DefaultFailoverConfig.
+func ResolveChainConfig(def Defaults, chain ChainConfig) ChainConfig {
+	r := resolver{explicit: chain.explicit}
+
+	r.bool(&chain.FromLatest, def.FromLatest, "from_latest")
+	r.bool(&chain.TwoWayIndexing, def.TwoWayIndexing, "two_way_indexing")
+	r.duration(&chain.PollInterval, def.PollInterval, "poll_interval")
+	r.int(&chain.ReorgRollbackWindow, def.ReorgRollbackWindow, "reorg_rollback_window")
+
+	r.float(&chain.Poll.Jitter, def.Poll.Jitter, "poll.jitter")
+	r.bool(&chain.Poll.Adaptive, def.Poll.Adaptive, "poll.adaptive")
+	r.duration(&chain.Poll.MinInterval, def.Poll.MinInterval, "poll.min_interval")
+	r.duration(&chain.Poll.MaxInterval, def.Poll.MaxInterval, "poll.max_interval")
+
+	r.duration(&chain.Client.Timeout, def.Client.Timeout, "client.timeout")
+	r.int(&chain.Client.MaxRetries, def.Client.MaxRetries, "client.max_retries")
+	r.duration(&chain.Client.RetryDelay, def.Client.RetryDelay, "client.retry_delay")
+	r.int(&chain.Client.MaxResponseBytes, def.Client.MaxResponseBytes, "client.max_response_bytes")
+
+	r.int(&chain.Throttle.RPS, def.Throttle.RPS, "throttle.rps")
+	r.int(&chain.Throttle.Burst, def.Throttle.Burst, "throttle.burst")
+	r.int(&chain.Throttle.BatchSize, def.Throttle.BatchSize, "throttle.batch_size")
+	r.int(&chain.Throttle.Concurrency, def.Throttle.Concurrency, "throttle.concurrency")
+	r.bool(&chain.Throttle.Parallel, def.Throttle.Parallel, "throttle.parallel")
+	r.int(&chain.Throttle.ProcessConcurrency, def.Throttle.ProcessConcurrency, "throttle.process_concurrency")
+	r.int(&chain.Throttle.MaxBlocksInMemory, def.Throttle.MaxBlocksInMemory, "throttle.max_blocks_in_memory")
+	r.int(&chain.Throttle.MaxMemoryBytes, def.Throttle.MaxMemoryBytes, "throttle.max_memory_bytes")
+	// The batch watchdog only exists on Bitcoin chains, so only they
+	// inherit a default budget; validateChainConfig rejects one set on
+	// another chain.
+	if chain.Type == enum.NetworkTypeBtc {
+		r.duration(&chain.Throttle.BatchBudget, def.Throttle.BatchBudget, "throttle.batch_budget")
+	}
+	r.float(&chain.Throttle.MaxWaitFraction, def.Throttle.MaxWaitFraction, "throttle.max_wait_fraction")
+
+	r.float(&chain.ValueCheck.SampleRate, def.ValueCheck.SampleRate, "value_check.sample_rate")
+	r.int(&chain.ValueCheck.ToleranceSats, def.ValueCheck.ToleranceSats, "value_check.tolerance_sats")
+	r.bool(&chain.ValueCheck.Strict, def.ValueCheck.Strict, "value_check.strict")
+
+	defFailover := resolveFailover(resolver{explicit: def.explicit}, def.Failover, rpc.DefaultFailoverConfig())
+	chain.Failover = resolveFailover(r, chain.Failover, defFailover)
+
+	return chain
+}
+
+func resolveFailover(r resolver, fc, def rpc.FailoverConfig) rpc.FailoverConfig {
+	r.duration(&fc.HealthCheckInterval, def.HealthCheckInterval, "failover.health_check_interval")
+	r.bool(&fc.EnableBlacklisting, def.EnableBlacklisting, "failover.enable_blacklisting")
+	r.int(&fc.MinActiveProviders, def.MinActiveProviders, "failover.min_active_providers")
+	r.int(&fc.ErrorThreshold, def.ErrorThreshold, "failover.error_threshold")
+	r.duration(&fc.DefaultTimeout, def.DefaultTimeout, "failover.default_timeout")
+	r.int(&fc.MaxBlockLag, def.MaxBlockLag, "failover.max_block_lag")
+	r.int(&fc.MaxAttempts, def.MaxAttempts, "failover.max_attempts")
+	r.duration(&fc.InitialBackoff, def.InitialBackoff, "failover.initial_backoff")
+	r.duration(&fc.MaxBackoff, def.MaxBackoff, "failover.max_backoff")
+	r.duration(&fc.HealthStateTTL, def.HealthStateTTL, "failover.health_state_ttl")
+	r.bool(&fc.WarmUp, def.WarmUp, "failover.warm_up")
+	return fc
+}
+
+// MarkExplicit records that the given keys (yaml paths relative to the
+// chain, e.g. "client.max_retries") were explicitly set, so their zero
+// values are kept instead of inheriting from Defaults.
+func (c *ChainConfig) MarkExplicit(keys ...string) {
+	c.explicit = markExplicit(c.explicit, keys)
+}
+
+// MarkExplicit records that the given keys (e.g. "failover.enable_blacklisting")
+// were explicitly set in defaults, so their zero values are kept instead of
+// falling back to built-in defaults.
+func (d *Defaults) MarkExplicit(keys ...string) {
+	d.explicit = markExplicit(d.explicit, keys)
+}
+
+func markExplicit(m map[string]bool, keys []string) map[string]bool {
+	if m == nil {
+		m = make(map[string]bool, len(keys))
+	}
+	for _, k := range keys {
+		m[k] = true
+	}
+	return m
+}
+
+// overridableKeys lists every key that ResolveChainConfig merges. The
+// loader checks each one for explicit presence under defaults and chains.
+var overridableKeys = []string{
+	"from_latest",
+	"two_way_indexing",
+	"poll_interval",
+	"reorg_rollback_window",
+	"poll.jitter",
+	"poll.adaptive",
+	"poll.min_interval",
+	"poll.max_interval",
+	"client.timeout",
+	"client.max_retries",
+	"client.retry_delay",
+	"client.max_response_bytes",
+	"throttle.rps",
+	"throttle.burst",
+	"throttle.batch_size",
+	"throttle.concurrency",
+	"throttle.parallel",
+	"throttle.process_concurrency",
+	"throttle.max_blocks_in_memory",
+	"throttle.max_memory_bytes",
+	"throttle.batch_budget",
+	"throttle.max_wait_fraction",
+	"failover.health_check_interval",
+	"failover.enable_blacklisting",
+	"failover.min_active_providers",
+	"failover.error_threshold",
+	"failover.default_timeout",
+	"failover.max_block_lag",
+	"failover.max_attempts",
+	"failover.initial_backoff",
+	"failover.max_backoff",
+	"failover.health_state_ttl",
+	"failover.warm_up",
+	"value_check.sample_rate",
+	"value_check.tolerance_sats",
+	"value_check.strict",
+}
+
+type resolver struct {
+	explicit map[string]bool
+}
+
+func (r resolver) int(v *int, def int, key string) {
+	if *v == 0 && !r.explicit[key] {
+		*v = def
+	}
+}
+
+func (r resolver) float(v *float64, def float64, key string) {
+	if *v == 0 && !r.explicit[key] {
+		*v = def
+	}
+}
+
+func (r resolver) bool(v *bool, def bool, key string) {
+	if !*v && !r.explicit[key] {
+		*v = def
+	}
+}
+
+func (r resolver) duration(v *time.Duration, def time.Duration, key string) {
+	if *v == 0 && !r.explicit[key] {
+		*v = def
+	}
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDefaults() Defaults {
+	return Defaults{
+		FromLatest:          true,
+		TwoWayIndexing:      true,
+		PollInterval:        5 * time.Second,
+		ReorgRollbackWindow: 20,
+		Client: ClientConfig{
+			Timeout:    20 * time.Second,
+			MaxRetries: 3,
+			RetryDelay: 5 * time.Second,
+		},
+		Throttle: Throttle{
+			RPS:         8,
+			Burst:       16,
+			BatchSize:   100,
+			Concurrency: 3,
+			Parallel:    true,
+		},
+	}
+}
+
+func TestResolveChainConfig_EmptyChainInheritsEverything(t *testing.T) {
+	def := testDefaults()
+
+	got := ResolveChainConfig(def, ChainConfig{})
+
+	assert.True(t, got.FromLatest)
+	assert.True(t, got.TwoWayIndexing)
+	assert.Equal(t, def.PollInterval, got.PollInterval)
+	assert.Equal(t, def.ReorgRollbackWindow, got.ReorgRollbackWindow)
+	assert.Equal(t, def.Client, got.Client)
+	assert.Equal(t, def.Throttle, got.Throttle)
+}
+
+func TestResolveChainConfig_PartialThrottleOverridesFieldByField(t *testing.T) {
+	def := testDefaults()
+
+	got := ResolveChainConfig(def, ChainConfig{Throttle: Throttle{RPS: 5}})
+
+	assert.Equal(t, 5, got.Throttle.RPS)
+	assert.Equal(t, def.Throttle.Burst, got.Throttle.Burst)
+	assert.Equal(t, def.Throttle.BatchSize, got.Throttle.BatchSize)
+	assert.Equal(t, def.Throttle.Concurrency, got.Throttle.Concurrency)
+	assert.True(t, got.Throttle.Parallel)
+}
+
+func TestResolveChainConfig_PartialClientOverridesFieldByField(t *testing.T) {
+	def := testDefaults()
+
+	got := ResolveChainConfig(def, ChainConfig{Client: ClientConfig{Timeout: time.Minute}})
+
+	assert.Equal(t, time.Minute, got.Client.Timeout)
+	assert.Equal(t, def.Client.MaxRetries, got.Client.MaxRetries)
+	assert.Equal(t, def.Client.RetryDelay, got.Client.RetryDelay)
+}
+
+func TestResolveChainConfig_PollOverridesFieldByField(t *testing.T) {
+	def := testDefaults()
+	def.Poll = PollConfig{Jitter: 0.1, Adaptive: true, MaxInterval: time.Minute}
+
+	chain := ChainConfig{Poll: PollConfig{MinInterval: time.Second}}
+	chain.MarkExplicit("poll.adaptive")
+	got := ResolveChainConfig(def, chain)
+
+	assert.Equal(t, PollConfig{Jitter: 0.1, MinInterval: time.Second, MaxInterval: time.Minute}, got.Poll)
+}
+
+func TestResolveChainConfig_NonZeroChainValuesWin(t *testing.T) {
+	def := testDefaults()
+	chain := ChainConfig{
+		PollInterval:        time.Minute,
+		ReorgRollbackWindow: 100,
+		Client:              ClientConfig{Timeout: time.Second, MaxRetries: 7, RetryDelay: time.Millisecond},
+		Throttle:            Throttle{RPS: 1, Burst: 2, BatchSize: 3, Concurrency: 4},
+	}
+
+	got := ResolveChainConfig(def, chain)
+
+	assert.Equal(t, chain.PollInterval, got.PollInterval)
+	assert.Equal(t, chain.ReorgRollbackWindow, got.ReorgRollbackWindow)
+	assert.Equal(t, chain.Client, got.Client)
+	assert.Equal(t, 1, got.Throttle.RPS)
+	assert.Equal(t, 2, got.Throttle.Burst)
+	assert.Equal(t, 3, got.Throttle.BatchSize)
+	assert.Equal(t, 4, got.Throttle.Concurrency)
+}
+
+func TestResolveChainConfig_ExplicitZeroIsKept(t *testing.T) {
+	def := testDefaults()
+	chain := ChainConfig{}
+	chain.MarkExplicit(
+		"from_latest",
+		"two_way_indexing",
+		"client.max_retries",
+		"client.retry_delay",
+		"throttle.parallel",
+	)
+
+	got := ResolveChainConfig(def, chain)
+
+	assert.False(t, got.FromLatest)
+	assert.False(t, got.TwoWayIndexing)
+	assert.Zero(t, got.Client.MaxRetries)
+	assert.Zero(t, got.Client.RetryDelay)
+	assert.False(t, got.Throttle.Parallel)
+	// fields not marked explicit still inherit
+	assert.Equal(t, def.Client.Timeout, got.Client.Timeout)
+	assert.Equal(t, def.Throttle.RPS, got.Throttle.RPS)
+}
+
+func TestResolveChainConfig_FailoverFallsBackToBuiltinDefaults(t *testing.T) {
+	got := ResolveChainConfig(testDefaults(), ChainConfig{})
+
+	assert.Equal(t, rpc.DefaultFailoverConfig(), got.Failover)
+}
+
+func TestResolveChainConfig_FailoverLayering(t *testing.T) {
+	def := testDefaults()
+	def.Failover = rpc.FailoverConfig{ErrorThreshold: 10}
+	def.MarkExplicit("failover.enable_blacklisting")
+	chain := ChainConfig{Failover: rpc.FailoverConfig{MinActiveProviders: 1}}
+	chain.MarkExplicit("failover.warm_up")
+
+	got := ResolveChainConfig(def, chain)
+	builtin := rpc.DefaultFailoverConfig()
+
+	assert.Equal(t, 1, got.Failover.MinActiveProviders)
+	assert.Equal(t, 10, got.Failover.ErrorThreshold)
+	assert.False(t, got.Failover.EnableBlacklisting)
+	assert.False(t, got.Failover.WarmUp)
+	assert.Equal(t, builtin.HealthCheckInterval, got.Failover.HealthCheckInterval)
+	assert.Equal(t, builtin.DefaultTimeout, got.Failover.DefaultTimeout)
+}
+
+func TestResolveChainConfig_DoesNotMutateDefaults(t *testing.T) {
+	def := testDefaults()
+	before := def.Throttle
+
+	_ = ResolveChainConfig(def, ChainConfig{Throttle: Throttle{RPS: 1}})
+
+	assert.Equal(t, before, def.Throttle)
+}
+
+func TestApplyDefaults_FillsNetworkIdentifiers(t *testing.T) {
+	chains := Chains{"eth_mainnet": {}}
+
+	require.NoError(t, chains.ApplyDefaults(testDefaults()))
+
+	assert.Equal(t, "eth_mainnet", chains["eth_mainnet"].NetworkId)
+	assert.Equal(t, "ETH_MAINNET", chains["eth_mainnet"].InternalCode)
+}
+
+func TestChains_SegwitHRPs(t *testing.T) {
+	chains := Chains{
+		"custom_signet": {Type: enum.NetworkTypeBtc, Bech32HRP: "sbt"},
+		"other_signet":  {Type: enum.NetworkTypeBtc, Bech32HRP: "obt"},
+		"same_signet":   {Type: enum.NetworkTypeBtc, Bech32HRP: "sbt"},
+		"bitcoin":       {Type: enum.NetworkTypeBtc},
+		"ethereum":      {Type: enum.NetworkTypeEVM, Bech32HRP: "ignored"},
+	}
+	assert.Equal(t, []string{"obt", "sbt"}, chains.SegwitHRPs())
+	assert.Equal(t, []string{"sbt"}, chains["custom_signet"].SegwitHRPs())
+	assert.Nil(t, chains["bitcoin"].SegwitHRPs())
+	assert.Nil(t, chains["ethereum"].SegwitHRPs())
+}
+
+func TestChainConfig_MaxMissingPrevoutRatio(t *testing.T) {
+	assert.Equal(t, DefaultMaxMissingPrevouts, ChainConfig{}.MaxMissingPrevoutRatio())
+
+	none := 0.0
+	assert.Zero(t, ChainConfig{MaxMissingPrevouts: &none}.MaxMissingPrevoutRatio(), "an explicit 0 requires every prevout")
+}
+
+func TestLoad_ResolvesExplicitZeroFromYAML(t *testing.T) {
+	yaml := `
+env: development
+defaults:
+  from_latest: true
+  poll_interval: 5s
+  reorg_rollback_window: 20
+  client:
+    max_retries: 3
+  throttle:
+    rps: 8
+    burst: 16
+    concurrency: 3
+chains:
+  eth:
+    type: evm
+    from_latest: false
+    start_block: 100
+    client:
+      max_retries: 0
+    throttle:
+      rps: 5
+    nodes:
+      - url: https://rpc.example.com
+services:
+  port: 8080
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	eth := cfg.Chains["eth"]
+	assert.False(t, eth.FromLatest)
+	assert.Zero(t, eth.Client.MaxRetries)
+	assert.Equal(t, 5, eth.Throttle.RPS)
+	assert.Equal(t, 16, eth.Throttle.Burst)
+	assert.Equal(t, 3, eth.Throttle.Concurrency)
+	assert.Equal(t, rpc.DefaultFailoverConfig(), eth.Failover)
+}
+
+func TestLoad_KeepsExplicitZeroResponseLimit(t *testing.T) {
+	yaml := `
+env: development
+defaults:
+  poll_interval: 5s
+  reorg_rollback_window: 20
+  client:
+    max_response_bytes: 1048576
+chains:
+  eth:
+    type: evm
+    client:
+      max_response_bytes: 0
+    nodes:
+      - url: https://rpc.example.com
+  base:
+    type: evm
+    nodes:
+      - url: https://rpc.example.com
+services:
+  port: 8080
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Chains["eth"].Client.MaxResponseBytes, "an explicit 0 lifts the limit")
+	assert.Equal(t, 1048576, cfg.Chains["base"].Client.MaxResponseBytes)
+}
+
+func TestLoad_KeepsExplicitZeroBatchBudget(t *testing.T) {
+	yaml := `
+env: development
+defaults:
+  poll_interval: 5s
+  reorg_rollback_window: 20
+  throttle:
+    batch_budget: 2m
+chains:
+  btc:
+    type: btc
+    throttle:
+      batch_budget: 0
+    nodes:
+      - url: https://btc.example.com
+  btc_signet:
+    type: btc
+    nodes:
+      - url: https://signet.example.com
+services:
+  port: 8080
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Chains["btc"].Throttle.BatchBudget, "an explicit 0 disables the watchdog")
+	assert.Equal(t, 2*time.Minute, cfg.Chains["btc_signet"].Throttle.BatchBudget)
+}
+
+func TestApplyEnvDefaults_ValueCheckSampleRate(t *testing.T) {
+	dev, prod := Defaults{}, Defaults{}
+	dev.applyEnvDefaults(DevEnv)
+	prod.applyEnvDefaults(ProdEnv)
+	assert.Equal(t, 1.0, dev.ValueCheck.SampleRate)
+	assert.Equal(t, ProdValueCheckSampleRate, prod.ValueCheck.SampleRate)
+
+	off := Defaults{explicit: map[string]bool{"value_check.sample_rate": true}}
+	off.applyEnvDefaults(DevEnv)
+	assert.Zero(t, off.ValueCheck.SampleRate, "an explicit 0 disables the check")
+}
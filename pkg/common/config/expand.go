@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	fileRefPrefix  = "file://"
+	redactedSecret = "****"
+)
+
+// envRefPattern matches ${VAR} references. Bare $VAR is intentionally not
+// supported because '$' can legitimately appear in URLs and API keys.
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// secretField is a config value that may hold a secret reference.
+type secretField struct {
+	path  string
+	value *string
+}
+
+// secretFields returns every config value that supports ${ENV_VAR} and
+// file:// expansion: node URLs, auth values and service credentials.
+func secretFields(cfg *Config) []secretField {
+	var fields []secretField
+	add := func(path string, v *string) {
+		fields = append(fields, secretField{path: path, value: v})
+	}
+
+	names := cfg.Chains.Names()
+	sort.Strings(names)
+	for _, name := range names {
+		chain := cfg.Chains[name]
+		for i := range chain.Nodes {
+			prefix := fmt.Sprintf("chains.%s.nodes[%d]", name, i)
+			add(prefix+".url", &chain.Nodes[i].URL)
+			add(prefix+".auth.value", &chain.Nodes[i].Auth.Value)
+		}
+	}
+
+	s := &cfg.Services
+	add("services.nats.url", &s.Nats.URL)
+	add("services.nats.username", &s.Nats.Username)
+	add("services.nats.password", &s.Nats.Password)
+	if s.Database != nil {
+		add("services.database.url", &s.Database.URL)
+	}
+	add("services.admin.token", &s.Admin.Token)
+	add("services.redis.url", &s.Redis.URL)
+	add("services.redis.password", &s.Redis.Password)
+	add("services.kvstore.consul.token", &s.KVS.Consul.Token)
+	add("services.kvstore.consul.http_auth.username", &s.KVS.Consul.HttpAuth.Username)
+	add("services.kvstore.consul.http_auth.password", &s.KVS.Consul.HttpAuth.Password)
+	add("services.alerting.slack_webhook_url", &s.Alerting.SlackWebhookURL)
+	add("services.alerting.webhook_url", &s.Alerting.WebhookURL)
+	return fields
+}
+
+// expandSecrets resolves ${ENV_VAR} and file://path references in place and
+// returns the resolved secret values so they can be redacted later.
+// All unresolved references are reported together in a single error.
+func expandSecrets(cfg *Config) ([]string, error) {
+	var (
+		secrets    []string
+		unresolved []string
+	)
+
+	for _, f := range secretFields(cfg) {
+		raw := *f.value
+		if raw == "" {
+			continue
+		}
+
+		expanded := envRefPattern.ReplaceAllStringFunc(raw, func(ref string) string {
+			name := envRefPattern.FindStringSubmatch(ref)[1]
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				unresolved = append(unresolved, fmt.Sprintf("%s (%s)", name, f.path))
+				return ref
+			}
+			if val != "" {
+				secrets = append(secrets, val)
+			}
+			return val
+		})
+
+		if path, ok := strings.CutPrefix(expanded, fileRefPrefix); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				unresolved = append(unresolved, fmt.Sprintf("%s (%s)", expanded, f.path))
+				continue
+			}
+			expanded = strings.TrimSpace(string(data))
+			if expanded != "" {
+				secrets = append(secrets, expanded)
+			}
+		}
+
+		*f.value = expanded
+	}
+
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf("unresolved config references: %s", strings.Join(unresolved, ", "))
+	}
+	return secrets, nil
+}
+
+// Redacted returns a copy of the config that is safe to log: values that
+// were expanded from secret references are masked, as are URL credentials
+// and auth values.
+func (c *Config) Redacted() Config {
+	out := *c
+	out.Chains = make(Chains, len(c.Chains))
+	for name, chain := range c.Chains {
+		nodes := make([]NodeConfig, len(chain.Nodes))
+		copy(nodes, chain.Nodes)
+		chain.Nodes = nodes
+		out.Chains[name] = chain
+	}
+	if c.Services.Database != nil {
+		db := *c.Services.Database
+		out.Services.Database = &db
+	}
+
+	for _, f := range secretFields(&out) {
+		*f.value = redactURLCredentials(*f.value)
+		for _, secret := range c.secrets {
+			*f.value = strings.ReplaceAll(*f.value, secret, redactedSecret)
+		}
+	}
+	for _, chain := range out.Chains {
+		for i := range chain.Nodes {
+			mask(&chain.Nodes[i].Auth.Value)
+		}
+	}
+	mask(&out.Services.Nats.Password)
+	mask(&out.Services.Redis.Password)
+	mask(&out.Services.KVS.Consul.Token)
+	mask(&out.Services.KVS.Consul.HttpAuth.Password)
+	return out
+}
+
+func mask(v *string) {
+	if *v != "" {
+		*v = redactedSecret
+	}
+}
+
+// redactURLCredentials masks the password component of URL userinfo.
+func redactURLCredentials(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw
+	}
+	u.User = url.UserPassword(u.User.Username(), redactedSecret)
+	return u.String()
+}
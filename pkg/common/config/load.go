@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/go-playground/validator/v10"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
@@ -33,15 +34,37 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	markExplicitKeys(v, &cfg)
+
 	// apply defaults
+	cfg.Defaults.applyEnvDefaults(cfg.Environment)
 	if err := cfg.Chains.ApplyDefaults(cfg.Defaults); err != nil {
 		return nil, err
 	}
 
+	// expand env/file references before validation so validators see real values
+	secrets, err := expandSecrets(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.secrets = secrets
+
 	// validate
 	if err := validate.Struct(&cfg); err != nil {
 		return nil, fmt.Errorf("struct validation failed: %w", err)
 	}
+	if _, err := constant.NewTxTypeRegistry(cfg.Services.TxTypes); err != nil {
+		return nil, fmt.Errorf("services.tx_types validation failed: %w", err)
+	}
+	if a := cfg.Services.Admin; a.Port != 0 && a.Port == cfg.Services.Port {
+		return nil, fmt.Errorf("services.admin validation failed: port %d is the health server's", a.Port)
+	}
+	if dir := cfg.Services.Nats.Buffer.Dir; dir != "" && dir == cfg.Services.TransferSink.Buffer.Dir {
+		return nil, fmt.Errorf("services.nats.buffer validation failed: dir %q is transfer_sink.buffer's", dir)
+	}
+	if a := cfg.Services.Alerting; a.Enabled && a.SlackWebhookURL == "" && a.WebhookURL == "" {
+		return nil, fmt.Errorf("services.alerting validation failed: slack_webhook_url or webhook_url is required")
+	}
 
 	for name, chain := range cfg.Chains {
 		// apply name to struct name
@@ -56,5 +79,30 @@ func Load(path string) (*Config, error) {
 		cfg.Chains[name] = chain
 	}
 
+	if err := validateChains(cfg.Chains); err != nil {
+		return nil, fmt.Errorf("chains validation failed: %w", err)
+	}
+	if err := validateLimiterGroups(cfg.Services.LimiterGroups, cfg.Chains); err != nil {
+		return nil, fmt.Errorf("services.limiter_groups validation failed: %w", err)
+	}
+
 	return &cfg, nil
 }
+
+// markExplicitKeys records which overridable keys are present in the source
+// config so ResolveChainConfig can tell an explicit zero from an unset field.
+func markExplicitKeys(v *viper.Viper, cfg *Config) {
+	for _, key := range overridableKeys {
+		if v.IsSet("defaults." + key) {
+			cfg.Defaults.MarkExplicit(key)
+		}
+	}
+	for name, chain := range cfg.Chains {
+		for _, key := range overridableKeys {
+			if v.IsSet("chains." + name + "." + key) {
+				chain.MarkExplicit(key)
+			}
+		}
+		cfg.Chains[name] = chain
+	}
+}
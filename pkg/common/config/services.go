@@ -1,16 +1,159 @@
 package config
 
-import "github.com/fystack/multichain-indexer/pkg/common/enum"
+import (
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
 
 type Services struct {
-	Port        int                `yaml:"port" validate:"required,min=1,max=65535"`
-	Worker      WorkerConfig       `yaml:"worker"`
-	Nats        NatsConfig         `yaml:"nats"`
-	Database    *DatabaseConfig    `yaml:"database,omitempty"`
-	KVS         KVSConfig          `yaml:"kvstore"`
-	Badger      BadgerConfig       `yaml:"badger"`
-	Redis       RedisConfig        `yaml:"redis"`
-	Bloomfilter *BloomfilterConfig `yaml:"bloomfilter,omitempty"`
+	Port           int                `yaml:"port" validate:"required,min=1,max=65535"`
+	Health         HealthConfig       `yaml:"health"`
+	Admin          AdminConfig        `yaml:"admin"`
+	Worker         WorkerConfig       `yaml:"worker"`
+	Nats           NatsConfig         `yaml:"nats"`
+	Database       *DatabaseConfig    `yaml:"database,omitempty"`
+	KVS            KVSConfig          `yaml:"kvstore"`
+	Badger         BadgerConfig       `yaml:"badger"`
+	Redis          RedisConfig        `yaml:"redis"`
+	Bloomfilter    *BloomfilterConfig `yaml:"bloomfilter,omitempty"`
+	WatchAddresses WatchAddressConfig `yaml:"watch_addresses"`
+	// TxTypes renames transaction types when they are emitted, from the
+	// indexers' type (see constant.TxTypes) to the name consumers expect.
+	TxTypes map[string]string `yaml:"tx_types"`
+	// AmountFormat selects how emitted transfers state amounts, see
+	// amount.Format: "legacy" (the default) or "raw".
+	AmountFormat string             `yaml:"amount_format" validate:"omitempty,oneof=legacy raw"`
+	TransferSink TransferSinkConfig `yaml:"transfer_sink"`
+	Alerting     AlertingConfig     `yaml:"alerting"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	// LimiterGroups are request budgets shared by the chains naming them in
+	// throttle.limiter_group, by group name.
+	LimiterGroups map[string]LimiterGroupConfig `yaml:"limiter_groups" validate:"dive"`
+}
+
+// LimiterGroupConfig budgets the requests of the chains in a limiter group,
+// typically those sharing a hosted provider's API key, over a UTC day and
+// month; a zero budget is unlimited. Every request sent counts, in Redis so
+// that replicas share the count and it survives restarts. Once SlowDownAt
+// of a budget is used, optional work (catchup, rescans, manual ranges) is
+// held back, each request up to MaxDelay as the budget depletes; past
+// StopOptionalAt it stops, leaving the rest to following the chain tip.
+type LimiterGroupConfig struct {
+	DailyRequests   int64         `yaml:"daily_requests"   validate:"min=0"`
+	MonthlyRequests int64         `yaml:"monthly_requests" validate:"min=0"`
+	SlowDownAt      float64       `yaml:"slow_down_at"     validate:"min=0,max=1"` // share used, ratelimiter.DefaultSlowDownAt if 0
+	StopOptionalAt  float64       `yaml:"stop_optional_at" validate:"min=0,max=1"` // share used, ratelimiter.DefaultStopOptionalAt if 0
+	MaxDelay        time.Duration `yaml:"max_delay"        validate:"min=0"`       // ratelimiter.DefaultBudgetMaxDelay if 0
+}
+
+// TracingConfig exports OpenTelemetry traces of block processing over
+// OTLP/HTTP. Without Enabled no span is recorded.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the collector's host:port, or a URL with a path, e.g.
+	// "localhost:4318" or "https://otel.example.com/v1/traces".
+	Endpoint string `yaml:"endpoint" validate:"required_if=Enabled true"`
+	// Insecure sends to a host:port Endpoint over plain HTTP.
+	Insecure bool `yaml:"insecure"`
+	// Headers are sent with every export, e.g. an API key.
+	Headers map[string]string `yaml:"headers"`
+	// SampleRatio is the share of blocks traced, from 0 to 1. Unset traces
+	// every block.
+	SampleRatio *float64 `yaml:"sample_ratio" validate:"omitempty,min=0,max=1"`
+	// ServiceName names the process in the tracing backend,
+	// "multichain-indexer" if empty.
+	ServiceName string `yaml:"service_name"`
+}
+
+// Ratio returns the share of blocks traced.
+func (c TracingConfig) Ratio() float64 {
+	if c.SampleRatio == nil {
+		return 1
+	}
+	return *c.SampleRatio
+}
+
+// AlertingConfig controls notifications of chains needing attention. Each
+// condition is off at its zero value; a firing condition is notified again
+// once resolved, and not sooner than Cooldown after its last notification.
+type AlertingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often conditions are checked, 30s if 0.
+	Interval time.Duration `yaml:"interval"`
+	// Cooldown is the least time between notifications of a chain's
+	// condition, 15m if 0.
+	Cooldown time.Duration `yaml:"cooldown"`
+
+	// StallAfter alerts on a chain that has indexed no block for this long.
+	StallAfter time.Duration `yaml:"stall_after"`
+	// MaxLag alerts on a chain lagging more blocks behind its tip.
+	MaxLag uint64 `yaml:"max_lag"`
+	// MaxConsecutiveFailures alerts on a chain failing this many jobs or
+	// blocks in a row.
+	MaxConsecutiveFailures int `yaml:"max_consecutive_failures" validate:"min=0"`
+	// AllNodesBlacklisted alerts when every node of a chain is blacklisted.
+	AllNodesBlacklisted bool `yaml:"all_nodes_blacklisted"`
+	// DeepReorg alerts on a reorg deeper than the chain's rollback window,
+	// and for as long as it halts the chain.
+	DeepReorg bool `yaml:"deep_reorg"`
+
+	// SlackWebhookURL posts alerts to a Slack incoming webhook.
+	SlackWebhookURL string `yaml:"slack_webhook_url"`
+	// WebhookURL posts alerts as JSON to any HTTP endpoint.
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// TransferSinkConfig controls writing emitted transfers to the database's
+// transfers table (sql/transfer.sql). It needs the database.
+type TransferSinkConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BatchSize is the number of rows per insert statement. 0 uses
+	// repository.DefaultTransferBatchSize.
+	BatchSize int `yaml:"batch_size" validate:"min=0"`
+	// Buffer queues writes in memory and spills them to disk while the
+	// database is slow or down. Off unless Buffer.Dir is set.
+	Buffer BufferConfig `yaml:"buffer"`
+}
+
+// BufferConfig tunes the buffer between the workers and a downstream, the
+// transfer sink's database (sink.BufferedSink) or NATS
+// (sink.BufferedQueue). Zero values use the sink.DefaultBuffer* defaults.
+type BufferConfig struct {
+	// Dir holds the write-ahead log that writes are spilled to.
+	Dir string `yaml:"dir"`
+	// QueueSize is the number of writes held in memory.
+	QueueSize int `yaml:"queue_size" validate:"min=0"`
+	// HandoffTimeout is how long a write waits for the downstream before
+	// it is spilled.
+	HandoffTimeout time.Duration `yaml:"handoff_timeout"`
+	// SegmentBytes is the size of a write-ahead log segment.
+	SegmentBytes int64 `yaml:"segment_bytes" validate:"min=0"`
+	// RetryInterval is the wait between failed writes to the downstream.
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+// HealthConfig tunes the /healthz readiness check.
+type HealthConfig struct {
+	// MaxLag is the indexing lag, in blocks, above which a chain is not
+	// ready. 0 uses each chain's max_lag.
+	MaxLag uint64 `yaml:"max_lag"`
+}
+
+// AdminConfig serves the endpoints that change the indexer's state, such as
+// resolving a reorg halt, on their own listener. They are off unless Port
+// is set, and every request must carry Token as a bearer token.
+type AdminConfig struct {
+	Port  int    `yaml:"port"  validate:"min=0,max=65535"`
+	Token string `yaml:"token" validate:"required_unless=Port 0"`
+}
+
+// WatchAddressConfig tunes the watch-address import API.
+type WatchAddressConfig struct {
+	// XpubLookahead is how many unused addresses of each branch of an
+	// imported xpub are watched past the highest used one, unless the
+	// import sets its own gap limit. 0 uses the BIP-44 gap limit of 20.
+	XpubLookahead uint32 `yaml:"xpub_lookahead" validate:"max=1000"`
 }
 
 type WorkerConfig struct {
@@ -31,6 +174,13 @@ type NatsConfig struct {
 	Username      string        `yaml:"username"`
 	Password      string        `yaml:"password"`
 	TLS           NatsTLSConfig `yaml:"tls"`
+	// Encoding selects the wire format per topic ("transfer": json|protobuf).
+	// Topics left out are published as JSON.
+	Encoding map[string]string `yaml:"encoding" validate:"omitempty,dive,keys,oneof=transfer,endkeys,oneof=json protobuf"`
+	// Buffer queues published messages in memory and spills them to disk
+	// while NATS is slow or down. Off unless Buffer.Dir is set; each stream
+	// keeps its log in a subdirectory.
+	Buffer BufferConfig `yaml:"buffer"`
 }
 
 type NatsTLSConfig struct {
@@ -80,6 +230,11 @@ type BloomfilterConfig struct {
 	Redis             RedisBFConfig    `yaml:"redis"`
 	InMemory          InMemoryConfig   `yaml:"in_memory"`
 	Sync              BloomSyncConfig  `yaml:"sync"`
+
+	// NotReady is what the filter answers for any address until it has
+	// loaded the database: "fail_open" (the default) or "fail_closed".
+	// Workers emit every transfer until then regardless.
+	NotReady string `yaml:"not_ready" validate:"omitempty,oneof=fail_open fail_closed"`
 }
 
 type BloomSyncConfig struct {
@@ -89,9 +244,13 @@ type BloomSyncConfig struct {
 }
 
 type RedisBFConfig struct {
-	KeyPrefix string  `yaml:"key_prefix"`
-	ErrorRate float64 `yaml:"error_rate"`
-	Capacity  int     `yaml:"capacity"`
+	KeyPrefix            string  `yaml:"key_prefix"`
+	ErrorRate            float64 `yaml:"error_rate"`
+	Capacity             int     `yaml:"capacity"`
+	MaddChunkSize        int     `yaml:"madd_chunk_size"`       // addresses per BF.MADD, default 1000
+	InitRetryTimeout     string  `yaml:"init_retry_timeout"`    // e.g. "1m": retries of transient errors while loading
+	RetryTimeout         string  `yaml:"retry_timeout"`         // e.g. "2s": retries of transient errors per lookup or add
+	RecreateIncompatible bool    `yaml:"recreate_incompatible"` // drop keys reserved with another error rate or capacity
 }
 
 type InMemoryConfig struct {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/fystack/multichain-indexer/internal/rpc"
@@ -20,46 +21,199 @@ type Config struct {
 	Defaults    Defaults `yaml:"defaults" validate:"required"`
 	Chains      Chains   `yaml:"chains"   validate:"required,min=1"`
 	Services    Services `yaml:"services" validate:"required"`
+
+	// secrets holds values resolved from ${ENV_VAR} or file:// references,
+	// used by Redacted to mask them in logged output.
+	secrets []string
 }
 
 type Defaults struct {
 	FromLatest          bool               `yaml:"from_latest"`
 	TwoWayIndexing      bool               `yaml:"two_way_indexing"`
 	PollInterval        time.Duration      `yaml:"poll_interval"         validate:"required"`
+	Poll                PollConfig         `yaml:"poll"`
 	ReorgRollbackWindow int                `yaml:"reorg_rollback_window" validate:"required,min=1"`
 	Client              ClientConfig       `yaml:"client"`
 	Throttle            Throttle           `yaml:"throttle"`
 	Failover            rpc.FailoverConfig `yaml:"failover"`
+	ValueCheck          ValueCheckConfig   `yaml:"value_check"`
+
+	// explicit holds keys set explicitly in YAML, see MarkExplicit.
+	explicit map[string]bool
 }
 
 type Chains map[string]ChainConfig
 
+// Fee attribution modes for UTXO chains, see ChainConfig.FeeAttribution.
+const (
+	FeeAttributionFirstOutput  = "first_output" // whole fee on the lowest-vout transfer (default)
+	FeeAttributionProportional = "proportional" // split by amount across non-change outputs
+	FeeAttributionTransaction  = "transaction"  // separate fee record, transfers carry no fee
+)
+
+// Handling of zero-value outputs on UTXO chains, see
+// ChainConfig.ZeroValueOutputs. Unspendable (OP_RETURN) outputs are never
+// emitted.
+const (
+	ZeroValueEmit    = "emit"    // transfer with amount "0" (default)
+	ZeroValueSkip    = "skip"    // no transfer
+	ZeroValueWatched = "watched" // transfer only to a watched address
+)
+
 type ChainConfig struct {
-	Name                string           `yaml:"-"`
-	NetworkId           string           `yaml:"network_id"`
-	InternalCode        string           `yaml:"internal_code"`
-	NativeDenom         string           `yaml:"native_denom"`
-	Type                enum.NetworkType `yaml:"type"                  validate:"required"`
-	FromLatest          bool             `yaml:"from_latest"`
-	StartBlock          int              `yaml:"start_block"           validate:"min=0"`
-	PollInterval        time.Duration    `yaml:"poll_interval"`
-	ReorgRollbackWindow int              `yaml:"reorg_rollback_window"`
-	TwoWayIndexing      bool             `yaml:"two_way_indexing"`
-	Confirmations       uint64           `yaml:"confirmations"`
-	MaxLag              uint64           `yaml:"max_lag"`
-	IndexUTXO           bool             `yaml:"index_utxo"`
-	DebugTrace          bool             `yaml:"debug_trace"`
-	TraceThrottle       TraceThrottle    `yaml:"trace_throttle"`
-	Client              ClientConfig     `yaml:"client"`
-	Throttle            Throttle         `yaml:"throttle"`
-	Ton                 TonConfig        `yaml:"ton"`
-	Nodes               []NodeConfig     `yaml:"nodes"                 validate:"required,min=1"`
+	Name                string              `yaml:"-"`
+	NetworkId           string              `yaml:"network_id"`
+	InternalCode        string              `yaml:"internal_code"`
+	NativeDenom         string              `yaml:"native_denom"`
+	NativeDecimals      uint8               `yaml:"native_decimals"` // 0 uses the network type's default
+	TokenDecimals       map[string]uint8    `yaml:"token_decimals"`  // by asset address, for amount_format: raw
+	Type                enum.NetworkType    `yaml:"type"                  validate:"required"`
+	Enabled             *bool               `yaml:"enabled"`
+	FromLatest          bool                `yaml:"from_latest"`
+	StartBlock          int                 `yaml:"start_block"           validate:"min=0"`
+	PollInterval        time.Duration       `yaml:"poll_interval"`
+	Poll                PollConfig          `yaml:"poll"`
+	Head                HeadConfig          `yaml:"head"`
+	ReorgRollbackWindow int                 `yaml:"reorg_rollback_window"`
+	TwoWayIndexing      bool                `yaml:"two_way_indexing"`
+	EmitEmptyBlocks     bool                `yaml:"emit_empty_blocks"`     // publish a block record when no transfer matched
+	EmitRetiredActivity bool                `yaml:"emit_retired_activity"` // publish transfers of retired addresses
+	EmitSelfTransfers   *bool               `yaml:"emit_self_transfers"`   // transfers to their own sender: true as self_transfer, false dropped but their fee, unset as extracted
+	Confirmations       uint64              `yaml:"confirmations"`
+	MaxLag              uint64              `yaml:"max_lag"`
+	ErrorAfterFailures  int                 `yaml:"error_after_failures"  validate:"min=0"`
+	IndexUTXO           bool                `yaml:"index_utxo"`
+	IndexNonstandard    bool                `yaml:"index_nonstandard_outputs"`
+	MaxMissingPrevouts  *float64            `yaml:"max_missing_prevout_ratio" validate:"omitempty,min=0,max=1"`
+	PrevoutLookups      int                 `yaml:"max_prevout_lookups"   validate:"min=0"` // node calls a block's prevout lookups may take, watched transactions first; 0 = all
+	FeeAttribution      string              `yaml:"fee_attribution"       validate:"omitempty,oneof=first_output proportional transaction"`
+	ZeroValueOutputs    string              `yaml:"zero_value_outputs"    validate:"omitempty,oneof=emit skip watched"`
+	CoinbaseTransfers   bool                `yaml:"coinbase_transfers"`
+	StrictMode          bool                `yaml:"strict_mode"` // fail blocks with transactions that cannot be fully parsed
+	BitcoinNetwork      string              `yaml:"bitcoin_network"       validate:"omitempty,oneof=mainnet testnet3 testnet4 signet regtest"`
+	Bech32HRP           string              `yaml:"bech32_hrp"            validate:"omitempty,lowercase,printascii,max=83"`
+	ExpectedChainID     string              `yaml:"expected_chain_id"`     // nodes reporting another chain ID are not used
+	ExpectedGenesisHash string              `yaml:"expected_genesis_hash"` // nodes reporting another genesis block are not used
+	DebugTrace          bool                `yaml:"debug_trace"`
+	TraceThrottle       TraceThrottle       `yaml:"trace_throttle"`
+	LogsBloomFilter     *bool               `yaml:"logs_bloom_filter"` // nil is true, see UsesLogsBloom
+	LogScan             LogScanConfig       `yaml:"log_scan"`
+	Client              ClientConfig        `yaml:"client"`
+	Throttle            Throttle            `yaml:"throttle"`
+	Failover            rpc.FailoverConfig  `yaml:"failover"`
+	ValueCheck          ValueCheckConfig    `yaml:"value_check"`
+	Lightning           LightningConfig     `yaml:"lightning"`
+	Ordinals            OrdinalsConfig      `yaml:"ordinals"`
+	Consolidation       ConsolidationConfig `yaml:"consolidation"`
+	Supply              SupplyConfig        `yaml:"supply"`
+	RPCCache            RPCCacheConfig      `yaml:"rpc_cache"`
+	AddressScan         AddressScanConfig   `yaml:"address_scan"`
+	BlockVerify         BlockVerifyConfig   `yaml:"block_verify"`
+	Checkpoint          CheckpointConfig    `yaml:"checkpoint"`
+	Ton                 TonConfig           `yaml:"ton"`
+	Assets              AssetFilterConfig   `yaml:"assets"`
+	Logging             ChainLoggingConfig  `yaml:"logging"`
+	Nodes               []NodeConfig        `yaml:"nodes"                 validate:"required,min=1"`
+
+	// explicit holds keys set explicitly in YAML, see MarkExplicit.
+	explicit map[string]bool
+}
+
+// IsEnabled reports whether workers should run for the chain. Chains are
+// enabled unless `enabled: false` is set explicitly.
+func (c ChainConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// UsesLogsBloom reports whether an EVM chain skips the receipts of blocks
+// whose logsBloom rules out the token transfers it extracts. It is on
+// unless `logs_bloom_filter: false` is set, for nodes serving no bloom or
+// a wrong one.
+func (c ChainConfig) UsesLogsBloom() bool {
+	return c.LogsBloomFilter == nil || *c.LogsBloomFilter
+}
+
+// DefaultMaxMissingPrevouts is the default ChainConfig.MaxMissingPrevouts.
+const DefaultMaxMissingPrevouts = 0.05
+
+// MaxMissingPrevoutRatio returns the fraction of a Bitcoin block's inputs
+// that may be left without prevout data. 0 requires every one.
+func (c ChainConfig) MaxMissingPrevoutRatio() float64 {
+	if c.MaxMissingPrevouts == nil {
+		return DefaultMaxMissingPrevouts
+	}
+	return *c.MaxMissingPrevouts
+}
+
+// SegwitHRPs returns the segwit HRP the chain's addresses use besides
+// Bitcoin's, its bech32_hrp, to pass to addressutil.Normalize and
+// ValidateAll. Chains of other network types have none.
+func (c ChainConfig) SegwitHRPs() []string {
+	if c.Type != enum.NetworkTypeBtc || c.Bech32HRP == "" {
+		return nil
+	}
+	return []string{c.Bech32HRP}
+}
+
+// PollConfig shapes the schedule around PollInterval. Jitter spreads chains
+// sharing providers off a common tick. Adaptive drops to MinInterval when a
+// new block arrives, since blocks often come in bursts, and backs off
+// towards MaxInterval while the chain is quiet. Both are ignored while the
+// chain's indexer has a push source active.
+type PollConfig struct {
+	Jitter      float64       `yaml:"jitter"       validate:"min=0,max=1"` // fraction of the interval, e.g. 0.1 for ±10%
+	Adaptive    bool          `yaml:"adaptive"`
+	MinInterval time.Duration `yaml:"min_interval"` // defaults to PollInterval/4
+	MaxInterval time.Duration `yaml:"max_interval"` // defaults to PollInterval*4
+}
+
+// LogScanConfig makes an EVM chain find token transfers by eth_getLogs
+// over windows of blocks, filtered by the Transfer topic and the allowed
+// contracts, instead of fetching the receipts of token calls. Native
+// transfers are still found by scanning blocks. The window starts at
+// MaxRange, halves whenever the node refuses a query over its range or
+// result limits, and grows back while queries succeed. Transfers found by
+// the scan carry no fee, marked by "fee_unknown" metadata, as their receipt
+// is not fetched. Ignored while debug_trace is active.
+type LogScanConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	MaxRange uint64 `yaml:"max_range"` // blocks per query, defaults to DefaultLogScanMaxRange
+}
+
+// DefaultLogScanMaxRange is the default LogScanConfig.MaxRange.
+const DefaultLogScanMaxRange = 1000
+
+// Head sources, see HeadConfig.
+const (
+	HeadSourcePoll      = "poll"
+	HeadSourceZMQ       = "zmq"       // bitcoind zmqpubhashblock
+	HeadSourceWebsocket = "websocket" // EVM eth_subscribe newHeads
+)
+
+// HeadConfig selects how the regular worker learns of new blocks. Poll,
+// the default, is the PollInterval schedule. A push source wakes the
+// worker as soon as a block arrives; while it is down, or silent for
+// StaleAfter, the chain falls back to polling until it is back.
+type HeadConfig struct {
+	Source     string        `yaml:"source"      validate:"omitempty,oneof=poll zmq websocket"`
+	URL        string        `yaml:"url"`         // tcp://host:port for zmq, ws(s):// for websocket
+	StaleAfter time.Duration `yaml:"stale_after"` // 0 never deems a connected source stale
+}
+
+// IsPush reports whether a push source is configured.
+func (h HeadConfig) IsPush() bool {
+	return h.Source != "" && h.Source != HeadSourcePoll
 }
 
 type ClientConfig struct {
 	Timeout    time.Duration `yaml:"timeout"`
 	MaxRetries int           `yaml:"max_retries" validate:"min=0"`
 	RetryDelay time.Duration `yaml:"retry_delay"`
+
+	// MaxResponseBytes fails node responses larger than this, 0 for no
+	// limit. Applies to EVM, Tron and Bitcoin nodes; a Bitcoin block over
+	// it is fetched transaction by transaction instead.
+	MaxResponseBytes int `yaml:"max_response_bytes" validate:"min=0"`
 }
 
 type Throttle struct {
@@ -68,6 +222,177 @@ type Throttle struct {
 	BatchSize   int  `yaml:"batch_size"`
 	Concurrency int  `yaml:"concurrency"`
 	Parallel    bool `yaml:"parallel"`
+
+	// ProcessConcurrency sizes the pool converting fetched blocks apart
+	// from the Concurrency fetching them, on chains that split the two
+	// (Bitcoin). 0 sizes it like the fetch pool, from Concurrency, since
+	// converting resolves prevouts over RPC. There, the Concurrency
+	// fetchers are shared by all workers of the chain, by priority: tip,
+	// then reorg re-fetches, gap repair and backfill.
+	ProcessConcurrency int `yaml:"process_concurrency" validate:"min=0"`
+
+	// MaxWaitFraction caps the share of a request's remaining deadline it
+	// may spend waiting on the rate limiter; past it the request fails as
+	// rate limited so failover can try another node. 0 means
+	// ratelimiter.DefaultMaxWaitFraction, 1 waits up to the deadline.
+	MaxWaitFraction float64 `yaml:"max_wait_fraction" validate:"min=0,max=1"`
+
+	// MaxBlocksInMemory caps how many fetched blocks a streamed range fetch
+	// holds at once; MaxMemoryBytes further shrinks that window to fit an
+	// approximate budget estimated from the blocks' reported Size.
+	MaxBlocksInMemory int `yaml:"max_blocks_in_memory"`
+	MaxMemoryBytes    int `yaml:"max_memory_bytes"`
+
+	// BatchBudget is how long a batch of blocks may take before the heights
+	// still outstanding are logged, again each BatchBudget, to spot a stuck
+	// fetch or block. 0 disables the watchdog. Bitcoin only: other chains
+	// reject it and do not inherit it from defaults.
+	BatchBudget time.Duration `yaml:"batch_budget"`
+
+	// LimiterGroup names the services.limiter_groups entry whose request
+	// budget the chain's requests count against, shared with every chain
+	// naming it, e.g. chains using one provider API key.
+	LimiterGroup string `yaml:"limiter_group"`
+}
+
+// ValueCheckConfig controls the value-conservation check of UTXO chains:
+// a checked transaction's transfers must carry its outputs' value and its
+// fee. SampleRate defaults to 1 in development and to
+// ProdValueCheckSampleRate in production.
+type ValueCheckConfig struct {
+	SampleRate    float64 `yaml:"sample_rate"    validate:"min=0,max=1"` // fraction of transactions checked
+	ToleranceSats int     `yaml:"tolerance_sats" validate:"min=0"`       // allowed difference before a discrepancy is reported
+	Strict        bool    `yaml:"strict"`                                // fail the block on any discrepancy
+}
+
+// ProdValueCheckSampleRate is the default ValueCheckConfig.SampleRate in
+// production.
+const ProdValueCheckSampleRate = 0.01
+
+// LightningConfig controls Lightning channel detection on Bitcoin chains:
+// probable funding outputs are tagged and remembered for Retention, so the
+// transaction closing the channel within it is tagged with more confidence.
+// Remembering needs Redis: a chain enabling it without Redis is not
+// started.
+type LightningConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Retention time.Duration `yaml:"retention"` // defaults to DefaultLightningRetention
+}
+
+// DefaultLightningRetention is the default LightningConfig.Retention.
+const DefaultLightningRetention = 180 * 24 * time.Hour
+
+// OrdinalsConfig controls inscription detection on Bitcoin chains: the
+// output an inscription lands on is tagged, and a BRC-20 mint or transfer
+// inscribed on it also yields a token transfer. Bodies over MaxBodyBytes
+// are only tagged.
+type OrdinalsConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	MaxBodyBytes int  `yaml:"max_body_bytes" validate:"min=0"` // defaults to DefaultInscriptionMaxBodyBytes
+}
+
+// DefaultInscriptionMaxBodyBytes is the default OrdinalsConfig.MaxBodyBytes.
+const DefaultInscriptionMaxBodyBytes = 4096
+
+// ConsolidationConfig controls consolidation detection on Bitcoin chains:
+// a transaction spending several inputs, every known one from a watched
+// address, to outputs that are all watched yields consolidation transfers,
+// emitted once each, in place of its native transfers.
+type ConsolidationConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// SupplyConfig controls supply tracking on Bitcoin chains: each processed
+// block's subsidy and fees are stored (sql/supply.sql), and served with the
+// cumulative totals at /v1/networks/<network_id>/supply. Needs the database.
+type SupplyConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// RPCCacheConfig caches the node responses of Bitcoin chains that can no
+// longer change: block hashes, headers and transactions buried under the
+// reorg rollback window. Size entries are kept in memory; with Redis they
+// are also shared between processes for TTL. Entries of rolled-back
+// heights are dropped.
+type RPCCacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Size    int           `yaml:"size"  validate:"min=0"` // defaults to DefaultRPCCacheSize
+	Redis   bool          `yaml:"redis"`
+	TTL     time.Duration `yaml:"ttl"` // defaults to DefaultRPCCacheTTL
+}
+
+// Defaults of RPCCacheConfig.
+const (
+	DefaultRPCCacheSize = 50_000
+	DefaultRPCCacheTTL  = 7 * 24 * time.Hour
+)
+
+// AddressScanConfig bounds on-demand discovery of a registered address's
+// history on Bitcoin chains: the scantxoutset run for its unspent outputs,
+// on nodes with heavy_scans only, and the historical backfill optionally
+// scheduled with it.
+type AddressScanConfig struct {
+	Timeout           time.Duration `yaml:"timeout"`             // defaults to DefaultAddressScanTimeout
+	MaxBackfillBlocks uint64        `yaml:"max_backfill_blocks"` // defaults to DefaultAddressScanMaxBackfill
+}
+
+// Defaults of AddressScanConfig.
+const (
+	DefaultAddressScanTimeout     = 5 * time.Minute
+	DefaultAddressScanMaxBackfill = 50_000
+)
+
+// CheckpointConfig spaces out the writes of a chain's checkpoint, the last
+// block its regular worker indexed: it is written once EveryBlocks blocks
+// were indexed or Interval passed since the last write, whichever comes
+// first, and on shutdown. Unset, it is written after every batch. A
+// restart resumes after the last checkpoint written, indexing the blocks
+// since again; their transfers keep their TransferIDs, so consumers drop
+// the repeats.
+type CheckpointConfig struct {
+	EveryBlocks int           `yaml:"every_blocks" validate:"min=0"`
+	Interval    time.Duration `yaml:"interval"     validate:"min=0"`
+}
+
+// BlockVerifyConfig controls the check of Bitcoin blocks against their
+// headers: the merkle root and witness commitment are recomputed from the
+// transactions and the header is hashed. Blocks near the tip are always
+// checked, backfilled ones at BackfillSampleRate. A block failing it marks
+// its node unhealthy and is fetched from another.
+type BlockVerifyConfig struct {
+	Disabled           bool     `yaml:"disabled"`
+	BackfillSampleRate *float64 `yaml:"backfill_sample_rate" validate:"omitempty,min=0,max=1"` // defaults to DefaultBlockVerifySampleRate
+}
+
+// DefaultBlockVerifySampleRate is the default
+// BlockVerifyConfig.BackfillSampleRate.
+const DefaultBlockVerifySampleRate = 0.05
+
+// SampleRate returns the fraction of backfilled blocks checked.
+func (c BlockVerifyConfig) SampleRate() float64 {
+	if c.BackfillSampleRate == nil {
+		return DefaultBlockVerifySampleRate
+	}
+	return *c.BackfillSampleRate
+}
+
+// ChainLoggingConfig tunes the logs of a chain's workers, indexer and
+// failover pool.
+type ChainLoggingConfig struct {
+	// Level overrides the global log level for the chain.
+	Level string `yaml:"level" validate:"omitempty,oneof=debug info warn error"`
+	// SampleEvery logs every Nth "Processed block" at info and the rest at
+	// debug. 0 or 1 logs them all at info.
+	SampleEvery int `yaml:"sample_every" validate:"min=0"`
+}
+
+// Leveler returns the chain's log level, nil if it has none.
+func (c ChainLoggingConfig) Leveler() slog.Leveler {
+	var level slog.Level
+	if c.Level == "" || level.UnmarshalText([]byte(c.Level)) != nil {
+		return nil
+	}
+	return level
 }
 
 type TonConfig struct {
@@ -83,6 +408,7 @@ type NodeConfig struct {
 	Auth       AuthConfig        `yaml:"auth"`
 	Headers    map[string]string `yaml:"headers"`
 	DebugTrace bool              `yaml:"debug_trace"` // node supports debug_* namespace
+	HeavyScans bool              `yaml:"heavy_scans"` // node may run scantxoutset (Bitcoin)
 }
 
 // TraceThrottle configures rate limiting and concurrency for debug_traceTransaction calls.
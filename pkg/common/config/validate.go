@@ -1,14 +1,173 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
 
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/ratelimiter"
 )
 
 func validateChainConfig(chain ChainConfig) error {
 	if chain.Type == enum.NetworkTypeCosmos && chain.NativeDenom == "" {
 		return fmt.Errorf("native_denom is required for cosmos chains")
 	}
+	if !chain.FromLatest && chain.StartBlock > 0 && chain.ReorgRollbackWindow >= chain.StartBlock {
+		return fmt.Errorf(
+			"reorg_rollback_window (%d) must be smaller than start_block (%d) when from_latest is false",
+			chain.ReorgRollbackWindow, chain.StartBlock,
+		)
+	}
+	if chain.Throttle.RPS > 0 && chain.Throttle.Burst > 0 && chain.Throttle.Burst < chain.Throttle.RPS {
+		return fmt.Errorf(
+			"throttle.burst (%d) must be >= throttle.rps (%d)",
+			chain.Throttle.Burst, chain.Throttle.RPS,
+		)
+	}
+	if chain.Throttle.BatchBudget > 0 && chain.Type != enum.NetworkTypeBtc {
+		return fmt.Errorf("throttle.batch_budget is only supported on btc chains, not %s", chain.Type)
+	}
+	if err := validateAssets(chain.Type, chain.Assets); err != nil {
+		return err
+	}
+	return validateHead(chain.Type, chain.Head)
+}
+
+// validateHead checks that a push head source suits the network type and
+// has a URL.
+func validateHead(networkType enum.NetworkType, head HeadConfig) error {
+	if !head.IsPush() {
+		return nil
+	}
+	supported := map[string]enum.NetworkType{
+		HeadSourceZMQ:       enum.NetworkTypeBtc,
+		HeadSourceWebsocket: enum.NetworkTypeEVM,
+	}
+	if want, ok := supported[head.Source]; ok && want != networkType {
+		return fmt.Errorf("head.source %s is not supported on %s chains", head.Source, networkType)
+	}
+	if strings.TrimSpace(head.URL) == "" {
+		return fmt.Errorf("head.url is required for head.source %s", head.Source)
+	}
 	return nil
 }
+
+// validateLimiterGroups checks that every limiter group a chain names is
+// configured, and slows optional work down before stopping it.
+func validateLimiterGroups(groups map[string]LimiterGroupConfig, chains Chains) error {
+	names := chains.Names()
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		group := chains[name].Throttle.LimiterGroup
+		if _, ok := groups[group]; group != "" && !ok {
+			errs = append(errs, fmt.Errorf("chain %s: throttle.limiter_group %q is not in services.limiter_groups", name, group))
+		}
+	}
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+	for _, group := range groupNames {
+		slow, stop := groups[group].SlowDownAt, groups[group].StopOptionalAt
+		if slow == 0 {
+			slow = ratelimiter.DefaultSlowDownAt
+		}
+		if stop == 0 {
+			stop = ratelimiter.DefaultStopOptionalAt
+		}
+		if slow >= stop {
+			errs = append(errs, fmt.Errorf("limiter group %s: slow_down_at (%g) must be below stop_optional_at (%g)",
+				group, slow, stop))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateChains runs checks that span multiple chains: network_id and
+// internal_code must be set and unique, and a node URL shared between
+// chains of different network types is reported as a warning.
+func validateChains(chains Chains) error {
+	names := chains.Names()
+	sort.Strings(names)
+
+	networkIDs := make(map[string][]string)
+	internalCodes := make(map[string][]string)
+	urlTypes := make(map[string]map[enum.NetworkType][]string)
+
+	var errs []error
+	for _, name := range names {
+		chain := chains[name]
+		if strings.TrimSpace(chain.NetworkId) == "" {
+			errs = append(errs, fmt.Errorf("chain %s: network_id is required", name))
+		} else {
+			networkIDs[chain.NetworkId] = append(networkIDs[chain.NetworkId], name)
+		}
+		if strings.TrimSpace(chain.InternalCode) == "" {
+			errs = append(errs, fmt.Errorf("chain %s: internal_code is required", name))
+		} else {
+			internalCodes[chain.InternalCode] = append(internalCodes[chain.InternalCode], name)
+		}
+		for _, node := range chain.Nodes {
+			url := strings.TrimRight(strings.TrimSpace(node.URL), "/")
+			if urlTypes[url] == nil {
+				urlTypes[url] = make(map[enum.NetworkType][]string)
+			}
+			urlTypes[url][chain.Type] = append(urlTypes[url][chain.Type], name)
+		}
+	}
+
+	errs = append(errs, duplicateErrors("network_id", networkIDs)...)
+	errs = append(errs, duplicateErrors("internal_code", internalCodes)...)
+
+	for url, types := range urlTypes {
+		if len(types) < 2 {
+			continue
+		}
+		var chainNames []string
+		for _, n := range types {
+			chainNames = append(chainNames, n...)
+		}
+		sort.Strings(chainNames)
+		logger.Warn("Node URL is shared by chains with different network types",
+			"host", urlHost(url),
+			"chains", chainNames,
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+// urlHost returns only the host of a node URL so API keys embedded in
+// the path or query never reach the logs.
+func urlHost(raw string) string {
+	u, err := neturl.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<invalid url>"
+	}
+	return u.Host
+}
+
+func duplicateErrors(field string, values map[string][]string) []error {
+	keys := make([]string, 0, len(values))
+	for v, chainNames := range values {
+		if len(chainNames) > 1 {
+			keys = append(keys, v)
+		}
+	}
+	sort.Strings(keys)
+
+	errs := make([]error, 0, len(keys))
+	for _, v := range keys {
+		errs = append(errs, fmt.Errorf(
+			"duplicate %s %q used by chains: %s",
+			field, v, strings.Join(values[v], ", "),
+		))
+	}
+	return errs
+}
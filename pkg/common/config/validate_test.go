@@ -1,7 +1,10 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 	"github.com/stretchr/testify/assert"
@@ -30,3 +33,261 @@ func TestValidateChainConfig_DoesNotRequireNativeDenomForNonCosmos(t *testing.T)
 	})
 	require.NoError(t, err)
 }
+
+func TestValidateChainConfig_RejectsRollbackWindowNotSmallerThanStart(t *testing.T) {
+	err := validateChainConfig(ChainConfig{
+		Type:                enum.NetworkTypeEVM,
+		StartBlock:          10,
+		ReorgRollbackWindow: 20,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reorg_rollback_window")
+}
+
+func TestValidateChainConfig_AllowsLargeRollbackWindowFromLatest(t *testing.T) {
+	err := validateChainConfig(ChainConfig{
+		Type:                enum.NetworkTypeEVM,
+		FromLatest:          true,
+		StartBlock:          10,
+		ReorgRollbackWindow: 20,
+	})
+	require.NoError(t, err)
+}
+
+func TestValidateChainConfig_RejectsBurstBelowRPS(t *testing.T) {
+	err := validateChainConfig(ChainConfig{
+		Type:     enum.NetworkTypeEVM,
+		Throttle: Throttle{RPS: 10, Burst: 5},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "throttle.burst")
+}
+
+func TestValidateChains_RejectsDuplicateNetworkID(t *testing.T) {
+	err := validateChains(Chains{
+		"eth_a": {NetworkId: "ethereum", InternalCode: "ETH_A", Type: enum.NetworkTypeEVM},
+		"eth_b": {NetworkId: "ethereum", InternalCode: "ETH_B", Type: enum.NetworkTypeEVM},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "network_id")
+	assert.Contains(t, err.Error(), "eth_a, eth_b")
+}
+
+func TestValidateChains_RejectsDuplicateInternalCode(t *testing.T) {
+	err := validateChains(Chains{
+		"eth_a": {NetworkId: "eth_a", InternalCode: "ETH", Type: enum.NetworkTypeEVM},
+		"eth_b": {NetworkId: "eth_b", InternalCode: "ETH", Type: enum.NetworkTypeEVM},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "internal_code")
+	assert.Contains(t, err.Error(), "eth_a, eth_b")
+}
+
+func TestValidateChains_RequiresNetworkIdentifiers(t *testing.T) {
+	err := validateChains(Chains{
+		"eth": {NetworkId: "eth", InternalCode: " ", Type: enum.NetworkTypeEVM},
+		"trx": {InternalCode: "TRX", Type: enum.NetworkTypeTron},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chain eth: internal_code is required")
+	assert.Contains(t, err.Error(), "chain trx: network_id is required")
+	assert.NotContains(t, err.Error(), "duplicate")
+}
+
+func TestConfig_GetChainByNetworkIdentifiers(t *testing.T) {
+	cfg := &Config{Chains: Chains{
+		"eth": {Name: "ETH", NetworkId: "ethereum_mainnet", InternalCode: "ETH_MAINNET"},
+		"trx": {Name: "TRX", NetworkId: "tron_mainnet", InternalCode: "TRON_MAINNET"},
+	}}
+
+	chain, err := cfg.GetChainByNetworkId("tron_mainnet")
+	require.NoError(t, err)
+	assert.Equal(t, "TRX", chain.Name)
+
+	chain, err = cfg.GetChainByInternalCode("ETH_MAINNET")
+	require.NoError(t, err)
+	assert.Equal(t, "ETH", chain.Name)
+
+	_, err = cfg.GetChainByNetworkId("ETH_MAINNET")
+	assert.Error(t, err, "the two namespaces are not mixed")
+	_, err = cfg.GetChainByInternalCode("unknown")
+	assert.Error(t, err)
+}
+
+func TestValidateChains_SharedURLIsNotAnError(t *testing.T) {
+	node := []NodeConfig{{URL: "https://rpc.example.com"}}
+	err := validateChains(Chains{
+		"eth": {NetworkId: "eth", InternalCode: "ETH", Type: enum.NetworkTypeEVM, Nodes: node},
+		"trx": {NetworkId: "trx", InternalCode: "TRX", Type: enum.NetworkTypeTron, Nodes: node},
+	})
+	require.NoError(t, err)
+}
+
+func TestValidateNatsEncoding(t *testing.T) {
+	require.NoError(t, validate.Struct(NatsConfig{Encoding: map[string]string{"transfer": "protobuf"}}))
+	require.NoError(t, validate.Struct(NatsConfig{}))
+	assert.Error(t, validate.Struct(NatsConfig{Encoding: map[string]string{"utxo": "protobuf"}}))
+	assert.Error(t, validate.Struct(NatsConfig{Encoding: map[string]string{"transfer": "xml"}}))
+}
+
+func TestLoad_ValidatesTxTypes(t *testing.T) {
+	load := func(txTypes string) error {
+		yaml := `
+env: development
+defaults:
+  poll_interval: 5s
+  reorg_rollback_window: 20
+chains:
+  eth:
+    type: evm
+    from_latest: true
+    nodes:
+      - url: https://rpc.example.com
+services:
+  port: 8080
+  tx_types:
+` + txTypes
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+		_, err := Load(path)
+		return err
+	}
+
+	require.NoError(t, load("    nonstandard: native_transfer\n"))
+	err := load("    coinjoin: transfer\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tx_types")
+}
+
+func TestLoad_AlertingNeedsNotifier(t *testing.T) {
+	t.Setenv("TEST_SLACK_WEBHOOK", "https://hooks.slack.example/T000/B000/secret")
+	load := func(alerting string) (*Config, error) {
+		yaml := `
+env: development
+defaults:
+  poll_interval: 5s
+  reorg_rollback_window: 20
+chains:
+  eth:
+    type: evm
+    from_latest: true
+    nodes:
+      - url: https://rpc.example.com
+services:
+  port: 8080
+  alerting:
+    enabled: true
+` + alerting
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+		return Load(path)
+	}
+
+	_, err := load("    stall_after: 10m\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "alerting")
+
+	cfg, err := load("    stall_after: 10m\n    slack_webhook_url: ${TEST_SLACK_WEBHOOK}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.example/T000/B000/secret", cfg.Services.Alerting.SlackWebhookURL)
+	assert.Equal(t, 10*time.Minute, cfg.Services.Alerting.StallAfter)
+}
+
+func TestValidateLimiterGroups(t *testing.T) {
+	chains := Chains{
+		"eth":     {Throttle: Throttle{LimiterGroup: "alchemy"}},
+		"polygon": {Throttle: Throttle{LimiterGroup: "alchemy"}},
+		"btc":     {},
+	}
+	groups := map[string]LimiterGroupConfig{"alchemy": {DailyRequests: 100000}}
+	require.NoError(t, validateLimiterGroups(groups, chains))
+
+	chains["base"] = ChainConfig{Throttle: Throttle{LimiterGroup: "infura"}}
+	err := validateLimiterGroups(groups, chains)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `chain base: throttle.limiter_group "infura"`)
+	delete(chains, "base")
+
+	// Stopping optional work at the default 0.9 before slowing it down.
+	groups["alchemy"] = LimiterGroupConfig{SlowDownAt: 0.95}
+	err = validateLimiterGroups(groups, chains)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "slow_down_at (0.95) must be below stop_optional_at (0.9)")
+}
+
+func TestValidateChainConfig_Assets(t *testing.T) {
+	err := validateChainConfig(ChainConfig{
+		Type: enum.NetworkTypeEVM,
+		Assets: AssetFilterConfig{
+			Allow: []string{"0xdAC17F958D2ee523a2206206994597C13D831ec7", "0x1234"},
+			Deny:  []string{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `assets.allow[1] "0x1234"`)
+	assert.Contains(t, err.Error(), `assets.deny[0] "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"`)
+	assert.NotContains(t, err.Error(), "allow[0]")
+
+	err = validateChainConfig(ChainConfig{
+		Type: enum.NetworkTypeTron,
+		Assets: AssetFilterConfig{
+			Allow: []string{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", "1002000"},
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestNormalizeAsset(t *testing.T) {
+	got, err := NormalizeAsset(enum.NetworkTypeTron, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	require.NoError(t, err)
+	assert.Equal(t, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", got)
+
+	got, err = NormalizeAsset(enum.NetworkTypeEVM, " 0xdAC17F958D2ee523a2206206994597C13D831ec7 ")
+	require.NoError(t, err)
+	assert.Equal(t, "0xdac17f958d2ee523a2206206994597c13d831ec7", got)
+
+	// Normalized as addresses are: a mistyped checksum is caught.
+	_, err = NormalizeAsset(enum.NetworkTypeEVM, "0xDAC17F958D2ee523a2206206994597C13D831ec7")
+	assert.Error(t, err)
+	got, err = NormalizeAsset(enum.NetworkTypeTron, " 1002000 ")
+	require.NoError(t, err)
+	assert.Equal(t, "1002000", got)
+}
+
+func TestValidateChainConfig_BatchBudget(t *testing.T) {
+	require.NoError(t, validateChainConfig(ChainConfig{
+		Type:     enum.NetworkTypeBtc,
+		Throttle: Throttle{BatchBudget: time.Minute},
+	}))
+	err := validateChainConfig(ChainConfig{
+		Type:     enum.NetworkTypeEVM,
+		Throttle: Throttle{BatchBudget: time.Minute},
+	})
+	assert.ErrorContains(t, err, "throttle.batch_budget is only supported on btc chains")
+
+	// A default budget is only inherited by Bitcoin chains.
+	def := testDefaults()
+	def.Throttle.BatchBudget = time.Minute
+	assert.Zero(t, ResolveChainConfig(def, ChainConfig{Type: enum.NetworkTypeEVM}).Throttle.BatchBudget)
+	assert.Equal(t, time.Minute, ResolveChainConfig(def, ChainConfig{Type: enum.NetworkTypeBtc}).Throttle.BatchBudget)
+}
+
+func TestValidateChainConfig_Head(t *testing.T) {
+	err := validateChainConfig(ChainConfig{
+		Type: enum.NetworkTypeBtc,
+		Head: HeadConfig{Source: HeadSourceZMQ, URL: "tcp://127.0.0.1:28332"},
+	})
+	require.NoError(t, err)
+
+	err = validateChainConfig(ChainConfig{
+		Type: enum.NetworkTypeEVM,
+		Head: HeadConfig{Source: HeadSourceZMQ, URL: "tcp://127.0.0.1:28332"},
+	})
+	assert.ErrorContains(t, err, "not supported")
+
+	err = validateChainConfig(ChainConfig{
+		Type: enum.NetworkTypeEVM,
+		Head: HeadConfig{Source: HeadSourceWebsocket},
+	})
+	assert.ErrorContains(t, err, "head.url is required")
+}
@@ -11,16 +11,25 @@ const (
 	MaxCatchupBlocks           = 100000
 	DefaultReorgRollbackWindow = 50
 	DefaultMaxLag              = 100
+	// Consecutive failures a worker logs as warnings before errors.
+	DefaultErrorAfterFailures = 3
 
 	KVPrefixLatestBlock     = "latest_block"
 	KVPrefixProgressCatchup = "catchup_progress"
 	KVPrefixFailedBlocks    = "failed_blocks"
 	KVPrefixBlockHash       = "block_hash"
+	KVPrefixReorgHalt       = "reorg_halt"
 
 	RangeProcessingTimeout = 3 * time.Minute
 
 	TxTypeTokenTransfer  TxType = "token_transfer"
 	TxTypeNativeTransfer TxType = "native_transfer"
+	TxTypeNonstandard    TxType = "nonstandard"   // value moved to/from a script with no address
+	TxTypeFee            TxType = "fee"           // a transaction's fee as its own record
+	TxTypeConsolidation  TxType = "consolidation" // value moved between watched addresses only
+	TxTypeSelfDestruct   TxType = "selfdestruct"  // a contract's balance sent to the beneficiary of its SELFDESTRUCT
+	TxTypeCoinbase       TxType = "coinbase"      // a block reward paid by a coinbase transaction
+	TxTypeSelfTransfer   TxType = "self_transfer" // value sent back to one of its own senders, see ChainConfig.EmitSelfTransfers
 
 	// Transaction confirmation status
 	TxnStatusPending    = "pending"    // 0 confirmations (mempool)
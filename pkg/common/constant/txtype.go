@@ -0,0 +1,85 @@
+package constant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TxTypes lists every transaction type the indexers emit.
+var TxTypes = []TxType{
+	TxTypeNativeTransfer,
+	TxTypeTokenTransfer,
+	TxTypeNonstandard,
+	TxTypeFee,
+	TxTypeConsolidation,
+	TxTypeSelfDestruct,
+	TxTypeCoinbase,
+	TxTypeSelfTransfer,
+}
+
+// TxTypeRegistry maps the transaction types the indexers emit to the names
+// published for them. Types without an override keep their own name; two
+// types may share a name, aliasing one to the other. The zero value and a
+// nil registry publish every type under its own name.
+type TxTypeRegistry struct {
+	names map[TxType]TxType
+}
+
+// NewTxTypeRegistry builds a registry from overrides of emitted type to
+// published name, e.g. {"nonstandard": "native_transfer"}. Every key must
+// be one of TxTypes and every name non-empty.
+func NewTxTypeRegistry(overrides map[string]string) (*TxTypeRegistry, error) {
+	known := make(map[TxType]bool, len(TxTypes))
+	for _, t := range TxTypes {
+		known[t] = true
+	}
+
+	var unknown []string
+	names := make(map[TxType]TxType, len(overrides))
+	for from, to := range overrides {
+		if !known[TxType(from)] {
+			unknown = append(unknown, from)
+			continue
+		}
+		to = strings.TrimSpace(to)
+		if to == "" {
+			return nil, fmt.Errorf("tx type %q: empty name", from)
+		}
+		names[TxType(from)] = TxType(to)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown tx types %s (known: %s)",
+			strings.Join(unknown, ", "), joinTxTypes(TxTypes))
+	}
+	return &TxTypeRegistry{names: names}, nil
+}
+
+// Name returns the name t is published under.
+func (r *TxTypeRegistry) Name(t TxType) TxType {
+	if r == nil {
+		return t
+	}
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	return t
+}
+
+// Mapping returns the published name of every emitted type.
+func (r *TxTypeRegistry) Mapping() map[TxType]TxType {
+	mapping := make(map[TxType]TxType, len(TxTypes))
+	for _, t := range TxTypes {
+		mapping[t] = r.Name(t)
+	}
+	return mapping
+}
+
+func joinTxTypes(types []TxType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,51 @@
+package constant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxTypeRegistry(t *testing.T) {
+	r, err := NewTxTypeRegistry(map[string]string{
+		"nonstandard": "native_transfer",
+		"fee":         " network_fee ",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, TxTypeNativeTransfer, r.Name(TxTypeNonstandard))
+	assert.Equal(t, TxType("network_fee"), r.Name(TxTypeFee))
+	assert.Equal(t, TxTypeTokenTransfer, r.Name(TxTypeTokenTransfer))
+	assert.Equal(t, map[TxType]TxType{
+		TxTypeNativeTransfer: TxTypeNativeTransfer,
+		TxTypeTokenTransfer:  TxTypeTokenTransfer,
+		TxTypeNonstandard:    TxTypeNativeTransfer,
+		TxTypeFee:            "network_fee",
+		TxTypeConsolidation:  TxTypeConsolidation,
+		TxTypeSelfDestruct:   TxTypeSelfDestruct,
+		TxTypeCoinbase:       TxTypeCoinbase,
+		TxTypeSelfTransfer:   TxTypeSelfTransfer,
+	}, r.Mapping())
+}
+
+func TestTxTypeRegistry_Defaults(t *testing.T) {
+	r, err := NewTxTypeRegistry(nil)
+	require.NoError(t, err)
+	var none *TxTypeRegistry
+
+	for _, typ := range TxTypes {
+		assert.Equal(t, typ, r.Name(typ))
+		assert.Equal(t, typ, none.Name(typ))
+	}
+	assert.Len(t, none.Mapping(), len(TxTypes))
+}
+
+func TestTxTypeRegistry_Invalid(t *testing.T) {
+	_, err := NewTxTypeRegistry(map[string]string{"coinjoin": "mixing", "mining": "coinbase"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "coinjoin, mining")
+
+	_, err = NewTxTypeRegistry(map[string]string{"fee": " "})
+	require.Error(t, err)
+}
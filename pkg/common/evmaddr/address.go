@@ -0,0 +1,97 @@
+// Package evmaddr validates EVM addresses and converts them between the
+// lowercase form they are stored and emitted in and the EIP-55 checksummed
+// form shown to users.
+package evmaddr
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidAddress is returned by ParseAddress for input that is not
+	// 20 bytes of hex.
+	ErrInvalidAddress = errors.New("not a 20-byte hex address")
+	// ErrBadChecksum is returned by ParseAddress for a mixed-case address
+	// whose case does not match its EIP-55 checksum, most likely a typo.
+	ErrBadChecksum = errors.New("bad EIP-55 checksum")
+)
+
+// LowerAddress returns addr in canonical form, lowercase with a 0x prefix,
+// which is how addresses are stored, matched and emitted. Like
+// ToChecksumAddress it does not validate addr; use ParseAddress for input
+// from users.
+func LowerAddress(addr string) string {
+	return "0x" + strings.TrimPrefix(strings.ToLower(addr), "0x")
+}
+
+// ParseAddress validates an address as a user entered it and returns its
+// canonical form, see LowerAddress. An address all in one case carries no
+// checksum and is accepted as is; one in mixed case is EIP-55 checksummed
+// and accepted only if the checksum matches, reported by checksummed.
+// ToChecksumAddress gives the form to display.
+func ParseAddress(addr string) (lower string, checksummed bool, err error) {
+	raw := addr
+	if len(raw) >= 2 && raw[0] == '0' && (raw[1] == 'x' || raw[1] == 'X') {
+		raw = raw[2:]
+	}
+	if len(raw) != 40 {
+		return "", false, ErrInvalidAddress
+	}
+	if _, err := hex.DecodeString(raw); err != nil {
+		return "", false, ErrInvalidAddress
+	}
+	lower = "0x" + strings.ToLower(raw)
+	if raw == lower[2:] || raw == strings.ToUpper(raw) {
+		return lower, false, nil
+	}
+	if ToChecksumAddress(lower) != "0x"+raw {
+		return "", false, ErrBadChecksum
+	}
+	return lower, true, nil
+}
+
+// ToChecksumAddress converts an Ethereum address to EIP-55 checksummed format
+func ToChecksumAddress(addr string) string {
+	// Remove 0x prefix if present
+	addr = strings.TrimPrefix(strings.ToLower(addr), "0x")
+
+	// Handle empty or invalid addresses
+	if len(addr) != 40 {
+		return "0x" + addr
+	}
+
+	// Compute keccak256 hash of the lowercase address
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(addr))
+	hashBytes := hash.Sum(nil)
+
+	// Build checksummed address
+	result := make([]byte, 42)
+	result[0] = '0'
+	result[1] = 'x'
+
+	for i := 0; i < 40; i++ {
+		c := addr[i]
+		// Get the corresponding nibble from hash
+		hashByte := hashBytes[i/2]
+		var nibble byte
+		if i%2 == 0 {
+			nibble = hashByte >> 4
+		} else {
+			nibble = hashByte & 0x0f
+		}
+
+		// If hash nibble >= 8, capitalize the character (if it's a letter)
+		if nibble >= 8 && c >= 'a' && c <= 'f' {
+			result[2+i] = c - 32 // Convert to uppercase
+		} else {
+			result[2+i] = c
+		}
+	}
+
+	return string(result)
+}
@@ -0,0 +1,48 @@
+package evmaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	// EIP-55 test vectors.
+	for _, addr := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		lower, checksummed, err := ParseAddress(addr)
+		assert.NoError(t, err, addr)
+		assert.True(t, checksummed, addr)
+		assert.Equal(t, LowerAddress(addr), lower)
+		assert.Equal(t, addr, ToChecksumAddress(lower), "checksummed form round-trips")
+	}
+
+	for _, addr := range []string{
+		"0x52908400098527886e0f7030069857d2e4169ee7",
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"52908400098527886e0f7030069857d2e4169ee7",
+		"0X52908400098527886E0F7030069857D2E4169EE7",
+	} {
+		lower, checksummed, err := ParseAddress(addr)
+		assert.NoError(t, err, addr)
+		assert.False(t, checksummed, addr)
+		assert.Equal(t, "0x52908400098527886e0f7030069857d2e4169ee7", lower)
+	}
+
+	_, _, err := ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	assert.ErrorIs(t, err, ErrBadChecksum)
+	for _, addr := range []string{"", "0x", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beagg"} {
+		_, _, err := ParseAddress(addr)
+		assert.ErrorIs(t, err, ErrInvalidAddress, addr)
+	}
+}
+
+func TestLowerAddress(t *testing.T) {
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", LowerAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"))
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", LowerAddress("0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"))
+	assert.Equal(t, "0x", LowerAddress(""))
+}
@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/lmittmann/tint"
 )
@@ -11,6 +13,11 @@ import (
 var (
 	once   sync.Once
 	logger *slog.Logger
+
+	// base writes every level; level filters it for loggers without their
+	// own, see ForChain.
+	base  slog.Handler
+	level slog.Leveler = slog.LevelInfo
 )
 
 type Options struct {
@@ -26,12 +33,15 @@ func Init(opts *Options) {
 			writer = os.Stdout
 		}
 
-		handler := tint.NewHandler(writer, &tint.Options{
-			Level:      opts.Level,
+		base = tint.NewHandler(writer, &tint.Options{
+			Level:      slog.LevelDebug,
 			TimeFormat: opts.TimeFormat,
 		})
+		if opts.Level != nil {
+			level = opts.Level
+		}
 
-		logger = slog.New(handler)
+		logger = slog.New(&levelHandler{Handler: base, level: level})
 		slog.SetDefault(logger)
 	})
 }
@@ -83,5 +93,68 @@ func Fatal(msg string, args ...any) {
 }
 
 func With(args ...any) *slog.Logger {
+	if logger == nil {
+		return slog.Default().With(args...)
+	}
 	return logger.With(args...)
 }
+
+// ForChain returns a logger adding the chain and network ID to every
+// record, logging from chainLevel, or the global level if nil.
+func ForChain(chain, networkID string, chainLevel slog.Leveler) *slog.Logger {
+	attrs := []any{"chain", chain}
+	if networkID != "" {
+		attrs = append(attrs, "network_id", networkID)
+	}
+	h := base
+	if h == nil {
+		h = slog.Default().Handler()
+	}
+	if chainLevel == nil {
+		chainLevel = level
+	}
+	return slog.New(&levelHandler{Handler: h, level: chainLevel}).With(attrs...)
+}
+
+// levelHandler drops records below level.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.level.Level() && h.Handler.Enabled(ctx, l)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// Sampler picks the level of a high-frequency message: info for every Nth
+// call and debug for the rest, so info logs keep a trace of progress.
+type Sampler struct {
+	every uint64
+	n     atomic.Uint64
+}
+
+// NewSampler returns a Sampler logging every Nth call at info; every
+// below 2 logs all calls at info.
+func NewSampler(every int) *Sampler {
+	return &Sampler{every: uint64(max(every, 1))}
+}
+
+// Level returns the level for the next message. A nil Sampler always
+// returns info.
+func (s *Sampler) Level() slog.Level {
+	if s == nil || s.every == 1 {
+		return slog.LevelInfo
+	}
+	if s.n.Add(1)%s.every == 1 {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestForChain_LevelAndContext(t *testing.T) {
+	var buf bytes.Buffer
+	prevBase, prevLevel := base, level
+	base = slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	level = slog.LevelInfo
+	defer func() { base, level = prevBase, prevLevel }()
+
+	quiet := ForChain("BTC", "bitcoin_mainnet", nil)
+	quiet.Debug("hidden")
+	quiet.Info("shown")
+
+	verbose := ForChain("ETH", "", slog.LevelDebug)
+	verbose.Debug("detail")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("debug record logged at the global info level:\n%s", out)
+	}
+	if !strings.Contains(out, "msg=shown chain=BTC network_id=bitcoin_mainnet") {
+		t.Errorf("chain context missing:\n%s", out)
+	}
+	if !strings.Contains(out, "msg=detail chain=ETH\n") {
+		t.Errorf("chain level override not applied, or empty network ID logged:\n%s", out)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(3)
+	var got []slog.Level
+	for range 6 {
+		got = append(got, s.Level())
+	}
+	want := []slog.Level{slog.LevelInfo, slog.LevelDebug, slog.LevelDebug, slog.LevelInfo, slog.LevelDebug, slog.LevelDebug}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("levels = %v, want %v", got, want)
+		}
+	}
+
+	for _, s := range []*Sampler{nil, NewSampler(0), NewSampler(1)} {
+		if l := s.Level(); l != slog.LevelInfo {
+			t.Errorf("unsampled level = %v, want info", l)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package tronaddr converts TRON addresses between the 41-prefixed hex
+// form node payloads carry and the base58check form wallets use.
+package tronaddr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// AddressPrefix is the version byte of TRON addresses, the 41 their hex
+// form starts with and the T their base58check form does.
+const AddressPrefix = 0x41
+
+var (
+	// ErrInvalidAddress is returned by HexToBase58 and Base58ToHex for input
+	// that is not a TRON address in the form they convert from.
+	ErrInvalidAddress = errors.New("invalid TRON address")
+	// ErrBadChecksum is returned by Base58ToHex for a base58check address
+	// whose checksum does not match, most likely a typo.
+	ErrBadChecksum = errors.New("bad TRON address checksum")
+)
+
+// HexToBase58 converts a hex address, as node RPC payloads carry it, to the
+// base58check form wallets and the wallet_addresses table use. It accepts
+// the 41-prefixed form, with or without 0x, and a bare 20-byte EVM-style
+// form, to which the prefix is added, as in TRC-20 logs.
+func HexToBase58(hexAddr string) (string, error) {
+	s := strings.TrimSpace(hexAddr)
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q is not hex", ErrInvalidAddress, hexAddr)
+	}
+	switch {
+	case len(raw) == 20:
+		raw = append([]byte{AddressPrefix}, raw...)
+	case len(raw) == 21 && raw[0] == AddressPrefix:
+	default:
+		return "", fmt.Errorf("%w: %q is not a 41-prefixed 21-byte address", ErrInvalidAddress, hexAddr)
+	}
+	return base58.Encode(append(raw, addressChecksum(raw)...)), nil
+}
+
+// Base58ToHex converts a base58check address to its 41-prefixed hex form,
+// verifying its checksum.
+func Base58ToHex(addr string) (string, error) {
+	raw := base58.Decode(strings.TrimSpace(addr))
+	if len(raw) != 25 || raw[0] != AddressPrefix {
+		return "", fmt.Errorf("%w: %q is not a base58check address", ErrInvalidAddress, addr)
+	}
+	if string(addressChecksum(raw[:21])) != string(raw[21:]) {
+		return "", fmt.Errorf("%w: %q", ErrBadChecksum, addr)
+	}
+	return hex.EncodeToString(raw[:21]), nil
+}
+
+// addressChecksum is the base58check checksum of payload: the first four
+// bytes of its double SHA-256.
+func addressChecksum(payload []byte) []byte {
+	h1 := sha256.Sum256(payload)
+	h2 := sha256.Sum256(h1[:])
+	return h2[:4]
+}
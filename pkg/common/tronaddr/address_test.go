@@ -0,0 +1,62 @@
+package tronaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHexToBase58(t *testing.T) {
+	tests := map[string]string{
+		// USDT, a contract address.
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded13c":   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		"0x41a614f803b6fd780986a42c78ec9c7f77e6ded13c": "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		"0xa614f803b6fd780986a42c78ec9c7f77e6ded13c":   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		"A614F803B6FD780986A42C78EC9C7F77E6DED13C":     "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		// The zero address.
+		"410000000000000000000000000000000000000000": "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb",
+	}
+	for in, want := range tests {
+		got, err := HexToBase58(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+
+		back, err := Base58ToHex(got)
+		require.NoError(t, err, got)
+		assert.Len(t, back, 42)
+		assert.Equal(t, "41", back[:2])
+	}
+
+	for _, in := range []string{
+		"",
+		"0x",
+		"41a614",
+		"42a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded1zz",
+		"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+	} {
+		_, err := HexToBase58(in)
+		assert.ErrorIs(t, err, ErrInvalidAddress, in)
+	}
+}
+
+func TestBase58ToHex(t *testing.T) {
+	got, err := Base58ToHex("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+	require.NoError(t, err)
+	assert.Equal(t, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", got)
+
+	_, err = Base58ToHex("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u")
+	assert.ErrorIs(t, err, ErrBadChecksum)
+
+	for _, in := range []string{
+		"",
+		"T",
+		"0OIl",
+		// A Bitcoin address: base58check, but version 0x00.
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+	} {
+		_, err := Base58ToHex(in)
+		assert.ErrorIs(t, err, ErrInvalidAddress, in)
+	}
+}
@@ -120,3 +120,27 @@ func TestEmitBlock_MixedSources_UniqueHashes(t *testing.T) {
 		seen[h] = source
 	}
 }
+
+func TestTransferRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		toMatched      bool
+		sendersMatched int
+		senders        int
+		want           string
+	}{
+		{"deposit", true, 0, 1, RoleDeposit},
+		{"withdrawal", false, 1, 1, RoleWithdrawal},
+		{"internal", true, 1, 1, RoleInternal},
+		{"multi-input withdrawal", false, 3, 3, RoleWithdrawal},
+		{"partial senders", false, 1, 3, RoleMixed},
+		{"partial senders to monitored", true, 2, 3, RoleMixed},
+		{"no senders known", true, 0, 0, RoleDeposit},
+		{"unmatched", false, 0, 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TransferRole(tt.toMatched, tt.sendersMatched, tt.senders))
+		})
+	}
+}
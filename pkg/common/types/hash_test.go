@@ -134,3 +134,49 @@ func TestTransactionHash_ReorgProducesDifferentHash(t *testing.T) {
 
 	assert.NotEqual(t, tx1.Hash(), tx2.Hash(), "reorg (different BlockHash) should produce different hash for NATS re-delivery")
 }
+
+func TestTransferID_StableAcrossBlockStatusAndDirection(t *testing.T) {
+	base := Transaction{
+		NetworkId:     "btc",
+		TxHash:        "abc",
+		TransferIndex: "1:0",
+		FromAddress:   "a",
+		ToAddress:     "b",
+	}
+	mempool := base
+	mempool.Status = StatusPending
+	mined := base
+	mined.BlockHash = "block1"
+	mined.Status = StatusConfirmed
+	mined.Direction = "in"
+
+	assert.Equal(t, mempool.ComputeTransferID(), mined.ComputeTransferID())
+	assert.NotEqual(t, mempool.Hash(), mined.Hash(), "idempotency key still changes with block hash")
+
+	other := base
+	other.TransferIndex = "2:0"
+	assert.NotEqual(t, base.ComputeTransferID(), other.ComputeTransferID())
+}
+
+func TestEnsureTransferID_KeepsExisting(t *testing.T) {
+	tx := Transaction{NetworkId: "eth", TxHash: "0xabc", TransferID: "preset"}
+	tx.EnsureTransferID()
+	assert.Equal(t, "preset", tx.TransferID)
+
+	tx.TransferID = ""
+	tx.EnsureTransferID()
+	assert.Equal(t, tx.ComputeTransferID(), tx.TransferID)
+	assert.Len(t, tx.TransferID, 64)
+}
+
+func TestBlockReorgHash(t *testing.T) {
+	reorg := BlockReorg{NetworkId: "btc", FromBlock: 10, ToBlock: 12, OldHash: "old", NewHash: "new", DetectedAt: 1}
+	again := reorg
+	again.DetectedAt = 2
+	again.TransferIDs = []string{"t1"}
+	assert.Equal(t, reorg.Hash(), again.Hash(), "a reorg detected twice is delivered once")
+
+	other := reorg
+	other.NewHash = "newer"
+	assert.NotEqual(t, reorg.Hash(), other.Hash())
+}
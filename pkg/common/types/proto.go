@@ -0,0 +1,222 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types/typespb"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Wire content types for published events.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Marshal encodes v (a Transaction or Block) using contentType.
+func Marshal(contentType string, v any) ([]byte, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return json.Marshal(v)
+	case ContentTypeProtobuf:
+		switch t := v.(type) {
+		case *Transaction:
+			return t.MarshalProto()
+		case Transaction:
+			return t.MarshalProto()
+		case *Block:
+			return t.MarshalProto()
+		case Block:
+			return t.MarshalProto()
+		}
+		return nil, fmt.Errorf("no protobuf encoding for %T", v)
+	}
+	return nil, fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// MarshalProto encodes the transaction as a typespb.Transaction.
+func (t Transaction) MarshalProto() ([]byte, error) {
+	pb, err := t.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(pb)
+}
+
+// UnmarshalProto decodes a typespb.Transaction into t.
+func (t *Transaction) UnmarshalProto(data []byte) error {
+	var pb typespb.Transaction
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	return t.FromProto(&pb)
+}
+
+// ToProto converts the transaction to its protobuf form.
+func (t Transaction) ToProto() (*typespb.Transaction, error) {
+	metadata, err := metadataToProto(t.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &typespb.Transaction{
+		TxHash:        t.TxHash,
+		NetworkId:     t.NetworkId,
+		InternalCode:  t.InternalCode,
+		BlockNumber:   t.BlockNumber,
+		BlockHash:     t.BlockHash,
+		TransferIndex: t.TransferIndex,
+		TransferId:    t.TransferID,
+		FromAddress:   t.FromAddress,
+		FromAddresses: t.FromAddresses,
+		ToAddress:     t.ToAddress,
+		AssetAddress:  t.AssetAddress,
+		Amount:        t.Amount,
+		Type:          string(t.Type),
+		TxFee:         t.TxFee.String(),
+		Timestamp:     t.Timestamp,
+		Confirmations: t.Confirmations,
+		Status:        t.Status,
+		Direction:     t.Direction,
+		Role:          t.Role,
+		Metadata:      metadata,
+		Unit:          unitToProto(t.Unit),
+		FeeUnit:       unitToProto(t.FeeUnit),
+	}, nil
+}
+
+// FromProto fills t from its protobuf form.
+func (t *Transaction) FromProto(pb *typespb.Transaction) error {
+	fee := decimal.Zero
+	if pb.GetTxFee() != "" {
+		var err error
+		if fee, err = decimal.NewFromString(pb.GetTxFee()); err != nil {
+			return fmt.Errorf("decode tx_fee: %w", err)
+		}
+	}
+	*t = Transaction{
+		TxHash:        pb.GetTxHash(),
+		NetworkId:     pb.GetNetworkId(),
+		InternalCode:  pb.GetInternalCode(),
+		BlockNumber:   pb.GetBlockNumber(),
+		BlockHash:     pb.GetBlockHash(),
+		TransferIndex: pb.GetTransferIndex(),
+		TransferID:    pb.GetTransferId(),
+		FromAddress:   pb.GetFromAddress(),
+		FromAddresses: pb.GetFromAddresses(),
+		ToAddress:     pb.GetToAddress(),
+		AssetAddress:  pb.GetAssetAddress(),
+		Amount:        pb.GetAmount(),
+		Type:          constant.TxType(pb.GetType()),
+		TxFee:         fee,
+		Timestamp:     pb.GetTimestamp(),
+		Confirmations: pb.GetConfirmations(),
+		Status:        pb.GetStatus(),
+		Direction:     pb.GetDirection(),
+		Role:          pb.GetRole(),
+		Metadata:      pb.GetMetadata().AsMap(),
+		Unit:          unitFromProto(pb.GetUnit()),
+		FeeUnit:       unitFromProto(pb.GetFeeUnit()),
+	}
+	if len(t.Metadata) == 0 {
+		t.Metadata = nil
+	}
+	return nil
+}
+
+// MarshalProto encodes the block as a typespb.Block.
+func (b Block) MarshalProto() ([]byte, error) {
+	metadata, err := metadataToProto(b.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	pb := &typespb.Block{
+		Number:       b.Number,
+		Hash:         b.Hash,
+		ParentHash:   b.ParentHash,
+		Timestamp:    b.Timestamp,
+		Transactions: make([]*typespb.Transaction, 0, len(b.Transactions)),
+		Metadata:     metadata,
+		Size:         b.Size,
+		Weight:       b.Weight,
+		Difficulty:   b.Difficulty,
+		TxCount:      uint32(b.TxCount),
+		GasUsed:      b.GasUsed,
+		GasLimit:     b.GasLimit,
+		InternalCode: b.InternalCode,
+	}
+	for _, tx := range b.Transactions {
+		txPB, err := tx.ToProto()
+		if err != nil {
+			return nil, err
+		}
+		pb.Transactions = append(pb.Transactions, txPB)
+	}
+	return proto.Marshal(pb)
+}
+
+// UnmarshalProto decodes a typespb.Block into b.
+func (b *Block) UnmarshalProto(data []byte) error {
+	var pb typespb.Block
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	*b = Block{
+		Number:       pb.GetNumber(),
+		Hash:         pb.GetHash(),
+		ParentHash:   pb.GetParentHash(),
+		Timestamp:    pb.GetTimestamp(),
+		Transactions: make([]Transaction, len(pb.GetTransactions())),
+		Metadata:     pb.GetMetadata().AsMap(),
+		Size:         pb.GetSize(),
+		Weight:       pb.GetWeight(),
+		Difficulty:   pb.GetDifficulty(),
+		TxCount:      int(pb.GetTxCount()),
+		GasUsed:      pb.GetGasUsed(),
+		GasLimit:     pb.GetGasLimit(),
+		InternalCode: pb.GetInternalCode(),
+	}
+	for i, txPB := range pb.GetTransactions() {
+		if err := b.Transactions[i].FromProto(txPB); err != nil {
+			return err
+		}
+	}
+	if len(b.Metadata) == 0 {
+		b.Metadata = nil
+	}
+	return nil
+}
+
+func unitToProto(u *AmountUnit) *typespb.AmountUnit {
+	if u == nil {
+		return nil
+	}
+	return &typespb.AmountUnit{Name: u.Name, Decimals: uint32(u.Decimals)}
+}
+
+func unitFromProto(pb *typespb.AmountUnit) *AmountUnit {
+	if pb == nil {
+		return nil
+	}
+	return &AmountUnit{Name: pb.GetName(), Decimals: uint8(pb.GetDecimals())}
+}
+
+// metadataToProto encodes metadata as a Struct via its JSON form, so
+// consumers see the same shape in both encodings.
+func metadataToProto(metadata map[string]any) (*structpb.Struct, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	return structpb.NewStruct(generic)
+}
@@ -0,0 +1,155 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func sampleTransaction() Transaction {
+	tx := Transaction{
+		TxHash:        "abc",
+		NetworkId:     "bitcoin_mainnet",
+		InternalCode:  "BTC",
+		BlockNumber:   840000,
+		BlockHash:     "000000000000000000032a",
+		TransferIndex: "1:0",
+		FromAddress:   "bc1qfrom",
+		FromAddresses: []string{"bc1qfrom", "bc1qother"},
+		ToAddress:     "bc1qto",
+		Amount:        "123456789012345678901234567890",
+		Type:          constant.TxTypeNativeTransfer,
+		TxFee:         decimal.RequireFromString("0.000000000000000001"),
+		Timestamp:     1713571767,
+		Confirmations: 6,
+		Status:        StatusConfirmed,
+		Direction:     "in",
+		Role:          RoleMixed,
+	}
+	tx.SetMetadata("vout", 1)
+	tx.SetMetadataString("script_pubkey", "0014abcd")
+	tx.EnsureTransferID()
+	return tx
+}
+
+func TestTransactionProto_RoundTrip(t *testing.T) {
+	tx := sampleTransaction()
+	tx.Unit = &AmountUnit{Name: "sat", Decimals: 8}
+	tx.FeeUnit = &AmountUnit{Decimals: 18}
+
+	data, err := tx.MarshalProto()
+	require.NoError(t, err)
+
+	var got Transaction
+	require.NoError(t, got.UnmarshalProto(data))
+
+	assert.Equal(t, tx.Amount, got.Amount)
+	assert.True(t, tx.TxFee.Equal(got.TxFee), "fee %s != %s", tx.TxFee, got.TxFee)
+	assert.Equal(t, "0.000000000000000001", got.TxFee.String())
+	assert.Equal(t, tx.TransferID, got.TransferID)
+	assert.Equal(t, tx.Hash(), got.Hash())
+	assert.Equal(t, tx.FromAddresses, got.FromAddresses)
+	assert.Equal(t, float64(1), got.Metadata["vout"], "metadata follows JSON number semantics")
+	assert.Equal(t, "0014abcd", got.GetMetadataString("script_pubkey"))
+
+	// Everything but metadata must match exactly.
+	tx.Metadata, got.Metadata = nil, nil
+	assert.Equal(t, tx, got)
+}
+
+func TestBlockProto_RoundTrip(t *testing.T) {
+	block := Block{
+		Number:       840000,
+		Hash:         "blockhash",
+		ParentHash:   "parenthash",
+		Timestamp:    1713571767,
+		Transactions: []Transaction{sampleTransaction(), sampleTransaction()},
+		Size:         1_600_000,
+		Weight:       3_993_000,
+		Difficulty:   86388558925171.02,
+		TxCount:      3050,
+		InternalCode: "BTC",
+	}
+	block.SetMetadata("utxo_events", []map[string]any{{"txHash": "abc"}})
+
+	data, err := block.MarshalProto()
+	require.NoError(t, err)
+
+	var got Block
+	require.NoError(t, got.UnmarshalProto(data))
+	assert.Equal(t, block.Number, got.Number)
+	assert.Equal(t, block.ParentHash, got.ParentHash)
+	require.Len(t, got.Transactions, 2)
+	assert.Equal(t, block.Transactions[1].TransferID, got.Transactions[1].TransferID)
+	assert.Equal(t, []any{map[string]any{"txHash": "abc"}}, got.Metadata["utxo_events"])
+	assert.Equal(t, block.Size, got.Size)
+	assert.Equal(t, block.Weight, got.Weight)
+	assert.Equal(t, block.Difficulty, got.Difficulty)
+	assert.Equal(t, block.TxCount, got.TxCount)
+	assert.Equal(t, "BTC", got.InternalCode)
+	assert.Equal(t, "BTC", got.Transactions[0].InternalCode)
+}
+
+func TestTransactionProto_IgnoresUnknownFields(t *testing.T) {
+	data, err := sampleTransaction().MarshalProto()
+	require.NoError(t, err)
+
+	// A newer producer adds field 100 (string) and field 101 (varint).
+	data = protowire.AppendTag(data, 100, protowire.BytesType)
+	data = protowire.AppendString(data, "from the future")
+	data = protowire.AppendTag(data, 101, protowire.VarintType)
+	data = protowire.AppendVarint(data, 42)
+
+	var got Transaction
+	require.NoError(t, got.UnmarshalProto(data))
+	assert.Equal(t, "abc", got.TxHash)
+}
+
+func TestTransactionJSON_IgnoresUnknownFields(t *testing.T) {
+	data, err := json.Marshal(sampleTransaction())
+	require.NoError(t, err)
+	data = append(data[:len(data)-1], []byte(`,"newField":{"x":1}}`)...)
+
+	var got Transaction
+	require.NoError(t, got.UnmarshalBinary(data))
+	assert.Equal(t, "abc", got.TxHash)
+}
+
+func TestMarshal_ContentTypes(t *testing.T) {
+	tx := sampleTransaction()
+
+	jsonData, err := Marshal(ContentTypeJSON, &tx)
+	require.NoError(t, err)
+	var fromJSON Transaction
+	require.NoError(t, json.Unmarshal(jsonData, &fromJSON))
+	assert.Equal(t, tx.TransferID, fromJSON.TransferID)
+
+	protoData, err := Marshal(ContentTypeProtobuf, &tx)
+	require.NoError(t, err)
+	var fromProto Transaction
+	require.NoError(t, fromProto.UnmarshalProto(protoData))
+	assert.Equal(t, tx.TransferID, fromProto.TransferID)
+
+	_, err = Marshal(ContentTypeProtobuf, UTXOEvent{})
+	assert.Error(t, err)
+	_, err = Marshal("text/xml", &tx)
+	assert.Error(t, err)
+}
+
+func TestBlockJSON_OmitsEmptyStats(t *testing.T) {
+	data, err := json.Marshal(Block{Number: 1, Hash: "h"})
+	require.NoError(t, err)
+	for _, key := range []string{"size", "weight", "difficulty", "tx_count", "gas_used", "gas_limit"} {
+		assert.NotContains(t, string(data), `"`+key+`"`)
+	}
+
+	data, err = json.Marshal(Block{Number: 1, GasUsed: 21000, TxCount: 1})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"gas_used":21000`)
+	assert.Contains(t, string(data), `"tx_count":1`)
+}
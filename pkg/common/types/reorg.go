@@ -0,0 +1,31 @@
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// BlockReorg tells consumers that a reorg rolled blocks FromBlock..ToBlock
+// back: the transfers emitted for them are void, and the blocks are
+// re-indexed from the new chain, whose transfers are emitted after this
+// event. Every chain with reorg detection emits the same shape.
+type BlockReorg struct {
+	NetworkId    string `json:"networkId"`
+	InternalCode string `json:"internalCode"`
+	FromBlock    uint64 `json:"fromBlock"`
+	ToBlock      uint64 `json:"toBlock"`
+	// OldHash is the hash indexed at ToBlock, NewHash the new chain's.
+	OldHash string `json:"oldHash"`
+	NewHash string `json:"newHash"`
+	// TransferIDs lists the transfers emitted for the rolled-back blocks
+	// since the indexer started. Blocks indexed before a restart are
+	// missing from it, so consumers should also void by height.
+	TransferIDs []string `json:"transferIds"`
+	DetectedAt  int64    `json:"detectedAt"` // Unix seconds
+}
+
+// Hash identifies the reorg for idempotent delivery.
+func (r BlockReorg) Hash() string {
+	key := fmt.Sprintf("%s|%d|%d|%s|%s", r.NetworkId, r.FromBlock, r.ToBlock, r.OldHash, r.NewHash)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// RetiredAddressActivity reports a transfer touching addresses retired from
+// the watch list, for compliance. The transfer is no longer emitted for
+// them, though it still is for any monitored address it touches.
+type RetiredAddressActivity struct {
+	NetworkId    string `json:"networkId"`
+	InternalCode string `json:"internalCode"`
+	BlockNumber  uint64 `json:"blockNumber"`
+	// Addresses are the retired addresses among the transfer's sender and
+	// recipient addresses, in canonical form.
+	Addresses []string    `json:"addresses"`
+	Transfer  Transaction `json:"transfer"`
+}
+
+// Hash identifies the activity for idempotent delivery.
+func (a RetiredAddressActivity) Hash() string {
+	key := "retired|" + a.Transfer.Hash() + "|" + strings.Join(a.Addresses, ",")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
@@ -18,6 +17,15 @@ type Block struct {
 	Timestamp    uint64                 `json:"timestamp"`
 	Transactions []Transaction          `json:"transactions"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	InternalCode string                 `json:"internal_code,omitempty"` // the chain's internal_code
+
+	// Optional block stats, filled where the chain reports them.
+	Size       uint64  `json:"size,omitempty"`       // bytes
+	Weight     uint64  `json:"weight,omitempty"`     // Bitcoin weight units
+	Difficulty float64 `json:"difficulty,omitempty"` // Bitcoin
+	TxCount    int     `json:"tx_count,omitempty"`   // all transactions in the block, not just extracted transfers
+	GasUsed    uint64  `json:"gas_used,omitempty"`   // EVM
+	GasLimit   uint64  `json:"gas_limit,omitempty"`  // EVM
 }
 
 func (b *Block) SetMetadata(key string, value interface{}) {
@@ -41,12 +49,39 @@ const (
 	DirectionOut = "out" // Transfer sent from a monitored address (withdrawal)
 )
 
+// Transfer roles relative to the monitored address set.
+const (
+	RoleDeposit    = "deposit"    // only the recipient is monitored
+	RoleWithdrawal = "withdrawal" // every sender is monitored, the recipient is not
+	RoleInternal   = "internal"   // the recipient and every sender are monitored
+	RoleMixed      = "mixed"      // some but not all senders are monitored
+)
+
+// TransferRole classifies a transfer from which sides matched the monitored
+// set: whether the recipient did and how many of its senders did. It returns
+// "" when nothing matched.
+func TransferRole(toMatched bool, sendersMatched, senders int) string {
+	switch {
+	case sendersMatched > 0 && sendersMatched < senders:
+		return RoleMixed
+	case toMatched && sendersMatched > 0:
+		return RoleInternal
+	case toMatched:
+		return RoleDeposit
+	case sendersMatched > 0:
+		return RoleWithdrawal
+	}
+	return ""
+}
+
 type Transaction struct {
 	TxHash        string          `json:"txHash"`
 	NetworkId     string          `json:"networkId"`
+	InternalCode  string          `json:"internalCode"` // the chain's internal_code, for consumers keyed on it
 	BlockNumber   uint64          `json:"blockNumber"` // 0 for mempool transactions
 	BlockHash     string          `json:"blockHash"`     // block hash for reorg-aware idempotency
-	TransferIndex string          `json:"transferIndex"` // unique position within tx (EVM only)
+	TransferIndex string          `json:"transferIndex"` // unique position within tx
+	TransferID    string          `json:"transferId"`    // stable transfer identity, see ComputeTransferID
 	FromAddress   string          `json:"fromAddress"`
 	FromAddresses []string        `json:"fromAddresses,omitempty"`
 	ToAddress     string          `json:"toAddress"`
@@ -58,7 +93,43 @@ type Transaction struct {
 	Confirmations uint64          `json:"confirmations"` // Number of confirmations (0 = mempool/unconfirmed)
 	Status        string          `json:"status"`        // "pending" (0 conf), "confirmed" (1+ conf)
 	Direction     string          `json:"direction"`     // "in" (deposit) or "out" (withdrawal)
+	Role          string          `json:"role,omitempty"` // see TransferRole
 	Metadata      map[string]any  `json:"metadata,omitempty"`
+	// Unit and FeeUnit are the units of Amount and TxFee. They are set on
+	// emitted transfers when amounts are formatted raw, see amount.Formatter.
+	Unit    *AmountUnit `json:"unit,omitempty"`
+	FeeUnit *AmountUnit `json:"feeUnit,omitempty"`
+
+	// displayAmount marks an Amount in whole units, see SetDisplayAmount.
+	displayAmount bool
+}
+
+// AmountUnit is the unit of an amount: the smallest unit of a coin or
+// token, Decimals digits below a whole one. Name is e.g. "wei" or "sat"
+// for native coins and empty when not known.
+type AmountUnit struct {
+	Name     string `json:"name,omitempty"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// SetDisplayAmount sets Amount to an amount in whole units of unit, for
+// chain data that states amounts that way (BRC-20), rather than in the
+// smallest unit as all other amounts are. See DisplayAmount.
+func (t *Transaction) SetDisplayAmount(amount string, unit AmountUnit) {
+	t.Amount = amount
+	t.Unit = &unit
+	t.displayAmount = true
+}
+
+// SetRawAmount sets Amount to an amount in the smallest unit.
+func (t *Transaction) SetRawAmount(amount string) {
+	t.Amount = amount
+	t.displayAmount = false
+}
+
+// DisplayAmount reports whether Amount is in whole units of Unit.
+func (t Transaction) DisplayAmount() bool {
+	return t.displayAmount
 }
 
 func (t *Transaction) SetMetadata(key string, value any) {
@@ -136,45 +207,49 @@ func (t Transaction) String() string {
 	)
 }
 
-// Hash generates a deterministic hash used as the NATS idempotency key (Event Instance Identity).
+// ComputeTransferID derives the stable identity of a transfer:
 //
-// When TransferIndex is set (EVM): NetworkId|TxHash|BlockHash|TransferIndex|Direction
-// When TransferIndex is empty (non-EVM fallback): NetworkId|TxHash|BlockHash|From|To|Timestamp|Direction
+//	sha256(NetworkId|TxHash|TransferIndex|From|To|AssetAddress)
 //
-// BlockHash ensures reorgs produce new hashes so consumers get updated data.
-// Direction ensures two-way-indexed in/out events don't collide.
-func (t Transaction) Hash() string {
+// It does not depend on block hash, status or direction, so re-extracting
+// the same transaction always yields the same ID.
+func (t Transaction) ComputeTransferID() string {
 	var builder strings.Builder
-	builder.WriteString(t.NetworkId)
-	builder.WriteByte('|')
-	builder.WriteString(t.TxHash)
-	builder.WriteByte('|')
-	builder.WriteString(t.BlockHash)
-	builder.WriteByte('|')
-
-	if t.TransferIndex != "" {
-		// EVM (or any chain that populates TransferIndex):
-		// Exact positional identity — reorg-aware via BlockHash.
-		builder.WriteString(t.TransferIndex)
-		builder.WriteByte('|')
-		builder.WriteString(t.Direction)
-	} else {
-		// Non-EVM fallback: preserve current hash behavior.
-		// Uses addresses + timestamp to distinguish multiple transfers
-		// from the same tx until that chain populates TransferIndex.
-		builder.WriteString(t.FromAddress)
-		builder.WriteByte('|')
-		builder.WriteString(t.ToAddress)
-		builder.WriteByte('|')
-		builder.WriteString(strconv.FormatUint(t.Timestamp, 10))
-		builder.WriteByte('|')
-		builder.WriteString(t.Direction)
+	for i, part := range []string{
+		t.NetworkId, t.TxHash, t.TransferIndex, t.FromAddress, t.ToAddress, t.AssetAddress,
+	} {
+		if i > 0 {
+			builder.WriteByte('|')
+		}
+		builder.WriteString(part)
 	}
-
 	hash := sha256.Sum256([]byte(builder.String()))
 	return fmt.Sprintf("%x", hash)
 }
 
+// EnsureTransferID sets TransferID if it is not populated yet.
+func (t *Transaction) EnsureTransferID() {
+	if t.TransferID == "" {
+		t.TransferID = t.ComputeTransferID()
+	}
+}
+
+// Hash generates a deterministic hash used as the NATS idempotency key (Event Instance Identity):
+//
+//	sha256(TransferID|BlockHash|Direction)
+//
+// BlockHash ensures reorgs (and mempool -> block) produce new hashes so
+// consumers get updated data. Direction ensures two-way-indexed in/out
+// events don't collide.
+func (t Transaction) Hash() string {
+	id := t.TransferID
+	if id == "" {
+		id = t.ComputeTransferID()
+	}
+	hash := sha256.Sum256([]byte(id + "|" + t.BlockHash + "|" + t.Direction))
+	return fmt.Sprintf("%x", hash)
+}
+
 // Transaction status constants
 const (
 	StatusPending   = "pending"   // Less than required confirmations
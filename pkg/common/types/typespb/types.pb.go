@@ -0,0 +1,554 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: types.proto
+
+package typespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Transaction mirrors types.Transaction. Amounts and fees are decimal
+// strings so no precision is lost across languages.
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxHash        string                 `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	NetworkId     string                 `protobuf:"bytes,2,opt,name=network_id,json=networkId,proto3" json:"network_id,omitempty"`
+	BlockNumber   uint64                 `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	BlockHash     string                 `protobuf:"bytes,4,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	TransferIndex string                 `protobuf:"bytes,5,opt,name=transfer_index,json=transferIndex,proto3" json:"transfer_index,omitempty"`
+	TransferId    string                 `protobuf:"bytes,6,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	FromAddress   string                 `protobuf:"bytes,7,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
+	FromAddresses []string               `protobuf:"bytes,8,rep,name=from_addresses,json=fromAddresses,proto3" json:"from_addresses,omitempty"`
+	ToAddress     string                 `protobuf:"bytes,9,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	AssetAddress  string                 `protobuf:"bytes,10,opt,name=asset_address,json=assetAddress,proto3" json:"asset_address,omitempty"`
+	Amount        string                 `protobuf:"bytes,11,opt,name=amount,proto3" json:"amount,omitempty"`
+	Type          string                 `protobuf:"bytes,12,opt,name=type,proto3" json:"type,omitempty"`
+	TxFee         string                 `protobuf:"bytes,13,opt,name=tx_fee,json=txFee,proto3" json:"tx_fee,omitempty"`
+	Timestamp     uint64                 `protobuf:"varint,14,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Confirmations uint64                 `protobuf:"varint,15,opt,name=confirmations,proto3" json:"confirmations,omitempty"`
+	Status        string                 `protobuf:"bytes,16,opt,name=status,proto3" json:"status,omitempty"`
+	Direction     string                 `protobuf:"bytes,17,opt,name=direction,proto3" json:"direction,omitempty"`
+	// Chain-specific extras, encoded as they would be in JSON.
+	Metadata *structpb.Struct `protobuf:"bytes,18,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// deposit, withdrawal, internal or mixed; empty when not classified.
+	Role string `protobuf:"bytes,19,opt,name=role,proto3" json:"role,omitempty"`
+	// The chain's internal_code, for consumers keyed on it rather than on
+	// network_id.
+	InternalCode string `protobuf:"bytes,20,opt,name=internal_code,json=internalCode,proto3" json:"internal_code,omitempty"`
+	// Units of amount and tx_fee; set when amounts are emitted raw, in the
+	// smallest unit.
+	Unit          *AmountUnit `protobuf:"bytes,21,opt,name=unit,proto3" json:"unit,omitempty"`
+	FeeUnit       *AmountUnit `protobuf:"bytes,22,opt,name=fee_unit,json=feeUnit,proto3" json:"fee_unit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_types_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Transaction) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *Transaction) GetNetworkId() string {
+	if x != nil {
+		return x.NetworkId
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *Transaction) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *Transaction) GetTransferIndex() string {
+	if x != nil {
+		return x.TransferIndex
+	}
+	return ""
+}
+
+func (x *Transaction) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *Transaction) GetFromAddress() string {
+	if x != nil {
+		return x.FromAddress
+	}
+	return ""
+}
+
+func (x *Transaction) GetFromAddresses() []string {
+	if x != nil {
+		return x.FromAddresses
+	}
+	return nil
+}
+
+func (x *Transaction) GetToAddress() string {
+	if x != nil {
+		return x.ToAddress
+	}
+	return ""
+}
+
+func (x *Transaction) GetAssetAddress() string {
+	if x != nil {
+		return x.AssetAddress
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Transaction) GetTxFee() string {
+	if x != nil {
+		return x.TxFee
+	}
+	return ""
+}
+
+func (x *Transaction) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Transaction) GetConfirmations() uint64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+func (x *Transaction) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Transaction) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *Transaction) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Transaction) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Transaction) GetInternalCode() string {
+	if x != nil {
+		return x.InternalCode
+	}
+	return ""
+}
+
+func (x *Transaction) GetUnit() *AmountUnit {
+	if x != nil {
+		return x.Unit
+	}
+	return nil
+}
+
+func (x *Transaction) GetFeeUnit() *AmountUnit {
+	if x != nil {
+		return x.FeeUnit
+	}
+	return nil
+}
+
+// Block mirrors types.Block.
+type Block struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Number       uint64                 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Hash         string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	ParentHash   string                 `protobuf:"bytes,3,opt,name=parent_hash,json=parentHash,proto3" json:"parent_hash,omitempty"`
+	Timestamp    uint64                 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transactions []*Transaction         `protobuf:"bytes,5,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	// Chain-specific extras, encoded as they would be in JSON.
+	Metadata *structpb.Struct `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Optional block stats; zero when the chain does not report them.
+	Size       uint64  `protobuf:"varint,7,opt,name=size,proto3" json:"size,omitempty"`
+	Weight     uint64  `protobuf:"varint,8,opt,name=weight,proto3" json:"weight,omitempty"`
+	Difficulty float64 `protobuf:"fixed64,9,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	TxCount    uint32  `protobuf:"varint,10,opt,name=tx_count,json=txCount,proto3" json:"tx_count,omitempty"`
+	GasUsed    uint64  `protobuf:"varint,11,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	GasLimit   uint64  `protobuf:"varint,12,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
+	// The chain's internal_code.
+	InternalCode  string `protobuf:"bytes,13,opt,name=internal_code,json=internalCode,proto3" json:"internal_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_types_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Block) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetParentHash() string {
+	if x != nil {
+		return x.ParentHash
+	}
+	return ""
+}
+
+func (x *Block) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Block) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Block) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Block) GetWeight() uint64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Block) GetDifficulty() float64 {
+	if x != nil {
+		return x.Difficulty
+	}
+	return 0
+}
+
+func (x *Block) GetTxCount() uint32 {
+	if x != nil {
+		return x.TxCount
+	}
+	return 0
+}
+
+func (x *Block) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Block) GetGasLimit() uint64 {
+	if x != nil {
+		return x.GasLimit
+	}
+	return 0
+}
+
+func (x *Block) GetInternalCode() string {
+	if x != nil {
+		return x.InternalCode
+	}
+	return ""
+}
+
+// AmountUnit mirrors types.AmountUnit: the smallest unit of a coin or
+// token, decimals digits below a whole one.
+type AmountUnit struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// e.g. "wei" or "sat"; empty when not known.
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Decimals      uint32 `protobuf:"varint,2,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AmountUnit) Reset() {
+	*x = AmountUnit{}
+	mi := &file_types_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AmountUnit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AmountUnit) ProtoMessage() {}
+
+func (x *AmountUnit) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AmountUnit.ProtoReflect.Descriptor instead.
+func (*AmountUnit) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AmountUnit) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AmountUnit) GetDecimals() uint32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+var File_types_proto protoreflect.FileDescriptor
+
+const file_types_proto_rawDesc = "" +
+	"\n" +
+	"\vtypes.proto\x12\x1bmultichain_indexer.types.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x89\x06\n" +
+	"\vTransaction\x12\x17\n" +
+	"\atx_hash\x18\x01 \x01(\tR\x06txHash\x12\x1d\n" +
+	"\n" +
+	"network_id\x18\x02 \x01(\tR\tnetworkId\x12!\n" +
+	"\fblock_number\x18\x03 \x01(\x04R\vblockNumber\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x04 \x01(\tR\tblockHash\x12%\n" +
+	"\x0etransfer_index\x18\x05 \x01(\tR\rtransferIndex\x12\x1f\n" +
+	"\vtransfer_id\x18\x06 \x01(\tR\n" +
+	"transferId\x12!\n" +
+	"\ffrom_address\x18\a \x01(\tR\vfromAddress\x12%\n" +
+	"\x0efrom_addresses\x18\b \x03(\tR\rfromAddresses\x12\x1d\n" +
+	"\n" +
+	"to_address\x18\t \x01(\tR\ttoAddress\x12#\n" +
+	"\rasset_address\x18\n" +
+	" \x01(\tR\fassetAddress\x12\x16\n" +
+	"\x06amount\x18\v \x01(\tR\x06amount\x12\x12\n" +
+	"\x04type\x18\f \x01(\tR\x04type\x12\x15\n" +
+	"\x06tx_fee\x18\r \x01(\tR\x05txFee\x12\x1c\n" +
+	"\ttimestamp\x18\x0e \x01(\x04R\ttimestamp\x12$\n" +
+	"\rconfirmations\x18\x0f \x01(\x04R\rconfirmations\x12\x16\n" +
+	"\x06status\x18\x10 \x01(\tR\x06status\x12\x1c\n" +
+	"\tdirection\x18\x11 \x01(\tR\tdirection\x123\n" +
+	"\bmetadata\x18\x12 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x12\n" +
+	"\x04role\x18\x13 \x01(\tR\x04role\x12#\n" +
+	"\rinternal_code\x18\x14 \x01(\tR\finternalCode\x12;\n" +
+	"\x04unit\x18\x15 \x01(\v2'.multichain_indexer.types.v1.AmountUnitR\x04unit\x12B\n" +
+	"\bfee_unit\x18\x16 \x01(\v2'.multichain_indexer.types.v1.AmountUnitR\afeeUnit\"\xb9\x03\n" +
+	"\x05Block\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x04R\x06number\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x1f\n" +
+	"\vparent_hash\x18\x03 \x01(\tR\n" +
+	"parentHash\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x04R\ttimestamp\x12L\n" +
+	"\ftransactions\x18\x05 \x03(\v2(.multichain_indexer.types.v1.TransactionR\ftransactions\x123\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x12\n" +
+	"\x04size\x18\a \x01(\x04R\x04size\x12\x16\n" +
+	"\x06weight\x18\b \x01(\x04R\x06weight\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\t \x01(\x01R\n" +
+	"difficulty\x12\x19\n" +
+	"\btx_count\x18\n" +
+	" \x01(\rR\atxCount\x12\x19\n" +
+	"\bgas_used\x18\v \x01(\x04R\agasUsed\x12\x1b\n" +
+	"\tgas_limit\x18\f \x01(\x04R\bgasLimit\x12#\n" +
+	"\rinternal_code\x18\r \x01(\tR\finternalCode\"<\n" +
+	"\n" +
+	"AmountUnit\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bdecimals\x18\x02 \x01(\rR\bdecimalsB@Z>github.com/fystack/multichain-indexer/pkg/common/types/typespbb\x06proto3"
+
+var (
+	file_types_proto_rawDescOnce sync.Once
+	file_types_proto_rawDescData []byte
+)
+
+func file_types_proto_rawDescGZIP() []byte {
+	file_types_proto_rawDescOnce.Do(func() {
+		file_types_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_types_proto_rawDesc), len(file_types_proto_rawDesc)))
+	})
+	return file_types_proto_rawDescData
+}
+
+var file_types_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_types_proto_goTypes = []any{
+	(*Transaction)(nil),     // 0: multichain_indexer.types.v1.Transaction
+	(*Block)(nil),           // 1: multichain_indexer.types.v1.Block
+	(*AmountUnit)(nil),      // 2: multichain_indexer.types.v1.AmountUnit
+	(*structpb.Struct)(nil), // 3: google.protobuf.Struct
+}
+var file_types_proto_depIdxs = []int32{
+	3, // 0: multichain_indexer.types.v1.Transaction.metadata:type_name -> google.protobuf.Struct
+	2, // 1: multichain_indexer.types.v1.Transaction.unit:type_name -> multichain_indexer.types.v1.AmountUnit
+	2, // 2: multichain_indexer.types.v1.Transaction.fee_unit:type_name -> multichain_indexer.types.v1.AmountUnit
+	0, // 3: multichain_indexer.types.v1.Block.transactions:type_name -> multichain_indexer.types.v1.Transaction
+	3, // 4: multichain_indexer.types.v1.Block.metadata:type_name -> google.protobuf.Struct
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_types_proto_init() }
+func file_types_proto_init() {
+	if File_types_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_types_proto_rawDesc), len(file_types_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_types_proto_goTypes,
+		DependencyIndexes: file_types_proto_depIdxs,
+		MessageInfos:      file_types_proto_msgTypes,
+	}.Build()
+	File_types_proto = out.File
+	file_types_proto_goTypes = nil
+	file_types_proto_depIdxs = nil
+}
@@ -34,6 +34,9 @@ type SpentUTXO struct {
 	Vin     uint32 `json:"vin"`
 	Address string `json:"address"`
 	Amount  string `json:"amount"`
+	// SpendType is how a P2SH output was spent: "p2sh-p2wpkh", "p2sh-p2wsh"
+	// or "p2sh". Empty for other outputs.
+	SpendType string `json:"spendType,omitempty"`
 }
 
 func (u UTXOEvent) MarshalBinary() ([]byte, error) {
@@ -2,7 +2,9 @@ package events
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/types"
 	"github.com/fystack/multichain-indexer/pkg/infra"
 )
@@ -22,6 +24,8 @@ type Emitter interface {
 	EmitBlock(chain string, block *types.Block) error
 	EmitTransaction(chain string, tx *types.Transaction) error
 	EmitUTXO(chain string, utxo *types.UTXOEvent) error
+	EmitReorg(chain string, reorg *types.BlockReorg) error
+	EmitRetiredActivity(chain string, activity *types.RetiredAddressActivity) error
 	EmitError(chain string, err error) error
 	Emit(event IndexerEvent) error
 	Close()
@@ -31,28 +35,84 @@ type emitter struct {
 	queue         infra.MessageQueue
 	utxoQueue     infra.MessageQueue
 	subjectPrefix string
+	contentTypes  map[string]string // queue topic -> content type
+	txTypes       *constant.TxTypeRegistry
 }
 
-func NewEmitter(queue infra.MessageQueue, utxoQueue infra.MessageQueue, subjectPrefix string) Emitter {
-	return &emitter{
+// Option configures an Emitter.
+type Option func(*emitter)
+
+// encodingTopics maps config topic names to the queue topics they control.
+var encodingTopics = map[string]string{
+	"transfer": infra.TransferEventTopicQueue,
+}
+
+var encodingContentTypes = map[string]string{
+	"json":     types.ContentTypeJSON,
+	"protobuf": types.ContentTypeProtobuf,
+}
+
+// WithEncodings sets the wire format per topic, e.g. {"transfer": "protobuf"}.
+// Unknown topics or encodings are ignored; config validation rejects them.
+func WithEncodings(encodings map[string]string) Option {
+	return func(e *emitter) {
+		for topic, encoding := range encodings {
+			queueTopic, ok := encodingTopics[topic]
+			contentType, known := encodingContentTypes[encoding]
+			if ok && known {
+				e.contentTypes[queueTopic] = contentType
+			}
+		}
+	}
+}
+
+// WithTxTypes publishes transactions under the type names of registry.
+func WithTxTypes(registry *constant.TxTypeRegistry) Option {
+	return func(e *emitter) {
+		e.txTypes = registry
+	}
+}
+
+func NewEmitter(queue infra.MessageQueue, utxoQueue infra.MessageQueue, subjectPrefix string, opts ...Option) Emitter {
+	e := &emitter{
 		queue:         queue,
 		utxoQueue:     utxoQueue,
 		subjectPrefix: subjectPrefix,
+		contentTypes:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
+// EmitBlock publishes block on the transfer stream, in the transfer
+// topic's encoding.
 func (e *emitter) EmitBlock(chain string, block *types.Block) error {
-	// TODO: implement
-	return nil
+	contentType := e.contentTypes[infra.TransferEventTopicQueue]
+	data, err := types.Marshal(contentType, block)
+	if err != nil {
+		return err
+	}
+	return e.queue.Enqueue(infra.BlockEventTopicQueue, data, &infra.EnqueueOptions{
+		IdempotententKey: fmt.Sprintf("block:%s:%d:%s", chain, block.Number, block.Hash),
+		ContentType:      contentType,
+	})
 }
 
 func (e *emitter) EmitTransaction(chain string, tx *types.Transaction) error {
-	txBytes, err := tx.MarshalBinary()
+	tx.EnsureTransferID()
+	// Rename on a copy: callers keep tx, e.g. to orphan it on a reorg.
+	out := *tx
+	out.Type = e.txTypes.Name(tx.Type)
+	contentType := e.contentTypes[infra.TransferEventTopicQueue]
+	txBytes, err := types.Marshal(contentType, &out)
 	if err != nil {
 		return err
 	}
 	return e.queue.Enqueue(infra.TransferEventTopicQueue, txBytes, &infra.EnqueueOptions{
 		IdempotententKey: tx.Hash(),
+		ContentType:      contentType,
 	})
 }
 
@@ -66,6 +126,31 @@ func (e *emitter) EmitUTXO(chain string, utxo *types.UTXOEvent) error {
 	})
 }
 
+// EmitReorg publishes reorg as JSON on the transfer stream, ahead of the
+// replacement blocks' transfers.
+func (e *emitter) EmitReorg(chain string, reorg *types.BlockReorg) error {
+	data, err := json.Marshal(reorg)
+	if err != nil {
+		return err
+	}
+	return e.queue.Enqueue(infra.ReorgEventTopicQueue, data, &infra.EnqueueOptions{
+		IdempotententKey: reorg.Hash(),
+		ContentType:      types.ContentTypeJSON,
+	})
+}
+
+// EmitRetiredActivity publishes activity as JSON on the transfer stream.
+func (e *emitter) EmitRetiredActivity(chain string, activity *types.RetiredAddressActivity) error {
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	return e.queue.Enqueue(infra.RetiredActivityTopicQueue, data, &infra.EnqueueOptions{
+		IdempotententKey: activity.Hash(),
+		ContentType:      types.ContentTypeJSON,
+	})
+}
+
 func (e *emitter) EmitError(chain string, err error) error {
 	// TODO: implement
 	return nil
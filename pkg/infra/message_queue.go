@@ -14,6 +14,15 @@ import (
 const (
 	TransferEventTopicQueue = "transfer.event.dispatch"
 	UTXOEventTopicQueue     = "utxo.event.dispatch"
+	// ReorgEventTopicQueue shares the transfer stream, so a reorg is
+	// delivered in order with the transfers it voids and replaces.
+	ReorgEventTopicQueue = "transfer.event.reorg"
+	// BlockEventTopicQueue shares the transfer stream too, so a block
+	// record follows any transfers of the blocks before it.
+	BlockEventTopicQueue = "transfer.event.block"
+	// RetiredActivityTopicQueue carries the transfers of retired
+	// addresses, on chains with emit_retired_activity set.
+	RetiredActivityTopicQueue = "transfer.event.retired"
 )
 
 var (
@@ -31,6 +40,7 @@ type MessageQueue interface {
 
 type EnqueueOptions struct {
 	IdempotententKey string
+	ContentType      string // sent as the Content-Type header when set
 }
 
 type msgQueue struct {
@@ -142,6 +152,9 @@ func (mq *msgQueue) Enqueue(topic string, message []byte, options *EnqueueOption
 	header := nats.Header{}
 	if options != nil {
 		header.Add("Nats-Msg-Id", options.IdempotententKey)
+		if options.ContentType != "" {
+			header.Add("Content-Type", options.ContentType)
+		}
 	}
 
 	_, err := mq.js.PublishMsg(context.Background(), &nats.Msg{
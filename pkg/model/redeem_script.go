@@ -0,0 +1,15 @@
+package model
+
+import (
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// RedeemScript is the redeem (or witness) script a custody system
+// registered for one of its P2SH or P2WSH multisig addresses, against which
+// deposits to the address are verified and spends from it decoded.
+type RedeemScript struct {
+	BaseModel
+	Address string           `gorm:"not null;type:varchar(255);uniqueIndex:idx_unique_redeem_address" json:"address"`
+	Type    enum.NetworkType `gorm:"type:varchar(64);not null;uniqueIndex:idx_unique_redeem_address"  json:"type"`
+	Script  string           `gorm:"not null;type:text"                                                json:"script"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// SupplyBlock is the supply tracked for one block: the subsidy its coinbase
+// may claim and the fees it pays, in satoshis. Re-recording a height, e.g.
+// after a reorg, updates its row.
+type SupplyBlock struct {
+	NetworkID string    `gorm:"primaryKey;type:varchar(64)" json:"network_id"`
+	Height    uint64    `gorm:"primaryKey"                  json:"height"`
+	BlockHash string    `gorm:"not null;type:varchar(128)"  json:"block_hash"`
+	Subsidy   int64     `gorm:"not null"                    json:"subsidy"`
+	Fees      int64     `gorm:"not null"                    json:"fees"`
+	CreatedAt time.Time `                                   json:"created_at"`
+	UpdatedAt time.Time `                                   json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transfer is an emitted transfer persisted by the DB sink, one row per
+// TransferID (see types.Transaction.ComputeTransferID). Re-indexing a
+// transfer, e.g. after a reorg, updates its row; a reorg marks the rows of
+// the blocks it rolled back types.StatusOrphaned first. Type is the
+// published name, see constant.TxTypeRegistry.
+type Transfer struct {
+	TransferID    string          `gorm:"primaryKey;type:varchar(64)"                                               json:"transfer_id"`
+	NetworkID     string          `gorm:"not null;type:varchar(64);index:idx_transfers_network_to_block,priority:1" json:"network_id"`
+	InternalCode  string          `gorm:"type:varchar(64)"                                                          json:"internal_code"`
+	TxHash        string          `gorm:"not null;type:varchar(128);index:idx_transfers_tx_hash"                    json:"tx_hash"`
+	TransferIndex string          `gorm:"type:varchar(64)"                                                          json:"transfer_index"`
+	BlockNumber   uint64          `gorm:"not null;index:idx_transfers_network_to_block,priority:3"                  json:"block_number"`
+	BlockHash     string          `gorm:"type:varchar(128)"                                                         json:"block_hash"`
+	FromAddress   string          `gorm:"type:varchar(255)"                                                         json:"from_address"`
+	FromAddresses []string        `gorm:"type:jsonb;serializer:json"                                                json:"from_addresses,omitempty"`
+	ToAddress     string          `gorm:"type:varchar(255);index:idx_transfers_network_to_block,priority:2"         json:"to_address"`
+	AssetAddress  string          `gorm:"type:varchar(255)"                                                         json:"asset_address"`
+	Amount        string          `gorm:"type:numeric"                                                              json:"amount"`
+	TxFee         decimal.Decimal `gorm:"type:numeric"                                                              json:"tx_fee"`
+	Type          string          `gorm:"type:varchar(64)"                                                          json:"type"`
+	Role          string          `gorm:"type:varchar(32)"                                                          json:"role,omitempty"`
+	Status        string          `gorm:"type:varchar(32)"                                                          json:"status"`
+	Confirmations uint64          `                                                                                 json:"confirmations"`
+	Timestamp     uint64          `                                                                                 json:"timestamp"`
+	Metadata      map[string]any  `gorm:"type:jsonb;serializer:json"                                                json:"metadata,omitempty"`
+	CreatedAt     time.Time       `                                                                                 json:"created_at"`
+	UpdatedAt     time.Time       `                                                                                 json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+)
+
+// WatchedScript is a scriptPubKey watched like an address, for outputs with
+// no address form such as bare multisig. ScriptID is its synthetic
+// identifier, "script:" and the hex SHA-256 of the script, which transfers
+// to it carry as ToAddress.
+type WatchedScript struct {
+	BaseModel
+	ScriptID string           `gorm:"not null;type:varchar(80);uniqueIndex:idx_unique_script_id" json:"script_id"`
+	Script   string           `gorm:"not null;type:text"                                         json:"script"`
+	Type     enum.NetworkType `gorm:"type:varchar(64);not null"                                  json:"type"`
+	Label    string           `gorm:"type:varchar(255)"                                          json:"label"`
+}
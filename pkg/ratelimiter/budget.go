@@ -0,0 +1,348 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by Wait for an optional request once its
+// pool's Budget is used past BudgetLimits.StopOptionalAt.
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+var errNoBudgetStore = errors.New("no budget store")
+
+// Budget defaults, see BudgetLimits.
+const (
+	DefaultSlowDownAt     = 0.7
+	DefaultStopOptionalAt = 0.9
+	DefaultBudgetMaxDelay = 5 * time.Second
+)
+
+// budgetSyncInterval is how often a Budget adds the requests it counted to
+// its store and reads back the group's totals.
+const budgetSyncInterval = time.Second
+
+// BudgetLimits caps the requests of a group over a UTC day and month; a
+// zero limit leaves that period unbudgeted. SlowDownAt and StopOptionalAt
+// are shares of the most used budget, MaxDelay the longest an optional
+// request is held back before StopOptionalAt; zero values take the
+// defaults above.
+type BudgetLimits struct {
+	Daily          int64
+	Monthly        int64
+	SlowDownAt     float64
+	StopOptionalAt float64
+	MaxDelay       time.Duration
+}
+
+// BudgetStore counts a group's requests across processes and restarts,
+// e.g. in Redis.
+type BudgetStore interface {
+	// Add adds n to the counter under key, kept for at least ttl, and
+	// returns its new value.
+	Add(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+}
+
+// Budget counts the requests of every pool sharing a provider API key, see
+// PooledRateLimiter.WithBudget, against the key's daily and monthly quota.
+// Every request sent counts. Optional requests, see WithOptional, are held
+// back more and more once SlowDownAt of a budget is used, and refused with
+// ErrBudgetExhausted past StopOptionalAt, so what is left goes to requests
+// that cannot wait, like following the chain tip. Counts are kept in
+// memory and added to the store every budgetSyncInterval; without a store,
+// or while it fails, they are this process's own.
+type Budget struct {
+	group  string
+	limits BudgetLimits
+	store  BudgetStore
+	now    func() time.Time
+
+	mu       sync.Mutex
+	day      budgetPeriod
+	month    budgetPeriod
+	syncing  bool
+	lastSync time.Time
+
+	requests, delayed, refused, storeErrors atomic.Uint64
+}
+
+// budgetPeriod is a Budget's count for one day or month.
+type budgetPeriod struct {
+	key     string // store key, naming the period
+	synced  int64  // the group's count as of the last sync
+	pending int64  // counted since, not yet in the store
+}
+
+func (p *budgetPeriod) used() int64 { return p.synced + p.pending }
+
+// roll starts counting period key when it is a new one.
+func (p *budgetPeriod) roll(key string) {
+	if p.key != key {
+		*p = budgetPeriod{key: key}
+	}
+}
+
+// BudgetStats describes a group's budget use. Used is the share of the
+// most used budget, the one its thresholds apply to.
+type BudgetStats struct {
+	DailyUsed    int64   `json:"daily_used"`
+	DailyLimit   int64   `json:"daily_limit,omitempty"`
+	MonthlyUsed  int64   `json:"monthly_used"`
+	MonthlyLimit int64   `json:"monthly_limit,omitempty"`
+	Used         float64 `json:"used"`
+	Requests     uint64  `json:"requests"`
+	Delayed      uint64  `json:"delayed"`
+	Refused      uint64  `json:"refused"`
+	StoreErrors  uint64  `json:"store_errors,omitempty"`
+}
+
+// NewBudget returns the budget of group, counted in store if not nil.
+func NewBudget(group string, limits BudgetLimits, store BudgetStore) *Budget {
+	return &Budget{group: group, limits: limits.withDefaults(), store: store, now: time.Now}
+}
+
+// withDefaults returns l with its zero shares and delay set to the
+// defaults.
+func (l BudgetLimits) withDefaults() BudgetLimits {
+	if l.SlowDownAt <= 0 {
+		l.SlowDownAt = DefaultSlowDownAt
+	}
+	if l.StopOptionalAt <= 0 {
+		l.StopOptionalAt = DefaultStopOptionalAt
+	}
+	if l.MaxDelay <= 0 {
+		l.MaxDelay = DefaultBudgetMaxDelay
+	}
+	return l
+}
+
+// admit holds back or refuses an optional request per the budget's use;
+// other requests pass at once. A nil Budget admits everything.
+func (b *Budget) admit(ctx context.Context) error {
+	if b == nil || !IsOptional(ctx) {
+		return nil
+	}
+	delay, ok := b.delayFor(b.Used())
+	if !ok {
+		b.refused.Add(1)
+		return ErrBudgetExhausted
+	}
+	if delay <= 0 {
+		return nil
+	}
+	b.delayed.Add(1)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delayFor returns how long an optional request waits at the share used
+// of the budget, growing linearly from nothing at SlowDownAt to MaxDelay
+// at StopOptionalAt, past which it is refused.
+func (b *Budget) delayFor(used float64) (time.Duration, bool) {
+	b.mu.Lock()
+	limits := b.limits
+	b.mu.Unlock()
+	slow, stop := limits.SlowDownAt, limits.StopOptionalAt
+	switch {
+	case used >= stop:
+		return 0, false
+	case used < slow:
+		return 0, true
+	}
+	return time.Duration(float64(limits.MaxDelay) * (used - slow) / (stop - slow)), true
+}
+
+// count records a request sent. A nil Budget counts nothing.
+func (b *Budget) count() {
+	if b == nil {
+		return
+	}
+	b.requests.Add(1)
+	b.mu.Lock()
+	b.rollLocked()
+	b.day.pending++
+	b.month.pending++
+	due := !b.syncing && b.now().Sub(b.lastSync) >= budgetSyncInterval
+	if due {
+		b.syncing = true
+	}
+	b.mu.Unlock()
+	if due {
+		go b.sync(context.Background())
+	}
+}
+
+// Used returns the share used of the most used of the group's budgets.
+func (b *Budget) Used() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollLocked()
+	return b.usedLocked()
+}
+
+func (b *Budget) usedLocked() float64 {
+	var used float64
+	if b.limits.Daily > 0 {
+		used = max(used, float64(b.day.used())/float64(b.limits.Daily))
+	}
+	if b.limits.Monthly > 0 {
+		used = max(used, float64(b.month.used())/float64(b.limits.Monthly))
+	}
+	return used
+}
+
+// rollLocked moves the day and month counts on to the current periods.
+// Callers hold mu.
+func (b *Budget) rollLocked() {
+	now := b.now().UTC()
+	b.day.roll(b.group + ":day:" + now.Format(time.DateOnly))
+	b.month.roll(b.group + ":month:" + now.Format("2006-01"))
+}
+
+// Flush adds the requests counted to the store and reads back the group's
+// totals, e.g. at startup to resume from them and on shutdown.
+func (b *Budget) Flush(ctx context.Context) {
+	b.mu.Lock()
+	b.syncing = true
+	b.mu.Unlock()
+	b.sync(ctx)
+}
+
+// sync adds the pending counts to the store. Callers set syncing.
+func (b *Budget) sync(ctx context.Context) {
+	b.mu.Lock()
+	b.rollLocked()
+	periods := []struct {
+		p     *budgetPeriod
+		key   string
+		delta int64
+		ttl   time.Duration
+	}{
+		{&b.day, b.day.key, b.day.pending, 48 * time.Hour},
+		{&b.month, b.month.key, b.month.pending, 32 * 24 * time.Hour},
+	}
+	for _, s := range periods {
+		s.p.pending = 0
+	}
+	b.mu.Unlock()
+
+	totals := make([]int64, len(periods))
+	errs := make([]error, len(periods))
+	for i, s := range periods {
+		if b.store == nil {
+			errs[i] = errNoBudgetStore
+			continue
+		}
+		totals[i], errs[i] = b.store.Add(ctx, s.key, s.delta, s.ttl)
+		if errs[i] != nil {
+			b.storeErrors.Add(1)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range periods {
+		if s.p.key != s.key {
+			continue // the period rolled over meanwhile
+		}
+		if errs[i] != nil {
+			s.p.synced += s.delta
+			continue
+		}
+		s.p.synced = totals[i]
+	}
+	b.syncing = false
+	b.lastSync = b.now()
+}
+
+// Stats returns the budget's use.
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollLocked()
+	return BudgetStats{
+		DailyUsed:    b.day.used(),
+		DailyLimit:   b.limits.Daily,
+		MonthlyUsed:  b.month.used(),
+		MonthlyLimit: b.limits.Monthly,
+		Used:         b.usedLocked(),
+		Requests:     b.requests.Load(),
+		Delayed:      b.delayed.Load(),
+		Refused:      b.refused.Load(),
+		StoreErrors:  b.storeErrors.Load(),
+	}
+}
+
+type optionalKey struct{}
+
+// WithOptional returns a copy of ctx whose requests are optional work, like
+// backfill, which a Budget running low holds back and then refuses.
+func WithOptional(ctx context.Context) context.Context {
+	return context.WithValue(ctx, optionalKey{}, true)
+}
+
+// IsOptional reports whether ctx's requests are optional work, see
+// WithOptional.
+func IsOptional(ctx context.Context) bool {
+	optional, _ := ctx.Value(optionalKey{}).(bool)
+	return optional
+}
+
+var budgets = struct {
+	sync.RWMutex
+	m map[string]*Budget
+}{m: make(map[string]*Budget)}
+
+// RegisterBudget sets the budget of group, for pools to share with
+// GetBudget, and returns it. A group registered again, e.g. on a config
+// reload, keeps its budget and counts, which the pools already sharing it
+// hold, with the new limits.
+func RegisterBudget(group string, limits BudgetLimits, store BudgetStore) *Budget {
+	budgets.Lock()
+	defer budgets.Unlock()
+	if b, ok := budgets.m[group]; ok {
+		b.mu.Lock()
+		b.limits = limits.withDefaults()
+		b.mu.Unlock()
+		return b
+	}
+	b := NewBudget(group, limits, store)
+	budgets.m[group] = b
+	return b
+}
+
+// GetBudget returns the budget registered for group, nil if none is.
+func GetBudget(group string) *Budget {
+	budgets.RLock()
+	defer budgets.RUnlock()
+	return budgets.m[group]
+}
+
+// GetBudgetStats returns the use of every registered budget, by group.
+func GetBudgetStats() map[string]BudgetStats {
+	budgets.RLock()
+	defer budgets.RUnlock()
+	stats := make(map[string]BudgetStats, len(budgets.m))
+	for group, b := range budgets.m {
+		stats[group] = b.Stats()
+	}
+	return stats
+}
+
+// FlushBudgets flushes every registered budget, see Budget.Flush.
+func FlushBudgets(ctx context.Context) {
+	budgets.RLock()
+	defer budgets.RUnlock()
+	for _, b := range budgets.m {
+		b.Flush(ctx)
+	}
+}
@@ -0,0 +1,160 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memBudgetStore is a BudgetStore shared by the budgets of a test, as
+// Redis is by replicas.
+type memBudgetStore struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (s *memBudgetStore) Add(_ context.Context, key string, n int64, _ time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[string]int64)
+	}
+	s.counts[key] += n
+	return s.counts[key], nil
+}
+
+// newTestBudget returns a budget whose clock stands still at *now and
+// which only syncs when flushed.
+func newTestBudget(limits BudgetLimits, store BudgetStore, now *time.Time) *Budget {
+	b := NewBudget("shared-key", limits, store)
+	b.now = func() time.Time { return *now }
+	b.lastSync = now.Add(time.Hour)
+	return b
+}
+
+func countN(b *Budget, n int) {
+	for range n {
+		b.count()
+	}
+}
+
+func TestBudget_Thresholds(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	b := newTestBudget(BudgetLimits{Daily: 100}, nil, &now)
+	optional := WithOptional(context.Background())
+
+	countN(b, 60)
+	if err := b.admit(optional); err != nil {
+		t.Fatalf("optional request below slow_down_at: %v", err)
+	}
+	if delay, ok := b.delayFor(0.8); !ok || delay != DefaultBudgetMaxDelay/2 {
+		t.Errorf("delay halfway between thresholds = %v, %v", delay, ok)
+	}
+
+	countN(b, 30)
+	if err := b.admit(optional); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("optional request past stop_optional_at: got %v", err)
+	}
+	if err := b.admit(context.Background()); err != nil {
+		t.Fatalf("tip request past stop_optional_at: %v", err)
+	}
+
+	stats := b.Stats()
+	if stats.DailyUsed != 90 || stats.Used != 0.9 || stats.Refused != 1 || stats.Requests != 90 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestBudget_SharedThroughStore(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	store := &memBudgetStore{}
+	limits := BudgetLimits{Daily: 1000, Monthly: 10000}
+	replicaA := newTestBudget(limits, store, &now)
+	replicaB := newTestBudget(limits, store, &now)
+
+	countN(replicaA, 30)
+	countN(replicaB, 20)
+	replicaA.Flush(context.Background())
+	replicaB.Flush(context.Background())
+	if used := replicaB.Stats().DailyUsed; used != 50 {
+		t.Errorf("replica B sees %d daily requests, want 50", used)
+	}
+
+	// A restarted process resumes from the store.
+	restarted := newTestBudget(limits, store, &now)
+	restarted.Flush(context.Background())
+	if stats := restarted.Stats(); stats.DailyUsed != 50 || stats.MonthlyUsed != 50 {
+		t.Errorf("restarted replica sees %+v", stats)
+	}
+
+	// The daily budget starts over at UTC midnight, the monthly one not.
+	now = now.Add(12 * time.Hour)
+	countN(restarted, 5)
+	restarted.Flush(context.Background())
+	if stats := restarted.Stats(); stats.DailyUsed != 5 || stats.MonthlyUsed != 55 {
+		t.Errorf("next day: %+v", stats)
+	}
+}
+
+func TestPooledRateLimiter_Budget(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	b := newTestBudget(BudgetLimits{Daily: 10, MaxDelay: time.Millisecond}, nil, &now)
+	pool := NewPooledRateLimiter(time.Millisecond, 20).WithBudget(b)
+	defer pool.Close()
+	optional := WithOptional(context.Background())
+
+	for i := range 9 {
+		if err := pool.Wait(optional, "node"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if err := pool.Wait(optional, "node"); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("optional request past the budget: got %v", err)
+	}
+	if err := pool.Wait(context.Background(), "node"); err != nil {
+		t.Fatalf("tip request past the budget: %v", err)
+	}
+	if used := b.Stats().DailyUsed; used != 10 {
+		t.Errorf("counted %d requests, want 10: refused ones are not sent", used)
+	}
+}
+
+func TestPooledRateLimiter_WithBudgetLeavesPoolUnchanged(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	b := newTestBudget(BudgetLimits{Daily: 1}, nil, &now)
+	pool := NewPooledRateLimiter(time.Millisecond, 20)
+	defer pool.Close()
+	budgeted := pool.WithBudget(b)
+	optional := WithOptional(context.Background())
+
+	if err := budgeted.Wait(optional, "node"); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := budgeted.Wait(optional, "node"); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("optional request past the budget: got %v", err)
+	}
+	if err := pool.Wait(optional, "node"); err != nil {
+		t.Errorf("the pool WithBudget derived from is unbudgeted: %v", err)
+	}
+}
+
+func TestRegisterBudget_KeepsBudgetWithNewLimits(t *testing.T) {
+	first := RegisterBudget("test-reload", BudgetLimits{Daily: 10}, nil)
+	first.count()
+	again := RegisterBudget("test-reload", BudgetLimits{Daily: 20, SlowDownAt: 0.5}, nil)
+
+	if again != first {
+		t.Fatal("registering a group again replaced the budget pools hold")
+	}
+	if stats := again.Stats(); stats.DailyLimit != 20 || stats.DailyUsed != 1 {
+		t.Errorf("got %+v, want the new limit and the count kept", stats)
+	}
+	if _, ok := again.delayFor(0.6); !ok {
+		t.Error("the default stop_optional_at applies to the new limits")
+	}
+	if delay, _ := again.delayFor(0.6); delay <= 0 {
+		t.Error("the new slow_down_at applies")
+	}
+}
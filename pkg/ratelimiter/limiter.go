@@ -2,10 +2,15 @@ package ratelimiter
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 )
 
+// ErrWaitBudgetExceeded is returned by WaitAtMost when no token frees up
+// within the allowed wait.
+var ErrWaitBudgetExceeded = errors.New("rate limiter wait exceeds budget")
+
 // RateLimiter implements a token bucket rate limiter
 type RateLimiter struct {
 	tokens chan struct{}
@@ -15,6 +20,7 @@ type RateLimiter struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
+	waits  waitStats
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -43,9 +49,34 @@ func NewRateLimiter(rate time.Duration, burst int) *RateLimiter {
 
 // Wait blocks until a token is available
 func (rl *RateLimiter) Wait(ctx context.Context) error {
+	return rl.WaitAtMost(ctx, 0)
+}
+
+// WaitAtMost is Wait giving up with ErrWaitBudgetExceeded after maxWait; 0
+// waits as long as ctx allows.
+func (rl *RateLimiter) WaitAtMost(ctx context.Context, maxWait time.Duration) error {
 	select {
 	case <-rl.tokens:
+		rl.waits.record(0)
 		return nil
+	default:
+	}
+
+	var budget <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		budget = timer.C
+	}
+
+	start := time.Now()
+	select {
+	case <-rl.tokens:
+		rl.waits.record(time.Since(start))
+		return nil
+	case <-budget:
+		rl.waits.reject()
+		return ErrWaitBudgetExceeded
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-rl.ctx.Done():
@@ -97,3 +128,16 @@ func (rl *RateLimiter) Close() {
 func (rl *RateLimiter) GetStats() (available, capacity int, rate time.Duration) {
 	return len(rl.tokens), rl.burst, rl.rate
 }
+
+// WaitStats describes how long callers waited for tokens.
+type WaitStats struct {
+	Waits    uint64        // tokens taken
+	Rejected uint64        // waits given up, see ErrWaitBudgetExceeded
+	P95      time.Duration // over the last waitSamples waits
+}
+
+// GetWaitStats returns the limiter's wait statistics.
+func (rl *RateLimiter) GetWaitStats() WaitStats {
+	waits, rejected := rl.waits.counts()
+	return WaitStats{Waits: waits, Rejected: rejected, P95: rl.waits.percentile(95)}
+}
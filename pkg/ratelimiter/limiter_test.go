@@ -2,6 +2,7 @@ package ratelimiter
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -85,3 +86,108 @@ func TestPooledRateLimiter(t *testing.T) {
 		t.Error("Node2 should be at limit")
 	}
 }
+
+func TestRateLimiter_WaitAtMost(t *testing.T) {
+	rl := NewRateLimiter(time.Hour, 1)
+	defer rl.Close()
+
+	ctx := context.Background()
+	if err := rl.WaitAtMost(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("Failed to get available token: %v", err)
+	}
+	if err := rl.WaitAtMost(ctx, 10*time.Millisecond); !errors.Is(err, ErrWaitBudgetExceeded) {
+		t.Fatalf("Expected ErrWaitBudgetExceeded, got %v", err)
+	}
+
+	stats := rl.GetWaitStats()
+	if stats.Waits != 1 || stats.Rejected != 1 {
+		t.Errorf("Expected 1 wait and 1 rejection, got %+v", stats)
+	}
+}
+
+func TestPooledRateLimiter_WaitBudgetFromDeadline(t *testing.T) {
+	prl := NewPooledRateLimiter(time.Hour, 1).WithMaxWaitFraction(0.2)
+	defer prl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := prl.Wait(ctx, "node"); err != nil {
+		t.Fatalf("Failed to get available token: %v", err)
+	}
+
+	start := time.Now()
+	err := prl.Wait(ctx, "node")
+	elapsed := time.Since(start)
+	if !errors.Is(err, ErrWaitBudgetExceeded) {
+		t.Fatalf("Expected ErrWaitBudgetExceeded, got %v", err)
+	}
+	if elapsed < 50*time.Millisecond || elapsed > 250*time.Millisecond {
+		t.Errorf("Expected to give up after about 100ms, gave up after %v", elapsed)
+	}
+
+	// Without a deadline there is no budget but ctx itself.
+	noDeadline, cancelWait := context.WithCancel(context.Background())
+	cancelWait()
+	if err := prl.Wait(noDeadline, "node"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ctx error, got %v", err)
+	}
+}
+
+func TestPooledRateLimiter_WithMaxWaitFractionLeavesPoolUnchanged(t *testing.T) {
+	pool := NewPooledRateLimiter(time.Hour, 1)
+	defer pool.Close()
+	patient := pool.WithMaxWaitFraction(1)
+	hasty := pool.WithMaxWaitFraction(0.2)
+
+	if pool.maxWaitFraction != 0 || patient.maxWaitFraction != 1 || hasty.maxWaitFraction != 0.2 {
+		t.Fatalf("Expected fractions 0, 1 and 0.2, got %v, %v and %v",
+			pool.maxWaitFraction, patient.maxWaitFraction, hasty.maxWaitFraction)
+	}
+
+	// The derived pools share the per-node limiters.
+	if !patient.TryAcquire("node") {
+		t.Fatal("Failed to get available token")
+	}
+	if hasty.TryAcquire("node") {
+		t.Error("Expected the shared token to be taken")
+	}
+}
+
+func TestWaitStats_Percentile(t *testing.T) {
+	var s waitStats
+	for i := 1; i <= 100; i++ {
+		s.record(time.Duration(i) * time.Millisecond)
+	}
+	if p95 := s.percentile(95); p95 != 95*time.Millisecond {
+		t.Errorf("Expected p95 of 95ms, got %v", p95)
+	}
+
+	// Only the last waitSamples waits count.
+	for range waitSamples {
+		s.record(time.Millisecond)
+	}
+	if p95 := s.percentile(95); p95 != time.Millisecond {
+		t.Errorf("Expected p95 of 1ms after older waits rolled off, got %v", p95)
+	}
+}
+
+func TestGetSharedPoolStats(t *testing.T) {
+	defer CloseAllRateLimiters()
+
+	pool := GetOrCreateSharedPooledRateLimiter("stats_chain", 10, 2)
+	if again := GetOrCreateSharedPooledRateLimiter("stats_chain", 10, 2); again != pool {
+		t.Error("Expected the same pool for the same chain and limits")
+	}
+	if err := pool.Wait(context.Background(), "http://node"); err != nil {
+		t.Fatalf("Failed to get token: %v", err)
+	}
+
+	nodes := GetSharedPoolStats()["stats_chain_10_2"]
+	node, ok := nodes["http://node"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected stats for http://node, got %v", nodes)
+	}
+	if node["waits"] != uint64(1) || node["available_tokens"] != 1 {
+		t.Errorf("Unexpected node stats %v", node)
+	}
+}
@@ -11,29 +11,93 @@ type Stats struct {
 	AvailableTokens int
 	Capacity        int
 	Rate            time.Duration
+	WaitStats
 }
 
+// DefaultMaxWaitFraction is the share of a call's remaining deadline Wait
+// spends waiting for a token when no other is set, see WithMaxWaitFraction.
+const DefaultMaxWaitFraction = 0.5
+
 // PooledRateLimiter manages rate limiters per node
 type PooledRateLimiter struct {
+	*nodeLimiters
+
+	maxWaitFraction float64
+	budget          *Budget
+}
+
+// nodeLimiters holds a pool's per-node limiters, shared by the pools
+// derived from it with WithMaxWaitFraction and WithBudget.
+type nodeLimiters struct {
 	limiters map[string]*RateLimiter
 	mutex    sync.RWMutex
 	rate     time.Duration
 	burst    int
 }
 
+// WithMaxWaitFraction returns a pool sharing p's per-node limiters whose
+// Wait caps how much of a context's remaining deadline it may spend
+// waiting for a token: beyond it Wait fails with ErrWaitBudgetExceeded
+// rather than leaving the call too little time for its I/O. 1 waits up to
+// the deadline; 0 keeps DefaultMaxWaitFraction. p itself is unchanged, so
+// chains sharing a pool keep their own fraction.
+func (p *PooledRateLimiter) WithMaxWaitFraction(fraction float64) *PooledRateLimiter {
+	derived := *p
+	derived.maxWaitFraction = fraction
+	return &derived
+}
+
+// WithBudget returns a pool sharing p's per-node limiters that counts its
+// requests against budget, shared with the pools of every chain using the
+// same provider API key. p itself is unchanged, so a chain outside the
+// limiter group sharing its pool stays unbudgeted.
+func (p *PooledRateLimiter) WithBudget(budget *Budget) *PooledRateLimiter {
+	derived := *p
+	derived.budget = budget
+	return &derived
+}
+
+// maxWait returns how long Wait may block under ctx, 0 for no limit
+// beyond ctx itself.
+func (p *PooledRateLimiter) maxWait(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	fraction := p.maxWaitFraction
+	if fraction <= 0 {
+		fraction = DefaultMaxWaitFraction
+	}
+	remaining := time.Until(deadline)
+	if fraction >= 1 || remaining <= 0 {
+		return 0
+	}
+	return max(time.Duration(float64(remaining)*fraction), time.Nanosecond)
+}
+
 // NewPooledRateLimiter creates a new pooled rate limiter
 func NewPooledRateLimiter(rate time.Duration, burst int) *PooledRateLimiter {
-	return &PooledRateLimiter{
+	return &PooledRateLimiter{nodeLimiters: &nodeLimiters{
 		limiters: make(map[string]*RateLimiter),
 		rate:     rate,
 		burst:    burst,
-	}
+	}}
 }
 
-// Wait waits for permission to make a request to the specified node
+// Wait waits for permission to make a request to the specified node, for
+// at most the pool's share of ctx's remaining deadline. With a budget, an
+// optional request may be held back first, or refused with
+// ErrBudgetExhausted.
 func (p *PooledRateLimiter) Wait(ctx context.Context, node string) error {
+	if err := p.budget.admit(ctx); err != nil {
+		return err
+	}
 	limiter := p.getLimiter(node)
-	return limiter.Wait(ctx)
+	if err := limiter.WaitAtMost(ctx, p.maxWait(ctx)); err != nil {
+		return err
+	}
+	p.budget.count()
+	return nil
 }
 
 // TryAcquire attempts to acquire permission without blocking
@@ -88,6 +152,7 @@ func (p *PooledRateLimiter) GetStats() map[string]Stats {
 			AvailableTokens: available,
 			Capacity:        capacity,
 			Rate:            rate,
+			WaitStats:       limiter.GetWaitStats(),
 		}
 	}
 	return stats
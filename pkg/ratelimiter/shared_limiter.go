@@ -9,11 +9,13 @@ import (
 // GlobalRateLimiterManager manages shared rate limiters across workers
 type GlobalRateLimiterManager struct {
 	limiters map[string]*RateLimiter
+	pools    map[string]*PooledRateLimiter
 	mutex    sync.RWMutex
 }
 
 var globalRateLimiterManager = &GlobalRateLimiterManager{
 	limiters: make(map[string]*RateLimiter),
+	pools:    make(map[string]*PooledRateLimiter),
 }
 
 // GetOrCreateRateLimiter returns a shared rate limiter for the given URL and config
@@ -36,35 +38,41 @@ func GetOrCreateRateLimiter(url string, rps int, burst int) *RateLimiter {
 }
 
 // GetOrCreateSharedPooledRateLimiter returns a PooledRateLimiter that uses shared rate limiters
-// The scope parameter allows different worker modes to have separate rate limiters
+// Workers asking for the same url, rps and burst share one pool, and so
+// one limiter per node.
 func GetOrCreateSharedPooledRateLimiter(url string, rps int, burst int) *PooledRateLimiter {
-	sharedLimiter := GetOrCreateRateLimiter(url, rps, burst)
-
-	// Create a wrapper that always returns the same shared limiter
-	return &PooledRateLimiter{
-		limiters: map[string]*RateLimiter{
-			url: sharedLimiter, // Use URL as key, but always return the same shared limiter
-		},
-		rate:  time.Duration(1000/rps) * time.Millisecond,
-		burst: burst,
-	}
+	return getOrCreatePool(url, rps, burst)
 }
 
 // GetOrCreateScopedPooledRateLimiter returns a PooledRateLimiter with a scope (e.g., worker mode)
 // This allows different worker modes to have separate rate limiters for the same chain
 func GetOrCreateScopedPooledRateLimiter(url string, scope string, rps int, burst int) *PooledRateLimiter {
 	// Create a scoped key to separate rate limiters by scope
-	scopedURL := fmt.Sprintf("%s:%s", url, scope)
-	sharedLimiter := GetOrCreateRateLimiter(scopedURL, rps, burst)
+	return getOrCreatePool(fmt.Sprintf("%s:%s", url, scope), rps, burst)
+}
+
+// getOrCreatePool returns the pool registered under name, rps and burst,
+// creating it around a shared limiter for name if needed.
+func getOrCreatePool(name string, rps int, burst int) *PooledRateLimiter {
+	sharedLimiter := GetOrCreateRateLimiter(name, rps, burst)
+
+	globalRateLimiterManager.mutex.Lock()
+	defer globalRateLimiterManager.mutex.Unlock()
+
+	key := fmt.Sprintf("%s_%d_%d", name, rps, burst)
+	if pool, exists := globalRateLimiterManager.pools[key]; exists {
+		return pool
+	}
 
-	// Create a wrapper that always returns the same shared limiter
-	return &PooledRateLimiter{
+	pool := &PooledRateLimiter{nodeLimiters: &nodeLimiters{
 		limiters: map[string]*RateLimiter{
-			url: sharedLimiter, // Use URL as key, but always return the same shared limiter
+			name: sharedLimiter, // Use the name as key, but always return the same shared limiter
 		},
 		rate:  time.Duration(1000/rps) * time.Millisecond,
 		burst: burst,
-	}
+	}}
+	globalRateLimiterManager.pools[key] = pool
+	return pool
 }
 
 // CloseAllRateLimiters closes all global rate limiters
@@ -75,7 +83,11 @@ func CloseAllRateLimiters() {
 	for _, limiter := range globalRateLimiterManager.limiters {
 		limiter.Close()
 	}
+	for _, pool := range globalRateLimiterManager.pools {
+		pool.Close()
+	}
 	globalRateLimiterManager.limiters = make(map[string]*RateLimiter)
+	globalRateLimiterManager.pools = make(map[string]*PooledRateLimiter)
 }
 
 // GetSharedRateLimiterStats returns statistics about all shared rate limiters
@@ -85,13 +97,41 @@ func GetSharedRateLimiterStats() map[string]any {
 
 	stats := make(map[string]any)
 	for key, limiter := range globalRateLimiterManager.limiters {
-		available, capacity, rate := limiter.GetStats()
-		stats[key] = map[string]any{
-			"available_tokens": available,
-			"capacity":         capacity,
-			"rate_ms":          rate.Milliseconds(),
+		stats[key] = limiterStats(limiter)
+	}
+
+	return stats
+}
+
+// GetSharedPoolStats returns statistics about the per-node limiters of all
+// shared pools, keyed by pool then node.
+func GetSharedPoolStats() map[string]map[string]any {
+	globalRateLimiterManager.mutex.RLock()
+	defer globalRateLimiterManager.mutex.RUnlock()
+
+	stats := make(map[string]map[string]any)
+	for key, pool := range globalRateLimiterManager.pools {
+		nodes := make(map[string]any)
+		pool.mutex.RLock()
+		for node, limiter := range pool.limiters {
+			nodes[node] = limiterStats(limiter)
 		}
+		pool.mutex.RUnlock()
+		stats[key] = nodes
 	}
 
 	return stats
 }
+
+func limiterStats(limiter *RateLimiter) map[string]any {
+	available, capacity, rate := limiter.GetStats()
+	waits := limiter.GetWaitStats()
+	return map[string]any{
+		"available_tokens": available,
+		"capacity":         capacity,
+		"rate_ms":          rate.Milliseconds(),
+		"waits":            waits.Waits,
+		"wait_rejected":    waits.Rejected,
+		"wait_p95_ms":      waits.P95.Milliseconds(),
+	}
+}
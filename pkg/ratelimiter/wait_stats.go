@@ -0,0 +1,50 @@
+package ratelimiter
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// waitSamples is how many recent waits a limiter keeps for percentiles.
+const waitSamples = 256
+
+// waitStats records how long callers waited for a token.
+type waitStats struct {
+	mu       sync.Mutex
+	samples  [waitSamples]time.Duration
+	n        uint64 // waits recorded, the latest at samples[(n-1)%waitSamples]
+	rejected uint64 // waits given up for exceeding their budget
+}
+
+func (s *waitStats) record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.n%waitSamples] = d
+	s.n++
+}
+
+func (s *waitStats) reject() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected++
+}
+
+// percentile returns the p-th percentile (0-100) of the recent waits.
+func (s *waitStats) percentile(p float64) time.Duration {
+	s.mu.Lock()
+	recent := slices.Clone(s.samples[:min(s.n, waitSamples)])
+	s.mu.Unlock()
+	if len(recent) == 0 {
+		return 0
+	}
+	slices.Sort(recent)
+	i := int(float64(len(recent)-1) * p / 100)
+	return recent[i]
+}
+
+func (s *waitStats) counts() (waits, rejected uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n, s.rejected
+}
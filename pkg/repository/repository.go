@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -31,6 +33,17 @@ var (
 
 type Repository[T any] interface {
 	Find(ctx context.Context, options FindOptions) ([]*T, error)
+	// CreateMany inserts entities, skipping rows that violate a unique
+	// constraint. It returns the number of rows actually inserted.
+	CreateMany(ctx context.Context, entities []*T) (int64, error)
+}
+
+// Restorer is implemented by repositories of soft-deleted models. Restore
+// undoes the deletion of the rows matching options.Where, setting their
+// created_at to now as if they were inserted again, and returns how many
+// it restored.
+type Restorer interface {
+	Restore(ctx context.Context, options FindOptions) (int64, error)
 }
 
 // gorm generic repository
@@ -167,6 +180,30 @@ func (r *repository[T]) Find(ctx context.Context, options FindOptions) ([]*T, er
 	return results, nil
 }
 
+func (r *repository[T]) CreateMany(ctx context.Context, entities []*T) (int64, error) {
+	if len(entities) == 0 {
+		return 0, nil
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entities)
+	if res.Error != nil {
+		return 0, r.WrapError(ctx, res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *repository[T]) Restore(ctx context.Context, options FindOptions) (int64, error) {
+	var entity T
+	db := r.db.WithContext(ctx).Unscoped().Model(&entity).Where("deleted_at IS NOT NULL")
+	if options.Where != nil {
+		db = db.Where(map[string]any(options.Where))
+	}
+	res := db.Updates(map[string]any{"deleted_at": nil, "created_at": time.Now()})
+	if res.Error != nil {
+		return 0, r.WrapError(ctx, res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
 func (r *repository[T]) Count(ctx context.Context, options FindOptions) (int64, error) {
 	var count int64
 	var entity T
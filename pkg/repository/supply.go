@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SupplyTotals aggregates the supply rows of a network up to a height.
+type SupplyTotals struct {
+	Blocks      int64  // rows counted
+	FirstHeight uint64 // lowest height counted, 0 without rows
+	Fees        int64  // summed fees in satoshis
+}
+
+// SupplyRepository persists the supply tracked per block, keyed by network
+// and height.
+type SupplyRepository interface {
+	// Upsert writes blocks, updating the rows of heights already stored.
+	Upsert(ctx context.Context, blocks ...*model.SupplyBlock) error
+	// Get returns the row at height, nil if there is none.
+	Get(ctx context.Context, networkID string, height uint64) (*model.SupplyBlock, error)
+	// Latest returns the highest row, nil if there is none.
+	Latest(ctx context.Context, networkID string) (*model.SupplyBlock, error)
+	// DeleteFrom deletes the rows at height and above, returning how many
+	// were deleted.
+	DeleteFrom(ctx context.Context, networkID string, height uint64) (int64, error)
+	// Totals aggregates the rows at height and below.
+	Totals(ctx context.Context, networkID string, height uint64) (SupplyTotals, error)
+}
+
+type supplyRepository struct {
+	*repository[model.SupplyBlock]
+}
+
+func NewSupplyRepository(db *gorm.DB) SupplyRepository {
+	return &supplyRepository{repository: &repository[model.SupplyBlock]{db: db}}
+}
+
+func (r *supplyRepository) Upsert(ctx context.Context, blocks ...*model.SupplyBlock) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "network_id"}, {Name: "height"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_hash", "subsidy", "fees", "updated_at"}),
+	}).Create(blocks)
+	if res.Error != nil {
+		return r.WrapError(ctx, res.Error)
+	}
+	return nil
+}
+
+func (r *supplyRepository) Get(ctx context.Context, networkID string, height uint64) (*model.SupplyBlock, error) {
+	return r.take(r.db.WithContext(ctx).Where("network_id = ? AND height = ?", networkID, height))
+}
+
+func (r *supplyRepository) Latest(ctx context.Context, networkID string) (*model.SupplyBlock, error) {
+	return r.take(r.db.WithContext(ctx).Where("network_id = ?", networkID).Order("height DESC"))
+}
+
+func (r *supplyRepository) take(db *gorm.DB) (*model.SupplyBlock, error) {
+	var block model.SupplyBlock
+	err := db.Take(&block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.WrapError(db.Statement.Context, err)
+	}
+	return &block, nil
+}
+
+func (r *supplyRepository) DeleteFrom(ctx context.Context, networkID string, height uint64) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("network_id = ? AND height >= ?", networkID, height).
+		Delete(&model.SupplyBlock{})
+	if res.Error != nil {
+		return 0, r.WrapError(ctx, res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *supplyRepository) Totals(ctx context.Context, networkID string, height uint64) (SupplyTotals, error) {
+	var totals SupplyTotals
+	err := r.db.WithContext(ctx).Model(&model.SupplyBlock{}).
+		Select("COUNT(*) AS blocks, COALESCE(MIN(height), 0) AS first_height, COALESCE(SUM(fees), 0) AS fees").
+		Where("network_id = ? AND height <= ?", networkID, height).
+		Scan(&totals).Error
+	if err != nil {
+		return SupplyTotals{}, r.WrapError(ctx, err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultTransferBatchSize is the number of rows UpsertMany writes per
+// statement when no batch size is given.
+const DefaultTransferBatchSize = 500
+
+// transferUpsertColumns are the columns a re-indexed transfer updates. The
+// rest are part of its TransferID, or fixed by the transaction.
+var transferUpsertColumns = []string{
+	"block_number", "block_hash", "from_addresses", "amount", "tx_fee", "type",
+	"role", "status", "confirmations", "timestamp", "metadata", "updated_at",
+}
+
+// TransferRepository persists transfers keyed by their TransferID.
+type TransferRepository interface {
+	Repository[model.Transfer]
+	// UpsertMany writes transfers in chunks, updating the rows of
+	// TransferIDs already stored.
+	UpsertMany(ctx context.Context, transfers []*model.Transfer) error
+	// OrphanFrom marks the transfers of networkID at height and above as
+	// orphaned, returning how many it marked. Transfers re-indexed in the
+	// blocks replacing them are upserted back.
+	OrphanFrom(ctx context.Context, networkID string, height uint64) (int64, error)
+}
+
+type transferRepository struct {
+	*repository[model.Transfer]
+	batchSize int
+}
+
+// NewTransferRepository returns a TransferRepository writing batchSize rows
+// per statement, DefaultTransferBatchSize if batchSize is not positive.
+func NewTransferRepository(db *gorm.DB, batchSize int) TransferRepository {
+	if batchSize <= 0 {
+		batchSize = DefaultTransferBatchSize
+	}
+	return &transferRepository{
+		repository: &repository[model.Transfer]{db: db},
+		batchSize:  batchSize,
+	}
+}
+
+func (r *transferRepository) UpsertMany(ctx context.Context, transfers []*model.Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "transfer_id"}},
+		DoUpdates: clause.AssignmentColumns(transferUpsertColumns),
+	}).CreateInBatches(transfers, r.batchSize)
+	if res.Error != nil {
+		return r.WrapError(ctx, res.Error)
+	}
+	return nil
+}
+
+func (r *transferRepository) OrphanFrom(ctx context.Context, networkID string, height uint64) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.Transfer{}).
+		Where("network_id = ? AND block_number >= ?", networkID, height).
+		Updates(map[string]any{"status": types.StatusOrphaned, "updated_at": time.Now()})
+	if res.Error != nil {
+		return 0, r.WrapError(ctx, res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -15,6 +15,15 @@ const (
 
 type Operation func() error
 
+// Permanent wraps err so that Constant and Exponential stop retrying and
+// return err immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &backoff.PermanentError{Err: err}
+}
+
 type ExponentialConfig struct {
 	InitialInterval time.Duration
 	MaxElapsedTime  time.Duration
@@ -49,6 +58,10 @@ func Constant(fn Operation, interval time.Duration, attempts int) error {
 		if err = fn(); err == nil {
 			return nil
 		}
+		var permanent *backoff.PermanentError
+		if errors.As(err, &permanent) {
+			return permanent.Err
+		}
 		if i < attempts {
 			time.Sleep(interval)
 		}
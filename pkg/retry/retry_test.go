@@ -103,3 +103,15 @@ func TestConstant_AttemptsNonPositiveMeansOneAttempt(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 1, calls, "should only attempt once when attempts<=0")
 }
+
+func TestConstant_PermanentStopsImmediately(t *testing.T) {
+	var calls int
+	notFound := errors.New("not found")
+	err := Constant(func() error {
+		calls++
+		return Permanent(notFound)
+	}, 1*time.Millisecond, 3)
+
+	assert.Same(t, notFound, err)
+	assert.Equal(t, 1, calls, "permanent error must not be retried")
+}
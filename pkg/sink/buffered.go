@@ -0,0 +1,445 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// Defaults for the zero fields of BufferedConfig.
+const (
+	DefaultBufferQueueSize      = 64
+	DefaultBufferHandoffTimeout = 5 * time.Second
+	DefaultBufferSegmentBytes   = 64 << 20
+	DefaultBufferRetryInterval  = time.Second
+)
+
+// ErrSinkClosed is returned by writes to a closed BufferedSink or
+// BufferedQueue.
+var ErrSinkClosed = errors.New("sink closed")
+
+// BufferedConfig tunes a BufferedSink or BufferedQueue.
+type BufferedConfig struct {
+	// Dir holds the write-ahead log segments.
+	Dir string
+	// QueueSize is the number of writes held in memory for the wrapped sink.
+	QueueSize int
+	// HandoffTimeout is how long a write waits for the wrapped sink before
+	// it is spilled to the log.
+	HandoffTimeout time.Duration
+	// SegmentBytes is the size past which the log starts a new segment.
+	SegmentBytes int64
+	// RetryInterval is the wait between failed writes to the wrapped sink.
+	RetryInterval time.Duration
+}
+
+func (c BufferedConfig) withDefaults() BufferedConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultBufferQueueSize
+	}
+	if c.HandoffTimeout <= 0 {
+		c.HandoffTimeout = DefaultBufferHandoffTimeout
+	}
+	if c.SegmentBytes <= 0 {
+		c.SegmentBytes = DefaultBufferSegmentBytes
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = DefaultBufferRetryInterval
+	}
+	return c
+}
+
+// bufferRecord is a write a buffer hands to its downstream, encoded as
+// JSON when spilled. logAttrs describes it in logs.
+type bufferRecord interface {
+	logAttrs() []any
+}
+
+// walRecord is one spilled write: transfers, or a rollback.
+type walRecord struct {
+	Chain     string              `json:"chain"`
+	Transfers []types.Transaction `json:"transfers"`
+	Rollback  *rollbackRecord     `json:"rollback,omitempty"`
+}
+
+func (r walRecord) logAttrs() []any {
+	return []any{"chain", r.Chain, "transfers", len(r.Transfers), "rollback", r.Rollback != nil}
+}
+
+// rollbackRecord is a RollBack queued behind the writes before it.
+type rollbackRecord struct {
+	NetworkID string `json:"network_id"`
+	From      uint64 `json:"from"`
+}
+
+// bufferedWrite is a write queued in memory.
+type bufferedWrite[R bufferRecord] struct {
+	rec       R
+	done      chan struct{} // closed once handedOff
+	handedOff bool          // written downstream or spilled
+}
+
+// buffer hands records to a downstream through a bounded in-memory queue,
+// spilling them to a write-ahead log while the downstream is slow or down.
+// Records queue in memory for a background writer; one the downstream has
+// not taken within HandoffTimeout, or that finds the queue full, is
+// spilled with the rest of the queue to the log and fsynced. While the log
+// holds records, new ones go to the log too, and the writer replays it in
+// order before taking records from memory again.
+//
+// handOff returns once the record is durably handed off, taken by the
+// downstream or fsynced to the log. Delivery is at least once: the log is
+// replayed from its oldest segment after a restart, and a record spilled
+// while in flight is delivered again.
+type buffer[R bufferRecord] struct {
+	name    string // what the log buffers, for logs
+	deliver func(context.Context, R) error
+	cfg     BufferedConfig
+
+	mu     sync.Mutex
+	queue  []*bufferedWrite[R]
+	log    *wal
+	closed bool
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	stats bufferStats
+}
+
+// bufferStats counts a buffer's hand-offs.
+type bufferStats struct {
+	written  uint64 // records taken by the downstream from memory
+	spilled  uint64 // records spilled to the log
+	replayed uint64 // log records taken by the downstream
+	failed   uint64 // failed deliveries
+}
+
+// openBuffer opens the write-ahead log in cfg.Dir and starts delivering,
+// beginning with any records the log holds from a previous run.
+func openBuffer[R bufferRecord](name string, deliver func(context.Context, R) error, cfg BufferedConfig) (*buffer[R], error) {
+	cfg = cfg.withDefaults()
+	log, err := openWAL(cfg.Dir, cfg.SegmentBytes)
+	if err != nil {
+		return nil, err
+	}
+	if log.pending() {
+		logger.Info("Replaying "+name+" write-ahead log",
+			"dir", cfg.Dir, "segments", log.segments(), "bytes", log.pendingBytes())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &buffer[R]{
+		name:    name,
+		deliver: deliver,
+		cfg:     cfg,
+		log:     log,
+		wake:    make(chan struct{}, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go b.run(ctx)
+	return b, nil
+}
+
+// handOff queues rec for the downstream and waits until it is taken, or
+// spills it to the log.
+func (b *buffer[R]) handOff(ctx context.Context, rec R) error {
+	w := &bufferedWrite[R]{rec: rec, done: make(chan struct{})}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrSinkClosed
+	}
+	if b.log.pending() || len(b.queue) >= b.cfg.QueueSize {
+		// Keep order: nothing goes around records already spilled.
+		b.queue = append(b.queue, w)
+		err := b.spillLocked()
+		b.mu.Unlock()
+		return err
+	}
+	b.queue = append(b.queue, w)
+	b.mu.Unlock()
+	b.signal()
+
+	timer := time.NewTimer(b.cfg.HandoffTimeout)
+	defer timer.Stop()
+	select {
+	case <-w.done:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w.handedOff {
+		return nil
+	}
+	if b.closed {
+		return ErrSinkClosed
+	}
+	return b.spillLocked()
+}
+
+// spillLocked appends the queued records to the log, in order, and syncs
+// it. A record in flight to the downstream is spilled too and may be
+// delivered twice.
+func (b *buffer[R]) spillLocked() error {
+	spilled := 0
+	var err error
+	for _, w := range b.queue {
+		var payload []byte
+		if payload, err = json.Marshal(w.rec); err != nil {
+			break
+		}
+		if err = b.log.append(payload); err != nil {
+			break
+		}
+		spilled++
+	}
+	if spilled > 0 {
+		if serr := b.log.sync(); serr != nil {
+			// Nothing appended is known durable; the writers retry.
+			return serr
+		}
+	}
+	for _, w := range b.queue[:spilled] {
+		w.handedOff = true
+		close(w.done)
+	}
+	b.queue = b.queue[spilled:]
+	b.stats.spilled += uint64(spilled)
+	if spilled > 0 {
+		b.signal()
+	}
+	return err
+}
+
+func (b *buffer[R]) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued records and log records, one at a time, retrying a
+// failed delivery every RetryInterval until it succeeds or the buffer is
+// closed.
+func (b *buffer[R]) run(ctx context.Context) {
+	defer close(b.done)
+	for {
+		rec, w, size, ok, err := b.next()
+		if err != nil {
+			logger.Error("Failed to read "+b.name+" write-ahead log", "dir", b.cfg.Dir, "err", err)
+			if !b.sleep(ctx) {
+				return
+			}
+			continue
+		}
+		if !ok {
+			select {
+			case <-b.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := b.deliver(ctx, rec); err != nil {
+			b.mu.Lock()
+			b.stats.failed++
+			b.mu.Unlock()
+			logger.Warn("Buffered "+b.name+" write failed, retrying",
+				append(rec.logAttrs(), "retry_in", b.cfg.RetryInterval, "err", err)...)
+			if !b.sleep(ctx) {
+				return
+			}
+			continue
+		}
+		b.delivered(w, size)
+	}
+}
+
+// next returns the next record for the downstream: the oldest log record,
+// with its size, or else the oldest queued write. ok is false when there
+// is nothing to deliver.
+func (b *buffer[R]) next() (rec R, w *bufferedWrite[R], size int64, ok bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextLocked()
+}
+
+func (b *buffer[R]) nextLocked() (rec R, w *bufferedWrite[R], size int64, ok bool, err error) {
+	if b.log.pending() {
+		payload, size, err := b.log.peek()
+		if err != nil {
+			return rec, nil, 0, false, err
+		}
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			// The CRC matched, so the record was written this way; it can
+			// never be replayed.
+			logger.Error("Dropping undecodable "+b.name+" write-ahead log record", "err", err)
+			if err := b.log.consume(size); err != nil {
+				return rec, nil, 0, false, err
+			}
+			return b.nextLocked()
+		}
+		return rec, nil, size, true, nil
+	}
+	if len(b.queue) > 0 {
+		return b.queue[0].rec, b.queue[0], 0, true, nil
+	}
+	return rec, nil, 0, false, nil
+}
+
+// delivered records that the downstream took w, or the log record of the
+// given size.
+func (b *buffer[R]) delivered(w *bufferedWrite[R], size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w == nil {
+		if err := b.log.consume(size); err != nil {
+			logger.Error("Failed to advance "+b.name+" write-ahead log", "dir", b.cfg.Dir, "err", err)
+		}
+		b.stats.replayed++
+		return
+	}
+	// A write spilled while in flight left the queue; its log record is
+	// delivered again.
+	if w.handedOff {
+		return
+	}
+	w.handedOff = true
+	close(w.done)
+	b.queue = b.queue[1:]
+	b.stats.written++
+}
+
+func (b *buffer[R]) sleep(ctx context.Context) bool {
+	timer := time.NewTimer(b.cfg.RetryInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// close stops delivering, spills the records still queued and closes the
+// log. Records left in the log are replayed by the next buffer opened on
+// the same directory.
+func (b *buffer[R]) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.cancel()
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.spillLocked()
+	if cerr := b.log.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// statsMap reports the queue depth, the log's segments and unreplayed
+// bytes, and how many records were taken from memory, spilled, replayed
+// or failed.
+func (b *buffer[R]) statsMap() map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return map[string]any{
+		"queue_depth":    len(b.queue),
+		"queue_capacity": b.cfg.QueueSize,
+		"spill_segments": b.log.segments(),
+		"spill_bytes":    b.log.pendingBytes(),
+		"written":        b.stats.written,
+		"spilled":        b.stats.spilled,
+		"replayed":       b.stats.replayed,
+		"failed":         b.stats.failed,
+	}
+}
+
+// BufferedSink wraps a Sink so a slow or unavailable database neither
+// blocks the workers indefinitely nor loses transfers: writes are queued
+// in memory and spilled to an on-disk write-ahead log, see buffer.
+//
+// WriteTransfers returns once the transfers are durably handed off,
+// written by the wrapped sink or fsynced to the log, so a worker advances
+// its checkpoint only past transfers that survive a restart.
+type BufferedSink struct {
+	inner Sink
+	buf   *buffer[walRecord]
+}
+
+// NewBufferedSink opens the write-ahead log in cfg.Dir and starts writing
+// to inner, beginning with any records the log holds from a previous run.
+func NewBufferedSink(inner Sink, cfg BufferedConfig) (*BufferedSink, error) {
+	s := &BufferedSink{inner: inner}
+	buf, err := openBuffer("transfer sink", s.write, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.buf = buf
+	return s, nil
+}
+
+// WriteTransfers hands transfers off to the wrapped sink, or to the log
+// when the sink does not take them in time.
+func (s *BufferedSink) WriteTransfers(ctx context.Context, chain string, transfers []types.Transaction) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	return s.buf.handOff(ctx, walRecord{Chain: chain, Transfers: transfers})
+}
+
+// RollBack hands the rollback off behind the writes queued or spilled
+// before it, so none of them stores a rolled-back transfer again. It does
+// nothing unless the wrapped sink is a Rollbacker.
+func (s *BufferedSink) RollBack(ctx context.Context, networkID string, from uint64) error {
+	if _, ok := s.inner.(Rollbacker); !ok {
+		return nil
+	}
+	return s.buf.handOff(ctx, walRecord{Rollback: &rollbackRecord{NetworkID: networkID, From: from}})
+}
+
+// write hands rec to the wrapped sink.
+func (s *BufferedSink) write(ctx context.Context, rec walRecord) error {
+	if rec.Rollback != nil {
+		rb, ok := s.inner.(Rollbacker)
+		if !ok {
+			return nil
+		}
+		return rb.RollBack(ctx, rec.Rollback.NetworkID, rec.Rollback.From)
+	}
+	return s.inner.WriteTransfers(ctx, rec.Chain, rec.Transfers)
+}
+
+// Close stops writing to the wrapped sink, spills the writes still queued
+// and closes the log. Records left in the log are replayed by the next
+// BufferedSink opened on the same directory.
+func (s *BufferedSink) Close() error {
+	return s.buf.close()
+}
+
+// Stats reports the queue depth, the log's segments and unreplayed bytes,
+// and how many writes were taken from memory, spilled, replayed or failed.
+func (s *BufferedSink) Stats() map[string]any {
+	return s.buf.statsMap()
+}
@@ -0,0 +1,213 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySink records the transfers it takes and fails while down.
+type flakySink struct {
+	mu     sync.Mutex
+	down   bool
+	hashes []string
+}
+
+func (f *flakySink) WriteTransfers(_ context.Context, _ string, transfers []types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("downstream unavailable")
+	}
+	for _, tx := range transfers {
+		f.hashes = append(f.hashes, tx.TxHash)
+	}
+	return nil
+}
+
+// RollBack records the rollback among the transfers, as "rollback:<from>".
+func (f *flakySink) RollBack(_ context.Context, _ string, from uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("downstream unavailable")
+	}
+	f.hashes = append(f.hashes, fmt.Sprintf("rollback:%d", from))
+	return nil
+}
+
+func (f *flakySink) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *flakySink) written() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.hashes...)
+}
+
+func testBufferedConfig(t *testing.T) BufferedConfig {
+	return BufferedConfig{
+		Dir:            t.TempDir(),
+		QueueSize:      2,
+		HandoffTimeout: 20 * time.Millisecond,
+		RetryInterval:  5 * time.Millisecond,
+	}
+}
+
+func transfers(hashes ...string) []types.Transaction {
+	out := make([]types.Transaction, len(hashes))
+	for i, h := range hashes {
+		out[i] = types.Transaction{TxHash: h}
+	}
+	return out
+}
+
+func TestBufferedSinkWritesThrough(t *testing.T) {
+	inner := &flakySink{}
+	s, err := NewBufferedSink(inner, testBufferedConfig(t))
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.WriteTransfers(context.Background(), "eth", transfers("a", "b")))
+
+	assert.Equal(t, []string{"a", "b"}, inner.written(), "handed off before returning")
+	stats := s.Stats()
+	assert.Equal(t, uint64(1), stats["written"])
+	assert.Equal(t, uint64(0), stats["spilled"])
+	assert.Equal(t, 0, stats["spill_segments"])
+}
+
+func TestBufferedSinkSpillsAndReplaysInOrder(t *testing.T) {
+	inner := &flakySink{down: true}
+	s, err := NewBufferedSink(inner, testBufferedConfig(t))
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx := context.Background()
+	require.NoError(t, s.WriteTransfers(ctx, "eth", transfers("a")), "spilled after the handoff timeout")
+	require.NoError(t, s.WriteTransfers(ctx, "eth", transfers("b")), "spilled behind the log")
+	require.NoError(t, s.WriteTransfers(ctx, "tron", transfers("c")))
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(3), stats["spilled"])
+	assert.Equal(t, 1, stats["spill_segments"])
+	assert.Positive(t, stats["spill_bytes"])
+	assert.Empty(t, inner.written())
+
+	inner.setDown(false)
+	require.Eventually(t, func() bool {
+		return s.Stats()["spill_segments"] == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "c"}, inner.written())
+	assert.Equal(t, uint64(3), s.Stats()["replayed"])
+	assert.Positive(t, s.Stats()["failed"])
+
+	// With the log drained, writes go through memory again.
+	require.NoError(t, s.WriteTransfers(ctx, "eth", transfers("d")))
+	assert.Equal(t, uint64(1), s.Stats()["written"])
+}
+
+func TestBufferedSinkRollsBackBehindSpilledWrites(t *testing.T) {
+	inner := &flakySink{down: true}
+	s, err := NewBufferedSink(inner, testBufferedConfig(t))
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx := context.Background()
+	require.NoError(t, s.WriteTransfers(ctx, "btc", transfers("a")))
+	require.NoError(t, s.RollBack(ctx, "btc_mainnet", 100))
+	require.NoError(t, s.WriteTransfers(ctx, "btc", transfers("b")))
+
+	inner.setDown(false)
+	require.Eventually(t, func() bool {
+		return s.Stats()["spill_segments"] == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a", "rollback:100", "b"}, inner.written())
+}
+
+func TestBufferedSinkReplaysAfterRestart(t *testing.T) {
+	cfg := testBufferedConfig(t)
+	down := &flakySink{down: true}
+	s, err := NewBufferedSink(down, cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.WriteTransfers(context.Background(), "btc", transfers("a")))
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.WriteTransfers(context.Background(), "btc", transfers("b")), ErrSinkClosed)
+
+	up := &flakySink{}
+	s, err = NewBufferedSink(up, cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	require.Eventually(t, func() bool {
+		return len(up.written()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a"}, up.written())
+}
+
+func TestWALRollsAndDeletesSegments(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openWAL(dir, 1)
+	require.NoError(t, err)
+	for _, rec := range []string{"one", "two", "three"} {
+		require.NoError(t, l.append([]byte(rec)))
+	}
+	require.NoError(t, l.sync())
+	assert.Equal(t, 3, l.segments(), "each record fills a 1-byte segment")
+
+	var got []string
+	for l.pending() {
+		payload, size, err := l.peek()
+		require.NoError(t, err)
+		got = append(got, string(payload))
+		require.NoError(t, l.consume(size))
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, got)
+	files, err := filepath.Glob(filepath.Join(dir, "*"+walSuffix))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestWALTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openWAL(dir, DefaultBufferSegmentBytes)
+	require.NoError(t, err)
+	require.NoError(t, l.append([]byte("complete")))
+	require.NoError(t, l.close())
+
+	// A crash mid-append leaves a header without its payload.
+	path := l.path(1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{100, 0, 0, 0, 1, 2, 3, 4, 'x'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err = openWAL(dir, DefaultBufferSegmentBytes)
+	require.NoError(t, err)
+	defer l.close()
+	payload, size, err := l.peek()
+	require.NoError(t, err)
+	assert.Equal(t, "complete", string(payload))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size(), "torn record truncated")
+
+	// Appends continue after the last complete record.
+	require.NoError(t, l.append([]byte("next")))
+	require.NoError(t, l.consume(size))
+	payload, _, err = l.peek()
+	require.NoError(t, err)
+	assert.Equal(t, "next", string(payload))
+}
@@ -0,0 +1,152 @@
+package sink
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/model"
+)
+
+// TransferStore writes transfers, updating those already stored, and
+// orphans those of rolled-back blocks; see repository.TransferRepository.
+type TransferStore interface {
+	UpsertMany(ctx context.Context, transfers []*model.Transfer) error
+	OrphanFrom(ctx context.Context, networkID string, height uint64) (int64, error)
+}
+
+// DBSink is a Sink upserting transfers into the transfers table. It stores
+// each transfer's type under its published name, as the emitter publishes
+// it.
+type DBSink struct {
+	repo    TransferStore
+	txTypes *constant.TxTypeRegistry
+	stats   writeStats
+}
+
+// NewDBSink returns a DBSink writing through repo, storing types under
+// their names in txTypes; a nil txTypes stores them under their own.
+func NewDBSink(repo TransferStore, txTypes *constant.TxTypeRegistry) *DBSink {
+	return &DBSink{repo: repo, txTypes: txTypes}
+}
+
+// WriteTransfers upserts transfers, once per TransferID: the in and out
+// copies of a transfer between monitored addresses share a row.
+func (s *DBSink) WriteTransfers(ctx context.Context, chain string, transfers []types.Transaction) error {
+	rows := make([]*model.Transfer, 0, len(transfers))
+	seen := make(map[string]bool, len(transfers))
+	for _, tx := range transfers {
+		tx.EnsureTransferID()
+		if seen[tx.TransferID] {
+			continue
+		}
+		seen[tx.TransferID] = true
+		row := transferRow(tx)
+		row.Type = string(s.txTypes.Name(tx.Type))
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := s.repo.UpsertMany(ctx, rows)
+	s.stats.record(time.Since(start), len(rows), err)
+	return err
+}
+
+// RollBack marks the transfers of networkID's blocks at height from and
+// above orphaned. Those re-indexed in the replacing blocks are upserted
+// back with their new block.
+func (s *DBSink) RollBack(ctx context.Context, networkID string, from uint64) error {
+	n, err := s.repo.OrphanFrom(ctx, networkID, from)
+	if err != nil {
+		return err
+	}
+	logger.Info("Transfer sink orphaned rolled-back transfers", "network_id", networkID, "from", from, "rows", n)
+	return nil
+}
+
+// Stats reports the sink's writes, rows written, failed writes and recent
+// write latencies.
+func (s *DBSink) Stats() map[string]any {
+	writes, rows, failed := s.stats.counts()
+	return map[string]any{
+		"writes":       writes,
+		"rows":         rows,
+		"failed":       failed,
+		"write_p50_ms": s.stats.percentile(50).Milliseconds(),
+		"write_p95_ms": s.stats.percentile(95).Milliseconds(),
+	}
+}
+
+func transferRow(tx types.Transaction) *model.Transfer {
+	return &model.Transfer{
+		TransferID:    tx.TransferID,
+		NetworkID:     tx.NetworkId,
+		InternalCode:  tx.InternalCode,
+		TxHash:        tx.TxHash,
+		TransferIndex: tx.TransferIndex,
+		BlockNumber:   tx.BlockNumber,
+		BlockHash:     tx.BlockHash,
+		FromAddress:   tx.FromAddress,
+		FromAddresses: tx.FromAddresses,
+		ToAddress:     tx.ToAddress,
+		AssetAddress:  tx.AssetAddress,
+		Amount:        tx.Amount,
+		TxFee:         tx.TxFee,
+		Type:          string(tx.Type),
+		Role:          tx.Role,
+		Status:        tx.Status,
+		Confirmations: tx.Confirmations,
+		Timestamp:     tx.Timestamp,
+		Metadata:      tx.Metadata,
+	}
+}
+
+// writeSamples is how many recent writes a sink keeps for percentiles.
+const writeSamples = 256
+
+// writeStats records how long writes took.
+type writeStats struct {
+	mu      sync.Mutex
+	samples [writeSamples]time.Duration
+	n       uint64 // writes recorded, the latest at samples[(n-1)%writeSamples]
+	rows    uint64
+	failed  uint64
+}
+
+func (s *writeStats) record(d time.Duration, rows int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.n%writeSamples] = d
+	s.n++
+	if err != nil {
+		s.failed++
+		return
+	}
+	s.rows += uint64(rows)
+}
+
+// percentile returns the p-th percentile (0-100) of the recent writes.
+func (s *writeStats) percentile(p float64) time.Duration {
+	s.mu.Lock()
+	recent := slices.Clone(s.samples[:min(s.n, writeSamples)])
+	s.mu.Unlock()
+	if len(recent) == 0 {
+		return 0
+	}
+	slices.Sort(recent)
+	i := int(float64(len(recent)-1) * p / 100)
+	return recent[i]
+}
+
+func (s *writeStats) counts() (writes, rows, failed uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n, s.rows, s.failed
+}
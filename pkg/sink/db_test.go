@@ -0,0 +1,109 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/constant"
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	rows     []*model.Transfer
+	orphaned map[string]uint64 // network ID -> height orphaned from
+	err      error
+}
+
+func (f *fakeStore) UpsertMany(_ context.Context, transfers []*model.Transfer) error {
+	f.rows = append(f.rows, transfers...)
+	return f.err
+}
+
+func (f *fakeStore) OrphanFrom(_ context.Context, networkID string, height uint64) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if f.orphaned == nil {
+		f.orphaned = make(map[string]uint64)
+	}
+	f.orphaned[networkID] = height
+	return 1, nil
+}
+
+func TestDBSinkWriteTransfers(t *testing.T) {
+	repo := &fakeStore{}
+	s := NewDBSink(repo, nil)
+	tx := types.Transaction{
+		TxHash:        "abc",
+		NetworkId:     "btc_mainnet",
+		BlockNumber:   100,
+		TransferIndex: "0:0",
+		FromAddress:   "from",
+		ToAddress:     "to",
+		Amount:        "1000",
+		TxFee:         decimal.RequireFromString("0.0001"),
+		Type:          constant.TxTypeNativeTransfer,
+		Role:          types.RoleInternal,
+	}
+	tx.SetMetadata("vout", 0)
+	in, out := tx, tx
+	in.Direction, out.Direction = types.DirectionIn, types.DirectionOut
+
+	require.NoError(t, s.WriteTransfers(context.Background(), "btc", []types.Transaction{in, out}))
+
+	require.Len(t, repo.rows, 1, "both directions share a row")
+	row := repo.rows[0]
+	assert.Equal(t, tx.ComputeTransferID(), row.TransferID)
+	assert.Equal(t, "btc_mainnet", row.NetworkID)
+	assert.Equal(t, "to", row.ToAddress)
+	assert.Equal(t, "native_transfer", row.Type)
+	assert.Equal(t, types.RoleInternal, row.Role)
+	assert.True(t, row.TxFee.Equal(tx.TxFee))
+	assert.Equal(t, map[string]any{"vout": 0}, row.Metadata)
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(1), stats["writes"])
+	assert.Equal(t, uint64(1), stats["rows"])
+	assert.Equal(t, uint64(0), stats["failed"])
+}
+
+func TestDBSinkWriteTransfersError(t *testing.T) {
+	s := NewDBSink(&fakeStore{err: errors.New("db down")}, nil)
+
+	err := s.WriteTransfers(context.Background(), "btc", []types.Transaction{{TxHash: "abc"}})
+
+	require.Error(t, err)
+	stats := s.Stats()
+	assert.Equal(t, uint64(1), stats["failed"])
+	assert.Equal(t, uint64(0), stats["rows"])
+}
+
+func TestDBSinkWriteTransfersEmpty(t *testing.T) {
+	repo := &fakeStore{err: errors.New("unexpected write")}
+	require.NoError(t, NewDBSink(repo, nil).WriteTransfers(context.Background(), "btc", nil))
+	assert.Empty(t, repo.rows)
+}
+
+func TestDBSinkStoresPublishedType(t *testing.T) {
+	repo := &fakeStore{}
+	txTypes, err := constant.NewTxTypeRegistry(map[string]string{"nonstandard": "native_transfer"})
+	require.NoError(t, err)
+	s := NewDBSink(repo, txTypes)
+
+	tx := types.Transaction{TxHash: "abc", Type: constant.TxTypeNonstandard}
+	require.NoError(t, s.WriteTransfers(context.Background(), "btc", []types.Transaction{tx}))
+
+	require.Len(t, repo.rows, 1)
+	assert.Equal(t, "native_transfer", repo.rows[0].Type)
+}
+
+func TestDBSinkRollBack(t *testing.T) {
+	repo := &fakeStore{}
+	require.NoError(t, NewDBSink(repo, nil).RollBack(context.Background(), "btc_mainnet", 100))
+	assert.Equal(t, map[string]uint64{"btc_mainnet": 100}, repo.orphaned)
+}
@@ -0,0 +1,81 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+)
+
+// queueRecord is one spilled message.
+type queueRecord struct {
+	Topic   string                `json:"topic"`
+	Message []byte                `json:"message"`
+	Options *infra.EnqueueOptions `json:"options,omitempty"`
+}
+
+func (r queueRecord) logAttrs() []any {
+	return []any{"topic", r.Topic, "bytes", len(r.Message)}
+}
+
+// BufferedQueue wraps a MessageQueue, e.g. the NATS transfer stream, so a
+// slow or unavailable broker neither blocks the workers indefinitely nor
+// loses messages: they are queued in memory and spilled to an on-disk
+// write-ahead log, see buffer, and published in order once the broker
+// takes them again. Any infra.MessageQueue can be wrapped.
+//
+// Enqueue returns once the message is durably handed off, published or
+// fsynced to the log. Each message keeps its idempotency key, so the
+// broker drops the copies at-least-once delivery publishes twice within
+// its deduplication window.
+type BufferedQueue struct {
+	inner infra.MessageQueue
+	buf   *buffer[queueRecord]
+}
+
+var _ infra.MessageQueue = (*BufferedQueue)(nil)
+
+// NewBufferedQueue opens the write-ahead log in cfg.Dir and starts
+// publishing to inner, beginning with any messages the log holds from a
+// previous run.
+func NewBufferedQueue(inner infra.MessageQueue, cfg BufferedConfig) (*BufferedQueue, error) {
+	q := &BufferedQueue{inner: inner}
+	buf, err := openBuffer("message queue", q.publish, cfg)
+	if err != nil {
+		return nil, err
+	}
+	q.buf = buf
+	return q, nil
+}
+
+// Enqueue hands message off to the wrapped queue, or to the log when the
+// queue does not take it in time.
+func (q *BufferedQueue) Enqueue(topic string, message []byte, options *infra.EnqueueOptions) error {
+	return q.buf.handOff(context.Background(), queueRecord{Topic: topic, Message: message, Options: options})
+}
+
+func (q *BufferedQueue) publish(_ context.Context, rec queueRecord) error {
+	return q.inner.Enqueue(rec.Topic, rec.Message, rec.Options)
+}
+
+// Dequeue consumes from the wrapped queue.
+func (q *BufferedQueue) Dequeue(topic string, handler func(message []byte) error) error {
+	return q.inner.Dequeue(topic, handler)
+}
+
+// Close stops publishing, spills the messages still queued, closes the log
+// and then the wrapped queue. Messages left in the log are published by
+// the next BufferedQueue opened on the same directory.
+func (q *BufferedQueue) Close() {
+	if err := q.buf.close(); err != nil {
+		logger.Error("Failed to spill buffered messages", "dir", q.buf.cfg.Dir, "err", err)
+	}
+	q.inner.Close()
+}
+
+// Stats reports the queue depth, the log's segments and unreplayed bytes,
+// and how many messages were published from memory, spilled, replayed or
+// failed.
+func (q *BufferedQueue) Stats() map[string]any {
+	return q.buf.statsMap()
+}
@@ -0,0 +1,71 @@
+package sink
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyQueue records the messages published to it and fails while down.
+type flakyQueue struct {
+	mu     sync.Mutex
+	down   bool
+	keys   []string
+	closed bool
+}
+
+func (q *flakyQueue) Enqueue(topic string, message []byte, options *infra.EnqueueOptions) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.down {
+		return errors.New("nats: timeout")
+	}
+	q.keys = append(q.keys, topic+"/"+string(message)+"/"+options.IdempotententKey)
+	return nil
+}
+
+func (q *flakyQueue) Dequeue(string, func([]byte) error) error { return nil }
+
+func (q *flakyQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+}
+
+func (q *flakyQueue) published() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.keys...)
+}
+
+func TestBufferedQueueSpillsAndPublishesInOrder(t *testing.T) {
+	inner := &flakyQueue{down: true}
+	cfg := testBufferedConfig(t)
+	q, err := NewBufferedQueue(inner, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Enqueue(infra.TransferEventTopicQueue, []byte("a"), &infra.EnqueueOptions{IdempotententKey: "ka"}))
+	require.NoError(t, q.Enqueue(infra.ReorgEventTopicQueue, []byte("b"), &infra.EnqueueOptions{IdempotententKey: "kb"}))
+	assert.Equal(t, uint64(2), q.Stats()["spilled"])
+	assert.Empty(t, inner.published())
+
+	// The log survives a restart, and its messages keep their keys.
+	q.Close()
+	assert.True(t, inner.closed)
+	up := &flakyQueue{}
+	q, err = NewBufferedQueue(up, cfg)
+	require.NoError(t, err)
+	defer q.Close()
+	require.Eventually(t, func() bool {
+		return q.Stats()["spill_segments"] == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{
+		infra.TransferEventTopicQueue + "/a/ka",
+		infra.ReorgEventTopicQueue + "/b/kb",
+	}, up.published())
+}
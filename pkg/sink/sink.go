@@ -0,0 +1,25 @@
+// Package sink persists the transfers the indexer emits, for deployments
+// that want them stored by the indexer itself rather than by a consumer of
+// the transfer stream, and buffers both the sink and the stream's message
+// queues on disk while they are slow or down.
+package sink
+
+import (
+	"context"
+
+	"github.com/fystack/multichain-indexer/pkg/common/types"
+)
+
+// Sink persists the transfers a worker emitted for a block. Writes must be
+// idempotent: a block whose write failed is processed, and written, again.
+type Sink interface {
+	WriteTransfers(ctx context.Context, chain string, transfers []types.Transaction) error
+}
+
+// Rollbacker is implemented by sinks that can void what they stored for
+// the blocks a reorg rolled back.
+type Rollbacker interface {
+	// RollBack marks the transfers stored for networkID's blocks at height
+	// from and above as orphaned. Like writes, it must be idempotent.
+	RollBack(ctx context.Context, networkID string, from uint64) error
+}
@@ -0,0 +1,302 @@
+package sink
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/logger"
+)
+
+const (
+	walSuffix = ".wal"
+	// walHeaderSize is a record's header: the payload length and its CRC-32C,
+	// both little-endian uint32.
+	walHeaderSize = 8
+	// maxWALRecord bounds a record's length so a corrupt header is not read
+	// as a huge allocation.
+	maxWALRecord = 256 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walSegment is one segment file of the log.
+type walSegment struct {
+	id   uint64
+	size int64 // bytes of complete records
+}
+
+// wal is an append-only log of records split into segment files named by
+// an increasing ID. Records are read back in order from the oldest
+// segment; a segment is deleted once all its records are consumed. The
+// read position is not persisted, so after a restart the log is replayed
+// from its oldest segment. wal is not safe for concurrent use.
+type wal struct {
+	dir          string
+	segmentBytes int64
+
+	segs    []walSegment // oldest first; records are appended to the last
+	w       *os.File     // the last segment, open for appending
+	r       *os.File     // the first segment, open for reading
+	readOff int64        // offset of the next record in segs[0]
+}
+
+// openWAL opens the log in dir, creating dir if needed. A segment ending in
+// a torn or corrupt record, as left by a crash mid-write, is truncated to
+// its last complete record.
+func openWAL(dir string, segmentBytes int64) (*wal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &wal{dir: dir, segmentBytes: segmentBytes}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, walSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, walSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		l.segs = append(l.segs, walSegment{id: id})
+	}
+	slices.SortFunc(l.segs, func(a, b walSegment) int { return cmp.Compare(a.id, b.id) })
+
+	valid := l.segs[:0]
+	for _, seg := range l.segs {
+		size, err := l.recover(seg.id)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			if err := os.Remove(l.path(seg.id)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		seg.size = size
+		valid = append(valid, seg)
+	}
+	l.segs = valid
+
+	if len(l.segs) > 0 {
+		last := l.segs[len(l.segs)-1]
+		if l.w, err = os.OpenFile(l.path(last.id), os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *wal) path(id uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", id, walSuffix))
+}
+
+// recover returns the size of the complete records of segment id,
+// truncating anything after them.
+func (l *wal) recover(id uint64) (int64, error) {
+	f, err := os.OpenFile(l.path(id), os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var off int64
+	for {
+		_, n, err := readRecord(f, off)
+		if err != nil {
+			break
+		}
+		off += n
+	}
+	if off < info.Size() {
+		logger.Warn("Truncating torn write-ahead log segment",
+			"segment", l.path(id), "size", info.Size(), "valid", off)
+		if err := f.Truncate(off); err != nil {
+			return 0, err
+		}
+		if err := f.Sync(); err != nil {
+			return 0, err
+		}
+	}
+	return off, nil
+}
+
+// readRecord reads the record at off, returning its payload and its size
+// on disk. A short or corrupt record is an error.
+func readRecord(f *os.File, off int64) ([]byte, int64, error) {
+	var header [walHeaderSize]byte
+	if _, err := f.ReadAt(header[:], off); err != nil {
+		return nil, 0, err
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+	if length > maxWALRecord {
+		return nil, 0, fmt.Errorf("wal record at %d: length %d too large", off, length)
+	}
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, off+walHeaderSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return nil, 0, fmt.Errorf("wal record at %d: checksum mismatch", off)
+	}
+	return payload, walHeaderSize + int64(length), nil
+}
+
+// append writes a record to the last segment, starting a new segment when
+// it is full. Records are not durable until sync.
+func (l *wal) append(payload []byte) error {
+	if l.w == nil || l.segs[len(l.segs)-1].size >= l.segmentBytes {
+		if err := l.roll(); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[walHeaderSize:], payload)
+
+	last := &l.segs[len(l.segs)-1]
+	if _, err := l.w.Write(buf); err != nil {
+		// Drop a partial write so the next record starts on a boundary.
+		_ = l.w.Truncate(last.size)
+		return err
+	}
+	last.size += int64(len(buf))
+	return nil
+}
+
+// roll starts a new segment, syncing the directory so the file survives a
+// crash.
+func (l *wal) roll() error {
+	if l.w != nil {
+		if err := l.w.Sync(); err != nil {
+			return err
+		}
+		if err := l.w.Close(); err != nil {
+			return err
+		}
+		l.w = nil
+	}
+	var id uint64 = 1
+	if len(l.segs) > 0 {
+		id = l.segs[len(l.segs)-1].id + 1
+	}
+	f, err := os.OpenFile(l.path(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	l.w = f
+	l.segs = append(l.segs, walSegment{id: id})
+	return syncDir(l.dir)
+}
+
+// sync flushes appended records to disk.
+func (l *wal) sync() error {
+	if l.w == nil {
+		return nil
+	}
+	return l.w.Sync()
+}
+
+// pending reports whether the log holds records not yet consumed.
+func (l *wal) pending() bool { return len(l.segs) > 0 }
+
+// segments returns the number of segment files.
+func (l *wal) segments() int { return len(l.segs) }
+
+// pendingBytes returns the size of the records not yet consumed.
+func (l *wal) pendingBytes() int64 {
+	var n int64
+	for _, seg := range l.segs {
+		n += seg.size
+	}
+	return n - l.readOff
+}
+
+// peek returns the next record and its size on disk, without consuming it.
+func (l *wal) peek() ([]byte, int64, error) {
+	if !l.pending() {
+		return nil, 0, io.EOF
+	}
+	if l.r == nil {
+		f, err := os.Open(l.path(l.segs[0].id))
+		if err != nil {
+			return nil, 0, err
+		}
+		l.r = f
+	}
+	return readRecord(l.r, l.readOff)
+}
+
+// consume moves past the record of size n returned by peek, deleting the
+// first segment once it is fully consumed.
+func (l *wal) consume(n int64) error {
+	l.readOff += n
+	if l.readOff < l.segs[0].size {
+		return nil
+	}
+	if l.r != nil {
+		l.r.Close()
+		l.r = nil
+	}
+	if len(l.segs) == 1 {
+		// The log is drained; the next append starts a new segment.
+		if err := l.w.Close(); err != nil {
+			return err
+		}
+		l.w = nil
+	}
+	if err := os.Remove(l.path(l.segs[0].id)); err != nil {
+		return err
+	}
+	l.segs = l.segs[1:]
+	l.readOff = 0
+	return nil
+}
+
+func (l *wal) close() error {
+	if l.r != nil {
+		l.r.Close()
+		l.r = nil
+	}
+	if l.w == nil {
+		return nil
+	}
+	err := l.w.Sync()
+	if cerr := l.w.Close(); err == nil {
+		err = cerr
+	}
+	l.w = nil
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
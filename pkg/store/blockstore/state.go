@@ -0,0 +1,112 @@
+package blockstore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/fystack/multichain-indexer/pkg/kvstore"
+)
+
+// ChainState is everything kept for a chain: its checkpoints, the window
+// of block hashes reorgs are detected against and its reorg halt. It is
+// what snapshots carry between deployments.
+type ChainState struct {
+	LatestBlock   uint64           `json:"latest_block,omitempty"`
+	FailedBlocks  []uint64         `json:"failed_blocks,omitempty"`
+	CatchupRanges []CatchupRange   `json:"catchup_ranges,omitempty"`
+	BlockHashes   []BlockHashEntry `json:"block_hashes,omitempty"`
+	ReorgHalt     *ReorgHalt       `json:"reorg_halt,omitempty"`
+}
+
+// LoadChainState reads chainName's state from s.
+func LoadChainState(s Store, chainName string) (ChainState, error) {
+	var state ChainState
+	latest, err := s.GetLatestBlock(chainName)
+	if err != nil && !errors.Is(err, kvstore.ErrKeyNotFound) {
+		return state, fmt.Errorf("get latest block: %w", err)
+	}
+	state.LatestBlock = latest
+	if state.FailedBlocks, err = s.GetFailedBlocks(chainName); err != nil {
+		return state, fmt.Errorf("get failed blocks: %w", err)
+	}
+	if state.CatchupRanges, err = s.GetCatchupProgress(chainName); err != nil {
+		return state, fmt.Errorf("get catchup progress: %w", err)
+	}
+	if state.BlockHashes, err = s.GetBlockHashes(chainName); err != nil {
+		return state, fmt.Errorf("get block hashes: %w", err)
+	}
+	if state.ReorgHalt, err = s.GetReorgHalt(chainName); err != nil {
+		return state, fmt.Errorf("get reorg halt: %w", err)
+	}
+	return state, nil
+}
+
+// ChainStatePairs encodes state as chainName's KV pairs, as Store writes
+// them, so the state of several chains can be written in one BatchSet.
+// Keys of the current state that state lacks are left out, see StaleKeys.
+func ChainStatePairs(chainName string, state ChainState) ([]infra.KVPair, error) {
+	if chainName == "" {
+		return nil, errors.New("chain name is required")
+	}
+	var pairs []infra.KVPair
+	add := func(key string, v any) error {
+		data, err := infra.JSON.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", key, err)
+		}
+		pairs = append(pairs, infra.KVPair{Key: key, Value: data})
+		return nil
+	}
+
+	if state.LatestBlock > 0 {
+		pairs = append(pairs, infra.KVPair{Key: latestBlockKey(chainName), Value: fmt.Appendf(nil, "%d", state.LatestBlock)})
+	}
+	if state.FailedBlocks != nil {
+		if err := add(failedBlocksKey(chainName), state.FailedBlocks); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range state.CatchupRanges {
+		if r.Start == 0 || r.End < r.Start {
+			continue
+		}
+		pairs = append(pairs, infra.KVPair{Key: catchupKey(chainName, r.Start, r.End), Value: fmt.Appendf(nil, "%d", r.Current)})
+	}
+	if state.BlockHashes != nil {
+		if err := add(blockHashesKey(chainName), state.BlockHashes); err != nil {
+			return nil, err
+		}
+	}
+	if state.ReorgHalt != nil {
+		if err := add(reorgHaltKey(chainName), state.ReorgHalt); err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
+
+// StaleKeys returns the keys of chainName's current state that writing
+// restored with ChainStatePairs leaves behind, e.g. catchup ranges
+// restored lacks. They must be deleted for restored to replace current.
+func StaleKeys(chainName string, current, restored ChainState) ([]string, error) {
+	currentPairs, err := ChainStatePairs(chainName, current)
+	if err != nil {
+		return nil, err
+	}
+	restoredPairs, err := ChainStatePairs(chainName, restored)
+	if err != nil {
+		return nil, err
+	}
+	written := make(map[string]bool, len(restoredPairs))
+	for _, p := range restoredPairs {
+		written[p.Key] = true
+	}
+	var keys []string
+	for _, p := range currentPairs {
+		if !written[p.Key] {
+			keys = append(keys, p.Key)
+		}
+	}
+	return keys, nil
+}
@@ -7,6 +7,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fystack/multichain-indexer/pkg/common/constant"
 	"github.com/fystack/multichain-indexer/pkg/common/logger"
@@ -46,10 +47,26 @@ func blockHashesKey(chainName string) string {
 	return fmt.Sprintf("%s/%s/%s", BlockStates, chainName, constant.KVPrefixBlockHash)
 }
 
+func reorgHaltKey(chainName string) string {
+	return fmt.Sprintf("%s/%s/%s", BlockStates, chainName, constant.KVPrefixReorgHalt)
+}
+
 func catchupKey(chain string, start, end uint64) string {
 	return fmt.Sprintf("%s/%s/%s/%d-%d", BlockStates, chain, constant.KVPrefixProgressCatchup, start, end)
 }
 
+// ReorgHalt records a chain whose regular indexing stopped at a reorg
+// deeper than its rollback window, until an operator resolves it.
+type ReorgHalt struct {
+	// Block is the highest block kept: the hash recorded for it is not
+	// the parent of the block the node now has above it.
+	Block          uint64    `json:"block"`
+	ExpectedParent string    `json:"expected_parent"`
+	ActualParent   string    `json:"actual_parent"`
+	RollbackWindow int       `json:"rollback_window"`
+	HaltedAt       time.Time `json:"halted_at"`
+}
+
 type blockStore struct {
 	store infra.KVStore
 }
@@ -72,6 +89,11 @@ type Store interface {
 	SaveBlockHashes(chainName string, hashes []BlockHashEntry) error
 	GetBlockHashes(chainName string) ([]BlockHashEntry, error)
 
+	// Reorg halt persistence, so a halted chain stays halted across restarts
+	SaveReorgHalt(chainName string, halt ReorgHalt) error
+	GetReorgHalt(chainName string) (*ReorgHalt, error)
+	DeleteReorgHalt(chainName string) error
+
 	Close() error
 }
 
@@ -318,6 +340,35 @@ func (bs *blockStore) GetBlockHashes(chainName string) ([]BlockHashEntry, error)
 	return hashes, nil
 }
 
+// SaveReorgHalt records that chainName's indexing is halted.
+func (bs *blockStore) SaveReorgHalt(chainName string, halt ReorgHalt) error {
+	if chainName == "" {
+		return errors.New("chain name is required")
+	}
+	return bs.store.SetAny(reorgHaltKey(chainName), halt)
+}
+
+// GetReorgHalt returns chainName's reorg halt, nil if it is not halted.
+func (bs *blockStore) GetReorgHalt(chainName string) (*ReorgHalt, error) {
+	if chainName == "" {
+		return nil, errors.New("chain name is required")
+	}
+	var halt ReorgHalt
+	ok, err := bs.store.GetAny(reorgHaltKey(chainName), &halt)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &halt, nil
+}
+
+// DeleteReorgHalt clears chainName's reorg halt.
+func (bs *blockStore) DeleteReorgHalt(chainName string) error {
+	if chainName == "" {
+		return errors.New("chain name is required")
+	}
+	return bs.store.Delete(reorgHaltKey(chainName))
+}
+
 func (bs *blockStore) Close() error {
 	return bs.store.Close()
 }
@@ -0,0 +1,37 @@
+package budgetstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/infra"
+)
+
+const keyPrefix = "request_budget"
+
+// Store keeps the request counts of limiter groups in Redis, one counter
+// per group and period, so replicas share them and they survive restarts.
+// It implements ratelimiter.BudgetStore.
+type Store struct {
+	redisClient infra.RedisClient
+}
+
+// New returns a Store, or nil when Redis is not configured.
+func New(redisClient infra.RedisClient) *Store {
+	if redisClient == nil || redisClient.GetClient() == nil {
+		return nil
+	}
+	return &Store{redisClient: redisClient}
+}
+
+func (s *Store) Add(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	key = fmt.Sprintf("%s:%s", keyPrefix, key)
+	pipe := s.redisClient.GetClient().TxPipeline()
+	count := pipe.IncrBy(ctx, key, n)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("add to request budget: %w", err)
+	}
+	return count.Val(), nil
+}
@@ -0,0 +1,134 @@
+package channelstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "ln_funding"
+
+// Store remembers probable Lightning channel funding outpoints in Redis,
+// each under its own TTL so memory stays bounded by the retention.
+// It implements indexer.ChannelStore.
+type Store struct {
+	redisClient infra.RedisClient
+}
+
+// New returns a Store, or nil when Redis is not configured.
+func New(redisClient infra.RedisClient) *Store {
+	if redisClient == nil || redisClient.GetClient() == nil {
+		return nil
+	}
+	return &Store{redisClient: redisClient}
+}
+
+func composeKey(chain, outpoint string) string {
+	return fmt.Sprintf("%s:%s:%s", keyPrefix, chain, outpoint)
+}
+
+// SaveFundings remembers outpoints ("txid:vout") of chain for ttl.
+func (s *Store) SaveFundings(ctx context.Context, chain string, outpoints []string, ttl time.Duration) error {
+	if len(outpoints) == 0 {
+		return nil
+	}
+	_, err := s.redisClient.GetClient().Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, outpoint := range outpoints {
+			p.Set(ctx, composeKey(chain, outpoint), 1, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save channel fundings: %w", err)
+	}
+	return nil
+}
+
+// TakeFundings returns which of outpoints were remembered and forgets them,
+// as a funding output is spent only once.
+func (s *Store) TakeFundings(ctx context.Context, chain string, outpoints []string) ([]string, error) {
+	if len(outpoints) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.StringCmd, len(outpoints))
+	_, err := s.redisClient.GetClient().Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, outpoint := range outpoints {
+			cmds[i] = p.GetDel(ctx, composeKey(chain, outpoint))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("take channel fundings: %w", err)
+	}
+	var found []string
+	for i, cmd := range cmds {
+		if cmd.Err() == nil {
+			found = append(found, outpoints[i])
+		}
+	}
+	return found, nil
+}
+
+// Funding is a remembered funding outpoint with the time it has left.
+type Funding struct {
+	Outpoint string        `json:"outpoint"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// ListFundings returns the outpoints remembered for chain, e.g. to
+// snapshot them.
+func (s *Store) ListFundings(ctx context.Context, chain string) ([]Funding, error) {
+	client := s.redisClient.GetClient()
+	prefix := composeKey(chain, "")
+	var keys []string
+	iter := client.Scan(ctx, 0, prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("scan channel fundings: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.DurationCmd, len(keys))
+	_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.PTTL(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get channel funding ttls: %w", err)
+	}
+	fundings := make([]Funding, 0, len(keys))
+	for i, key := range keys {
+		// A key gone since the scan reports a negative TTL.
+		if ttl := cmds[i].Val(); ttl > 0 {
+			fundings = append(fundings, Funding{Outpoint: key[len(prefix):], TTL: ttl})
+		}
+	}
+	return fundings, nil
+}
+
+// RestoreFundings remembers fundings of chain, each for the time it has
+// left, in one transaction.
+func (s *Store) RestoreFundings(ctx context.Context, chain string, fundings []Funding) error {
+	if len(fundings) == 0 {
+		return nil
+	}
+	_, err := s.redisClient.GetClient().TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, f := range fundings {
+			p.Set(ctx, composeKey(chain, f.Outpoint), 1, f.TTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("restore channel fundings: %w", err)
+	}
+	return nil
+}
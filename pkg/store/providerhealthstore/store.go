@@ -0,0 +1,63 @@
+package providerhealthstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fystack/multichain-indexer/internal/rpc"
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "provider_health"
+
+// Store keeps rpc.ProviderHealth in Redis. It implements rpc.HealthStore.
+type Store struct {
+	redisClient infra.RedisClient
+}
+
+// New returns a Store, or nil when Redis is not configured.
+func New(redisClient infra.RedisClient) *Store {
+	if redisClient == nil || redisClient.GetClient() == nil {
+		return nil
+	}
+	return &Store{redisClient: redisClient}
+}
+
+// composeKey keys entries by a hash of the node URL, which often carries
+// an API key.
+func composeKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("%s:%s", keyPrefix, hex.EncodeToString(sum[:16]))
+}
+
+func (s *Store) LoadHealth(ctx context.Context, url string) (rpc.ProviderHealth, bool, error) {
+	var h rpc.ProviderHealth
+	data, err := s.redisClient.GetClient().Get(ctx, composeKey(url)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return h, false, nil
+	}
+	if err != nil {
+		return h, false, fmt.Errorf("get provider health: %w", err)
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, false, fmt.Errorf("decode provider health: %w", err)
+	}
+	return h, true, nil
+}
+
+func (s *Store) SaveHealth(ctx context.Context, url string, h rpc.ProviderHealth, ttl time.Duration) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("encode provider health: %w", err)
+	}
+	if err := s.redisClient.GetClient().Set(ctx, composeKey(url), data, ttl).Err(); err != nil {
+		return fmt.Errorf("set provider health: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package pubkeystore
 
 import (
 	"fmt"
+	"strings"
+	"sync/atomic"
 
+	"github.com/fystack/multichain-indexer/internal/rpc/bitcoin"
 	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/addressutil"
 	"github.com/fystack/multichain-indexer/pkg/common/enum"
 )
 
@@ -17,26 +21,102 @@ type Store interface {
 	Close() error
 }
 
+// RetiredChecker is implemented by stores that know which addresses were
+// retired. Exist reports those as not monitored.
+type RetiredChecker interface {
+	Retired(addressType enum.NetworkType, publicKey string) bool
+}
+
+// ScriptWatcher is implemented by stores that also watch scriptPubKeys, by
+// their synthetic script ID (see bitcoin.ScriptID). Exist reports watched
+// script IDs as monitored.
+type ScriptWatcher interface {
+	WatchedScript(addressType enum.NetworkType, scriptID string) (label string, ok bool)
+}
+
+// RedeemScriptResolver is implemented by stores that know the redeem
+// scripts registered for script-hash addresses.
+type RedeemScriptResolver interface {
+	RedeemScript(addressType enum.NetworkType, address string) (script string, ok bool)
+}
+
 type publicKeyStore struct {
 	bloomFilter addressbloomfilter.WalletAddressBloomFilter
+	retired     *addressbloomfilter.RetiredSet
+	scripts     *addressbloomfilter.ScriptSet
 }
 
+// NewPublicKeyStore returns a store checking addresses against bloomFilter,
+// less those in retired, and script IDs against the filter's script
+// namespace and scripts. retired and scripts may be nil.
 func NewPublicKeyStore(
 	bloomFilter addressbloomfilter.WalletAddressBloomFilter,
+	retired *addressbloomfilter.RetiredSet,
+	scripts *addressbloomfilter.ScriptSet,
 ) Store {
-	return &publicKeyStore{bloomFilter: bloomFilter}
+	return &publicKeyStore{bloomFilter: bloomFilter, retired: retired, scripts: scripts}
 }
 
+// failOpenDecisions counts the addresses reported monitored because the
+// bloom filter was not ready, across stores.
+var failOpenDecisions atomic.Uint64
+
+// FailOpenDecisions returns how many addresses Exist reported monitored only
+// because the bloom filter was still loading.
+func FailOpenDecisions() uint64 {
+	return failOpenDecisions.Load()
+}
+
+// Exist reports whether publicKey, in any representation accepted for
+// addressType, is monitored. Until the bloom filter is ready every address
+// is, unless the filter is configured to fail closed. Retired addresses
+// never are.
 func (s *publicKeyStore) Exist(addressType enum.NetworkType, publicKey string) bool {
 	if s.bloomFilter == nil {
 		return false
 	}
-	return s.bloomFilter.Contains(publicKey, addressType)
+	if strings.HasPrefix(publicKey, bitcoin.ScriptIDPrefix) {
+		_, ok := s.WatchedScript(addressType, publicKey)
+		return ok
+	}
+	canonical := addressutil.Canonical(addressType, publicKey)
+	if s.retired.Contains(canonical, addressType) {
+		return false
+	}
+	ready := s.bloomFilter.IsReady()
+	exists := s.bloomFilter.Contains(canonical, addressType)
+	if exists && !ready {
+		failOpenDecisions.Add(1)
+	}
+	return exists
+}
+
+// Retired reports whether publicKey, in any representation accepted for
+// addressType, was retired.
+func (s *publicKeyStore) Retired(addressType enum.NetworkType, publicKey string) bool {
+	return s.retired.Contains(addressutil.Canonical(addressType, publicKey), addressType)
+}
+
+// WatchedScript reports whether scriptID is watched on addressType, with
+// the label it was registered with. Without watched scripts the filter is
+// not consulted, sparing a Redis filter a lookup per output.
+func (s *publicKeyStore) WatchedScript(addressType enum.NetworkType, scriptID string) (string, bool) {
+	if s.bloomFilter == nil || s.scripts.Count(addressType) == 0 ||
+		!s.bloomFilter.Contains(scriptID, addressbloomfilter.ScriptNamespace(addressType)) {
+		return "", false
+	}
+	return s.scripts.Label(scriptID, addressType)
+}
+
+// RedeemScript returns the hex redeem script registered for address, in
+// any representation accepted for addressType.
+func (s *publicKeyStore) RedeemScript(addressType enum.NetworkType, address string) (string, bool) {
+	return s.scripts.RedeemScript(addressutil.Canonical(addressType, address), addressType)
 }
 
 func (s *publicKeyStore) Save(addressType enum.NetworkType, publicKey string) error {
 	if s.bloomFilter != nil {
-		s.bloomFilter.Add(publicKey, addressType)
+		s.bloomFilter.Add(addressutil.Canonical(addressType, publicKey), addressType)
 	}
 	return nil
 }
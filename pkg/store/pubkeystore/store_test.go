@@ -0,0 +1,99 @@
+package pubkeystore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/addressbloomfilter"
+	"github.com/fystack/multichain-indexer/pkg/common/enum"
+	"github.com/fystack/multichain-indexer/pkg/model"
+	"github.com/fystack/multichain-indexer/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walletRepo serves a single EVM address.
+type walletRepo struct{ address string }
+
+func (r walletRepo) Find(_ context.Context, opts repository.FindOptions) ([]*model.WalletAddress, error) {
+	if opts.Where["type"] != enum.NetworkTypeEVM || opts.Offset > 0 {
+		return nil, nil
+	}
+	return []*model.WalletAddress{{Address: r.address}}, nil
+}
+
+func (walletRepo) CreateMany(context.Context, []*model.WalletAddress) (int64, error) {
+	return 0, nil
+}
+
+func TestExistWhileBloomFilterLoads(t *testing.T) {
+	const watched = "0x1111111111111111111111111111111111111111"
+	const other = "0x2222222222222222222222222222222222222222"
+
+	for _, policy := range []addressbloomfilter.NotReadyPolicy{addressbloomfilter.FailOpen, addressbloomfilter.FailClosed} {
+		t.Run(string(policy), func(t *testing.T) {
+			bf := addressbloomfilter.NewAddressBloomFilter(addressbloomfilter.Config{
+				WalletAddressRepo: walletRepo{address: watched},
+				ExpectedItems:     100,
+				FalsePositiveRate: 0.0001,
+				BatchSize:         10,
+				NotReady:          policy,
+			})
+			store := NewPublicKeyStore(bf, nil, nil)
+
+			before := FailOpenDecisions()
+			failOpen := policy == addressbloomfilter.FailOpen
+			assert.Equal(t, failOpen, store.Exist(enum.NetworkTypeEVM, other))
+			if failOpen {
+				assert.Equal(t, before+1, FailOpenDecisions())
+			} else {
+				assert.Equal(t, before, FailOpenDecisions())
+			}
+
+			require.NoError(t, bf.Initialize(context.Background()))
+			before = FailOpenDecisions()
+			assert.True(t, store.Exist(enum.NetworkTypeEVM, watched))
+			assert.False(t, store.Exist(enum.NetworkTypeEVM, other))
+			assert.Equal(t, before, FailOpenDecisions(), "no fail-open decisions once ready")
+		})
+	}
+}
+
+func TestWatchedScript(t *testing.T) {
+	const script = "script:0a0b"
+	bf := addressbloomfilter.NewAddressBloomFilter(addressbloomfilter.Config{
+		ExpectedItems:     100,
+		FalsePositiveRate: 0.0001,
+	})
+	scripts := addressbloomfilter.NewScriptSet()
+	store := NewPublicKeyStore(bf, nil, scripts)
+	assert.False(t, store.Exist(enum.NetworkTypeBtc, script))
+
+	bf.Add(script, addressbloomfilter.ScriptNamespace(enum.NetworkTypeBtc))
+	scripts.Watch(map[string]string{script: "escrow"}, enum.NetworkTypeBtc)
+	assert.True(t, store.Exist(enum.NetworkTypeBtc, script))
+	assert.False(t, store.Exist(enum.NetworkTypeEVM, script))
+	assert.False(t, bf.Contains(script, enum.NetworkTypeBtc), "kept out of the address namespace")
+
+	label, ok := store.(ScriptWatcher).WatchedScript(enum.NetworkTypeBtc, script)
+	assert.True(t, ok)
+	assert.Equal(t, "escrow", label)
+	_, ok = store.(ScriptWatcher).WatchedScript(enum.NetworkTypeBtc, "script:ffff")
+	assert.False(t, ok)
+}
+
+func TestRedeemScript(t *testing.T) {
+	const addr = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	scripts := addressbloomfilter.NewScriptSet()
+	store := NewPublicKeyStore(nil, nil, scripts).(RedeemScriptResolver)
+	_, ok := store.RedeemScript(enum.NetworkTypeBtc, addr)
+	assert.False(t, ok)
+
+	scripts.AddRedeemScripts(map[string]string{addr: "5121aa51ae"}, enum.NetworkTypeBtc)
+	script, ok := store.RedeemScript(enum.NetworkTypeBtc, strings.ToUpper(addr))
+	assert.True(t, ok, "any accepted representation")
+	assert.Equal(t, "5121aa51ae", script)
+	_, ok = store.RedeemScript(enum.NetworkTypeEVM, addr)
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package responsecachestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fystack/multichain-indexer/pkg/infra"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "rpc_cache"
+
+// maxIndexed bounds the height index of each chain. Entries past it, the
+// lowest, expire with their TTL but can no longer be invalidated, which
+// only a rollback reaching that deep would need.
+const maxIndexed = 200_000
+
+// Store keeps immutable node responses in Redis, each under a TTL, and
+// indexes them by height in a sorted set so a rollback can drop them. It
+// implements rpc.ResponseStore.
+type Store struct {
+	redisClient infra.RedisClient
+	chain       string
+	ttl         time.Duration
+}
+
+// New returns a Store for chain's responses, or nil when Redis is not
+// configured.
+func New(redisClient infra.RedisClient, chain string, ttl time.Duration) *Store {
+	if redisClient == nil || redisClient.GetClient() == nil {
+		return nil
+	}
+	return &Store{redisClient: redisClient, chain: chain, ttl: ttl}
+}
+
+type entry struct {
+	Height uint64          `json:"height"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (s *Store) composeKey(key string) string {
+	return fmt.Sprintf("%s:%s:%s", keyPrefix, s.chain, key)
+}
+
+func (s *Store) indexKey() string {
+	return fmt.Sprintf("%s:%s:heights", keyPrefix, s.chain)
+}
+
+func (s *Store) Get(ctx context.Context, key string) (uint64, json.RawMessage, bool, error) {
+	raw, err := s.redisClient.GetClient().Get(ctx, s.composeKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil, false, nil
+	}
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("get cached response: %w", err)
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return 0, nil, false, fmt.Errorf("decode cached response: %w", err)
+	}
+	return e.Height, e.Result, true, nil
+}
+
+func (s *Store) Put(ctx context.Context, key string, height uint64, result json.RawMessage) error {
+	raw, err := json.Marshal(entry{Height: height, Result: result})
+	if err != nil {
+		return err
+	}
+	full := s.composeKey(key)
+	_, err = s.redisClient.GetClient().Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, full, raw, s.ttl)
+		p.ZAdd(ctx, s.indexKey(), redis.Z{Score: float64(height), Member: full})
+		p.ZRemRangeByRank(ctx, s.indexKey(), 0, -maxIndexed-1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cache response: %w", err)
+	}
+	return nil
+}
+
+// DeleteFrom drops the responses of blocks at height and above.
+func (s *Store) DeleteFrom(ctx context.Context, height uint64) error {
+	client := s.redisClient.GetClient()
+	from := strconv.FormatUint(height, 10)
+	keys, err := client.ZRangeByScore(ctx, s.indexKey(), &redis.ZRangeBy{Min: from, Max: "+inf"}).Result()
+	if err != nil {
+		return fmt.Errorf("list cached responses from height %d: %w", height, err)
+	}
+	_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		if len(keys) > 0 {
+			p.Del(ctx, keys...)
+		}
+		p.ZRemRangeByScore(ctx, s.indexKey(), from, "+inf")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("drop cached responses from height %d: %w", height, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package tracing records OpenTelemetry spans of block processing. Until
+// Init installs an exporter the global tracer provider is a no-op, so Start
+// costs next to nothing when tracing is disabled.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/fystack/multichain-indexer"
+	defaultServiceName  = "multichain-indexer"
+)
+
+// Start starts a span named name as a child of the span in ctx, or as the
+// root of a new trace, and returns a context carrying it.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Init installs the global tracer provider exporting to cfg.Endpoint, and
+// returns the function flushing and stopping it. With cfg.Enabled unset it
+// leaves the no-op provider in place.
+func Init(ctx context.Context, cfg config.TracingConfig, version string) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+	if strings.Contains(cfg.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+
+	provider := newProvider(cfg, version, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newProvider returns a tracer provider sampling cfg.Ratio() of the
+// traces and recording spans with opts.
+func newProvider(cfg config.TracingConfig, version string, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	name := cfg.ServiceName
+	if name == "" {
+		name = defaultServiceName
+	}
+	res, _ := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", name),
+		attribute.String("service.version", version),
+	))
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		// A span follows its parent's decision, so a block is traced whole
+		// or not at all.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Ratio()))),
+	)
+	return sdktrace.NewTracerProvider(opts...)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fystack/multichain-indexer/pkg/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestInitDisabledRecordsNothing(t *testing.T) {
+	shutdown, err := Init(context.Background(), config.TracingConfig{}, "test")
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	_, span := Start(context.Background(), "block")
+	assert.False(t, span.IsRecording())
+	assert.False(t, span.SpanContext().IsSampled())
+	End(span, nil)
+}
+
+func TestNewProviderSampling(t *testing.T) {
+	zero, one, tiny := 0.0, 1.0, 1e-12
+	for _, tc := range []struct {
+		name    string
+		ratio   *float64
+		sampled bool
+	}{{"unset", nil, true}, {"1", &one, true}, {"1e-12", &tiny, false}, {"0", &zero, false}} {
+		p := newProvider(config.TracingConfig{SampleRatio: tc.ratio}, "test")
+		ctx, root := p.Tracer("test").Start(context.Background(), "block")
+		_, child := p.Tracer("test").Start(ctx, "fetch")
+		assert.Equal(t, tc.sampled, root.SpanContext().IsSampled(), "ratio %s", tc.name)
+		assert.Equal(t, tc.sampled, child.SpanContext().IsSampled(), "a child follows its block")
+		require.NoError(t, p.Shutdown(context.Background()))
+	}
+}
+
+func TestNewProviderResource(t *testing.T) {
+	p := newProvider(config.TracingConfig{}, "1.2.3")
+	_, span := p.Tracer("test").Start(context.Background(), "block")
+	attrs := span.(sdktrace.ReadOnlySpan).Resource().Attributes()
+	assert.Contains(t, attrs, attribute.String("service.name", defaultServiceName))
+	assert.Contains(t, attrs, attribute.String("service.version", "1.2.3"))
+}
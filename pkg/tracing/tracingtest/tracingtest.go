@@ -0,0 +1,44 @@
+// Package tracingtest records the spans started through package tracing, so
+// tests can check what a code path traces.
+package tracingtest
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Record installs a global tracer provider sampling every span into the
+// returned recorder until t ends. Tests using it must not run in parallel.
+func Record(t testing.TB) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+// Children returns the names of the ended spans whose parent is parent, in
+// the order they ended.
+func Children(rec *tracetest.SpanRecorder, parent sdktrace.ReadOnlySpan) []string {
+	var names []string
+	for _, s := range rec.Ended() {
+		if s.Parent().SpanID() == parent.SpanContext().SpanID() {
+			names = append(names, s.Name())
+		}
+	}
+	return names
+}
+
+// Find returns the first ended span named name, or nil.
+func Find(rec *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, s := range rec.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+-- Redeem scripts registered for multisig deposit addresses
+-- (POST /addresses/redeem-scripts). Deposits to the address are checked to
+-- pay the script, and spends from it report which cosigner keys signed.
+CREATE TABLE IF NOT EXISTS redeem_scripts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    address VARCHAR(255) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    script TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_redeem_address ON redeem_scripts (address, type);
+CREATE INDEX IF NOT EXISTS idx_redeem_scripts_deleted_at ON redeem_scripts (deleted_at);
+
+COMMENT ON TABLE redeem_scripts IS 'redeem or witness scripts of multisig addresses, by address and network type';
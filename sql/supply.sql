@@ -0,0 +1,15 @@
+-- Supply tracked per Bitcoin block (chains.<name>.supply), one row per height.
+-- Cumulative fees are summed over the rows; a reorg deletes the rolled-back
+-- heights before their replacements are recorded.
+CREATE TABLE IF NOT EXISTS supply_blocks (
+    network_id VARCHAR(64) NOT NULL,
+    height BIGINT NOT NULL,
+    block_hash VARCHAR(128) NOT NULL,
+    subsidy BIGINT NOT NULL,
+    fees BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (network_id, height)
+);
+
+COMMENT ON TABLE supply_blocks IS 'Block subsidy and fees in satoshis, per network and height';
@@ -0,0 +1,35 @@
+-- Transfers written by the DB sink (services.transfer_sink), one row per
+-- deterministic transfer_id. Re-indexed transfers are upserted in place;
+-- those of blocks rolled back by a reorg are marked status 'orphaned' until
+-- re-indexed in the replacing blocks.
+CREATE TABLE IF NOT EXISTS transfers (
+    transfer_id VARCHAR(64) PRIMARY KEY,
+    network_id VARCHAR(64) NOT NULL,
+    internal_code VARCHAR(64),
+    tx_hash VARCHAR(128) NOT NULL,
+    transfer_index VARCHAR(64),
+    block_number BIGINT NOT NULL,
+    block_hash VARCHAR(128),
+    from_address VARCHAR(255),
+    from_addresses JSONB,
+    to_address VARCHAR(255),
+    asset_address VARCHAR(255),
+    amount NUMERIC,
+    tx_fee NUMERIC,
+    type VARCHAR(64),
+    role VARCHAR(32),
+    status VARCHAR(32),
+    confirmations BIGINT,
+    timestamp BIGINT,
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deposits to an address by height, and lookups by transaction
+CREATE INDEX IF NOT EXISTS idx_transfers_network_to_block ON transfers (network_id, to_address, block_number);
+CREATE INDEX IF NOT EXISTS idx_transfers_tx_hash ON transfers (tx_hash);
+-- Rollbacks, which orphan a network's transfers from a height on
+CREATE INDEX IF NOT EXISTS idx_transfers_network_block ON transfers (network_id, block_number);
+
+COMMENT ON TABLE transfers IS 'Transfers emitted by the indexer, keyed by their deterministic transfer ID';
@@ -0,0 +1,18 @@
+-- Raw scriptPubKeys watched like addresses (POST /addresses/scripts), for
+-- outputs with no address form. script_id is "script:" followed by the hex
+-- SHA-256 of the script, the ToAddress of the transfers paying it.
+CREATE TABLE IF NOT EXISTS watched_scripts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    script_id VARCHAR(80) NOT NULL,
+    script TEXT NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    label VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_script_id ON watched_scripts (script_id);
+CREATE INDEX IF NOT EXISTS idx_watched_scripts_deleted_at ON watched_scripts (deleted_at);
+
+COMMENT ON TABLE watched_scripts IS 'scriptPubKeys watched per network type, with an operator label';